@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"flag"
+	"os"
+
+	chainlib "github.com/btcsuite/btcd/blockchain"
+	"github.com/deso-protocol/core/lib"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var exportChainCmd = &cobra.Command{
+	Use:   "export-chain",
+	Short: "Export the main chain to a portable archive file",
+	Long: `Writes every block on the main chain, in height order, to a single
+gzip-compressed and checksummed archive. The node must not be running
+against the same data directory.`,
+	Run: ExportChain,
+}
+
+var importChainCmd = &cobra.Command{
+	Use:   "import-chain",
+	Short: "Import a chain archive created with export-chain",
+	Long: `Verifies the archive's checksum and then processes every block in it
+as if it had been received from a peer. Blocks that are already in the
+chain are skipped so an interrupted import can be re-run.`,
+	Run: ImportChain,
+}
+
+func init() {
+	SetupRunFlags(exportChainCmd)
+	exportChainCmd.PersistentFlags().String("archive-file", "",
+		"The path of the archive file to write.")
+	rootCmd.AddCommand(exportChainCmd)
+
+	SetupRunFlags(importChainCmd)
+	importChainCmd.PersistentFlags().String("archive-file", "",
+		"The path of the archive file to read.")
+	importChainCmd.PersistentFlags().Bool("skip-signature-checks", false,
+		"When set, transaction signatures are not verified during import. Only use this "+
+			"with archives from a trusted source.")
+	rootCmd.AddCommand(importChainCmd)
+}
+
+// openChainForArchive opens the badger db in the configured data directory and
+// sets up a Blockchain over it without any networking.
+func openChainForArchive(config *Config) (*lib.Blockchain, *badger.DB) {
+	flag.Set("alsologtostderr", "true")
+	flag.Parse()
+
+	if config.Regtest {
+		config.Params.EnableRegtest()
+	}
+
+	db, err := openChainDB(config.DataDirectory)
+	if err != nil {
+		glog.Fatal(err)
+	}
+
+	chain, err := lib.NewBlockchain(
+		config.TrustedBlockProducerPublicKeys,
+		config.TrustedBlockProducerStartHeight,
+		config.Params, chainlib.NewMedianTime(), db, nil, 0, nil)
+	if err != nil {
+		glog.Fatal(err)
+	}
+
+	return chain, db
+}
+
+func ExportChain(cmd *cobra.Command, args []string) {
+	BindFlags(cmd)
+	config := LoadConfig()
+	archiveFile := viper.GetString("archive-file")
+	if archiveFile == "" {
+		glog.Fatal("--archive-file is required")
+	}
+
+	chain, db := openChainForArchive(config)
+	defer db.Close()
+
+	file, err := os.Create(archiveFile)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	defer file.Close()
+
+	numBlocks, err := lib.ExportChainArchive(chain, file)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	glog.Infof("Exported %d blocks to %s", numBlocks, archiveFile)
+}
+
+func ImportChain(cmd *cobra.Command, args []string) {
+	BindFlags(cmd)
+	config := LoadConfig()
+	archiveFile := viper.GetString("archive-file")
+	if archiveFile == "" {
+		glog.Fatal("--archive-file is required")
+	}
+	skipSignatureChecks := viper.GetBool("skip-signature-checks")
+
+	chain, db := openChainForArchive(config)
+	defer db.Close()
+
+	// ImportChainArchive verifies the whole archive before processing any block.
+	file, err := os.Open(archiveFile)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	defer file.Close()
+
+	numProcessed, err := lib.ImportChainArchive(chain, file, !skipSignatureChecks)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	glog.Infof("Imported %d blocks; tip is now at height %d",
+		numProcessed, chain.BlockTip().Height)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"flag"
+
+	"github.com/deso-protocol/core/lib"
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var checkDbCmd = &cobra.Command{
+	Use:   "check-db",
+	Short: "Check the node's database for inconsistent indices",
+	Long: `Walks every primary prefix in the database along with its secondary
+indices and reports entries that are missing, stale, or dangling. With
+--repair, the secondary indices are rebuilt from the primary entries. The
+node must not be running against the same data directory.`,
+	Run: CheckDb,
+}
+
+func init() {
+	SetupRunFlags(checkDbCmd)
+	checkDbCmd.PersistentFlags().Bool("repair", false,
+		"When set, rebuild secondary indices from primary entries and delete dangling entries.")
+	checkDbCmd.PersistentFlags().Bool("print-issues", true,
+		"When set, log every issue found rather than just a summary.")
+	rootCmd.AddCommand(checkDbCmd)
+}
+
+func CheckDb(cmd *cobra.Command, args []string) {
+	BindFlags(cmd)
+	config := LoadConfig()
+	repair := viper.GetBool("repair")
+	printIssues := viper.GetBool("print-issues")
+
+	flag.Set("alsologtostderr", "true")
+	flag.Parse()
+
+	db, err := openChainDB(config.DataDirectory)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	defer db.Close()
+
+	issues, err := lib.CheckDbIntegrity(db)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	for _, unchecked := range lib.DbIntegrityUncheckedIndices() {
+		glog.Infof("Not checked: %s", unchecked)
+	}
+	if printIssues {
+		for _, issue := range issues {
+			glog.Info(issue)
+		}
+	}
+	if len(issues) == 0 {
+		glog.Info("No issues found")
+		return
+	}
+	glog.Infof("Found %d issues:\n%s", len(issues), lib.SummarizeDbIntegrityIssues(issues))
+
+	if !repair {
+		glog.Info("Run with --repair to fix them")
+		return
+	}
+	numRepaired, err := lib.RepairDbIntegrityIssues(db, issues)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	glog.Infof("Repaired %d of %d issues", numRepaired, len(issues))
+}
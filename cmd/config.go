@@ -16,6 +16,9 @@ type Config struct {
 	TXIndex              bool
 	Regtest              bool
 	PostgresURI          string
+	PruneBlocks          uint64
+
+	ReindexBlockPositionTxns bool
 
 	// Peers
 	ConnectIPs          []string
@@ -33,8 +36,11 @@ type Config struct {
 	OneInboundPerIp   bool
 
 	// Mining
-	MinerPublicKeys  []string
-	NumMiningThreads uint64
+	MinerPublicKeys            []string
+	NumMiningThreads           uint64
+	MiningServerHost           string
+	MiningServerPort           uint16
+	MiningServerShareTargetHex string
 
 	// Fees
 	RateLimitFeerate uint64
@@ -43,7 +49,7 @@ type Config struct {
 	// BlockProducer
 	MaxBlockTemplatesCache          uint64
 	MinBlockUpdateInterval          uint64
-	BlockCypherAPIKey               string
+	BitcoinHeaderSourceURL          string
 	BlockProducerSeed               string
 	TrustedBlockProducerPublicKeys  []string
 	TrustedBlockProducerStartHeight uint64
@@ -54,6 +60,7 @@ type Config struct {
 	GlogVmodule           string
 	LogDBSummarySnapshots bool
 	DatadogProfiler       bool
+	MetricsPort           uint16
 }
 
 func LoadConfig() *Config {
@@ -84,6 +91,8 @@ func LoadConfig() *Config {
 	config.TXIndex = viper.GetBool("txindex")
 	config.Regtest = viper.GetBool("regtest")
 	config.PostgresURI = viper.GetString("postgres-uri")
+	config.PruneBlocks = viper.GetUint64("prune-blocks")
+	config.ReindexBlockPositionTxns = viper.GetBool("reindex-block-position-txns")
 
 	// Peers
 	config.ConnectIPs = viper.GetStringSlice("connect-ips")
@@ -103,6 +112,9 @@ func LoadConfig() *Config {
 	// Mining + Admin
 	config.MinerPublicKeys = viper.GetStringSlice("miner-public-keys")
 	config.NumMiningThreads = viper.GetUint64("num-mining-threads")
+	config.MiningServerHost = viper.GetString("mining-server-host")
+	config.MiningServerPort = viper.GetUint16("mining-server-port")
+	config.MiningServerShareTargetHex = viper.GetString("mining-server-share-target-hex")
 
 	// Fees
 	config.RateLimitFeerate = viper.GetUint64("rate-limit-feerate")
@@ -111,7 +123,7 @@ func LoadConfig() *Config {
 	// BlockProducer
 	config.MaxBlockTemplatesCache = viper.GetUint64("max-block-templates-cache")
 	config.MinBlockUpdateInterval = viper.GetUint64("min-block-update-interval")
-	config.BlockCypherAPIKey = viper.GetString("block-cypher-api-key")
+	config.BitcoinHeaderSourceURL = viper.GetString("bitcoin-header-source-url")
 	config.BlockProducerSeed = viper.GetString("block-producer-seed")
 	config.TrustedBlockProducerStartHeight = viper.GetUint64("trusted-block-producer-start-height")
 	config.TrustedBlockProducerPublicKeys = viper.GetStringSlice("trusted-block-producer-public-keys")
@@ -125,6 +137,7 @@ func LoadConfig() *Config {
 	config.GlogVmodule = viper.GetString("glog-vmodule")
 	config.LogDBSummarySnapshots = viper.GetBool("log-db-summary-snapshots")
 	config.DatadogProfiler = viper.GetBool("datadog-profiler")
+	config.MetricsPort = uint16(viper.GetUint64("metrics-port"))
 
 	return &config
 }
@@ -142,6 +155,10 @@ func (config *Config) Print() {
 		glog.Infof("Postgres URI: %s", config.PostgresURI)
 	}
 
+	if config.PruneBlocks > 0 {
+		glog.Infof("Pruning blocks older than the last %d", config.PruneBlocks)
+	}
+
 	if len(config.ConnectIPs) > 0 {
 		glog.Infof("Connect IPs: %s", config.ConnectIPs)
 	}
@@ -173,6 +190,14 @@ func (config *Config) Print() {
 		glog.Infof("Mining with public keys: %s", config.MinerPublicKeys)
 	}
 
+	if config.MiningServerPort > 0 {
+		glog.Infof("Mining server listening on %s:%d", config.MiningServerHost, config.MiningServerPort)
+	}
+
 	glog.Infof("Rate Limit Feerate: %d", config.RateLimitFeerate)
 	glog.Infof("Min Feerate: %d", config.MinFeerate)
+
+	if config.MetricsPort > 0 {
+		glog.Infof("Prometheus metrics on port %d", config.MetricsPort)
+	}
 }
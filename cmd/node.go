@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"time"
 
@@ -30,6 +31,8 @@ type Node struct {
 	Params   *lib.DeSoParams
 	Config   *Config
 	Postgres *lib.Postgres
+
+	metricsServer *http.Server
 }
 
 func NewNode(config *Config) *Node {
@@ -60,6 +63,11 @@ func (node *Node) Start() {
 	// Validate params
 	validateParams(node.Params)
 
+	// Pruning deletes the data the txindex and postgres rely on.
+	if node.Config.PruneBlocks > 0 && (node.Config.TXIndex || node.Config.PostgresURI != "") {
+		glog.Fatal("--prune-blocks cannot be used with --txindex or --postgres-uri")
+	}
+
 	// Setup Datadog span tracer and profiler
 	if node.Config.DatadogProfiler {
 		tracer.Start()
@@ -101,11 +109,7 @@ func (node *Node) Start() {
 	}
 
 	// Setup chain database
-	dbDir := lib.GetBadgerDbPath(node.Config.DataDirectory)
-	opts := badger.DefaultOptions(dbDir)
-	opts.ValueDir = dbDir
-	opts.MemTableSize = 1024 << 20
-	node.chainDB, err = badger.Open(opts)
+	node.chainDB, err = openChainDB(node.Config.DataDirectory)
 	if err != nil {
 		panic(err)
 	}
@@ -118,24 +122,11 @@ func (node *Node) Start() {
 	// Setup postgres using a remote URI
 	var db *pg.DB
 	if node.Config.PostgresURI != "" {
-		options, err := pg.ParseURL(node.Config.PostgresURI)
+		db, err = openPostgres(node.Config.PostgresURI)
 		if err != nil {
 			panic(err)
 		}
-
-		db = pg.Connect(options)
 		node.Postgres = lib.NewPostgres(db)
-
-		// LoadMigrations registers all the migration files in the migrate package.
-		// See LoadMigrations for more info.
-		migrate.LoadMigrations()
-
-		// Migrate the database after loading all the migrations. This is equivalent
-		// to running "go run migrate.go migrate". See migrate.go for a migrations CLI tool
-		err = migrations.Run(db, "migrate", []string{"", "migrate"})
-		if err != nil {
-			panic(err)
-		}
 	}
 
 	// Setup eventManager
@@ -159,7 +150,6 @@ func (node *Node) Start() {
 		node.Config.StallTimeoutSeconds,
 		node.Config.MaxBlockTemplatesCache,
 		node.Config.MinBlockUpdateInterval,
-		node.Config.BlockCypherAPIKey,
 		true,
 		node.Config.DataDirectory,
 		node.Config.MempoolDumpDirectory,
@@ -170,14 +160,43 @@ func (node *Node) Start() {
 		node.Config.BlockProducerSeed,
 		node.Config.TrustedBlockProducerPublicKeys,
 		node.Config.TrustedBlockProducerStartHeight,
+		node.Config.PruneBlocks,
+		node.Config.MiningServerHost,
+		node.Config.MiningServerPort,
+		node.Config.MiningServerShareTargetHex,
+		node.Config.BitcoinHeaderSourceURL,
 		eventManager,
 	)
 	if err != nil {
 		panic(err)
 	}
 
+	if node.Config.ReindexBlockPositionTxns {
+		glog.Info("Reindexing block position txns")
+		if err := node.Server.GetBlockchain().ReindexBlockPositionTxns(); err != nil {
+			glog.Fatal(err)
+		}
+	}
+
 	node.Server.Start()
 
+	// Setup the Prometheus endpoint
+	if node.Config.MetricsPort > 0 {
+		node.Server.StartMetricsReporter()
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", lib.NewMetricsHandler())
+		node.metricsServer = &http.Server{
+			Addr:    fmt.Sprintf(":%d", node.Config.MetricsPort),
+			Handler: mux,
+		}
+		go func() {
+			if err := node.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				glog.Errorf("Problem serving metrics: %v", err)
+			}
+		}()
+	}
+
 	// Setup TXIndex - not compatible with postgres
 	if node.Config.TXIndex && node.Postgres == nil {
 		node.TXIndex, err = lib.NewTXIndex(node.Server.GetBlockchain(), node.Params, node.Config.DataDirectory)
@@ -189,9 +208,45 @@ func (node *Node) Start() {
 	}
 }
 
+// openChainDB opens the badger db that holds the chain in the data directory.
+func openChainDB(dataDirectory string) (*badger.DB, error) {
+	dbDir := lib.GetBadgerDbPath(dataDirectory)
+	opts := badger.DefaultOptions(dbDir)
+	opts.ValueDir = dbDir
+	opts.MemTableSize = 1024 << 20
+	return badger.Open(opts)
+}
+
+// openPostgres connects to the database at uri and runs any pending migrations.
+func openPostgres(uri string) (*pg.DB, error) {
+	options, err := pg.ParseURL(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	db := pg.Connect(options)
+
+	// LoadMigrations registers all the migration files in the migrate package.
+	// See LoadMigrations for more info.
+	migrate.LoadMigrations()
+
+	// Migrate the database after loading all the migrations. This is equivalent
+	// to running "go run migrate.go migrate". See migrate.go for a migrations CLI tool
+	err = migrations.Run(db, "migrate", []string{"", "migrate"})
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
 func (node *Node) Stop() {
 	node.Server.Stop()
 
+	if node.metricsServer != nil {
+		node.metricsServer.Close()
+	}
+
 	if node.TXIndex != nil {
 		node.TXIndex.Stop()
 	}
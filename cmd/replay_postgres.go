@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/deso-protocol/core/lib"
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var replayPostgresCmd = &cobra.Command{
+	Use:   "replay-postgres",
+	Short: "Keep a read replica in sync with a node's Postgres database",
+	Long: `Follows the change log written by a node running with --postgres-uri
+and replays it into the database at --replica-postgres-uri. The replica's
+position is stored in the replica itself, so the command can be stopped and
+restarted at any time.`,
+	Run: ReplayPostgres,
+}
+
+func init() {
+	SetupRunFlags(replayPostgresCmd)
+	replayPostgresCmd.PersistentFlags().String("replica-postgres-uri", "",
+		"The Postgres database to replay the change log into.")
+	replayPostgresCmd.PersistentFlags().String("replica-name", "default",
+		"The name the replica's position is stored under. Only needs to be changed "+
+			"when several sources replay into the same database.")
+	rootCmd.AddCommand(replayPostgresCmd)
+}
+
+func ReplayPostgres(cmd *cobra.Command, args []string) {
+	BindFlags(cmd)
+	config := LoadConfig()
+	replicaURI := viper.GetString("replica-postgres-uri")
+	replicaName := viper.GetString("replica-name")
+
+	flag.Set("alsologtostderr", "true")
+	flag.Parse()
+
+	if config.PostgresURI == "" || replicaURI == "" {
+		glog.Fatal("--postgres-uri and --replica-postgres-uri are both required")
+	}
+
+	sourceDB, err := openPostgres(config.PostgresURI)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	defer sourceDB.Close()
+
+	replicaDB, err := openPostgres(replicaURI)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	defer replicaDB.Close()
+
+	replica := lib.NewPostgresReplica(lib.NewPostgres(sourceDB), lib.NewPostgres(replicaDB), replicaName)
+	replica.Start()
+
+	shutdownListener := make(chan os.Signal)
+	signal.Notify(shutdownListener, syscall.SIGINT, syscall.SIGTERM)
+	<-shutdownListener
+
+	replica.Stop()
+	glog.Info("Shutdown complete")
+}
@@ -24,6 +24,7 @@ func init() {
 
 func Run(cmd *cobra.Command, args []string) {
 	// Parse the configuration (can use CLI flags, environment variables, or config file)
+	BindFlags(cmd)
 	config := LoadConfig()
 
 	// Start the deso node
@@ -62,6 +63,14 @@ func SetupRunFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().String("postgres-uri", "", "BETA: Use Postgres as the backing store for chain data."+
 		"When enabled, most data is stored in postgres although badger is still currently used for some state. Run your "+
 		"Postgres instance on the same machine as your node for optimal performance.")
+	cmd.PersistentFlags().Uint64("prune-blocks", 0,
+		"When set to a non-zero value N, the node only keeps the bodies and undo data of the "+
+			"last N blocks and deletes older ones in the background. Pruned nodes don't serve old "+
+			"blocks to peers and reject reorgs deeper than N blocks. Not compatible with --txindex "+
+			"or --postgres-uri.")
+	cmd.PersistentFlags().Bool("reindex-block-position-txns", false,
+		"When set, the node rebuilds the index of main chain txns by (height, txn index) "+
+			"on startup. Only needed once for chains synced before the index existed.")
 
 	// Peers
 	cmd.PersistentFlags().StringSlice("connect-ips", []string{},
@@ -114,6 +123,18 @@ func SetupRunFlags(cmd *cobra.Command) {
 		"How many threads to run for mining. Only has an effect when --miner-public-keys "+
 			"is set. If set to zero, which is the default, then the number of "+
 			"threads available to the system will be used.")
+	cmd.PersistentFlags().Uint64("mining-server-port", 0,
+		"When set, starts a work server on this port that external miners can connect to "+
+			"using newline-delimited JSON over TCP. Workers must authorize with one of the "+
+			"--miner-public-keys, and the in-process miner is not started. Requires "+
+			"--max-block-templates-cache to be non-zero.")
+	cmd.PersistentFlags().String("mining-server-host", "127.0.0.1",
+		"The address the mining server listens on. Defaults to localhost so that only miners "+
+			"on this machine can connect. Set to 0.0.0.0 to accept miners from other machines.")
+	cmd.PersistentFlags().String("mining-server-share-target-hex", "",
+		"The hash target that a share submitted to the mining server must meet. Should be "+
+			"easier than the block difficulty target so that workers submit shares regularly. "+
+			"Defaults to the network's minimum difficulty target.")
 
 	// Fees
 	cmd.PersistentFlags().Uint64("rate-limit-feerate", 0,
@@ -138,9 +159,11 @@ func SetupRunFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().Uint64("min-block-update-interval", 10,
 		"When set to a non-zero value, the node will wait at least this many seconds "+
 			"before producing another block template")
-	cmd.PersistentFlags().String("block-cypher-api-key", "",
-		"When specified, this key is used to power the BitcoinExchange flow "+
-			"and to check for double-spends in the mempool")
+	cmd.PersistentFlags().String("bitcoin-header-source-url", "",
+		"When set, the node keeps a local Bitcoin header chain in sync with the Esplora "+
+			"API at this URL (e.g. https://blockstream.info/api) and checks the merkle "+
+			"proofs and confirmations of BitcoinExchange burns against it. Past the "+
+			"BitcoinExchange SPV fork height a node needs this to connect blocks with burns.")
 	cmd.PersistentFlags().String("block-producer-seed", "",
 		"When set, all blocks produced by the block producer will be signed by this "+
 			"seed.")
@@ -176,7 +199,16 @@ func SetupRunFlags(cmd *cobra.Command) {
 			"level to 3 in all Go files whose names begin \"gopher\".")
 	cmd.PersistentFlags().Bool("log-db-summary-snapshots", false, "The node will log a snapshot of all DB keys every 30s.")
 	cmd.PersistentFlags().Bool("datadog-profiler", false, "Enable the DataDog profiler for performance testing")
+	cmd.PersistentFlags().Uint64("metrics-port", 0,
+		"When set, the node serves Prometheus metrics at /metrics on this port.")
 
+	BindFlags(cmd)
+}
+
+// BindFlags points viper at the flags of the given command. Several commands
+// share the same flag names, so each command re-binds its own flags before
+// loading the config; otherwise viper would read whichever command bound last.
+func BindFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
 		viper.BindPFlag(flag.Name, flag)
 	})
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"flag"
+
+	"github.com/deso-protocol/core/lib"
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var simulateDifficultyCmd = &cobra.Command{
+	Use:   "simulate-difficulty",
+	Short: "Replay hashrate scenarios through the difficulty retarget",
+	Long: `Simulates mining a chain whose hashrate changes over time and reports
+the mean and variance of the resulting block times for each phase. Use
+--ewma to compare the per-block EWMA retarget against the windowed
+retarget for the selected network. No data directory is used.`,
+	Run: SimulateDifficulty,
+}
+
+func init() {
+	simulateDifficultyCmd.PersistentFlags().Bool("testnet", false,
+		"When set, simulate with the testnet params rather than the mainnet params.")
+	simulateDifficultyCmd.PersistentFlags().Bool("regtest", false,
+		"When set, simulate with the regtest params. Requires --testnet.")
+	simulateDifficultyCmd.PersistentFlags().String("scenario", "500:1,500:4,500:1,500:0.5",
+		"A comma-separated list of blocks:multiplier phases. Each phase mines the given "+
+			"number of blocks at the given multiple of the hashrate that mines one block "+
+			"per TimeBetweenBlocks at the min difficulty.")
+	simulateDifficultyCmd.PersistentFlags().Int64("seed", 1,
+		"The seed for the random block times. The same seed always produces the same result.")
+	simulateDifficultyCmd.PersistentFlags().Bool("ewma", false,
+		"When set, use the per-block EWMA retarget from the first block.")
+	simulateDifficultyCmd.PersistentFlags().Int64("ewma-window-blocks", 0,
+		"Overrides DifficultyEWMAWindowBlocks for the selected network when non-zero.")
+	rootCmd.AddCommand(simulateDifficultyCmd)
+}
+
+func SimulateDifficulty(cmd *cobra.Command, args []string) {
+	BindFlags(cmd)
+	flag.Set("alsologtostderr", "true")
+	flag.Parse()
+
+	// Work on a copy so the global params are left alone.
+	var params lib.DeSoParams
+	if viper.GetBool("testnet") {
+		params = lib.DeSoTestnetParams
+	} else {
+		params = lib.DeSoMainnetParams
+	}
+	if viper.GetBool("regtest") {
+		params.EnableRegtest()
+	}
+	if windowBlocks := viper.GetInt64("ewma-window-blocks"); windowBlocks > 0 {
+		params.DifficultyEWMAWindowBlocks = windowBlocks
+	}
+	if viper.GetBool("ewma") {
+		params.ForkHeights.EWMADifficultyRetargetBlockHeight = 0
+	} else {
+		params.DifficultyEWMAWindowBlocks = 0
+	}
+
+	phases, err := lib.ParseHashrateScenario(viper.GetString("scenario"))
+	if err != nil {
+		glog.Fatal(err)
+	}
+	result, err := lib.SimulateDifficultyAdjustment(&params, phases, viper.GetInt64("seed"))
+	if err != nil {
+		glog.Fatal(err)
+	}
+
+	glog.Infof("Target block time: %v, retarget period: %v, EWMA window: %d blocks",
+		params.TimeBetweenBlocks, params.TimeBetweenDifficultyRetargets,
+		params.DifficultyEWMAWindowBlocks)
+	for ii, stats := range result.PhaseStats {
+		glog.Infof("Phase %d: %d blocks at %.2fx hashrate: mean block time %.1fs, "+
+			"std dev %.1fs, variance %.1f", ii, stats.NumBlocks, stats.HashrateMultiplier,
+			stats.MeanBlockTimeSecs, stats.StdDevBlockTimeSecs, stats.BlockTimeVariance)
+	}
+	glog.Infof("Overall: %d blocks: mean block time %.1fs, std dev %.1fs, variance %.1f",
+		result.OverallStats.NumBlocks, result.OverallStats.MeanBlockTimeSecs,
+		result.OverallStats.StdDevBlockTimeSecs, result.OverallStats.BlockTimeVariance)
+}
@@ -19,6 +19,33 @@ func DeSoHashV1(input []byte) [32]byte {
 	return result
 }
 
+// DeSoHashV1x4 computes DeSoHashV1 for four inputs at once. Miners use it to hash
+// several nonces of the same header in one call.
+func DeSoHashV1x4(inputs *[4][]byte) [4][32]byte {
+	results := sha3m.Sum256x4(inputs)
+
+	for ii := range results {
+		for i, c := range DeSoHashV1MixConstant {
+			results[ii][i] ^= c
+		}
+	}
+
+	return results
+}
+
+// DeSoHashV1x8 computes DeSoHashV1 for eight inputs at once.
+func DeSoHashV1x8(inputs *[8][]byte) [8][32]byte {
+	results := sha3m.Sum256x8(inputs)
+
+	for ii := range results {
+		for i, c := range DeSoHashV1MixConstant {
+			results[ii][i] ^= c
+		}
+	}
+
+	return results
+}
+
 func DeSoHashV0(input []byte) [32]byte {
 	output := sha256.Sum256(input)
 
@@ -2,6 +2,7 @@ package desohash
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"math/rand"
@@ -110,6 +111,54 @@ func TestDeSoHashV1(t *testing.T) {
 	}
 }
 
+func TestDeSoHashV1Lanes(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().Unix()))
+
+	// Every lane has to match hashing its input on its own, including inputs
+	// that span more than one block of the sponge and batches whose inputs
+	// have different lengths.
+	for _, inputLen := range []int{0, 80, 100, 135, 136, 137, 300} {
+		inputs := [8][]byte{}
+		for i := range inputs {
+			inputs[i] = make([]byte, inputLen)
+			r.Read(inputs[i])
+		}
+		mixedInputs := inputs
+		mixedInputs[3] = append([]byte{}, inputs[3][:inputLen/2]...)
+
+		for _, batch := range [][8][]byte{inputs, mixedInputs} {
+			hashes8 := DeSoHashV1x8(&batch)
+			hashes4 := DeSoHashV1x4(&[4][]byte{batch[0], batch[1], batch[2], batch[3]})
+			for i, input := range batch {
+				expected := DeSoHashV1(input)
+				if hashes8[i] != expected {
+					t.Errorf("TestDeSoHashV1Lanes: Mismatched x8 hash value! Input: %v, Hash: %v, Expected: %v", hex.EncodeToString(input), hex.EncodeToString(hashes8[i][:]), hex.EncodeToString(expected[:]))
+				}
+				if i < 4 && hashes4[i] != expected {
+					t.Errorf("TestDeSoHashV1Lanes: Mismatched x4 hash value! Input: %v, Hash: %v, Expected: %v", hex.EncodeToString(input), hex.EncodeToString(hashes4[i][:]), hex.EncodeToString(expected[:]))
+				}
+			}
+		}
+	}
+
+	// The test vectors hash the same way in a batch.
+	inputs := [4][]byte{}
+	for i := range inputs {
+		inputs[i] = maxHeaderV1.input
+	}
+	inputs[2] = zeroHeaderV1.input
+	hashes := DeSoHashV1x4(&inputs)
+	for i, hash := range hashes {
+		expected := maxHeaderV1.expected.V1
+		if i == 2 {
+			expected = zeroHeaderV1.expected.V1
+		}
+		if bytes.Compare(expected[:], hash[:]) != 0 {
+			t.Errorf("TestDeSoHashV1Lanes: Mismatched hash value for test vector! Hash: %v, Expected: %v", hex.EncodeToString(hash[:]), hex.EncodeToString(expected[:]))
+		}
+	}
+}
+
 func TestDeSoHashV0(t *testing.T) {
 	for _, vec := range testVectors {
 		hash := DeSoHashV0(vec.input)
@@ -132,3 +181,31 @@ func BenchmarkDeSoHashV1(b *testing.B) {
 		_ = DeSoHashV1([]byte(strconv.FormatInt(int64(i), 10)))
 	}
 }
+
+// The lane benchmarks hash v1 headers that only differ in their nonce, which is
+// what miners do. Each op hashes a full batch.
+func BenchmarkDeSoHashV1x4(b *testing.B) {
+	inputs := [4][]byte{}
+	for j := range inputs {
+		inputs[j] = append([]byte{}, zeroHeaderV1.input...)
+	}
+	for i := 0; i < b.N; i++ {
+		for j := range inputs {
+			binary.BigEndian.PutUint64(inputs[j][84:], uint64(4*i+j))
+		}
+		_ = DeSoHashV1x4(&inputs)
+	}
+}
+
+func BenchmarkDeSoHashV1x8(b *testing.B) {
+	inputs := [8][]byte{}
+	for j := range inputs {
+		inputs[j] = append([]byte{}, zeroHeaderV1.input...)
+	}
+	for i := 0; i < b.N; i++ {
+		for j := range inputs {
+			binary.BigEndian.PutUint64(inputs[j][84:], uint64(8*i+j))
+		}
+		_ = DeSoHashV1x8(&inputs)
+	}
+}
@@ -0,0 +1,75 @@
+package sha3m
+
+// This file provides batched SHA-3m functions that hash several inputs in one
+// call. They are used by miners, which hash many copies of the same block
+// header that differ only in their nonce.
+//
+// Each input is hashed with its own call to keccakF1600. We tried interleaving
+// the permutations of several states so the CPU could overlap their dependency
+// chains, but the Go compiler can't keep more than one state's temporaries in
+// registers. Hashing four states on amd64 took about 7.4us as four calls to
+// keccakF1600, 8.2us as two calls to a two-lane version and 14.5us with a
+// four-lane version, and an eight-lane version would spill even more, so the
+// interleaved permutations were dropped. What the batch still saves over Sum256
+// is the hash.Hash state and the copying in its Write and Sum.
+
+import "encoding/binary"
+
+const (
+	// sha3m256Rate is the rate in bytes of the sponge used by New256.
+	sha3m256Rate = 136
+	// sha3m256DSByte is the domain separation byte used by New256.
+	sha3m256DSByte = 0x06
+)
+
+// Sum256x4 returns the SHA3-256 digests of four inputs. The result is identical
+// to calling Sum256 on each input.
+func Sum256x4(inputs *[4][]byte) (digests [4][32]byte) {
+	for ii := range inputs {
+		digests[ii] = sum256Lane(inputs[ii])
+	}
+	return
+}
+
+// Sum256x8 returns the SHA3-256 digests of eight inputs. The result is identical
+// to calling Sum256 on each input.
+func Sum256x8(inputs *[8][]byte) (digests [8][32]byte) {
+	for ii := range inputs {
+		digests[ii] = sum256Lane(inputs[ii])
+	}
+	return
+}
+
+// sum256Lane absorbs an input into a bare sponge state and squeezes out a
+// 32-byte digest.
+func sum256Lane(in []byte) (digest [32]byte) {
+	var a [25]uint64
+
+	// Absorb all of the full blocks.
+	for len(in) >= sha3m256Rate {
+		xorInLane(&a, in[:sha3m256Rate])
+		keccakF1600(&a)
+		in = in[sha3m256Rate:]
+	}
+
+	// Pad the final partial block the same way padAndPermute does.
+	var block [sha3m256Rate]byte
+	copy(block[:], in)
+	block[len(in)] = sha3m256DSByte
+	block[sha3m256Rate-1] ^= 0x80
+	xorInLane(&a, block[:])
+	keccakF1600(&a)
+
+	// The 32-byte output fits in the first squeeze.
+	for ii := 0; ii < 4; ii++ {
+		binary.LittleEndian.PutUint64(digest[ii*8:], a[ii])
+	}
+	return
+}
+
+// xorInLane xors a full block of input into a single lane's state.
+func xorInLane(a *[25]uint64, buf []byte) {
+	for ii := 0; ii < len(buf)/8; ii++ {
+		a[ii] ^= binary.LittleEndian.Uint64(buf[ii*8:])
+	}
+}
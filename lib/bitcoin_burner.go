@@ -5,12 +5,9 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"github.com/davecgh/go-spew/spew"
 	"io/ioutil"
 	"math"
 	"net/http"
-	"strings"
-	"time"
 
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
@@ -25,8 +22,8 @@ import (
 // bitcoin_burner.go finds the Bitcoin UTXOs associated with a Bitcoin
 // address and constructs a burn transaction on behalf of the user. Note that the
 // use of an API here is strictly cosmetic, and that none of this
-// logic is used for actually validating anything (that is all done by bitcoin_manager.go,
-// which relies on Bitcoin peers and is fully decentralized). The user can
+// logic is used for actually validating anything (that is all done against the Bitcoin
+// header chain kept by bitcoin_header_chain.go, which checks every header itself). The user can
 // also simply use an existing Bitcoin wallet to send Bitcoin to the burn address
 // rather than this utility, but that is slightly less convenient than just
 // baking this functionality in, which is why we do it.
@@ -309,10 +306,6 @@ type BlockCypherAPIFullAddressResponse struct {
 	Error string `json:"error"`
 }
 
-type BlockchainInfoAPIResponse struct {
-	DoubleSpend bool `json:"double_spend"`
-}
-
 func BlockCypherExtractBitcoinUtxosFromResponse(
 	apiData *BlockCypherAPIFullAddressResponse, addrString string, params *DeSoParams) (
 	[]*BitcoinUtxo, error) {
@@ -473,159 +466,6 @@ func FrontendBlockCypherUtxoSource(
 	return BlockCypherExtractBitcoinUtxosFromResponse(apiData, addrString, params)
 }
 
-func BlockchainInfoCheckBitcoinDoubleSpend(txnHash *chainhash.Hash, blockCypherAPIKey string, params *DeSoParams) (
-	_isDoubleSpend bool, _err error) {
-
-	// Always pass on testnet for simplicity.
-	if IsBitcoinTestnet(params) {
-		return false, nil
-	}
-	URL := fmt.Sprintf("https://blockchain.info/rawtx/%s", txnHash.String())
-	glog.V(2).Infof("BlockchainInfoCheckBitcoinDoubleSpend: Querying URL: %s", URL)
-
-	req, _ := http.NewRequest("GET", URL, nil)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return false, fmt.Errorf("BlockchainInfoCheckBitcoinDoubleSpend: "+
-			"Problem with HTTP request %s: %v", URL, err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		glog.V(2).Infof("BlockchainInfoCheckBitcoinDoubleSpend: Bitcoin txn with "+
-			"hash %v was not found in Blockchain.info OR an error occurred: %v", txnHash, spew.Sdump(resp))
-		return true, nil
-	}
-
-	// Decode the response into the appropriate struct.
-	body, _ := ioutil.ReadAll(resp.Body)
-	responseData := &BlockchainInfoAPIResponse{}
-	decoder := json.NewDecoder(bytes.NewReader(body))
-	if err := decoder.Decode(responseData); err != nil {
-		return false, fmt.Errorf("BlockchainInfoCheckBitcoinDoubleSpend: "+
-			"Problem decoding response JSON into "+
-			"interface %v, response: %v, error: %v", responseData, resp, err)
-	}
-
-	if responseData.DoubleSpend {
-		glog.V(2).Infof("BlockchainInfoCheckBitcoinDoubleSpend: Bitcoin "+
-			"txn with hash %v was a double spend", txnHash)
-		return true, nil
-	}
-
-	return false, nil
-}
-
-func GetBlockCypherTxnResponse(txnHash *chainhash.Hash, blockCypherAPIKey string, params *DeSoParams) (*BlockCypherAPITxnResponse, error) {
-	URL := fmt.Sprintf("http://api.blockcypher.com/v1/btc/main/txs/%s", txnHash.String())
-	if IsBitcoinTestnet(params) {
-		URL = fmt.Sprintf("http://api.blockcypher.com/v1/btc/test3/txs/%s", txnHash.String())
-	}
-	glog.V(2).Infof("CheckBitcoinDoubleSpend: Querying URL: %s", URL)
-
-	// jsonValue, err := json.Marshal(postData)
-	req, _ := http.NewRequest("GET", URL, nil)
-	req.Header.Set("Content-Type", "application/json")
-
-	// To add a ?a=b query string, use the below.
-	q := req.URL.Query()
-	// TODO: Right now we'll only fetch a maximum of 50 transactions from the API.
-	// This means if the user has done more than 50 transactions with their address
-	// we'll start missing some of the older utxos. This is easy to fix, though, and
-	// just amounts to cycling through the API's pages. Note also that this does not
-	// prevent a user from buying DeSo in this case nor does it prevent her from being
-	// able to recover her Bitcoin. Both of these can be accomplished by loading the
-	// address in a standard Bitcoin wallet like Electrum.
-	q.Add("token", blockCypherAPIKey)
-	req.URL.RawQuery = q.Encode()
-	glog.V(2).Infof("CheckBitcoinDoubleSpend: URL with params: %s", req.URL)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("CheckBitcoinDoubleSpend: Problem with HTTP request %s: %v", URL, err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 404 {
-		return nil, nil
-	} else if resp.StatusCode != 200 {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return nil, fmt.Errorf("CheckBitcoinDoubleSpend: Error code returned "+
-			"from BlockCypher: %v %v", resp.StatusCode, string(body))
-	}
-
-	// Decode the response into the appropriate struct.
-	body, _ := ioutil.ReadAll(resp.Body)
-	responseData := &BlockCypherAPITxnResponse{}
-	decoder := json.NewDecoder(bytes.NewReader(body))
-	if err := decoder.Decode(responseData); err != nil {
-		return nil, fmt.Errorf("CheckBitcoinDoubleSpend: Problem decoding response JSON into "+
-			"interface %v, response: %v, error: %v", responseData, resp, err)
-	}
-
-	return responseData, nil
-}
-
-func BlockCypherCheckBitcoinDoubleSpend(txnHash *chainhash.Hash, blockCypherAPIKey string, params *DeSoParams) (
-	_isDoubleSpend bool, _err error) {
-
-	glog.Infof("CheckBitcoinDoubleSpend: Checking txn %v for double-spend", txnHash)
-	responseData, err := GetBlockCypherTxnResponse(txnHash, blockCypherAPIKey, params)
-	if err != nil {
-		return false, errors.Wrapf(err, "BlockCypherCheckBitcoinDoubleSpend: Error fetching txn: ")
-	}
-
-	// If we didn't find the txn in BlockCypher then we consider this a double-spend
-	if responseData == nil {
-		glog.V(2).Infof("CheckBitcoinDoubleSpend: Bitcoin txn with hash %v was not found in BlockCypher", txnHash)
-		return true, nil
-	}
-
-	if responseData.DoubleSpend {
-		glog.V(2).Infof("CheckBitcoinDoubleSpend: Bitcoin txn with hash %v was a double spend", txnHash)
-		return true, nil
-	}
-
-	// If the transaction is not mined, then we need to recursively check
-	// its inputs to determine whether they are double-spends.
-	if responseData.Confirmations == 0 {
-		// If there are too many inputs on this txn, then just mark it as a double-spend.
-		// This avoids us having to use our API quota unnecessarily.
-		if len(responseData.Inputs) > 25 {
-			glog.Warningf("CheckBitcoinDoubleSpend: Unmined bitcoin txn with hash %v had %v inputs, "+
-				"which made us label it as a double-spend. If this is happening a lot, consider "+
-				"increasing the limit on this if statement", txnHash, len(responseData.Inputs))
-			return true, nil
-		}
-		// Recursively scan through the unmined inputs one at a time to see
-		// if any ancestors contain double-spends. If they do, then this txn is
-		// a double-spend by transitivity.
-		for _, txIn := range responseData.Inputs {
-			inputTxHash, err := chainhash.NewHashFromStr(txIn.PrevTxIDHex)
-			if err != nil {
-				return false, errors.Wrapf(
-					err, "BlockCypherCheckBitcoinDoubleSpend: Error parsing INPUT txn hash: %v", inputTxHash)
-			}
-			glog.V(1).Infof("CheckBitcoinDoubleSpend: Checking INPUT %v for double-spend", inputTxHash)
-			inputIsDoubleSpend, err := BlockCypherCheckBitcoinDoubleSpend(inputTxHash, blockCypherAPIKey, params)
-			if err != nil {
-				return false, errors.Wrapf(
-					err, "BlockCypherCheckBitcoinDoubleSpend: Error fetching INPUT txn: %v", inputTxHash)
-			}
-			if inputIsDoubleSpend {
-				return true, nil
-			}
-		}
-	}
-	// If we get here, it means that this txn wasn't a double-spend *and*
-	// none of its unmined inputs were double-spends either.
-
-	return false, nil
-}
 func BlockCypherPushTransaction(txnHex string, txnHash *chainhash.Hash, blockCypherAPIKey string, params *DeSoParams) (
 	_added bool, _err error) {
 
@@ -678,104 +518,3 @@ func BlockCypherPushTransaction(txnHex string, txnHash *chainhash.Hash, blockCyp
 	return false, fmt.Errorf("PushTransaction: Failed to submit transaction "+
 		"to Bitcoin blockchain: %v, Body: %v, Txn Hash: %v", resp.StatusCode, string(body), txnHash)
 }
-
-func CheckBitcoinDoubleSpend(txnHash *chainhash.Hash, blockCypherAPIKey string, params *DeSoParams) error {
-
-	{
-		isDoubleSpend, err := BlockCypherCheckBitcoinDoubleSpend(txnHash, blockCypherAPIKey, params)
-		if err != nil {
-			return fmt.Errorf("PushAndWaitForTxn: Error occurred when checking for "+
-				"double-spend on BlockCypher. Your transaction will go through once it "+
-				"has been mined into a Bitcoin block. Txn hash: %v", txnHash)
-		}
-		if isDoubleSpend {
-			return fmt.Errorf("PushAndWaitForTxn: Error: double-spend detected "+
-				"by BlockCypher. Your transaction will go through once it mines into the "+
-				"next Bitcoin block, which should take about ten minutes. Txn hash: %v", txnHash)
-		}
-	}
-
-	// Also check the Blockchain.com API for a double-spend. This prevents an attack
-	// that exploits a weakness in BlockCypher's APIs.
-	//
-	// TODO: Document this attack later.
-	{
-		isDoubleSpend, err := BlockchainInfoCheckBitcoinDoubleSpend(txnHash, blockCypherAPIKey, params)
-		if err != nil {
-			return fmt.Errorf("PushAndWaitForTxn: Error occurred when checking for "+
-				"double-spend on blockchain.info. Your transaction will go through once "+
-				"it has been mined into a Bitcoin block. Txn hash: %v", txnHash)
-		}
-		if isDoubleSpend {
-			return fmt.Errorf("PushAndWaitForTxn: Error: double-spend detected "+
-				"by blockchain.info. Your transaction will go through once it mines "+
-				"into the next Bitcoin block. Txn hash: %v", txnHash)
-		}
-	}
-
-	// If we get here then there was no double-spend detected.
-	return nil
-}
-
-func BlockCypherPushAndWaitForTxn(txnHex string, txnHash *chainhash.Hash,
-	blockCypherAPIKey string, doubleSpendWaitSeconds float64, params *DeSoParams) (_isDoubleSpend bool, _err error) {
-	_, err := BlockCypherPushTransaction(txnHex, txnHash, blockCypherAPIKey, params)
-	if err != nil {
-		return false, fmt.Errorf("PushAndWaitForTxn: %v", err)
-	}
-	// Wait some amount of time before checking for a double-spend.
-	time.Sleep(time.Duration(doubleSpendWaitSeconds) * time.Second)
-
-	if err := CheckBitcoinDoubleSpend(txnHash, blockCypherAPIKey, params); err != nil {
-		return true, err
-	}
-
-	return false, nil
-}
-
-type BlockonomicsRBFResponse struct {
-	RBF    int64  `json:"rbf"`
-	Status string `json:"status"`
-}
-
-func BlockonomicsCheckRBF(bitcoinTxnHash string) (
-	_hasRBF bool, _err error) {
-
-	URL := fmt.Sprintf("https://www.blockonomics.co/api/tx_detail?txid=%s", bitcoinTxnHash)
-	glog.V(1).Infof("BlockonomicsCheckRBF: Querying URL: %s", URL)
-
-	req, _ := http.NewRequest("GET", URL, nil)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return false, fmt.Errorf("BlockonomicsCheckRBF: Problem with HTTP request %s: %v", URL, err)
-	}
-	defer resp.Body.Close()
-
-	// Decode the body.
-	body, _ := ioutil.ReadAll(resp.Body)
-	if resp.StatusCode != 200 {
-		retErr := fmt.Errorf("BlockonomicsCheckRBF: Error when checking "+
-			"RBF tatus for txn %v: %v", bitcoinTxnHash, string(body))
-		return false, retErr
-	}
-
-	responseData := &BlockonomicsRBFResponse{}
-	decoder := json.NewDecoder(bytes.NewReader(body))
-	if err := decoder.Decode(responseData); err != nil {
-		return false, fmt.Errorf("BlockonomicsCheckRBF: Problem decoding response JSON into "+
-			"interface %v, response: %v, body: %v, error: %v", responseData, resp, string(body), err)
-	}
-	//glog.V(2).Infof("UtxoSource: Received response: %v", responseData)
-
-	if strings.ToLower(responseData.Status) == "unconfirmed" &&
-		(responseData.RBF == 1 || responseData.RBF == 2) {
-
-		glog.V(1).Infof("BlockonomicsCheckRBF: Bitcoin txn with hash %v has RBF set", bitcoinTxnHash)
-		return true, nil
-	}
-
-	return false, nil
-}
@@ -0,0 +1,574 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	btcdchain "github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/deso-protocol/go-deadlock"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// bitcoin_header_chain.go maintains a local copy of the Bitcoin header chain, starting
+// from params.BitcoinStartBlockNode, so that BitcoinExchange burns can be verified
+// with a merkle proof against a header we've checked the work of ourselves rather
+// than by asking an external API. Headers are fed in by a BitcoinHeaderSource, which
+// can be anything from a Bitcoin peer to a block explorer, since every header is
+// validated before it's accepted and the chain with the most work always wins.
+//
+// Nodes are stored under _PrefixBitcoinHeightHashToNodeInfo the same way the DeSo block
+// index is, with the Bitcoin merkle root kept in the header's TransactionMerkleRoot.
+// The blocks on the best chain are also indexed by hash under
+// _PrefixBitcoinBestChainHashToHeight so that a burn's block can be looked up without
+// loading the header chain into memory, which is how _connectBitcoinExchange checks
+// burns once ForkHeights.BitcoinExchangeSPVBlockHeight has passed. Before that the
+// mempool still only accepts burns that VerifyBitcoinExchange can prove against the
+// header chain.
+
+const (
+	// The maximum number of headers we ask a BitcoinHeaderSource for at once.
+	MaxBitcoinHeadersPerRequest = 2000
+
+	// How far into the future a Bitcoin header's timestamp can be, mirroring the
+	// limit Bitcoin nodes use.
+	MaxBitcoinHeaderFutureSeconds = 2 * 60 * 60
+
+	// The number of previous headers whose median timestamp a new header must exceed.
+	BitcoinMedianTimeBlocks = 11
+
+	// How often a node with a BitcoinHeaderSource checks it for new headers.
+	BitcoinHeaderSyncInterval = 1 * time.Minute
+)
+
+// BitcoinHeaderSource supplies Bitcoin headers to a BitcoinHeaderChain.
+type BitcoinHeaderSource interface {
+	// GetBitcoinHeaders returns up to maxHeaders headers, in order, that follow the
+	// first hash in the locator that is on the source's best chain. The locator is
+	// ordered from the tip of the local chain backwards. It returns no headers if
+	// the source has nothing past that hash.
+	GetBitcoinHeaders(locator []*BlockHash, maxHeaders int) ([]*wire.BlockHeader, error)
+}
+
+type BitcoinHeaderChain struct {
+	db     *badger.DB
+	params *DeSoParams
+	source BitcoinHeaderSource
+
+	// Protects headerIndex and bestChain.
+	mtx deadlock.RWMutex
+	// Every header we've accepted, including those that aren't on the best chain.
+	headerIndex map[BlockHash]*BlockNode
+	// The best chain from the start node to the tip. The node at index ii has a
+	// height equal to the start node's height plus ii.
+	bestChain []*BlockNode
+
+	syncInterval time.Duration
+	stopChan     chan struct{}
+	waitGroup    sync.WaitGroup
+}
+
+// NewBitcoinHeaderChain loads the Bitcoin header chain from the db, initializing it
+// with params.BitcoinStartBlockNode if it's empty. The source may be nil if headers
+// will only be fed in through ProcessBitcoinHeaders.
+func NewBitcoinHeaderChain(db *badger.DB, params *DeSoParams, source BitcoinHeaderSource,
+	syncInterval time.Duration) (*BitcoinHeaderChain, error) {
+
+	headerIndex, err := GetBlockIndex(db, true /*bitcoinNodes*/)
+	if err != nil {
+		return nil, errors.Wrapf(err, "NewBitcoinHeaderChain: Problem loading header index: ")
+	}
+
+	// If we've never stored any Bitcoin headers then start from the start node.
+	if len(headerIndex) == 0 {
+		startNode := *params.BitcoinStartBlockNode
+		startHeader := *startNode.Header
+		startHeader.Height = uint64(startNode.Height)
+		startNode.Header = &startHeader
+		if startNode.CumWork == nil {
+			startNode.CumWork = big.NewInt(0)
+		}
+		err = db.Update(func(txn *badger.Txn) error {
+			if err := PutHeightHashToNodeInfoWithTxn(txn, &startNode, true /*bitcoinNodes*/); err != nil {
+				return err
+			}
+			if err := DbPutBitcoinBestChainHeightWithTxn(txn, startNode.Hash, startNode.Height); err != nil {
+				return err
+			}
+			return PutBestHashWithTxn(txn, startNode.Hash, ChainTypeBitcoinHeader)
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "NewBitcoinHeaderChain: Problem storing start node: ")
+		}
+		headerIndex[*startNode.Hash] = &startNode
+	}
+
+	tipHash := DbGetBestHash(db, ChainTypeBitcoinHeader)
+	if tipHash == nil {
+		return nil, fmt.Errorf("NewBitcoinHeaderChain: Best Bitcoin header hash not found")
+	}
+	tipNode, exists := headerIndex[*tipHash]
+	if !exists {
+		return nil, fmt.Errorf("NewBitcoinHeaderChain: Best Bitcoin header %v not found "+
+			"in header index", tipHash)
+	}
+	bestChain, err := GetBestChain(tipNode, headerIndex)
+	if err != nil {
+		return nil, errors.Wrapf(err, "NewBitcoinHeaderChain: Problem loading best chain: ")
+	}
+
+	return &BitcoinHeaderChain{
+		db:           db,
+		params:       params,
+		source:       source,
+		headerIndex:  headerIndex,
+		bestChain:    bestChain,
+		syncInterval: syncInterval,
+		stopChan:     make(chan struct{}),
+	}, nil
+}
+
+// Tip returns the node at the tip of the best Bitcoin header chain.
+func (hc *BitcoinHeaderChain) Tip() *BlockNode {
+	hc.mtx.RLock()
+	defer hc.mtx.RUnlock()
+
+	return hc.bestChain[len(hc.bestChain)-1]
+}
+
+// GetBestChainNode returns the node for the given hash if it's on the best chain.
+func (hc *BitcoinHeaderChain) GetBestChainNode(blockHash *BlockHash) *BlockNode {
+	hc.mtx.RLock()
+	defer hc.mtx.RUnlock()
+
+	node, exists := hc.headerIndex[*blockHash]
+	if !exists || !hc._isOnBestChain(node) {
+		return nil
+	}
+	return node
+}
+
+// VerifyBitcoinExchange checks a BitcoinExchange burn against the best Bitcoin header
+// chain. The burn's block must be on the best chain with at least
+// BitcoinMinNumConfirmations blocks, counting itself, and the merkle proof must connect
+// the burn to that block's merkle root.
+//
+// This is used by the mempool to decide which burns to accept. A burn whose block we
+// haven't synced far enough yet returns an error that isn't a RuleError so that it can
+// be retried once we have more headers.
+func (hc *BitcoinHeaderChain) VerifyBitcoinExchange(txMeta *BitcoinExchangeMetadata) error {
+	if txMeta.BitcoinBlockHash == nil || txMeta.BitcoinMerkleRoot == nil {
+		return RuleErrorBitcoinExchangeBlockHashNotFoundInMainBitcoinChain
+	}
+
+	hc.mtx.RLock()
+	defer hc.mtx.RUnlock()
+
+	blockNode, exists := hc.headerIndex[*txMeta.BitcoinBlockHash]
+	if !exists || !hc._isOnBestChain(blockNode) {
+		return fmt.Errorf("VerifyBitcoinExchange: Bitcoin block %v is not on "+
+			"our best Bitcoin header chain yet", txMeta.BitcoinBlockHash)
+	}
+
+	// The merkle root in the metadata must be the one in the header we validated.
+	if err := _verifyBitcoinExchangeMerkleProof(txMeta, blockNode.Header); err != nil {
+		return errors.Wrapf(err, "VerifyBitcoinExchange: ")
+	}
+
+	tipNode := hc.bestChain[len(hc.bestChain)-1]
+	numConfirmations := tipNode.Height - blockNode.Height + 1
+	if numConfirmations < hc.params.BitcoinMinNumConfirmations {
+		return fmt.Errorf("VerifyBitcoinExchange: Bitcoin block %v has %d "+
+			"confirmations but %d are required", txMeta.BitcoinBlockHash,
+			numConfirmations, hc.params.BitcoinMinNumConfirmations)
+	}
+
+	return nil
+}
+
+func (hc *BitcoinHeaderChain) _isOnBestChain(node *BlockNode) bool {
+	startHeight := hc.bestChain[0].Height
+	if node.Height < startHeight {
+		return false
+	}
+	index := node.Height - startHeight
+	return index < uint32(len(hc.bestChain)) && *hc.bestChain[index].Hash == *node.Hash
+}
+
+// LatestLocator returns the hashes of the best chain a source needs to find where it
+// should start sending headers from. Like a Bitcoin block locator, the hashes start
+// at the tip and step back exponentially further, always ending with the start node.
+func (hc *BitcoinHeaderChain) LatestLocator() []*BlockHash {
+	hc.mtx.RLock()
+	defer hc.mtx.RUnlock()
+
+	locator := []*BlockHash{}
+	step := 1
+	for index := len(hc.bestChain) - 1; index > 0; index -= step {
+		locator = append(locator, hc.bestChain[index].Hash)
+		// Include the ten most recent hashes before stepping back exponentially.
+		if len(locator) > 10 {
+			step *= 2
+		}
+	}
+	return append(locator, hc.bestChain[0].Hash)
+}
+
+// ProcessBitcoinHeaders validates and stores the headers in order, stopping at the
+// first one that can't be accepted.
+func (hc *BitcoinHeaderChain) ProcessBitcoinHeaders(headers []*wire.BlockHeader) error {
+	for _, header := range headers {
+		if _, err := hc.ProcessBitcoinHeader(header); err != nil {
+			return errors.Wrapf(err, "ProcessBitcoinHeaders: ")
+		}
+	}
+	return nil
+}
+
+// ProcessBitcoinHeader validates a header and stores it, making it the new tip if
+// the chain it's on has more work than the current best chain. Headers whose parent
+// we don't have are rejected.
+func (hc *BitcoinHeaderChain) ProcessBitcoinHeader(header *wire.BlockHeader) (_isMainChain bool, _err error) {
+	hc.mtx.Lock()
+	defer hc.mtx.Unlock()
+
+	headerHash := (BlockHash)(header.BlockHash())
+	if existingNode, exists := hc.headerIndex[headerHash]; exists {
+		return hc._isOnBestChain(existingNode), nil
+	}
+
+	parentNode, exists := hc.headerIndex[(BlockHash)(header.PrevBlock)]
+	if !exists {
+		return false, fmt.Errorf("ProcessBitcoinHeader: Parent %v of header %v not found",
+			(BlockHash)(header.PrevBlock), &headerHash)
+	}
+	if err := hc._validateBitcoinHeader(header, parentNode); err != nil {
+		return false, errors.Wrapf(err, "ProcessBitcoinHeader: Header %v is invalid: ", &headerHash)
+	}
+
+	// We are bastardizing the DeSo header to store Bitcoin information here.
+	prevBlockHash := (BlockHash)(header.PrevBlock)
+	merkleRoot := (BlockHash)(header.MerkleRoot)
+	newNode := NewBlockNode(
+		parentNode,
+		&headerHash,
+		parentNode.Height+1,
+		_difficultyBitsToHash(header.Bits),
+		new(big.Int).Add(parentNode.CumWork, btcdchain.CalcWork(header.Bits)),
+		&MsgDeSoHeader{
+			PrevBlockHash:         &prevBlockHash,
+			TransactionMerkleRoot: &merkleRoot,
+			TstampSecs:            uint64(header.Timestamp.Unix()),
+			Height:                uint64(parentNode.Height + 1),
+			Nonce:                 uint64(header.Nonce),
+		},
+		StatusBitcoinHeaderValidated,
+	)
+	if err := PutHeightHashToNodeInfo(newNode, hc.db, true /*bitcoinNodes*/); err != nil {
+		return false, errors.Wrapf(err, "ProcessBitcoinHeader: Problem storing header %v: ", &headerHash)
+	}
+	hc.headerIndex[headerHash] = newNode
+
+	// Only switch to the new header's chain if it has strictly more work.
+	tipNode := hc.bestChain[len(hc.bestChain)-1]
+	if newNode.CumWork.Cmp(tipNode.CumWork) <= 0 {
+		return false, nil
+	}
+	if err := hc._setBestChainTip(newNode); err != nil {
+		return false, errors.Wrapf(err, "ProcessBitcoinHeader: ")
+	}
+	return true, nil
+}
+
+func (hc *BitcoinHeaderChain) _validateBitcoinHeader(header *wire.BlockHeader, parentNode *BlockNode) error {
+	btcdParams := hc.params.BitcoinBtcdParams
+
+	// The header must meet the target it claims, and that target can't be easier
+	// than the network allows.
+	target := btcdchain.CompactToBig(header.Bits)
+	if target.Sign() <= 0 || target.Cmp(btcdParams.PowLimit) > 0 {
+		return fmt.Errorf("_validateBitcoinHeader: Target %064x is out of range", target)
+	}
+	headerHash := header.BlockHash()
+	if btcdchain.HashToBig(&headerHash).Cmp(target) > 0 {
+		return fmt.Errorf("_validateBitcoinHeader: Hash %v does not meet target %064x",
+			&headerHash, target)
+	}
+
+	// Check the claimed target against the retarget rules. Testnet allows
+	// minimum-difficulty blocks whenever blocks are slow, which can't be checked
+	// without knowing when the header was received, so we rely on the work
+	// comparison between chains there.
+	if !btcdParams.ReduceMinDifficulty {
+		expectedBits, canCheck := hc._expectedBitcoinBits(parentNode)
+		if canCheck && header.Bits != expectedBits {
+			return fmt.Errorf("_validateBitcoinHeader: Bits %08x do not match expected bits %08x",
+				header.Bits, expectedBits)
+		}
+	}
+
+	// The timestamp must be after the median of the previous headers and can't be
+	// too far in the future.
+	tstamps := []uint64{}
+	for node := parentNode; node != nil && len(tstamps) < BitcoinMedianTimeBlocks; node = node.Parent {
+		tstamps = append(tstamps, node.Header.TstampSecs)
+	}
+	sort.Slice(tstamps, func(ii, jj int) bool { return tstamps[ii] < tstamps[jj] })
+	medianTstamp := tstamps[len(tstamps)/2]
+	if uint64(header.Timestamp.Unix()) <= medianTstamp {
+		return fmt.Errorf("_validateBitcoinHeader: Timestamp %d is not after median time %d",
+			header.Timestamp.Unix(), medianTstamp)
+	}
+	if header.Timestamp.Unix() > time.Now().Unix()+MaxBitcoinHeaderFutureSeconds {
+		return fmt.Errorf("_validateBitcoinHeader: Timestamp %d is too far in the future",
+			header.Timestamp.Unix())
+	}
+
+	return nil
+}
+
+// _expectedBitcoinBits returns the difficulty bits a child of parentNode must have.
+// It returns false if the retarget window reaches back past the start node, in which
+// case the bits can't be checked.
+func (hc *BitcoinHeaderChain) _expectedBitcoinBits(parentNode *BlockNode) (_bits uint32, _canCheck bool) {
+	btcdParams := hc.params.BitcoinBtcdParams
+	parentTarget := HashToBigint(parentNode.DifficultyTarget)
+
+	// The target only changes at the start of each retarget interval.
+	blocksPerRetarget := uint32(btcdParams.TargetTimespan / btcdParams.TargetTimePerBlock)
+	if (parentNode.Height+1)%blocksPerRetarget != 0 {
+		return btcdchain.BigToCompact(parentTarget), true
+	}
+
+	firstNode := parentNode
+	for ii := uint32(1); ii < blocksPerRetarget; ii++ {
+		if firstNode.Parent == nil {
+			return 0, false
+		}
+		firstNode = firstNode.Parent
+	}
+
+	// Scale the target by how long the interval actually took, limiting the
+	// adjustment the same way Bitcoin does.
+	targetTimespanSecs := int64(btcdParams.TargetTimespan / time.Second)
+	actualTimespanSecs := int64(parentNode.Header.TstampSecs) - int64(firstNode.Header.TstampSecs)
+	minTimespanSecs := targetTimespanSecs / btcdParams.RetargetAdjustmentFactor
+	maxTimespanSecs := targetTimespanSecs * btcdParams.RetargetAdjustmentFactor
+	if actualTimespanSecs < minTimespanSecs {
+		actualTimespanSecs = minTimespanSecs
+	} else if actualTimespanSecs > maxTimespanSecs {
+		actualTimespanSecs = maxTimespanSecs
+	}
+	newTarget := new(big.Int).Mul(parentTarget, big.NewInt(actualTimespanSecs))
+	newTarget.Div(newTarget, big.NewInt(targetTimespanSecs))
+	if newTarget.Cmp(btcdParams.PowLimit) > 0 {
+		newTarget.Set(btcdParams.PowLimit)
+	}
+	return btcdchain.BigToCompact(newTarget), true
+}
+
+// _setBestChainTip switches the best chain to end at newTipNode, updating the best
+// chain index in the db and in memory. The mutex must be held for writing.
+func (hc *BitcoinHeaderChain) _setBestChainTip(newTipNode *BlockNode) error {
+	// Walk back from the new tip until we hit the current best chain.
+	attachNodes := []*BlockNode{}
+	forkNode := newTipNode
+	for forkNode != nil && !hc._isOnBestChain(forkNode) {
+		attachNodes = append(attachNodes, forkNode)
+		forkNode = forkNode.Parent
+	}
+	if forkNode == nil {
+		return fmt.Errorf("_setBestChainTip: New tip %v does not share an ancestor "+
+			"with the best chain", newTipNode.Hash)
+	}
+	forkIndex := forkNode.Height - hc.bestChain[0].Height
+	detachNodes := append([]*BlockNode{}, hc.bestChain[forkIndex+1:]...)
+
+	err := hc.db.Update(func(txn *badger.Txn) error {
+		for _, detachNode := range detachNodes {
+			if err := DbDeleteBitcoinBestChainHeightWithTxn(txn, detachNode.Hash); err != nil {
+				return err
+			}
+		}
+		for _, attachNode := range attachNodes {
+			if err := DbPutBitcoinBestChainHeightWithTxn(txn, attachNode.Hash, attachNode.Height); err != nil {
+				return err
+			}
+		}
+		return PutBestHashWithTxn(txn, newTipNode.Hash, ChainTypeBitcoinHeader)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "_setBestChainTip: Problem updating best chain: ")
+	}
+
+	if len(detachNodes) > 0 {
+		glog.Infof("_setBestChainTip: Bitcoin header reorg detached %d headers and "+
+			"attached %d headers at height %d", len(detachNodes), len(attachNodes), forkNode.Height)
+	}
+	// The detached nodes were copied above so it's safe to overwrite them here.
+	hc.bestChain = hc.bestChain[:forkIndex+1]
+	for ii := len(attachNodes) - 1; ii >= 0; ii-- {
+		hc.bestChain = append(hc.bestChain, attachNodes[ii])
+	}
+	return nil
+}
+
+// SyncWithSource fetches headers from the source until it has nothing past our tip.
+func (hc *BitcoinHeaderChain) SyncWithSource() error {
+	if hc.source == nil {
+		return fmt.Errorf("SyncWithSource: No BitcoinHeaderSource set")
+	}
+	for {
+		headers, err := hc.source.GetBitcoinHeaders(hc.LatestLocator(), MaxBitcoinHeadersPerRequest)
+		if err != nil {
+			return errors.Wrapf(err, "SyncWithSource: Problem getting headers: ")
+		}
+		if err := hc.ProcessBitcoinHeaders(headers); err != nil {
+			return errors.Wrapf(err, "SyncWithSource: ")
+		}
+		if len(headers) < MaxBitcoinHeadersPerRequest {
+			return nil
+		}
+	}
+}
+
+func (hc *BitcoinHeaderChain) Start() {
+	if hc.source == nil {
+		return
+	}
+
+	hc.waitGroup.Add(1)
+	go func() {
+		defer hc.waitGroup.Done()
+		for {
+			if err := hc.SyncWithSource(); err != nil {
+				glog.Errorf("BitcoinHeaderChain.Start: Problem syncing Bitcoin headers: %v", err)
+			} else {
+				glog.V(1).Infof("BitcoinHeaderChain.Start: Bitcoin header tip is %v", hc.Tip())
+			}
+
+			select {
+			case <-hc.stopChan:
+				return
+			case <-time.After(hc.syncInterval):
+			}
+		}
+	}()
+}
+
+func (hc *BitcoinHeaderChain) Stop() {
+	close(hc.stopChan)
+	hc.waitGroup.Wait()
+}
+
+// EsploraBitcoinHeaderSource fetches Bitcoin headers from a server running the
+// Esplora REST API, such as blockstream.info/api.
+type EsploraBitcoinHeaderSource struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewEsploraBitcoinHeaderSource(baseURL string) *EsploraBitcoinHeaderSource {
+	return &EsploraBitcoinHeaderSource{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// _get returns the body of the response to a GET request, or nil if the server
+// returned a 404.
+func (src *EsploraBitcoinHeaderSource) _get(path string) ([]byte, error) {
+	resp, err := src.client.Get(src.baseURL + path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "EsploraBitcoinHeaderSource._get: Problem fetching %v: ", path)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "EsploraBitcoinHeaderSource._get: Problem reading %v: ", path)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("EsploraBitcoinHeaderSource._get: Status %d fetching %v: %s",
+			resp.StatusCode, path, body)
+	}
+	return body, nil
+}
+
+func (src *EsploraBitcoinHeaderSource) GetBitcoinHeaders(locator []*BlockHash, maxHeaders int) (
+	[]*wire.BlockHeader, error) {
+
+	// Find the first hash in the locator that's on the server's best chain.
+	startHeight := int64(-1)
+	for _, blockHash := range locator {
+		body, err := src._get(fmt.Sprintf("/block/%v/status", (*chainhash.Hash)(blockHash)))
+		if err != nil {
+			return nil, err
+		}
+		if body == nil {
+			continue
+		}
+		status := struct {
+			InBestChain bool  `json:"in_best_chain"`
+			Height      int64 `json:"height"`
+		}{}
+		if err := json.Unmarshal(body, &status); err != nil {
+			return nil, errors.Wrapf(err, "EsploraBitcoinHeaderSource.GetBitcoinHeaders: "+
+				"Problem parsing status for %v: ", blockHash)
+		}
+		if status.InBestChain {
+			startHeight = status.Height
+			break
+		}
+	}
+	if startHeight < 0 {
+		return nil, fmt.Errorf("EsploraBitcoinHeaderSource.GetBitcoinHeaders: None of the " +
+			"locator hashes are on the server's best chain")
+	}
+
+	headers := []*wire.BlockHeader{}
+	for height := startHeight + 1; len(headers) < maxHeaders; height++ {
+		hashBody, err := src._get("/block-height/" + strconv.FormatInt(height, 10))
+		if err != nil {
+			return nil, err
+		}
+		if hashBody == nil {
+			break
+		}
+		headerBody, err := src._get("/block/" + strings.TrimSpace(string(hashBody)) + "/header")
+		if err != nil {
+			return nil, err
+		}
+		if headerBody == nil {
+			break
+		}
+		headerBytes, err := hex.DecodeString(strings.TrimSpace(string(headerBody)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "EsploraBitcoinHeaderSource.GetBitcoinHeaders: "+
+				"Problem decoding header at height %d: ", height)
+		}
+		header := &wire.BlockHeader{}
+		if err := header.Deserialize(bytes.NewReader(headerBytes)); err != nil {
+			return nil, errors.Wrapf(err, "EsploraBitcoinHeaderSource.GetBitcoinHeaders: "+
+				"Problem parsing header at height %d: ", height)
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
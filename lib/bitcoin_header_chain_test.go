@@ -0,0 +1,261 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	merkletree "github.com/deso-protocol/go-merkle-tree"
+	"github.com/stretchr/testify/require"
+)
+
+// _testBitcoinHeaderSource serves headers from a fixed slice of Bitcoin headers.
+type _testBitcoinHeaderSource struct {
+	headers []*wire.BlockHeader
+}
+
+func (src *_testBitcoinHeaderSource) GetBitcoinHeaders(locator []*BlockHash, maxHeaders int) (
+	[]*wire.BlockHeader, error) {
+
+	for _, blockHash := range locator {
+		for ii, header := range src.headers {
+			if (BlockHash)(header.BlockHash()) != *blockHash {
+				continue
+			}
+			endIndex := ii + 1 + maxHeaders
+			if endIndex > len(src.headers) {
+				endIndex = len(src.headers)
+			}
+			return src.headers[ii+1 : endIndex], nil
+		}
+	}
+	return nil, nil
+}
+
+func _indexOfBitcoinHeader(headers []*wire.BlockHeader, blockHash *BlockHash) int {
+	for ii, header := range headers {
+		if (BlockHash)(header.BlockHash()) == *blockHash {
+			return ii
+		}
+	}
+	return -1
+}
+
+func TestBitcoinHeaderChain(t *testing.T) {
+	require := require.New(t)
+
+	_, params, db := NewLowDifficultyBlockchain()
+	_, bitcoinHeaders, bitcoinHeaderHeights := _readBitcoinExchangeTestData(t)
+	params = GetTestParamsCopy(bitcoinHeaders[0], bitcoinHeaderHeights[0], params, 0)
+
+	headerChain, err := NewBitcoinHeaderChain(db, params, nil, 0)
+	require.NoError(err)
+	require.Equal(*params.BitcoinStartBlockNode.Hash, *headerChain.Tip().Hash)
+	require.Equal(bitcoinHeaderHeights[0], headerChain.Tip().Height)
+
+	// A header whose parent we don't have should be rejected.
+	_, err = headerChain.ProcessBitcoinHeader(bitcoinHeaders[2])
+	require.Error(err)
+
+	// Processing a run of headers should move the tip to the last one.
+	require.NoError(headerChain.ProcessBitcoinHeaders(bitcoinHeaders[1:1000]))
+	require.Equal((BlockHash)(bitcoinHeaders[999].BlockHash()), *headerChain.Tip().Hash)
+	require.Equal(bitcoinHeaderHeights[999], headerChain.Tip().Height)
+	for _, ii := range []int{0, 1, 500, 999} {
+		blockHash := (BlockHash)(bitcoinHeaders[ii].BlockHash())
+		height, exists := DbGetBitcoinBestChainHeight(db, &blockHash)
+		require.True(exists)
+		require.Equal(bitcoinHeaderHeights[ii], height)
+		require.NotNil(headerChain.GetBestChainNode(&blockHash))
+	}
+	{
+		blockHash := (BlockHash)(bitcoinHeaders[1000].BlockHash())
+		_, exists := DbGetBitcoinBestChainHeight(db, &blockHash)
+		require.False(exists)
+		require.Nil(headerChain.GetBestChainNode(&blockHash))
+	}
+
+	// Processing a header we already have is a no-op.
+	isMainChain, err := headerChain.ProcessBitcoinHeader(bitcoinHeaders[500])
+	require.NoError(err)
+	require.True(isMainChain)
+
+	// A header that doesn't meet its target should be rejected.
+	{
+		badHeader := *bitcoinHeaders[1000]
+		badHeader.Nonce++
+		_, err = headerChain.ProcessBitcoinHeader(&badHeader)
+		require.Error(err)
+	}
+
+	// A header whose timestamp isn't past the median of its parents should be rejected.
+	{
+		badHeader := *bitcoinHeaders[1000]
+		badHeader.Timestamp = bitcoinHeaders[990].Timestamp
+		_, err = headerChain.ProcessBitcoinHeader(&badHeader)
+		require.Error(err)
+	}
+
+	// Reloading the chain from the db should give us the same tip.
+	reloadedChain, err := NewBitcoinHeaderChain(db, params, nil, 0)
+	require.NoError(err)
+	require.Equal(*headerChain.Tip().Hash, *reloadedChain.Tip().Hash)
+	require.NoError(reloadedChain.ProcessBitcoinHeaders(bitcoinHeaders[1000:1001]))
+	require.Equal(bitcoinHeaderHeights[1000], reloadedChain.Tip().Height)
+}
+
+func TestBitcoinHeaderChainSyncWithSource(t *testing.T) {
+	require := require.New(t)
+
+	_, params, db := NewLowDifficultyBlockchain()
+	_, bitcoinHeaders, bitcoinHeaderHeights := _readBitcoinExchangeTestData(t)
+	params = GetTestParamsCopy(bitcoinHeaders[0], bitcoinHeaderHeights[0], params, 0)
+
+	// Syncing without a source should fail.
+	{
+		headerChain, err := NewBitcoinHeaderChain(db, params, nil, 0)
+		require.NoError(err)
+		require.Error(headerChain.SyncWithSource())
+	}
+
+	source := &_testBitcoinHeaderSource{headers: bitcoinHeaders[:100]}
+	headerChain, err := NewBitcoinHeaderChain(db, params, source, 0)
+	require.NoError(err)
+
+	require.NoError(headerChain.SyncWithSource())
+	require.Equal(bitcoinHeaderHeights[99], headerChain.Tip().Height)
+
+	// The locator should start at the tip and end at the start node.
+	locator := headerChain.LatestLocator()
+	require.Equal(*headerChain.Tip().Hash, *locator[0])
+	require.Equal(*params.BitcoinStartBlockNode.Hash, *locator[len(locator)-1])
+	require.Less(len(locator), 30)
+
+	// Once the source has more headers a sync should pick all of them up, even
+	// if there are more than fit in a single request.
+	source.headers = bitcoinHeaders
+	require.NoError(headerChain.SyncWithSource())
+	require.Equal(bitcoinHeaderHeights[len(bitcoinHeaderHeights)-1], headerChain.Tip().Height)
+
+	// Syncing again when there's nothing new should leave the tip where it is.
+	require.NoError(headerChain.SyncWithSource())
+	require.Equal(bitcoinHeaderHeights[len(bitcoinHeaderHeights)-1], headerChain.Tip().Height)
+}
+
+func TestBitcoinExchangeSPV(t *testing.T) {
+	require := require.New(t)
+
+	oldInitialUSDCentsPerBitcoinExchangeRate := InitialUSDCentsPerBitcoinExchangeRate
+	InitialUSDCentsPerBitcoinExchangeRate = uint64(1350000)
+	defer func() {
+		InitialUSDCentsPerBitcoinExchangeRate = oldInitialUSDCentsPerBitcoinExchangeRate
+	}()
+
+	paramsTmp := DeSoTestnetParams
+	paramsTmp.DeSoNanosPurchasedAtGenesis = 0
+	chain, params, db := NewLowDifficultyBlockchainWithParams(&paramsTmp)
+	params.BitcoinMinNumConfirmations = 6
+
+	bitcoinBlocks, bitcoinHeaders, bitcoinHeaderHeights := _readBitcoinExchangeTestData(t)
+	headerParams := GetTestParamsCopy(bitcoinHeaders[0], bitcoinHeaderHeights[0], params, 0)
+	headerChain, err := NewBitcoinHeaderChain(db, headerParams, nil, 0)
+	require.NoError(err)
+
+	mempool := NewDeSoMempool(chain, 0, /* rateLimitFeeRateNanosPerKB */
+		0 /* minFeeRateNanosPerKB */, false, "" /*dataDir*/, "")
+	mempool.bitcoinHeaderChain = headerChain
+
+	burnTxns, err := ExtractBitcoinExchangeTransactionsFromBitcoinBlock(
+		bitcoinBlocks[1], BitcoinTestnetBurnAddress, params)
+	require.NoError(err)
+	require.NotEmpty(burnTxns)
+	burnTxn := burnTxns[0]
+	burnBlockIndex := _indexOfBitcoinHeader(bitcoinHeaders, burnTxn.TxnMeta.(*BitcoinExchangeMetadata).BitcoinBlockHash)
+	require.Greater(burnBlockIndex, 0)
+
+	_processBurn := func(txn *MsgDeSoTxn) error {
+		_, err := mempool.processTransaction(
+			txn, false /*allowUnconnectedTxn*/, false /*rateLimit*/, 0 /*peerID*/, true /*verifySignatures*/)
+		return err
+	}
+	blockHeight := chain.blockTip().Height + 1
+	_connectBurn := func(txn *MsgDeSoTxn, spvBlockHeight uint32) error {
+		connectParams := *params
+		connectParams.ForkHeights.BitcoinExchangeSPVBlockHeight = spvBlockHeight
+		utxoView, err := NewUtxoView(db, &connectParams, nil)
+		require.NoError(err)
+		_, _, _, _, err = utxoView.ConnectTransaction(
+			txn, txn.Hash(), getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+		return err
+	}
+
+	// Before the fork, connecting the burn doesn't depend on our Bitcoin headers.
+	require.NoError(_connectBurn(burnTxn, blockHeight+1))
+
+	// The burn's block isn't in the header chain yet so neither the mempool nor a
+	// block past the fork should take it. That's something we can retry once we have
+	// more headers, so it isn't a RuleError.
+	err = _processBurn(burnTxn)
+	require.Error(err)
+	require.Contains(err.Error(), "is not on our best Bitcoin header chain")
+	require.False(IsRuleError(err))
+	err = _connectBurn(burnTxn, blockHeight)
+	require.Error(err)
+	require.Contains(err.Error(), "is not on our best Bitcoin header chain")
+	require.False(IsRuleError(err))
+
+	// With only five blocks on the burn's block, counting itself, there aren't
+	// enough confirmations.
+	require.NoError(headerChain.ProcessBitcoinHeaders(bitcoinHeaders[1 : burnBlockIndex+5]))
+	err = _processBurn(burnTxn)
+	require.Error(err)
+	require.Contains(err.Error(), "confirmations but 6 are required")
+	require.False(IsRuleError(err))
+	err = _connectBurn(burnTxn, blockHeight)
+	require.Error(err)
+	require.Contains(err.Error(), "confirmations but 6 are required")
+	require.False(IsRuleError(err))
+
+	// A merkle root that doesn't match the header should fail, even if the proof is
+	// consistent with it.
+	{
+		badTxn := *burnTxn
+		badMeta := *burnTxn.TxnMeta.(*BitcoinExchangeMetadata)
+		badMerkleRoot := (BlockHash)(bitcoinBlocks[0].Header.MerkleRoot)
+		badMeta.BitcoinMerkleRoot = &badMerkleRoot
+		badTxn.TxnMeta = &badMeta
+		err = _processBurn(&badTxn)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorBitcoinExchangeHasBadMerkleRoot)
+		err = _connectBurn(&badTxn, blockHeight)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorBitcoinExchangeHasBadMerkleRoot)
+	}
+
+	// A tampered merkle proof should fail.
+	{
+		badTxn := *burnTxn
+		badMeta := *burnTxn.TxnMeta.(*BitcoinExchangeMetadata)
+		require.NotEmpty(badMeta.BitcoinMerkleProof)
+		badProof := []*merkletree.ProofPart{}
+		for _, proofPart := range badMeta.BitcoinMerkleProof {
+			partCopy := *proofPart
+			partCopy.Hash = append([]byte{}, proofPart.Hash...)
+			badProof = append(badProof, &partCopy)
+		}
+		badProof[0].Hash[0] ^= 0xff
+		badMeta.BitcoinMerkleProof = badProof
+		badTxn.TxnMeta = &badMeta
+		err = _processBurn(&badTxn)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorBitcoinExchangeInvalidMerkleProof)
+		err = _connectBurn(&badTxn, blockHeight)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorBitcoinExchangeInvalidMerkleProof)
+	}
+
+	// A sixth block should let it into the mempool and into blocks past the fork.
+	require.NoError(headerChain.ProcessBitcoinHeaders(bitcoinHeaders[burnBlockIndex+5 : burnBlockIndex+6]))
+	require.NoError(_connectBurn(burnTxn, blockHeight))
+	require.NoError(_processBurn(burnTxn))
+	require.Equal(1, len(mempool.poolMap))
+}
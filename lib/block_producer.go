@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"github.com/btcsuite/btcd/wire"
@@ -117,6 +118,23 @@ func (desoBlockProducer *DeSoBlockProducer) _updateBlockTimestamp(blk *MsgDeSoBl
 	blk.Header.TstampSecs = uint64(blockTstamp)
 }
 
+// _settleNFTAuctionsForTemplate applies the NFT auction settlements that the block
+// reward of blockRet will perform so that txns are checked against the same state
+// they will see when the block is connected.
+func (desoBlockProducer *DeSoBlockProducer) _settleNFTAuctionsForTemplate(
+	utxoView *UtxoView, blockRet *MsgDeSoBlock) error {
+
+	blockHeight := uint32(blockRet.Header.Height)
+	if blockHeight < desoBlockProducer.params.ForkHeights.NFTAuctionsBlockHeight {
+		return nil
+	}
+	blockRewardTxn := blockRet.Txns[0]
+	if _, err := utxoView._connectNFTAuctionSettlements(blockRewardTxn, blockRewardTxn.Hash(), blockHeight); err != nil {
+		return errors.Wrapf(err, "_settleNFTAuctionsForTemplate: Problem settling NFT auctions: ")
+	}
+	return nil
+}
+
 func (desoBlockProducer *DeSoBlockProducer) _getBlockTemplate(publicKey []byte) (
 	_blk *MsgDeSoBlock, _diffTarget *BlockHash, _lastNode *BlockNode, _err error) {
 
@@ -203,6 +221,11 @@ func (desoBlockProducer *DeSoBlockProducer) _getBlockTemplate(publicKey []byte)
 			return nil, nil, nil, errors.Wrapf(err,
 				"DeSoBlockProducer._getBlockTemplate: Error generating checker UtxoView: ")
 		}
+		// NFT auctions that end at this height are settled by the block reward before
+		// any other txn in the block is connected, so settle them here as well.
+		if err = desoBlockProducer._settleNFTAuctionsForTemplate(utxoView, blockRet); err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "DeSoBlockProducer._getBlockTemplate: ")
+		}
 
 		txnsAddedToBlock := make(map[BlockHash]bool)
 		for ii, mempoolTx := range txnsOrderedByTimeAdded {
@@ -288,6 +311,9 @@ func (desoBlockProducer *DeSoBlockProducer) _getBlockTemplate(publicKey []byte)
 		return nil, nil, nil, fmt.Errorf(
 			"DeSoBlockProducer._getBlockTemplate: Error generating UtxoView to compute txn fees: %v", err)
 	}
+	if err = desoBlockProducer._settleNFTAuctionsForTemplate(feesUtxoView, blockRet); err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "DeSoBlockProducer._getBlockTemplate: ")
+	}
 	// Skip the block reward, which is the first txn in the block.
 	for _, txnInBlock := range blockRet.Txns[1:] {
 		var feeNanos uint64
@@ -409,6 +435,19 @@ func (desoBlockProducer *DeSoBlockProducer) AddBlockTemplate(block *MsgDeSoBlock
 	}
 }
 
+// GetLatestBlockTemplateHash returns the hash of the most recent block template
+// added to the cache, or nil if no template has been produced yet.
+func (desoBlockProducer *DeSoBlockProducer) GetLatestBlockTemplateHash() *BlockHash {
+	desoBlockProducer.mtxRecentBlockTemplatesProduced.RLock()
+	defer desoBlockProducer.mtxRecentBlockTemplatesProduced.RUnlock()
+
+	if desoBlockProducer.latestBlockTemplateHash == nil {
+		return nil
+	}
+	latestBlockTemplateHash := *desoBlockProducer.latestBlockTemplateHash
+	return &latestBlockTemplateHash
+}
+
 func (blockProducer *DeSoBlockProducer) GetHeadersAndExtraDatas(
 	publicKeyBytes []byte, numHeaders int64, headerVersion uint32) (
 	_blockID string, _headers [][]byte, _extraNonces []uint64, _diffTarget *BlockHash, _err error) {
@@ -482,11 +521,13 @@ func (blockProducer *DeSoBlockProducer) GetHeadersAndExtraDatas(
 
 func (desoBlockProducer *DeSoBlockProducer) UpdateLatestBlockTemplate() error {
 	// Use a dummy public key.
+	buildStart := time.Now()
 	currentBlockTemplate, diffTarget, lastNode, err :=
 		desoBlockProducer._getBlockTemplate(MustBase58CheckDecode(ArchitectPubKeyBase58Check))
 	if err != nil {
 		return err
 	}
+	observeSecondsSince(metricBlockTemplateBuildSeconds, buildStart)
 
 	// Log the results.
 	glog.V(1).Infof("Produced block template with difficulty target %v "+
@@ -510,6 +551,13 @@ func (desoBlockProducer *DeSoBlockProducer) SignBlock(blockFound *MsgDeSoBlock)
 			fmt.Errorf("Error computing block hash from header submitted: %v", err), "")
 	}
 
+	// Once the block producer schedule is active, only the scheduled producer may
+	// sign the block. Refuse to sign rather than producing a block that will be
+	// rejected when it's connected.
+	if err := desoBlockProducer._checkScheduledBlockProducer(blockFound); err != nil {
+		return errors.Wrapf(err, "SignBlock: ")
+	}
+
 	signature, err := desoBlockProducer.blockProducerPrivateKey.Sign(blockHash[:])
 	if err != nil {
 		return errors.Wrap(
@@ -526,6 +574,45 @@ func (desoBlockProducer *DeSoBlockProducer) SignBlock(blockFound *MsgDeSoBlock)
 	return nil
 }
 
+// _checkScheduledBlockProducer returns an error if the block producer schedule is
+// active and this producer isn't one of the producers that may sign the block. The schedule
+// can only be computed against the current tip, so blocks built on any other parent
+// are left for ConnectBlock to check.
+func (desoBlockProducer *DeSoBlockProducer) _checkScheduledBlockProducer(blockFound *MsgDeSoBlock) error {
+	chain := desoBlockProducer.chain
+	chain.ChainLock.RLock()
+	defer chain.ChainLock.RUnlock()
+
+	tipNode := chain.blockTip()
+	if tipNode == nil || blockFound.Header.PrevBlockHash == nil ||
+		*tipNode.Hash != *blockFound.Header.PrevBlockHash {
+		return nil
+	}
+
+	utxoView, err := NewUtxoView(chain.db, desoBlockProducer.params, chain.postgres)
+	if err != nil {
+		return errors.Wrapf(err, "Problem creating UtxoView: ")
+	}
+	scheduledEntries, err := utxoView.GetScheduledBlockProducers(
+		blockFound.Header, tipNode.Header.TstampSecs)
+	if err != nil {
+		return errors.Wrapf(err, "Problem computing scheduled block producers: ")
+	}
+	if len(scheduledEntries) == 0 {
+		return nil
+	}
+
+	publicKey := desoBlockProducer.blockProducerPrivateKey.PubKey().SerializeCompressed()
+	for _, scheduledEntry := range scheduledEntries {
+		if bytes.Equal(scheduledEntry.PublicKey, publicKey) {
+			return nil
+		}
+	}
+	return errors.Wrapf(RuleErrorBlockProducerNotScheduled, "Scheduled producer is %v, not %v",
+		PkToString(scheduledEntries[0].PublicKey, desoBlockProducer.params),
+		PkToString(publicKey, desoBlockProducer.params))
+}
+
 func (desoBlockProducer *DeSoBlockProducer) Start() {
 	// Set the time to a nil value so we run on the first iteration of the loop.
 	var lastBlockUpdate time.Time
@@ -0,0 +1,133 @@
+package lib
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// The maximum number of blocks whose bodies and UtxoOperations we delete in a
+// single badger transaction. Keeping this small prevents us from hitting badger's
+// transaction size limits and from holding the ChainLock for too long.
+const MaxBlocksToPrunePerTxn = 100
+
+// BlockPruner runs in the background on nodes started with --prune-blocks. It
+// deletes the block bodies, UtxoOperations and block position txn entries of
+// main chain blocks that are more than pruneDepth blocks behind the tip. The
+// BlockNodes themselves are kept so that headers can still be served and the
+// block index can still be loaded.
+type BlockPruner struct {
+	chain      *Blockchain
+	pruneDepth uint64
+
+	// Update wait group
+	updateWaitGroup sync.WaitGroup
+
+	// Shutdown channel
+	stopUpdateChannel chan struct{}
+}
+
+func NewBlockPruner(chain *Blockchain, pruneDepth uint64) (*BlockPruner, error) {
+	if pruneDepth == 0 {
+		return nil, fmt.Errorf("NewBlockPruner: pruneDepth must be greater than zero")
+	}
+	if chain.postgres != nil {
+		return nil, fmt.Errorf("NewBlockPruner: Pruning is not supported when running with Postgres")
+	}
+
+	return &BlockPruner{
+		chain:             chain,
+		pruneDepth:        pruneDepth,
+		stopUpdateChannel: make(chan struct{}),
+	}, nil
+}
+
+func (bp *BlockPruner) Start() {
+	glog.Infof("BlockPruner: Starting prune thread with depth %d", bp.pruneDepth)
+
+	// Add to the wait group before starting the goroutine so that a Stop() right
+	// after Start() can't Wait() before the goroutine has been counted.
+	bp.updateWaitGroup.Add(1)
+	go func() {
+		for {
+			select {
+			case <-bp.stopUpdateChannel:
+				bp.updateWaitGroup.Done()
+				return
+			default:
+				if bp.chain.ChainState() == SyncStateFullyCurrent {
+					if _, err := bp.PruneOnce(); err != nil {
+						glog.Error(fmt.Errorf("BlockPruner: Problem pruning blocks: %v", err))
+					}
+				}
+				break
+			}
+
+			time.Sleep(1 * time.Second)
+		}
+	}()
+}
+
+func (bp *BlockPruner) Stop() {
+	glog.Info("BlockPruner: Stopping prune thread")
+
+	bp.stopUpdateChannel <- struct{}{}
+	bp.updateWaitGroup.Wait()
+}
+
+// PruneOnce deletes at most MaxBlocksToPrunePerTxn block bodies and their
+// UtxoOperations, starting at the current pruned height. It returns the number
+// of blocks that were pruned.
+func (bp *BlockPruner) PruneOnce() (_numPruned int, _err error) {
+	// Hold the read lock so that ProcessBlock can't reorg the blocks we're
+	// deleting out from under us. ProcessBlock consults the pruned height
+	// under the write lock so it always sees a consistent value.
+	bp.chain.ChainLock.RLock()
+	defer bp.chain.ChainLock.RUnlock()
+
+	tip := bp.chain.blockTip()
+	if tip == nil || uint64(tip.Height) <= bp.pruneDepth {
+		return 0, nil
+	}
+	pruneUpToHeight := uint64(tip.Height) - bp.pruneDepth
+
+	// Never prune the genesis block.
+	startHeight := DbGetPrunedBlockHeight(bp.chain.db)
+	if startHeight == 0 {
+		startHeight = 1
+	}
+	if startHeight >= pruneUpToHeight {
+		return 0, nil
+	}
+	endHeight := startHeight + MaxBlocksToPrunePerTxn
+	if endHeight > pruneUpToHeight {
+		endHeight = pruneUpToHeight
+	}
+
+	err := bp.chain.db.Update(func(txn *badger.Txn) error {
+		for height := startHeight; height < endHeight; height++ {
+			nodeToPrune := bp.chain.bestChain[height]
+			if err := DeleteBlockBodyWithTxn(txn, nodeToPrune.Hash); err != nil {
+				return errors.Wrapf(err, "PruneOnce: Problem deleting block %v", nodeToPrune)
+			}
+			if err := DeleteUtxoOperationsForBlockWithTxn(txn, nodeToPrune.Hash); err != nil {
+				return errors.Wrapf(err, "PruneOnce: Problem deleting utxo ops for block %v", nodeToPrune)
+			}
+			// The position index holds a copy of every txn, so it has to go too.
+			if err := DbDeleteBlockPositionTxnsForHeightWithTxn(txn, height); err != nil {
+				return errors.Wrapf(err, "PruneOnce: Problem deleting block position txns for block %v", nodeToPrune)
+			}
+		}
+		return DbPutPrunedBlockHeightWithTxn(txn, endHeight)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	glog.V(1).Infof("BlockPruner: Pruned blocks from height %d to %d", startHeight, endHeight)
+	return int(endHeight - startHeight), nil
+}
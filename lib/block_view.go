@@ -46,17 +46,48 @@ type UtxoView struct {
 	// Messaging group entries.
 	MessagingGroupKeyToMessagingGroupEntry map[MessagingGroupKey]*MessagingGroupEntry
 
+	// Message read markers.
+	MessageReadKeyToMessageReadEntry map[MessageReadKey]*MessageReadEntry
+
 	// Postgres stores message data slightly differently
 	MessageMap map[BlockHash]*PGMessage
 
 	// Follow data
 	FollowKeyToFollowEntry map[FollowKey]*FollowEntry
 
+	// User block data
+	UserBlockKeyToUserBlockEntry map[UserBlockKey]*UserBlockEntry
+
 	// NFT data
 	NFTKeyToNFTEntry              map[NFTKey]*NFTEntry
 	NFTBidKeyToNFTBidEntry        map[NFTBidKey]*NFTBidEntry
 	NFTKeyToAcceptedNFTBidHistory map[NFTKey]*[]*NFTBidEntry
 
+	// NFT collection data
+	NFTCollectionIDToNFTCollectionEntry map[BlockHash]*NFTCollectionEntry
+
+	// NFT voucher data
+	NFTKeyToNFTVoucherRedemptionEntry map[NFTKey]*NFTVoucherRedemptionEntry
+
+	// Block producer data
+	PublicKeyToBlockProducerEntry map[PkMapKey]*BlockProducerEntry
+
+	// Username listing data
+	UsernameToUsernameListingEntry map[UsernameMapKey]*UsernameListingEntry
+
+	// Pending key rotation data
+	PublicKeyToKeyRotationEntry map[PkMapKey]*KeyRotationEntry
+
+	// Key rotation timelock data
+	PKIDToKeyRotationTimelockEntry map[PKID]*KeyRotationTimelockEntry
+
+	// Rotated public key data
+	PublicKeyToRotatedPublicKeyEntry map[PkMapKey]*RotatedPublicKeyEntry
+
+	// Account recovery data
+	PKIDToRecoveryGuardianSetEntry map[PKID]*RecoveryGuardianSetEntry
+	PKIDToAccountRecoveryEntry     map[PKID]*AccountRecoveryEntry
+
 	// Diamond data
 	DiamondKeyToDiamondEntry map[DiamondKey]*DiamondEntry
 
@@ -69,6 +100,13 @@ type UtxoView struct {
 	// Post data
 	PostHashToPostEntry map[BlockHash]*PostEntry
 
+	// Post edit history
+	PostVersionKeyToPostVersionEntry map[PostVersionKey]*PostVersionEntry
+
+	// Poll data
+	PostHashToPollEntry        map[BlockHash]*PollEntry
+	PollVoteKeyToPollVoteEntry map[PollVoteKey]*PollVoteEntry
+
 	// Profile data
 	PublicKeyToPKIDEntry map[PkMapKey]*PKIDEntry
 	// The PKIDEntry is only used here to store the public key.
@@ -82,6 +120,9 @@ type UtxoView struct {
 	// DAO coin balance entries
 	HODLerPKIDCreatorPKIDToDAOCoinBalanceEntry map[BalanceEntryMapKey]*BalanceEntry
 
+	// Creator coin price candles
+	CreatorCoinPriceCandleKeyToCandleEntry map[CreatorCoinPriceCandleKey]*CreatorCoinPriceCandleEntry
+
 	// Derived Key entries. Map key is a combination of owner and derived public keys.
 	DerivedKeyToDerivedEntry map[DerivedKeyMapKey]*DerivedKeyEntry
 
@@ -114,6 +155,9 @@ func (bav *UtxoView) _ResetViewMappingsAfterFlush() {
 
 	// Post and profile data
 	bav.PostHashToPostEntry = make(map[BlockHash]*PostEntry)
+	bav.PostVersionKeyToPostVersionEntry = make(map[PostVersionKey]*PostVersionEntry)
+	bav.PostHashToPollEntry = make(map[BlockHash]*PollEntry)
+	bav.PollVoteKeyToPollVoteEntry = make(map[PollVoteKey]*PollVoteEntry)
 	bav.PublicKeyToPKIDEntry = make(map[PkMapKey]*PKIDEntry)
 	bav.PKIDToPublicKey = make(map[PKID]*PKIDEntry)
 	bav.ProfilePKIDToProfileEntry = make(map[PKID]*ProfileEntry)
@@ -126,14 +170,45 @@ func (bav *UtxoView) _ResetViewMappingsAfterFlush() {
 	// Messaging group entries
 	bav.MessagingGroupKeyToMessagingGroupEntry = make(map[MessagingGroupKey]*MessagingGroupEntry)
 
+	// Message read markers
+	bav.MessageReadKeyToMessageReadEntry = make(map[MessageReadKey]*MessageReadEntry)
+
 	// Follow data
 	bav.FollowKeyToFollowEntry = make(map[FollowKey]*FollowEntry)
 
+	// User block data
+	bav.UserBlockKeyToUserBlockEntry = make(map[UserBlockKey]*UserBlockEntry)
+
 	// NFT data
 	bav.NFTKeyToNFTEntry = make(map[NFTKey]*NFTEntry)
 	bav.NFTBidKeyToNFTBidEntry = make(map[NFTBidKey]*NFTBidEntry)
 	bav.NFTKeyToAcceptedNFTBidHistory = make(map[NFTKey]*[]*NFTBidEntry)
 
+	// NFT collection data
+	bav.NFTCollectionIDToNFTCollectionEntry = make(map[BlockHash]*NFTCollectionEntry)
+
+	// NFT voucher data
+	bav.NFTKeyToNFTVoucherRedemptionEntry = make(map[NFTKey]*NFTVoucherRedemptionEntry)
+
+	// Block producer data
+	bav.PublicKeyToBlockProducerEntry = make(map[PkMapKey]*BlockProducerEntry)
+
+	// Username listing data
+	bav.UsernameToUsernameListingEntry = make(map[UsernameMapKey]*UsernameListingEntry)
+
+	// Pending key rotation data
+	bav.PublicKeyToKeyRotationEntry = make(map[PkMapKey]*KeyRotationEntry)
+
+	// Key rotation timelock data
+	bav.PKIDToKeyRotationTimelockEntry = make(map[PKID]*KeyRotationTimelockEntry)
+
+	// Rotated public key data
+	bav.PublicKeyToRotatedPublicKeyEntry = make(map[PkMapKey]*RotatedPublicKeyEntry)
+
+	// Account recovery data
+	bav.PKIDToRecoveryGuardianSetEntry = make(map[PKID]*RecoveryGuardianSetEntry)
+	bav.PKIDToAccountRecoveryEntry = make(map[PKID]*AccountRecoveryEntry)
+
 	// Diamond data
 	bav.DiamondKeyToDiamondEntry = make(map[DiamondKey]*DiamondEntry)
 
@@ -149,6 +224,9 @@ func (bav *UtxoView) _ResetViewMappingsAfterFlush() {
 	// DAO Coin Balance Entries
 	bav.HODLerPKIDCreatorPKIDToDAOCoinBalanceEntry = make(map[BalanceEntryMapKey]*BalanceEntry)
 
+	// Creator Coin Price Candles
+	bav.CreatorCoinPriceCandleKeyToCandleEntry = make(map[CreatorCoinPriceCandleKey]*CreatorCoinPriceCandleEntry)
+
 	// Derived Key entries
 	bav.DerivedKeyToDerivedEntry = make(map[DerivedKeyMapKey]*DerivedKeyEntry)
 }
@@ -198,6 +276,25 @@ func (bav *UtxoView) CopyUtxoView() (*UtxoView, error) {
 		newView.PostHashToPostEntry[postHash] = &newPostEntry
 	}
 
+	// Copy the post version data
+	newView.PostVersionKeyToPostVersionEntry = make(
+		map[PostVersionKey]*PostVersionEntry, len(bav.PostVersionKeyToPostVersionEntry))
+	for postVersionKey, postVersionEntry := range bav.PostVersionKeyToPostVersionEntry {
+		newPostVersionEntry := *postVersionEntry
+		newView.PostVersionKeyToPostVersionEntry[postVersionKey] = &newPostVersionEntry
+	}
+
+	// Copy the poll data
+	newView.PostHashToPollEntry = make(map[BlockHash]*PollEntry, len(bav.PostHashToPollEntry))
+	for postHash, pollEntry := range bav.PostHashToPollEntry {
+		newView.PostHashToPollEntry[postHash] = pollEntry.Copy()
+	}
+	newView.PollVoteKeyToPollVoteEntry = make(map[PollVoteKey]*PollVoteEntry, len(bav.PollVoteKeyToPollVoteEntry))
+	for pollVoteKey, pollVoteEntry := range bav.PollVoteKeyToPollVoteEntry {
+		newPollVoteEntry := *pollVoteEntry
+		newView.PollVoteKeyToPollVoteEntry[pollVoteKey] = &newPollVoteEntry
+	}
+
 	// Copy the PKID data
 	newView.PublicKeyToPKIDEntry = make(map[PkMapKey]*PKIDEntry, len(bav.PublicKeyToPKIDEntry))
 	for pkMapKey, pkid := range bav.PublicKeyToPKIDEntry {
@@ -251,6 +348,13 @@ func (bav *UtxoView) CopyUtxoView() (*UtxoView, error) {
 		newView.MessagingGroupKeyToMessagingGroupEntry[pkid] = &newEntry
 	}
 
+	// Copy message read markers
+	newView.MessageReadKeyToMessageReadEntry = make(map[MessageReadKey]*MessageReadEntry, len(bav.MessageReadKeyToMessageReadEntry))
+	for messageReadKey, messageReadEntry := range bav.MessageReadKeyToMessageReadEntry {
+		newMessageReadEntry := *messageReadEntry
+		newView.MessageReadKeyToMessageReadEntry[messageReadKey] = &newMessageReadEntry
+	}
+
 	// Copy the follow data
 	newView.FollowKeyToFollowEntry = make(map[FollowKey]*FollowEntry, len(bav.FollowKeyToFollowEntry))
 	for followKey, followEntry := range bav.FollowKeyToFollowEntry {
@@ -262,6 +366,13 @@ func (bav *UtxoView) CopyUtxoView() (*UtxoView, error) {
 		newView.FollowKeyToFollowEntry[followKey] = &newFollowEntry
 	}
 
+	// Copy the user block data
+	newView.UserBlockKeyToUserBlockEntry = make(map[UserBlockKey]*UserBlockEntry, len(bav.UserBlockKeyToUserBlockEntry))
+	for userBlockKey, userBlockEntry := range bav.UserBlockKeyToUserBlockEntry {
+		newUserBlockEntry := *userBlockEntry
+		newView.UserBlockKeyToUserBlockEntry[userBlockKey] = &newUserBlockEntry
+	}
+
 	// Copy the like data
 	newView.LikeKeyToLikeEntry = make(map[LikeKey]*LikeEntry, len(bav.LikeKeyToLikeEntry))
 	for likeKey, likeEntry := range bav.LikeKeyToLikeEntry {
@@ -304,6 +415,14 @@ func (bav *UtxoView) CopyUtxoView() (*UtxoView, error) {
 		newView.HODLerPKIDCreatorPKIDToDAOCoinBalanceEntry[daoBalanceEntryMapKey] = &newDAOBalanceEntry
 	}
 
+	// Copy the creator coin price candle data
+	newView.CreatorCoinPriceCandleKeyToCandleEntry = make(
+		map[CreatorCoinPriceCandleKey]*CreatorCoinPriceCandleEntry, len(bav.CreatorCoinPriceCandleKeyToCandleEntry))
+	for candleKey, candleEntry := range bav.CreatorCoinPriceCandleKeyToCandleEntry {
+		newCandleEntry := *candleEntry
+		newView.CreatorCoinPriceCandleKeyToCandleEntry[candleKey] = &newCandleEntry
+	}
+
 	// Copy the Diamond data
 	newView.DiamondKeyToDiamondEntry = make(
 		map[DiamondKey]*DiamondEntry, len(bav.DiamondKeyToDiamondEntry))
@@ -331,6 +450,67 @@ func (bav *UtxoView) CopyUtxoView() (*UtxoView, error) {
 		newView.NFTKeyToAcceptedNFTBidHistory[nftKey] = &newNFTBidEntries
 	}
 
+	// Copy the NFT collection data
+	newView.NFTCollectionIDToNFTCollectionEntry = make(map[BlockHash]*NFTCollectionEntry, len(bav.NFTCollectionIDToNFTCollectionEntry))
+	for collectionID, nftCollectionEntry := range bav.NFTCollectionIDToNFTCollectionEntry {
+		newNFTCollectionEntry := *nftCollectionEntry
+		newView.NFTCollectionIDToNFTCollectionEntry[collectionID] = &newNFTCollectionEntry
+	}
+
+	// Copy the NFT voucher data
+	newView.NFTKeyToNFTVoucherRedemptionEntry = make(map[NFTKey]*NFTVoucherRedemptionEntry, len(bav.NFTKeyToNFTVoucherRedemptionEntry))
+	for nftKey, redemptionEntry := range bav.NFTKeyToNFTVoucherRedemptionEntry {
+		newRedemptionEntry := *redemptionEntry
+		newView.NFTKeyToNFTVoucherRedemptionEntry[nftKey] = &newRedemptionEntry
+	}
+
+	// Copy the block producer data
+	newView.PublicKeyToBlockProducerEntry = make(map[PkMapKey]*BlockProducerEntry, len(bav.PublicKeyToBlockProducerEntry))
+	for pkMapKey, blockProducerEntry := range bav.PublicKeyToBlockProducerEntry {
+		newBlockProducerEntry := *blockProducerEntry
+		newView.PublicKeyToBlockProducerEntry[pkMapKey] = &newBlockProducerEntry
+	}
+
+	// Copy the username listing data
+	newView.UsernameToUsernameListingEntry = make(map[UsernameMapKey]*UsernameListingEntry, len(bav.UsernameToUsernameListingEntry))
+	for usernameMapKey, listingEntry := range bav.UsernameToUsernameListingEntry {
+		newListingEntry := *listingEntry
+		newView.UsernameToUsernameListingEntry[usernameMapKey] = &newListingEntry
+	}
+
+	// Copy the pending key rotation data
+	newView.PublicKeyToKeyRotationEntry = make(map[PkMapKey]*KeyRotationEntry, len(bav.PublicKeyToKeyRotationEntry))
+	for pkMapKey, keyRotationEntry := range bav.PublicKeyToKeyRotationEntry {
+		newKeyRotationEntry := *keyRotationEntry
+		newView.PublicKeyToKeyRotationEntry[pkMapKey] = &newKeyRotationEntry
+	}
+
+	// Copy the key rotation timelock data
+	newView.PKIDToKeyRotationTimelockEntry = make(map[PKID]*KeyRotationTimelockEntry, len(bav.PKIDToKeyRotationTimelockEntry))
+	for pkid, timelockEntry := range bav.PKIDToKeyRotationTimelockEntry {
+		newTimelockEntry := *timelockEntry
+		newView.PKIDToKeyRotationTimelockEntry[pkid] = &newTimelockEntry
+	}
+
+	// Copy the rotated public key data
+	newView.PublicKeyToRotatedPublicKeyEntry = make(map[PkMapKey]*RotatedPublicKeyEntry, len(bav.PublicKeyToRotatedPublicKeyEntry))
+	for pkMapKey, rotatedPublicKeyEntry := range bav.PublicKeyToRotatedPublicKeyEntry {
+		newRotatedPublicKeyEntry := *rotatedPublicKeyEntry
+		newView.PublicKeyToRotatedPublicKeyEntry[pkMapKey] = &newRotatedPublicKeyEntry
+	}
+
+	// Copy the account recovery data
+	newView.PKIDToRecoveryGuardianSetEntry = make(map[PKID]*RecoveryGuardianSetEntry, len(bav.PKIDToRecoveryGuardianSetEntry))
+	for pkid, guardianSetEntry := range bav.PKIDToRecoveryGuardianSetEntry {
+		newGuardianSetEntry := *guardianSetEntry
+		newView.PKIDToRecoveryGuardianSetEntry[pkid] = &newGuardianSetEntry
+	}
+	newView.PKIDToAccountRecoveryEntry = make(map[PKID]*AccountRecoveryEntry, len(bav.PKIDToAccountRecoveryEntry))
+	for pkid, accountRecoveryEntry := range bav.PKIDToAccountRecoveryEntry {
+		newAccountRecoveryEntry := *accountRecoveryEntry
+		newView.PKIDToAccountRecoveryEntry[pkid] = &newAccountRecoveryEntry
+	}
+
 	// Copy the Derived Key data
 	newView.DerivedKeyToDerivedEntry = make(map[DerivedKeyMapKey]*DerivedKeyEntry, len(bav.DerivedKeyToDerivedEntry))
 	for entryKey, entry := range bav.DerivedKeyToDerivedEntry {
@@ -682,7 +862,7 @@ func (bav *UtxoView) _disconnectBasicTransfer(currentTxn *MsgDeSoTxn, txnHash *B
 
 		// Get the existing diamondEntry so we can delete it.
 		senderPKID := bav.GetPKIDForPublicKey(currentTxn.PublicKey)
-		receiverPKID := bav.GetPKIDForPublicKey(diamondedPostEntry.PosterPublicKey)
+		receiverPKID := bav.GetPKIDForPublicKey(bav.GetCurrentPublicKey(diamondedPostEntry.PosterPublicKey))
 		diamondKey := MakeDiamondKey(senderPKID.PKID, receiverPKID.PKID, diamondPostHash)
 		diamondEntry := bav.GetDiamondEntryForDiamondKey(&diamondKey)
 
@@ -860,6 +1040,15 @@ func (bav *UtxoView) DisconnectTransaction(currentTxn *MsgDeSoTxn, txnHash *Bloc
 	utxoOpsForTxn []*UtxoOperation, blockHeight uint32) error {
 
 	if currentTxn.TxnMeta.GetTxnType() == TxnTypeBlockReward || currentTxn.TxnMeta.GetTxnType() == TxnTypeBasicTransfer {
+		// A block reward may carry NFT auction settlements at the end of its operations.
+		// Revert those before reverting the block reward itself.
+		if currentTxn.TxnMeta.GetTxnType() == TxnTypeBlockReward {
+			var err error
+			utxoOpsForTxn, err = bav._disconnectNFTAuctionSettlements(utxoOpsForTxn)
+			if err != nil {
+				return errors.Wrapf(err, "DisconnectTransaction: ")
+			}
+		}
 		return bav._disconnectBasicTransfer(
 			currentTxn, txnHash, utxoOpsForTxn, blockHeight)
 
@@ -875,6 +1064,58 @@ func (bav *UtxoView) DisconnectTransaction(currentTxn *MsgDeSoTxn, txnHash *Bloc
 		return bav._disconnectMessagingGroup(
 			OperationTypeMessagingKey, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
 
+	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypeMessagingGroupUpdate {
+		return bav._disconnectMessagingGroupUpdate(
+			OperationTypeMessagingGroupUpdate, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
+
+	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypeMessageRead {
+		return bav._disconnectMessageRead(
+			OperationTypeMessageRead, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
+
+	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypeCreateNFTCollection {
+		return bav._disconnectCreateNFTCollection(
+			OperationTypeCreateNFTCollection, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
+
+	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypeNFTVault {
+		return bav._disconnectNFTVault(
+			OperationTypeNFTVault, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
+
+	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypeRedeemNFTVoucher {
+		return bav._disconnectRedeemNFTVoucher(
+			OperationTypeRedeemNFTVoucher, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
+
+	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypeRegisterBlockProducer {
+		return bav._disconnectRegisterBlockProducer(
+			OperationTypeRegisterBlockProducer, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
+
+	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypeDeregisterBlockProducer {
+		return bav._disconnectDeregisterBlockProducer(
+			OperationTypeDeregisterBlockProducer, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
+
+	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypeClaimBlockProducerBond {
+		return bav._disconnectClaimBlockProducerBond(
+			OperationTypeClaimBlockProducerBond, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
+
+	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypeUsernameListing {
+		return bav._disconnectUsernameListing(
+			OperationTypeUsernameListing, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
+
+	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypeAcceptUsernameListing {
+		return bav._disconnectAcceptUsernameListing(
+			OperationTypeAcceptUsernameListing, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
+
+	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypeKeyRotation {
+		return bav._disconnectKeyRotation(
+			OperationTypeKeyRotation, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
+
+	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypeRecoveryGuardians {
+		return bav._disconnectRecoveryGuardians(
+			OperationTypeRecoveryGuardians, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
+
+	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypeAccountRecovery {
+		return bav._disconnectAccountRecovery(
+			OperationTypeAccountRecovery, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
+
 	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypeSubmitPost {
 		return bav._disconnectSubmitPost(
 			OperationTypeSubmitPost, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
@@ -915,6 +1156,14 @@ func (bav *UtxoView) DisconnectTransaction(currentTxn *MsgDeSoTxn, txnHash *Bloc
 		return bav._disconnectDAOCoinTransfer(
 			OperationTypeDAOCoinTransfer, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
 
+	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypePollVote {
+		return bav._disconnectPollVote(
+			OperationTypePollVote, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
+
+	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypeUserBlock {
+		return bav._disconnectUserBlock(
+			OperationTypeUserBlock, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
+
 	} else if currentTxn.TxnMeta.GetTxnType() == TxnTypeSwapIdentity {
 		return bav._disconnectSwapIdentity(
 			OperationTypeSwapIdentity, currentTxn, txnHash, utxoOpsForTxn, blockHeight)
@@ -1012,6 +1261,14 @@ func (bav *UtxoView) DisconnectBlock(
 				"utxoOps (%d)", numOutputs, numAddOps)
 	}
 
+	// The block producer schedule was applied before any of the block's txns, so it
+	// is reverted after all of them. Its operations sit at the end of the block
+	// reward's operations.
+	var scheduleUtxoOps []*UtxoOperation
+	if len(utxoOps) > 0 {
+		scheduleUtxoOps, utxoOps[0] = _splitBlockProducerScheduleUtxoOps(utxoOps[0])
+	}
+
 	// Loop through the txns backwards to process them.
 	// Track the operation we're performing as we go.
 	for txnIndex := len(desoBlock.Txns) - 1; txnIndex >= 0; txnIndex-- {
@@ -1026,6 +1283,10 @@ func (bav *UtxoView) DisconnectBlock(
 		}
 	}
 
+	if err := bav._disconnectBlockProducerSchedule(scheduleUtxoOps); err != nil {
+		return errors.Wrapf(err, "DisconnectBlock: ")
+	}
+
 	// At this point, all of the transactions in the block should be fully
 	// reversed and the view should therefore be in the state it was in before
 	// this block was applied.
@@ -1310,13 +1571,23 @@ func (bav *UtxoView) _connectBasicTransfer(
 		}
 
 		// Store the diamond recipient pub key so we can figure out how much they are paid.
-		diamondRecipientPubKey := previousDiamondPostEntry.PosterPublicKey
+		// If the poster has rotated their key since, the diamond goes to their current key.
+		diamondRecipientPubKey := bav.GetCurrentPublicKey(previousDiamondPostEntry.PosterPublicKey)
 
 		// Check that the diamond sender and receiver public keys are different.
 		if reflect.DeepEqual(txn.PublicKey, diamondRecipientPubKey) {
 			return 0, 0, nil, RuleErrorBasicTransferDiamondCannotTransferToSelf
 		}
 
+		// Check that the poster hasn't blocked the diamond sender.
+		if blockHeight >= bav.Params.ForkHeights.UserBlocksBlockHeight &&
+			bav.IsPublicKeyBlockedByPublicKey(txn.PublicKey, diamondRecipientPubKey) {
+
+			return 0, 0, nil, errors.Wrapf(
+				RuleErrorDiamondSenderBlockedByPoster,
+				"_connectBasicTransfer: Poster pub key: %v", PkToStringBoth(diamondRecipientPubKey))
+		}
+
 		expectedDeSoNanosToTransfer, netNewDiamonds, err := bav.ValidateDiamondsAndGetNumDeSoNanos(
 			txn.PublicKey, diamondRecipientPubKey, diamondPostHash, diamondLevel, blockHeight)
 		if err != nil {
@@ -1642,6 +1913,16 @@ func (bav *UtxoView) _connectTransaction(txn *MsgDeSoTxn, txHash *BlockHash,
 			bav._connectBasicTransfer(
 				txn, txHash, blockHeight, verifySignatures)
 
+		// NFT auctions that have ended are settled by the block reward of the first
+		// block at or past their end height.
+		if err == nil && txn.TxnMeta.GetTxnType() == TxnTypeBlockReward &&
+			blockHeight >= bav.Params.ForkHeights.NFTAuctionsBlockHeight {
+
+			var settlementUtxoOps []*UtxoOperation
+			settlementUtxoOps, err = bav._connectNFTAuctionSettlements(txn, txHash, blockHeight)
+			utxoOpsForTxn = append(utxoOpsForTxn, settlementUtxoOps...)
+		}
+
 	} else if txn.TxnMeta.GetTxnType() == TxnTypeBitcoinExchange {
 		totalInput, totalOutput, utxoOpsForTxn, err =
 			bav._connectBitcoinExchange(
@@ -1657,6 +1938,71 @@ func (bav *UtxoView) _connectTransaction(txn *MsgDeSoTxn, txHash *BlockHash,
 			bav._connectMessagingGroup(
 				txn, txHash, blockHeight, verifySignatures)
 
+	} else if txn.TxnMeta.GetTxnType() == TxnTypeMessagingGroupUpdate {
+		totalInput, totalOutput, utxoOpsForTxn, err =
+			bav._connectMessagingGroupUpdate(
+				txn, txHash, blockHeight, verifySignatures)
+
+	} else if txn.TxnMeta.GetTxnType() == TxnTypeMessageRead {
+		totalInput, totalOutput, utxoOpsForTxn, err =
+			bav._connectMessageRead(
+				txn, txHash, blockHeight, verifySignatures)
+
+	} else if txn.TxnMeta.GetTxnType() == TxnTypeCreateNFTCollection {
+		totalInput, totalOutput, utxoOpsForTxn, err =
+			bav._connectCreateNFTCollection(
+				txn, txHash, blockHeight, verifySignatures)
+
+	} else if txn.TxnMeta.GetTxnType() == TxnTypeNFTVault {
+		totalInput, totalOutput, utxoOpsForTxn, err =
+			bav._connectNFTVault(
+				txn, txHash, blockHeight, verifySignatures)
+
+	} else if txn.TxnMeta.GetTxnType() == TxnTypeRedeemNFTVoucher {
+		totalInput, totalOutput, utxoOpsForTxn, err =
+			bav._connectRedeemNFTVoucher(
+				txn, txHash, blockHeight, verifySignatures)
+
+	} else if txn.TxnMeta.GetTxnType() == TxnTypeRegisterBlockProducer {
+		totalInput, totalOutput, utxoOpsForTxn, err =
+			bav._connectRegisterBlockProducer(
+				txn, txHash, blockHeight, verifySignatures)
+
+	} else if txn.TxnMeta.GetTxnType() == TxnTypeDeregisterBlockProducer {
+		totalInput, totalOutput, utxoOpsForTxn, err =
+			bav._connectDeregisterBlockProducer(
+				txn, txHash, blockHeight, verifySignatures)
+
+	} else if txn.TxnMeta.GetTxnType() == TxnTypeClaimBlockProducerBond {
+		totalInput, totalOutput, utxoOpsForTxn, err =
+			bav._connectClaimBlockProducerBond(
+				txn, txHash, blockHeight, verifySignatures)
+
+	} else if txn.TxnMeta.GetTxnType() == TxnTypeUsernameListing {
+		totalInput, totalOutput, utxoOpsForTxn, err =
+			bav._connectUsernameListing(
+				txn, txHash, blockHeight, verifySignatures)
+
+	} else if txn.TxnMeta.GetTxnType() == TxnTypeAcceptUsernameListing {
+		totalInput, totalOutput, utxoOpsForTxn, err =
+			bav._connectAcceptUsernameListing(
+				txn, txHash, blockHeight, verifySignatures)
+
+	} else if txn.TxnMeta.GetTxnType() == TxnTypeKeyRotation {
+		totalInput, totalOutput, utxoOpsForTxn, err =
+			bav._connectKeyRotation(
+				txn, txHash, blockHeight, verifySignatures)
+
+	} else if txn.TxnMeta.GetTxnType() == TxnTypeRecoveryGuardians {
+		totalInput, totalOutput, utxoOpsForTxn, err =
+			bav._connectRecoveryGuardians(
+				txn, txHash, blockHeight, verifySignatures)
+
+	} else if txn.TxnMeta.GetTxnType() == TxnTypeAccountRecovery {
+		totalInput, totalOutput, utxoOpsForTxn, err =
+			bav._connectAccountRecovery(
+				txn, txHash, blockHeight, verifySignatures)
+
 	} else if txn.TxnMeta.GetTxnType() == TxnTypeSubmitPost {
 		totalInput, totalOutput, utxoOpsForTxn, err =
 			bav._connectSubmitPost(
@@ -1706,6 +2052,16 @@ func (bav *UtxoView) _connectTransaction(txn *MsgDeSoTxn, txHash *BlockHash,
 			bav._connectDAOCoinTransfer(
 				txn, txHash, blockHeight, verifySignatures)
 
+	} else if txn.TxnMeta.GetTxnType() == TxnTypePollVote {
+		totalInput, totalOutput, utxoOpsForTxn, err =
+			bav._connectPollVote(
+				txn, txHash, blockHeight, verifySignatures)
+
+	} else if txn.TxnMeta.GetTxnType() == TxnTypeUserBlock {
+		totalInput, totalOutput, utxoOpsForTxn, err =
+			bav._connectUserBlock(
+				txn, txHash, blockHeight, verifySignatures)
+
 	} else if txn.TxnMeta.GetTxnType() == TxnTypeSwapIdentity {
 		totalInput, totalOutput, utxoOpsForTxn, err =
 			bav._connectSwapIdentity(
@@ -1803,6 +2159,16 @@ func (bav *UtxoView) ConnectBlock(
 	}
 
 	blockHeader := desoBlock.Header
+
+	// Check that the block was signed by the producer scheduled for its height
+	// using the registered producer set as of its parent. The operations that
+	// record the producer's block and any missed slots are stored at the end of
+	// the block reward's operations.
+	scheduleUtxoOps, err := bav._connectBlockProducerSchedule(desoBlock, verifySignatures)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ConnectBlock: ")
+	}
+
 	// Loop through all the transactions and validate them using the view. Also
 	// keep track of the total fees throughout.
 	var totalFees uint64
@@ -1882,6 +2248,10 @@ func (bav *UtxoView) ConnectBlock(
 		return nil, RuleErrorBlockRewardExceedsMaxAllowed
 	}
 
+	if len(scheduleUtxoOps) > 0 {
+		utxoOps[0] = append(utxoOps[0], scheduleUtxoOps...)
+	}
+
 	// If we made it to the end and this block is valid, advance the tip
 	// of the view to reflect that.
 	blockHash, err := desoBlock.Header.Hash()
@@ -0,0 +1,587 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// block_view_account_recovery.go lets a user who loses their key get their account
+// back with the help of guardians they picked beforehand. The owner registers a set
+// of guardian public keys, the number of them that must agree to a recovery and a
+// delay in blocks. Once enough guardians have signed off on a new key, anyone can
+// submit the signatures to start a recovery. The owner can cancel it until the delay
+// has passed, after which it can be completed, moving the owner's PKID to the new
+// key the same way a KeyRotation does. Until then the guardians can also replace it
+// with a new recovery, which they have to sign again since every recovery uses up
+// the nonce they signed.
+//
+// Guardian sets and pending recoveries are keyed by PKID so they stay with the
+// account when its public key changes. A recovery names the owner by any key the
+// account has had and is applied to whichever key holds the account now, so a
+// thief who rotates the account away doesn't escape a recovery. Once an account
+// has guardians, a change to them only takes effect after the current guardians'
+// delay, and the guardians can veto it by starting a recovery in the meantime.
+// Otherwise a thief holding the owner's key could simply remove the guardians.
+// For the same reason a KeyRotation has to use a timelock of at least the
+// guardians' delay.
+
+// AccountRecoverySignatureData returns the bytes the guardians and the new key sign
+// to approve a recovery of ownerPublicKey to newPublicKey.
+func AccountRecoverySignatureData(ownerPublicKey []byte, newPublicKey []byte, recoveryNonce uint64) []byte {
+	data := append([]byte{}, ownerPublicKey...)
+	data = append(data, newPublicKey...)
+	data = append(data, UintToBuf(recoveryNonce)...)
+	return data
+}
+
+// GetRecoveryGuardianSetEntry returns the guardians of ownerPKID, or nil if they've
+// never registered any.
+func (bav *UtxoView) GetRecoveryGuardianSetEntry(ownerPKID *PKID) *RecoveryGuardianSetEntry {
+	// If an entry exists in the in-memory map, return the value of that mapping.
+	if mapValue, existsMapValue := bav.PKIDToRecoveryGuardianSetEntry[*ownerPKID]; existsMapValue {
+		if mapValue.isDeleted {
+			return nil
+		}
+		return mapValue
+	}
+
+	// If we get here it means no value exists in our in-memory map. In this case,
+	// defer to the db. If a mapping exists in the db, return it. If not, return
+	// nil. Either way, save the value to the in-memory view mapping got later.
+	var guardianSetEntry *RecoveryGuardianSetEntry
+	if bav.Postgres != nil {
+		if guardianSet := bav.Postgres.GetRecoveryGuardianSet(ownerPKID); guardianSet != nil {
+			guardianSetEntry = guardianSet.NewRecoveryGuardianSetEntry()
+		}
+	} else {
+		guardianSetEntry = DbGetRecoveryGuardianSetEntry(bav.Handle, ownerPKID)
+	}
+	if guardianSetEntry != nil {
+		bav._setRecoveryGuardianSetEntryMappings(guardianSetEntry)
+	}
+	return guardianSetEntry
+}
+
+func (bav *UtxoView) _setRecoveryGuardianSetEntryMappings(guardianSetEntry *RecoveryGuardianSetEntry) {
+	// This function shouldn't be called with nil.
+	if guardianSetEntry == nil {
+		glog.Errorf("_setRecoveryGuardianSetEntryMappings: Called with nil RecoveryGuardianSetEntry; " +
+			"this should never happen.")
+		return
+	}
+
+	bav.PKIDToRecoveryGuardianSetEntry[*guardianSetEntry.OwnerPKID] = guardianSetEntry
+}
+
+func (bav *UtxoView) _deleteRecoveryGuardianSetEntryMappings(guardianSetEntry *RecoveryGuardianSetEntry) {
+
+	// Create a tombstone entry.
+	tombstoneGuardianSetEntry := *guardianSetEntry
+	tombstoneGuardianSetEntry.isDeleted = true
+
+	// Set the mappings to point to the tombstone entry.
+	bav._setRecoveryGuardianSetEntryMappings(&tombstoneGuardianSetEntry)
+}
+
+// GetActiveRecoveryGuardianSetEntry returns the guardians of ownerPKID as of
+// blockHeight, with a pending change applied if it has taken effect by then.
+func (bav *UtxoView) GetActiveRecoveryGuardianSetEntry(ownerPKID *PKID, blockHeight uint64) *RecoveryGuardianSetEntry {
+	guardianSetEntry := bav.GetRecoveryGuardianSetEntry(ownerPKID)
+	if guardianSetEntry == nil {
+		return nil
+	}
+	activeGuardianSetEntry := *guardianSetEntry
+	if activeGuardianSetEntry.PendingUnlockBlockHeight != 0 &&
+		blockHeight >= activeGuardianSetEntry.PendingUnlockBlockHeight {
+
+		activeGuardianSetEntry.GuardianPublicKeys = activeGuardianSetEntry.PendingGuardianPublicKeys
+		activeGuardianSetEntry.Threshold = activeGuardianSetEntry.PendingThreshold
+		activeGuardianSetEntry.DelayBlocks = activeGuardianSetEntry.PendingDelayBlocks
+		activeGuardianSetEntry.PendingGuardianPublicKeys = nil
+		activeGuardianSetEntry.PendingThreshold = 0
+		activeGuardianSetEntry.PendingDelayBlocks = 0
+		activeGuardianSetEntry.PendingUnlockBlockHeight = 0
+	}
+	return &activeGuardianSetEntry
+}
+
+// GetPendingAccountRecoveryEntry returns the recovery of ownerPKID that is waiting
+// on the owner's delay, or nil if there isn't one.
+func (bav *UtxoView) GetPendingAccountRecoveryEntry(ownerPKID *PKID) *AccountRecoveryEntry {
+	// If an entry exists in the in-memory map, return the value of that mapping.
+	if mapValue, existsMapValue := bav.PKIDToAccountRecoveryEntry[*ownerPKID]; existsMapValue {
+		if mapValue.isDeleted {
+			return nil
+		}
+		return mapValue
+	}
+
+	// If we get here it means no value exists in our in-memory map. In this case,
+	// defer to the db. If a mapping exists in the db, return it. If not, return
+	// nil. Either way, save the value to the in-memory view mapping got later.
+	var accountRecoveryEntry *AccountRecoveryEntry
+	if bav.Postgres != nil {
+		if accountRecovery := bav.Postgres.GetPendingAccountRecovery(ownerPKID); accountRecovery != nil {
+			accountRecoveryEntry = accountRecovery.NewAccountRecoveryEntry()
+		}
+	} else {
+		accountRecoveryEntry = DbGetPendingAccountRecoveryEntry(bav.Handle, ownerPKID)
+	}
+	if accountRecoveryEntry != nil {
+		bav._setAccountRecoveryEntryMappings(accountRecoveryEntry)
+	}
+	return accountRecoveryEntry
+}
+
+func (bav *UtxoView) _setAccountRecoveryEntryMappings(accountRecoveryEntry *AccountRecoveryEntry) {
+	// This function shouldn't be called with nil.
+	if accountRecoveryEntry == nil {
+		glog.Errorf("_setAccountRecoveryEntryMappings: Called with nil AccountRecoveryEntry; " +
+			"this should never happen.")
+		return
+	}
+
+	bav.PKIDToAccountRecoveryEntry[*accountRecoveryEntry.OwnerPKID] = accountRecoveryEntry
+}
+
+func (bav *UtxoView) _deleteAccountRecoveryEntryMappings(accountRecoveryEntry *AccountRecoveryEntry) {
+
+	// Create a tombstone entry.
+	tombstoneAccountRecoveryEntry := *accountRecoveryEntry
+	tombstoneAccountRecoveryEntry.isDeleted = true
+
+	// Set the mappings to point to the tombstone entry.
+	bav._setAccountRecoveryEntryMappings(&tombstoneAccountRecoveryEntry)
+}
+
+// _isSameRecoveryGuardianSet returns true if txMeta sets the guardians that
+// guardianSetEntry already has.
+func _isSameRecoveryGuardianSet(guardianSetEntry *RecoveryGuardianSetEntry, txMeta *RecoveryGuardiansMetadata) bool {
+	return reflect.DeepEqual(guardianSetEntry.GuardianPublicKeys, txMeta.GuardianPublicKeys) &&
+		guardianSetEntry.Threshold == txMeta.Threshold &&
+		guardianSetEntry.DelayBlocks == txMeta.DelayBlocks
+}
+
+func (bav *UtxoView) _connectRecoveryGuardians(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
+	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
+
+	if blockHeight < bav.Params.ForkHeights.AccountRecoveryBlockHeight {
+		return 0, 0, nil, RuleErrorRecoveryGuardiansBeforeBlockHeight
+	}
+
+	// Check that the transaction has the right TxnType.
+	if txn.TxnMeta.GetTxnType() != TxnTypeRecoveryGuardians {
+		return 0, 0, nil, fmt.Errorf("_connectRecoveryGuardians: called with bad TxnType %s",
+			txn.TxnMeta.GetTxnType().String())
+	}
+	txMeta := txn.TxnMeta.(*RecoveryGuardiansMetadata)
+
+	// A derived key mustn't be able to pick who can take over its owner's account.
+	if _, isDerived := txn.ExtraData[DerivedPublicKey]; isDerived {
+		return 0, 0, nil, RuleErrorRecoveryGuardiansCannotUseDerivedKey
+	}
+
+	ownerPKID := bav.GetPKIDForPublicKey(txn.PublicKey).PKID
+	prevGuardianSetEntry := bav.GetRecoveryGuardianSetEntry(ownerPKID)
+	activeGuardianSetEntry := bav.GetActiveRecoveryGuardianSetEntry(ownerPKID, uint64(blockHeight))
+
+	if len(txMeta.GuardianPublicKeys) == 0 {
+		// Removing the guardians only makes sense if there are some.
+		if activeGuardianSetEntry == nil || len(activeGuardianSetEntry.GuardianPublicKeys) == 0 {
+			return 0, 0, nil, RuleErrorRecoveryGuardiansNotRegistered
+		}
+		if txMeta.Threshold != 0 {
+			return 0, 0, nil, RuleErrorRecoveryGuardiansInvalidThreshold
+		}
+		if txMeta.DelayBlocks != 0 {
+			return 0, 0, nil, RuleErrorRecoveryGuardiansInvalidDelay
+		}
+	} else {
+		if len(txMeta.GuardianPublicKeys) > MaxRecoveryGuardians {
+			return 0, 0, nil, errors.Wrapf(RuleErrorRecoveryGuardiansTooMany,
+				"_connectRecoveryGuardians: Number of guardians: %d, max: %d",
+				len(txMeta.GuardianPublicKeys), MaxRecoveryGuardians)
+		}
+		guardianPublicKeys := make(map[PkMapKey]bool)
+		for _, guardianPublicKey := range txMeta.GuardianPublicKeys {
+			if len(guardianPublicKey) != btcec.PubKeyBytesLenCompressed {
+				return 0, 0, nil, RuleErrorRecoveryGuardiansInvalidGuardianPublicKey
+			}
+			if _, err := btcec.ParsePubKey(guardianPublicKey, btcec.S256()); err != nil {
+				return 0, 0, nil, errors.Wrapf(RuleErrorRecoveryGuardiansInvalidGuardianPublicKey, err.Error())
+			}
+			if reflect.DeepEqual(guardianPublicKey, txn.PublicKey) {
+				return 0, 0, nil, RuleErrorRecoveryGuardiansOwnerCannotBeGuardian
+			}
+			if guardianPublicKeys[MakePkMapKey(guardianPublicKey)] {
+				return 0, 0, nil, errors.Wrapf(RuleErrorRecoveryGuardiansDuplicateGuardian,
+					"_connectRecoveryGuardians: Guardian: %v", PkToStringBoth(guardianPublicKey))
+			}
+			guardianPublicKeys[MakePkMapKey(guardianPublicKey)] = true
+		}
+		if txMeta.Threshold == 0 || txMeta.Threshold > uint64(len(txMeta.GuardianPublicKeys)) {
+			return 0, 0, nil, errors.Wrapf(RuleErrorRecoveryGuardiansInvalidThreshold,
+				"_connectRecoveryGuardians: Threshold: %d, number of guardians: %d",
+				txMeta.Threshold, len(txMeta.GuardianPublicKeys))
+		}
+		// Without a delay the owner would have no chance to cancel a recovery.
+		if txMeta.DelayBlocks == 0 {
+			return 0, 0, nil, RuleErrorRecoveryGuardiansInvalidDelay
+		}
+	}
+	if activeGuardianSetEntry != nil && activeGuardianSetEntry.DelayBlocks > math.MaxUint64-uint64(blockHeight) {
+		return 0, 0, nil, RuleErrorRecoveryGuardiansInvalidDelay
+	}
+
+	// Connect basic txn to get the total input and the total output without
+	// considering the transaction metadata.
+	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
+		txn, txHash, blockHeight, verifySignatures)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectRecoveryGuardians: ")
+	}
+
+	// Force the input to be non-zero so that we can prevent replay attacks.
+	if totalInput == 0 {
+		return 0, 0, nil, RuleErrorRecoveryGuardiansRequiresNonZeroInput
+	}
+
+	newGuardianSetEntry := &RecoveryGuardianSetEntry{
+		OwnerPKID:          ownerPKID,
+		GuardianPublicKeys: txMeta.GuardianPublicKeys,
+		Threshold:          txMeta.Threshold,
+		DelayBlocks:        txMeta.DelayBlocks,
+	}
+	if activeGuardianSetEntry != nil {
+		// Carry the nonce over so that signatures made for the old guardians can't
+		// be used again.
+		newGuardianSetEntry.RecoveryNonce = activeGuardianSetEntry.RecoveryNonce
+
+		// Once there are guardians, a change to them waits out their delay, the
+		// same as a recovery would, so that whoever holds a stolen owner key can't
+		// drop them first. Setting the current guardians again drops a pending change.
+		if len(activeGuardianSetEntry.GuardianPublicKeys) != 0 &&
+			!_isSameRecoveryGuardianSet(activeGuardianSetEntry, txMeta) {
+
+			newGuardianSetEntry = &RecoveryGuardianSetEntry{
+				OwnerPKID:                 ownerPKID,
+				GuardianPublicKeys:        activeGuardianSetEntry.GuardianPublicKeys,
+				Threshold:                 activeGuardianSetEntry.Threshold,
+				DelayBlocks:               activeGuardianSetEntry.DelayBlocks,
+				RecoveryNonce:             activeGuardianSetEntry.RecoveryNonce,
+				PendingGuardianPublicKeys: txMeta.GuardianPublicKeys,
+				PendingThreshold:          txMeta.Threshold,
+				PendingDelayBlocks:        txMeta.DelayBlocks,
+				PendingUnlockBlockHeight:  uint64(blockHeight) + activeGuardianSetEntry.DelayBlocks,
+			}
+		}
+	}
+	var prevGuardianSetEntryCopy *RecoveryGuardianSetEntry
+	if prevGuardianSetEntry != nil {
+		prevEntry := *prevGuardianSetEntry
+		prevGuardianSetEntryCopy = &prevEntry
+		bav._deleteRecoveryGuardianSetEntryMappings(prevGuardianSetEntry)
+	}
+	bav._setRecoveryGuardianSetEntryMappings(newGuardianSetEntry)
+
+	// Add an operation to the list at the end indicating we've updated the guardians.
+	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
+		Type:                         OperationTypeRecoveryGuardians,
+		PrevRecoveryGuardianSetEntry: prevGuardianSetEntryCopy,
+	})
+
+	return totalInput, totalOutput, utxoOpsForTxn, nil
+}
+
+func (bav *UtxoView) _disconnectRecoveryGuardians(
+	operationType OperationType, currentTxn *MsgDeSoTxn, txnHash *BlockHash,
+	utxoOpsForTxn []*UtxoOperation, blockHeight uint32) error {
+
+	// Verify that the last operation is a RecoveryGuardians operation
+	if len(utxoOpsForTxn) == 0 {
+		return fmt.Errorf("_disconnectRecoveryGuardians: utxoOperations are missing")
+	}
+	operationIndex := len(utxoOpsForTxn) - 1
+	if utxoOpsForTxn[operationIndex].Type != OperationTypeRecoveryGuardians {
+		return fmt.Errorf("_disconnectRecoveryGuardians: Trying to revert "+
+			"OperationTypeRecoveryGuardians but found type %v",
+			utxoOpsForTxn[operationIndex].Type)
+	}
+	operationData := utxoOpsForTxn[operationIndex]
+
+	ownerPKID := bav.GetPKIDForPublicKey(currentTxn.PublicKey).PKID
+	guardianSetEntry := bav.GetRecoveryGuardianSetEntry(ownerPKID)
+	if guardianSetEntry == nil {
+		return fmt.Errorf("_disconnectRecoveryGuardians: RecoveryGuardianSetEntry for "+
+			"PKID %v not found; this should never happen", PkToStringBoth(ownerPKID[:]))
+	}
+	bav._deleteRecoveryGuardianSetEntryMappings(guardianSetEntry)
+	if operationData.PrevRecoveryGuardianSetEntry != nil {
+		prevEntry := *operationData.PrevRecoveryGuardianSetEntry
+		bav._setRecoveryGuardianSetEntryMappings(&prevEntry)
+	}
+
+	// Now revert the basic transfer with the remaining operations. Cut off
+	// the RecoveryGuardians operation at the end since we just reverted it.
+	return bav._disconnectBasicTransfer(
+		currentTxn, txnHash, utxoOpsForTxn[:operationIndex], blockHeight)
+}
+
+// _verifyGuardianSignatures checks that the signatures come from at least Threshold
+// distinct guardians in the set and that each of them signed data.
+func _verifyGuardianSignatures(guardianSetEntry *RecoveryGuardianSetEntry,
+	guardianSignatures []*GuardianSignature, data []byte) error {
+
+	guardianPublicKeys := make(map[PkMapKey]bool)
+	for _, guardianPublicKey := range guardianSetEntry.GuardianPublicKeys {
+		guardianPublicKeys[MakePkMapKey(guardianPublicKey)] = true
+	}
+
+	signedGuardians := make(map[PkMapKey]bool)
+	for _, guardianSignature := range guardianSignatures {
+		pkMapKey := MakePkMapKey(guardianSignature.GuardianPublicKey)
+		if !guardianPublicKeys[pkMapKey] {
+			return errors.Wrapf(RuleErrorAccountRecoveryNotGuardian,
+				"_verifyGuardianSignatures: Public key: %v", PkToStringBoth(guardianSignature.GuardianPublicKey))
+		}
+		if signedGuardians[pkMapKey] {
+			return errors.Wrapf(RuleErrorAccountRecoveryDuplicateGuardianSignature,
+				"_verifyGuardianSignatures: Guardian: %v", PkToStringBoth(guardianSignature.GuardianPublicKey))
+		}
+		if err := _verifyBytesSignature(guardianSignature.GuardianPublicKey, data, guardianSignature.Signature); err != nil {
+			return errors.Wrapf(RuleErrorAccountRecoveryInvalidGuardianSignature,
+				"_verifyGuardianSignatures: Guardian: %v: %v",
+				PkToStringBoth(guardianSignature.GuardianPublicKey), err)
+		}
+		signedGuardians[pkMapKey] = true
+	}
+
+	if uint64(len(signedGuardians)) < guardianSetEntry.Threshold {
+		return errors.Wrapf(RuleErrorAccountRecoveryBelowThreshold,
+			"_verifyGuardianSignatures: Signatures: %d, threshold: %d",
+			len(signedGuardians), guardianSetEntry.Threshold)
+	}
+	return nil
+}
+
+func (bav *UtxoView) _connectAccountRecovery(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
+	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
+
+	if blockHeight < bav.Params.ForkHeights.AccountRecoveryBlockHeight {
+		return 0, 0, nil, RuleErrorAccountRecoveryBeforeBlockHeight
+	}
+
+	// Check that the transaction has the right TxnType.
+	if txn.TxnMeta.GetTxnType() != TxnTypeAccountRecovery {
+		return 0, 0, nil, fmt.Errorf("_connectAccountRecovery: called with bad TxnType %s",
+			txn.TxnMeta.GetTxnType().String())
+	}
+	txMeta := txn.TxnMeta.(*AccountRecoveryMetadata)
+
+	if _, isDerived := txn.ExtraData[DerivedPublicKey]; isDerived {
+		return 0, 0, nil, RuleErrorAccountRecoveryCannotUseDerivedKey
+	}
+
+	if len(txMeta.OwnerPublicKey) != btcec.PubKeyBytesLenCompressed {
+		return 0, 0, nil, RuleErrorAccountRecoveryInvalidOwnerPublicKey
+	}
+	if _, err := btcec.ParsePubKey(txMeta.OwnerPublicKey, btcec.S256()); err != nil {
+		return 0, 0, nil, errors.Wrapf(RuleErrorAccountRecoveryInvalidOwnerPublicKey, err.Error())
+	}
+	// The account may have been rotated away from the key the guardians signed for.
+	ownerPublicKey := bav.GetCurrentPublicKey(txMeta.OwnerPublicKey)
+	ownerPKID := bav.GetPKIDForPublicKey(ownerPublicKey).PKID
+
+	prevAccountRecoveryEntry := bav.GetPendingAccountRecoveryEntry(ownerPKID)
+	var newAccountRecoveryEntry *AccountRecoveryEntry
+	switch txMeta.OperationType {
+	case AccountRecoveryOperationInitiate:
+		// A pending recovery can only be cancelled with the owner's key, which is
+		// the one that was lost, so the guardians can replace it with a new one. The
+		// nonce they sign goes up with every recovery, so only a new quorum can.
+		activeGuardianSetEntry := bav.GetActiveRecoveryGuardianSetEntry(ownerPKID, uint64(blockHeight))
+		if activeGuardianSetEntry == nil || len(activeGuardianSetEntry.GuardianPublicKeys) == 0 {
+			return 0, 0, nil, RuleErrorAccountRecoveryNoGuardians
+		}
+		if len(txMeta.NewPublicKey) != btcec.PubKeyBytesLenCompressed ||
+			reflect.DeepEqual(txMeta.NewPublicKey, ownerPublicKey) {
+			return 0, 0, nil, RuleErrorAccountRecoveryInvalidNewPublicKey
+		}
+		if _, err := btcec.ParsePubKey(txMeta.NewPublicKey, btcec.S256()); err != nil {
+			return 0, 0, nil, errors.Wrapf(RuleErrorAccountRecoveryInvalidNewPublicKey, err.Error())
+		}
+		// Catch a new key that can't take over the account now rather than when the
+		// recovery is completed.
+		if _, _, err := bav._validatePublicKeyRotation(ownerPublicKey, txMeta.NewPublicKey); err != nil {
+			return 0, 0, nil, errors.Wrapf(err, "_connectAccountRecovery: ")
+		}
+		signatureData := AccountRecoverySignatureData(
+			txMeta.OwnerPublicKey, txMeta.NewPublicKey, activeGuardianSetEntry.RecoveryNonce)
+		if err := _verifyBytesSignature(txMeta.NewPublicKey, signatureData, txMeta.NewKeySignature); err != nil {
+			return 0, 0, nil, errors.Wrapf(RuleErrorAccountRecoveryInvalidNewKeySignature, err.Error())
+		}
+		if err := _verifyGuardianSignatures(activeGuardianSetEntry, txMeta.GuardianSignatures, signatureData); err != nil {
+			return 0, 0, nil, errors.Wrapf(err, "_connectAccountRecovery: ")
+		}
+		if activeGuardianSetEntry.DelayBlocks > math.MaxUint64-uint64(blockHeight) {
+			return 0, 0, nil, RuleErrorAccountRecoveryInvalidDelay
+		}
+		newAccountRecoveryEntry = &AccountRecoveryEntry{
+			OwnerPKID:         ownerPKID,
+			NewPublicKey:      txMeta.NewPublicKey,
+			UnlockBlockHeight: uint64(blockHeight) + activeGuardianSetEntry.DelayBlocks,
+		}
+
+	case AccountRecoveryOperationCancel, AccountRecoveryOperationComplete:
+		if txMeta.OperationType == AccountRecoveryOperationCancel &&
+			!reflect.DeepEqual(txn.PublicKey, ownerPublicKey) {
+			return 0, 0, nil, RuleErrorAccountRecoveryCancelNotOwner
+		}
+		if prevAccountRecoveryEntry == nil {
+			return 0, 0, nil, RuleErrorAccountRecoveryNotPending
+		}
+		if !reflect.DeepEqual(txMeta.NewPublicKey, prevAccountRecoveryEntry.NewPublicKey) {
+			return 0, 0, nil, RuleErrorAccountRecoveryNewPublicKeyMismatch
+		}
+		if txMeta.OperationType == AccountRecoveryOperationComplete &&
+			uint64(blockHeight) < prevAccountRecoveryEntry.UnlockBlockHeight {
+			return 0, 0, nil, errors.Wrapf(RuleErrorAccountRecoveryDelayNotExpired,
+				"_connectAccountRecovery: Height: %d, Unlock height: %d",
+				blockHeight, prevAccountRecoveryEntry.UnlockBlockHeight)
+		}
+
+	default:
+		return 0, 0, nil, errors.Wrapf(RuleErrorAccountRecoveryInvalidOperationType,
+			"_connectAccountRecovery: Operation type: %d", txMeta.OperationType)
+	}
+
+	// Connect basic txn to get the total input and the total output without
+	// considering the transaction metadata.
+	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
+		txn, txHash, blockHeight, verifySignatures)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectAccountRecovery: ")
+	}
+
+	// Force the input to be non-zero so that we can prevent replay attacks.
+	if totalInput == 0 {
+		return 0, 0, nil, RuleErrorAccountRecoveryRequiresNonZeroInput
+	}
+
+	utxoOp := &UtxoOperation{
+		Type: OperationTypeAccountRecovery,
+	}
+
+	// Starting a recovery uses up the nonce the guardians signed. Starting or
+	// completing one also drops any change to the guardians that hasn't taken
+	// effect yet, since it may have been made with the lost key.
+	if txMeta.OperationType != AccountRecoveryOperationCancel {
+		if prevGuardianSetEntry := bav.GetRecoveryGuardianSetEntry(ownerPKID); prevGuardianSetEntry != nil {
+			prevEntry := *prevGuardianSetEntry
+			utxoOp.PrevRecoveryGuardianSetEntry = &prevEntry
+
+			newGuardianSetEntry := *bav.GetActiveRecoveryGuardianSetEntry(ownerPKID, uint64(blockHeight))
+			newGuardianSetEntry.PendingGuardianPublicKeys = nil
+			newGuardianSetEntry.PendingThreshold = 0
+			newGuardianSetEntry.PendingDelayBlocks = 0
+			newGuardianSetEntry.PendingUnlockBlockHeight = 0
+			if txMeta.OperationType == AccountRecoveryOperationInitiate {
+				newGuardianSetEntry.RecoveryNonce++
+			}
+			bav._deleteRecoveryGuardianSetEntryMappings(prevGuardianSetEntry)
+			bav._setRecoveryGuardianSetEntryMappings(&newGuardianSetEntry)
+		}
+	}
+
+	if txMeta.OperationType == AccountRecoveryOperationComplete {
+		// A rotation the lost key started, possibly by whoever has it now, has to
+		// go or it could take the account back once it unlocks.
+		if keyRotationEntry := bav.GetPendingKeyRotationEntry(ownerPublicKey); keyRotationEntry != nil {
+			prevEntry := *keyRotationEntry
+			utxoOp.PrevKeyRotationEntry = &prevEntry
+			bav._deleteKeyRotationEntryMappings(keyRotationEntry)
+		}
+
+		utxoOp.AccountRecoveryOldPublicKey = ownerPublicKey
+		utxoOp.KeyRotationDerivedKeyEntries, utxoOp.KeyRotationMessagingGroupEntries, err =
+			bav._rotatePublicKey(ownerPublicKey, txMeta.NewPublicKey)
+		if err != nil {
+			return 0, 0, nil, errors.Wrapf(err, "_connectAccountRecovery: ")
+		}
+	}
+
+	if prevAccountRecoveryEntry != nil {
+		prevEntry := *prevAccountRecoveryEntry
+		utxoOp.PrevAccountRecoveryEntry = &prevEntry
+		bav._deleteAccountRecoveryEntryMappings(prevAccountRecoveryEntry)
+	}
+	if newAccountRecoveryEntry != nil {
+		bav._setAccountRecoveryEntryMappings(newAccountRecoveryEntry)
+	}
+
+	// Add an operation to the list at the end indicating we've recovered an account.
+	utxoOpsForTxn = append(utxoOpsForTxn, utxoOp)
+
+	return totalInput, totalOutput, utxoOpsForTxn, nil
+}
+
+func (bav *UtxoView) _disconnectAccountRecovery(
+	operationType OperationType, currentTxn *MsgDeSoTxn, txnHash *BlockHash,
+	utxoOpsForTxn []*UtxoOperation, blockHeight uint32) error {
+
+	// Verify that the last operation is an AccountRecovery operation
+	if len(utxoOpsForTxn) == 0 {
+		return fmt.Errorf("_disconnectAccountRecovery: utxoOperations are missing")
+	}
+	operationIndex := len(utxoOpsForTxn) - 1
+	if utxoOpsForTxn[operationIndex].Type != OperationTypeAccountRecovery {
+		return fmt.Errorf("_disconnectAccountRecovery: Trying to revert "+
+			"OperationTypeAccountRecovery but found type %v",
+			utxoOpsForTxn[operationIndex].Type)
+	}
+	txMeta := currentTxn.TxnMeta.(*AccountRecoveryMetadata)
+	operationData := utxoOpsForTxn[operationIndex]
+
+	if txMeta.OperationType == AccountRecoveryOperationComplete {
+		if err := bav._unrotatePublicKey(operationData.AccountRecoveryOldPublicKey, txMeta.NewPublicKey,
+			operationData.KeyRotationDerivedKeyEntries, operationData.KeyRotationMessagingGroupEntries); err != nil {
+			return errors.Wrapf(err, "_disconnectAccountRecovery: ")
+		}
+		if operationData.PrevKeyRotationEntry != nil {
+			prevEntry := *operationData.PrevKeyRotationEntry
+			bav._setKeyRotationEntryMappings(&prevEntry)
+		}
+	}
+
+	// The owner's current key maps to its original PKID again now that any
+	// rotation has been undone.
+	ownerPKID := bav.GetPKIDForPublicKey(bav.GetCurrentPublicKey(txMeta.OwnerPublicKey)).PKID
+
+	if accountRecoveryEntry := bav.GetPendingAccountRecoveryEntry(ownerPKID); accountRecoveryEntry != nil {
+		bav._deleteAccountRecoveryEntryMappings(accountRecoveryEntry)
+	}
+	if operationData.PrevAccountRecoveryEntry != nil {
+		prevEntry := *operationData.PrevAccountRecoveryEntry
+		bav._setAccountRecoveryEntryMappings(&prevEntry)
+	}
+
+	if operationData.PrevRecoveryGuardianSetEntry != nil {
+		if guardianSetEntry := bav.GetRecoveryGuardianSetEntry(ownerPKID); guardianSetEntry != nil {
+			bav._deleteRecoveryGuardianSetEntryMappings(guardianSetEntry)
+		}
+		prevEntry := *operationData.PrevRecoveryGuardianSetEntry
+		bav._setRecoveryGuardianSetEntryMappings(&prevEntry)
+	}
+
+	// Now revert the basic transfer with the remaining operations. Cut off
+	// the AccountRecovery operation at the end since we just reverted it.
+	return bav._disconnectBasicTransfer(
+		currentTxn, txnHash, utxoOpsForTxn[:operationIndex], blockHeight)
+}
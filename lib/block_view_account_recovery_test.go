@@ -0,0 +1,653 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// _getAccountRecoverySignature signs the recovery of ownerPubBase58Check to
+// newPubBase58Check at recoveryNonce with signerPrivBase58Check.
+func _getAccountRecoverySignature(t *testing.T, ownerPubBase58Check string, newPubBase58Check string,
+	recoveryNonce uint64, signerPrivBase58Check string) []byte {
+
+	require := require.New(t)
+
+	ownerPkBytes, _, err := Base58CheckDecode(ownerPubBase58Check)
+	require.NoError(err)
+	newPkBytes, _, err := Base58CheckDecode(newPubBase58Check)
+	require.NoError(err)
+	signerPrivBytes, _, err := Base58CheckDecode(signerPrivBase58Check)
+	require.NoError(err)
+	signerPriv, _ := btcec.PrivKeyFromBytes(btcec.S256(), signerPrivBytes)
+
+	signature, err := signerPriv.Sign(
+		Sha256DoubleHash(AccountRecoverySignatureData(ownerPkBytes, newPkBytes, recoveryNonce))[:])
+	require.NoError(err)
+	return signature.Serialize()
+}
+
+func _getGuardianSignature(t *testing.T, ownerPubBase58Check string, newPubBase58Check string,
+	recoveryNonce uint64, guardianPubBase58Check string, guardianPrivBase58Check string) *GuardianSignature {
+
+	guardianPkBytes, _, err := Base58CheckDecode(guardianPubBase58Check)
+	require.NoError(t, err)
+	return &GuardianSignature{
+		GuardianPublicKey: guardianPkBytes,
+		Signature: _getAccountRecoverySignature(
+			t, ownerPubBase58Check, newPubBase58Check, recoveryNonce, guardianPrivBase58Check),
+	}
+}
+
+func _recoveryGuardians(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+	feeRateNanosPerKB uint64, ownerPkBase58Check string, ownerPrivBase58Check string,
+	guardianPublicKeys [][]byte, threshold uint64, delayBlocks uint64,
+) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
+
+	require := require.New(t)
+
+	ownerPkBytes, _, err := Base58CheckDecode(ownerPkBase58Check)
+	require.NoError(err)
+
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateRecoveryGuardiansTxn(
+		ownerPkBytes,
+		guardianPublicKeys,
+		threshold,
+		delayBlocks,
+		feeRateNanosPerKB,
+		nil,
+		[]*DeSoOutput{})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	require.Equal(totalInputMake, changeAmountMake+feesMake)
+
+	// Sign the transaction now that its inputs are set up.
+	_signTxn(t, txn, ownerPrivBase58Check)
+
+	txHash := txn.Hash()
+	// Always use height+1 for validation since it's assumed the transaction will
+	// get mined into the next block.
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(totalInput, totalInputMake)
+	require.Equal(OperationTypeRecoveryGuardians, utxoOps[len(utxoOps)-1].Type)
+
+	require.NoError(utxoView.FlushToDb())
+
+	return utxoOps, txn, blockHeight, nil
+}
+
+func _recoveryGuardiansWithTestMeta(
+	testMeta *TestMeta,
+	feeRateNanosPerKB uint64,
+	ownerPkBase58Check string,
+	ownerPrivBase58Check string,
+	guardianPublicKeys [][]byte,
+	threshold uint64,
+	delayBlocks uint64,
+) {
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, ownerPkBase58Check))
+	currentOps, currentTxn, _, err := _recoveryGuardians(
+		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
+		ownerPkBase58Check,
+		ownerPrivBase58Check,
+		guardianPublicKeys,
+		threshold,
+		delayBlocks,
+	)
+	require.NoError(testMeta.t, err)
+	testMeta.txnOps = append(testMeta.txnOps, currentOps)
+	testMeta.txns = append(testMeta.txns, currentTxn)
+}
+
+func _accountRecoveryTxn(t *testing.T, chain *Blockchain, params *DeSoParams,
+	feeRateNanosPerKB uint64, transactorPkBase58Check string, transactorPrivBase58Check string,
+	operationType AccountRecoveryOperationType, ownerPkBase58Check string, newPkBase58Check string,
+	newKeySignature []byte, guardianSignatures []*GuardianSignature,
+) *MsgDeSoTxn {
+
+	require := require.New(t)
+
+	transactorPkBytes, _, err := Base58CheckDecode(transactorPkBase58Check)
+	require.NoError(err)
+	ownerPkBytes, _, err := Base58CheckDecode(ownerPkBase58Check)
+	require.NoError(err)
+	newPkBytes, _, err := Base58CheckDecode(newPkBase58Check)
+	require.NoError(err)
+
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateAccountRecoveryTxn(
+		transactorPkBytes,
+		operationType,
+		ownerPkBytes,
+		newPkBytes,
+		newKeySignature,
+		guardianSignatures,
+		feeRateNanosPerKB,
+		nil,
+		[]*DeSoOutput{})
+	require.NoError(err)
+
+	require.Equal(totalInputMake, changeAmountMake+feesMake)
+
+	// Sign the transaction now that its inputs are set up.
+	_signTxn(t, txn, transactorPrivBase58Check)
+
+	return txn
+}
+
+func _accountRecovery(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+	feeRateNanosPerKB uint64, transactorPkBase58Check string, transactorPrivBase58Check string,
+	operationType AccountRecoveryOperationType, ownerPkBase58Check string, newPkBase58Check string,
+	newKeySignature []byte, guardianSignatures []*GuardianSignature,
+) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
+
+	require := require.New(t)
+
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+
+	txn := _accountRecoveryTxn(t, chain, params, feeRateNanosPerKB, transactorPkBase58Check,
+		transactorPrivBase58Check, operationType, ownerPkBase58Check, newPkBase58Check,
+		newKeySignature, guardianSignatures)
+
+	txHash := txn.Hash()
+	// Always use height+1 for validation since it's assumed the transaction will
+	// get mined into the next block.
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(OperationTypeAccountRecovery, utxoOps[len(utxoOps)-1].Type)
+
+	require.NoError(utxoView.FlushToDb())
+
+	return utxoOps, txn, blockHeight, nil
+}
+
+func _accountRecoveryWithTestMeta(
+	testMeta *TestMeta,
+	feeRateNanosPerKB uint64,
+	transactorPkBase58Check string,
+	transactorPrivBase58Check string,
+	operationType AccountRecoveryOperationType,
+	ownerPkBase58Check string,
+	newPkBase58Check string,
+	newKeySignature []byte,
+	guardianSignatures []*GuardianSignature,
+) {
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, transactorPkBase58Check))
+	currentOps, currentTxn, _, err := _accountRecovery(
+		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
+		transactorPkBase58Check,
+		transactorPrivBase58Check,
+		operationType,
+		ownerPkBase58Check,
+		newPkBase58Check,
+		newKeySignature,
+		guardianSignatures,
+	)
+	require.NoError(testMeta.t, err)
+	testMeta.txnOps = append(testMeta.txnOps, currentOps)
+	testMeta.txns = append(testMeta.txns, currentTxn)
+}
+
+func TestAccountRecovery(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	params.ForkHeights.KeyRotationBlockHeight = uint32(0)
+	params.ForkHeights.AccountRecoveryBlockHeight = uint32(0)
+
+	// Mine a few blocks to give the senderPkString some money.
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	// We build the testMeta obj after mining blocks so that we save the correct block height.
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+
+	// m0 is the owner, m1, m2 and m4 are their guardians and m3 is the key m0
+	// gets their account back on. m1 has a profile of their own.
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 10000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 10000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m2Pub, senderPrivString, 10000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m3Pub, senderPrivString, 10000)
+	_updateProfileWithTestMeta(testMeta, 10, m0Pub, m0Priv, []byte{}, "m0", "i am the m0",
+		shortPic, 10*100, 1.25*100*100, false)
+	_updateProfileWithTestMeta(testMeta, 10, m1Pub, m1Priv, []byte{}, "m1", "i am the m1",
+		shortPic, 10*100, 1.25*100*100, false)
+
+	m0PKID := DBGetPKIDEntryForPublicKey(db, m0PkBytes).PKID
+	guardians := [][]byte{m1PkBytes, m2PkBytes, m4PkBytes}
+
+	// Error case: the guardians must be valid and distinct keys other than the owner's.
+	{
+		_, _, _, err = _recoveryGuardians(t, chain, db, params, 10, m0Pub, m0Priv,
+			[][]byte{m1PkBytes, m1PkBytes[:10]}, 1, 10)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorRecoveryGuardiansInvalidGuardianPublicKey)
+
+		_, _, _, err = _recoveryGuardians(t, chain, db, params, 10, m0Pub, m0Priv,
+			[][]byte{m1PkBytes, m0PkBytes}, 1, 10)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorRecoveryGuardiansOwnerCannotBeGuardian)
+
+		_, _, _, err = _recoveryGuardians(t, chain, db, params, 10, m0Pub, m0Priv,
+			[][]byte{m1PkBytes, m1PkBytes}, 1, 10)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorRecoveryGuardiansDuplicateGuardian)
+	}
+
+	// Error case: the threshold must be between one and the number of guardians.
+	{
+		_, _, _, err = _recoveryGuardians(t, chain, db, params, 10, m0Pub, m0Priv, guardians, 0, 10)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorRecoveryGuardiansInvalidThreshold)
+
+		_, _, _, err = _recoveryGuardians(t, chain, db, params, 10, m0Pub, m0Priv, guardians, 4, 10)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorRecoveryGuardiansInvalidThreshold)
+	}
+
+	// Error case: the owner needs some time to cancel a recovery.
+	{
+		_, _, _, err = _recoveryGuardians(t, chain, db, params, 10, m0Pub, m0Priv, guardians, 2, 0)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorRecoveryGuardiansInvalidDelay)
+	}
+
+	// Error case: there are no guardians to remove.
+	{
+		_, _, _, err = _recoveryGuardians(t, chain, db, params, 10, m0Pub, m0Priv, nil, 0, 0)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorRecoveryGuardiansNotRegistered)
+	}
+
+	// Error case: a recovery needs guardians.
+	{
+		_, _, _, err = _accountRecovery(t, chain, db, params, 10, m1Pub, m1Priv,
+			AccountRecoveryOperationInitiate, m0Pub, m3Pub,
+			_getAccountRecoverySignature(t, m0Pub, m3Pub, 0, m3Priv),
+			[]*GuardianSignature{
+				_getGuardianSignature(t, m0Pub, m3Pub, 0, m1Pub, m1Priv),
+			})
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorAccountRecoveryNoGuardians)
+	}
+
+	// m0 registers m1, m2 and m4 as guardians, two of whom must agree to a recovery.
+	{
+		_recoveryGuardiansWithTestMeta(testMeta, 10, m0Pub, m0Priv, guardians, 2, 10)
+
+		guardianSetEntry := DbGetRecoveryGuardianSetEntry(db, m0PKID)
+		require.NotNil(guardianSetEntry)
+		require.Equal(guardians, guardianSetEntry.GuardianPublicKeys)
+		require.Equal(uint64(2), guardianSetEntry.Threshold)
+		require.Equal(uint64(10), guardianSetEntry.DelayBlocks)
+		require.Equal(uint64(0), guardianSetEntry.RecoveryNonce)
+	}
+
+	// Error case: a key rotation can't go through before the guardians could
+	// start a recovery.
+	{
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m0Pub, m0Priv,
+			KeyRotationOperationInitiate, m3Pub, 0, _getKeyRotationSignature(t, m0Pub, m3Pub, m3Priv))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationTimelockBelowMinimum)
+
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m0Pub, m0Priv,
+			KeyRotationOperationInitiate, m3Pub, 9, _getKeyRotationSignature(t, m0Pub, m3Pub, m3Priv))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationTimelockBelowMinimum)
+	}
+
+	// m0's key, which a thief might hold, replaces the guardians with m4 alone. The
+	// change waits out the guardians' delay, so until then the old guardians count.
+	changeBlockHeight := chain.blockTip().Height + 1
+	{
+		_recoveryGuardiansWithTestMeta(testMeta, 10, m0Pub, m0Priv, [][]byte{m4PkBytes}, 1, 1)
+
+		guardianSetEntry := DbGetRecoveryGuardianSetEntry(db, m0PKID)
+		require.NotNil(guardianSetEntry)
+		require.Equal(guardians, guardianSetEntry.GuardianPublicKeys)
+		require.Equal([][]byte{m4PkBytes}, guardianSetEntry.PendingGuardianPublicKeys)
+		require.Equal(uint64(1), guardianSetEntry.PendingThreshold)
+		require.Equal(uint64(changeBlockHeight)+10, guardianSetEntry.PendingUnlockBlockHeight)
+
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		require.Equal(guardians, utxoView.GetActiveRecoveryGuardianSetEntry(
+			m0PKID, uint64(changeBlockHeight)+9).GuardianPublicKeys)
+		require.Equal([][]byte{m4PkBytes}, utxoView.GetActiveRecoveryGuardianSetEntry(
+			m0PKID, uint64(changeBlockHeight)+10).GuardianPublicKeys)
+	}
+
+	// Setting the current guardians again drops the pending change.
+	{
+		_recoveryGuardiansWithTestMeta(testMeta, 10, m0Pub, m0Priv, guardians, 2, 10)
+
+		guardianSetEntry := DbGetRecoveryGuardianSetEntry(db, m0PKID)
+		require.Equal(guardians, guardianSetEntry.GuardianPublicKeys)
+		require.Empty(guardianSetEntry.PendingGuardianPublicKeys)
+		require.Equal(uint64(0), guardianSetEntry.PendingUnlockBlockHeight)
+	}
+
+	// The change is made again. This time the guardians veto it below by starting
+	// a recovery before it takes effect.
+	_recoveryGuardiansWithTestMeta(testMeta, 10, m0Pub, m0Priv, [][]byte{m4PkBytes}, 1, 1)
+	require.NotEqual(uint64(0), DbGetRecoveryGuardianSetEntry(db, m0PKID).PendingUnlockBlockHeight)
+
+	// Error case: enough distinct guardians must sign the recovery.
+	{
+		_, _, _, err = _accountRecovery(t, chain, db, params, 10, m1Pub, m1Priv,
+			AccountRecoveryOperationInitiate, m0Pub, m3Pub,
+			_getAccountRecoverySignature(t, m0Pub, m3Pub, 0, m3Priv),
+			[]*GuardianSignature{
+				_getGuardianSignature(t, m0Pub, m3Pub, 0, m1Pub, m1Priv),
+			})
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorAccountRecoveryBelowThreshold)
+
+		_, _, _, err = _accountRecovery(t, chain, db, params, 10, m1Pub, m1Priv,
+			AccountRecoveryOperationInitiate, m0Pub, m3Pub,
+			_getAccountRecoverySignature(t, m0Pub, m3Pub, 0, m3Priv),
+			[]*GuardianSignature{
+				_getGuardianSignature(t, m0Pub, m3Pub, 0, m1Pub, m1Priv),
+				_getGuardianSignature(t, m0Pub, m3Pub, 0, m1Pub, m1Priv),
+			})
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorAccountRecoveryDuplicateGuardianSignature)
+
+		_, _, _, err = _accountRecovery(t, chain, db, params, 10, m1Pub, m1Priv,
+			AccountRecoveryOperationInitiate, m0Pub, m3Pub,
+			_getAccountRecoverySignature(t, m0Pub, m3Pub, 0, m3Priv),
+			[]*GuardianSignature{
+				_getGuardianSignature(t, m0Pub, m3Pub, 0, m1Pub, m1Priv),
+				_getGuardianSignature(t, m0Pub, m3Pub, 0, m3Pub, m3Priv),
+			})
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorAccountRecoveryNotGuardian)
+	}
+
+	// Error case: every signature must be over the owner, the new key and the nonce.
+	{
+		_, _, _, err = _accountRecovery(t, chain, db, params, 10, m1Pub, m1Priv,
+			AccountRecoveryOperationInitiate, m0Pub, m3Pub,
+			_getAccountRecoverySignature(t, m0Pub, m3Pub, 0, m3Priv),
+			[]*GuardianSignature{
+				_getGuardianSignature(t, m0Pub, m3Pub, 0, m1Pub, m1Priv),
+				_getGuardianSignature(t, m0Pub, m3Pub, 1, m2Pub, m2Priv),
+			})
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorAccountRecoveryInvalidGuardianSignature)
+
+		_, _, _, err = _accountRecovery(t, chain, db, params, 10, m1Pub, m1Priv,
+			AccountRecoveryOperationInitiate, m0Pub, m3Pub,
+			_getAccountRecoverySignature(t, m0Pub, m3Pub, 0, m2Priv),
+			[]*GuardianSignature{
+				_getGuardianSignature(t, m0Pub, m3Pub, 0, m1Pub, m1Priv),
+				_getGuardianSignature(t, m0Pub, m3Pub, 0, m2Pub, m2Priv),
+			})
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorAccountRecoveryInvalidNewKeySignature)
+	}
+
+	// m1 submits a recovery of m0's account to m3 signed by m1 and m2.
+	blockHeight := chain.blockTip().Height + 1
+	recoverySignatures := []*GuardianSignature{
+		_getGuardianSignature(t, m0Pub, m3Pub, 0, m1Pub, m1Priv),
+		_getGuardianSignature(t, m0Pub, m3Pub, 0, m2Pub, m2Priv),
+	}
+	{
+		_accountRecoveryWithTestMeta(testMeta, 10, m1Pub, m1Priv, AccountRecoveryOperationInitiate,
+			m0Pub, m3Pub, _getAccountRecoverySignature(t, m0Pub, m3Pub, 0, m3Priv), recoverySignatures)
+
+		accountRecoveryEntry := DbGetPendingAccountRecoveryEntry(db, m0PKID)
+		require.NotNil(accountRecoveryEntry)
+		require.Equal(m3PkBytes, accountRecoveryEntry.NewPublicKey)
+		require.Equal(uint64(blockHeight)+10, accountRecoveryEntry.UnlockBlockHeight)
+
+		// The nonce is used up and the pending change to the guardians is gone.
+		guardianSetEntry := DbGetRecoveryGuardianSetEntry(db, m0PKID)
+		require.Equal(uint64(1), guardianSetEntry.RecoveryNonce)
+		require.Equal(guardians, guardianSetEntry.GuardianPublicKeys)
+		require.Equal(uint64(0), guardianSetEntry.PendingUnlockBlockHeight)
+
+		// Nothing moves until the recovery is completed.
+		require.Equal(m0PKID, DBGetPKIDEntryForPublicKey(db, m0PkBytes).PKID)
+	}
+
+	// Error case: the signatures that started the recovery can't start it again.
+	{
+		_, _, _, err = _accountRecovery(t, chain, db, params, 10, m2Pub, m2Priv,
+			AccountRecoveryOperationInitiate, m0Pub, m3Pub,
+			_getAccountRecoverySignature(t, m0Pub, m3Pub, 0, m3Priv), recoverySignatures)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorAccountRecoveryInvalidNewKeySignature)
+	}
+
+	// Error case: a recovery to a key that couldn't take over the account is
+	// rejected up front instead of when it's completed.
+	{
+		_, _, _, err = _accountRecovery(t, chain, db, params, 10, m2Pub, m2Priv,
+			AccountRecoveryOperationInitiate, m0Pub, m1Pub,
+			_getAccountRecoverySignature(t, m0Pub, m1Pub, 1, m1Priv),
+			[]*GuardianSignature{
+				_getGuardianSignature(t, m0Pub, m1Pub, 1, m2Pub, m2Priv),
+				_getGuardianSignature(t, m0Pub, m1Pub, 1, m4Pub, m4Priv),
+			})
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationNewKeyHasProfile)
+	}
+
+	// A new quorum of guardians can replace the pending recovery, say if the new
+	// key was lost too.
+	{
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		txn := _accountRecoveryTxn(t, chain, params, 10, m2Pub, m2Priv,
+			AccountRecoveryOperationInitiate, m0Pub, m2Pub,
+			_getAccountRecoverySignature(t, m0Pub, m2Pub, 1, m2Priv),
+			[]*GuardianSignature{
+				_getGuardianSignature(t, m0Pub, m2Pub, 1, m2Pub, m2Priv),
+				_getGuardianSignature(t, m0Pub, m2Pub, 1, m4Pub, m4Priv),
+			})
+		utxoOps, _, _, _, err := utxoView.ConnectTransaction(
+			txn, txn.Hash(), getTxnSize(*txn), blockHeight+1, true /*verifySignature*/, false /*ignoreUtxos*/)
+		require.NoError(err)
+
+		accountRecoveryEntry := utxoView.GetPendingAccountRecoveryEntry(m0PKID)
+		require.NotNil(accountRecoveryEntry)
+		require.Equal(m2PkBytes, accountRecoveryEntry.NewPublicKey)
+		require.Equal(uint64(blockHeight)+11, accountRecoveryEntry.UnlockBlockHeight)
+		require.Equal(uint64(2), utxoView.GetRecoveryGuardianSetEntry(m0PKID).RecoveryNonce)
+
+		// Disconnecting it should bring the first recovery back.
+		require.NoError(utxoView.DisconnectTransaction(txn, txn.Hash(), utxoOps, blockHeight+1))
+		accountRecoveryEntry = utxoView.GetPendingAccountRecoveryEntry(m0PKID)
+		require.NotNil(accountRecoveryEntry)
+		require.Equal(m3PkBytes, accountRecoveryEntry.NewPublicKey)
+		require.Equal(uint64(1), utxoView.GetRecoveryGuardianSetEntry(m0PKID).RecoveryNonce)
+	}
+
+	// Error case: the recovery can't be completed before the delay has passed.
+	{
+		_, _, _, err = _accountRecovery(t, chain, db, params, 10, m1Pub, m1Priv,
+			AccountRecoveryOperationComplete, m0Pub, m3Pub, nil, nil)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorAccountRecoveryDelayNotExpired)
+	}
+
+	// Error case: only the owner can cancel the recovery.
+	{
+		_, _, _, err = _accountRecovery(t, chain, db, params, 10, m1Pub, m1Priv,
+			AccountRecoveryOperationCancel, m0Pub, m3Pub, nil, nil)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorAccountRecoveryCancelNotOwner)
+
+		_, _, _, err = _accountRecovery(t, chain, db, params, 10, m0Pub, m0Priv,
+			AccountRecoveryOperationCancel, m0Pub, m2Pub, nil, nil)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorAccountRecoveryNewPublicKeyMismatch)
+	}
+
+	// m0 cancels the recovery. The guardians' signatures can't be used again.
+	{
+		_accountRecoveryWithTestMeta(testMeta, 10, m0Pub, m0Priv, AccountRecoveryOperationCancel,
+			m0Pub, m3Pub, nil, nil)
+		require.Nil(DbGetPendingAccountRecoveryEntry(db, m0PKID))
+
+		_, _, _, err = _accountRecovery(t, chain, db, params, 10, m2Pub, m2Priv,
+			AccountRecoveryOperationInitiate, m0Pub, m3Pub,
+			_getAccountRecoverySignature(t, m0Pub, m3Pub, 0, m3Priv), recoverySignatures)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorAccountRecoveryInvalidNewKeySignature)
+	}
+
+	// m0 starts a key rotation, as a thief holding their key might, and then the
+	// guardians start a recovery with fresh signatures.
+	rotationBlockHeight := chain.blockTip().Height + 1
+	{
+		_keyRotationWithTestMeta(testMeta, 10, m0Pub, m0Priv, KeyRotationOperationInitiate, m2Pub, 100,
+			_getKeyRotationSignature(t, m0Pub, m2Pub, m2Priv))
+
+		_accountRecoveryWithTestMeta(testMeta, 10, m2Pub, m2Priv, AccountRecoveryOperationInitiate,
+			m0Pub, m3Pub, _getAccountRecoverySignature(t, m0Pub, m3Pub, 1, m3Priv),
+			[]*GuardianSignature{
+				_getGuardianSignature(t, m0Pub, m3Pub, 1, m2Pub, m2Priv),
+				_getGuardianSignature(t, m0Pub, m3Pub, 1, m4Pub, m4Priv),
+			})
+		require.NotNil(DbGetPendingAccountRecoveryEntry(db, m0PKID))
+		require.Equal(uint64(2), DbGetRecoveryGuardianSetEntry(db, m0PKID).RecoveryNonce)
+	}
+
+	// The thief completes the rotation to m2 first. The recovery follows m0's PKID
+	// and moves the account on from m2 to m3.
+	{
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		rotationTxn := _keyRotationTxn(t, chain, params, 10, m0Pub, m0Priv,
+			KeyRotationOperationComplete, m2Pub, 0, nil)
+		rotationUtxoOps, _, _, _, err := utxoView.ConnectTransaction(rotationTxn, rotationTxn.Hash(),
+			getTxnSize(*rotationTxn), rotationBlockHeight+100, true /*verifySignature*/, false /*ignoreUtxos*/)
+		require.NoError(err)
+		require.Equal(m0PKID, utxoView.GetPKIDForPublicKey(m2PkBytes).PKID)
+		accountRecoveryEntry := utxoView.GetPendingAccountRecoveryEntry(m0PKID)
+		require.NotNil(accountRecoveryEntry)
+		require.Equal(m3PkBytes, accountRecoveryEntry.NewPublicKey)
+
+		// m0's key was rotated away, so it can't cancel the recovery anymore.
+		cancelTxn := _accountRecoveryTxn(t, chain, params, 10, m0Pub, m0Priv,
+			AccountRecoveryOperationCancel, m0Pub, m3Pub, nil, nil)
+		_, _, _, _, err = utxoView.ConnectTransaction(cancelTxn, cancelTxn.Hash(),
+			getTxnSize(*cancelTxn), rotationBlockHeight+100, true /*verifySignature*/, false /*ignoreUtxos*/)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorAccountRecoveryCancelNotOwner)
+
+		recoveryTxn := _accountRecoveryTxn(t, chain, params, 10, m1Pub, m1Priv,
+			AccountRecoveryOperationComplete, m0Pub, m3Pub, nil, nil)
+		recoveryUtxoOps, _, _, _, err := utxoView.ConnectTransaction(recoveryTxn, recoveryTxn.Hash(),
+			getTxnSize(*recoveryTxn), rotationBlockHeight+100, true /*verifySignature*/, false /*ignoreUtxos*/)
+		require.NoError(err)
+		require.Equal(m0PKID, utxoView.GetPKIDForPublicKey(m3PkBytes).PKID)
+		require.Equal(m3PkBytes, utxoView.GetProfileEntryForUsername([]byte("m0")).PublicKey)
+		require.Equal(m3PkBytes, utxoView.GetCurrentPublicKey(m0PkBytes))
+		require.Equal(m3PkBytes, utxoView.GetCurrentPublicKey(m2PkBytes))
+		require.Nil(utxoView.GetPendingAccountRecoveryEntry(m0PKID))
+
+		// Disconnecting both should hand the account back to m0.
+		require.NoError(utxoView.DisconnectTransaction(
+			recoveryTxn, recoveryTxn.Hash(), recoveryUtxoOps, rotationBlockHeight+100))
+		require.Equal(m0PKID, utxoView.GetPKIDForPublicKey(m2PkBytes).PKID)
+		require.NotNil(utxoView.GetPendingAccountRecoveryEntry(m0PKID))
+		require.NoError(utxoView.DisconnectTransaction(
+			rotationTxn, rotationTxn.Hash(), rotationUtxoOps, rotationBlockHeight+100))
+		require.Equal(m0PKID, utxoView.GetPKIDForPublicKey(m0PkBytes).PKID)
+		require.Equal(m0PkBytes, utxoView.GetProfileEntryForUsername([]byte("m0")).PublicKey)
+		require.NotNil(utxoView.GetPendingAccountRecoveryEntry(m0PKID))
+		require.NotNil(utxoView.GetPendingKeyRotationEntry(m0PkBytes))
+	}
+
+	// Once the delay has passed anyone can complete the recovery. m3 takes over
+	// the profile and the guardians, and the key rotation is cleared.
+	{
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		txn := _accountRecoveryTxn(t, chain, params, 10, m1Pub, m1Priv,
+			AccountRecoveryOperationComplete, m0Pub, m3Pub, nil, nil)
+		utxoOps, _, _, _, err := utxoView.ConnectTransaction(
+			txn, txn.Hash(), getTxnSize(*txn), blockHeight+10, true /*verifySignature*/, false /*ignoreUtxos*/)
+		require.NoError(err)
+
+		require.Equal(m0PKID, utxoView.GetPKIDForPublicKey(m3PkBytes).PKID)
+		require.Equal(m3PkBytes, utxoView.GetProfileEntryForUsername([]byte("m0")).PublicKey)
+		require.Nil(utxoView.GetPendingAccountRecoveryEntry(m0PKID))
+		require.Nil(utxoView.GetPendingKeyRotationEntry(m0PkBytes))
+		require.NotNil(utxoView.GetRecoveryGuardianSetEntry(m0PKID))
+
+		// Disconnecting the recovery should put everything back.
+		require.NoError(utxoView.DisconnectTransaction(txn, txn.Hash(), utxoOps, blockHeight+10))
+		require.Equal(m0PKID, utxoView.GetPKIDForPublicKey(m0PkBytes).PKID)
+		require.Equal(m0PkBytes, utxoView.GetProfileEntryForUsername([]byte("m0")).PublicKey)
+		require.NotNil(utxoView.GetPendingAccountRecoveryEntry(m0PKID))
+		require.NotNil(utxoView.GetPendingKeyRotationEntry(m0PkBytes))
+	}
+
+	// m0 removes their guardians, which also only takes effect after their delay.
+	// The nonce is kept so that old signatures stay spent if they add guardians again.
+	{
+		removeBlockHeight := chain.blockTip().Height + 1
+		_recoveryGuardiansWithTestMeta(testMeta, 10, m0Pub, m0Priv, nil, 0, 0)
+
+		guardianSetEntry := DbGetRecoveryGuardianSetEntry(db, m0PKID)
+		require.NotNil(guardianSetEntry)
+		require.Equal(guardians, guardianSetEntry.GuardianPublicKeys)
+		require.Empty(guardianSetEntry.PendingGuardianPublicKeys)
+		require.Equal(uint64(removeBlockHeight)+10, guardianSetEntry.PendingUnlockBlockHeight)
+		require.Equal(uint64(2), guardianSetEntry.RecoveryNonce)
+
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		activeGuardianSetEntry := utxoView.GetActiveRecoveryGuardianSetEntry(m0PKID, uint64(removeBlockHeight)+10)
+		require.Empty(activeGuardianSetEntry.GuardianPublicKeys)
+		require.Equal(uint64(2), activeGuardianSetEntry.RecoveryNonce)
+	}
+
+	// Roll all successful txns through connect and disconnect loops to make sure nothing breaks.
+	_rollBackTestMetaTxnsAndFlush(testMeta)
+	_applyTestMetaTxnsToMempool(testMeta)
+	_applyTestMetaTxnsToViewAndFlush(testMeta)
+	_disconnectTestMetaTxnsFromViewAndFlush(testMeta)
+	_connectBlockThenDisconnectBlockAndFlush(testMeta)
+}
@@ -301,6 +301,15 @@ func (bav *UtxoView) HelpConnectCoinTransfer(
 		return 0, 0, nil, RuleErrorCoinTransferCannotTransferToSelf
 	}
 
+	// Users can't send creator coins to someone who has blocked them.
+	if !isDAOCoin && blockHeight >= bav.Params.ForkHeights.UserBlocksBlockHeight &&
+		bav.IsPublicKeyBlockedByPublicKey(txn.PublicKey, receiverPublicKey) {
+
+		return 0, 0, nil, errors.Wrapf(
+			RuleErrorCreatorCoinTransferSenderBlockedByReceiver,
+			"_helpConnectCoinTransfer: Receiver pub key: %v", PkToStringBoth(receiverPublicKey))
+	}
+
 	// Check that the specified profile public key is valid and that a profile
 	// corresponding to that public key exists.
 	if len(profilePublicKey) != btcec.PubKeyBytesLenCompressed {
@@ -470,6 +479,7 @@ func (bav *UtxoView) HelpConnectCoinTransfer(
 	// TODO(DELETEME): Get rid of this once HyperSync is here.
 	var previousDiamondPostEntry *PostEntry
 	var previousDiamondEntry *DiamondEntry
+	var prevCandleEntry *CreatorCoinPriceCandleEntry
 	if !isDAOCoin {
 		// If this creator coin transfer has diamonds, validate them and do the connection.
 		diamondPostHashBytes, hasDiamondPostHash := txn.ExtraData[DiamondPostHashKey]
@@ -550,6 +560,17 @@ func (bav *UtxoView) HelpConnectCoinTransfer(
 
 			// Now set the diamond entry mappings on the view so they are flushed to the DB.
 			bav._setDiamondEntryMappings(newDiamondEntry)
+
+			// Record the diamond in the coin's candle for this block. The volume is the
+			// value of the coins sent at the coin's current price.
+			bigVolumeNanos := Div(Mul(
+				NewFloat().SetUint64(coinToTransferNanos.Uint64()),
+				NewFloat().SetUint64(CalculateCreatorCoinPriceNanos(&creatorProfileEntry.CreatorCoinEntry, bav.Params))),
+				NewFloat().SetUint64(NanosPerUnit))
+			volumeNanos, _ := bigVolumeNanos.Uint64()
+			prevCandleEntry = bav._connectCreatorCoinPricePoint(
+				bav.GetPKIDForPublicKey(creatorProfileEntry.PublicKey).PKID, &prevCoinEntry,
+				&creatorProfileEntry.CreatorCoinEntry, volumeNanos, blockHeight)
 		}
 	}
 
@@ -570,8 +591,9 @@ func (bav *UtxoView) HelpConnectCoinTransfer(
 
 		// Legacy CreatorCoin fields from when diamonds were associated with
 		// CreatorCoin transfers.
-		PrevPostEntry:    previousDiamondPostEntry,
-		PrevDiamondEntry: previousDiamondEntry,
+		PrevPostEntry:                   previousDiamondPostEntry,
+		PrevDiamondEntry:                previousDiamondEntry,
+		PrevCreatorCoinPriceCandleEntry: prevCandleEntry,
 	})
 
 	return totalInput, totalOutput, utxoOpsForTxn, nil
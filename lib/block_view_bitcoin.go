@@ -7,6 +7,7 @@ import (
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
+	merkletree "github.com/deso-protocol/go-merkle-tree"
 	"github.com/pkg/errors"
 	"math"
 	"math/big"
@@ -79,6 +80,74 @@ func ExtractBitcoinPublicKeyFromBitcoinTransactionInputs(
 		"No valid public key found after scanning all input signature scripts")
 }
 
+// _verifyBitcoinExchangeMerkleProof checks that a burn's merkle root is the one in
+// the header of the Bitcoin block it claims to be mined in, and that its merkle proof
+// connects the burn to that root. Since a header is fixed by its hash, the result
+// doesn't depend on how far a node has synced the Bitcoin header chain.
+func _verifyBitcoinExchangeMerkleProof(txMeta *BitcoinExchangeMetadata, header *MsgDeSoHeader) error {
+	if header == nil || header.TransactionMerkleRoot == nil ||
+		*header.TransactionMerkleRoot != *txMeta.BitcoinMerkleRoot {
+		return errors.Wrapf(RuleErrorBitcoinExchangeHasBadMerkleRoot,
+			"_verifyBitcoinExchangeMerkleProof: Merkle root %v does not match the header for "+
+				"Bitcoin block %v", txMeta.BitcoinMerkleRoot, txMeta.BitcoinBlockHash)
+	}
+	bitcoinTxHash := (BlockHash)(txMeta.BitcoinTransaction.TxHash())
+	if !merkletree.VerifyProof(bitcoinTxHash[:], txMeta.BitcoinMerkleProof, txMeta.BitcoinMerkleRoot[:]) {
+		return errors.Wrapf(RuleErrorBitcoinExchangeInvalidMerkleProof,
+			"_verifyBitcoinExchangeMerkleProof: Bitcoin txn %v", &bitcoinTxHash)
+	}
+	return nil
+}
+
+// _verifyBitcoinExchangeAgainstHeaderChain checks a BitcoinExchange burn against the
+// Bitcoin header chain stored in the db by the BitcoinHeaderChain. It never goes to the
+// network. The burn's block must be on the best chain with at least
+// BitcoinMinNumConfirmations blocks, counting itself, and the merkle proof must connect
+// the burn to that block's merkle root.
+//
+// A bad merkle root or proof is a RuleError since every node will see the same header
+// for the burn's block. A block we haven't synced yet, or haven't synced enough blocks
+// on top of, returns an error that isn't a RuleError so that the DeSo block carrying
+// the burn is retried once we have more headers rather than marked invalid.
+func (bav *UtxoView) _verifyBitcoinExchangeAgainstHeaderChain(txMeta *BitcoinExchangeMetadata) error {
+	if txMeta.BitcoinBlockHash == nil || txMeta.BitcoinMerkleRoot == nil {
+		return RuleErrorBitcoinExchangeBlockHashNotFoundInMainBitcoinChain
+	}
+
+	blockHeight, onBestChain := DbGetBitcoinBestChainHeight(bav.Handle, txMeta.BitcoinBlockHash)
+	if !onBestChain {
+		return fmt.Errorf("_verifyBitcoinExchangeAgainstHeaderChain: Bitcoin block %v "+
+			"is not on our best Bitcoin header chain yet", txMeta.BitcoinBlockHash)
+	}
+	blockNode := GetHeightHashToNodeInfo(bav.Handle, blockHeight, txMeta.BitcoinBlockHash, true /*bitcoinNodes*/)
+	if blockNode == nil {
+		return fmt.Errorf("_verifyBitcoinExchangeAgainstHeaderChain: Missing header "+
+			"for Bitcoin block %v", txMeta.BitcoinBlockHash)
+	}
+	if err := _verifyBitcoinExchangeMerkleProof(txMeta, blockNode.Header); err != nil {
+		return errors.Wrapf(err, "_verifyBitcoinExchangeAgainstHeaderChain: ")
+	}
+
+	// Count the confirmations on top of the block using the tip of the best chain.
+	tipHash := DbGetBestHash(bav.Handle, ChainTypeBitcoinHeader)
+	if tipHash == nil {
+		return fmt.Errorf("_verifyBitcoinExchangeAgainstHeaderChain: Bitcoin header tip not found")
+	}
+	tipHeight, tipExists := DbGetBitcoinBestChainHeight(bav.Handle, tipHash)
+	if !tipExists || tipHeight < blockHeight {
+		return fmt.Errorf("_verifyBitcoinExchangeAgainstHeaderChain: Bitcoin header tip %v "+
+			"is not on the best chain", tipHash)
+	}
+	numConfirmations := tipHeight - blockHeight + 1
+	if numConfirmations < bav.Params.BitcoinMinNumConfirmations {
+		return fmt.Errorf("_verifyBitcoinExchangeAgainstHeaderChain: Bitcoin block %v has %d "+
+			"confirmations but %d are required", txMeta.BitcoinBlockHash, numConfirmations,
+			bav.Params.BitcoinMinNumConfirmations)
+	}
+
+	return nil
+}
+
 func _computeBitcoinBurnOutput(bitcoinTransaction *wire.MsgTx, bitcoinBurnAddress string,
 	btcdParams *chaincfg.Params) (_burnedOutputSatoshis int64, _err error) {
 
@@ -183,6 +252,14 @@ func (bav *UtxoView) _connectBitcoinExchange(
 		return 0, 0, nil, RuleErrorBitcoinExchangeDoubleSpendingBitcoinTransaction
 	}
 
+	// Check that the burn was mined into a block on our best Bitcoin header chain
+	// with enough blocks on top of it.
+	if blockHeight >= bav.Params.ForkHeights.BitcoinExchangeSPVBlockHeight {
+		if err := bav._verifyBitcoinExchangeAgainstHeaderChain(txMetaa); err != nil {
+			return 0, 0, nil, errors.Wrapf(err, "_connectBitcoinExchange: ")
+		}
+	}
+
 	if verifySignatures {
 		// We don't check for signatures and we don't do any checks to verify that
 		// the inputs of the BitcoinTransaction are actually entitled to spend their
@@ -73,7 +73,7 @@ func _dumpAndLoadMempool(mempool *DeSoMempool) {
 	mempool.DumpTxnsToDB()
 	newMempool := NewDeSoMempool(
 		mempool.bc, 0, /* rateLimitFeeRateNanosPerKB */
-		0 /* minFeeRateNanosPerKB */, "", true,
+		0 /* minFeeRateNanosPerKB */, true,
 		mempool.dataDir, mempoolDir)
 	mempool.mempoolDir = ""
 	mempool.resetPool(newMempool)
@@ -359,7 +359,7 @@ func TestBitcoinExchange(t *testing.T) {
 	// Reset the pool to give the mempool access to the new BitcoinManager object.
 	mempool.resetPool(NewDeSoMempool(chain, 0, /* rateLimitFeeRateNanosPerKB */
 		0, /* minFeeRateNanosPerKB */
-		"" /*blockCypherAPIKey*/, false,
+		false,
 		"" /*dataDir*/, ""))
 
 	// Validating the first Bitcoin burn transaction via a UtxoView should
@@ -1059,7 +1059,7 @@ func TestBitcoinExchangeGlobalParams(t *testing.T) {
 	// Reset the pool to give the mempool access to the new BitcoinManager object.
 	mempool.resetPool(NewDeSoMempool(chain, 0, /* rateLimitFeeRateNanosPerKB */
 		0, /* minFeeRateNanosPerKB */
-		"" /*blockCypherAPIKey*/, false,
+		false,
 		"" /*dataDir*/, ""))
 
 	//// Validating the first Bitcoin burn transaction via a UtxoView should
@@ -1785,7 +1785,7 @@ func TestSpendOffOfUnminedTxnsBitcoinExchange(t *testing.T) {
 	// Reset the pool to give the mempool access to the new BitcoinManager object.
 	mempool.resetPool(NewDeSoMempool(chain, 0, /* rateLimitFeeRateNanosPerKB */
 		0, /* minFeeRateNanosPerKB */
-		"" /*blockCypherAPIKey*/, false,
+		false,
 		"" /*dataDir*/, ""))
 
 	// The amount of work on the first burn transaction should be zero.
@@ -2388,7 +2388,7 @@ func TestBitcoinExchangeWithAmountNanosNonZeroAtGenesis(t *testing.T) {
 	// Reset the pool to give the mempool access to the new BitcoinManager object.
 	mempool.resetPool(NewDeSoMempool(chain, 0, /* rateLimitFeeRateNanosPerKB */
 		0, /* minFeeRateNanosPerKB */
-		"" /*blockCypherAPIKey*/, false,
+		false,
 		"" /*dataDir*/, ""))
 
 	// The amount of work on the first burn transaction should be zero.
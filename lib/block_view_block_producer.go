@@ -0,0 +1,688 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// block_view_block_producer.go implements on-chain block producer registration and
+// the round-robin schedule that decides which registered producer signs each block.
+//
+// The schedule for a block is derived from its height and the producers registered
+// as of its parent, sorted by public key. The producer at index (height % numProducers)
+// is scheduled to sign it. So that the chain doesn't stall when that producer is
+// offline, every BlockProducerSlotTimeout that elapses between the parent's timestamp
+// and the block's timestamp lets the next producer in the rotation sign it instead.
+// processHeader won't accept a header that relies on this with a timestamp in the
+// future, so a producer can't take a slot early by dating its block ahead. The
+// producers that a fallback producer takes the slot from are charged a missed slot.
+//
+// A producer's bond stays locked up for BlockProducerUnbondingBlocks after it
+// deregisters, and is then released to it by a ClaimBlockProducerBond txn.
+
+// GetBlockProducerEntryForPublicKey returns the entry for the given block producer,
+// or nil if it has never registered. The entry of a deregistered producer is
+// returned with IsRegistered set to false.
+func (bav *UtxoView) GetBlockProducerEntryForPublicKey(publicKey []byte) *BlockProducerEntry {
+	// If an entry exists in the in-memory map, return the value of that mapping.
+	pkMapKey := MakePkMapKey(publicKey)
+	if mapValue, existsMapValue := bav.PublicKeyToBlockProducerEntry[pkMapKey]; existsMapValue {
+		if mapValue.isDeleted {
+			return nil
+		}
+		return mapValue
+	}
+
+	// If we get here it means no value exists in our in-memory map. In this case,
+	// defer to the db. If a mapping exists in the db, return it. If not, return
+	// nil. Either way, save the value to the in-memory view mapping got later.
+	var blockProducerEntry *BlockProducerEntry
+	if bav.Postgres != nil {
+		if blockProducer := bav.Postgres.GetBlockProducer(publicKey); blockProducer != nil {
+			blockProducerEntry = blockProducer.NewBlockProducerEntry()
+		}
+	} else {
+		blockProducerEntry = DbGetBlockProducerEntry(bav.Handle, publicKey)
+	}
+	if blockProducerEntry != nil {
+		bav._setBlockProducerEntryMappings(blockProducerEntry)
+	}
+	return blockProducerEntry
+}
+
+// GetAllBlockProducerEntries returns every block producer that has ever registered,
+// sorted by public key.
+func (bav *UtxoView) GetAllBlockProducerEntries() ([]*BlockProducerEntry, error) {
+	// Load all of the entries from the db into the view so that the view's
+	// entries take precedence over them.
+	var dbBlockProducerEntries []*BlockProducerEntry
+	if bav.Postgres != nil {
+		for _, blockProducer := range bav.Postgres.GetAllBlockProducers() {
+			dbBlockProducerEntries = append(dbBlockProducerEntries, blockProducer.NewBlockProducerEntry())
+		}
+	} else {
+		var err error
+		dbBlockProducerEntries, err = DbGetAllBlockProducerEntries(bav.Handle)
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetAllBlockProducerEntries: ")
+		}
+	}
+	for _, blockProducerEntry := range dbBlockProducerEntries {
+		if _, exists := bav.PublicKeyToBlockProducerEntry[MakePkMapKey(blockProducerEntry.PublicKey)]; !exists {
+			bav._setBlockProducerEntryMappings(blockProducerEntry)
+		}
+	}
+
+	blockProducerEntries := []*BlockProducerEntry{}
+	for _, blockProducerEntry := range bav.PublicKeyToBlockProducerEntry {
+		if blockProducerEntry.isDeleted {
+			continue
+		}
+		blockProducerEntries = append(blockProducerEntries, blockProducerEntry)
+	}
+	sort.Slice(blockProducerEntries, func(ii, jj int) bool {
+		return bytes.Compare(blockProducerEntries[ii].PublicKey, blockProducerEntries[jj].PublicKey) < 0
+	})
+	return blockProducerEntries, nil
+}
+
+// GetScheduledBlockProducerEntries returns the producers that take part in the
+// schedule, sorted by public key. These are the registered producers whose public
+// keys haven't been forbidden.
+func (bav *UtxoView) GetScheduledBlockProducerEntries() ([]*BlockProducerEntry, error) {
+	blockProducerEntries, err := bav.GetAllBlockProducerEntries()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetScheduledBlockProducerEntries: ")
+	}
+
+	scheduledEntries := []*BlockProducerEntry{}
+	for _, blockProducerEntry := range blockProducerEntries {
+		if !blockProducerEntry.IsRegistered || bav._isForbiddenBlockProducerPublicKey(blockProducerEntry.PublicKey) {
+			continue
+		}
+		scheduledEntries = append(scheduledEntries, blockProducerEntry)
+	}
+	return scheduledEntries, nil
+}
+
+// ComputeScheduledBlockProducerIndex returns the index of the producer scheduled
+// to sign a block at blockHeight. It only depends on the height so that a producer
+// can't move the slot by choosing its block's timestamp.
+func ComputeScheduledBlockProducerIndex(blockHeight uint64, numBlockProducers int) int {
+	return int(blockHeight % uint64(numBlockProducers))
+}
+
+// ComputeNumFallbackBlockProducers returns how many of the producers after the
+// scheduled one may sign a block in its place, one for every slotTimeout that has
+// passed since the block's parent. processHeader rejects headers that rely on a
+// fallback with a timestamp in the future, so the slot timeouts must really have
+// passed before a fallback producer can take the slot.
+func ComputeNumFallbackBlockProducers(
+	numBlockProducers int, parentTstampSecs uint64, tstampSecs uint64, slotTimeout time.Duration) int {
+
+	slotTimeoutSecs := uint64(slotTimeout / time.Second)
+	if slotTimeoutSecs == 0 || tstampSecs <= parentTstampSecs {
+		return 0
+	}
+	numFallbacks := (tstampSecs - parentTstampSecs) / slotTimeoutSecs
+	if numFallbacks > uint64(numBlockProducers-1) {
+		numFallbacks = uint64(numBlockProducers - 1)
+	}
+	return int(numFallbacks)
+}
+
+// GetScheduledBlockProducers returns the producers that may sign a block with the
+// given header on top of the view's tip. The first is the producer scheduled for
+// the block's height, followed by the fallback producers in the order they take
+// over the slot. It returns nil if the schedule isn't active, either because the
+// fork height hasn't been reached or because no producers are registered.
+func (bav *UtxoView) GetScheduledBlockProducers(header *MsgDeSoHeader, parentTstampSecs uint64) (
+	_scheduledEntries []*BlockProducerEntry, _err error) {
+
+	if header.Height < uint64(bav.Params.ForkHeights.BlockProducerScheduleBlockHeight) {
+		return nil, nil
+	}
+	registeredEntries, err := bav.GetScheduledBlockProducerEntries()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetScheduledBlockProducers: ")
+	}
+	if len(registeredEntries) == 0 {
+		return nil, nil
+	}
+	return bav._scheduleBlockProducers(header, registeredEntries, parentTstampSecs), nil
+}
+
+// _scheduleBlockProducers orders the producers that may sign the block from a
+// non-empty set of registered producers.
+func (bav *UtxoView) _scheduleBlockProducers(header *MsgDeSoHeader,
+	registeredEntries []*BlockProducerEntry, parentTstampSecs uint64) []*BlockProducerEntry {
+
+	numProducers := len(registeredEntries)
+	index := ComputeScheduledBlockProducerIndex(header.Height, numProducers)
+	numFallbacks := ComputeNumFallbackBlockProducers(
+		numProducers, parentTstampSecs, header.TstampSecs, bav.Params.BlockProducerSlotTimeout)
+
+	scheduledEntries := []*BlockProducerEntry{}
+	for ii := 0; ii <= numFallbacks; ii++ {
+		scheduledEntries = append(scheduledEntries, registeredEntries[(index+ii)%numProducers])
+	}
+	return scheduledEntries
+}
+
+func (bav *UtxoView) _isForbiddenBlockProducerPublicKey(publicKey []byte) bool {
+	if forbiddenPubKeyEntry, exists := bav.ForbiddenPubKeyToForbiddenPubKeyEntry[MakePkMapKey(publicKey)]; exists {
+		return !forbiddenPubKeyEntry.isDeleted
+	}
+	if bav.Postgres != nil {
+		return bav.Postgres.GetForbiddenKey(publicKey) != nil
+	}
+	return DbGetForbiddenBlockSignaturePubKey(bav.Handle, publicKey) != nil
+}
+
+// _getBlockTstampSecs returns the timestamp of a block that has already been
+// processed, such as the parent of the block being connected.
+func (bav *UtxoView) _getBlockTstampSecs(blockHash *BlockHash, blockHeight uint32) (uint64, error) {
+	if bav.Postgres != nil {
+		block := bav.Postgres.GetBlock(blockHash)
+		if block == nil {
+			return 0, fmt.Errorf("_getBlockTstampSecs: Block %v not found", blockHash)
+		}
+		return block.Timestamp, nil
+	}
+
+	blockNode := GetHeightHashToNodeInfo(bav.Handle, blockHeight, blockHash, false /*bitcoinNodes*/)
+	if blockNode == nil || blockNode.Header == nil {
+		return 0, fmt.Errorf("_getBlockTstampSecs: Block %v at height %d not found", blockHash, blockHeight)
+	}
+	return blockNode.Header.TstampSecs, nil
+}
+
+func (bav *UtxoView) _setBlockProducerEntryMappings(blockProducerEntry *BlockProducerEntry) {
+	// This function shouldn't be called with nil.
+	if blockProducerEntry == nil {
+		glog.Errorf("_setBlockProducerEntryMappings: Called with nil BlockProducerEntry; " +
+			"this should never happen.")
+		return
+	}
+
+	bav.PublicKeyToBlockProducerEntry[MakePkMapKey(blockProducerEntry.PublicKey)] = blockProducerEntry
+}
+
+func (bav *UtxoView) _deleteBlockProducerEntryMappings(blockProducerEntry *BlockProducerEntry) {
+
+	// Create a tombstone entry.
+	tombstoneBlockProducerEntry := *blockProducerEntry
+	tombstoneBlockProducerEntry.isDeleted = true
+
+	// Set the mappings to point to the tombstone entry.
+	bav._setBlockProducerEntryMappings(&tombstoneBlockProducerEntry)
+}
+
+// _revertBlockProducerEntry restores a producer's entry to what it was before a
+// transaction. A nil prevBlockProducerEntry means the producer had never registered.
+func (bav *UtxoView) _revertBlockProducerEntry(publicKey []byte, prevBlockProducerEntry *BlockProducerEntry) error {
+	blockProducerEntry := bav.GetBlockProducerEntryForPublicKey(publicKey)
+	if blockProducerEntry == nil {
+		return fmt.Errorf("_revertBlockProducerEntry: BlockProducerEntry for %v doesn't exist; "+
+			"this should never happen", PkToStringBoth(publicKey))
+	}
+	if prevBlockProducerEntry == nil {
+		bav._deleteBlockProducerEntryMappings(blockProducerEntry)
+		return nil
+	}
+	prevEntry := *prevBlockProducerEntry
+	bav._setBlockProducerEntryMappings(&prevEntry)
+	return nil
+}
+
+func (bav *UtxoView) _connectRegisterBlockProducer(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
+	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
+
+	if blockHeight < bav.Params.ForkHeights.BlockProducerScheduleBlockHeight {
+		return 0, 0, nil, RuleErrorBlockProducerRegistrationBeforeBlockHeight
+	}
+
+	// Check that the transaction has the right TxnType.
+	if txn.TxnMeta.GetTxnType() != TxnTypeRegisterBlockProducer {
+		return 0, 0, nil, fmt.Errorf("_connectRegisterBlockProducer: called with bad TxnType %s",
+			txn.TxnMeta.GetTxnType().String())
+	}
+	txMeta := txn.TxnMeta.(*RegisterBlockProducerMetadata)
+
+	// A key that can't sign blocks can't register to produce them.
+	if bav._isForbiddenBlockProducerPublicKey(txn.PublicKey) {
+		return 0, 0, nil, RuleErrorBlockProducerPublicKeyForbidden
+	}
+
+	prevBlockProducerEntry := bav.GetBlockProducerEntryForPublicKey(txn.PublicKey)
+	if prevBlockProducerEntry != nil && prevBlockProducerEntry.IsRegistered {
+		return 0, 0, nil, RuleErrorBlockProducerAlreadyRegistered
+	}
+	if txMeta.BondNanos < bav.Params.BlockProducerMinBondNanos {
+		return 0, 0, nil, errors.Wrapf(RuleErrorBlockProducerBondBelowMinimum,
+			"_connectRegisterBlockProducer: Bond: %d, Minimum: %d",
+			txMeta.BondNanos, bav.Params.BlockProducerMinBondNanos)
+	}
+
+	// Connect basic txn to get the total input and the total output without
+	// considering the transaction metadata.
+	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
+		txn, txHash, blockHeight, verifySignatures)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectRegisterBlockProducer: ")
+	}
+
+	// Force the input to be non-zero so that we can prevent replay attacks.
+	if totalInput == 0 {
+		return 0, 0, nil, RuleErrorBlockProducerRegistrationRequiresNonZeroInput
+	}
+
+	// The bond counts as output being spent by this transaction. It is locked up
+	// in the producer's entry until the producer deregisters.
+	if totalOutput > math.MaxUint64-txMeta.BondNanos {
+		return 0, 0, nil, errors.Wrapf(RuleErrorBlockProducerBondExceedsInput,
+			"_connectRegisterBlockProducer: Bond %d overflows the output", txMeta.BondNanos)
+	}
+	totalOutput += txMeta.BondNanos
+	if totalInput < totalOutput {
+		return 0, 0, nil, errors.Wrapf(RuleErrorBlockProducerBondExceedsInput,
+			"_connectRegisterBlockProducer: Input: %d, Output: %d", totalInput, totalOutput)
+	}
+
+	// A producer that registers again keeps its record of produced blocks and
+	// missed slots.
+	var newBlockProducerEntry BlockProducerEntry
+	var prevEntryCopy *BlockProducerEntry
+	if prevBlockProducerEntry != nil {
+		newBlockProducerEntry = *prevBlockProducerEntry
+		prevEntry := *prevBlockProducerEntry
+		prevEntryCopy = &prevEntry
+	} else {
+		newBlockProducerEntry = BlockProducerEntry{
+			PublicKey: txn.PublicKey,
+		}
+	}
+	newBlockProducerEntry.BondNanos = txMeta.BondNanos
+	newBlockProducerEntry.IsRegistered = true
+	newBlockProducerEntry.RegisteredBlockHeight = blockHeight
+	bav._setBlockProducerEntryMappings(&newBlockProducerEntry)
+
+	// Add an operation to the list at the end indicating we've registered a block producer.
+	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
+		Type:                   OperationTypeRegisterBlockProducer,
+		PrevBlockProducerEntry: prevEntryCopy,
+	})
+
+	return totalInput, totalOutput, utxoOpsForTxn, nil
+}
+
+func (bav *UtxoView) _disconnectRegisterBlockProducer(
+	operationType OperationType, currentTxn *MsgDeSoTxn, txnHash *BlockHash,
+	utxoOpsForTxn []*UtxoOperation, blockHeight uint32) error {
+
+	// Verify that the last operation is a RegisterBlockProducer operation
+	if len(utxoOpsForTxn) == 0 {
+		return fmt.Errorf("_disconnectRegisterBlockProducer: utxoOperations are missing")
+	}
+	operationIndex := len(utxoOpsForTxn) - 1
+	if utxoOpsForTxn[operationIndex].Type != OperationTypeRegisterBlockProducer {
+		return fmt.Errorf("_disconnectRegisterBlockProducer: Trying to revert "+
+			"OperationTypeRegisterBlockProducer but found type %v",
+			utxoOpsForTxn[operationIndex].Type)
+	}
+	operationData := utxoOpsForTxn[operationIndex]
+
+	if err := bav._revertBlockProducerEntry(currentTxn.PublicKey, operationData.PrevBlockProducerEntry); err != nil {
+		return errors.Wrapf(err, "_disconnectRegisterBlockProducer: ")
+	}
+
+	// Now revert the basic transfer with the remaining operations. Cut off
+	// the RegisterBlockProducer operation at the end since we just reverted it.
+	return bav._disconnectBasicTransfer(
+		currentTxn, txnHash, utxoOpsForTxn[:operationIndex], blockHeight)
+}
+
+func (bav *UtxoView) _connectDeregisterBlockProducer(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
+	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
+
+	if blockHeight < bav.Params.ForkHeights.BlockProducerScheduleBlockHeight {
+		return 0, 0, nil, RuleErrorBlockProducerRegistrationBeforeBlockHeight
+	}
+
+	// Check that the transaction has the right TxnType.
+	if txn.TxnMeta.GetTxnType() != TxnTypeDeregisterBlockProducer {
+		return 0, 0, nil, fmt.Errorf("_connectDeregisterBlockProducer: called with bad TxnType %s",
+			txn.TxnMeta.GetTxnType().String())
+	}
+
+	prevBlockProducerEntry := bav.GetBlockProducerEntryForPublicKey(txn.PublicKey)
+	if prevBlockProducerEntry == nil || !prevBlockProducerEntry.IsRegistered {
+		return 0, 0, nil, RuleErrorBlockProducerNotRegistered
+	}
+
+	// Connect basic txn to get the total input and the total output without
+	// considering the transaction metadata.
+	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
+		txn, txHash, blockHeight, verifySignatures)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectDeregisterBlockProducer: ")
+	}
+
+	// Force the input to be non-zero so that we can prevent replay attacks.
+	if totalInput == 0 {
+		return 0, 0, nil, RuleErrorBlockProducerRegistrationRequiresNonZeroInput
+	}
+
+	// The bond isn't refunded right away. It's held for BlockProducerUnbondingBlocks
+	// so that the producer can still be slashed for anything it did while it was
+	// registered, and then released with a ClaimBlockProducerBond txn. If an
+	// earlier bond is still unbonding, the two are combined and the period restarts.
+	if prevBlockProducerEntry.UnbondingNanos > math.MaxUint64-prevBlockProducerEntry.BondNanos {
+		return 0, 0, nil, fmt.Errorf("_connectDeregisterBlockProducer: Unbonding nanos " +
+			"overflow; this should never happen")
+	}
+
+	// Keep the entry around so that its record of missed slots survives.
+	newBlockProducerEntry := *prevBlockProducerEntry
+	newBlockProducerEntry.UnbondingNanos += prevBlockProducerEntry.BondNanos
+	newBlockProducerEntry.UnbondingBlockHeight = blockHeight
+	newBlockProducerEntry.BondNanos = 0
+	newBlockProducerEntry.IsRegistered = false
+	bav._setBlockProducerEntryMappings(&newBlockProducerEntry)
+
+	// Add an operation to the list at the end indicating we've deregistered a block producer.
+	prevEntry := *prevBlockProducerEntry
+	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
+		Type:                   OperationTypeDeregisterBlockProducer,
+		PrevBlockProducerEntry: &prevEntry,
+	})
+
+	return totalInput, totalOutput, utxoOpsForTxn, nil
+}
+
+func (bav *UtxoView) _disconnectDeregisterBlockProducer(
+	operationType OperationType, currentTxn *MsgDeSoTxn, txnHash *BlockHash,
+	utxoOpsForTxn []*UtxoOperation, blockHeight uint32) error {
+
+	// Verify that the last operation is a DeregisterBlockProducer operation
+	if len(utxoOpsForTxn) == 0 {
+		return fmt.Errorf("_disconnectDeregisterBlockProducer: utxoOperations are missing")
+	}
+	operationIndex := len(utxoOpsForTxn) - 1
+	if utxoOpsForTxn[operationIndex].Type != OperationTypeDeregisterBlockProducer {
+		return fmt.Errorf("_disconnectDeregisterBlockProducer: Trying to revert "+
+			"OperationTypeDeregisterBlockProducer but found type %v",
+			utxoOpsForTxn[operationIndex].Type)
+	}
+	operationData := utxoOpsForTxn[operationIndex]
+	if operationData.PrevBlockProducerEntry == nil {
+		return fmt.Errorf("_disconnectDeregisterBlockProducer: PrevBlockProducerEntry is nil; " +
+			"this should never happen")
+	}
+
+	if err := bav._revertBlockProducerEntry(currentTxn.PublicKey, operationData.PrevBlockProducerEntry); err != nil {
+		return errors.Wrapf(err, "_disconnectDeregisterBlockProducer: ")
+	}
+
+	// Now revert the basic transfer with the remaining operations. Cut off
+	// the DeregisterBlockProducer operation at the end since we just reverted it.
+	return bav._disconnectBasicTransfer(
+		currentTxn, txnHash, utxoOpsForTxn[:operationIndex], blockHeight)
+}
+
+func (bav *UtxoView) _connectClaimBlockProducerBond(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
+	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
+
+	if blockHeight < bav.Params.ForkHeights.BlockProducerScheduleBlockHeight {
+		return 0, 0, nil, RuleErrorBlockProducerRegistrationBeforeBlockHeight
+	}
+
+	// Check that the transaction has the right TxnType.
+	if txn.TxnMeta.GetTxnType() != TxnTypeClaimBlockProducerBond {
+		return 0, 0, nil, fmt.Errorf("_connectClaimBlockProducerBond: called with bad TxnType %s",
+			txn.TxnMeta.GetTxnType().String())
+	}
+
+	prevBlockProducerEntry := bav.GetBlockProducerEntryForPublicKey(txn.PublicKey)
+	if prevBlockProducerEntry == nil || prevBlockProducerEntry.UnbondingNanos == 0 {
+		return 0, 0, nil, RuleErrorBlockProducerNoBondToClaim
+	}
+	unbondedBlockHeight := uint64(prevBlockProducerEntry.UnbondingBlockHeight) +
+		uint64(bav.Params.BlockProducerUnbondingBlocks)
+	if uint64(blockHeight) < unbondedBlockHeight {
+		return 0, 0, nil, errors.Wrapf(RuleErrorBlockProducerBondStillUnbonding,
+			"_connectClaimBlockProducerBond: Block height: %d, Unbonded at: %d",
+			blockHeight, unbondedBlockHeight)
+	}
+
+	// Connect basic txn to get the total input and the total output without
+	// considering the transaction metadata.
+	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
+		txn, txHash, blockHeight, verifySignatures)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectClaimBlockProducerBond: ")
+	}
+
+	// Force the input to be non-zero so that we can prevent replay attacks.
+	if totalInput == 0 {
+		return 0, 0, nil, RuleErrorBlockProducerRegistrationRequiresNonZeroInput
+	}
+
+	// Refund the bond to the producer as an implicit output at the end of the
+	// transaction.
+	refundUtxoKey := UtxoKey{
+		TxID:  *txHash,
+		Index: uint32(len(txn.TxOutputs)),
+	}
+	refundUtxoEntry := UtxoEntry{
+		AmountNanos: prevBlockProducerEntry.UnbondingNanos,
+		PublicKey:   txn.PublicKey,
+		BlockHeight: blockHeight,
+		UtxoType:    UtxoTypeBlockProducerBondRefund,
+		UtxoKey:     &refundUtxoKey,
+		// We leave the position unset and isSpent to false by default.
+		// The position will be set in the call to _addUtxo.
+	}
+	utxoOp, err := bav._addUtxo(&refundUtxoEntry)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectClaimBlockProducerBond: Problem "+
+			"adding bond refund utxo: ")
+	}
+	utxoOpsForTxn = append(utxoOpsForTxn, utxoOp)
+
+	newBlockProducerEntry := *prevBlockProducerEntry
+	newBlockProducerEntry.UnbondingNanos = 0
+	newBlockProducerEntry.UnbondingBlockHeight = 0
+	bav._setBlockProducerEntryMappings(&newBlockProducerEntry)
+
+	// Add an operation to the list at the end indicating we've refunded the bond.
+	prevEntry := *prevBlockProducerEntry
+	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
+		Type:                   OperationTypeClaimBlockProducerBond,
+		PrevBlockProducerEntry: &prevEntry,
+	})
+
+	return totalInput, totalOutput, utxoOpsForTxn, nil
+}
+
+func (bav *UtxoView) _disconnectClaimBlockProducerBond(
+	operationType OperationType, currentTxn *MsgDeSoTxn, txnHash *BlockHash,
+	utxoOpsForTxn []*UtxoOperation, blockHeight uint32) error {
+
+	// Verify that the last operation is a ClaimBlockProducerBond operation
+	if len(utxoOpsForTxn) == 0 {
+		return fmt.Errorf("_disconnectClaimBlockProducerBond: utxoOperations are missing")
+	}
+	operationIndex := len(utxoOpsForTxn) - 1
+	if utxoOpsForTxn[operationIndex].Type != OperationTypeClaimBlockProducerBond {
+		return fmt.Errorf("_disconnectClaimBlockProducerBond: Trying to revert "+
+			"OperationTypeClaimBlockProducerBond but found type %v",
+			utxoOpsForTxn[operationIndex].Type)
+	}
+	operationData := utxoOpsForTxn[operationIndex]
+	operationIndex--
+	if operationData.PrevBlockProducerEntry == nil {
+		return fmt.Errorf("_disconnectClaimBlockProducerBond: PrevBlockProducerEntry is nil; " +
+			"this should never happen")
+	}
+
+	// Revert the bond refund, which is an "implicit" output at the end of the
+	// list of UtxoOperations.
+	if operationIndex < 0 || utxoOpsForTxn[operationIndex].Type != OperationTypeAddUtxo {
+		return fmt.Errorf("_disconnectClaimBlockProducerBond: Expected an ADD operation " +
+			"for the bond refund; this should never happen")
+	}
+	refundUtxoKey := &UtxoKey{
+		TxID:  *txnHash,
+		Index: uint32(len(currentTxn.TxOutputs)),
+	}
+	if err := bav._unAddUtxo(refundUtxoKey); err != nil {
+		return errors.Wrapf(err, "_disconnectClaimBlockProducerBond: Problem unAdding "+
+			"bond refund utxo %v: ", refundUtxoKey)
+	}
+	operationIndex--
+
+	if err := bav._revertBlockProducerEntry(currentTxn.PublicKey, operationData.PrevBlockProducerEntry); err != nil {
+		return errors.Wrapf(err, "_disconnectClaimBlockProducerBond: ")
+	}
+
+	// Now revert the basic transfer with the remaining operations.
+	return bav._disconnectBasicTransfer(
+		currentTxn, txnHash, utxoOpsForTxn[:operationIndex+1], blockHeight)
+}
+
+func (bav *UtxoView) _connectBlockProducerSchedule(desoBlock *MsgDeSoBlock, verifySignatures bool) (
+	[]*UtxoOperation, error) {
+
+	header := desoBlock.Header
+	if header.Height < uint64(bav.Params.ForkHeights.BlockProducerScheduleBlockHeight) || header.Height == 0 {
+		return nil, nil
+	}
+
+	// If no producers are registered then anyone can produce the block. Check
+	// this first so we don't look up the parent for every block before the
+	// first producer registers.
+	registeredEntries, err := bav.GetScheduledBlockProducerEntries()
+	if err != nil {
+		return nil, errors.Wrapf(err, "_connectBlockProducerSchedule: ")
+	}
+	if len(registeredEntries) == 0 {
+		return nil, nil
+	}
+
+	parentTstampSecs, err := bav._getBlockTstampSecs(header.PrevBlockHash, uint32(header.Height-1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "_connectBlockProducerSchedule: ")
+	}
+	scheduledEntries := bav._scheduleBlockProducers(header, registeredEntries, parentTstampSecs)
+
+	// Verify that one of the scheduled producers signed the block.
+	blockProducerInfo := desoBlock.BlockProducerInfo
+	if blockProducerInfo == nil || blockProducerInfo.Signature == nil {
+		return nil, errors.Wrapf(RuleErrorMissingBlockProducerSignature,
+			"_connectBlockProducerSchedule: Block at height %d must be signed by scheduled "+
+				"producer %v", header.Height, PkToStringBoth(scheduledEntries[0].PublicKey))
+	}
+	signerIndex := -1
+	for ii, scheduledEntry := range scheduledEntries {
+		if bytes.Equal(blockProducerInfo.PublicKey, scheduledEntry.PublicKey) {
+			signerIndex = ii
+			break
+		}
+	}
+	if signerIndex < 0 {
+		return nil, errors.Wrapf(RuleErrorBlockProducerNotScheduled,
+			"_connectBlockProducerSchedule: Block at height %d was signed by %v but %v "+
+				"is scheduled", header.Height, PkToStringBoth(blockProducerInfo.PublicKey),
+			PkToStringBoth(scheduledEntries[0].PublicKey))
+	}
+	scheduledEntry := scheduledEntries[signerIndex]
+	// Only the producers the signer actually took the slot from are charged a
+	// missed slot, not every slot timeout the block's timestamp covers.
+	missedEntries := scheduledEntries[:signerIndex]
+	if verifySignatures {
+		blockHash, err := header.Hash()
+		if err != nil {
+			return nil, errors.Wrapf(err, "_connectBlockProducerSchedule: Problem hashing header: ")
+		}
+		pkObj, err := btcec.ParsePubKey(blockProducerInfo.PublicKey, btcec.S256())
+		if err != nil {
+			return nil, errors.Wrapf(RuleErrorInvalidBlockProducerPublicKey,
+				"_connectBlockProducerSchedule: %v", err)
+		}
+		if !blockProducerInfo.Signature.Verify(blockHash[:], pkObj) {
+			return nil, errors.Wrapf(RuleErrorInvalidBlockProducerSIgnature,
+				"_connectBlockProducerSchedule: Error validating signature %v for public key %v",
+				hex.EncodeToString(blockProducerInfo.Signature.Serialize()),
+				PkToStringBoth(blockProducerInfo.PublicKey))
+		}
+	}
+
+	// Record the block and the missed slots. Each entry is copied once before it's
+	// updated so that it can be restored on disconnect.
+	prevBlockProducerEntries := []*BlockProducerEntry{}
+	newBlockProducerEntries := make(map[PkMapKey]*BlockProducerEntry)
+	getNewEntry := func(blockProducerEntry *BlockProducerEntry) *BlockProducerEntry {
+		pkMapKey := MakePkMapKey(blockProducerEntry.PublicKey)
+		if newEntry, exists := newBlockProducerEntries[pkMapKey]; exists {
+			return newEntry
+		}
+		prevEntry := *blockProducerEntry
+		prevBlockProducerEntries = append(prevBlockProducerEntries, &prevEntry)
+		newEntry := *blockProducerEntry
+		newBlockProducerEntries[pkMapKey] = &newEntry
+		return &newEntry
+	}
+	for _, missedEntry := range missedEntries {
+		newEntry := getNewEntry(missedEntry)
+		newEntry.NumMissedSlots++
+		newEntry.LastMissedBlockHeight = uint32(header.Height)
+	}
+	getNewEntry(scheduledEntry).NumBlocksProduced++
+	for _, newEntry := range newBlockProducerEntries {
+		bav._setBlockProducerEntryMappings(newEntry)
+	}
+
+	return []*UtxoOperation{{
+		Type:                     OperationTypeBlockProducerSchedule,
+		PrevBlockProducerEntries: prevBlockProducerEntries,
+	}}, nil
+}
+
+// _splitBlockProducerScheduleUtxoOps separates the block producer schedule
+// operations at the end of a block reward's operations from the rest of them.
+func _splitBlockProducerScheduleUtxoOps(blockRewardUtxoOps []*UtxoOperation) (
+	_scheduleUtxoOps []*UtxoOperation, _remainingUtxoOps []*UtxoOperation) {
+
+	splitIndex := len(blockRewardUtxoOps)
+	for splitIndex > 0 && blockRewardUtxoOps[splitIndex-1].Type == OperationTypeBlockProducerSchedule {
+		splitIndex--
+	}
+	return blockRewardUtxoOps[splitIndex:], blockRewardUtxoOps[:splitIndex]
+}
+
+func (bav *UtxoView) _disconnectBlockProducerSchedule(scheduleUtxoOps []*UtxoOperation) error {
+	for ii := len(scheduleUtxoOps) - 1; ii >= 0; ii-- {
+		operationData := scheduleUtxoOps[ii]
+		if operationData.Type != OperationTypeBlockProducerSchedule {
+			return fmt.Errorf("_disconnectBlockProducerSchedule: Trying to revert "+
+				"OperationTypeBlockProducerSchedule but found type %v", operationData.Type)
+		}
+		for _, prevBlockProducerEntry := range operationData.PrevBlockProducerEntries {
+			prevEntry := *prevBlockProducerEntry
+			bav._setBlockProducerEntryMappings(&prevEntry)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,639 @@
+package lib
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+)
+
+func _registerBlockProducer(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+	feeRateNanosPerKB uint64, producerPkBase58Check string, producerPrivBase58Check string,
+	bondNanos uint64,
+) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
+
+	require := require.New(t)
+
+	producerPkBytes, _, err := Base58CheckDecode(producerPkBase58Check)
+	require.NoError(err)
+
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateRegisterBlockProducerTxn(
+		producerPkBytes,
+		bondNanos,
+		feeRateNanosPerKB,
+		nil,
+		[]*DeSoOutput{})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	require.Equal(totalInputMake, changeAmountMake+feesMake+bondNanos)
+
+	// Sign the transaction now that its inputs are set up.
+	_signTxn(t, txn, producerPrivBase58Check)
+
+	txHash := txn.Hash()
+	// Always use height+1 for validation since it's assumed the transaction will
+	// get mined into the next block.
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(totalInput, totalInputMake)
+	require.Equal(OperationTypeRegisterBlockProducer, utxoOps[len(utxoOps)-1].Type)
+
+	require.NoError(utxoView.FlushToDb())
+
+	return utxoOps, txn, blockHeight, nil
+}
+
+func _registerBlockProducerWithTestMeta(
+	testMeta *TestMeta,
+	feeRateNanosPerKB uint64,
+	producerPkBase58Check string,
+	producerPrivBase58Check string,
+	bondNanos uint64,
+) {
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, producerPkBase58Check))
+	currentOps, currentTxn, _, err := _registerBlockProducer(
+		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
+		producerPkBase58Check,
+		producerPrivBase58Check,
+		bondNanos,
+	)
+	require.NoError(testMeta.t, err)
+	testMeta.txnOps = append(testMeta.txnOps, currentOps)
+	testMeta.txns = append(testMeta.txns, currentTxn)
+}
+
+func _deregisterBlockProducer(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+	feeRateNanosPerKB uint64, producerPkBase58Check string, producerPrivBase58Check string,
+) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
+
+	require := require.New(t)
+
+	producerPkBytes, _, err := Base58CheckDecode(producerPkBase58Check)
+	require.NoError(err)
+
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateDeregisterBlockProducerTxn(
+		producerPkBytes,
+		feeRateNanosPerKB,
+		nil,
+		[]*DeSoOutput{})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	require.Equal(totalInputMake, changeAmountMake+feesMake)
+
+	// Sign the transaction now that its inputs are set up.
+	_signTxn(t, txn, producerPrivBase58Check)
+
+	txHash := txn.Hash()
+	// Always use height+1 for validation since it's assumed the transaction will
+	// get mined into the next block.
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(totalInput, totalInputMake)
+	require.Equal(OperationTypeDeregisterBlockProducer, utxoOps[len(utxoOps)-1].Type)
+
+	require.NoError(utxoView.FlushToDb())
+
+	return utxoOps, txn, blockHeight, nil
+}
+
+func _deregisterBlockProducerWithTestMeta(
+	testMeta *TestMeta,
+	feeRateNanosPerKB uint64,
+	producerPkBase58Check string,
+	producerPrivBase58Check string,
+) {
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, producerPkBase58Check))
+	currentOps, currentTxn, _, err := _deregisterBlockProducer(
+		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
+		producerPkBase58Check,
+		producerPrivBase58Check,
+	)
+	require.NoError(testMeta.t, err)
+	testMeta.txnOps = append(testMeta.txnOps, currentOps)
+	testMeta.txns = append(testMeta.txns, currentTxn)
+}
+
+func _claimBlockProducerBond(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+	feeRateNanosPerKB uint64, producerPkBase58Check string, producerPrivBase58Check string,
+) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
+
+	require := require.New(t)
+
+	producerPkBytes, _, err := Base58CheckDecode(producerPkBase58Check)
+	require.NoError(err)
+
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateClaimBlockProducerBondTxn(
+		producerPkBytes,
+		feeRateNanosPerKB,
+		nil,
+		[]*DeSoOutput{})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	require.Equal(totalInputMake, changeAmountMake+feesMake)
+
+	// Sign the transaction now that its inputs are set up.
+	_signTxn(t, txn, producerPrivBase58Check)
+
+	txHash := txn.Hash()
+	// Always use height+1 for validation since it's assumed the transaction will
+	// get mined into the next block.
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	// The bond refund is an implicit output.
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(totalInput, totalInputMake)
+	require.Equal(OperationTypeClaimBlockProducerBond, utxoOps[len(utxoOps)-1].Type)
+
+	require.NoError(utxoView.FlushToDb())
+
+	return utxoOps, txn, blockHeight, nil
+}
+
+func _claimBlockProducerBondWithTestMeta(
+	testMeta *TestMeta,
+	feeRateNanosPerKB uint64,
+	producerPkBase58Check string,
+	producerPrivBase58Check string,
+) {
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, producerPkBase58Check))
+	currentOps, currentTxn, _, err := _claimBlockProducerBond(
+		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
+		producerPkBase58Check,
+		producerPrivBase58Check,
+	)
+	require.NoError(testMeta.t, err)
+	testMeta.txnOps = append(testMeta.txnOps, currentOps)
+	testMeta.txns = append(testMeta.txns, currentTxn)
+}
+
+func _getBlockSignerKeys(t *testing.T, params *DeSoParams) (_privKey *btcec.PrivateKey, _privBase58Check string) {
+	require := require.New(t)
+
+	seedBytes, err := bip39.NewSeedWithErrorChecking(blockSignerSeed, "")
+	require.NoError(err)
+	_, privKey, _, err := ComputeKeysFromSeed(seedBytes, 0, params)
+	require.NoError(err)
+	return privKey, Base58CheckEncode(privKey.Serialize(), true, params)
+}
+
+func TestComputeScheduledBlockProducerIndex(t *testing.T) {
+	require := require.New(t)
+
+	slotTimeout := 3 * time.Minute
+	slotTimeoutSecs := uint64(slotTimeout / time.Second)
+	parentTstampSecs := uint64(1600000000)
+
+	// The producers rotate with the block height.
+	for height := uint64(10); height < 20; height++ {
+		require.Equal(int(height%4), ComputeScheduledBlockProducerIndex(height, 4))
+	}
+
+	// No fallback producers are allowed until a slot timeout passes.
+	require.Equal(0, ComputeNumFallbackBlockProducers(
+		4, parentTstampSecs, parentTstampSecs+slotTimeoutSecs-1, slotTimeout))
+
+	// Every slot timeout that passes lets one more producer sign the block.
+	require.Equal(1, ComputeNumFallbackBlockProducers(
+		4, parentTstampSecs, parentTstampSecs+slotTimeoutSecs, slotTimeout))
+	require.Equal(3, ComputeNumFallbackBlockProducers(
+		4, parentTstampSecs, parentTstampSecs+3*slotTimeoutSecs, slotTimeout))
+
+	// The fallbacks stop once every other producer can sign the block.
+	require.Equal(3, ComputeNumFallbackBlockProducers(
+		4, parentTstampSecs, parentTstampSecs+5*slotTimeoutSecs, slotTimeout))
+	require.Equal(0, ComputeNumFallbackBlockProducers(
+		1, parentTstampSecs, parentTstampSecs+5*slotTimeoutSecs, slotTimeout))
+
+	// A zero slot timeout never allows a fallback.
+	require.Equal(0, ComputeNumFallbackBlockProducers(
+		4, parentTstampSecs, parentTstampSecs+slotTimeoutSecs, 0))
+}
+
+func TestBlockProducerRegistration(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	params.ForkHeights.BlockProducerScheduleBlockHeight = uint32(0)
+	params.BlockProducerMinBondNanos = 100
+	params.BlockProducerUnbondingBlocks = 10
+
+	// Mine a few blocks to give the senderPkString some money.
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	// We build the testMeta obj after mining blocks so that we save the correct block height.
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+	_, blockSignerPriv := _getBlockSignerKeys(t, params)
+
+	// Fund all the keys.
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 1000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 1000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, blockSignerPk, senderPrivString, 1000)
+
+	// Error case: the bond must be at least the minimum.
+	{
+		_, _, _, err = _registerBlockProducer(t, chain, db, params, 10, m0Pub, m0Priv, 99)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorBlockProducerBondBelowMinimum)
+	}
+
+	// Error case: the producer must be able to cover the bond.
+	{
+		_, _, _, err = _registerBlockProducer(t, chain, db, params, 10, m0Pub, m0Priv, 1001)
+		require.Error(err)
+	}
+
+	// Error case: a producer that isn't registered can't deregister.
+	{
+		_, _, _, err = _deregisterBlockProducer(t, chain, db, params, 10, m0Pub, m0Priv)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorBlockProducerNotRegistered)
+	}
+
+	// m0 registers with a bond of 200.
+	{
+		m0BalanceBefore := _getBalance(t, chain, nil, m0Pub)
+		_registerBlockProducerWithTestMeta(testMeta, 10, m0Pub, m0Priv, 200)
+
+		// m0 pays the bond and the txn fee.
+		require.Less(_getBalance(t, chain, nil, m0Pub), m0BalanceBefore-200)
+
+		blockProducerEntry := DbGetBlockProducerEntry(db, m0PkBytes)
+		require.NotNil(blockProducerEntry)
+		require.True(blockProducerEntry.IsRegistered)
+		require.Equal(uint64(200), blockProducerEntry.BondNanos)
+		require.Equal(testMeta.savedHeight, blockProducerEntry.RegisteredBlockHeight)
+	}
+
+	// Error case: m0 can't register twice.
+	{
+		_, _, _, err = _registerBlockProducer(t, chain, db, params, 10, m0Pub, m0Priv, 200)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorBlockProducerAlreadyRegistered)
+	}
+
+	// m0 is now the only scheduled producer, so the test miner refuses to sign blocks.
+	{
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		scheduledEntries, err := utxoView.GetScheduledBlockProducerEntries()
+		require.NoError(err)
+		require.Len(scheduledEntries, 1)
+		require.Equal(m0PkBytes, scheduledEntries[0].PublicKey)
+
+		_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorBlockProducerNotScheduled)
+	}
+
+	// Error case: a producer that hasn't deregistered has no bond to claim.
+	{
+		_, _, _, err = _claimBlockProducerBond(t, chain, db, params, 10, m0Pub, m0Priv)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorBlockProducerNoBondToClaim)
+	}
+
+	// m0 deregisters and their bond starts unbonding.
+	{
+		m0BalanceBefore := _getBalance(t, chain, nil, m0Pub)
+		_deregisterBlockProducerWithTestMeta(testMeta, 10, m0Pub, m0Priv)
+
+		// m0 only pays the txn fee.
+		require.Less(_getBalance(t, chain, nil, m0Pub), m0BalanceBefore)
+
+		// The entry is kept so that its history isn't lost.
+		blockProducerEntry := DbGetBlockProducerEntry(db, m0PkBytes)
+		require.NotNil(blockProducerEntry)
+		require.False(blockProducerEntry.IsRegistered)
+		require.Equal(uint64(0), blockProducerEntry.BondNanos)
+		require.Equal(uint64(200), blockProducerEntry.UnbondingNanos)
+		require.Equal(testMeta.savedHeight, blockProducerEntry.UnbondingBlockHeight)
+	}
+
+	// Error case: the bond can't be claimed until the unbonding period has passed.
+	{
+		_, _, _, err = _claimBlockProducerBond(t, chain, db, params, 10, m0Pub, m0Priv)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorBlockProducerBondStillUnbonding)
+	}
+
+	// Once the unbonding period has passed, m0 claims their bond back.
+	{
+		params.BlockProducerUnbondingBlocks = 0
+
+		m0BalanceBefore := _getBalance(t, chain, nil, m0Pub)
+		_claimBlockProducerBondWithTestMeta(testMeta, 10, m0Pub, m0Priv)
+
+		// m0 gets the bond back less the txn fee.
+		m0BalanceAfter := _getBalance(t, chain, nil, m0Pub)
+		require.Greater(m0BalanceAfter, m0BalanceBefore)
+		require.Less(m0BalanceAfter, m0BalanceBefore+200)
+
+		blockProducerEntry := DbGetBlockProducerEntry(db, m0PkBytes)
+		require.Equal(uint64(0), blockProducerEntry.UnbondingNanos)
+
+		// The bond can only be claimed once.
+		_, _, _, err = _claimBlockProducerBond(t, chain, db, params, 10, m0Pub, m0Priv)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorBlockProducerNoBondToClaim)
+	}
+
+	// Error case: m0 can't deregister twice.
+	{
+		_, _, _, err = _deregisterBlockProducer(t, chain, db, params, 10, m0Pub, m0Priv)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorBlockProducerNotRegistered)
+	}
+
+	// m0 can register again and the block signer registers too.
+	{
+		_registerBlockProducerWithTestMeta(testMeta, 10, m0Pub, m0Priv, 300)
+		_registerBlockProducerWithTestMeta(testMeta, 10, blockSignerPk, blockSignerPriv, 100)
+
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		blockProducerEntries, err := utxoView.GetAllBlockProducerEntries()
+		require.NoError(err)
+		require.Len(blockProducerEntries, 2)
+		require.Equal(uint64(300), DbGetBlockProducerEntry(db, m0PkBytes).BondNanos)
+	}
+
+	// Error case: a forbidden block signing key can't register.
+	{
+		require.NoError(DbPutForbiddenBlockSignaturePubKey(db, m1PkBytes))
+
+		_, _, _, err = _registerBlockProducer(t, chain, db, params, 10, m1Pub, m1Priv, 100)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorBlockProducerPublicKeyForbidden)
+
+		require.NoError(DbDeleteForbiddenBlockSignaturePubKey(db, m1PkBytes))
+	}
+
+	// Roll all successful txns through connect and disconnect loops to make sure nothing breaks.
+	_rollBackTestMetaTxnsAndFlush(testMeta)
+	_applyTestMetaTxnsToMempool(testMeta)
+	_applyTestMetaTxnsToViewAndFlush(testMeta)
+	_disconnectTestMetaTxnsFromViewAndFlush(testMeta)
+	_connectBlockThenDisconnectBlockAndFlush(testMeta)
+}
+
+func TestBlockProducerSchedule(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	params.ForkHeights.BlockProducerScheduleBlockHeight = uint32(0)
+	params.BlockProducerMinBondNanos = 100
+	params.BlockProducerSlotTimeout = 3 * time.Minute
+	slotTimeoutSecs := uint64(params.BlockProducerSlotTimeout / time.Second)
+
+	// Mine a few blocks to give the senderPkString some money.
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	blockSignerPrivKey, blockSignerPriv := _getBlockSignerKeys(t, params)
+	blockSignerPkBytes, _, err := Base58CheckDecode(blockSignerPk)
+	require.NoError(err)
+	m0PrivBytes, _, err := Base58CheckDecode(m0Priv)
+	require.NoError(err)
+	m0PrivKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), m0PrivBytes)
+
+	_doBasicTransferWithViewFlush(
+		t, chain, db, params, senderPkString, blockSignerPk, senderPrivString, 1000, 11)
+	_doBasicTransferWithViewFlush(
+		t, chain, db, params, senderPkString, m0Pub, senderPrivString, 1000, 11)
+
+	// With only the block signer registered, blocks signed by it connect and are
+	// credited to it.
+	{
+		_, _, _, err = _registerBlockProducer(t, chain, db, params, 10, blockSignerPk, blockSignerPriv, 100)
+		require.NoError(err)
+
+		_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+		require.NoError(err)
+
+		blockProducerEntry := DbGetBlockProducerEntry(db, blockSignerPkBytes)
+		require.Equal(uint64(1), blockProducerEntry.NumBlocksProduced)
+		require.Equal(uint64(0), blockProducerEntry.NumMissedSlots)
+	}
+
+	// Register m0 so that the two producers alternate.
+	_, _, _, err = _registerBlockProducer(t, chain, db, params, 10, m0Pub, m0Priv, 100)
+	require.NoError(err)
+
+	signBlock := func(block *MsgDeSoBlock, privKey *btcec.PrivateKey) {
+		blockHash, err := block.Header.Hash()
+		require.NoError(err)
+		signature, err := privKey.Sign(blockHash[:])
+		require.NoError(err)
+		block.BlockProducerInfo = &BlockProducerInfo{
+			PublicKey: privKey.PubKey().SerializeCompressed(),
+			Signature: signature,
+		}
+	}
+	tipNode := chain.blockTip()
+	blockForTstamp := func(tstampSecs uint64) *MsgDeSoBlock {
+		return &MsgDeSoBlock{
+			Header: &MsgDeSoHeader{
+				Version:               CurrentHeaderVersion,
+				PrevBlockHash:         tipNode.Hash,
+				TransactionMerkleRoot: &BlockHash{},
+				TstampSecs:            tstampSecs,
+				Height:                uint64(tipNode.Height + 1),
+			},
+		}
+	}
+
+	// Figure out which producer is scheduled for the next block.
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+	block := blockForTstamp(tipNode.Header.TstampSecs + 1)
+	scheduledEntries, err := utxoView.GetScheduledBlockProducers(
+		block.Header, tipNode.Header.TstampSecs)
+	require.NoError(err)
+	require.Len(scheduledEntries, 1)
+	scheduledPrivKey, otherPrivKey := blockSignerPrivKey, m0PrivKey
+	if !bytes.Equal(scheduledEntries[0].PublicKey, blockSignerPkBytes) {
+		scheduledPrivKey, otherPrivKey = m0PrivKey, blockSignerPrivKey
+	}
+
+	// Error case: the block must be signed.
+	{
+		_, err = utxoView._connectBlockProducerSchedule(block, true /*verifySignatures*/)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorMissingBlockProducerSignature)
+	}
+
+	// Error case: the producer that isn't scheduled can't sign the block.
+	{
+		signBlock(block, otherPrivKey)
+		_, err = utxoView._connectBlockProducerSchedule(block, true /*verifySignatures*/)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorBlockProducerNotScheduled)
+	}
+
+	// Error case: the signature must be valid for the scheduled producer.
+	{
+		signBlock(block, otherPrivKey)
+		block.BlockProducerInfo.PublicKey = scheduledPrivKey.PubKey().SerializeCompressed()
+		_, err = utxoView._connectBlockProducerSchedule(block, true /*verifySignatures*/)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorInvalidBlockProducerSIgnature)
+	}
+
+	// The scheduled producer signs the block and is credited with it.
+	{
+		signBlock(block, scheduledPrivKey)
+		scheduleUtxoOps, err := utxoView._connectBlockProducerSchedule(block, true /*verifySignatures*/)
+		require.NoError(err)
+		require.Len(scheduleUtxoOps, 1)
+
+		scheduledPkBytes := scheduledPrivKey.PubKey().SerializeCompressed()
+		blockProducerEntry := utxoView.GetBlockProducerEntryForPublicKey(scheduledPkBytes)
+		numBlocksProducedBefore := DbGetBlockProducerEntry(db, scheduledPkBytes).NumBlocksProduced
+		require.Equal(numBlocksProducedBefore+1, blockProducerEntry.NumBlocksProduced)
+
+		require.NoError(utxoView._disconnectBlockProducerSchedule(scheduleUtxoOps))
+		blockProducerEntry = utxoView.GetBlockProducerEntryForPublicKey(scheduledPkBytes)
+		require.Equal(numBlocksProducedBefore, blockProducerEntry.NumBlocksProduced)
+	}
+
+	// The timestamp doesn't change which producer is scheduled, so the scheduled
+	// producer can still sign a late block and isn't charged a missed slot.
+	{
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		block := blockForTstamp(tipNode.Header.TstampSecs + 5*slotTimeoutSecs)
+
+		signBlock(block, scheduledPrivKey)
+		_, err = utxoView._connectBlockProducerSchedule(block, true /*verifySignatures*/)
+		require.NoError(err)
+		scheduledEntry := utxoView.GetBlockProducerEntryForPublicKey(
+			scheduledPrivKey.PubKey().SerializeCompressed())
+		require.Equal(uint64(0), scheduledEntry.NumMissedSlots)
+	}
+
+	// Once a slot times out the other producer may sign the block, and the scheduled
+	// producer is charged a missed slot.
+	{
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		block := blockForTstamp(tipNode.Header.TstampSecs + slotTimeoutSecs)
+
+		signBlock(block, otherPrivKey)
+		scheduleUtxoOps, err := utxoView._connectBlockProducerSchedule(block, true /*verifySignatures*/)
+		require.NoError(err)
+		require.NoError(utxoView.FlushToDb())
+
+		missedEntry := DbGetBlockProducerEntry(db, scheduledPrivKey.PubKey().SerializeCompressed())
+		require.Equal(uint64(1), missedEntry.NumMissedSlots)
+		require.Equal(uint32(block.Header.Height), missedEntry.LastMissedBlockHeight)
+
+		utxoView, err = NewUtxoView(db, params, nil)
+		require.NoError(err)
+		require.NoError(utxoView._disconnectBlockProducerSchedule(scheduleUtxoOps))
+		require.NoError(utxoView.FlushToDb())
+		missedEntry = DbGetBlockProducerEntry(db, scheduledPrivKey.PubKey().SerializeCompressed())
+		require.Equal(uint64(0), missedEntry.NumMissedSlots)
+	}
+
+	// However many slot timeouts pass, only the producers the signer took the slot
+	// from are charged.
+	{
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		block := blockForTstamp(tipNode.Header.TstampSecs + 5*slotTimeoutSecs)
+		scheduledEntries, err := utxoView.GetScheduledBlockProducers(block.Header, tipNode.Header.TstampSecs)
+		require.NoError(err)
+		require.Len(scheduledEntries, 2)
+
+		signBlock(block, otherPrivKey)
+		_, err = utxoView._connectBlockProducerSchedule(block, true /*verifySignatures*/)
+		require.NoError(err)
+		scheduledEntry := utxoView.GetBlockProducerEntryForPublicKey(
+			scheduledPrivKey.PubKey().SerializeCompressed())
+		require.Equal(uint64(1), scheduledEntry.NumMissedSlots)
+		otherEntry := utxoView.GetBlockProducerEntryForPublicKey(
+			otherPrivKey.PubKey().SerializeCompressed())
+		require.Equal(uint64(0), otherEntry.NumMissedSlots)
+	}
+
+	// Error case: a header can't claim a fallback slot with a timestamp in the future.
+	{
+		tstampSecs := uint64(time.Now().Unix())
+		if tstampSecs < tipNode.Header.TstampSecs {
+			tstampSecs = tipNode.Header.TstampSecs
+		}
+		block := blockForTstamp(tstampSecs + slotTimeoutSecs)
+		headerHash, err := block.Header.Hash()
+		require.NoError(err)
+		_, _, err = chain.ProcessHeader(block.Header, headerHash)
+		require.Error(err)
+		require.Contains(err.Error(), HeaderErrorBlockProducerFallbackInTheFuture)
+	}
+
+	// The test miner only signs blocks when the block signer is scheduled.
+	if scheduledPrivKey == blockSignerPrivKey {
+		_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+		require.NoError(err)
+	}
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.Error(err)
+	require.Contains(err.Error(), RuleErrorBlockProducerNotScheduled)
+}
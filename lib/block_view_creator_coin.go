@@ -428,6 +428,10 @@ func (bav *UtxoView) _disconnectCreatorCoin(
 	existingProfileEntry.CreatorCoinEntry = *operationData.PrevCoinEntry
 	bav._setProfileEntryMappings(existingProfileEntry)
 
+	// Revert the coin's candle for this block.
+	bav._disconnectCreatorCoinPricePoint(
+		creatorPKID, blockHeight, operationData.PrevCreatorCoinPriceCandleEntry)
+
 	// Now revert the basic transfer with the remaining operations. Cut off
 	// the CreatorCoin operation at the end since we just reverted it.
 	return bav._disconnectBasicTransfer(
@@ -578,8 +582,13 @@ func (bav *UtxoView) _disconnectCreatorCoinTransfer(
 			bav._setDiamondEntryMappings(operationData.PrevDiamondEntry)
 		}
 
-		// Finally, revert the post entry mapping since we likely updated the DiamondCount.
+		// Revert the post entry mapping since we likely updated the DiamondCount.
 		bav._setPostEntryMappings(operationData.PrevPostEntry)
+
+		// Finally, revert the coin's candle for this block.
+		creatorPKID := bav.GetPKIDForPublicKey(txMeta.ProfilePublicKey)
+		bav._disconnectCreatorCoinPricePoint(
+			creatorPKID.PKID, blockHeight, operationData.PrevCreatorCoinPriceCandleEntry)
 	}
 
 	// Now revert the basic transfer with the remaining operations. Cut off
@@ -588,6 +597,140 @@ func (bav *UtxoView) _disconnectCreatorCoinTransfer(
 		currentTxn, txnHash, utxoOpsForTxn[:operationIndex+1], blockHeight)
 }
 
+// _applyCreatorCoinTradeFee returns what's left of desoNanos after the creator coin
+// trade fee is burned.
+//
+// TODO(performance): We use bigints to avoid overflow in the intermediate
+// stages of the calculation but this most likely isn't necessary. This
+// formula is equal to:
+// - desoAfterFeesNanos = desoNanos * ((100*100 - CreatorCoinTradeFeeBasisPoints) / (100*100))
+func _applyCreatorCoinTradeFee(desoNanos uint64, params *DeSoParams) uint64 {
+	return IntDiv(
+		IntMul(
+			big.NewInt(int64(desoNanos)),
+			big.NewInt(int64(100*100-params.CreatorCoinTradeFeeBasisPoints))),
+		big.NewInt(100*100)).Uint64()
+}
+
+// _calculateDeSoFounderRewardNanos returns the DeSo that goes to the creator out of a
+// buy of their coin. Before the DeSoFounderRewardBlockHeight the founder reward is
+// paid in creator coin instead.
+//
+// Note: If the user performing the buy has the same public key as the profile being
+// bought, we do not cut a founder reward.
+func (bav *UtxoView) _calculateDeSoFounderRewardNanos(buyerPublicKey []byte,
+	profileEntry *ProfileEntry, desoAfterFeesNanos uint64, blockHeight uint32) (uint64, error) {
+
+	if blockHeight <= bav.Params.ForkHeights.DeSoFounderRewardBlockHeight ||
+		reflect.DeepEqual(buyerPublicKey, profileEntry.PublicKey) {
+		return 0, nil
+	}
+
+	// This formula is equal to:
+	// desoFounderRewardNanos = desoAfterFeesNanos * creatorBasisPoints / (100*100)
+	desoFounderRewardNanos := IntDiv(
+		IntMul(
+			big.NewInt(int64(desoAfterFeesNanos)),
+			big.NewInt(int64(profileEntry.CreatorCoinEntry.CreatorBasisPoints))),
+		big.NewInt(100*100)).Uint64()
+
+	// Sanity check, just to be extra safe.
+	if desoAfterFeesNanos < desoFounderRewardNanos {
+		return 0, fmt.Errorf("_calculateDeSoFounderRewardNanos: desoAfterFeesNanos"+
+			" less than desoFounderRewardNanos: %v %v",
+			desoAfterFeesNanos, desoFounderRewardNanos)
+	}
+	return desoFounderRewardNanos, nil
+}
+
+// _calculateCreatorCoinFounderRewardNanos returns the creator coin minted to the
+// creator as a founder reward on a buy. coinEntry is the coin after the
+// creatorCoinToMintNanos have been added to CoinsInCirculationNanos but before
+// CoinWatermarkNanos is updated.
+func (bav *UtxoView) _calculateCreatorCoinFounderRewardNanos(coinEntry *CoinEntry,
+	creatorCoinToMintNanos uint64, blockHeight uint32) uint64 {
+
+	if blockHeight > bav.Params.ForkHeights.DeSoFounderRewardBlockHeight {
+		// Do nothing. The chain stopped minting creator coins as a founder reward for
+		// creators at this blockheight.  It gives DeSo as a founder reward now instead.
+		return 0
+
+	} else if blockHeight > bav.Params.ForkHeights.SalomonFixBlockHeight {
+		// Following the SalomonFixBlockHeight block, creator coin buys continuously mint
+		// a founders reward based on the CreatorBasisPoints.
+		return IntDiv(
+			IntMul(
+				big.NewInt(int64(creatorCoinToMintNanos)),
+				big.NewInt(int64(coinEntry.CreatorBasisPoints))),
+			big.NewInt(100*100)).Uint64()
+	}
+
+	// Up to and including the SalomonFixBlockHeight block, creator coin buys only minted
+	// a founders reward if the creator reached a new all time high.
+	//
+	// For CreatorCoins it's OK to cast to Uint64() because we check for their
+	// exceeding this everywhere.
+	if coinEntry.CoinsInCirculationNanos.Uint64() <= coinEntry.CoinWatermarkNanos {
+		return 0
+	}
+	// This value must be positive if we made it past the if condition above.
+	watermarkDiff := coinEntry.CoinsInCirculationNanos.Uint64() - coinEntry.CoinWatermarkNanos
+	// The founder reward is computed as a percentage of the "net coins created,"
+	// which is equal to the watermarkDiff
+	return IntDiv(
+		IntMul(
+			big.NewInt(int64(watermarkDiff)),
+			big.NewInt(int64(coinEntry.CreatorBasisPoints))),
+		big.NewInt(100*100)).Uint64()
+}
+
+// _calculateCreatorCoinSellNanos returns how much creator coin a sell of
+// creatorCoinToSellNanos out of a balance of sellerBalanceNanos actually sells, and
+// the DeSo it returns before fees. The caller must check that creatorCoinToSellNanos
+// doesn't exceed sellerBalanceNanos.
+func (bav *UtxoView) _calculateCreatorCoinSellNanos(coinEntry *CoinEntry,
+	sellerBalanceNanos uint64, creatorCoinToSellNanos uint64, blockHeight uint32) (
+	_creatorCoinToSellNanos uint64, _desoBeforeFeesNanos uint64) {
+
+	// For CreatorCoins it's OK to cast to Uint64() because we check for their
+	// exceeding this everywhere.
+	desoBeforeFeesNanos := uint64(0)
+	if blockHeight > bav.Params.ForkHeights.SalomonFixBlockHeight {
+		// Following the SalomonFixBlockHeight block, if a user would be left with less than
+		// bav.Params.CreatorCoinAutoSellThresholdNanos, we clear all their remaining holdings
+		// to prevent 1 or 2 lingering creator coin nanos from staying in their wallet.
+		// This also gives a method for cleanly and accurately reducing the numberOfHolders.
+		if sellerBalanceNanos-creatorCoinToSellNanos < bav.Params.CreatorCoinAutoSellThresholdNanos {
+			// Setup to sell all the creator coins the seller has.
+			creatorCoinToSellNanos = sellerBalanceNanos
+		}
+		desoBeforeFeesNanos = CalculateDeSoToReturn(
+			creatorCoinToSellNanos, coinEntry.CoinsInCirculationNanos.Uint64(),
+			coinEntry.DeSoLockedNanos, bav.Params)
+
+	} else if creatorCoinToSellNanos == coinEntry.CoinsInCirculationNanos.Uint64() {
+		// Prior to the SalomonFixBlockHeight block, coins would be minted based on floating point
+		// arithmetic with the exception being if a creator was selling all remaining creator coins. This caused
+		// a rare issue where a creator would be left with 1 creator coin nano in circulation
+		// and 1 nano DeSo locked after completely selling. This in turn made the Bancor Curve unstable.
+		desoBeforeFeesNanos = coinEntry.DeSoLockedNanos
+
+	} else {
+		// Calculate the amount to return based on the Bancor Curve.
+		desoBeforeFeesNanos = CalculateDeSoToReturn(
+			creatorCoinToSellNanos, coinEntry.CoinsInCirculationNanos.Uint64(),
+			coinEntry.DeSoLockedNanos, bav.Params)
+	}
+
+	// If the amount the formula is offering is more than what is locked in the
+	// profile, then truncate it down. This addresses an edge case where our
+	// equations may return *too much* DeSo due to rounding errors.
+	if desoBeforeFeesNanos > coinEntry.DeSoLockedNanos {
+		desoBeforeFeesNanos = coinEntry.DeSoLockedNanos
+	}
+	return creatorCoinToSellNanos, desoBeforeFeesNanos
+}
+
 // TODO: A lot of duplicate code between buy and sell. Consider factoring
 // out the common code.
 func (bav *UtxoView) HelpConnectCreatorCoinBuy(
@@ -666,15 +809,7 @@ func (bav *UtxoView) HelpConnectCreatorCoinBuy(
 	// will not result in a user being able to print infinite amounts of DeSo
 	// through the protocol.
 	//
-	// TODO(performance): We use bigints to avoid overflow in the intermediate
-	// stages of the calculation but this most likely isn't necessary. This
-	// formula is equal to:
-	// - desoAfterFeesNanos = desoBeforeFeesNanos * (CreatorCoinTradeFeeBasisPoints / (100*100))
-	desoAfterFeesNanos := IntDiv(
-		IntMul(
-			big.NewInt(int64(desoBeforeFeesNanos)),
-			big.NewInt(int64(100*100-bav.Params.CreatorCoinTradeFeeBasisPoints))),
-		big.NewInt(100*100)).Uint64()
+	desoAfterFeesNanos := _applyCreatorCoinTradeFee(desoBeforeFeesNanos, bav.Params)
 
 	// The amount of DeSo being convertend must be nonzero after fees as well.
 	if desoAfterFeesNanos == 0 {
@@ -682,32 +817,12 @@ func (bav *UtxoView) HelpConnectCreatorCoinBuy(
 	}
 
 	// Figure out how much deso goes to the founder.
-	// Note: If the user performing this transaction has the same public key as the
-	// profile being bought, we do not cut a founder reward.
-	desoRemainingNanos := uint64(0)
-	desoFounderRewardNanos := uint64(0)
-	if blockHeight > bav.Params.ForkHeights.DeSoFounderRewardBlockHeight &&
-		!reflect.DeepEqual(txn.PublicKey, existingProfileEntry.PublicKey) {
-
-		// This formula is equal to:
-		// desoFounderRewardNanos = desoAfterFeesNanos * creatorBasisPoints / (100*100)
-		desoFounderRewardNanos = IntDiv(
-			IntMul(
-				big.NewInt(int64(desoAfterFeesNanos)),
-				big.NewInt(int64(existingProfileEntry.CreatorCoinEntry.CreatorBasisPoints))),
-			big.NewInt(100*100)).Uint64()
-
-		// Sanity check, just to be extra safe.
-		if desoAfterFeesNanos < desoFounderRewardNanos {
-			return 0, 0, 0, 0, nil, fmt.Errorf("HelpConnectCreatorCoinBuy: desoAfterFeesNanos"+
-				" less than desoFounderRewardNanos: %v %v",
-				desoAfterFeesNanos, desoFounderRewardNanos)
-		}
-
-		desoRemainingNanos = desoAfterFeesNanos - desoFounderRewardNanos
-	} else {
-		desoRemainingNanos = desoAfterFeesNanos
+	desoFounderRewardNanos, err := bav._calculateDeSoFounderRewardNanos(
+		txn.PublicKey, existingProfileEntry, desoAfterFeesNanos, blockHeight)
+	if err != nil {
+		return 0, 0, 0, 0, nil, errors.Wrapf(err, "HelpConnectCreatorCoinBuy: ")
 	}
+	desoRemainingNanos := desoAfterFeesNanos - desoFounderRewardNanos
 
 	if desoRemainingNanos == 0 {
 		return 0, 0, 0, 0, nil, RuleErrorCreatorCoinBuyMustTradeNonZeroDeSoAfterFounderReward
@@ -775,39 +890,8 @@ func (bav *UtxoView) HelpConnectCreatorCoinBuy(
 		existingProfileEntry.CreatorCoinEntry.CoinsInCirculationNanos.Uint64() + creatorCoinToMintNanos)
 
 	// Calculate the *Creator Coin nanos* to give as a founder reward.
-	creatorCoinFounderRewardNanos := uint64(0)
-	if blockHeight > bav.Params.ForkHeights.DeSoFounderRewardBlockHeight {
-		// Do nothing. The chain stopped minting creator coins as a founder reward for
-		// creators at this blockheight.  It gives DeSo as a founder reward now instead.
-
-	} else if blockHeight > bav.Params.ForkHeights.SalomonFixBlockHeight {
-		// Following the SalomonFixBlockHeight block, creator coin buys continuously mint
-		// a founders reward based on the CreatorBasisPoints.
-
-		creatorCoinFounderRewardNanos = IntDiv(
-			IntMul(
-				big.NewInt(int64(creatorCoinToMintNanos)),
-				big.NewInt(int64(existingProfileEntry.CreatorCoinEntry.CreatorBasisPoints))),
-			big.NewInt(100*100)).Uint64()
-	} else {
-		// Up to and including the SalomonFixBlockHeight block, creator coin buys only minted
-		// a founders reward if the creator reached a new all time high.
-
-		// For CreatorCoins it's OK to cast to Uint64() because we check for their
-		// exceeding this everywhere.
-		if existingProfileEntry.CreatorCoinEntry.CoinsInCirculationNanos.Uint64() > existingProfileEntry.CreatorCoinEntry.CoinWatermarkNanos {
-			// This value must be positive if we made it past the if condition above.
-			watermarkDiff := existingProfileEntry.CreatorCoinEntry.CoinsInCirculationNanos.Uint64() -
-				existingProfileEntry.CreatorCoinEntry.CoinWatermarkNanos
-			// The founder reward is computed as a percentage of the "net coins created,"
-			// which is equal to the watermarkDiff
-			creatorCoinFounderRewardNanos = IntDiv(
-				IntMul(
-					big.NewInt(int64(watermarkDiff)),
-					big.NewInt(int64(existingProfileEntry.CreatorCoinEntry.CreatorBasisPoints))),
-				big.NewInt(100*100)).Uint64()
-		}
-	}
+	creatorCoinFounderRewardNanos := bav._calculateCreatorCoinFounderRewardNanos(
+		&existingProfileEntry.CreatorCoinEntry, creatorCoinToMintNanos, blockHeight)
 
 	// CoinWatermarkNanos is no longer used, however it may be helpful for
 	// future analytics or updates so we continue to update it here.
@@ -1025,16 +1109,23 @@ func (bav *UtxoView) HelpConnectCreatorCoinBuy(
 	}
 	desoLockedNanosDiff := int64(existingProfileEntry.CreatorCoinEntry.DeSoLockedNanos) - int64(prevCoinEntry.DeSoLockedNanos)
 
+	// Record the new price in the coin's candle for this block. The volume is the
+	// DeSo that went into the curve.
+	prevCandleEntry := bav._connectCreatorCoinPricePoint(
+		bav.GetPKIDForPublicKey(existingProfileEntry.PublicKey).PKID, &prevCoinEntry,
+		&existingProfileEntry.CreatorCoinEntry, uint64(desoLockedNanosDiff), blockHeight)
+
 	// Add an operation to the list at the end indicating we've executed a
 	// CreatorCoin txn. Save the previous state of the CreatorCoinEntry for easy
 	// reversion during disconnect.
 	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
-		Type:                           OperationTypeCreatorCoin,
-		PrevCoinEntry:                  &prevCoinEntry,
-		PrevTransactorBalanceEntry:     &prevBuyerBalanceEntry,
-		PrevCreatorBalanceEntry:        &prevCreatorBalanceEntry,
-		FounderRewardUtxoKey:           outputKey,
-		CreatorCoinDESOLockedNanosDiff: desoLockedNanosDiff,
+		Type:                            OperationTypeCreatorCoin,
+		PrevCoinEntry:                   &prevCoinEntry,
+		PrevTransactorBalanceEntry:      &prevBuyerBalanceEntry,
+		PrevCreatorBalanceEntry:         &prevCreatorBalanceEntry,
+		FounderRewardUtxoKey:            outputKey,
+		CreatorCoinDESOLockedNanosDiff:  desoLockedNanosDiff,
+		PrevCreatorCoinPriceCandleEntry: prevCandleEntry,
 	})
 
 	return totalInput, totalOutput, coinsBuyerGetsNanos, creatorCoinFounderRewardNanos, utxoOpsForTxn, nil
@@ -1127,78 +1218,13 @@ func (bav *UtxoView) HelpConnectCreatorCoinSell(
 		return 0, 0, 0, nil, RuleErrorCreatorCoinSellNotAllowedWhenZeroDeSoLocked
 	}
 
-	desoBeforeFeesNanos := uint64(0)
-	// Compute the amount of DeSo to return.
-	if blockHeight > bav.Params.ForkHeights.SalomonFixBlockHeight {
-		// Following the SalomonFixBlockHeight block, if a user would be left with less than
-		// bav.Params.CreatorCoinAutoSellThresholdNanos, we clear all their remaining holdings
-		// to prevent 1 or 2 lingering creator coin nanos from staying in their wallet.
-		// This also gives a method for cleanly and accurately reducing the numberOfHolders.
-
-		// Note that we check that sellerBalanceEntry.BalanceNanos >= creatorCoinToSellNanos above.
-		//
-		// CreatorCoin balances can't exceed uint64
-		if sellerBalanceEntry.BalanceNanos.Uint64()-creatorCoinToSellNanos < bav.Params.CreatorCoinAutoSellThresholdNanos {
-			// Setup to sell all the creator coins the seller has.
-			//
-			// CreatorCoin balances can't exceed uint64
-			creatorCoinToSellNanos = sellerBalanceEntry.BalanceNanos.Uint64()
-
-			// Compute the amount of DeSo to return with the new creatorCoinToSellNanos.
-			//
-			// For CreatorCoins it's OK to cast to Uint64() because we check for their
-			// exceeding this everywhere.
-			desoBeforeFeesNanos = CalculateDeSoToReturn(
-				creatorCoinToSellNanos, existingProfileEntry.CreatorCoinEntry.CoinsInCirculationNanos.Uint64(),
-				existingProfileEntry.CreatorCoinEntry.DeSoLockedNanos, bav.Params)
-
-			// If the amount the formula is offering is more than what is locked in the
-			// profile, then truncate it down. This addresses an edge case where our
-			// equations may return *too much* DeSo due to rounding errors.
-			if desoBeforeFeesNanos > existingProfileEntry.CreatorCoinEntry.DeSoLockedNanos {
-				desoBeforeFeesNanos = existingProfileEntry.CreatorCoinEntry.DeSoLockedNanos
-			}
-		} else {
-			// If we're above the CreatorCoinAutoSellThresholdNanos, we can safely compute
-			// the amount to return based on the Bancor curve.
-			//
-			// For CreatorCoins it's OK to cast to Uint64() because we check for their
-			// exceeding this everywhere.
-			desoBeforeFeesNanos = CalculateDeSoToReturn(
-				creatorCoinToSellNanos, existingProfileEntry.CreatorCoinEntry.CoinsInCirculationNanos.Uint64(),
-				existingProfileEntry.CreatorCoinEntry.DeSoLockedNanos, bav.Params)
-
-			// If the amount the formula is offering is more than what is locked in the
-			// profile, then truncate it down. This addresses an edge case where our
-			// equations may return *too much* DeSo due to rounding errors.
-			if desoBeforeFeesNanos > existingProfileEntry.CreatorCoinEntry.DeSoLockedNanos {
-				desoBeforeFeesNanos = existingProfileEntry.CreatorCoinEntry.DeSoLockedNanos
-			}
-		}
-	} else {
-		// Prior to the SalomonFixBlockHeight block, coins would be minted based on floating point
-		// arithmetic with the exception being if a creator was selling all remaining creator coins. This caused
-		// a rare issue where a creator would be left with 1 creator coin nano in circulation
-		// and 1 nano DeSo locked after completely selling. This in turn made the Bancor Curve unstable.
-
-		// For CreatorCoins it's OK to cast to Uint64() because we check for their
-		// exceeding this everywhere.
-		if creatorCoinToSellNanos == existingProfileEntry.CreatorCoinEntry.CoinsInCirculationNanos.Uint64() {
-			desoBeforeFeesNanos = existingProfileEntry.CreatorCoinEntry.DeSoLockedNanos
-		} else {
-			// Calculate the amount to return based on the Bancor Curve.
-			desoBeforeFeesNanos = CalculateDeSoToReturn(
-				creatorCoinToSellNanos, existingProfileEntry.CreatorCoinEntry.CoinsInCirculationNanos.Uint64(),
-				existingProfileEntry.CreatorCoinEntry.DeSoLockedNanos, bav.Params)
-
-			// If the amount the formula is offering is more than what is locked in the
-			// profile, then truncate it down. This addresses an edge case where our
-			// equations may return *too much* DeSo due to rounding errors.
-			if desoBeforeFeesNanos > existingProfileEntry.CreatorCoinEntry.DeSoLockedNanos {
-				desoBeforeFeesNanos = existingProfileEntry.CreatorCoinEntry.DeSoLockedNanos
-			}
-		}
-	}
+	// Compute the amount of DeSo to return. This may bump creatorCoinToSellNanos
+	// up to the seller's whole balance.
+	//
+	// CreatorCoin balances can't exceed uint64
+	creatorCoinToSellNanos, desoBeforeFeesNanos := bav._calculateCreatorCoinSellNanos(
+		&existingProfileEntry.CreatorCoinEntry, sellerBalanceEntry.BalanceNanos.Uint64(),
+		creatorCoinToSellNanos, blockHeight)
 
 	// Save all the old values from the CreatorCoinEntry before we potentially
 	// update them. Note that CreatorCoinEntry doesn't contain any pointers and so
@@ -1274,11 +1300,7 @@ func (bav *UtxoView) HelpConnectCreatorCoinSell(
 
 	// Charge a fee on the DeSo the seller is getting to hedge against
 	// floating point errors
-	desoAfterFeesNanos := IntDiv(
-		IntMul(
-			big.NewInt(int64(desoBeforeFeesNanos)),
-			big.NewInt(int64(100*100-bav.Params.CreatorCoinTradeFeeBasisPoints))),
-		big.NewInt(100*100)).Uint64()
+	desoAfterFeesNanos := _applyCreatorCoinTradeFee(desoBeforeFeesNanos, bav.Params)
 
 	// Check that the seller is getting back an amount of DeSo that is
 	// greater than or equal to what they expect. Note that this check is
@@ -1331,15 +1353,22 @@ func (bav *UtxoView) HelpConnectCreatorCoinSell(
 	}
 	desoLockedNanosDiff := int64(existingProfileEntry.CreatorCoinEntry.DeSoLockedNanos) - int64(prevCoinEntry.DeSoLockedNanos)
 
+	// Record the new price in the coin's candle for this block. The volume is the
+	// DeSo that came out of the curve.
+	prevCandleEntry := bav._connectCreatorCoinPricePoint(
+		bav.GetPKIDForPublicKey(existingProfileEntry.PublicKey).PKID, &prevCoinEntry,
+		&existingProfileEntry.CreatorCoinEntry, uint64(-desoLockedNanosDiff), blockHeight)
+
 	// Add an operation to the list at the end indicating we've executed a
 	// CreatorCoin txn. Save the previous state of the CreatorCoinEntry for easy
 	// reversion during disconnect.
 	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
-		Type:                           OperationTypeCreatorCoin,
-		PrevCoinEntry:                  &prevCoinEntry,
-		PrevTransactorBalanceEntry:     &prevTransactorBalanceEntry,
-		PrevCreatorBalanceEntry:        nil,
-		CreatorCoinDESOLockedNanosDiff: desoLockedNanosDiff,
+		Type:                            OperationTypeCreatorCoin,
+		PrevCoinEntry:                   &prevCoinEntry,
+		PrevTransactorBalanceEntry:      &prevTransactorBalanceEntry,
+		PrevCreatorBalanceEntry:         nil,
+		CreatorCoinDESOLockedNanosDiff:  desoLockedNanosDiff,
+		PrevCreatorCoinPriceCandleEntry: prevCandleEntry,
 	})
 
 	// The DeSo that the user gets from selling their creator coin counts
@@ -1360,6 +1389,11 @@ func (bav *UtxoView) _connectCreatorCoin(
 	}
 	txMeta := txn.TxnMeta.(*CreatorCoinMetadataa)
 
+	// Trades that set a deadline fail once the chain has moved past it.
+	if err := bav._checkCreatorCoinDeadlineBlockHeight(txn, blockHeight); err != nil {
+		return 0, 0, nil, err
+	}
+
 	// We save the previous CreatorCoinEntry so that we can revert things easily during a
 	// disconnect. If we didn't do this, it would be annoying to reset the coin
 	// state when reverting a transaction.
@@ -1384,6 +1418,30 @@ func (bav *UtxoView) _connectCreatorCoin(
 		"OperationType: %v", txMeta.OperationType)
 }
 
+// _checkCreatorCoinDeadlineBlockHeight returns an error if a creator coin txn sets a
+// deadline in its extra data and blockHeight is past it. The key is ignored before the
+// CreatorCoinTradeDeadlineBlockHeight.
+func (bav *UtxoView) _checkCreatorCoinDeadlineBlockHeight(txn *MsgDeSoTxn, blockHeight uint32) error {
+	if blockHeight < bav.Params.ForkHeights.CreatorCoinTradeDeadlineBlockHeight {
+		return nil
+	}
+	deadlineBytes, hasDeadline := txn.ExtraData[CreatorCoinDeadlineBlockHeightKey]
+	if !hasDeadline {
+		return nil
+	}
+	deadlineBlockHeight, bytesRead := Uvarint(deadlineBytes)
+	if bytesRead <= 0 || bytesRead != len(deadlineBytes) {
+		return errors.Wrapf(RuleErrorCreatorCoinInvalidDeadlineBlockHeight,
+			"_checkCreatorCoinDeadlineBlockHeight: Problem reading bytes for %v", CreatorCoinDeadlineBlockHeightKey)
+	}
+	if uint64(blockHeight) > deadlineBlockHeight {
+		return errors.Wrapf(RuleErrorCreatorCoinPastDeadlineBlockHeight,
+			"_checkCreatorCoinDeadlineBlockHeight: Block height %v is past deadline %v",
+			blockHeight, deadlineBlockHeight)
+	}
+	return nil
+}
+
 func (bav *UtxoView) _connectCreatorCoinTransfer(
 	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
 	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
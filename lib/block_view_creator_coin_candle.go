@@ -0,0 +1,236 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// block_view_creator_coin_candle.go records a price history for creator coins. Every
+// buy, sell or diamond transfer that changes a coin's CoinEntry updates the coin's
+// candle for the current block, creating it if it's the first change in the block.
+// The per-block candles are what get stored, and GetCreatorCoinPriceCandles merges
+// them into candles spanning any number of blocks when they're fetched.
+
+// CalculateCreatorCoinPriceNanos returns the spot price of one whole creator coin in
+// DeSo nanos. Under the Bancor curve this is the DeSo locked divided by the reserve
+// ratio times the coins in circulation.
+func CalculateCreatorCoinPriceNanos(coinEntry *CoinEntry, params *DeSoParams) uint64 {
+	// CreatorCoins can't exceed a uint64
+	coinsInCirculationNanos := coinEntry.CoinsInCirculationNanos.Uint64()
+	if coinsInCirculationNanos == 0 {
+		return 0
+	}
+
+	bigNanosPerUnit := NewFloat().SetUint64(NanosPerUnit)
+	bigCoinsInCirculation := Div(NewFloat().SetUint64(coinsInCirculationNanos), bigNanosPerUnit)
+	bigPriceNanos := Div(NewFloat().SetUint64(coinEntry.DeSoLockedNanos),
+		Mul(params.CreatorCoinReserveRatio, bigCoinsInCirculation))
+	priceNanos, _ := bigPriceNanos.Uint64()
+	return priceNanos
+}
+
+func (bav *UtxoView) _setCreatorCoinPriceCandleEntryMappings(candleEntry *CreatorCoinPriceCandleEntry) {
+	// This function shouldn't be called with nil.
+	if candleEntry == nil {
+		glog.Errorf("_setCreatorCoinPriceCandleEntryMappings: Called with nil CreatorCoinPriceCandleEntry; " +
+			"this should never happen.")
+		return
+	}
+
+	candleKey := MakeCreatorCoinPriceCandleKey(candleEntry.CreatorPKID, candleEntry.StartBlockHeight)
+	bav.CreatorCoinPriceCandleKeyToCandleEntry[candleKey] = candleEntry
+}
+
+func (bav *UtxoView) _deleteCreatorCoinPriceCandleEntryMappings(candleEntry *CreatorCoinPriceCandleEntry) {
+
+	// Create a tombstone entry.
+	tombstoneCandleEntry := *candleEntry
+	tombstoneCandleEntry.isDeleted = true
+
+	// Set the mappings to point to the tombstone entry.
+	bav._setCreatorCoinPriceCandleEntryMappings(&tombstoneCandleEntry)
+}
+
+// GetCreatorCoinPriceCandleEntry returns the candle for a creator coin at the given
+// block height, or nil if the coin didn't change in that block.
+func (bav *UtxoView) GetCreatorCoinPriceCandleEntry(creatorPKID *PKID, blockHeight uint32) *CreatorCoinPriceCandleEntry {
+	// If an entry exists in the in-memory map, return the value of that mapping.
+	candleKey := MakeCreatorCoinPriceCandleKey(creatorPKID, blockHeight)
+	if mapValue, existsMapValue := bav.CreatorCoinPriceCandleKeyToCandleEntry[candleKey]; existsMapValue {
+		if mapValue.isDeleted {
+			return nil
+		}
+		return mapValue
+	}
+
+	// If we get here it means no value exists in our in-memory map. In this case,
+	// defer to the db. If a mapping exists in the db, return it. If not, return
+	// nil. Either way, save the value to the in-memory view mapping got later.
+	var candleEntry *CreatorCoinPriceCandleEntry
+	if bav.Postgres != nil {
+		if candle := bav.Postgres.GetCreatorCoinPriceCandle(creatorPKID, blockHeight); candle != nil {
+			candleEntry = candle.NewCreatorCoinPriceCandleEntry()
+		}
+	} else {
+		candleEntry = DbGetCreatorCoinPriceCandleEntry(bav.Handle, creatorPKID, blockHeight)
+	}
+	if candleEntry != nil {
+		bav._setCreatorCoinPriceCandleEntryMappings(candleEntry)
+	}
+	return candleEntry
+}
+
+// GetCreatorCoinPriceCandles returns the candles for a creator's coin with heights
+// in [startHeight, endHeight], each covering blocksPerCandle blocks counting from
+// startHeight. Candles whose blocks saw no trades are left out.
+func (bav *UtxoView) GetCreatorCoinPriceCandles(creatorPublicKey []byte, startHeight uint32,
+	endHeight uint32, blocksPerCandle uint32) ([]*CreatorCoinPriceCandleEntry, error) {
+
+	if blocksPerCandle == 0 {
+		return nil, fmt.Errorf("GetCreatorCoinPriceCandles: blocksPerCandle must be non-zero")
+	}
+	if startHeight > endHeight {
+		return nil, fmt.Errorf("GetCreatorCoinPriceCandles: startHeight %d is greater "+
+			"than endHeight %d", startHeight, endHeight)
+	}
+	creatorPKIDEntry := bav.GetPKIDForPublicKey(creatorPublicKey)
+	if creatorPKIDEntry == nil || creatorPKIDEntry.isDeleted {
+		return nil, fmt.Errorf("GetCreatorCoinPriceCandles: No PKID found for public key %v",
+			PkToStringBoth(creatorPublicKey))
+	}
+	creatorPKID := creatorPKIDEntry.PKID
+
+	// Load the candles from the db into the view first so that entries modified
+	// in the view take precedence.
+	var dbCandleEntries []*CreatorCoinPriceCandleEntry
+	if bav.Postgres != nil {
+		for _, candle := range bav.Postgres.GetCreatorCoinPriceCandles(creatorPKID, startHeight, endHeight) {
+			dbCandleEntries = append(dbCandleEntries, candle.NewCreatorCoinPriceCandleEntry())
+		}
+	} else {
+		var err error
+		dbCandleEntries, err = DbGetCreatorCoinPriceCandleEntriesForHeightRange(
+			bav.Handle, creatorPKID, startHeight, endHeight)
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetCreatorCoinPriceCandles: ")
+		}
+	}
+	for _, candleEntry := range dbCandleEntries {
+		candleKey := MakeCreatorCoinPriceCandleKey(candleEntry.CreatorPKID, candleEntry.StartBlockHeight)
+		if _, exists := bav.CreatorCoinPriceCandleKeyToCandleEntry[candleKey]; !exists {
+			bav._setCreatorCoinPriceCandleEntryMappings(candleEntry)
+		}
+	}
+
+	var blockCandleEntries []*CreatorCoinPriceCandleEntry
+	for candleKey, candleEntry := range bav.CreatorCoinPriceCandleKeyToCandleEntry {
+		if candleEntry.isDeleted || candleKey.CreatorPKID != *creatorPKID ||
+			candleKey.BlockHeight < startHeight || candleKey.BlockHeight > endHeight {
+			continue
+		}
+		blockCandleEntries = append(blockCandleEntries, candleEntry)
+	}
+	sort.Slice(blockCandleEntries, func(ii, jj int) bool {
+		return blockCandleEntries[ii].StartBlockHeight < blockCandleEntries[jj].StartBlockHeight
+	})
+
+	// Merge the per-block candles into candles of the requested width.
+	var candleEntries []*CreatorCoinPriceCandleEntry
+	for _, blockCandleEntry := range blockCandleEntries {
+		candleStartHeight := blockCandleEntry.StartBlockHeight -
+			(blockCandleEntry.StartBlockHeight-startHeight)%blocksPerCandle
+		if len(candleEntries) == 0 || candleEntries[len(candleEntries)-1].StartBlockHeight != candleStartHeight {
+			candleEndHeight := uint64(candleStartHeight) + uint64(blocksPerCandle) - 1
+			if candleEndHeight > uint64(endHeight) {
+				candleEndHeight = uint64(endHeight)
+			}
+			candleEntries = append(candleEntries, &CreatorCoinPriceCandleEntry{
+				CreatorPKID:      creatorPKID,
+				StartBlockHeight: candleStartHeight,
+				EndBlockHeight:   uint32(candleEndHeight),
+				OpenPriceNanos:   blockCandleEntry.OpenPriceNanos,
+				HighPriceNanos:   blockCandleEntry.HighPriceNanos,
+				LowPriceNanos:    blockCandleEntry.LowPriceNanos,
+			})
+		}
+		candleEntry := candleEntries[len(candleEntries)-1]
+		if blockCandleEntry.HighPriceNanos > candleEntry.HighPriceNanos {
+			candleEntry.HighPriceNanos = blockCandleEntry.HighPriceNanos
+		}
+		if blockCandleEntry.LowPriceNanos < candleEntry.LowPriceNanos {
+			candleEntry.LowPriceNanos = blockCandleEntry.LowPriceNanos
+		}
+		candleEntry.ClosePriceNanos = blockCandleEntry.ClosePriceNanos
+		candleEntry.VolumeNanos = _saturatingAddUint64(candleEntry.VolumeNanos, blockCandleEntry.VolumeNanos)
+		candleEntry.NumTrades += blockCandleEntry.NumTrades
+	}
+
+	return candleEntries, nil
+}
+
+// _connectCreatorCoinPricePoint adds a trade that moved a creator coin from
+// prevCoinEntry to newCoinEntry to the coin's candle for the current block. It
+// returns the candle as it was before the trade, or nil if the trade created it,
+// so that it can be restored on disconnect.
+func (bav *UtxoView) _connectCreatorCoinPricePoint(creatorPKID *PKID, prevCoinEntry *CoinEntry,
+	newCoinEntry *CoinEntry, volumeNanos uint64, blockHeight uint32) *CreatorCoinPriceCandleEntry {
+
+	prevPriceNanos := CalculateCreatorCoinPriceNanos(prevCoinEntry, bav.Params)
+	newPriceNanos := CalculateCreatorCoinPriceNanos(newCoinEntry, bav.Params)
+
+	var prevCandleEntry *CreatorCoinPriceCandleEntry
+	newCandleEntry := &CreatorCoinPriceCandleEntry{
+		CreatorPKID:      creatorPKID,
+		StartBlockHeight: blockHeight,
+		EndBlockHeight:   blockHeight,
+		OpenPriceNanos:   prevPriceNanos,
+		HighPriceNanos:   prevPriceNanos,
+		LowPriceNanos:    prevPriceNanos,
+	}
+	if existingCandleEntry := bav.GetCreatorCoinPriceCandleEntry(creatorPKID, blockHeight); existingCandleEntry != nil {
+		// Make a copy of the existing entry so that it can be restored on disconnect.
+		prevCandleEntryCopy := *existingCandleEntry
+		prevCandleEntry = &prevCandleEntryCopy
+		candleEntryCopy := *existingCandleEntry
+		newCandleEntry = &candleEntryCopy
+	}
+
+	if newPriceNanos > newCandleEntry.HighPriceNanos {
+		newCandleEntry.HighPriceNanos = newPriceNanos
+	}
+	if newPriceNanos < newCandleEntry.LowPriceNanos {
+		newCandleEntry.LowPriceNanos = newPriceNanos
+	}
+	newCandleEntry.ClosePriceNanos = newPriceNanos
+	newCandleEntry.VolumeNanos = _saturatingAddUint64(newCandleEntry.VolumeNanos, volumeNanos)
+	newCandleEntry.NumTrades++
+	bav._setCreatorCoinPriceCandleEntryMappings(newCandleEntry)
+
+	return prevCandleEntry
+}
+
+// _disconnectCreatorCoinPricePoint restores a creator coin's candle for the block to
+// what it was before a trade was connected.
+func (bav *UtxoView) _disconnectCreatorCoinPricePoint(creatorPKID *PKID, blockHeight uint32,
+	prevCandleEntry *CreatorCoinPriceCandleEntry) {
+
+	if candleEntry := bav.GetCreatorCoinPriceCandleEntry(creatorPKID, blockHeight); candleEntry != nil {
+		bav._deleteCreatorCoinPriceCandleEntryMappings(candleEntry)
+	}
+	if prevCandleEntry != nil {
+		bav._setCreatorCoinPriceCandleEntryMappings(prevCandleEntry)
+	}
+}
+
+// _saturatingAddUint64 adds two uint64s, capping the result at math.MaxUint64. It's
+// only used for statistics where an overflow shouldn't fail a txn.
+func _saturatingAddUint64(aa uint64, bb uint64) uint64 {
+	if aa > math.MaxUint64-bb {
+		return math.MaxUint64
+	}
+	return aa + bb
+}
@@ -0,0 +1,270 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateCreatorCoinPriceNanos(t *testing.T) {
+	require := require.New(t)
+
+	params := &DeSoTestnetParams
+
+	// A coin with nothing in circulation has no price.
+	require.Equal(uint64(0), CalculateCreatorCoinPriceNanos(&CoinEntry{DeSoLockedNanos: 100}, params))
+
+	// With 1 DeSo locked behind 3 coins the price is 1 / (RR * 3), which is just over
+	// 1 DeSo per coin since RR is just under a third.
+	coinEntry := &CoinEntry{DeSoLockedNanos: NanosPerUnit}
+	coinEntry.CoinsInCirculationNanos.SetUint64(3 * NanosPerUnit)
+	priceNanos := CalculateCreatorCoinPriceNanos(coinEntry, params)
+	require.Greater(priceNanos, uint64(NanosPerUnit))
+	require.Less(priceNanos, uint64(NanosPerUnit+1000))
+}
+
+func TestCreatorCoinPriceCandles(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	params.ForkHeights.SalomonFixBlockHeight = 0
+	params.ForkHeights.BuyCreatorCoinAfterDeletedBalanceEntryFixBlockHeight = 0
+	params.ForkHeights.DeSoFounderRewardBlockHeight = 0
+
+	// Mine a few blocks to give the senderPkString some money.
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	// We build the testMeta obj after mining blocks so that we save the correct block height.
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 1000000000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 1000000000)
+
+	m0PKID := DBGetPKIDEntryForPublicKey(db, m0PkBytes).PKID
+	blockHeight := chain.blockTip().Height + 1
+
+	getCandleEntry := func() *CreatorCoinPriceCandleEntry {
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		return utxoView.GetCreatorCoinPriceCandleEntry(m0PKID, blockHeight)
+	}
+	getPriceNanos := func() uint64 {
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		profileEntry := utxoView.GetProfileEntryForPublicKey(m0PkBytes)
+		return CalculateCreatorCoinPriceNanos(&profileEntry.CreatorCoinEntry, params)
+	}
+
+	_updateProfileWithTestMeta(
+		testMeta,
+		10,            /*feeRateNanosPerKB*/
+		m0Pub,         /*updaterPkBase58Check*/
+		m0Priv,        /*updaterPrivBase58Check*/
+		[]byte{},      /*profilePubKey*/
+		"m0",          /*newUsername*/
+		"i am the m0", /*newDescription*/
+		shortPic,      /*newProfilePic*/
+		10*100,        /*newCreatorBasisPoints*/
+		1.25*100*100,  /*newStakeMultipleBasisPoints*/
+		false /*isHidden*/)
+
+	// Updating a profile doesn't record a price.
+	require.Nil(getCandleEntry())
+
+	// m1 buys some of m0's coin, which opens the candle for this block at zero.
+	_creatorCoinTxnWithTestMeta(
+		testMeta,
+		10,     /*feeRateNanosPerKB*/
+		m1Pub,  /*updaterPkBase58Check*/
+		m1Priv, /*updaterPrivBase58Check*/
+		m0Pub,  /*profilePubKeyBase58Check*/
+		CreatorCoinOperationTypeBuy,
+		100000000, /*DeSoToSellNanos*/
+		0,         /*CreatorCoinToSellNanos*/
+		0,         /*DeSoToAddNanos*/
+		0,         /*MinDeSoExpectedNanos*/
+		0,         /*MinCreatorCoinExpectedNanos*/
+	)
+	firstPriceNanos := getPriceNanos()
+	firstDeSoLockedNanos, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+	{
+		candleEntry := getCandleEntry()
+		require.NotNil(candleEntry)
+		require.Equal(blockHeight, candleEntry.StartBlockHeight)
+		require.Equal(blockHeight, candleEntry.EndBlockHeight)
+		require.Equal(uint64(0), candleEntry.OpenPriceNanos)
+		require.Equal(uint64(0), candleEntry.LowPriceNanos)
+		require.Equal(firstPriceNanos, candleEntry.HighPriceNanos)
+		require.Equal(firstPriceNanos, candleEntry.ClosePriceNanos)
+		require.Equal(firstDeSoLockedNanos, candleEntry.VolumeNanos)
+		require.Equal(uint64(1), candleEntry.NumTrades)
+	}
+
+	// m1 buys more, pushing the price up.
+	_creatorCoinTxnWithTestMeta(
+		testMeta,
+		10,     /*feeRateNanosPerKB*/
+		m1Pub,  /*updaterPkBase58Check*/
+		m1Priv, /*updaterPrivBase58Check*/
+		m0Pub,  /*profilePubKeyBase58Check*/
+		CreatorCoinOperationTypeBuy,
+		200000000, /*DeSoToSellNanos*/
+		0,         /*CreatorCoinToSellNanos*/
+		0,         /*DeSoToAddNanos*/
+		0,         /*MinDeSoExpectedNanos*/
+		0,         /*MinCreatorCoinExpectedNanos*/
+	)
+	secondPriceNanos := getPriceNanos()
+	secondDeSoLockedNanos, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+	require.Greater(secondPriceNanos, firstPriceNanos)
+	{
+		candleEntry := getCandleEntry()
+		require.Equal(uint64(0), candleEntry.OpenPriceNanos)
+		require.Equal(secondPriceNanos, candleEntry.HighPriceNanos)
+		require.Equal(secondPriceNanos, candleEntry.ClosePriceNanos)
+		require.Equal(secondDeSoLockedNanos, candleEntry.VolumeNanos)
+		require.Equal(uint64(2), candleEntry.NumTrades)
+	}
+
+	// m1 sells half of their coins, bringing the price back down.
+	{
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		m1BalanceEntry, _, _ := utxoView.GetCreatorCoinBalanceEntryForHODLerPubKeyAndCreatorPubKey(m1PkBytes, m0PkBytes)
+		_creatorCoinTxnWithTestMeta(
+			testMeta,
+			10,     /*feeRateNanosPerKB*/
+			m1Pub,  /*updaterPkBase58Check*/
+			m1Priv, /*updaterPrivBase58Check*/
+			m0Pub,  /*profilePubKeyBase58Check*/
+			CreatorCoinOperationTypeSell,
+			0,                                      /*DeSoToSellNanos*/
+			m1BalanceEntry.BalanceNanos.Uint64()/2, /*CreatorCoinToSellNanos*/
+			0,                                      /*DeSoToAddNanos*/
+			0,                                      /*MinDeSoExpectedNanos*/
+			0,                                      /*MinCreatorCoinExpectedNanos*/
+		)
+	}
+	thirdPriceNanos := getPriceNanos()
+	thirdDeSoLockedNanos, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+	require.Less(thirdPriceNanos, secondPriceNanos)
+	{
+		candleEntry := getCandleEntry()
+		require.Equal(uint64(0), candleEntry.OpenPriceNanos)
+		require.Equal(uint64(0), candleEntry.LowPriceNanos)
+		require.Equal(secondPriceNanos, candleEntry.HighPriceNanos)
+		require.Equal(thirdPriceNanos, candleEntry.ClosePriceNanos)
+		require.Equal(secondDeSoLockedNanos+(secondDeSoLockedNanos-thirdDeSoLockedNanos), candleEntry.VolumeNanos)
+		require.Equal(uint64(3), candleEntry.NumTrades)
+	}
+
+	// Fetching candles over a range merges the block's candle into a wider one.
+	{
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		candleEntries, err := utxoView.GetCreatorCoinPriceCandles(m0PkBytes, 0, blockHeight+10, 100)
+		require.NoError(err)
+		require.Len(candleEntries, 1)
+		require.Equal(uint32(0), candleEntries[0].StartBlockHeight)
+		require.Equal(blockHeight+10, candleEntries[0].EndBlockHeight)
+		require.Equal(secondPriceNanos, candleEntries[0].HighPriceNanos)
+		require.Equal(thirdPriceNanos, candleEntries[0].ClosePriceNanos)
+		require.Equal(uint64(3), candleEntries[0].NumTrades)
+
+		candleEntries, err = utxoView.GetCreatorCoinPriceCandles(m0PkBytes, blockHeight+1, blockHeight+10, 1)
+		require.NoError(err)
+		require.Empty(candleEntries)
+
+		_, err = utxoView.GetCreatorCoinPriceCandles(m0PkBytes, 0, blockHeight, 0)
+		require.Error(err)
+	}
+
+	// Roll all successful txns through connect and disconnect loops to make sure nothing breaks.
+	_rollBackTestMetaTxnsAndFlush(testMeta)
+	require.Nil(getCandleEntry())
+	_applyTestMetaTxnsToMempool(testMeta)
+	_applyTestMetaTxnsToViewAndFlush(testMeta)
+	require.Equal(uint64(3), getCandleEntry().NumTrades)
+	_disconnectTestMetaTxnsFromViewAndFlush(testMeta)
+	require.Nil(getCandleEntry())
+	_connectBlockThenDisconnectBlockAndFlush(testMeta)
+	require.Nil(getCandleEntry())
+}
+
+func TestCreatorCoinPriceCandlesRollUp(t *testing.T) {
+	require := require.New(t)
+
+	_, params, db := NewLowDifficultyBlockchain()
+	m0PKID := &PKID{}
+	copy(m0PKID[:], m0PkBytes)
+
+	// Store per-block candles for m0 at a few heights.
+	{
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		for _, candleEntry := range []*CreatorCoinPriceCandleEntry{
+			{StartBlockHeight: 100, OpenPriceNanos: 10, HighPriceNanos: 12, LowPriceNanos: 9, ClosePriceNanos: 11, VolumeNanos: 5, NumTrades: 2},
+			{StartBlockHeight: 101, OpenPriceNanos: 11, HighPriceNanos: 20, LowPriceNanos: 11, ClosePriceNanos: 15, VolumeNanos: 7, NumTrades: 1},
+			{StartBlockHeight: 105, OpenPriceNanos: 15, HighPriceNanos: 15, LowPriceNanos: 3, ClosePriceNanos: 4, VolumeNanos: 9, NumTrades: 3},
+			{StartBlockHeight: 112, OpenPriceNanos: 4, HighPriceNanos: 6, LowPriceNanos: 4, ClosePriceNanos: 6, VolumeNanos: 1, NumTrades: 1},
+			{StartBlockHeight: 120, OpenPriceNanos: 6, HighPriceNanos: 8, LowPriceNanos: 6, ClosePriceNanos: 8, VolumeNanos: 1, NumTrades: 1},
+		} {
+			candleEntry.CreatorPKID = m0PKID
+			candleEntry.EndBlockHeight = candleEntry.StartBlockHeight
+			utxoView._setCreatorCoinPriceCandleEntryMappings(candleEntry)
+		}
+		require.NoError(utxoView.FlushToDb())
+	}
+
+	dbCandleEntries, err := DbGetCreatorCoinPriceCandleEntriesForHeightRange(db, m0PKID, 101, 112)
+	require.NoError(err)
+	require.Len(dbCandleEntries, 3)
+	require.Equal(uint32(101), dbCandleEntries[0].StartBlockHeight)
+	require.Equal(uint32(112), dbCandleEntries[2].StartBlockHeight)
+
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+	candleEntries, err := utxoView.GetCreatorCoinPriceCandles(m0PkBytes, 100, 114, 5)
+	require.NoError(err)
+	require.Len(candleEntries, 3)
+
+	require.Equal(uint32(100), candleEntries[0].StartBlockHeight)
+	require.Equal(uint32(104), candleEntries[0].EndBlockHeight)
+	require.Equal(uint64(10), candleEntries[0].OpenPriceNanos)
+	require.Equal(uint64(20), candleEntries[0].HighPriceNanos)
+	require.Equal(uint64(9), candleEntries[0].LowPriceNanos)
+	require.Equal(uint64(15), candleEntries[0].ClosePriceNanos)
+	require.Equal(uint64(12), candleEntries[0].VolumeNanos)
+	require.Equal(uint64(3), candleEntries[0].NumTrades)
+
+	require.Equal(uint32(105), candleEntries[1].StartBlockHeight)
+	require.Equal(uint64(3), candleEntries[1].LowPriceNanos)
+	require.Equal(uint64(4), candleEntries[1].ClosePriceNanos)
+
+	// The last candle is cut off at the end of the range.
+	require.Equal(uint32(110), candleEntries[2].StartBlockHeight)
+	require.Equal(uint32(114), candleEntries[2].EndBlockHeight)
+	require.Equal(uint64(6), candleEntries[2].ClosePriceNanos)
+
+	// Entries modified in the view take precedence over the db.
+	utxoView._deleteCreatorCoinPriceCandleEntryMappings(dbCandleEntries[1])
+	candleEntries, err = utxoView.GetCreatorCoinPriceCandles(m0PkBytes, 100, 114, 5)
+	require.NoError(err)
+	require.Len(candleEntries, 2)
+}
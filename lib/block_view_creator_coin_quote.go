@@ -0,0 +1,210 @@
+package lib
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/holiman/uint256"
+	"github.com/pkg/errors"
+)
+
+// block_view_creator_coin_quote.go computes what a creator coin buy or sell would
+// yield without connecting it. The fee, founder reward and curve math is shared with
+// HelpConnectCreatorCoinBuy and HelpConnectCreatorCoinSell so that a quote computed
+// against the view a trade will be connected on top of matches what the trade gets,
+// which is what a wallet needs to pick sensible MinCreatorCoinExpectedNanos and
+// MinDeSoExpectedNanos values.
+
+// CreatorCoinQuote describes the outcome of a creator coin trade.
+type CreatorCoinQuote struct {
+	OperationType CreatorCoinOperationType
+
+	// AmountInNanos is the DeSo spent on a buy or the creator coin sold on a sell.
+	// For a sell this can be more than what was asked for when the seller would be
+	// left with less than CreatorCoinAutoSellThresholdNanos, in which case their
+	// whole balance is sold.
+	AmountInNanos uint64
+	// AmountOutNanos is the creator coin the buyer gets on a buy or the DeSo the
+	// seller gets on a sell, net of fees and founder rewards. This is the amount
+	// MinCreatorCoinExpectedNanos or MinDeSoExpectedNanos is checked against.
+	AmountOutNanos uint64
+
+	// TradeFeeNanos is the DeSo burned by CreatorCoinTradeFeeBasisPoints.
+	TradeFeeNanos uint64
+	// The founder reward is paid in DeSo after the DeSoFounderRewardBlockHeight and
+	// in creator coin before it. At most one of these is non-zero.
+	DeSoFounderRewardNanos        uint64
+	CreatorCoinFounderRewardNanos uint64
+
+	// The spot price of one whole creator coin before and after the trade, and how
+	// far the trade moves it in basis points of the price before the trade.
+	SpotPriceBeforeNanos   uint64
+	SpotPriceAfterNanos    uint64
+	PriceImpactBasisPoints uint64
+}
+
+// GetCreatorCoinQuote returns what a creator coin trade by transactorPublicKey on
+// profilePublicKey's coin would yield if it were connected at blockHeight on top of
+// this view. amountNanos is the DeSo to spend on a buy or the creator coin to sell
+// on a sell. Checks on the trade amounts return the same RuleErrors connecting the
+// txn would. The checks on the buyer's and creator's resulting balances are not
+// repeated here.
+func (bav *UtxoView) GetCreatorCoinQuote(transactorPublicKey []byte, profilePublicKey []byte,
+	operationType CreatorCoinOperationType, amountNanos uint64, blockHeight uint32) (*CreatorCoinQuote, error) {
+
+	if len(profilePublicKey) != btcec.PubKeyBytesLenCompressed {
+		return nil, RuleErrorCreatorCoinInvalidPubKeySize
+	}
+	profileEntry := bav.GetProfileEntryForPublicKey(profilePublicKey)
+	if profileEntry == nil || profileEntry.isDeleted {
+		return nil, errors.Wrapf(
+			RuleErrorCreatorCoinOperationOnNonexistentProfile,
+			"GetCreatorCoinQuote: Profile pub key: %v", PkToStringBoth(profilePublicKey))
+	}
+
+	var quote *CreatorCoinQuote
+	var err error
+	switch operationType {
+	case CreatorCoinOperationTypeBuy:
+		quote, err = bav._getCreatorCoinBuyQuote(transactorPublicKey, profileEntry, amountNanos, blockHeight)
+	case CreatorCoinOperationTypeSell:
+		quote, err = bav._getCreatorCoinSellQuote(transactorPublicKey, profileEntry, amountNanos, blockHeight)
+	default:
+		return nil, fmt.Errorf("GetCreatorCoinQuote: Unsupported CreatorCoin OperationType: %v",
+			operationType)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetCreatorCoinQuote: ")
+	}
+
+	quote.OperationType = operationType
+	quote.PriceImpactBasisPoints = _calculatePriceImpactBasisPoints(
+		quote.SpotPriceBeforeNanos, quote.SpotPriceAfterNanos)
+	return quote, nil
+}
+
+func (bav *UtxoView) _getCreatorCoinBuyQuote(buyerPublicKey []byte, profileEntry *ProfileEntry,
+	desoBeforeFeesNanos uint64, blockHeight uint32) (*CreatorCoinQuote, error) {
+
+	// CreatorCoinEntry doesn't contain any pointers so this copy is safe to modify.
+	coinEntry := profileEntry.CreatorCoinEntry
+
+	if desoBeforeFeesNanos == 0 {
+		return nil, RuleErrorCreatorCoinBuyMustTradeNonZeroDeSo
+	}
+	desoAfterFeesNanos := _applyCreatorCoinTradeFee(desoBeforeFeesNanos, bav.Params)
+	if desoAfterFeesNanos == 0 {
+		return nil, RuleErrorCreatorCoinBuyMustTradeNonZeroDeSoAfterFees
+	}
+
+	desoFounderRewardNanos, err := bav._calculateDeSoFounderRewardNanos(
+		buyerPublicKey, profileEntry, desoAfterFeesNanos, blockHeight)
+	if err != nil {
+		return nil, errors.Wrapf(err, "_getCreatorCoinBuyQuote: ")
+	}
+	desoRemainingNanos := desoAfterFeesNanos - desoFounderRewardNanos
+	if desoRemainingNanos == 0 {
+		return nil, RuleErrorCreatorCoinBuyMustTradeNonZeroDeSoAfterFounderReward
+	}
+
+	// For CreatorCoins it's OK to cast to Uint64() because we check for their
+	// exceeding this everywhere.
+	creatorCoinToMintNanos := CalculateCreatorCoinToMint(
+		desoRemainingNanos, coinEntry.CoinsInCirculationNanos.Uint64(), coinEntry.DeSoLockedNanos, bav.Params)
+	if blockHeight > bav.Params.ForkHeights.SalomonFixBlockHeight &&
+		creatorCoinToMintNanos < bav.Params.CreatorCoinAutoSellThresholdNanos {
+		return nil, RuleErrorCreatorCoinBuyMustSatisfyAutoSellThresholdNanos
+	}
+
+	spotPriceBeforeNanos := CalculateCreatorCoinPriceNanos(&coinEntry, bav.Params)
+	coinEntry.DeSoLockedNanos += desoRemainingNanos
+	coinEntry.CoinsInCirculationNanos = *uint256.NewInt().SetUint64(
+		coinEntry.CoinsInCirculationNanos.Uint64() + creatorCoinToMintNanos)
+
+	creatorCoinFounderRewardNanos := bav._calculateCreatorCoinFounderRewardNanos(
+		&coinEntry, creatorCoinToMintNanos, blockHeight)
+
+	return &CreatorCoinQuote{
+		AmountInNanos:                 desoBeforeFeesNanos,
+		AmountOutNanos:                creatorCoinToMintNanos - creatorCoinFounderRewardNanos,
+		TradeFeeNanos:                 desoBeforeFeesNanos - desoAfterFeesNanos,
+		DeSoFounderRewardNanos:        desoFounderRewardNanos,
+		CreatorCoinFounderRewardNanos: creatorCoinFounderRewardNanos,
+		SpotPriceBeforeNanos:          spotPriceBeforeNanos,
+		SpotPriceAfterNanos:           CalculateCreatorCoinPriceNanos(&coinEntry, bav.Params),
+	}, nil
+}
+
+func (bav *UtxoView) _getCreatorCoinSellQuote(sellerPublicKey []byte, profileEntry *ProfileEntry,
+	creatorCoinToSellNanos uint64, blockHeight uint32) (*CreatorCoinQuote, error) {
+
+	// CreatorCoinEntry doesn't contain any pointers so this copy is safe to modify.
+	coinEntry := profileEntry.CreatorCoinEntry
+
+	sellerBalanceEntry, _, _ := bav.GetCreatorCoinBalanceEntryForHODLerPubKeyAndCreatorPubKey(
+		sellerPublicKey, profileEntry.PublicKey)
+	if sellerBalanceEntry == nil || sellerBalanceEntry.isDeleted {
+		return nil, RuleErrorCreatorCoinSellerBalanceEntryDoesNotExist
+	}
+	if creatorCoinToSellNanos == 0 {
+		return nil, RuleErrorCreatorCoinSellMustTradeNonZeroCreatorCoin
+	}
+	// CreatorCoin balances can't exceed uint64
+	sellerBalanceNanos := sellerBalanceEntry.BalanceNanos.Uint64()
+	if creatorCoinToSellNanos > sellerBalanceNanos {
+		return nil, errors.Wrapf(RuleErrorCreatorCoinSellInsufficientCoins,
+			"_getCreatorCoinSellQuote: CreatorCoin nanos being sold %v exceeds "+
+				"user's creator coin balance %v", creatorCoinToSellNanos, sellerBalanceNanos)
+	}
+	if coinEntry.DeSoLockedNanos == 0 {
+		return nil, RuleErrorCreatorCoinSellNotAllowedWhenZeroDeSoLocked
+	}
+
+	// A seller who would be left with less than the auto-sell threshold sells
+	// everything.
+	creatorCoinToSellNanos, desoBeforeFeesNanos := bav._calculateCreatorCoinSellNanos(
+		&coinEntry, sellerBalanceNanos, creatorCoinToSellNanos, blockHeight)
+	if creatorCoinToSellNanos > coinEntry.CoinsInCirculationNanos.Uint64() {
+		return nil, fmt.Errorf("_getCreatorCoinSellQuote: CreatorCoin nanos seller "+
+			"is selling %v exceeds CreatorCoin nanos in circulation %v",
+			creatorCoinToSellNanos, coinEntry.CoinsInCirculationNanos.Uint64())
+	}
+
+	spotPriceBeforeNanos := CalculateCreatorCoinPriceNanos(&coinEntry, bav.Params)
+	coinEntry.DeSoLockedNanos -= desoBeforeFeesNanos
+	coinEntry.CoinsInCirculationNanos = *uint256.NewInt().SetUint64(
+		coinEntry.CoinsInCirculationNanos.Uint64() - creatorCoinToSellNanos)
+	// The coin is reset once the last holder sells out.
+	if sellerBalanceNanos == creatorCoinToSellNanos && coinEntry.NumberOfHolders == 1 {
+		coinEntry.DeSoLockedNanos = 0
+		coinEntry.CoinsInCirculationNanos = *uint256.NewInt()
+	}
+
+	desoAfterFeesNanos := _applyCreatorCoinTradeFee(desoBeforeFeesNanos, bav.Params)
+	return &CreatorCoinQuote{
+		AmountInNanos:        creatorCoinToSellNanos,
+		AmountOutNanos:       desoAfterFeesNanos,
+		TradeFeeNanos:        desoBeforeFeesNanos - desoAfterFeesNanos,
+		SpotPriceBeforeNanos: spotPriceBeforeNanos,
+		SpotPriceAfterNanos:  CalculateCreatorCoinPriceNanos(&coinEntry, bav.Params),
+	}, nil
+}
+
+// _calculatePriceImpactBasisPoints returns how far the price moved from before to
+// after in basis points of before. A move from a zero price counts as 100%.
+func _calculatePriceImpactBasisPoints(beforeNanos uint64, afterNanos uint64) uint64 {
+	if beforeNanos == afterNanos {
+		return 0
+	}
+	if beforeNanos == 0 {
+		return 100 * 100
+	}
+	diffNanos := afterNanos - beforeNanos
+	if afterNanos < beforeNanos {
+		diffNanos = beforeNanos - afterNanos
+	}
+	return IntDiv(
+		IntMul(big.NewInt(0).SetUint64(diffNanos), big.NewInt(100*100)),
+		big.NewInt(0).SetUint64(beforeNanos)).Uint64()
+}
@@ -0,0 +1,311 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatorCoinQuote(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	params.ForkHeights.SalomonFixBlockHeight = 0
+	params.ForkHeights.BuyCreatorCoinAfterDeletedBalanceEntryFixBlockHeight = 0
+	params.ForkHeights.DeSoFounderRewardBlockHeight = 0
+
+	// Mine a few blocks to give the senderPkString some money.
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	// We build the testMeta obj after mining blocks so that we save the correct block height.
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 1000000000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 1000000000)
+
+	getQuote := func(transactorPkBytes []byte, operationType CreatorCoinOperationType,
+		amountNanos uint64) (*CreatorCoinQuote, error) {
+
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		return utxoView.GetCreatorCoinQuote(
+			transactorPkBytes, m0PkBytes, operationType, amountNanos, chain.blockTip().Height+1)
+	}
+	getPriceNanos := func() uint64 {
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		profileEntry := utxoView.GetProfileEntryForPublicKey(m0PkBytes)
+		return CalculateCreatorCoinPriceNanos(&profileEntry.CreatorCoinEntry, params)
+	}
+	getM1BalanceNanos := func() uint64 {
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		balanceEntry, _, _ := utxoView.GetCreatorCoinBalanceEntryForHODLerPubKeyAndCreatorPubKey(m1PkBytes, m0PkBytes)
+		if balanceEntry == nil || balanceEntry.isDeleted {
+			return 0
+		}
+		return balanceEntry.BalanceNanos.Uint64()
+	}
+
+	// Quoting a coin without a profile should fail.
+	{
+		_, err = getQuote(m1PkBytes, CreatorCoinOperationTypeBuy, 100000000)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorCreatorCoinOperationOnNonexistentProfile)
+	}
+
+	_updateProfileWithTestMeta(
+		testMeta,
+		10,            /*feeRateNanosPerKB*/
+		m0Pub,         /*updaterPkBase58Check*/
+		m0Priv,        /*updaterPrivBase58Check*/
+		[]byte{},      /*profilePubKey*/
+		"m0",          /*newUsername*/
+		"i am the m0", /*newDescription*/
+		shortPic,      /*newProfilePic*/
+		10*100,        /*newCreatorBasisPoints*/
+		1.25*100*100,  /*newStakeMultipleBasisPoints*/
+		false /*isHidden*/)
+
+	// Quoting a sell without any coins or a zero buy should fail the same way the
+	// txn would.
+	{
+		_, err = getQuote(m1PkBytes, CreatorCoinOperationTypeSell, 100000000)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorCreatorCoinSellerBalanceEntryDoesNotExist)
+
+		_, err = getQuote(m1PkBytes, CreatorCoinOperationTypeBuy, 0)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorCreatorCoinBuyMustTradeNonZeroDeSo)
+	}
+
+	// The first buy takes the price up from zero, and m0 gets 10% of what's left
+	// after the trade fee as a DeSo founder reward.
+	{
+		quote, err := getQuote(m1PkBytes, CreatorCoinOperationTypeBuy, 100000000)
+		require.NoError(err)
+		require.Equal(CreatorCoinOperationTypeBuy, quote.OperationType)
+		require.Equal(uint64(100000000), quote.AmountInNanos)
+		desoAfterFeesNanos := uint64(100000000) * (100*100 - params.CreatorCoinTradeFeeBasisPoints) / (100 * 100)
+		require.Equal(100000000-desoAfterFeesNanos, quote.TradeFeeNanos)
+		require.Equal(desoAfterFeesNanos/10, quote.DeSoFounderRewardNanos)
+		require.Equal(uint64(0), quote.CreatorCoinFounderRewardNanos)
+		require.Equal(uint64(0), quote.SpotPriceBeforeNanos)
+		require.Equal(uint64(100*100), quote.PriceImpactBasisPoints)
+
+		_creatorCoinTxnWithTestMeta(
+			testMeta,
+			10,     /*feeRateNanosPerKB*/
+			m1Pub,  /*updaterPkBase58Check*/
+			m1Priv, /*updaterPrivBase58Check*/
+			m0Pub,  /*profilePubKeyBase58Check*/
+			CreatorCoinOperationTypeBuy,
+			100000000,            /*DeSoToSellNanos*/
+			0,                    /*CreatorCoinToSellNanos*/
+			0,                    /*DeSoToAddNanos*/
+			0,                    /*MinDeSoExpectedNanos*/
+			quote.AmountOutNanos, /*MinCreatorCoinExpectedNanos*/
+		)
+		require.Equal(quote.AmountOutNanos, getM1BalanceNanos())
+		require.Equal(quote.SpotPriceAfterNanos, getPriceNanos())
+	}
+
+	// A second buy moves the price by less than 100%. Asking for a single nano
+	// more than the quote should fail.
+	{
+		m1BalanceBeforeNanos := getM1BalanceNanos()
+		quote, err := getQuote(m1PkBytes, CreatorCoinOperationTypeBuy, 200000000)
+		require.NoError(err)
+		require.Equal(getPriceNanos(), quote.SpotPriceBeforeNanos)
+		require.Greater(quote.SpotPriceAfterNanos, quote.SpotPriceBeforeNanos)
+		require.Greater(quote.PriceImpactBasisPoints, uint64(0))
+		require.Less(quote.PriceImpactBasisPoints, uint64(100*100))
+
+		_, _, _, err = _creatorCoinTxn(
+			t, chain, db, params, 10, m1Pub, m1Priv, m0Pub, CreatorCoinOperationTypeBuy,
+			200000000, 0, 0, 0, quote.AmountOutNanos+1)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorCreatorCoinLessThanMinimumSetByUser)
+
+		_creatorCoinTxnWithTestMeta(
+			testMeta,
+			10,     /*feeRateNanosPerKB*/
+			m1Pub,  /*updaterPkBase58Check*/
+			m1Priv, /*updaterPrivBase58Check*/
+			m0Pub,  /*profilePubKeyBase58Check*/
+			CreatorCoinOperationTypeBuy,
+			200000000,            /*DeSoToSellNanos*/
+			0,                    /*CreatorCoinToSellNanos*/
+			0,                    /*DeSoToAddNanos*/
+			0,                    /*MinDeSoExpectedNanos*/
+			quote.AmountOutNanos, /*MinCreatorCoinExpectedNanos*/
+		)
+		require.Equal(m1BalanceBeforeNanos+quote.AmountOutNanos, getM1BalanceNanos())
+		require.Equal(quote.SpotPriceAfterNanos, getPriceNanos())
+	}
+
+	// m0 buying their own coin pays no founder reward.
+	{
+		quote, err := getQuote(m0PkBytes, CreatorCoinOperationTypeBuy, 100000000)
+		require.NoError(err)
+		require.Equal(uint64(0), quote.DeSoFounderRewardNanos)
+	}
+
+	// Selling half of m1's coins brings the price down and the quote matches what
+	// the sell returns.
+	{
+		m1BalanceBeforeNanos := getM1BalanceNanos()
+		quote, err := getQuote(m1PkBytes, CreatorCoinOperationTypeSell, m1BalanceBeforeNanos/2)
+		require.NoError(err)
+		require.Equal(m1BalanceBeforeNanos/2, quote.AmountInNanos)
+		require.Less(quote.SpotPriceAfterNanos, quote.SpotPriceBeforeNanos)
+		require.Greater(quote.TradeFeeNanos, uint64(0))
+		require.Equal(uint64(0), quote.DeSoFounderRewardNanos)
+
+		_, _, _, err = _creatorCoinTxn(
+			t, chain, db, params, 10, m1Pub, m1Priv, m0Pub, CreatorCoinOperationTypeSell,
+			0, m1BalanceBeforeNanos/2, 0, quote.AmountOutNanos+1, 0)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorDeSoReceivedIsLessThanMinimumSetBySeller)
+
+		_creatorCoinTxnWithTestMeta(
+			testMeta,
+			10,     /*feeRateNanosPerKB*/
+			m1Pub,  /*updaterPkBase58Check*/
+			m1Priv, /*updaterPrivBase58Check*/
+			m0Pub,  /*profilePubKeyBase58Check*/
+			CreatorCoinOperationTypeSell,
+			0,                      /*DeSoToSellNanos*/
+			m1BalanceBeforeNanos/2, /*CreatorCoinToSellNanos*/
+			0,                      /*DeSoToAddNanos*/
+			quote.AmountOutNanos,   /*MinDeSoExpectedNanos*/
+			0,                      /*MinCreatorCoinExpectedNanos*/
+		)
+		require.Equal(m1BalanceBeforeNanos-m1BalanceBeforeNanos/2, getM1BalanceNanos())
+		require.Equal(quote.SpotPriceAfterNanos, getPriceNanos())
+	}
+
+	// Selling all but a few nanos sells everything.
+	{
+		m1BalanceNanos := getM1BalanceNanos()
+		quote, err := getQuote(m1PkBytes, CreatorCoinOperationTypeSell, m1BalanceNanos-1)
+		require.NoError(err)
+		require.Equal(m1BalanceNanos, quote.AmountInNanos)
+	}
+
+	// Roll back all of the above using the utxoOps from each.
+	_rollBackTestMetaTxnsAndFlush(testMeta)
+	_applyTestMetaTxnsToMempool(testMeta)
+	_applyTestMetaTxnsToViewAndFlush(testMeta)
+	_disconnectTestMetaTxnsFromViewAndFlush(testMeta)
+	_connectBlockThenDisconnectBlockAndFlush(testMeta)
+}
+
+func TestCreatorCoinDeadlineBlockHeight(t *testing.T) {
+	require := require.New(t)
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	params.ForkHeights.SalomonFixBlockHeight = 0
+	params.ForkHeights.BuyCreatorCoinAfterDeletedBalanceEntryFixBlockHeight = 0
+	params.ForkHeights.DeSoFounderRewardBlockHeight = 0
+
+	// Mine a few blocks to give the senderPkString some money.
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 1000000000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 1000000000)
+	_updateProfileWithTestMeta(
+		testMeta,
+		10,            /*feeRateNanosPerKB*/
+		m0Pub,         /*updaterPkBase58Check*/
+		m0Priv,        /*updaterPrivBase58Check*/
+		[]byte{},      /*profilePubKey*/
+		"m0",          /*newUsername*/
+		"i am the m0", /*newDescription*/
+		shortPic,      /*newProfilePic*/
+		10*100,        /*newCreatorBasisPoints*/
+		1.25*100*100,  /*newStakeMultipleBasisPoints*/
+		false /*isHidden*/)
+
+	blockHeight := chain.blockTip().Height + 1
+	_connectBuy := func(deadlineBlockHeight uint64, deadlineBytes []byte) error {
+		var txn *MsgDeSoTxn
+		var err error
+		if deadlineBlockHeight == 0 {
+			txn, _, _, _, err = chain.CreateCreatorCoinTxn(
+				m1PkBytes, m0PkBytes, CreatorCoinOperationTypeBuy, 100000000, 0, 0, 0, 0,
+				10, nil, []*DeSoOutput{})
+		} else {
+			txn, _, _, _, err = chain.CreateCreatorCoinTxnWithDeadline(
+				m1PkBytes, m0PkBytes, CreatorCoinOperationTypeBuy, 100000000, 0, 0, 0,
+				deadlineBlockHeight, 10, nil, []*DeSoOutput{})
+		}
+		require.NoError(err)
+		if deadlineBlockHeight != 0 {
+			require.Equal(UintToBuf(deadlineBlockHeight), txn.ExtraData[CreatorCoinDeadlineBlockHeightKey])
+		}
+		if deadlineBytes != nil {
+			txn.ExtraData = map[string][]byte{CreatorCoinDeadlineBlockHeightKey: deadlineBytes}
+		}
+		_signTxn(t, txn, m1Priv)
+
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		_, _, _, _, err = utxoView.ConnectTransaction(
+			txn, txn.Hash(), getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+		return err
+	}
+
+	// The deadline is ignored before the fork.
+	params.ForkHeights.CreatorCoinTradeDeadlineBlockHeight = blockHeight + 1
+	require.NoError(_connectBuy(uint64(blockHeight-1), nil))
+
+	params.ForkHeights.CreatorCoinTradeDeadlineBlockHeight = 0
+
+	// A trade with no deadline or one at or after the current height goes through.
+	require.NoError(_connectBuy(0, nil))
+	require.NoError(_connectBuy(uint64(blockHeight), nil))
+	require.NoError(_connectBuy(uint64(blockHeight+10), nil))
+
+	// A trade past its deadline fails.
+	err = _connectBuy(uint64(blockHeight-1), nil)
+	require.Error(err)
+	require.Contains(err.Error(), RuleErrorCreatorCoinPastDeadlineBlockHeight)
+
+	// A deadline that can't be decoded fails.
+	err = _connectBuy(0, []byte{})
+	require.Error(err)
+	require.Contains(err.Error(), RuleErrorCreatorCoinInvalidDeadlineBlockHeight)
+	err = _connectBuy(0, append(UintToBuf(uint64(blockHeight)), 0))
+	require.Error(err)
+	require.Contains(err.Error(), RuleErrorCreatorCoinInvalidDeadlineBlockHeight)
+}
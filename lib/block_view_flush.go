@@ -7,6 +7,7 @@ import (
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
 	"reflect"
+	"time"
 )
 
 func (bav *UtxoView) FlushToDb() error {
@@ -39,6 +40,8 @@ func (bav *UtxoView) FlushToDb() error {
 }
 
 func (bav *UtxoView) FlushToDbWithTxn(txn *badger.Txn) error {
+	defer observeSecondsSince(metricDbFlushSeconds, time.Now())
+
 	// Only flush to BadgerDB if Postgres is disabled
 	if bav.Postgres == nil {
 		if err := bav._flushUtxosToDbWithTxn(txn); err != nil {
@@ -53,24 +56,42 @@ func (bav *UtxoView) FlushToDbWithTxn(txn *badger.Txn) error {
 		if err := bav._flushPostEntriesToDbWithTxn(txn); err != nil {
 			return err
 		}
+		if err := bav._flushPostVersionEntriesToDbWithTxn(txn); err != nil {
+			return err
+		}
+		if err := bav._flushPollEntriesToDbWithTxn(txn); err != nil {
+			return err
+		}
+		if err := bav._flushPollVoteEntriesToDbWithTxn(txn); err != nil {
+			return err
+		}
 		if err := bav._flushLikeEntriesToDbWithTxn(txn); err != nil {
 			return err
 		}
 		if err := bav._flushFollowEntriesToDbWithTxn(txn); err != nil {
 			return err
 		}
+		if err := bav._flushUserBlockEntriesToDbWithTxn(txn); err != nil {
+			return err
+		}
 		if err := bav._flushDiamondEntriesToDbWithTxn(txn); err != nil {
 			return err
 		}
 		if err := bav._flushMessageEntriesToDbWithTxn(txn); err != nil {
 			return err
 		}
+		if err := bav._flushMessageReadEntriesToDbWithTxn(txn); err != nil {
+			return err
+		}
 		if err := bav._flushBalanceEntriesToDbWithTxn(txn); err != nil {
 			return err
 		}
 		if err := bav._flushDAOCoinBalanceEntriesToDbWithTxn(txn); err != nil {
 			return err
 		}
+		if err := bav._flushCreatorCoinPriceCandleEntriesToDbWithTxn(txn); err != nil {
+			return err
+		}
 		if err := bav._flushDeSoBalancesToDbWithTxn(txn); err != nil {
 			return err
 		}
@@ -80,6 +101,33 @@ func (bav *UtxoView) FlushToDbWithTxn(txn *badger.Txn) error {
 		if err := bav._flushNFTEntriesToDbWithTxn(txn); err != nil {
 			return err
 		}
+		if err := bav._flushNFTCollectionEntriesToDbWithTxn(txn); err != nil {
+			return err
+		}
+		if err := bav._flushNFTVoucherRedemptionEntriesToDbWithTxn(txn); err != nil {
+			return err
+		}
+		if err := bav._flushBlockProducerEntriesToDbWithTxn(txn); err != nil {
+			return err
+		}
+		if err := bav._flushUsernameListingEntriesToDbWithTxn(txn); err != nil {
+			return err
+		}
+		if err := bav._flushPendingKeyRotationEntriesToDbWithTxn(txn); err != nil {
+			return err
+		}
+		if err := bav._flushKeyRotationTimelockEntriesToDbWithTxn(txn); err != nil {
+			return err
+		}
+		if err := bav._flushRotatedPublicKeyEntriesToDbWithTxn(txn); err != nil {
+			return err
+		}
+		if err := bav._flushRecoveryGuardianSetEntriesToDbWithTxn(txn); err != nil {
+			return err
+		}
+		if err := bav._flushPendingAccountRecoveryEntriesToDbWithTxn(txn); err != nil {
+			return err
+		}
 		if err := bav._flushNFTBidEntriesToDbWithTxn(txn); err != nil {
 			return err
 		}
@@ -301,6 +349,134 @@ func (bav *UtxoView) _flushMessageEntriesToDbWithTxn(txn *badger.Txn) error {
 	return nil
 }
 
+func (bav *UtxoView) _flushPostVersionEntriesToDbWithTxn(txn *badger.Txn) error {
+
+	// Go through all the entries in the PostVersionKeyToPostVersionEntry map.
+	for postVersionKeyIter, postVersionEntry := range bav.PostVersionKeyToPostVersionEntry {
+		// Make a copy of the iterator since we make references to it below.
+		postVersionKey := postVersionKeyIter
+
+		// Sanity-check that the PostVersionKey computed from the PostVersionEntry is
+		// equal to the PostVersionKey that maps to that entry.
+		postVersionKeyInEntry := MakePostVersionKey(postVersionEntry.PostHash, postVersionEntry.Version)
+		if postVersionKeyInEntry != postVersionKey {
+			return fmt.Errorf("_flushPostVersionEntriesToDbWithTxn: PostVersionEntry has "+
+				"PostVersionKey: %v, which doesn't match the PostVersionKeyToPostVersionEntry map key %v",
+				&postVersionKeyInEntry, &postVersionKey)
+		}
+
+		// Delete the existing mapping in the db for this PostVersionKey. It will be re-added
+		// if the corresponding entry in memory has isDeleted=false.
+		if err := DbDeletePostVersionEntryWithTxn(
+			txn, &postVersionKey.PostHash, postVersionKey.Version); err != nil {
+
+			return errors.Wrapf(
+				err, "_flushPostVersionEntriesToDbWithTxn: Problem deleting mapping "+
+					"for PostVersionKey: %v: ", &postVersionKey)
+		}
+	}
+	for _, postVersionEntry := range bav.PostVersionKeyToPostVersionEntry {
+		if postVersionEntry.isDeleted {
+			// If the PostVersionEntry has isDeleted=true then there's nothing to do because
+			// we already deleted the entry above.
+		} else {
+			// If the PostVersionEntry has (isDeleted = false) then we put the corresponding
+			// mapping for it into the db.
+			if err := DbPutPostVersionEntryWithTxn(txn, postVersionEntry); err != nil {
+				return err
+			}
+		}
+	}
+
+	// At this point all of the PostVersionEntry mappings in the db should be up-to-date.
+
+	return nil
+}
+
+func (bav *UtxoView) _flushPollEntriesToDbWithTxn(txn *badger.Txn) error {
+
+	// Go through all the entries in the PostHashToPollEntry map.
+	for postHashIter, pollEntry := range bav.PostHashToPollEntry {
+		// Make a copy of the iterator since we make references to it below.
+		postHash := postHashIter
+
+		// Sanity-check that the hash in the PollEntry is the same as the map key.
+		if *pollEntry.PostHash != postHash {
+			return fmt.Errorf("_flushPollEntriesToDbWithTxn: PollEntry has "+
+				"PostHash: %v, which doesn't match the PostHashToPollEntry map key %v",
+				pollEntry.PostHash, &postHash)
+		}
+
+		// Delete the existing mapping in the db for this PostHash. It will be re-added
+		// if the corresponding entry in memory has isDeleted=false.
+		if err := DbDeletePollEntryWithTxn(txn, &postHash); err != nil {
+			return errors.Wrapf(
+				err, "_flushPollEntriesToDbWithTxn: Problem deleting mapping "+
+					"for PostHash: %v: ", &postHash)
+		}
+	}
+	for _, pollEntry := range bav.PostHashToPollEntry {
+		if pollEntry.isDeleted {
+			// If the PollEntry has isDeleted=true then there's nothing to do because
+			// we already deleted the entry above.
+		} else {
+			// If the PollEntry has (isDeleted = false) then we put the corresponding
+			// mapping for it into the db.
+			if err := DbPutPollEntryWithTxn(txn, pollEntry); err != nil {
+				return err
+			}
+		}
+	}
+
+	// At this point all of the PollEntry mappings in the db should be up-to-date.
+
+	return nil
+}
+
+func (bav *UtxoView) _flushPollVoteEntriesToDbWithTxn(txn *badger.Txn) error {
+
+	// Go through all the entries in the PollVoteKeyToPollVoteEntry map.
+	for pollVoteKeyIter, pollVoteEntry := range bav.PollVoteKeyToPollVoteEntry {
+		// Make a copy of the iterator since we make references to it below.
+		pollVoteKey := pollVoteKeyIter
+
+		// Sanity-check that the PollVoteKey computed from the PollVoteEntry is
+		// equal to the PollVoteKey that maps to that entry.
+		pollVoteKeyInEntry := MakePollVoteKey(pollVoteEntry.PostHash, pollVoteEntry.VoterPKID)
+		if pollVoteKeyInEntry != pollVoteKey {
+			return fmt.Errorf("_flushPollVoteEntriesToDbWithTxn: PollVoteEntry has "+
+				"PollVoteKey: %v, which doesn't match the PollVoteKeyToPollVoteEntry map key %v",
+				&pollVoteKeyInEntry, &pollVoteKey)
+		}
+
+		// Delete the existing mapping in the db for this PollVoteKey. It will be re-added
+		// if the corresponding entry in memory has isDeleted=false.
+		if err := DbDeletePollVoteEntryWithTxn(
+			txn, &pollVoteKey.PostHash, &pollVoteKey.VoterPKID); err != nil {
+
+			return errors.Wrapf(
+				err, "_flushPollVoteEntriesToDbWithTxn: Problem deleting mapping "+
+					"for PollVoteKey: %v: ", &pollVoteKey)
+		}
+	}
+	for _, pollVoteEntry := range bav.PollVoteKeyToPollVoteEntry {
+		if pollVoteEntry.isDeleted {
+			// If the PollVoteEntry has isDeleted=true then there's nothing to do because
+			// we already deleted the entry above.
+		} else {
+			// If the PollVoteEntry has (isDeleted = false) then we put the corresponding
+			// mapping for it into the db.
+			if err := DbPutPollVoteEntryWithTxn(txn, pollVoteEntry); err != nil {
+				return err
+			}
+		}
+	}
+
+	// At this point all of the PollVoteEntry mappings in the db should be up-to-date.
+
+	return nil
+}
+
 func (bav *UtxoView) _flushRepostEntriesToDbWithTxn(txn *badger.Txn) error {
 
 	// Go through all the entries in the repostKeyTorepostEntry map.
@@ -440,6 +616,221 @@ func (bav *UtxoView) _flushFollowEntriesToDbWithTxn(txn *badger.Txn) error {
 	return nil
 }
 
+func (bav *UtxoView) _flushUserBlockEntriesToDbWithTxn(txn *badger.Txn) error {
+
+	// Go through all the entries in the UserBlockKeyToUserBlockEntry map.
+	for userBlockKeyIter, userBlockEntry := range bav.UserBlockKeyToUserBlockEntry {
+		// Make a copy of the iterator since we make references to it below.
+		userBlockKey := userBlockKeyIter
+
+		// Sanity-check that the UserBlockKey computed from the UserBlockEntry is
+		// equal to the UserBlockKey that maps to that entry.
+		userBlockKeyInEntry := MakeUserBlockKey(
+			userBlockEntry.BlockerPKID, userBlockEntry.BlockedPKID)
+		if userBlockKeyInEntry != userBlockKey {
+			return fmt.Errorf("_flushUserBlockEntriesToDbWithTxn: UserBlockEntry has "+
+				"UserBlockKey: %v, which doesn't match the UserBlockKeyToUserBlockEntry map key %v",
+				&userBlockKeyInEntry, &userBlockKey)
+		}
+
+		// Delete the existing mappings in the db for this UserBlockKey. They will be re-added
+		// if the corresponding entry in memory has isDeleted=false.
+		if err := DbDeleteUserBlockMappingsWithTxn(
+			txn, userBlockEntry.BlockerPKID, userBlockEntry.BlockedPKID); err != nil {
+
+			return errors.Wrapf(
+				err, "_flushUserBlockEntriesToDbWithTxn: Problem deleting mappings "+
+					"for UserBlockKey: %v: ", &userBlockKey)
+		}
+	}
+
+	// Go through all the entries in the UserBlockKeyToUserBlockEntry map.
+	for _, userBlockEntry := range bav.UserBlockKeyToUserBlockEntry {
+		if userBlockEntry.isDeleted {
+			// If the UserBlockEntry has isDeleted=true then there's nothing to do because
+			// we already deleted the entry above.
+		} else {
+			// If the UserBlockEntry has (isDeleted = false) then we put the corresponding
+			// mappings for it into the db.
+			if err := DbPutUserBlockMappingsWithTxn(txn, userBlockEntry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (bav *UtxoView) _flushMessageReadEntriesToDbWithTxn(txn *badger.Txn) error {
+
+	// Go through all the entries in the MessageReadKeyToMessageReadEntry map.
+	for messageReadKeyIter, messageReadEntry := range bav.MessageReadKeyToMessageReadEntry {
+		// Make a copy of the iterator since we make references to it below.
+		messageReadKey := messageReadKeyIter
+
+		// Sanity-check that the MessageReadKey computed from the MessageReadEntry is
+		// equal to the MessageReadKey that maps to that entry.
+		messageReadKeyInEntry := MakeMessageReadKey(
+			messageReadEntry.ReaderPublicKey[:], messageReadEntry.ThreadPublicKey[:])
+		if messageReadKeyInEntry != messageReadKey {
+			return fmt.Errorf("_flushMessageReadEntriesToDbWithTxn: MessageReadEntry has "+
+				"MessageReadKey: %v, which doesn't match the MessageReadKeyToMessageReadEntry map key %v",
+				&messageReadKeyInEntry, &messageReadKey)
+		}
+
+		// Delete the existing mapping in the db for this MessageReadKey. It will be re-added
+		// if the corresponding entry in memory has isDeleted=false.
+		if err := DbDeleteMessageReadEntryWithTxn(
+			txn, messageReadEntry.ReaderPublicKey, messageReadEntry.ThreadPublicKey); err != nil {
+
+			return errors.Wrapf(
+				err, "_flushMessageReadEntriesToDbWithTxn: Problem deleting mapping "+
+					"for MessageReadKey: %v: ", &messageReadKey)
+		}
+	}
+
+	// Go through all the entries in the MessageReadKeyToMessageReadEntry map.
+	for _, messageReadEntry := range bav.MessageReadKeyToMessageReadEntry {
+		if messageReadEntry.isDeleted {
+			// If the MessageReadEntry has isDeleted=true then there's nothing to do because
+			// we already deleted the entry above.
+		} else {
+			// If the MessageReadEntry has (isDeleted = false) then we put the corresponding
+			// mapping for it into the db.
+			if err := DbPutMessageReadEntryWithTxn(txn, messageReadEntry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (bav *UtxoView) _flushNFTCollectionEntriesToDbWithTxn(txn *badger.Txn) error {
+
+	// Go through all the entries in the NFTCollectionIDToNFTCollectionEntry map.
+	for collectionIDIter, nftCollectionEntry := range bav.NFTCollectionIDToNFTCollectionEntry {
+		// Make a copy of the iterator since we make references to it below.
+		collectionID := collectionIDIter
+
+		// Sanity-check that the collection ID in the entry is equal to the
+		// collection ID that maps to that entry.
+		if *nftCollectionEntry.CollectionID != collectionID {
+			return fmt.Errorf("_flushNFTCollectionEntriesToDbWithTxn: NFTCollectionEntry has "+
+				"CollectionID: %v, which doesn't match the NFTCollectionIDToNFTCollectionEntry map key %v",
+				nftCollectionEntry.CollectionID, &collectionID)
+		}
+
+		// Delete the existing mapping in the db for this collection ID. It will be re-added
+		// if the corresponding entry in memory has isDeleted=false.
+		if err := DbDeleteNFTCollectionEntryWithTxn(txn, &collectionID); err != nil {
+			return errors.Wrapf(
+				err, "_flushNFTCollectionEntriesToDbWithTxn: Problem deleting mapping "+
+					"for CollectionID: %v: ", &collectionID)
+		}
+	}
+
+	// Go through all the entries in the NFTCollectionIDToNFTCollectionEntry map.
+	for _, nftCollectionEntry := range bav.NFTCollectionIDToNFTCollectionEntry {
+		if nftCollectionEntry.isDeleted {
+			// If the NFTCollectionEntry has isDeleted=true then there's nothing to do because
+			// we already deleted the entry above.
+		} else {
+			// If the NFTCollectionEntry has (isDeleted = false) then we put the corresponding
+			// mapping for it into the db.
+			if err := DbPutNFTCollectionEntryWithTxn(txn, nftCollectionEntry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (bav *UtxoView) _flushNFTVoucherRedemptionEntriesToDbWithTxn(txn *badger.Txn) error {
+
+	// Go through all the entries in the NFTKeyToNFTVoucherRedemptionEntry map.
+	for nftKeyIter, redemptionEntry := range bav.NFTKeyToNFTVoucherRedemptionEntry {
+		// Make a copy of the iterator since we make references to it below.
+		nftKey := nftKeyIter
+
+		// Sanity-check that the NFT key in the entry is equal to the
+		// NFT key that maps to that entry.
+		if MakeNFTKey(redemptionEntry.NFTPostHash, redemptionEntry.SerialNumber) != nftKey {
+			return fmt.Errorf("_flushNFTVoucherRedemptionEntriesToDbWithTxn: NFTVoucherRedemptionEntry "+
+				"has NFTKey: %v, which doesn't match the NFTKeyToNFTVoucherRedemptionEntry map key %v",
+				MakeNFTKey(redemptionEntry.NFTPostHash, redemptionEntry.SerialNumber), nftKey)
+		}
+
+		// Delete the existing mapping in the db for this NFT key. It will be re-added
+		// if the corresponding entry in memory has isDeleted=false.
+		if err := DbDeleteNFTVoucherRedemptionEntryWithTxn(
+			txn, &nftKey.NFTPostHash, nftKey.SerialNumber); err != nil {
+
+			return errors.Wrapf(
+				err, "_flushNFTVoucherRedemptionEntriesToDbWithTxn: Problem deleting mapping "+
+					"for NFTKey: %v: ", nftKey)
+		}
+	}
+
+	// Go through all the entries in the NFTKeyToNFTVoucherRedemptionEntry map.
+	for _, redemptionEntry := range bav.NFTKeyToNFTVoucherRedemptionEntry {
+		if redemptionEntry.isDeleted {
+			// If the NFTVoucherRedemptionEntry has isDeleted=true then there's nothing to do
+			// because we already deleted the entry above.
+		} else {
+			// If the NFTVoucherRedemptionEntry has (isDeleted = false) then we put the
+			// corresponding mapping for it into the db.
+			if err := DbPutNFTVoucherRedemptionEntryWithTxn(txn, redemptionEntry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (bav *UtxoView) _flushBlockProducerEntriesToDbWithTxn(txn *badger.Txn) error {
+
+	// Go through all the entries in the PublicKeyToBlockProducerEntry map.
+	for pkMapKeyIter, blockProducerEntry := range bav.PublicKeyToBlockProducerEntry {
+		// Make a copy of the iterator since we make references to it below.
+		pkMapKey := pkMapKeyIter
+
+		// Sanity-check that the public key in the entry is equal to the
+		// public key that maps to that entry.
+		if MakePkMapKey(blockProducerEntry.PublicKey) != pkMapKey {
+			return fmt.Errorf("_flushBlockProducerEntriesToDbWithTxn: BlockProducerEntry "+
+				"has public key: %v, which doesn't match the PublicKeyToBlockProducerEntry map key %v",
+				PkToStringBoth(blockProducerEntry.PublicKey), PkToStringBoth(pkMapKey[:]))
+		}
+
+		// Delete the existing mapping in the db for this public key. It will be re-added
+		// if the corresponding entry in memory has isDeleted=false.
+		if err := DbDeleteBlockProducerEntryWithTxn(txn, pkMapKey[:]); err != nil {
+			return errors.Wrapf(
+				err, "_flushBlockProducerEntriesToDbWithTxn: Problem deleting mapping "+
+					"for public key: %v: ", PkToStringBoth(pkMapKey[:]))
+		}
+	}
+
+	// Go through all the entries in the PublicKeyToBlockProducerEntry map.
+	for _, blockProducerEntry := range bav.PublicKeyToBlockProducerEntry {
+		if blockProducerEntry.isDeleted {
+			// If the BlockProducerEntry has isDeleted=true then there's nothing to do
+			// because we already deleted the entry above.
+		} else {
+			// If the BlockProducerEntry has (isDeleted = false) then we put the
+			// corresponding mapping for it into the db.
+			if err := DbPutBlockProducerEntryWithTxn(txn, blockProducerEntry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func (bav *UtxoView) _flushNFTEntriesToDbWithTxn(txn *badger.Txn) error {
 
 	// Go through and delete all the entries so they can be added back fresh.
@@ -891,6 +1282,50 @@ func (bav *UtxoView) _flushDAOCoinBalanceEntriesToDbWithTxn(txn *badger.Txn) err
 	return nil
 }
 
+func (bav *UtxoView) _flushCreatorCoinPriceCandleEntriesToDbWithTxn(txn *badger.Txn) error {
+
+	// Go through all the entries in the CreatorCoinPriceCandleKeyToCandleEntry map.
+	for candleKeyIter, candleEntry := range bav.CreatorCoinPriceCandleKeyToCandleEntry {
+		// Make a copy of the iterator since we make references to it below.
+		candleKey := candleKeyIter
+
+		// Sanity-check that the key computed from the entry is equal to the key that
+		// maps to that entry.
+		candleKeyInEntry := MakeCreatorCoinPriceCandleKey(candleEntry.CreatorPKID, candleEntry.StartBlockHeight)
+		if candleKeyInEntry != candleKey {
+			return fmt.Errorf("_flushCreatorCoinPriceCandleEntriesToDbWithTxn: CreatorCoinPriceCandleEntry "+
+				"has key: %v, which doesn't match the CreatorCoinPriceCandleKeyToCandleEntry map key %v",
+				&candleKeyInEntry, &candleKey)
+		}
+
+		// Delete the existing mapping in the db for this key. It will be re-added
+		// if the corresponding entry in memory has isDeleted=false.
+		if err := DbDeleteCreatorCoinPriceCandleEntryWithTxn(
+			txn, &candleKey.CreatorPKID, candleKey.BlockHeight); err != nil {
+
+			return errors.Wrapf(
+				err, "_flushCreatorCoinPriceCandleEntriesToDbWithTxn: Problem deleting mapping "+
+					"for key: %v: ", &candleKey)
+		}
+	}
+	for _, candleEntry := range bav.CreatorCoinPriceCandleKeyToCandleEntry {
+		if candleEntry.isDeleted {
+			// If the CreatorCoinPriceCandleEntry has isDeleted=true then there's nothing
+			// to do because we already deleted the entry above.
+		} else {
+			// If the CreatorCoinPriceCandleEntry has (isDeleted = false) then we put the
+			// corresponding mapping for it into the db.
+			if err := DbPutCreatorCoinPriceCandleEntryWithTxn(txn, candleEntry); err != nil {
+				return err
+			}
+		}
+	}
+
+	// At this point all of the CreatorCoinPriceCandleEntry mappings in the db should be up-to-date.
+
+	return nil
+}
+
 func (bav *UtxoView) _flushDerivedKeyEntryToDbWithTxn(txn *badger.Txn) error {
 	glog.V(1).Infof("_flushDerivedKeyEntryToDbWithTxn: flushing %d mappings", len(bav.DerivedKeyToDerivedEntry))
 
@@ -986,3 +1421,249 @@ func (bav *UtxoView) _flushMessagingGroupEntriesToDbWithTxn(txn *badger.Txn) err
 	glog.V(1).Infof("_flushMessagingGroupEntriesToDbWithTxn: deleted %d mappings, put %d mappings", numDeleted, numPut)
 	return nil
 }
+
+func (bav *UtxoView) _flushUsernameListingEntriesToDbWithTxn(txn *badger.Txn) error {
+
+	// Go through all the entries in the UsernameToUsernameListingEntry map.
+	for usernameMapKeyIter, listingEntry := range bav.UsernameToUsernameListingEntry {
+		// Make a copy of the iterator since we make references to it below.
+		usernameMapKey := usernameMapKeyIter
+
+		// Sanity-check that the username in the entry maps to the same key as
+		// the one the entry is stored under.
+		if MakeUsernameMapKey(listingEntry.Username) != usernameMapKey {
+			return fmt.Errorf("_flushUsernameListingEntriesToDbWithTxn: UsernameListingEntry "+
+				"has username: %v, which doesn't match the UsernameToUsernameListingEntry map key %v",
+				string(listingEntry.Username), string(usernameMapKey[:]))
+		}
+
+		// Delete the existing mapping in the db for this username. It will be re-added
+		// if the corresponding entry in memory has isDeleted=false.
+		if err := DbDeleteUsernameListingEntryWithTxn(txn, listingEntry.Username); err != nil {
+			return errors.Wrapf(
+				err, "_flushUsernameListingEntriesToDbWithTxn: Problem deleting mapping "+
+					"for username: %v: ", string(listingEntry.Username))
+		}
+	}
+
+	// Go through all the entries in the UsernameToUsernameListingEntry map.
+	for _, listingEntry := range bav.UsernameToUsernameListingEntry {
+		if listingEntry.isDeleted {
+			// If the UsernameListingEntry has isDeleted=true then there's nothing to do
+			// because we already deleted the entry above.
+		} else {
+			// If the UsernameListingEntry has (isDeleted = false) then we put the
+			// corresponding mapping for it into the db.
+			if err := DbPutUsernameListingEntryWithTxn(txn, listingEntry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (bav *UtxoView) _flushPendingKeyRotationEntriesToDbWithTxn(txn *badger.Txn) error {
+
+	// Go through all the entries in the PublicKeyToKeyRotationEntry map.
+	for pkMapKeyIter, keyRotationEntry := range bav.PublicKeyToKeyRotationEntry {
+		// Make a copy of the iterator since we make references to it below.
+		pkMapKey := pkMapKeyIter
+
+		// Sanity-check that the old public key in the entry is equal to the
+		// public key that maps to that entry.
+		if MakePkMapKey(keyRotationEntry.OldPublicKey) != pkMapKey {
+			return fmt.Errorf("_flushPendingKeyRotationEntriesToDbWithTxn: KeyRotationEntry "+
+				"has public key: %v, which doesn't match the PublicKeyToKeyRotationEntry map key %v",
+				PkToStringBoth(keyRotationEntry.OldPublicKey), PkToStringBoth(pkMapKey[:]))
+		}
+
+		// Delete the existing mapping in the db for this public key. It will be re-added
+		// if the corresponding entry in memory has isDeleted=false.
+		if err := DbDeletePendingKeyRotationEntryWithTxn(txn, pkMapKey[:]); err != nil {
+			return errors.Wrapf(
+				err, "_flushPendingKeyRotationEntriesToDbWithTxn: Problem deleting mapping "+
+					"for public key: %v: ", PkToStringBoth(pkMapKey[:]))
+		}
+	}
+
+	// Go through all the entries in the PublicKeyToKeyRotationEntry map.
+	for _, keyRotationEntry := range bav.PublicKeyToKeyRotationEntry {
+		if keyRotationEntry.isDeleted {
+			// If the KeyRotationEntry has isDeleted=true then there's nothing to do
+			// because we already deleted the entry above.
+		} else {
+			// If the KeyRotationEntry has (isDeleted = false) then we put the
+			// corresponding mapping for it into the db.
+			if err := DbPutPendingKeyRotationEntryWithTxn(txn, keyRotationEntry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (bav *UtxoView) _flushKeyRotationTimelockEntriesToDbWithTxn(txn *badger.Txn) error {
+
+	// Go through all the entries in the PKIDToKeyRotationTimelockEntry map.
+	for pkidIter, timelockEntry := range bav.PKIDToKeyRotationTimelockEntry {
+		// Make a copy of the iterator since we make references to it below.
+		pkid := pkidIter
+
+		// Sanity-check that the owner PKID in the entry is equal to the
+		// PKID that maps to that entry.
+		if *timelockEntry.OwnerPKID != pkid {
+			return fmt.Errorf("_flushKeyRotationTimelockEntriesToDbWithTxn: KeyRotationTimelockEntry "+
+				"has PKID: %v, which doesn't match the PKIDToKeyRotationTimelockEntry map key %v",
+				PkToStringBoth(timelockEntry.OwnerPKID[:]), PkToStringBoth(pkid[:]))
+		}
+
+		// Delete the existing mapping in the db for this PKID. It will be re-added
+		// if the corresponding entry in memory has isDeleted=false.
+		if err := DbDeleteKeyRotationTimelockEntryWithTxn(txn, &pkid); err != nil {
+			return errors.Wrapf(
+				err, "_flushKeyRotationTimelockEntriesToDbWithTxn: Problem deleting mapping "+
+					"for PKID: %v: ", PkToStringBoth(pkid[:]))
+		}
+	}
+
+	// Go through all the entries in the PKIDToKeyRotationTimelockEntry map.
+	for _, timelockEntry := range bav.PKIDToKeyRotationTimelockEntry {
+		if timelockEntry.isDeleted {
+			// If the KeyRotationTimelockEntry has isDeleted=true then there's nothing to do
+			// because we already deleted the entry above.
+		} else {
+			// If the KeyRotationTimelockEntry has (isDeleted = false) then we put the
+			// corresponding mapping for it into the db.
+			if err := DbPutKeyRotationTimelockEntryWithTxn(txn, timelockEntry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (bav *UtxoView) _flushRotatedPublicKeyEntriesToDbWithTxn(txn *badger.Txn) error {
+
+	// Go through all the entries in the PublicKeyToRotatedPublicKeyEntry map.
+	for pkMapKeyIter, rotatedPublicKeyEntry := range bav.PublicKeyToRotatedPublicKeyEntry {
+		// Make a copy of the iterator since we make references to it below.
+		pkMapKey := pkMapKeyIter
+
+		// Sanity-check that the old public key in the entry is equal to the
+		// public key that maps to that entry.
+		if MakePkMapKey(rotatedPublicKeyEntry.OldPublicKey) != pkMapKey {
+			return fmt.Errorf("_flushRotatedPublicKeyEntriesToDbWithTxn: RotatedPublicKeyEntry "+
+				"has public key: %v, which doesn't match the PublicKeyToRotatedPublicKeyEntry map key %v",
+				PkToStringBoth(rotatedPublicKeyEntry.OldPublicKey), PkToStringBoth(pkMapKey[:]))
+		}
+
+		// Delete the existing mapping in the db for this public key. It will be re-added
+		// if the corresponding entry in memory has isDeleted=false.
+		if err := DbDeleteRotatedPublicKeyEntryWithTxn(txn, pkMapKey[:]); err != nil {
+			return errors.Wrapf(
+				err, "_flushRotatedPublicKeyEntriesToDbWithTxn: Problem deleting mapping "+
+					"for public key: %v: ", PkToStringBoth(pkMapKey[:]))
+		}
+	}
+
+	// Go through all the entries in the PublicKeyToRotatedPublicKeyEntry map.
+	for _, rotatedPublicKeyEntry := range bav.PublicKeyToRotatedPublicKeyEntry {
+		if rotatedPublicKeyEntry.isDeleted {
+			// If the RotatedPublicKeyEntry has isDeleted=true then there's nothing to do
+			// because we already deleted the entry above.
+		} else {
+			// If the RotatedPublicKeyEntry has (isDeleted = false) then we put the
+			// corresponding mapping for it into the db.
+			if err := DbPutRotatedPublicKeyEntryWithTxn(txn, rotatedPublicKeyEntry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (bav *UtxoView) _flushRecoveryGuardianSetEntriesToDbWithTxn(txn *badger.Txn) error {
+
+	// Go through all the entries in the PKIDToRecoveryGuardianSetEntry map.
+	for pkidIter, guardianSetEntry := range bav.PKIDToRecoveryGuardianSetEntry {
+		// Make a copy of the iterator since we make references to it below.
+		pkid := pkidIter
+
+		// Sanity-check that the owner PKID in the entry is equal to the
+		// PKID that maps to that entry.
+		if *guardianSetEntry.OwnerPKID != pkid {
+			return fmt.Errorf("_flushRecoveryGuardianSetEntriesToDbWithTxn: RecoveryGuardianSetEntry "+
+				"has PKID: %v, which doesn't match the PKIDToRecoveryGuardianSetEntry map key %v",
+				PkToStringBoth(guardianSetEntry.OwnerPKID[:]), PkToStringBoth(pkid[:]))
+		}
+
+		// Delete the existing mapping in the db for this PKID. It will be re-added
+		// if the corresponding entry in memory has isDeleted=false.
+		if err := DbDeleteRecoveryGuardianSetEntryWithTxn(txn, &pkid); err != nil {
+			return errors.Wrapf(
+				err, "_flushRecoveryGuardianSetEntriesToDbWithTxn: Problem deleting mapping "+
+					"for PKID: %v: ", PkToStringBoth(pkid[:]))
+		}
+	}
+
+	// Go through all the entries in the PKIDToRecoveryGuardianSetEntry map.
+	for _, guardianSetEntry := range bav.PKIDToRecoveryGuardianSetEntry {
+		if guardianSetEntry.isDeleted {
+			// If the RecoveryGuardianSetEntry has isDeleted=true then there's nothing to do
+			// because we already deleted the entry above.
+		} else {
+			// If the RecoveryGuardianSetEntry has (isDeleted = false) then we put the
+			// corresponding mapping for it into the db.
+			if err := DbPutRecoveryGuardianSetEntryWithTxn(txn, guardianSetEntry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (bav *UtxoView) _flushPendingAccountRecoveryEntriesToDbWithTxn(txn *badger.Txn) error {
+
+	// Go through all the entries in the PKIDToAccountRecoveryEntry map.
+	for pkidIter, accountRecoveryEntry := range bav.PKIDToAccountRecoveryEntry {
+		// Make a copy of the iterator since we make references to it below.
+		pkid := pkidIter
+
+		// Sanity-check that the owner PKID in the entry is equal to the
+		// PKID that maps to that entry.
+		if *accountRecoveryEntry.OwnerPKID != pkid {
+			return fmt.Errorf("_flushPendingAccountRecoveryEntriesToDbWithTxn: AccountRecoveryEntry "+
+				"has PKID: %v, which doesn't match the PKIDToAccountRecoveryEntry map key %v",
+				PkToStringBoth(accountRecoveryEntry.OwnerPKID[:]), PkToStringBoth(pkid[:]))
+		}
+
+		// Delete the existing mapping in the db for this PKID. It will be re-added
+		// if the corresponding entry in memory has isDeleted=false.
+		if err := DbDeletePendingAccountRecoveryEntryWithTxn(txn, &pkid); err != nil {
+			return errors.Wrapf(
+				err, "_flushPendingAccountRecoveryEntriesToDbWithTxn: Problem deleting mapping "+
+					"for PKID: %v: ", PkToStringBoth(pkid[:]))
+		}
+	}
+
+	// Go through all the entries in the PKIDToAccountRecoveryEntry map.
+	for _, accountRecoveryEntry := range bav.PKIDToAccountRecoveryEntry {
+		if accountRecoveryEntry.isDeleted {
+			// If the AccountRecoveryEntry has isDeleted=true then there's nothing to do
+			// because we already deleted the entry above.
+		} else {
+			// If the AccountRecoveryEntry has (isDeleted = false) then we put the
+			// corresponding mapping for it into the db.
+			if err := DbPutPendingAccountRecoveryEntryWithTxn(txn, accountRecoveryEntry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,669 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// block_view_key_rotation.go lets a user move their PKID, and with it their profile,
+// creator coin and DAO coin balances, NFTs and anything else keyed by PKID, from a
+// public key they no longer trust to a new one. Unlike SwapIdentity, which only a
+// param updater can sign, a KeyRotation txn is signed by the old key and carries the
+// new key's signature agreeing to the rotation.
+//
+// A rotation with a timelock is left pending until UnlockBlockHeight so that, if the
+// old key is stolen, its rightful owner has time to cancel a rotation they didn't
+// start. For that to hold the owner has to commit to a minimum timelock ahead of
+// time with SetMinTimelock, since whoever holds the key could otherwise pick a
+// timelock of zero. The minimum is kept by PKID so it moves with the account, and
+// lowering it only takes effect once the current minimum has passed. An account
+// with recovery guardians also can't use a timelock shorter than their delay, so
+// the guardians can always start a recovery before a rotation goes through.
+//
+// Once the rotation happens the two keys swap PKIDs, the way they would with
+// SwapIdentity, and the derived keys and messaging groups owned by the old key are
+// re-keyed to the new key since they're indexed by public key rather than by PKID.
+// DESO is held by public key as well and moves with the txn's regular outputs.
+//
+// Posts aren't re-keyed: they stay under the PosterPublicKey they were made with.
+// Instead the rotation is recorded in a RotatedPublicKeyEntry and GetCurrentPublicKey
+// resolves the old key to whichever key holds the account now. That's the key that
+// can edit the old posts, mint and sign vouchers for NFTs on them, and is paid their
+// diamonds and creator royalties. The old key itself can't submit posts anymore and
+// can never be rotated to or from again. Anything else that was keyed by the old
+// public key, such as the likes and reposts it made, stays with the old key.
+
+// KeyRotationSignatureData returns the bytes the new key signs to agree to take over
+// the old key's PKID.
+func KeyRotationSignatureData(oldPublicKey []byte, newPublicKey []byte) []byte {
+	return append(append([]byte{}, oldPublicKey...), newPublicKey...)
+}
+
+// GetPendingKeyRotationEntry returns the rotation away from oldPublicKey that is
+// waiting on its timelock, or nil if there isn't one.
+func (bav *UtxoView) GetPendingKeyRotationEntry(oldPublicKey []byte) *KeyRotationEntry {
+	// If an entry exists in the in-memory map, return the value of that mapping.
+	pkMapKey := MakePkMapKey(oldPublicKey)
+	if mapValue, existsMapValue := bav.PublicKeyToKeyRotationEntry[pkMapKey]; existsMapValue {
+		if mapValue.isDeleted {
+			return nil
+		}
+		return mapValue
+	}
+
+	// If we get here it means no value exists in our in-memory map. In this case,
+	// defer to the db. If a mapping exists in the db, return it. If not, return
+	// nil. Either way, save the value to the in-memory view mapping got later.
+	var keyRotationEntry *KeyRotationEntry
+	if bav.Postgres != nil {
+		if keyRotation := bav.Postgres.GetPendingKeyRotation(oldPublicKey); keyRotation != nil {
+			keyRotationEntry = keyRotation.NewKeyRotationEntry()
+		}
+	} else {
+		keyRotationEntry = DbGetPendingKeyRotationEntry(bav.Handle, oldPublicKey)
+	}
+	if keyRotationEntry != nil {
+		bav._setKeyRotationEntryMappings(keyRotationEntry)
+	}
+	return keyRotationEntry
+}
+
+func (bav *UtxoView) _setKeyRotationEntryMappings(keyRotationEntry *KeyRotationEntry) {
+	// This function shouldn't be called with nil.
+	if keyRotationEntry == nil {
+		glog.Errorf("_setKeyRotationEntryMappings: Called with nil KeyRotationEntry; " +
+			"this should never happen.")
+		return
+	}
+
+	bav.PublicKeyToKeyRotationEntry[MakePkMapKey(keyRotationEntry.OldPublicKey)] = keyRotationEntry
+}
+
+func (bav *UtxoView) _deleteKeyRotationEntryMappings(keyRotationEntry *KeyRotationEntry) {
+
+	// Create a tombstone entry.
+	tombstoneKeyRotationEntry := *keyRotationEntry
+	tombstoneKeyRotationEntry.isDeleted = true
+
+	// Set the mappings to point to the tombstone entry.
+	bav._setKeyRotationEntryMappings(&tombstoneKeyRotationEntry)
+}
+
+// GetKeyRotationTimelockEntry returns the minimum timelock ownerPKID has committed
+// to, or nil if it has never set one.
+func (bav *UtxoView) GetKeyRotationTimelockEntry(ownerPKID *PKID) *KeyRotationTimelockEntry {
+	// If an entry exists in the in-memory map, return the value of that mapping.
+	if mapValue, existsMapValue := bav.PKIDToKeyRotationTimelockEntry[*ownerPKID]; existsMapValue {
+		if mapValue.isDeleted {
+			return nil
+		}
+		return mapValue
+	}
+
+	// If we get here it means no value exists in our in-memory map. In this case,
+	// defer to the db. If a mapping exists in the db, return it. If not, return
+	// nil. Either way, save the value to the in-memory view mapping got later.
+	var timelockEntry *KeyRotationTimelockEntry
+	if bav.Postgres != nil {
+		if timelock := bav.Postgres.GetKeyRotationTimelock(ownerPKID); timelock != nil {
+			timelockEntry = timelock.NewKeyRotationTimelockEntry()
+		}
+	} else {
+		timelockEntry = DbGetKeyRotationTimelockEntry(bav.Handle, ownerPKID)
+	}
+	if timelockEntry != nil {
+		bav._setKeyRotationTimelockEntryMappings(timelockEntry)
+	}
+	return timelockEntry
+}
+
+func (bav *UtxoView) _setKeyRotationTimelockEntryMappings(timelockEntry *KeyRotationTimelockEntry) {
+	// This function shouldn't be called with nil.
+	if timelockEntry == nil {
+		glog.Errorf("_setKeyRotationTimelockEntryMappings: Called with nil KeyRotationTimelockEntry; " +
+			"this should never happen.")
+		return
+	}
+
+	bav.PKIDToKeyRotationTimelockEntry[*timelockEntry.OwnerPKID] = timelockEntry
+}
+
+func (bav *UtxoView) _deleteKeyRotationTimelockEntryMappings(timelockEntry *KeyRotationTimelockEntry) {
+
+	// Create a tombstone entry.
+	tombstoneTimelockEntry := *timelockEntry
+	tombstoneTimelockEntry.isDeleted = true
+
+	// Set the mappings to point to the tombstone entry.
+	bav._setKeyRotationTimelockEntryMappings(&tombstoneTimelockEntry)
+}
+
+// GetMinKeyRotationTimelockBlocks returns the smallest timelock ownerPKID can use
+// to rotate its key at blockHeight.
+func (bav *UtxoView) GetMinKeyRotationTimelockBlocks(ownerPKID *PKID, blockHeight uint64) uint64 {
+	timelockEntry := bav.GetKeyRotationTimelockEntry(ownerPKID)
+	if timelockEntry == nil {
+		return 0
+	}
+	if timelockEntry.PendingUnlockBlockHeight != 0 && blockHeight >= timelockEntry.PendingUnlockBlockHeight {
+		return timelockEntry.PendingMinTimelockBlocks
+	}
+	return timelockEntry.MinTimelockBlocks
+}
+
+// GetRotatedPublicKeyEntry returns the record of publicKey being rotated away from
+// its account, or nil if it never was.
+func (bav *UtxoView) GetRotatedPublicKeyEntry(publicKey []byte) *RotatedPublicKeyEntry {
+	// If an entry exists in the in-memory map, return the value of that mapping.
+	pkMapKey := MakePkMapKey(publicKey)
+	if mapValue, existsMapValue := bav.PublicKeyToRotatedPublicKeyEntry[pkMapKey]; existsMapValue {
+		if mapValue.isDeleted {
+			return nil
+		}
+		return mapValue
+	}
+
+	// If we get here it means no value exists in our in-memory map. In this case,
+	// defer to the db. If a mapping exists in the db, return it. If not, return
+	// nil. Either way, save the value to the in-memory view mapping got later.
+	var rotatedPublicKeyEntry *RotatedPublicKeyEntry
+	if bav.Postgres != nil {
+		if rotatedPublicKey := bav.Postgres.GetRotatedPublicKey(publicKey); rotatedPublicKey != nil {
+			rotatedPublicKeyEntry = rotatedPublicKey.NewRotatedPublicKeyEntry()
+		}
+	} else {
+		rotatedPublicKeyEntry = DbGetRotatedPublicKeyEntry(bav.Handle, publicKey)
+	}
+	if rotatedPublicKeyEntry != nil {
+		bav._setRotatedPublicKeyEntryMappings(rotatedPublicKeyEntry)
+	}
+	return rotatedPublicKeyEntry
+}
+
+func (bav *UtxoView) _setRotatedPublicKeyEntryMappings(rotatedPublicKeyEntry *RotatedPublicKeyEntry) {
+	// This function shouldn't be called with nil.
+	if rotatedPublicKeyEntry == nil {
+		glog.Errorf("_setRotatedPublicKeyEntryMappings: Called with nil RotatedPublicKeyEntry; " +
+			"this should never happen.")
+		return
+	}
+
+	bav.PublicKeyToRotatedPublicKeyEntry[MakePkMapKey(rotatedPublicKeyEntry.OldPublicKey)] = rotatedPublicKeyEntry
+}
+
+func (bav *UtxoView) _deleteRotatedPublicKeyEntryMappings(rotatedPublicKeyEntry *RotatedPublicKeyEntry) {
+
+	// Create a tombstone entry.
+	tombstoneRotatedPublicKeyEntry := *rotatedPublicKeyEntry
+	tombstoneRotatedPublicKeyEntry.isDeleted = true
+
+	// Set the mappings to point to the tombstone entry.
+	bav._setRotatedPublicKeyEntryMappings(&tombstoneRotatedPublicKeyEntry)
+}
+
+// GetCurrentPublicKey returns the public key that now holds the account publicKey
+// was rotated away from, or publicKey itself if it was never rotated. Posts stay
+// keyed by the PosterPublicKey they were made with, so this is how the account's
+// current key is found for them.
+func (bav *UtxoView) GetCurrentPublicKey(publicKey []byte) []byte {
+	rotatedPublicKeyEntry := bav.GetRotatedPublicKeyEntry(publicKey)
+	if rotatedPublicKeyEntry == nil {
+		return publicKey
+	}
+	return bav.GetPublicKeyForPKID(rotatedPublicKeyEntry.PKID)
+}
+
+// _swapPKIDsForKeyRotation swaps the PKIDs of two public keys and moves each
+// profile to the public key that now has its PKID. Swapping twice restores the
+// original mappings, so it's used to both connect and disconnect a rotation.
+func (bav *UtxoView) _swapPKIDsForKeyRotation(oldPublicKey []byte, newPublicKey []byte) error {
+	// Look the profiles up before the PKIDs are swapped or we'd get them backwards.
+	oldProfileEntry := bav.GetProfileEntryForPublicKey(oldPublicKey)
+	newProfileEntry := bav.GetProfileEntryForPublicKey(newPublicKey)
+
+	// These are guaranteed to be set since they default to the public key itself.
+	oldPKIDEntry := bav.GetPKIDForPublicKey(oldPublicKey)
+	if oldPKIDEntry == nil || oldPKIDEntry.isDeleted {
+		return RuleErrorKeyRotationOldKeyHasDeletedPKID
+	}
+	newPKIDEntry := bav.GetPKIDForPublicKey(newPublicKey)
+	if newPKIDEntry == nil || newPKIDEntry.isDeleted {
+		return RuleErrorKeyRotationNewKeyHasDeletedPKID
+	}
+
+	swappedOldPKIDEntry := *oldPKIDEntry
+	swappedOldPKIDEntry.PKID = newPKIDEntry.PKID
+	swappedNewPKIDEntry := *newPKIDEntry
+	swappedNewPKIDEntry.PKID = oldPKIDEntry.PKID
+
+	bav._deletePKIDMappings(oldPKIDEntry)
+	bav._deletePKIDMappings(newPKIDEntry)
+	bav._setPKIDMappings(&swappedOldPKIDEntry)
+	bav._setPKIDMappings(&swappedNewPKIDEntry)
+
+	// Re-set each profile with the public key its PKID now maps to. Postgres doesn't
+	// have a concept of PKID mappings, so, like SwapIdentity, we save an empty profile
+	// for a key without one to keep track of its PKID.
+	for _, profileAndPublicKey := range []struct {
+		profileEntry *ProfileEntry
+		publicKey    []byte
+	}{
+		{oldProfileEntry, newPublicKey},
+		{newProfileEntry, oldPublicKey},
+	} {
+		if profileAndPublicKey.profileEntry != nil && !profileAndPublicKey.profileEntry.isDeleted {
+			movedProfileEntry := *profileAndPublicKey.profileEntry
+			movedProfileEntry.PublicKey = profileAndPublicKey.publicKey
+			bav._setProfileEntryMappings(&movedProfileEntry)
+		} else if bav.Postgres != nil {
+			bav._setProfileEntryMappings(&ProfileEntry{
+				PublicKey: profileAndPublicKey.publicKey,
+			})
+		}
+	}
+
+	return nil
+}
+
+// _getOwnedMessagingGroupEntries returns the messaging groups created by the owner,
+// leaving out the base group every key has and the groups the owner is only a
+// member of.
+func (bav *UtxoView) _getOwnedMessagingGroupEntries(ownerPublicKey []byte) ([]*MessagingGroupEntry, error) {
+	messagingGroupEntries, err := bav.GetMessagingGroupEntriesForUser(ownerPublicKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "_getOwnedMessagingGroupEntries: ")
+	}
+
+	ownedEntries := []*MessagingGroupEntry{}
+	for _, messagingGroupEntry := range messagingGroupEntries {
+		if !reflect.DeepEqual(messagingGroupEntry.GroupOwnerPublicKey[:], ownerPublicKey) ||
+			EqualGroupKeyName(messagingGroupEntry.MessagingGroupKeyName, BaseGroupKeyName()) {
+			continue
+		}
+		ownedEntries = append(ownedEntries, messagingGroupEntry)
+	}
+	sort.Slice(ownedEntries, func(ii, jj int) bool {
+		return bytes.Compare(ownedEntries[ii].MessagingGroupKeyName[:], ownedEntries[jj].MessagingGroupKeyName[:]) < 0
+	})
+	return ownedEntries, nil
+}
+
+// _validatePublicKeyRotation checks that oldPublicKey's PKID can be given to
+// newPublicKey without clobbering anything newPublicKey already has. It returns the
+// derived keys and messaging groups _rotatePublicKey would move.
+func (bav *UtxoView) _validatePublicKeyRotation(oldPublicKey []byte, newPublicKey []byte) (
+	_derivedKeyEntries []*DerivedKeyEntry, _messagingGroupEntries []*MessagingGroupEntry, _err error) {
+
+	// A key that was rotated away is treated as compromised for good, so it can't
+	// take over or hand off an account again.
+	if bav.GetRotatedPublicKeyEntry(oldPublicKey) != nil {
+		return nil, nil, RuleErrorKeyRotationOldKeyWasRotatedAway
+	}
+	if bav.GetRotatedPublicKeyEntry(newPublicKey) != nil {
+		return nil, nil, RuleErrorKeyRotationNewKeyWasRotatedAway
+	}
+
+	// Taking over a PKID would orphan the new key's own profile.
+	newProfileEntry := bav.GetProfileEntryForPublicKey(newPublicKey)
+	if newProfileEntry != nil && !newProfileEntry.isDeleted && len(newProfileEntry.Username) != 0 {
+		return nil, nil, RuleErrorKeyRotationNewKeyHasProfile
+	}
+
+	derivedKeyMappings, err := bav.GetAllDerivedKeyMappingsForOwner(oldPublicKey)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "_validatePublicKeyRotation: ")
+	}
+	derivedKeyEntries := []*DerivedKeyEntry{}
+	for _, derivedKeyEntry := range derivedKeyMappings {
+		existingEntry := bav._getDerivedKeyMappingForOwner(newPublicKey, derivedKeyEntry.DerivedPublicKey[:])
+		if existingEntry != nil && !existingEntry.isDeleted {
+			return nil, nil, errors.Wrapf(RuleErrorKeyRotationDerivedKeyConflict,
+				"_validatePublicKeyRotation: Derived key %v", PkToStringBoth(derivedKeyEntry.DerivedPublicKey[:]))
+		}
+		derivedKeyEntries = append(derivedKeyEntries, derivedKeyEntry)
+	}
+	sort.Slice(derivedKeyEntries, func(ii, jj int) bool {
+		return bytes.Compare(derivedKeyEntries[ii].DerivedPublicKey[:], derivedKeyEntries[jj].DerivedPublicKey[:]) < 0
+	})
+
+	messagingGroupEntries, err := bav._getOwnedMessagingGroupEntries(oldPublicKey)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "_validatePublicKeyRotation: ")
+	}
+	newOwnerPublicKey := NewPublicKey(newPublicKey)
+	for _, messagingGroupEntry := range messagingGroupEntries {
+		existingEntry := bav.GetMessagingGroupKeyToMessagingGroupEntryMapping(
+			NewMessagingGroupKey(newOwnerPublicKey, messagingGroupEntry.MessagingGroupKeyName[:]))
+		if existingEntry != nil && !existingEntry.isDeleted {
+			return nil, nil, errors.Wrapf(RuleErrorKeyRotationMessagingGroupConflict,
+				"_validatePublicKeyRotation: Group key name %v", string(messagingGroupEntry.MessagingGroupKeyName[:]))
+		}
+	}
+
+	return derivedKeyEntries, messagingGroupEntries, nil
+}
+
+// _rotatePublicKey gives newPublicKey the PKID of oldPublicKey and re-keys the old
+// key's derived keys and messaging groups. It returns the derived keys and
+// messaging groups it moved, as they were under the old key.
+func (bav *UtxoView) _rotatePublicKey(oldPublicKey []byte, newPublicKey []byte) (
+	_derivedKeyEntries []*DerivedKeyEntry, _messagingGroupEntries []*MessagingGroupEntry, _err error) {
+
+	derivedKeyEntries, messagingGroupEntries, err := bav._validatePublicKeyRotation(oldPublicKey, newPublicKey)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "_rotatePublicKey: ")
+	}
+
+	if err := bav._swapPKIDsForKeyRotation(oldPublicKey, newPublicKey); err != nil {
+		return nil, nil, errors.Wrapf(err, "_rotatePublicKey: ")
+	}
+	bav._setRotatedPublicKeyEntryMappings(&RotatedPublicKeyEntry{
+		OldPublicKey: oldPublicKey,
+		PKID:         bav.GetPKIDForPublicKey(newPublicKey).PKID,
+	})
+
+	newOwnerPublicKey := NewPublicKey(newPublicKey)
+	prevDerivedKeyEntries := []*DerivedKeyEntry{}
+	for _, derivedKeyEntry := range derivedKeyEntries {
+		prevDerivedKeyEntry := *derivedKeyEntry
+		prevDerivedKeyEntries = append(prevDerivedKeyEntries, &prevDerivedKeyEntry)
+
+		bav._deleteDerivedKeyMapping(derivedKeyEntry)
+		movedDerivedKeyEntry := *derivedKeyEntry
+		movedDerivedKeyEntry.OwnerPublicKey = *newOwnerPublicKey
+		bav._setDerivedKeyMapping(&movedDerivedKeyEntry)
+	}
+
+	oldOwnerPublicKey := NewPublicKey(oldPublicKey)
+	prevMessagingGroupEntries := []*MessagingGroupEntry{}
+	for _, messagingGroupEntry := range messagingGroupEntries {
+		prevMessagingGroupEntry := *messagingGroupEntry
+		prevMessagingGroupEntries = append(prevMessagingGroupEntries, &prevMessagingGroupEntry)
+
+		bav._deleteMessagingGroupKeyToMessagingGroupEntryMapping(oldOwnerPublicKey, messagingGroupEntry)
+		movedMessagingGroupEntry := *messagingGroupEntry
+		movedMessagingGroupEntry.GroupOwnerPublicKey = newOwnerPublicKey
+		bav._setMessagingGroupKeyToMessagingGroupEntryMapping(newOwnerPublicKey, &movedMessagingGroupEntry)
+	}
+
+	return prevDerivedKeyEntries, prevMessagingGroupEntries, nil
+}
+
+// _unrotatePublicKey reverts _rotatePublicKey given the derived keys and messaging
+// groups it moved.
+func (bav *UtxoView) _unrotatePublicKey(oldPublicKey []byte, newPublicKey []byte,
+	prevDerivedKeyEntries []*DerivedKeyEntry, prevMessagingGroupEntries []*MessagingGroupEntry) error {
+
+	newOwnerPublicKey := NewPublicKey(newPublicKey)
+	for _, prevMessagingGroupEntry := range prevMessagingGroupEntries {
+		movedEntry := bav.GetMessagingGroupKeyToMessagingGroupEntryMapping(
+			NewMessagingGroupKey(newOwnerPublicKey, prevMessagingGroupEntry.MessagingGroupKeyName[:]))
+		if movedEntry == nil || movedEntry.isDeleted {
+			return fmt.Errorf("_unrotatePublicKey: Messaging group %v not found under the new key; "+
+				"this should never happen", string(prevMessagingGroupEntry.MessagingGroupKeyName[:]))
+		}
+		bav._deleteMessagingGroupKeyToMessagingGroupEntryMapping(newOwnerPublicKey, movedEntry)
+		prevEntry := *prevMessagingGroupEntry
+		bav._setMessagingGroupKeyToMessagingGroupEntryMapping(prevEntry.GroupOwnerPublicKey, &prevEntry)
+	}
+
+	for _, prevDerivedKeyEntry := range prevDerivedKeyEntries {
+		movedEntry := bav._getDerivedKeyMappingForOwner(newPublicKey, prevDerivedKeyEntry.DerivedPublicKey[:])
+		if movedEntry == nil || movedEntry.isDeleted {
+			return fmt.Errorf("_unrotatePublicKey: Derived key %v not found under the new key; "+
+				"this should never happen", PkToStringBoth(prevDerivedKeyEntry.DerivedPublicKey[:]))
+		}
+		bav._deleteDerivedKeyMapping(movedEntry)
+		prevEntry := *prevDerivedKeyEntry
+		bav._setDerivedKeyMapping(&prevEntry)
+	}
+
+	rotatedPublicKeyEntry := bav.GetRotatedPublicKeyEntry(oldPublicKey)
+	if rotatedPublicKeyEntry == nil {
+		return fmt.Errorf("_unrotatePublicKey: RotatedPublicKeyEntry for %v not found; "+
+			"this should never happen", PkToStringBoth(oldPublicKey))
+	}
+	bav._deleteRotatedPublicKeyEntryMappings(rotatedPublicKeyEntry)
+
+	if err := bav._swapPKIDsForKeyRotation(oldPublicKey, newPublicKey); err != nil {
+		return errors.Wrapf(err, "_unrotatePublicKey: ")
+	}
+	return nil
+}
+
+// _isKeyRotationPerformed returns true if the txn rotates the key when it's
+// connected, as opposed to only starting or cancelling a pending rotation.
+func _isKeyRotationPerformed(txMeta *KeyRotationMetadata) bool {
+	return txMeta.OperationType == KeyRotationOperationComplete ||
+		(txMeta.OperationType == KeyRotationOperationInitiate && txMeta.TimelockBlocks == 0)
+}
+
+func (bav *UtxoView) _connectKeyRotation(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
+	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
+
+	if blockHeight < bav.Params.ForkHeights.KeyRotationBlockHeight {
+		return 0, 0, nil, RuleErrorKeyRotationBeforeBlockHeight
+	}
+
+	// Check that the transaction has the right TxnType.
+	if txn.TxnMeta.GetTxnType() != TxnTypeKeyRotation {
+		return 0, 0, nil, fmt.Errorf("_connectKeyRotation: called with bad TxnType %s",
+			txn.TxnMeta.GetTxnType().String())
+	}
+	txMeta := txn.TxnMeta.(*KeyRotationMetadata)
+
+	// A derived key mustn't be able to hand the owner's PKID to someone else.
+	if _, isDerived := txn.ExtraData[DerivedPublicKey]; isDerived {
+		return 0, 0, nil, RuleErrorKeyRotationCannotUseDerivedKey
+	}
+
+	ownerPKID := bav.GetPKIDForPublicKey(txn.PublicKey).PKID
+	minTimelockBlocks := bav.GetMinKeyRotationTimelockBlocks(ownerPKID, uint64(blockHeight))
+
+	prevKeyRotationEntry := bav.GetPendingKeyRotationEntry(txn.PublicKey)
+	var newKeyRotationEntry *KeyRotationEntry
+	var newTimelockEntry *KeyRotationTimelockEntry
+	switch txMeta.OperationType {
+	case KeyRotationOperationInitiate:
+		if prevKeyRotationEntry != nil {
+			return 0, 0, nil, RuleErrorKeyRotationAlreadyPending
+		}
+		if len(txMeta.NewPublicKey) != btcec.PubKeyBytesLenCompressed ||
+			reflect.DeepEqual(txMeta.NewPublicKey, txn.PublicKey) {
+			return 0, 0, nil, RuleErrorKeyRotationInvalidNewPublicKey
+		}
+		if _, err := btcec.ParsePubKey(txMeta.NewPublicKey, btcec.S256()); err != nil {
+			return 0, 0, nil, errors.Wrapf(RuleErrorKeyRotationInvalidNewPublicKey, err.Error())
+		}
+		if err := _verifyBytesSignature(txMeta.NewPublicKey,
+			KeyRotationSignatureData(txn.PublicKey, txMeta.NewPublicKey), txMeta.NewKeySignature); err != nil {
+			return 0, 0, nil, errors.Wrapf(RuleErrorKeyRotationInvalidNewKeySignature, err.Error())
+		}
+		if txMeta.TimelockBlocks < minTimelockBlocks {
+			return 0, 0, nil, errors.Wrapf(RuleErrorKeyRotationTimelockBelowMinimum,
+				"_connectKeyRotation: Timelock: %d, minimum: %d", txMeta.TimelockBlocks, minTimelockBlocks)
+		}
+		activeGuardianSetEntry := bav.GetActiveRecoveryGuardianSetEntry(ownerPKID, uint64(blockHeight))
+		if activeGuardianSetEntry != nil && len(activeGuardianSetEntry.GuardianPublicKeys) != 0 &&
+			txMeta.TimelockBlocks < activeGuardianSetEntry.DelayBlocks {
+			return 0, 0, nil, errors.Wrapf(RuleErrorKeyRotationTimelockBelowMinimum,
+				"_connectKeyRotation: Timelock: %d, recovery guardians' delay: %d",
+				txMeta.TimelockBlocks, activeGuardianSetEntry.DelayBlocks)
+		}
+		if txMeta.TimelockBlocks > 0 {
+			if txMeta.TimelockBlocks > math.MaxUint64-uint64(blockHeight) {
+				return 0, 0, nil, RuleErrorKeyRotationInvalidTimelock
+			}
+			newKeyRotationEntry = &KeyRotationEntry{
+				OldPublicKey:      txn.PublicKey,
+				NewPublicKey:      txMeta.NewPublicKey,
+				UnlockBlockHeight: uint64(blockHeight) + txMeta.TimelockBlocks,
+			}
+		}
+
+	case KeyRotationOperationCancel, KeyRotationOperationComplete:
+		if prevKeyRotationEntry == nil {
+			return 0, 0, nil, RuleErrorKeyRotationNotPending
+		}
+		if !reflect.DeepEqual(txMeta.NewPublicKey, prevKeyRotationEntry.NewPublicKey) {
+			return 0, 0, nil, RuleErrorKeyRotationNewPublicKeyMismatch
+		}
+		if txMeta.OperationType == KeyRotationOperationComplete &&
+			uint64(blockHeight) < prevKeyRotationEntry.UnlockBlockHeight {
+			return 0, 0, nil, errors.Wrapf(RuleErrorKeyRotationTimelockNotExpired,
+				"_connectKeyRotation: Height: %d, Unlock height: %d",
+				blockHeight, prevKeyRotationEntry.UnlockBlockHeight)
+		}
+
+	case KeyRotationOperationSetMinTimelock:
+		if len(txMeta.NewPublicKey) != 0 {
+			return 0, 0, nil, RuleErrorKeyRotationInvalidNewPublicKey
+		}
+		newTimelockEntry = &KeyRotationTimelockEntry{
+			OwnerPKID:         ownerPKID,
+			MinTimelockBlocks: txMeta.TimelockBlocks,
+		}
+		// A lower minimum waits out the current one so that a stolen key can't lower
+		// it and then rotate the account away before the owner notices. Raising it
+		// drops any lower minimum that was pending.
+		if txMeta.TimelockBlocks < minTimelockBlocks {
+			if minTimelockBlocks > math.MaxUint64-uint64(blockHeight) {
+				return 0, 0, nil, RuleErrorKeyRotationInvalidTimelock
+			}
+			newTimelockEntry.MinTimelockBlocks = minTimelockBlocks
+			newTimelockEntry.PendingMinTimelockBlocks = txMeta.TimelockBlocks
+			newTimelockEntry.PendingUnlockBlockHeight = uint64(blockHeight) + minTimelockBlocks
+		}
+
+	default:
+		return 0, 0, nil, errors.Wrapf(RuleErrorKeyRotationInvalidOperationType,
+			"_connectKeyRotation: Operation type: %d", txMeta.OperationType)
+	}
+
+	// Connect basic txn to get the total input and the total output without
+	// considering the transaction metadata.
+	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
+		txn, txHash, blockHeight, verifySignatures)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectKeyRotation: ")
+	}
+
+	// Force the input to be non-zero so that we can prevent replay attacks.
+	if totalInput == 0 {
+		return 0, 0, nil, RuleErrorKeyRotationRequiresNonZeroInput
+	}
+
+	var derivedKeyEntries []*DerivedKeyEntry
+	var messagingGroupEntries []*MessagingGroupEntry
+	if _isKeyRotationPerformed(txMeta) {
+		derivedKeyEntries, messagingGroupEntries, err = bav._rotatePublicKey(txn.PublicKey, txMeta.NewPublicKey)
+		if err != nil {
+			return 0, 0, nil, errors.Wrapf(err, "_connectKeyRotation: ")
+		}
+	}
+
+	utxoOp := &UtxoOperation{
+		Type:                             OperationTypeKeyRotation,
+		KeyRotationDerivedKeyEntries:     derivedKeyEntries,
+		KeyRotationMessagingGroupEntries: messagingGroupEntries,
+	}
+
+	// A pending recovery is kept by PKID so it stays with the account and moves it
+	// on from the new key once it completes. One to the new key itself is done.
+	if _isKeyRotationPerformed(txMeta) {
+		accountRecoveryEntry := bav.GetPendingAccountRecoveryEntry(ownerPKID)
+		if accountRecoveryEntry != nil && reflect.DeepEqual(accountRecoveryEntry.NewPublicKey, txMeta.NewPublicKey) {
+			prevEntry := *accountRecoveryEntry
+			utxoOp.PrevAccountRecoveryEntry = &prevEntry
+			bav._deleteAccountRecoveryEntryMappings(accountRecoveryEntry)
+		}
+	}
+
+	if newTimelockEntry != nil {
+		// Setting the minimum leaves any pending rotation alone.
+		if prevTimelockEntry := bav.GetKeyRotationTimelockEntry(ownerPKID); prevTimelockEntry != nil {
+			prevEntry := *prevTimelockEntry
+			utxoOp.PrevKeyRotationTimelockEntry = &prevEntry
+			bav._deleteKeyRotationTimelockEntryMappings(prevTimelockEntry)
+		}
+		bav._setKeyRotationTimelockEntryMappings(newTimelockEntry)
+	} else {
+		if prevKeyRotationEntry != nil {
+			prevEntry := *prevKeyRotationEntry
+			utxoOp.PrevKeyRotationEntry = &prevEntry
+			bav._deleteKeyRotationEntryMappings(prevKeyRotationEntry)
+		}
+		if newKeyRotationEntry != nil {
+			bav._setKeyRotationEntryMappings(newKeyRotationEntry)
+		}
+	}
+
+	// Add an operation to the list at the end indicating we've rotated a key.
+	utxoOpsForTxn = append(utxoOpsForTxn, utxoOp)
+
+	return totalInput, totalOutput, utxoOpsForTxn, nil
+}
+
+func (bav *UtxoView) _disconnectKeyRotation(
+	operationType OperationType, currentTxn *MsgDeSoTxn, txnHash *BlockHash,
+	utxoOpsForTxn []*UtxoOperation, blockHeight uint32) error {
+
+	// Verify that the last operation is a KeyRotation operation
+	if len(utxoOpsForTxn) == 0 {
+		return fmt.Errorf("_disconnectKeyRotation: utxoOperations are missing")
+	}
+	operationIndex := len(utxoOpsForTxn) - 1
+	if utxoOpsForTxn[operationIndex].Type != OperationTypeKeyRotation {
+		return fmt.Errorf("_disconnectKeyRotation: Trying to revert "+
+			"OperationTypeKeyRotation but found type %v",
+			utxoOpsForTxn[operationIndex].Type)
+	}
+	txMeta := currentTxn.TxnMeta.(*KeyRotationMetadata)
+	operationData := utxoOpsForTxn[operationIndex]
+
+	if _isKeyRotationPerformed(txMeta) {
+		if err := bav._unrotatePublicKey(currentTxn.PublicKey, txMeta.NewPublicKey,
+			operationData.KeyRotationDerivedKeyEntries, operationData.KeyRotationMessagingGroupEntries); err != nil {
+			return errors.Wrapf(err, "_disconnectKeyRotation: ")
+		}
+		if operationData.PrevAccountRecoveryEntry != nil {
+			prevEntry := *operationData.PrevAccountRecoveryEntry
+			bav._setAccountRecoveryEntryMappings(&prevEntry)
+		}
+	}
+
+	if txMeta.OperationType == KeyRotationOperationSetMinTimelock {
+		ownerPKID := bav.GetPKIDForPublicKey(currentTxn.PublicKey).PKID
+		timelockEntry := bav.GetKeyRotationTimelockEntry(ownerPKID)
+		if timelockEntry == nil {
+			return fmt.Errorf("_disconnectKeyRotation: KeyRotationTimelockEntry for "+
+				"PKID %v not found; this should never happen", PkToStringBoth(ownerPKID[:]))
+		}
+		bav._deleteKeyRotationTimelockEntryMappings(timelockEntry)
+		if operationData.PrevKeyRotationTimelockEntry != nil {
+			prevEntry := *operationData.PrevKeyRotationTimelockEntry
+			bav._setKeyRotationTimelockEntryMappings(&prevEntry)
+		}
+	} else {
+		if keyRotationEntry := bav.GetPendingKeyRotationEntry(currentTxn.PublicKey); keyRotationEntry != nil {
+			bav._deleteKeyRotationEntryMappings(keyRotationEntry)
+		}
+		if operationData.PrevKeyRotationEntry != nil {
+			prevEntry := *operationData.PrevKeyRotationEntry
+			bav._setKeyRotationEntryMappings(&prevEntry)
+		}
+	}
+
+	// Now revert the basic transfer with the remaining operations. Cut off
+	// the KeyRotation operation at the end since we just reverted it.
+	return bav._disconnectBasicTransfer(
+		currentTxn, txnHash, utxoOpsForTxn[:operationIndex], blockHeight)
+}
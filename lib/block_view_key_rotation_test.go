@@ -0,0 +1,506 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// _getKeyRotationSignature signs the consent of newPubBase58Check to take over the
+// PKID of oldPubBase58Check with signerPrivBase58Check.
+func _getKeyRotationSignature(t *testing.T, oldPubBase58Check string, newPubBase58Check string,
+	signerPrivBase58Check string) []byte {
+
+	require := require.New(t)
+
+	oldPkBytes, _, err := Base58CheckDecode(oldPubBase58Check)
+	require.NoError(err)
+	newPkBytes, _, err := Base58CheckDecode(newPubBase58Check)
+	require.NoError(err)
+	signerPrivBytes, _, err := Base58CheckDecode(signerPrivBase58Check)
+	require.NoError(err)
+	signerPriv, _ := btcec.PrivKeyFromBytes(btcec.S256(), signerPrivBytes)
+
+	signature, err := signerPriv.Sign(Sha256DoubleHash(KeyRotationSignatureData(oldPkBytes, newPkBytes))[:])
+	require.NoError(err)
+	return signature.Serialize()
+}
+
+func _keyRotationTxn(t *testing.T, chain *Blockchain, params *DeSoParams,
+	feeRateNanosPerKB uint64, oldPkBase58Check string, oldPrivBase58Check string,
+	operationType KeyRotationOperationType, newPkBase58Check string, timelockBlocks uint64,
+	newKeySignature []byte,
+) *MsgDeSoTxn {
+
+	require := require.New(t)
+
+	oldPkBytes, _, err := Base58CheckDecode(oldPkBase58Check)
+	require.NoError(err)
+	// Setting the minimum timelock doesn't have a new key.
+	var newPkBytes []byte
+	if newPkBase58Check != "" {
+		newPkBytes, _, err = Base58CheckDecode(newPkBase58Check)
+		require.NoError(err)
+	}
+
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateKeyRotationTxn(
+		oldPkBytes,
+		operationType,
+		newPkBytes,
+		timelockBlocks,
+		newKeySignature,
+		feeRateNanosPerKB,
+		nil,
+		[]*DeSoOutput{})
+	require.NoError(err)
+
+	require.Equal(totalInputMake, changeAmountMake+feesMake)
+
+	// Sign the transaction now that its inputs are set up.
+	_signTxn(t, txn, oldPrivBase58Check)
+
+	return txn
+}
+
+func _keyRotation(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+	feeRateNanosPerKB uint64, oldPkBase58Check string, oldPrivBase58Check string,
+	operationType KeyRotationOperationType, newPkBase58Check string, timelockBlocks uint64,
+	newKeySignature []byte,
+) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
+
+	require := require.New(t)
+
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+
+	txn := _keyRotationTxn(t, chain, params, feeRateNanosPerKB, oldPkBase58Check, oldPrivBase58Check,
+		operationType, newPkBase58Check, timelockBlocks, newKeySignature)
+
+	txHash := txn.Hash()
+	// Always use height+1 for validation since it's assumed the transaction will
+	// get mined into the next block.
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(OperationTypeKeyRotation, utxoOps[len(utxoOps)-1].Type)
+
+	require.NoError(utxoView.FlushToDb())
+
+	return utxoOps, txn, blockHeight, nil
+}
+
+func _keyRotationWithTestMeta(
+	testMeta *TestMeta,
+	feeRateNanosPerKB uint64,
+	oldPkBase58Check string,
+	oldPrivBase58Check string,
+	operationType KeyRotationOperationType,
+	newPkBase58Check string,
+	timelockBlocks uint64,
+	newKeySignature []byte,
+) {
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, oldPkBase58Check))
+	currentOps, currentTxn, _, err := _keyRotation(
+		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
+		oldPkBase58Check,
+		oldPrivBase58Check,
+		operationType,
+		newPkBase58Check,
+		timelockBlocks,
+		newKeySignature,
+	)
+	require.NoError(testMeta.t, err)
+	testMeta.txnOps = append(testMeta.txnOps, currentOps)
+	testMeta.txns = append(testMeta.txns, currentTxn)
+}
+
+func TestKeyRotation(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	params.ForkHeights.NFTTransferOrBurnAndDerivedKeysBlockHeight = uint32(0)
+	params.ForkHeights.KeyRotationBlockHeight = uint32(0)
+	params.ForkHeights.DeSoDiamondsBlockHeight = uint32(0)
+
+	// Mine a few blocks to give the senderPkString some money.
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	// We build the testMeta obj after mining blocks so that we save the correct block height.
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+
+	// Fund all the keys. m0 and m1 get profiles while m2 and m3 are left bare so
+	// they can take over a PKID.
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 10000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 10000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m2Pub, senderPrivString, 10000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m3Pub, senderPrivString, 10000)
+	_updateProfileWithTestMeta(testMeta, 10, m0Pub, m0Priv, []byte{}, "m0", "i am the m0",
+		shortPic, 10*100, 1.25*100*100, false)
+	_updateProfileWithTestMeta(testMeta, 10, m1Pub, m1Priv, []byte{}, "m1", "i am the m1",
+		shortPic, 10*100, 1.25*100*100, false)
+
+	// m0 buys their own coin and m1 buys some too.
+	_creatorCoinTxnWithTestMeta(testMeta, 10, m0Pub, m0Priv, m0Pub,
+		CreatorCoinOperationTypeBuy, 1000, 0, 0, 0, 0)
+	_creatorCoinTxnWithTestMeta(testMeta, 10, m1Pub, m1Priv, m0Pub,
+		CreatorCoinOperationTypeBuy, 1000, 0, 0, 0, 0)
+
+	// m0 makes a post that stays keyed by m0's public key after the rotation.
+	_submitPostWithTestMeta(testMeta, 10, m0Pub, m0Priv, []byte{}, []byte{},
+		&DeSoBodySchema{Body: "m0 post"}, []byte{}, 1502947011*1e9, false)
+	m0PostHash := testMeta.txns[len(testMeta.txns)-1].Hash()
+
+	// m0 authorizes a derived key.
+	m0PrivBytes, _, err := Base58CheckDecode(m0Priv)
+	require.NoError(err)
+	m0PrivKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), m0PrivBytes)
+	authTxnMeta, derivedPriv := _getAuthorizeDerivedKeyMetadata(t, m0PrivKey, params, 100, false)
+	derivedPrivBase58Check := Base58CheckEncode(derivedPriv.Serialize(), true, params)
+	{
+		testMeta.expectedSenderBalances = append(
+			testMeta.expectedSenderBalances, _getBalance(t, chain, nil, m0Pub))
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		utxoOps, txn, _, err := _doAuthorizeTxn(t, chain, db, params, utxoView, 10, m0PkBytes,
+			authTxnMeta.DerivedPublicKey, derivedPrivBase58Check, authTxnMeta.ExpirationBlock,
+			authTxnMeta.AccessSignature, false)
+		require.NoError(err)
+		require.NoError(utxoView.FlushToDb())
+		testMeta.txnOps = append(testMeta.txnOps, utxoOps)
+		testMeta.txns = append(testMeta.txns, txn)
+	}
+
+	m0PKID := DBGetPKIDEntryForPublicKey(db, m0PkBytes).PKID
+	m1PKID := DBGetPKIDEntryForPublicKey(db, m1PkBytes).PKID
+	m3PKID := DBGetPKIDEntryForPublicKey(db, m3PkBytes).PKID
+
+	// Error case: the new key's signature must be over the old and new keys.
+	{
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m0Pub, m0Priv,
+			KeyRotationOperationInitiate, m3Pub, 0, _getKeyRotationSignature(t, m0Pub, m3Pub, m2Priv))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationInvalidNewKeySignature)
+
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m0Pub, m0Priv,
+			KeyRotationOperationInitiate, m3Pub, 0, _getKeyRotationSignature(t, m0Pub, m2Pub, m3Priv))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationInvalidNewKeySignature)
+	}
+
+	// Error case: a key can't be rotated to itself.
+	{
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m0Pub, m0Priv,
+			KeyRotationOperationInitiate, m0Pub, 0, _getKeyRotationSignature(t, m0Pub, m0Pub, m0Priv))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationInvalidNewPublicKey)
+	}
+
+	// Error case: the new key can't already have a profile.
+	{
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m0Pub, m0Priv,
+			KeyRotationOperationInitiate, m1Pub, 0, _getKeyRotationSignature(t, m0Pub, m1Pub, m1Priv))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationNewKeyHasProfile)
+	}
+
+	// Error case: there's nothing to cancel or complete.
+	{
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m0Pub, m0Priv,
+			KeyRotationOperationCancel, m3Pub, 0, nil)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationNotPending)
+
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m0Pub, m0Priv,
+			KeyRotationOperationComplete, m3Pub, 0, nil)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationNotPending)
+	}
+
+	// m0 rotates to m3 right away. The profile, the coins and the derived key
+	// all move to m3.
+	{
+		_keyRotationWithTestMeta(testMeta, 10, m0Pub, m0Priv, KeyRotationOperationInitiate, m3Pub, 0,
+			_getKeyRotationSignature(t, m0Pub, m3Pub, m3Priv))
+
+		require.Equal(m0PKID, DBGetPKIDEntryForPublicKey(db, m3PkBytes).PKID)
+		require.Equal(m3PKID, DBGetPKIDEntryForPublicKey(db, m0PkBytes).PKID)
+
+		profileEntry := DBGetProfileEntryForPKID(db, m0PKID)
+		require.NotNil(profileEntry)
+		require.Equal("m0", string(profileEntry.Username))
+		require.Equal(m3PkBytes, profileEntry.PublicKey)
+		require.Equal(m3PkBytes, DBGetProfileEntryForUsername(db, []byte("m0")).PublicKey)
+
+		// The holdings are keyed by PKID so they now belong to m3.
+		m1BalanceEntry := DbGetBalanceEntry(db, m1PKID, m0PKID, false)
+		require.NotNil(m1BalanceEntry)
+		require.Greater(m1BalanceEntry.BalanceNanos, uint64(0))
+		require.NotNil(DbGetBalanceEntry(db, m0PKID, m0PKID, false))
+
+		derivedPublicKey := *NewPublicKey(authTxnMeta.DerivedPublicKey)
+		require.Nil(DBGetOwnerToDerivedKeyMapping(db, *NewPublicKey(m0PkBytes), derivedPublicKey))
+		movedDerivedKeyEntry := DBGetOwnerToDerivedKeyMapping(db, *NewPublicKey(m3PkBytes), derivedPublicKey)
+		require.NotNil(movedDerivedKeyEntry)
+		require.Equal(*NewPublicKey(m3PkBytes), movedDerivedKeyEntry.OwnerPublicKey)
+
+		// Disconnecting the rotation should give everything back to m0.
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		lastTxn := testMeta.txns[len(testMeta.txns)-1]
+		require.NoError(utxoView.DisconnectTransaction(lastTxn, lastTxn.Hash(),
+			testMeta.txnOps[len(testMeta.txnOps)-1], chain.blockTip().Height+1))
+		require.Equal(m0PKID, utxoView.GetPKIDForPublicKey(m0PkBytes).PKID)
+		require.Equal(m3PKID, utxoView.GetPKIDForPublicKey(m3PkBytes).PKID)
+		require.Equal(m0PkBytes, utxoView.GetProfileEntryForUsername([]byte("m0")).PublicKey)
+		require.NotNil(utxoView._getDerivedKeyMappingForOwner(m0PkBytes, authTxnMeta.DerivedPublicKey))
+		require.Nil(utxoView.GetRotatedPublicKeyEntry(m0PkBytes))
+		require.Equal(m0PkBytes, utxoView.GetCurrentPublicKey(m0PkBytes))
+	}
+
+	// m0's post resolves to m3, and m0 itself can't post anymore.
+	{
+		rotatedPublicKeyEntry := DbGetRotatedPublicKeyEntry(db, m0PkBytes)
+		require.NotNil(rotatedPublicKeyEntry)
+		require.Equal(m0PKID, rotatedPublicKeyEntry.PKID)
+
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		require.Equal(m3PkBytes, utxoView.GetCurrentPublicKey(m0PkBytes))
+		require.Equal(m3PkBytes, utxoView.GetCurrentPublicKey(
+			utxoView.GetPostEntryForPostHash(m0PostHash).PosterPublicKey))
+
+		_, _, _, err = _submitPost(t, chain, db, params, 10, m0Pub, m0Priv, []byte{}, []byte{},
+			&DeSoBodySchema{Body: "m0 post again"}, []byte{}, 1502947012*1e9, false)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorSubmitPostFromRotatedPublicKey)
+
+		_, _, _, err = _submitPost(t, chain, db, params, 10, m0Pub, m0Priv, m0PostHash[:], []byte{},
+			&DeSoBodySchema{Body: "m0 post edited by m0"}, []byte{}, 1502947012*1e9, false)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorSubmitPostFromRotatedPublicKey)
+	}
+
+	// m3 can edit the post m0 made.
+	_submitPostWithTestMeta(testMeta, 10, m3Pub, m3Priv, m0PostHash[:], []byte{},
+		&DeSoBodySchema{Body: "m0 post edited by m3"}, []byte{}, 1502947012*1e9, false)
+	require.Equal(m0PkBytes, DBGetPostEntryByPostHash(db, m0PostHash).PosterPublicKey)
+
+	// Diamonds on m0's post go to m3 now.
+	{
+		m0BalanceBefore := _getBalance(t, chain, nil, m0Pub)
+		m3BalanceBefore := _getBalance(t, chain, nil, m3Pub)
+
+		_giveDeSoDiamondsWithTestMeta(testMeta, 10, senderPkString, senderPrivString, m0PostHash, 1)
+
+		diamondTxn := testMeta.txns[len(testMeta.txns)-1]
+		require.Equal(m3PkBytes, diamondTxn.TxOutputs[0].PublicKey)
+		diamondNanos := GetDeSoNanosDiamondLevelMapAtBlockHeight(int64(chain.blockTip().Height + 1))[1]
+		require.Equal(diamondNanos, diamondTxn.TxOutputs[0].AmountNanos)
+		require.Equal(m0BalanceBefore, _getBalance(t, chain, nil, m0Pub))
+		require.Equal(m3BalanceBefore+diamondNanos, _getBalance(t, chain, nil, m3Pub))
+	}
+
+	// Error case: m0 was rotated away, so it can't be rotated to or from again.
+	{
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m0Pub, m0Priv,
+			KeyRotationOperationInitiate, m2Pub, 0, _getKeyRotationSignature(t, m0Pub, m2Pub, m2Priv))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationOldKeyWasRotatedAway)
+
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m2Pub, m2Priv,
+			KeyRotationOperationInitiate, m0Pub, 0, _getKeyRotationSignature(t, m2Pub, m0Pub, m0Priv))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationNewKeyWasRotatedAway)
+	}
+
+	// m3 now holds the profile and can use it like any other.
+	_updateProfileWithTestMeta(testMeta, 10, m3Pub, m3Priv, []byte{}, "m0", "i was the m0",
+		shortPic, 10*100, 1.25*100*100, false)
+	require.Equal("i was the m0", string(DBGetProfileEntryForPKID(db, m0PKID).Description))
+
+	// m1 starts a rotation to m2 with a timelock of 10 blocks.
+	blockHeight := chain.blockTip().Height + 1
+	{
+		_keyRotationWithTestMeta(testMeta, 10, m1Pub, m1Priv, KeyRotationOperationInitiate, m2Pub, 10,
+			_getKeyRotationSignature(t, m1Pub, m2Pub, m2Priv))
+
+		// Nothing moves until the rotation is completed.
+		require.Equal(m1PKID, DBGetPKIDEntryForPublicKey(db, m1PkBytes).PKID)
+		keyRotationEntry := DbGetPendingKeyRotationEntry(db, m1PkBytes)
+		require.NotNil(keyRotationEntry)
+		require.Equal(m2PkBytes, keyRotationEntry.NewPublicKey)
+		require.Equal(uint64(blockHeight)+10, keyRotationEntry.UnlockBlockHeight)
+	}
+
+	// Error case: only one rotation can be pending at a time.
+	{
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m1Pub, m1Priv,
+			KeyRotationOperationInitiate, m2Pub, 10, _getKeyRotationSignature(t, m1Pub, m2Pub, m2Priv))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationAlreadyPending)
+	}
+
+	// Error case: the rotation can't be completed before the timelock has passed.
+	{
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m1Pub, m1Priv,
+			KeyRotationOperationComplete, m2Pub, 0, nil)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationTimelockNotExpired)
+	}
+
+	// Error case: the new key must match the pending rotation.
+	{
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m1Pub, m1Priv,
+			KeyRotationOperationCancel, m0Pub, 0, nil)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationNewPublicKeyMismatch)
+	}
+
+	// m1 cancels the rotation and then starts it again.
+	{
+		_keyRotationWithTestMeta(testMeta, 10, m1Pub, m1Priv, KeyRotationOperationCancel, m2Pub, 0, nil)
+		require.Nil(DbGetPendingKeyRotationEntry(db, m1PkBytes))
+
+		_keyRotationWithTestMeta(testMeta, 10, m1Pub, m1Priv, KeyRotationOperationInitiate, m2Pub, 5,
+			_getKeyRotationSignature(t, m1Pub, m2Pub, m2Priv))
+		require.NotNil(DbGetPendingKeyRotationEntry(db, m1PkBytes))
+	}
+
+	// Once the timelock has passed the rotation can be completed.
+	{
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		txn := _keyRotationTxn(t, chain, params, 10, m1Pub, m1Priv,
+			KeyRotationOperationComplete, m2Pub, 0, nil)
+		_, _, _, _, err = utxoView.ConnectTransaction(
+			txn, txn.Hash(), getTxnSize(*txn), blockHeight+5, true /*verifySignature*/, false /*ignoreUtxos*/)
+		require.NoError(err)
+
+		require.Nil(utxoView.GetPendingKeyRotationEntry(m1PkBytes))
+		require.Equal(m1PKID, utxoView.GetPKIDForPublicKey(m2PkBytes).PKID)
+		require.Equal(m2PkBytes, utxoView.GetProfileEntryForUsername([]byte("m1")).PublicKey)
+	}
+
+	// Error case: a derived key can't rotate its owner's key.
+	{
+		txn := _keyRotationTxn(t, chain, params, 10, m3Pub, m3Priv,
+			KeyRotationOperationInitiate, m2Pub, 0, _getKeyRotationSignature(t, m3Pub, m2Pub, m2Priv))
+		_signTxnWithDerivedKey(t, txn, derivedPrivBase58Check)
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		_, _, _, _, err = utxoView.ConnectTransaction(
+			txn, txn.Hash(), getTxnSize(*txn), chain.blockTip().Height+1, true /*verifySignature*/, false /*ignoreUtxos*/)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationCannotUseDerivedKey)
+	}
+
+	// m3 commits to a timelock of at least 20 blocks for rotating m0's old PKID.
+	{
+		_keyRotationWithTestMeta(testMeta, 10, m3Pub, m3Priv, KeyRotationOperationSetMinTimelock, "", 20, nil)
+
+		timelockEntry := DbGetKeyRotationTimelockEntry(db, m0PKID)
+		require.NotNil(timelockEntry)
+		require.Equal(uint64(20), timelockEntry.MinTimelockBlocks)
+		require.Equal(uint64(0), timelockEntry.PendingUnlockBlockHeight)
+	}
+
+	// Error case: a rotation can't use a shorter timelock than the minimum, so
+	// whoever has m3's key can't take the account right away.
+	{
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m3Pub, m3Priv,
+			KeyRotationOperationInitiate, m2Pub, 0, _getKeyRotationSignature(t, m3Pub, m2Pub, m2Priv))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationTimelockBelowMinimum)
+
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m3Pub, m3Priv,
+			KeyRotationOperationInitiate, m2Pub, 19, _getKeyRotationSignature(t, m3Pub, m2Pub, m2Priv))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationTimelockBelowMinimum)
+	}
+
+	// Error case: setting the minimum doesn't take a new key.
+	{
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m3Pub, m3Priv,
+			KeyRotationOperationSetMinTimelock, m2Pub, 20, nil)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationInvalidNewPublicKey)
+	}
+
+	// Lowering the minimum only takes effect once the current minimum has passed.
+	{
+		lowerBlockHeight := chain.blockTip().Height + 1
+		_keyRotationWithTestMeta(testMeta, 10, m3Pub, m3Priv, KeyRotationOperationSetMinTimelock, "", 0, nil)
+
+		timelockEntry := DbGetKeyRotationTimelockEntry(db, m0PKID)
+		require.NotNil(timelockEntry)
+		require.Equal(uint64(20), timelockEntry.MinTimelockBlocks)
+		require.Equal(uint64(0), timelockEntry.PendingMinTimelockBlocks)
+		require.Equal(uint64(lowerBlockHeight)+20, timelockEntry.PendingUnlockBlockHeight)
+
+		_, _, _, err = _keyRotation(t, chain, db, params, 10, m3Pub, m3Priv,
+			KeyRotationOperationInitiate, m2Pub, 0, _getKeyRotationSignature(t, m3Pub, m2Pub, m2Priv))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorKeyRotationTimelockBelowMinimum)
+
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		require.Equal(uint64(20), utxoView.GetMinKeyRotationTimelockBlocks(m0PKID, uint64(lowerBlockHeight)+19))
+		require.Equal(uint64(0), utxoView.GetMinKeyRotationTimelockBlocks(m0PKID, uint64(lowerBlockHeight)+20))
+		txn := _keyRotationTxn(t, chain, params, 10, m3Pub, m3Priv,
+			KeyRotationOperationInitiate, m2Pub, 0, _getKeyRotationSignature(t, m3Pub, m2Pub, m2Priv))
+		_, _, _, _, err = utxoView.ConnectTransaction(
+			txn, txn.Hash(), getTxnSize(*txn), lowerBlockHeight+20, true /*verifySignature*/, false /*ignoreUtxos*/)
+		require.NoError(err)
+	}
+
+	// Raising the minimum again drops the lower one that was pending.
+	{
+		_keyRotationWithTestMeta(testMeta, 10, m3Pub, m3Priv, KeyRotationOperationSetMinTimelock, "", 30, nil)
+
+		timelockEntry := DbGetKeyRotationTimelockEntry(db, m0PKID)
+		require.NotNil(timelockEntry)
+		require.Equal(uint64(30), timelockEntry.MinTimelockBlocks)
+		require.Equal(uint64(0), timelockEntry.PendingUnlockBlockHeight)
+
+		// Disconnecting it should bring the pending lower minimum back.
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		lastTxn := testMeta.txns[len(testMeta.txns)-1]
+		require.NoError(utxoView.DisconnectTransaction(lastTxn, lastTxn.Hash(),
+			testMeta.txnOps[len(testMeta.txnOps)-1], chain.blockTip().Height+1))
+		prevTimelockEntry := utxoView.GetKeyRotationTimelockEntry(m0PKID)
+		require.NotNil(prevTimelockEntry)
+		require.Equal(uint64(20), prevTimelockEntry.MinTimelockBlocks)
+		require.NotEqual(uint64(0), prevTimelockEntry.PendingUnlockBlockHeight)
+	}
+
+	// Roll all successful txns through connect and disconnect loops to make sure nothing breaks.
+	_rollBackTestMetaTxnsAndFlush(testMeta)
+	_applyTestMetaTxnsToMempool(testMeta)
+	_applyTestMetaTxnsToViewAndFlush(testMeta)
+	_disconnectTestMetaTxnsFromViewAndFlush(testMeta)
+	_connectBlockThenDisconnectBlockAndFlush(testMeta)
+}
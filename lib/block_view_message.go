@@ -97,11 +97,22 @@ func (bav *UtxoView) GetMessagingGroupKeyToMessagingGroupEntryMapping(
 			return nil
 		}
 
+		// Groups stored before admins were added don't have this column set.
+		var adminPublicKeys []*PublicKey
+		if len(pgMessagingGroup.MessagingGroupAdmins) > 0 {
+			if err := gob.NewDecoder(
+				bytes.NewReader(pgMessagingGroup.MessagingGroupAdmins)).Decode(&adminPublicKeys); err != nil {
+				glog.Errorf("Error decoding MessagingGroupAdmins from DB: %v", err)
+				return nil
+			}
+		}
+
 		messagingGroupEntry := &MessagingGroupEntry{
-			GroupOwnerPublicKey: pgMessagingGroup.GroupOwnerPublicKey,
-			MessagingPublicKey: pgMessagingGroup.MessagingPublicKey,
+			GroupOwnerPublicKey:   pgMessagingGroup.GroupOwnerPublicKey,
+			MessagingPublicKey:    pgMessagingGroup.MessagingPublicKey,
 			MessagingGroupKeyName: pgMessagingGroup.MessagingGroupKeyName,
 			MessagingGroupMembers: memberEntries,
+			MessagingGroupAdmins:  adminPublicKeys,
 		}
 		bav._setMessagingGroupKeyToMessagingGroupEntryMapping(&messagingGroupKey.OwnerPublicKey, messagingGroupEntry)
 		return messagingGroupEntry
@@ -191,7 +202,7 @@ func (bav *UtxoView) GetMessagingGroupEntriesForUser(ownerPublicKey []byte) (
 		// from the DB. For now we also omit the base key, we will add it later when querying the DB.
 
 		// Check if the messaging key corresponds to our public key.
-		if reflect.DeepEqual(messagingKey.OwnerPublicKey, ownerPublicKey) {
+		if reflect.DeepEqual(messagingKey.OwnerPublicKey[:], ownerPublicKey) {
 			messagingKeysMap[messagingKey] = messagingKeyEntry
 			continue
 		}
@@ -215,6 +226,11 @@ func (bav *UtxoView) GetMessagingGroupEntriesForUser(ownerPublicKey []byte) (
 	for _, messagingKeyEntry := range dbMessagingKeys {
 		key := *NewMessagingGroupKey(
 			messagingKeyEntry.GroupOwnerPublicKey, messagingKeyEntry.MessagingGroupKeyName[:])
+		// The UtxoView is authoritative for any group it has an entry for. If the user was
+		// removed from a group in the view, the db will still have their member mapping.
+		if _, existsInView := bav.MessagingGroupKeyToMessagingGroupEntry[key]; existsInView {
+			continue
+		}
 		// Check if we have seen the messaging key before.
 		if _, exists := messagingKeysMap[key]; !exists {
 			messagingKeysMap[key] = messagingKeyEntry
@@ -408,6 +424,15 @@ func (bav *UtxoView) _connectPrivateMessage(
 		return 0, 0, nil, RuleErrorPrivateMessageTstampIsZero
 	}
 
+	// Check that the recipient hasn't blocked the sender.
+	if blockHeight >= bav.Params.ForkHeights.UserBlocksBlockHeight &&
+		bav.IsPublicKeyBlockedByPublicKey(txn.PublicKey, txMeta.RecipientPublicKey) {
+
+		return 0, 0, nil, errors.Wrapf(
+			RuleErrorPrivateMessageSenderBlockedByRecipient,
+			"_connectPrivateMessage: Recipient pub key: %v", PkToStringBoth(txMeta.RecipientPublicKey))
+	}
+
 	// Connect basic txn to get the total input and the total output without
 	// considering the transaction metadata.
 	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
@@ -703,6 +728,43 @@ func (bav *UtxoView) _disconnectPrivateMessage(
 		currentTxn, txnHash, utxoOpsForTxn[:operationIndex], blockHeight)
 }
 
+// _validateMessagingGroupMember checks that a member being added to a group has a
+// well-formed encrypted key, and that the messaging key it's addressed to exists.
+func (bav *UtxoView) _validateMessagingGroupMember(messagingMember *MessagingGroupMember) error {
+	// Encrypted public key cannot be empty, and has to have at least as many bytes as a generic private key.
+	//
+	// Note that if someone is adding themselves to an unencrypted group, then this value can be set to
+	// zeros or G, the elliptic curve group element, which is also OK.
+	if len(messagingMember.EncryptedKey) < btcec.PrivKeyBytesLen {
+		return errors.Wrapf(
+			RuleErrorMessagingMemberEncryptedKeyTooShort, "_validateMessagingGroupMember: "+
+				"Problem validating messagingMember encrypted key for messagingMember (%v): Encrypted " +
+				"key length %v less than the minimum allowed %v. If this is an unencrypted group " +
+				"member, please set %v zeros for this value", messagingMember.GroupMemberPublicKey[:],
+			len(messagingMember.EncryptedKey), btcec.PrivKeyBytesLen, btcec.PrivKeyBytesLen)
+	}
+
+	// Make sure the messagingMember public key and messaging key name are valid.
+	if err := ValidateGroupPublicKeyAndName(messagingMember.GroupMemberPublicKey[:], messagingMember.GroupMemberKeyName[:]); err != nil {
+		return errors.Wrapf(err, "_validateMessagingGroupMember: " +
+			"Problem validating public key or messaging key for messagingMember (%v)", messagingMember.GroupMemberPublicKey[:])
+	}
+
+	// Now make sure messagingMember's MessagingGroupKey has already been added to UtxoView or DB.
+	// We encrypt the groupMessagingKey to recipients' messaging keys.
+	memberMessagingGroupKey := NewMessagingGroupKey(
+		messagingMember.GroupMemberPublicKey, messagingMember.GroupMemberKeyName[:])
+	memberGroupEntry := bav.GetMessagingGroupKeyToMessagingGroupEntryMapping(memberMessagingGroupKey)
+	// The messaging key has to exist and cannot be deleted.
+	if memberGroupEntry == nil || memberGroupEntry.isDeleted {
+		return errors.Wrapf(
+			RuleErrorMessagingMemberKeyDoesntExist, "_validateMessagingGroupMember: "+
+				"Problem verifying messaing key for messagingMember (%v)", messagingMember.GroupMemberPublicKey[:])
+	}
+
+	return nil
+}
+
 func (bav *UtxoView) _connectMessagingGroup(
 	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
 	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
@@ -863,35 +925,8 @@ func (bav *UtxoView) _connectMessagingGroup(
 
 	// Validate all members.
 	for _, messagingMember := range txMeta.MessagingGroupMembers {
-		// Encrypted public key cannot be empty, and has to have at least as many bytes as a generic private key.
-		//
-		// Note that if someone is adding themselves to an unencrypted group, then this value can be set to
-		// zeros or G, the elliptic curve group element, which is also OK.
-		if len(messagingMember.EncryptedKey) < btcec.PrivKeyBytesLen {
-			return 0, 0, nil, errors.Wrapf(
-				RuleErrorMessagingMemberEncryptedKeyTooShort, "_connectMessagingGroup: "+
-					"Problem validating messagingMember encrypted key for messagingMember (%v): Encrypted " +
-					"key length %v less than the minimum allowed %v. If this is an unencrypted group " +
-					"member, please set %v zeros for this value", messagingMember.GroupMemberPublicKey[:],
-					len(messagingMember.EncryptedKey), btcec.PrivKeyBytesLen, btcec.PrivKeyBytesLen)
-		}
-
-		// Make sure the messagingMember public key and messaging key name are valid.
-		if err := ValidateGroupPublicKeyAndName(messagingMember.GroupMemberPublicKey[:], messagingMember.GroupMemberKeyName[:]); err != nil {
-			return 0, 0, nil, errors.Wrapf(err, "_connectMessagingGroup: " +
-				"Problem validating public key or messaging key for messagingMember (%v)", messagingMember.GroupMemberPublicKey[:])
-		}
-
-		// Now make sure messagingMember's MessagingGroupKey has already been added to UtxoView or DB.
-		// We encrypt the groupMessagingKey to recipients' messaging keys.
-		memberMessagingGroupKey := NewMessagingGroupKey(
-			messagingMember.GroupMemberPublicKey, messagingMember.GroupMemberKeyName[:])
-		memberGroupEntry := bav.GetMessagingGroupKeyToMessagingGroupEntryMapping(memberMessagingGroupKey)
-		// The messaging key has to exist and cannot be deleted.
-		if memberGroupEntry == nil || memberGroupEntry.isDeleted {
-			return 0, 0, nil, errors.Wrapf(
-				RuleErrorMessagingMemberKeyDoesntExist, "_connectMessagingGroup: "+
-					"Problem verifying messaing key for messagingMember (%v)", messagingMember.GroupMemberPublicKey[:])
+		if err := bav._validateMessagingGroupMember(messagingMember); err != nil {
+			return 0, 0, nil, errors.Wrapf(err, "_connectMessagingGroup: ")
 		}
 		// The messagingMember can't be already added to the list of existing members.
 		if _, exists := existingMembers[*messagingMember.GroupMemberPublicKey]; exists {
@@ -921,6 +956,8 @@ func (bav *UtxoView) _connectMessagingGroup(
 	if existingEntry != nil && !existingEntry.isDeleted {
 		prevMessagingKeyEntry = &MessagingGroupEntry{}
 		prevMessagingKeyEntry.Decode(existingEntry.Encode())
+		// Adding members doesn't change who the admins are.
+		messagingGroupEntry.MessagingGroupAdmins = existingEntry.MessagingGroupAdmins
 	}
 	bav._setMessagingGroupKeyToMessagingGroupEntryMapping(&messagingGroupKey.OwnerPublicKey, &messagingGroupEntry)
 
@@ -995,3 +1032,327 @@ func (bav *UtxoView) _disconnectMessagingGroup(
 	return bav._disconnectBasicTransfer(
 		currentTxn, txnHash, utxoOpsForTxn[:operationIndex], blockHeight)
 }
+
+func (bav *UtxoView) _connectMessagingGroupUpdate(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
+	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
+
+	// A MessagingGroupUpdate lets the owner of a group remove members, re-key the group to
+	// a new messaging public key, and designate admins. Admins can add new members, but
+	// everything else is reserved for the owner.
+	//
+	// Removing a member can't make them forget the group's messaging private key, so an
+	// owner who removes someone will usually want to re-key the group in the same txn.
+	// When re-keying, the txn has to give every remaining member the new private key.
+
+	if blockHeight < bav.Params.ForkHeights.MessagingGroupUpdateBlockHeight {
+		return 0, 0, nil, errors.Wrapf(
+			RuleErrorMessagingGroupUpdateBeforeBlockHeight, "_connectMessagingGroupUpdate: "+
+				"Problem connecting messaging group update, too early block height")
+	}
+	if txn.TxnMeta.GetTxnType() != TxnTypeMessagingGroupUpdate {
+		return 0, 0, nil, fmt.Errorf("_connectMessagingGroupUpdate: called with bad TxnType %s",
+			txn.TxnMeta.GetTxnType().String())
+	}
+	txMeta := txn.TxnMeta.(*MessagingGroupUpdateMetadata)
+
+	// Make sure the group owner and key name are well-formed. The base key isn't a
+	// group and can't be updated.
+	if err := ValidateGroupPublicKeyAndName(txMeta.GroupOwnerPublicKey, txMeta.MessagingGroupKeyName); err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectMessagingGroupUpdate: "+
+			"Problem parsing group owner public key: %v", txMeta.GroupOwnerPublicKey)
+	}
+	if EqualGroupKeyName(NewGroupKeyName(txMeta.MessagingGroupKeyName), BaseGroupKeyName()) {
+		return 0, 0, nil, errors.Wrapf(
+			RuleErrorMessagingKeyNameCannotBeZeros, "_connectMessagingGroupUpdate: "+
+				"Cannot update the base key")
+	}
+	// Unencrypted groups are owned by the base point, which nobody can sign for.
+	if reflect.DeepEqual(txMeta.GroupOwnerPublicKey, GetS256BasePointCompressed()) {
+		return 0, 0, nil, RuleErrorMessagingGroupUpdateUnencryptedGroup
+	}
+
+	messagingGroupKey := NewMessagingGroupKey(NewPublicKey(txMeta.GroupOwnerPublicKey), txMeta.MessagingGroupKeyName)
+	existingEntry := bav.GetMessagingGroupKeyToMessagingGroupEntryMapping(messagingGroupKey)
+	if existingEntry == nil || existingEntry.isDeleted {
+		return 0, 0, nil, errors.Wrapf(
+			RuleErrorMessagingGroupDoesntExist, "_connectMessagingGroupUpdate: "+
+				"No group for key %v", messagingGroupKey)
+	}
+
+	// Only the owner and the group's admins can update the group, and admins can only add members.
+	isOwner := reflect.DeepEqual(txn.PublicKey, txMeta.GroupOwnerPublicKey)
+	isAdmin := false
+	for _, admin := range existingEntry.MessagingGroupAdmins {
+		if reflect.DeepEqual(admin[:], txn.PublicKey) {
+			isAdmin = true
+			break
+		}
+	}
+	if !isOwner && !isAdmin {
+		return 0, 0, nil, RuleErrorMessagingGroupUpdateNotOwnerOrAdmin
+	}
+	isRekey := len(txMeta.NewMessagingPublicKey) > 0
+	if !isOwner && (isRekey || len(txMeta.RemovedMemberPublicKeys) > 0 ||
+		len(txMeta.AddedAdminPublicKeys) > 0 || len(txMeta.RemovedAdminPublicKeys) > 0) {
+
+		return 0, 0, nil, RuleErrorMessagingGroupUpdateAdminCanOnlyAddMembers
+	}
+	if !isRekey && len(txMeta.MessagingGroupMembers) == 0 && len(txMeta.RemovedMemberPublicKeys) == 0 &&
+		len(txMeta.AddedAdminPublicKeys) == 0 && len(txMeta.RemovedAdminPublicKeys) == 0 {
+
+		return 0, 0, nil, RuleErrorMessagingGroupUpdateNoChanges
+	}
+
+	// Validate the new messaging public key. This mirrors the checks in _connectMessagingGroup.
+	messagingPublicKey := existingEntry.MessagingPublicKey
+	if isRekey {
+		if err := ValidateGroupPublicKeyAndName(txMeta.NewMessagingPublicKey, txMeta.MessagingGroupKeyName); err != nil {
+			return 0, 0, nil, errors.Wrapf(err, "_connectMessagingGroupUpdate: "+
+				"Problem parsing new messaging public key: %v", txMeta.NewMessagingPublicKey)
+		}
+		if reflect.DeepEqual(txMeta.NewMessagingPublicKey, txn.PublicKey) {
+			return 0, 0, nil, errors.Wrapf(RuleErrorMessagingPublicKeyCannotBeOwnerKey,
+				"_connectMessagingGroupUpdate: messaging public key and txn public key can't be the same")
+		}
+		if reflect.DeepEqual(txMeta.NewMessagingPublicKey, existingEntry.MessagingPublicKey[:]) {
+			return 0, 0, nil, RuleErrorMessagingGroupRekeySameKey
+		}
+		if EqualGroupKeyName(NewGroupKeyName(txMeta.MessagingGroupKeyName), DefaultGroupKeyName()) {
+			bytes := append([]byte{}, txMeta.NewMessagingPublicKey...)
+			bytes = append(bytes, txMeta.MessagingGroupKeyName...)
+			if err := _verifyBytesSignature(txn.PublicKey, bytes, txMeta.GroupOwnerSignature); err != nil {
+				return 0, 0, nil, errors.Wrapf(err, "_connectMessagingGroupUpdate: "+
+					"Problem verifying signature bytes, error: %v", RuleErrorMessagingSignatureInvalid)
+			}
+		}
+		messagingPublicKey = NewPublicKey(txMeta.NewMessagingPublicKey)
+	}
+
+	// Connect basic txn to get the total input and the total output without
+	// considering the transaction metadata.
+	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
+		txn, txHash, blockHeight, verifySignatures)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectMessagingGroupUpdate: ")
+	}
+
+	// Force the input to be non-zero so that an old update can't be replayed after the
+	// group has been changed back.
+	if totalInput == 0 {
+		return 0, 0, nil, RuleErrorMessagingGroupUpdateRequiresNonZeroInput
+	}
+
+	// Start with the existing members, minus the ones being removed.
+	removedMembers := make(map[PublicKey]bool)
+	for _, removedMemberPublicKey := range txMeta.RemovedMemberPublicKeys {
+		if len(removedMemberPublicKey) != btcec.PubKeyBytesLenCompressed {
+			return 0, 0, nil, errors.Wrapf(
+				RuleErrorMessagingGroupMemberDoesntExist, "_connectMessagingGroupUpdate: "+
+					"Removed member public key has length %v", len(removedMemberPublicKey))
+		}
+		if _, exists := removedMembers[*NewPublicKey(removedMemberPublicKey)]; exists {
+			return 0, 0, nil, errors.Wrapf(
+				RuleErrorMessagingGroupDuplicatePublicKey, "_connectMessagingGroupUpdate: "+
+					"Member (%v) is removed twice", removedMemberPublicKey)
+		}
+		removedMembers[*NewPublicKey(removedMemberPublicKey)] = true
+	}
+	var messagingMembers []*MessagingGroupMember
+	memberIndexes := make(map[PublicKey]int)
+	numRemoved := 0
+	for _, existingMember := range existingEntry.MessagingGroupMembers {
+		if _, removed := removedMembers[*existingMember.GroupMemberPublicKey]; removed {
+			numRemoved++
+			continue
+		}
+		memberIndexes[*existingMember.GroupMemberPublicKey] = len(messagingMembers)
+		messagingMembers = append(messagingMembers, existingMember)
+	}
+	if numRemoved != len(removedMembers) {
+		return 0, 0, nil, errors.Wrapf(
+			RuleErrorMessagingGroupMemberDoesntExist, "_connectMessagingGroupUpdate: "+
+				"Found %v of the %v members to remove", numRemoved, len(removedMembers))
+	}
+
+	// Now go through the members in the txn. When re-keying, the ones that are already in
+	// the group replace the existing entries so they pick up the new encrypted key.
+	txnMembers := make(map[PublicKey]bool)
+	for _, messagingMember := range txMeta.MessagingGroupMembers {
+		if err := bav._validateMessagingGroupMember(messagingMember); err != nil {
+			return 0, 0, nil, errors.Wrapf(err, "_connectMessagingGroupUpdate: ")
+		}
+		memberPublicKey := *messagingMember.GroupMemberPublicKey
+		if _, exists := txnMembers[memberPublicKey]; exists {
+			return 0, 0, nil, errors.Wrapf(
+				RuleErrorMessagingGroupDuplicatePublicKey, "_connectMessagingGroupUpdate: "+
+					"Member (%v) is included twice", memberPublicKey[:])
+		}
+		txnMembers[memberPublicKey] = true
+		if _, removed := removedMembers[memberPublicKey]; removed {
+			return 0, 0, nil, errors.Wrapf(
+				RuleErrorMessagingGroupMemberRemovedAndAdded, "_connectMessagingGroupUpdate: "+
+					"Member (%v) is both removed and added", memberPublicKey[:])
+		}
+		// A group's members can't contain the messagingPublicKey.
+		if memberPublicKey == *messagingPublicKey {
+			return 0, 0, nil, errors.Wrapf(
+				RuleErrorMessagingMemberAlreadyExists, "_connectMessagingGroupUpdate: "+
+					"Member can't be the messaging public key (%v)", memberPublicKey[:])
+		}
+		if memberIndex, exists := memberIndexes[memberPublicKey]; exists {
+			if !isRekey {
+				return 0, 0, nil, errors.Wrapf(
+					RuleErrorMessagingMemberAlreadyExists, "_connectMessagingGroupUpdate: "+
+						"Error, member already exists (%v)", memberPublicKey[:])
+			}
+			messagingMembers[memberIndex] = messagingMember
+			continue
+		}
+		messagingMembers = append(messagingMembers, messagingMember)
+	}
+	if isRekey {
+		for memberPublicKey := range memberIndexes {
+			if _, exists := txnMembers[memberPublicKey]; !exists {
+				return 0, 0, nil, errors.Wrapf(
+					RuleErrorMessagingGroupRekeyMissingMember, "_connectMessagingGroupUpdate: "+
+						"Member (%v) wasn't given the new key", memberPublicKey[:])
+			}
+		}
+	}
+	memberPublicKeys := make(map[PublicKey]bool)
+	for _, messagingMember := range messagingMembers {
+		memberPublicKeys[*messagingMember.GroupMemberPublicKey] = true
+	}
+
+	// Finally, update the admins. Removed members stop being admins automatically.
+	removedAdmins := make(map[PublicKey]bool)
+	for _, removedAdminPublicKey := range txMeta.RemovedAdminPublicKeys {
+		if len(removedAdminPublicKey) != btcec.PubKeyBytesLenCompressed {
+			return 0, 0, nil, errors.Wrapf(
+				RuleErrorMessagingGroupAdminDoesntExist, "_connectMessagingGroupUpdate: "+
+					"Removed admin public key has length %v", len(removedAdminPublicKey))
+		}
+		if _, exists := removedAdmins[*NewPublicKey(removedAdminPublicKey)]; exists {
+			return 0, 0, nil, errors.Wrapf(
+				RuleErrorMessagingGroupDuplicatePublicKey, "_connectMessagingGroupUpdate: "+
+					"Admin (%v) is removed twice", removedAdminPublicKey)
+		}
+		removedAdmins[*NewPublicKey(removedAdminPublicKey)] = true
+	}
+	var messagingAdmins []*PublicKey
+	adminPublicKeys := make(map[PublicKey]bool)
+	numAdminsRemoved := 0
+	for _, existingAdmin := range existingEntry.MessagingGroupAdmins {
+		if _, removed := removedAdmins[*existingAdmin]; removed {
+			numAdminsRemoved++
+			continue
+		}
+		if _, removed := removedMembers[*existingAdmin]; removed {
+			continue
+		}
+		adminPublicKeys[*existingAdmin] = true
+		messagingAdmins = append(messagingAdmins, existingAdmin)
+	}
+	if numAdminsRemoved != len(removedAdmins) {
+		return 0, 0, nil, errors.Wrapf(
+			RuleErrorMessagingGroupAdminDoesntExist, "_connectMessagingGroupUpdate: "+
+				"Found %v of the %v admins to remove", numAdminsRemoved, len(removedAdmins))
+	}
+	for _, addedAdminPublicKey := range txMeta.AddedAdminPublicKeys {
+		if len(addedAdminPublicKey) != btcec.PubKeyBytesLenCompressed {
+			return 0, 0, nil, errors.Wrapf(
+				RuleErrorMessagingGroupAdminNotMember, "_connectMessagingGroupUpdate: "+
+					"Added admin public key has length %v", len(addedAdminPublicKey))
+		}
+		adminPublicKey := NewPublicKey(addedAdminPublicKey)
+		if _, removed := removedAdmins[*adminPublicKey]; removed {
+			return 0, 0, nil, errors.Wrapf(
+				RuleErrorMessagingGroupDuplicatePublicKey, "_connectMessagingGroupUpdate: "+
+					"Admin (%v) is both removed and added", addedAdminPublicKey)
+		}
+		if _, exists := adminPublicKeys[*adminPublicKey]; exists {
+			return 0, 0, nil, errors.Wrapf(
+				RuleErrorMessagingGroupAdminAlreadyExists, "_connectMessagingGroupUpdate: "+
+					"Admin (%v) already exists", addedAdminPublicKey)
+		}
+		if _, isMember := memberPublicKeys[*adminPublicKey]; !isMember {
+			return 0, 0, nil, errors.Wrapf(
+				RuleErrorMessagingGroupAdminNotMember, "_connectMessagingGroupUpdate: "+
+					"Admin (%v) isn't a member of the group", addedAdminPublicKey)
+		}
+		adminPublicKeys[*adminPublicKey] = true
+		messagingAdmins = append(messagingAdmins, adminPublicKey)
+	}
+
+	// Save a copy of the existing entry so we can restore it exactly on disconnect.
+	prevMessagingKeyEntry := &MessagingGroupEntry{}
+	if err := prevMessagingKeyEntry.Decode(existingEntry.Encode()); err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectMessagingGroupUpdate: "+
+			"Problem copying existing entry")
+	}
+	bav._setMessagingGroupKeyToMessagingGroupEntryMapping(&messagingGroupKey.OwnerPublicKey, &MessagingGroupEntry{
+		GroupOwnerPublicKey:   &messagingGroupKey.OwnerPublicKey,
+		MessagingPublicKey:    messagingPublicKey,
+		MessagingGroupKeyName: NewGroupKeyName(txMeta.MessagingGroupKeyName),
+		MessagingGroupMembers: messagingMembers,
+		MessagingGroupAdmins:  messagingAdmins,
+	})
+
+	// Construct UtxoOperation.
+	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
+		Type:                  OperationTypeMessagingGroupUpdate,
+		PrevMessagingKeyEntry: prevMessagingKeyEntry,
+	})
+
+	return totalInput, totalOutput, utxoOpsForTxn, nil
+}
+
+func (bav *UtxoView) _disconnectMessagingGroupUpdate(
+	operationType OperationType, currentTxn *MsgDeSoTxn, txnHash *BlockHash,
+	utxoOpsForTxn []*UtxoOperation, blockHeight uint32) error {
+
+	// Verify that the last operation is a MessagingGroupUpdate operation
+	if len(utxoOpsForTxn) == 0 {
+		return fmt.Errorf("_disconnectMessagingGroupUpdate: utxoOperations are missing")
+	}
+	operationIndex := len(utxoOpsForTxn) - 1
+	if utxoOpsForTxn[operationIndex].Type != OperationTypeMessagingGroupUpdate {
+		return fmt.Errorf("_disconnectMessagingGroupUpdate: Trying to revert "+
+			"OperationTypeMessagingGroupUpdate but found type %v",
+			utxoOpsForTxn[operationIndex].Type)
+	}
+	txMeta := currentTxn.TxnMeta.(*MessagingGroupUpdateMetadata)
+
+	// Sanity check that the group owner and key name are valid.
+	if err := ValidateGroupPublicKeyAndName(txMeta.GroupOwnerPublicKey, txMeta.MessagingGroupKeyName); err != nil {
+		return errors.Wrapf(err, "_disconnectMessagingGroupUpdate: failed validating the group "+
+			"owner public key and key name")
+	}
+	messagingGroupKey := NewMessagingGroupKey(NewPublicKey(txMeta.GroupOwnerPublicKey), txMeta.MessagingGroupKeyName)
+
+	// The group must exist, and the previous entry must be for the same group.
+	messagingGroupEntry := bav.GetMessagingGroupKeyToMessagingGroupEntryMapping(messagingGroupKey)
+	if messagingGroupEntry == nil || messagingGroupEntry.isDeleted {
+		return fmt.Errorf("_disconnectMessagingGroupUpdate: Group doesn't exist for "+
+			"messagingGroupKey: %v", messagingGroupKey)
+	}
+	prevMessagingKeyEntry := utxoOpsForTxn[operationIndex].PrevMessagingKeyEntry
+	if prevMessagingKeyEntry == nil {
+		return fmt.Errorf("_disconnectMessagingGroupUpdate: PrevMessagingKeyEntry is missing")
+	}
+	if !reflect.DeepEqual(prevMessagingKeyEntry.GroupOwnerPublicKey[:], messagingGroupEntry.GroupOwnerPublicKey[:]) ||
+		!EqualGroupKeyName(prevMessagingKeyEntry.MessagingGroupKeyName, messagingGroupEntry.MessagingGroupKeyName) {
+
+		return fmt.Errorf("_disconnectMessagingGroupUpdate: PrevMessagingKeyEntry %v doesn't "+
+			"match the current entry %v", prevMessagingKeyEntry, messagingGroupEntry)
+	}
+
+	// Restore the group to how it was before the update. Reverse-index mappings for
+	// members and messaging keys are recomputed from the entry when the view is flushed.
+	bav._setMessagingGroupKeyToMessagingGroupEntryMapping(&messagingGroupKey.OwnerPublicKey, prevMessagingKeyEntry)
+
+	// Now disconnect the basic transfer.
+	return bav._disconnectBasicTransfer(
+		currentTxn, txnHash, utxoOpsForTxn[:operationIndex], blockHeight)
+}
@@ -0,0 +1,234 @@
+package lib
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// GetMessageReadEntry returns the read marker readerPublicKey has set on the
+// thread identified by threadPublicKey, or nil if there isn't one.
+func (bav *UtxoView) GetMessageReadEntry(readerPublicKey []byte, threadPublicKey []byte) *MessageReadEntry {
+	// If an entry exists in the in-memory map, return the value of that mapping.
+	messageReadKey := MakeMessageReadKey(readerPublicKey, threadPublicKey)
+	if mapValue, existsMapValue := bav.MessageReadKeyToMessageReadEntry[messageReadKey]; existsMapValue {
+		if mapValue.isDeleted {
+			return nil
+		}
+		return mapValue
+	}
+
+	// If we get here it means no value exists in our in-memory map. In this case,
+	// defer to the db. If a mapping exists in the db, return it. If not, return
+	// nil. Either way, save the value to the in-memory view mapping got later.
+	var messageReadEntry *MessageReadEntry
+	if bav.Postgres != nil {
+		if messageRead := bav.Postgres.GetMessageRead(readerPublicKey, threadPublicKey); messageRead != nil {
+			messageReadEntry = messageRead.NewMessageReadEntry()
+		}
+	} else {
+		messageReadEntry = DbGetMessageReadEntry(
+			bav.Handle, NewPublicKey(readerPublicKey), NewPublicKey(threadPublicKey))
+	}
+	if messageReadEntry != nil {
+		bav._setMessageReadEntryMappings(messageReadEntry)
+	}
+	return messageReadEntry
+}
+
+// GetMessageThreadPublicKey returns the public key of the thread a message
+// belongs to from the point of view of readerPublicKey. Group chats are keyed
+// by the group's messaging public key and direct messages by the sender's
+// public key. Messages the reader sent themselves return nil since they can
+// never be unread.
+func GetMessageThreadPublicKey(readerPublicKey []byte, messageEntry *MessageEntry,
+	groupMessagingPublicKeys map[PublicKey]bool) *PublicKey {
+
+	if reflect.DeepEqual(messageEntry.SenderPublicKey[:], readerPublicKey) {
+		return nil
+	}
+	if messageEntry.RecipientMessagingPublicKey != nil &&
+		groupMessagingPublicKeys[*messageEntry.RecipientMessagingPublicKey] {
+
+		return messageEntry.RecipientMessagingPublicKey
+	}
+	if reflect.DeepEqual(messageEntry.RecipientPublicKey[:], readerPublicKey) {
+		return messageEntry.SenderPublicKey
+	}
+	return nil
+}
+
+// GetUnreadMessageCountsForUser fetches up to limit messages for publicKey and
+// returns the number of unread messages in each thread. Threads without any
+// unread messages are omitted.
+func (bav *UtxoView) GetUnreadMessageCountsForUser(publicKey []byte, limit uint64) (
+	_threadPublicKeyToUnreadCount map[PublicKey]uint64, _err error) {
+
+	messageEntries, messagingGroupEntries, err := bav.GetLimitedMessagesForUser(publicKey, limit)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetUnreadMessageCountsForUser: Problem getting messages: ")
+	}
+
+	// Any messaging group with members is a group chat, so messages sent to it
+	// are tracked under the group's messaging public key.
+	groupMessagingPublicKeys := make(map[PublicKey]bool)
+	for _, messagingGroupEntry := range messagingGroupEntries {
+		if len(messagingGroupEntry.MessagingGroupMembers) > 0 {
+			groupMessagingPublicKeys[*messagingGroupEntry.MessagingPublicKey] = true
+		}
+	}
+
+	threadPublicKeyToUnreadCount := make(map[PublicKey]uint64)
+	for _, messageEntry := range messageEntries {
+		threadPublicKey := GetMessageThreadPublicKey(publicKey, messageEntry, groupMessagingPublicKeys)
+		if threadPublicKey == nil {
+			continue
+		}
+		messageReadEntry := bav.GetMessageReadEntry(publicKey, threadPublicKey[:])
+		if messageReadEntry != nil && messageEntry.TstampNanos <= messageReadEntry.LastReadTstampNanos {
+			continue
+		}
+		threadPublicKeyToUnreadCount[*threadPublicKey]++
+	}
+
+	return threadPublicKeyToUnreadCount, nil
+}
+
+// IsMessageReadByRecipient returns true if the recipient of a direct message
+// has marked the thread as read up to or past the message.
+func (bav *UtxoView) IsMessageReadByRecipient(messageEntry *MessageEntry) bool {
+	messageReadEntry := bav.GetMessageReadEntry(
+		messageEntry.RecipientPublicKey[:], messageEntry.SenderPublicKey[:])
+	return messageReadEntry != nil && messageEntry.TstampNanos <= messageReadEntry.LastReadTstampNanos
+}
+
+func (bav *UtxoView) _setMessageReadEntryMappings(messageReadEntry *MessageReadEntry) {
+	// This function shouldn't be called with nil.
+	if messageReadEntry == nil {
+		glog.Errorf("_setMessageReadEntryMappings: Called with nil MessageReadEntry; " +
+			"this should never happen.")
+		return
+	}
+
+	messageReadKey := MakeMessageReadKey(
+		messageReadEntry.ReaderPublicKey[:], messageReadEntry.ThreadPublicKey[:])
+	bav.MessageReadKeyToMessageReadEntry[messageReadKey] = messageReadEntry
+}
+
+func (bav *UtxoView) _deleteMessageReadEntryMappings(messageReadEntry *MessageReadEntry) {
+
+	// Create a tombstone entry.
+	tombstoneMessageReadEntry := *messageReadEntry
+	tombstoneMessageReadEntry.isDeleted = true
+
+	// Set the mappings to point to the tombstone entry.
+	bav._setMessageReadEntryMappings(&tombstoneMessageReadEntry)
+}
+
+func (bav *UtxoView) _connectMessageRead(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
+	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
+
+	if blockHeight < bav.Params.ForkHeights.MessageReadReceiptsBlockHeight {
+		return 0, 0, nil, RuleErrorMessageReadBeforeBlockHeight
+	}
+
+	// Check that the transaction has the right TxnType.
+	if txn.TxnMeta.GetTxnType() != TxnTypeMessageRead {
+		return 0, 0, nil, fmt.Errorf("_connectMessageRead: called with bad TxnType %s",
+			txn.TxnMeta.GetTxnType().String())
+	}
+	txMeta := txn.TxnMeta.(*MessageReadMetadata)
+
+	// Check that a proper thread public key is provided in the message metadata.
+	if len(txMeta.ThreadPublicKey) != btcec.PubKeyBytesLenCompressed {
+		return 0, 0, nil, errors.Wrapf(
+			RuleErrorMessageReadThreadPubKeyLen, "_connectMessageRead: "+
+				"ThreadPubKeyLen = %d; Expected length = %d",
+			len(txMeta.ThreadPublicKey), btcec.PubKeyBytesLenCompressed)
+	}
+	if txMeta.LastReadTstampNanos == 0 {
+		return 0, 0, nil, errors.Wrapf(
+			RuleErrorMessageReadTstampNotIncreasing, "_connectMessageRead: "+
+				"LastReadTstampNanos cannot be zero")
+	}
+
+	// Connect basic txn to get the total input and the total output without
+	// considering the transaction metadata.
+	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
+		txn, txHash, blockHeight, verifySignatures)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectMessageRead: ")
+	}
+
+	// At this point the inputs and outputs have been processed. Now we
+	// need to handle the metadata.
+
+	// Read markers only ever move forward. This keeps a marker from being rolled
+	// back by replaying an older transaction.
+	existingMessageReadEntry := bav.GetMessageReadEntry(txn.PublicKey, txMeta.ThreadPublicKey)
+	if existingMessageReadEntry != nil && txMeta.LastReadTstampNanos <= existingMessageReadEntry.LastReadTstampNanos {
+		return 0, 0, nil, errors.Wrapf(
+			RuleErrorMessageReadTstampNotIncreasing, "_connectMessageRead: "+
+				"LastReadTstampNanos = %d; Existing LastReadTstampNanos = %d",
+			txMeta.LastReadTstampNanos, existingMessageReadEntry.LastReadTstampNanos)
+	}
+
+	bav._setMessageReadEntryMappings(&MessageReadEntry{
+		ReaderPublicKey:     NewPublicKey(txn.PublicKey),
+		ThreadPublicKey:     NewPublicKey(txMeta.ThreadPublicKey),
+		LastReadTstampNanos: txMeta.LastReadTstampNanos,
+	})
+
+	// Add an operation to the list at the end indicating we've updated a read marker.
+	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
+		Type:                 OperationTypeMessageRead,
+		PrevMessageReadEntry: existingMessageReadEntry,
+	})
+
+	return totalInput, totalOutput, utxoOpsForTxn, nil
+}
+
+func (bav *UtxoView) _disconnectMessageRead(
+	operationType OperationType, currentTxn *MsgDeSoTxn, txnHash *BlockHash,
+	utxoOpsForTxn []*UtxoOperation, blockHeight uint32) error {
+
+	// Verify that the last operation is a MessageRead operation
+	if len(utxoOpsForTxn) == 0 {
+		return fmt.Errorf("_disconnectMessageRead: utxoOperations are missing")
+	}
+	operationIndex := len(utxoOpsForTxn) - 1
+	currentOperation := utxoOpsForTxn[operationIndex]
+	if currentOperation.Type != OperationTypeMessageRead {
+		return fmt.Errorf("_disconnectMessageRead: Trying to revert "+
+			"OperationTypeMessageRead but found type %v",
+			currentOperation.Type)
+	}
+
+	// Now we know the txMeta is a MessageRead
+	txMeta := currentTxn.TxnMeta.(*MessageReadMetadata)
+
+	// Sanity check that the current marker is the one set by the transaction
+	// we're rolling back.
+	messageReadEntry := bav.GetMessageReadEntry(currentTxn.PublicKey, txMeta.ThreadPublicKey)
+	if messageReadEntry == nil || messageReadEntry.LastReadTstampNanos != txMeta.LastReadTstampNanos {
+		return fmt.Errorf("_disconnectMessageRead: MessageReadEntry %v doesn't match "+
+			"LastReadTstampNanos %d", messageReadEntry, txMeta.LastReadTstampNanos)
+	}
+
+	// Now that we are confident the MessageReadEntry lines up with the transaction
+	// we're rolling back, restore the previous entry.
+	if currentOperation.PrevMessageReadEntry != nil {
+		bav._setMessageReadEntryMappings(currentOperation.PrevMessageReadEntry)
+	} else {
+		bav._deleteMessageReadEntryMappings(messageReadEntry)
+	}
+
+	// Now revert the basic transfer with the remaining operations. Cut off
+	// the MessageRead operation at the end since we just reverted it.
+	return bav._disconnectBasicTransfer(
+		currentTxn, txnHash, utxoOpsForTxn[:operationIndex], blockHeight)
+}
@@ -2199,3 +2199,463 @@ func TestGroupMessages(t *testing.T) {
 	require.NoError(err)
 	assert.Equal(0, len(messages))
 }
+
+// _messagingGroupUpdate connects a MessagingGroupUpdate txn built from txMeta to a new utxo and flushes to DB.
+func _messagingGroupUpdate(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+	senderPk []byte, signerPriv string, txMeta *MessagingGroupUpdateMetadata) ([]*UtxoOperation, *MsgDeSoTxn, error) {
+
+	require := require.New(t)
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateMessagingGroupUpdateTxn(
+		senderPk, txMeta.GroupOwnerPublicKey, txMeta.MessagingGroupKeyName, txMeta.NewMessagingPublicKey,
+		txMeta.GroupOwnerSignature, txMeta.MessagingGroupMembers, txMeta.RemovedMemberPublicKeys,
+		txMeta.AddedAdminPublicKeys, txMeta.RemovedAdminPublicKeys, 10, nil, []*DeSoOutput{})
+	require.NoError(err)
+	require.Equal(totalInputMake, changeAmountMake+feesMake)
+
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+	_signTxn(t, txn, signerPriv)
+	txHash := txn.Hash()
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight,
+			true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, err
+	}
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(totalInput, totalInputMake)
+	// We should have one SPEND UtxoOperation for each input, one ADD operation
+	// for each output, and one OperationTypeMessagingGroupUpdate operation at the end.
+	require.Equal(len(txn.TxInputs)+len(txn.TxOutputs)+1, len(utxoOps))
+	for ii := 0; ii < len(txn.TxInputs); ii++ {
+		require.Equal(OperationTypeSpendUtxo, utxoOps[ii].Type)
+	}
+	require.Equal(OperationTypeMessagingGroupUpdate, utxoOps[len(utxoOps)-1].Type)
+	require.NoError(utxoView.FlushToDb())
+	return utxoOps, txn, err
+}
+
+// _messagingGroupUpdateWithTestMeta is used to connect and flush a messaging group update to the DB.
+func _messagingGroupUpdateWithTestMeta(testMeta *TestMeta, senderPk []byte, signerPriv string,
+	txMeta *MessagingGroupUpdateMetadata, expectedError error) {
+
+	require := require.New(testMeta.t)
+
+	senderPkBase58Check := Base58CheckEncode(senderPk, false, testMeta.params)
+	balance := _getBalance(testMeta.t, testMeta.chain, nil, senderPkBase58Check)
+
+	utxoOps, txn, err := _messagingGroupUpdate(testMeta.t, testMeta.chain, testMeta.db, testMeta.params,
+		senderPk, signerPriv, txMeta)
+
+	if expectedError != nil {
+		require.Error(err)
+		require.Contains(err.Error(), expectedError.Error())
+		return
+	}
+	require.NoError(err)
+
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, balance)
+	testMeta.txnOps = append(testMeta.txnOps, utxoOps)
+	testMeta.txns = append(testMeta.txns, txn)
+}
+
+func TestMessagingGroupUpdate(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	_ = require
+	_ = assert
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+
+	params.ForkHeights.DeSoV3MessagesBlockHeight = 0
+	params.ForkHeights.MessagingGroupUpdateBlockHeight = 0
+
+	// Mine two blocks to give the sender some DeSo.
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 1000000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 1000000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m2Pub, senderPrivString, 1000000)
+
+	senderPkBytes, _, err := Base58CheckDecode(senderPkString)
+	require.NoError(err)
+	senderPrivBytes, _, err := Base58CheckDecode(senderPrivString)
+	require.NoError(err)
+
+	// Members are added with their base key and a placeholder encrypted key, since
+	// consensus doesn't decrypt it.
+	newMember := func(publicKey []byte, encryptedKeyByte byte) *MessagingGroupMember {
+		encryptedKey := make([]byte, btcec.PrivKeyBytesLen)
+		for ii := range encryptedKey {
+			encryptedKey[ii] = encryptedKeyByte
+		}
+		return &MessagingGroupMember{
+			GroupMemberPublicKey: NewPublicKey(publicKey),
+			GroupMemberKeyName:   BaseGroupKeyName(),
+			EncryptedKey:         encryptedKey,
+		}
+	}
+	getGroupEntry := func() *MessagingGroupEntry {
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		return utxoView.GetMessagingGroupKeyToMessagingGroupEntryMapping(
+			NewMessagingGroupKey(NewPublicKey(senderPkBytes), []byte("group")))
+	}
+	getMemberGroupCount := func(publicKey []byte) int {
+		// Every user has the base key, so we subtract it.
+		entries, err := DBGetAllUserGroupEntries(db, publicKey)
+		require.NoError(err)
+		return len(entries) - 1
+	}
+
+	// The sender creates a group with m0 and m1.
+	keyName := []byte("group")
+	_, _, groupKeyEntry := _generateMessagingKey(senderPkBytes, senderPrivBytes, keyName)
+	groupPublicKey := groupKeyEntry.MessagingPublicKey[:]
+	_messagingKeyWithTestMeta(testMeta, senderPkBytes, senderPrivString, groupPublicKey, keyName, nil,
+		[]*MessagingGroupMember{newMember(m0PkBytes, 1), newMember(m1PkBytes, 1)}, nil)
+	require.Equal(1, getMemberGroupCount(m0PkBytes))
+	require.Equal(1, getMemberGroupCount(m1PkBytes))
+
+	// Invalid updates fail.
+	{
+		// m0 isn't an admin yet.
+		_messagingGroupUpdateWithTestMeta(testMeta, m0PkBytes, m0Priv, &MessagingGroupUpdateMetadata{
+			GroupOwnerPublicKey:   senderPkBytes,
+			MessagingGroupKeyName: keyName,
+			MessagingGroupMembers: []*MessagingGroupMember{newMember(m2PkBytes, 1)},
+		}, RuleErrorMessagingGroupUpdateNotOwnerOrAdmin)
+
+		// m2 isn't a member so it can't be an admin.
+		_messagingGroupUpdateWithTestMeta(testMeta, senderPkBytes, senderPrivString, &MessagingGroupUpdateMetadata{
+			GroupOwnerPublicKey:   senderPkBytes,
+			MessagingGroupKeyName: keyName,
+			AddedAdminPublicKeys:  [][]byte{m2PkBytes},
+		}, RuleErrorMessagingGroupAdminNotMember)
+
+		// m2 can't be removed since it isn't a member.
+		_messagingGroupUpdateWithTestMeta(testMeta, senderPkBytes, senderPrivString, &MessagingGroupUpdateMetadata{
+			GroupOwnerPublicKey:     senderPkBytes,
+			MessagingGroupKeyName:   keyName,
+			RemovedMemberPublicKeys: [][]byte{m2PkBytes},
+		}, RuleErrorMessagingGroupMemberDoesntExist)
+
+		// An update has to change something.
+		_messagingGroupUpdateWithTestMeta(testMeta, senderPkBytes, senderPrivString, &MessagingGroupUpdateMetadata{
+			GroupOwnerPublicKey:   senderPkBytes,
+			MessagingGroupKeyName: keyName,
+		}, RuleErrorMessagingGroupUpdateNoChanges)
+
+		// Re-keying has to give every remaining member the new key.
+		_, _, rekeyEntry := _generateMessagingKey(senderPkBytes, senderPrivBytes, keyName)
+		_messagingGroupUpdateWithTestMeta(testMeta, senderPkBytes, senderPrivString, &MessagingGroupUpdateMetadata{
+			GroupOwnerPublicKey:   senderPkBytes,
+			MessagingGroupKeyName: keyName,
+			NewMessagingPublicKey: rekeyEntry.MessagingPublicKey[:],
+			MessagingGroupMembers: []*MessagingGroupMember{newMember(m0PkBytes, 2)},
+		}, RuleErrorMessagingGroupRekeyMissingMember)
+
+		// Updating a group that doesn't exist fails.
+		_messagingGroupUpdateWithTestMeta(testMeta, senderPkBytes, senderPrivString, &MessagingGroupUpdateMetadata{
+			GroupOwnerPublicKey:   senderPkBytes,
+			MessagingGroupKeyName: []byte("nonexistent"),
+			MessagingGroupMembers: []*MessagingGroupMember{newMember(m2PkBytes, 1)},
+		}, RuleErrorMessagingGroupDoesntExist)
+	}
+
+	// The owner makes m0 an admin, and m0 adds m2.
+	_messagingGroupUpdateWithTestMeta(testMeta, senderPkBytes, senderPrivString, &MessagingGroupUpdateMetadata{
+		GroupOwnerPublicKey:   senderPkBytes,
+		MessagingGroupKeyName: keyName,
+		AddedAdminPublicKeys:  [][]byte{m0PkBytes},
+	}, nil)
+	require.Equal([]*PublicKey{NewPublicKey(m0PkBytes)}, getGroupEntry().MessagingGroupAdmins)
+	_messagingGroupUpdateWithTestMeta(testMeta, m0PkBytes, m0Priv, &MessagingGroupUpdateMetadata{
+		GroupOwnerPublicKey:   senderPkBytes,
+		MessagingGroupKeyName: keyName,
+		MessagingGroupMembers: []*MessagingGroupMember{newMember(m2PkBytes, 1)},
+	}, nil)
+	require.Len(getGroupEntry().MessagingGroupMembers, 3)
+	require.Equal(1, getMemberGroupCount(m2PkBytes))
+
+	// Admins can't remove members.
+	_messagingGroupUpdateWithTestMeta(testMeta, m0PkBytes, m0Priv, &MessagingGroupUpdateMetadata{
+		GroupOwnerPublicKey:     senderPkBytes,
+		MessagingGroupKeyName:   keyName,
+		RemovedMemberPublicKeys: [][]byte{m1PkBytes},
+	}, RuleErrorMessagingGroupUpdateAdminCanOnlyAddMembers)
+
+	// The owner removes m1 and re-keys the group for m0 and m2.
+	_, _, rekeyEntry := _generateMessagingKey(senderPkBytes, senderPrivBytes, keyName)
+	newGroupPublicKey := rekeyEntry.MessagingPublicKey[:]
+	_messagingGroupUpdateWithTestMeta(testMeta, senderPkBytes, senderPrivString, &MessagingGroupUpdateMetadata{
+		GroupOwnerPublicKey:     senderPkBytes,
+		MessagingGroupKeyName:   keyName,
+		NewMessagingPublicKey:   newGroupPublicKey,
+		MessagingGroupMembers:   []*MessagingGroupMember{newMember(m2PkBytes, 2), newMember(m0PkBytes, 2)},
+		RemovedMemberPublicKeys: [][]byte{m1PkBytes},
+	}, nil)
+	rekeyedGroupEntry := getGroupEntry()
+	require.Equal(newGroupPublicKey, rekeyedGroupEntry.MessagingPublicKey[:])
+	require.Len(rekeyedGroupEntry.MessagingGroupMembers, 2)
+	for _, member := range rekeyedGroupEntry.MessagingGroupMembers {
+		require.Equal(newMember(member.GroupMemberPublicKey[:], 2).EncryptedKey, member.EncryptedKey)
+	}
+	// m1's member mapping is gone, and m0's now points at the new messaging key.
+	require.Equal(0, getMemberGroupCount(m1PkBytes))
+	m0Entries, err := DBGetAllUserGroupEntries(db, m0PkBytes)
+	require.NoError(err)
+	require.Len(m0Entries, 2)
+	require.Equal(newGroupPublicKey, m0Entries[1].MessagingPublicKey[:])
+	{
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		m1GroupEntries, err := utxoView.GetMessagingGroupEntriesForUser(m1PkBytes)
+		require.NoError(err)
+		require.Len(m1GroupEntries, 1)
+	}
+
+	// Removing m0 also removes it as an admin. Disconnecting restores it exactly.
+	_messagingGroupUpdateWithTestMeta(testMeta, senderPkBytes, senderPrivString, &MessagingGroupUpdateMetadata{
+		GroupOwnerPublicKey:     senderPkBytes,
+		MessagingGroupKeyName:   keyName,
+		RemovedMemberPublicKeys: [][]byte{m0PkBytes},
+	}, nil)
+	require.Len(getGroupEntry().MessagingGroupAdmins, 0)
+	require.Equal(0, getMemberGroupCount(m0PkBytes))
+	{
+		lastIndex := len(testMeta.txns) - 1
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		lastTxn := testMeta.txns[lastIndex]
+		require.NoError(utxoView.DisconnectTransaction(
+			lastTxn, lastTxn.Hash(), testMeta.txnOps[lastIndex], testMeta.savedHeight))
+		require.NoError(utxoView.FlushToDb())
+		require.Equal(rekeyedGroupEntry.Encode(), getGroupEntry().Encode())
+		require.Equal(1, getMemberGroupCount(m0PkBytes))
+
+		testMeta.txns = testMeta.txns[:lastIndex]
+		testMeta.txnOps = testMeta.txnOps[:lastIndex]
+		testMeta.expectedSenderBalances = testMeta.expectedSenderBalances[:lastIndex]
+	}
+
+	// Roll back everything, then connect and disconnect it all in a single view.
+	_rollBackTestMetaTxnsAndFlush(testMeta)
+	require.Nil(getGroupEntry())
+	require.Equal(0, getMemberGroupCount(m0PkBytes))
+	require.Equal(0, getMemberGroupCount(m1PkBytes))
+	require.Equal(0, getMemberGroupCount(m2PkBytes))
+
+	_applyTestMetaTxnsToViewAndFlush(testMeta)
+	require.Equal(rekeyedGroupEntry.Encode(), getGroupEntry().Encode())
+	require.Equal(0, getMemberGroupCount(m1PkBytes))
+	_disconnectTestMetaTxnsFromViewAndFlush(testMeta)
+	require.Nil(getGroupEntry())
+	require.Equal(0, getMemberGroupCount(m0PkBytes))
+}
+
+func _messageRead(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+	readerPk []byte, readerPriv string, threadPk []byte, lastReadTstampNanos uint64) (
+	[]*UtxoOperation, *MsgDeSoTxn, error) {
+
+	require := require.New(t)
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateMessageReadTxn(
+		readerPk, threadPk, lastReadTstampNanos, 10, nil, []*DeSoOutput{})
+	require.NoError(err)
+	require.Equal(totalInputMake, changeAmountMake+feesMake)
+
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+	_signTxn(t, txn, readerPriv)
+	txHash := txn.Hash()
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight,
+			true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, err
+	}
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(totalInput, totalInputMake)
+	// We should have one SPEND UtxoOperation for each input, one ADD operation
+	// for each output, and one OperationTypeMessageRead operation at the end.
+	require.Equal(len(txn.TxInputs)+len(txn.TxOutputs)+1, len(utxoOps))
+	for ii := 0; ii < len(txn.TxInputs); ii++ {
+		require.Equal(OperationTypeSpendUtxo, utxoOps[ii].Type)
+	}
+	require.Equal(OperationTypeMessageRead, utxoOps[len(utxoOps)-1].Type)
+	require.NoError(utxoView.FlushToDb())
+	return utxoOps, txn, err
+}
+
+// _messageReadWithTestMeta is used to connect and flush a read marker to the DB.
+func _messageReadWithTestMeta(testMeta *TestMeta, readerPk []byte, readerPriv string,
+	threadPk []byte, lastReadTstampNanos uint64, expectedError error) {
+
+	require := require.New(testMeta.t)
+
+	readerPkBase58Check := Base58CheckEncode(readerPk, false, testMeta.params)
+	balance := _getBalance(testMeta.t, testMeta.chain, nil, readerPkBase58Check)
+
+	utxoOps, txn, err := _messageRead(testMeta.t, testMeta.chain, testMeta.db, testMeta.params,
+		readerPk, readerPriv, threadPk, lastReadTstampNanos)
+
+	if expectedError != nil {
+		require.Error(err)
+		require.Contains(err.Error(), expectedError.Error())
+		return
+	}
+	require.NoError(err)
+
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, balance)
+	testMeta.txnOps = append(testMeta.txnOps, utxoOps)
+	testMeta.txns = append(testMeta.txns, txn)
+}
+
+func TestMessageReadReceipts(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	_ = require
+	_ = assert
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+
+	params.ForkHeights.MessageReadReceiptsBlockHeight = 0
+
+	// Mine two blocks to give the sender some DeSo.
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 1000000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 1000000)
+
+	senderPkBytes, _, err := Base58CheckDecode(senderPkString)
+	require.NoError(err)
+
+	// m0 sends the sender two messages and m1 sends one. The sender replies to m0.
+	sendMessage := func(senderPk string, senderPriv string, recipientPk string, tstampNanos uint64) *MessageEntry {
+		balance := _getBalance(t, chain, nil, senderPk)
+		utxoOps, txn, _, err := _privateMessage(
+			t, chain, db, params, 10 /*feeRateNanosPerKB*/, senderPk, recipientPk,
+			senderPriv, "hello", tstampNanos)
+		require.NoError(err)
+		testMeta.expectedSenderBalances = append(testMeta.expectedSenderBalances, balance)
+		testMeta.txnOps = append(testMeta.txnOps, utxoOps)
+		testMeta.txns = append(testMeta.txns, txn)
+
+		recipientPkBytes, _, err := Base58CheckDecode(recipientPk)
+		require.NoError(err)
+		return DBGetMessageEntry(db, recipientPkBytes, tstampNanos)
+	}
+	firstMessage := sendMessage(m0Pub, m0Priv, senderPkString, 100)
+	secondMessage := sendMessage(m0Pub, m0Priv, senderPkString, 200)
+	sendMessage(m1Pub, m1Priv, senderPkString, 300)
+	sendMessage(senderPkString, senderPrivString, m0Pub, 400)
+
+	getUnreadCounts := func(publicKey []byte) map[PublicKey]uint64 {
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		unreadCounts, err := utxoView.GetUnreadMessageCountsForUser(publicKey, 100)
+		require.NoError(err)
+		return unreadCounts
+	}
+	isRead := func(messageEntry *MessageEntry) bool {
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		return utxoView.IsMessageReadByRecipient(messageEntry)
+	}
+
+	// Nothing has been read yet. Messages the user sent don't count as unread.
+	require.Equal(map[PublicKey]uint64{
+		*NewPublicKey(m0PkBytes): 2,
+		*NewPublicKey(m1PkBytes): 1,
+	}, getUnreadCounts(senderPkBytes))
+	require.Equal(map[PublicKey]uint64{
+		*NewPublicKey(senderPkBytes): 1,
+	}, getUnreadCounts(m0PkBytes))
+
+	// Invalid read markers fail.
+	{
+		_messageReadWithTestMeta(testMeta, senderPkBytes, senderPrivString,
+			m0PkBytes[:10], 100, RuleErrorMessageReadThreadPubKeyLen)
+		_messageReadWithTestMeta(testMeta, senderPkBytes, senderPrivString,
+			m0PkBytes, 0, RuleErrorMessageReadTstampNotIncreasing)
+
+		params.ForkHeights.MessageReadReceiptsBlockHeight = 1000000
+		_messageReadWithTestMeta(testMeta, senderPkBytes, senderPrivString,
+			m0PkBytes, 100, RuleErrorMessageReadBeforeBlockHeight)
+		params.ForkHeights.MessageReadReceiptsBlockHeight = 0
+	}
+
+	// The sender reads m0's first message.
+	_messageReadWithTestMeta(testMeta, senderPkBytes, senderPrivString, m0PkBytes, 100, nil)
+	require.Equal(map[PublicKey]uint64{
+		*NewPublicKey(m0PkBytes): 1,
+		*NewPublicKey(m1PkBytes): 1,
+	}, getUnreadCounts(senderPkBytes))
+	require.True(isRead(firstMessage))
+	require.False(isRead(secondMessage))
+
+	// Read markers can't move backwards or be replayed.
+	_messageReadWithTestMeta(testMeta, senderPkBytes, senderPrivString,
+		m0PkBytes, 100, RuleErrorMessageReadTstampNotIncreasing)
+	_messageReadWithTestMeta(testMeta, senderPkBytes, senderPrivString,
+		m0PkBytes, 50, RuleErrorMessageReadTstampNotIncreasing)
+
+	// The sender catches up on both threads. Read markers are per reader, so
+	// m0's unread count doesn't change.
+	_messageReadWithTestMeta(testMeta, senderPkBytes, senderPrivString, m0PkBytes, 200, nil)
+	_messageReadWithTestMeta(testMeta, senderPkBytes, senderPrivString, m1PkBytes, 300, nil)
+	require.Empty(getUnreadCounts(senderPkBytes))
+	require.True(isRead(secondMessage))
+	require.Equal(map[PublicKey]uint64{
+		*NewPublicKey(senderPkBytes): 1,
+	}, getUnreadCounts(m0PkBytes))
+
+	// Roll back everything, then connect and disconnect it all in a single view.
+	_rollBackTestMetaTxnsAndFlush(testMeta)
+	require.Nil(DbGetMessageReadEntry(db, NewPublicKey(senderPkBytes), NewPublicKey(m0PkBytes)))
+	require.Nil(DbGetMessageReadEntry(db, NewPublicKey(senderPkBytes), NewPublicKey(m1PkBytes)))
+
+	_applyTestMetaTxnsToViewAndFlush(testMeta)
+	require.Empty(getUnreadCounts(senderPkBytes))
+	messageReadEntry := DbGetMessageReadEntry(db, NewPublicKey(senderPkBytes), NewPublicKey(m0PkBytes))
+	require.NotNil(messageReadEntry)
+	require.Equal(uint64(200), messageReadEntry.LastReadTstampNanos)
+	messageReadEntries, err := DbGetMessageReadEntriesForReader(db, NewPublicKey(senderPkBytes))
+	require.NoError(err)
+	require.Len(messageReadEntries, 2)
+
+	_disconnectTestMetaTxnsFromViewAndFlush(testMeta)
+	require.Nil(DbGetMessageReadEntry(db, NewPublicKey(senderPkBytes), NewPublicKey(m0PkBytes)))
+}
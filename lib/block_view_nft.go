@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"github.com/btcsuite/btcd/btcec"
@@ -123,6 +124,58 @@ func (bav *UtxoView) GetNFTEntriesForPKID(ownerPKID *PKID) []*NFTEntry {
 	return nftEntries
 }
 
+// GetNFTEntriesWithAuctionsEndingByHeight returns every NFT whose auction ends
+// at or before blockHeight. Entries are sorted by end height, post hash and
+// serial number so that settlements are applied in a deterministic order.
+func (bav *UtxoView) GetNFTEntriesWithAuctionsEndingByHeight(blockHeight uint64) []*NFTEntry {
+	var dbNFTEntries []*NFTEntry
+	if bav.Postgres != nil {
+		nfts := bav.Postgres.GetNFTsWithAuctionsEndingByHeight(blockHeight)
+		for _, nft := range nfts {
+			dbNFTEntries = append(dbNFTEntries, nft.NewNFTEntry())
+		}
+	} else {
+		nftKeys := DBGetNFTKeysWithAuctionsEndingByHeight(bav.Handle, blockHeight)
+		for _, nftKey := range nftKeys {
+			dbNFTEntry := DBGetNFTEntryByPostHashSerialNumber(bav.Handle, &nftKey.NFTPostHash, nftKey.SerialNumber)
+			if dbNFTEntry != nil {
+				dbNFTEntries = append(dbNFTEntries, dbNFTEntry)
+			}
+		}
+	}
+
+	// Make sure all of the DB entries are loaded in the view.
+	for _, dbNFTEntry := range dbNFTEntries {
+		nftKey := MakeNFTKey(dbNFTEntry.NFTPostHash, dbNFTEntry.SerialNumber)
+
+		// If the NFT is not in the view, add it to the view.
+		if _, ok := bav.NFTKeyToNFTEntry[nftKey]; !ok {
+			bav._setNFTEntryMappings(dbNFTEntry)
+		}
+	}
+
+	// Loop over the view and build the final set of NFTEntries to return.
+	nftEntries := []*NFTEntry{}
+	for _, nftEntry := range bav.NFTKeyToNFTEntry {
+		if !nftEntry.isDeleted && nftEntry.IsForSale && nftEntry.AuctionEndBlockHeight > 0 &&
+			nftEntry.AuctionEndBlockHeight <= blockHeight {
+
+			nftEntries = append(nftEntries, nftEntry)
+		}
+	}
+	sort.Slice(nftEntries, func(ii, jj int) bool {
+		if nftEntries[ii].AuctionEndBlockHeight != nftEntries[jj].AuctionEndBlockHeight {
+			return nftEntries[ii].AuctionEndBlockHeight < nftEntries[jj].AuctionEndBlockHeight
+		}
+		postHashCmp := bytes.Compare(nftEntries[ii].NFTPostHash[:], nftEntries[jj].NFTPostHash[:])
+		if postHashCmp != 0 {
+			return postHashCmp < 0
+		}
+		return nftEntries[ii].SerialNumber < nftEntries[jj].SerialNumber
+	})
+	return nftEntries
+}
+
 func (bav *UtxoView) GetNFTBidEntriesForPKID(bidderPKID *PKID) (_nftBidEntries []*NFTBidEntry) {
 	var dbNFTBidEntries []*NFTBidEntry
 	if bav.Postgres != nil {
@@ -161,16 +214,23 @@ func (bav *UtxoView) GetHighAndLowBidsForNFTCollection(
 	highBid := uint64(0)
 	lowBid := uint64(0)
 	postEntry := bav.GetPostEntryForPostHash(nftHash)
+	if postEntry == nil || postEntry.isDeleted {
+		return highBid, lowBid
+	}
 
-	// First we get the highest and lowest bids from the db.
+	// First we get the highest and lowest bids from the db. A low bid of zero
+	// means no bid has been found yet, so serial numbers without bids are skipped.
 	for ii := uint64(1); ii <= postEntry.NumNFTCopies; ii++ {
 		highBidForSerialNum, lowBidForSerialNum := bav.GetDBHighAndLowBidsForNFT(nftHash, ii)
+		if highBidForSerialNum == 0 {
+			continue
+		}
 
 		if highBidForSerialNum > highBid {
 			highBid = highBidForSerialNum
 		}
 
-		if lowBidForSerialNum < lowBid {
+		if lowBid == 0 || lowBidForSerialNum < lowBid {
 			lowBid = lowBidForSerialNum
 		}
 	}
@@ -182,7 +242,7 @@ func (bav *UtxoView) GetHighAndLowBidsForNFTCollection(
 				highBid = nftBidEntry.BidAmountNanos
 			}
 
-			if nftBidEntry.BidAmountNanos < lowBid {
+			if lowBid == 0 || nftBidEntry.BidAmountNanos < lowBid {
 				lowBid = nftBidEntry.BidAmountNanos
 			}
 		}
@@ -467,6 +527,53 @@ func (bav *UtxoView) _getBuyNowExtraData(txn *MsgDeSoTxn, blockHeight uint32) (
 	return isBuyNow, buyNowPrice, nil
 }
 
+// _getNFTAuctionExtraData pulls the auction parameters out of an UpdateNFT
+// transaction's ExtraData. An end block height of zero means the NFT isn't
+// being put up for auction.
+func (bav *UtxoView) _getNFTAuctionExtraData(txn *MsgDeSoTxn, blockHeight uint32) (
+	_auctionEndBlockHeight uint64, _reservePriceNanos uint64, _minBidIncrementNanos uint64, _err error) {
+
+	// Auction keys are ignored entirely before the NFTAuctionsBlockHeight.
+	if blockHeight < bav.Params.ForkHeights.NFTAuctionsBlockHeight {
+		return 0, 0, 0, nil
+	}
+
+	endHeightBytes, hasEndHeight := txn.ExtraData[AuctionEndBlockHeightKey]
+	reservePriceBytes, hasReservePrice := txn.ExtraData[AuctionReservePriceNanosKey]
+	minBidIncrementBytes, hasMinBidIncrement := txn.ExtraData[AuctionMinBidIncrementNanosKey]
+	if !hasEndHeight {
+		if hasReservePrice || hasMinBidIncrement {
+			return 0, 0, 0, errors.Wrapf(RuleErrorInvalidNFTAuctionExtraData,
+				"_getNFTAuctionExtraData: %v is required to set auction parameters", AuctionEndBlockHeightKey)
+		}
+		return 0, 0, 0, nil
+	}
+
+	auctionEndBlockHeight, bytesRead := Uvarint(endHeightBytes)
+	if bytesRead <= 0 || auctionEndBlockHeight == 0 {
+		return 0, 0, 0, errors.Wrapf(RuleErrorInvalidNFTAuctionExtraData,
+			"_getNFTAuctionExtraData: Problem reading bytes for %v", AuctionEndBlockHeightKey)
+	}
+	reservePriceNanos := uint64(0)
+	if hasReservePrice {
+		reservePriceNanos, bytesRead = Uvarint(reservePriceBytes)
+		if bytesRead <= 0 {
+			return 0, 0, 0, errors.Wrapf(RuleErrorInvalidNFTAuctionExtraData,
+				"_getNFTAuctionExtraData: Problem reading bytes for %v", AuctionReservePriceNanosKey)
+		}
+	}
+	minBidIncrementNanos := uint64(0)
+	if hasMinBidIncrement {
+		minBidIncrementNanos, bytesRead = Uvarint(minBidIncrementBytes)
+		if bytesRead <= 0 {
+			return 0, 0, 0, errors.Wrapf(RuleErrorInvalidNFTAuctionExtraData,
+				"_getNFTAuctionExtraData: Problem reading bytes for %v", AuctionMinBidIncrementNanosKey)
+		}
+	}
+
+	return auctionEndBlockHeight, reservePriceNanos, minBidIncrementNanos, nil
+}
+
 // Pull out a function that converts extraData to the map that we need
 // for royalties.
 func (bav *UtxoView) extractAdditionalRoyaltyMap(
@@ -592,16 +699,18 @@ func (bav *UtxoView) _connectCreateNFT(
 		return 0, 0, nil, RuleErrorCreateNFTOnNonexistentPost
 	}
 
-	posterPKID := bav.GetPKIDForPublicKey(postEntry.PosterPublicKey)
+	// If the poster has rotated their key since, the NFT belongs to their current key.
+	posterPublicKey := bav.GetCurrentPublicKey(postEntry.PosterPublicKey)
+	posterPKID := bav.GetPKIDForPublicKey(posterPublicKey)
 	if posterPKID == nil || posterPKID.isDeleted {
 		return 0, 0, nil, fmt.Errorf("_connectCreateNFT: non-existent posterPKID: %s",
-			PkToString(postEntry.PosterPublicKey, bav.Params))
+			PkToString(posterPublicKey, bav.Params))
 	}
 
 	if IsVanillaRepost(postEntry) {
 		return 0, 0, nil, RuleErrorCreateNFTOnVanillaRepost
 	}
-	if !reflect.DeepEqual(postEntry.PosterPublicKey, txn.PublicKey) {
+	if !reflect.DeepEqual(posterPublicKey, txn.PublicKey) {
 		return 0, 0, nil, RuleErrorCreateNFTMustBeCalledByPoster
 	}
 	if postEntry.IsNFT {
@@ -634,11 +743,19 @@ func (bav *UtxoView) _connectCreateNFT(
 		return 0, 0, nil, RuleErrorNFTRoyaltyHasTooManyBasisPoints
 	}
 
-	profileEntry := bav.GetProfileEntryForPublicKey(postEntry.PosterPublicKey)
+	profileEntry := bav.GetProfileEntryForPublicKey(posterPublicKey)
 	if profileEntry == nil || profileEntry.isDeleted {
 		return 0, 0, nil, RuleErrorCantCreateNFTWithoutProfileEntry
 	}
 
+	// If the NFT is being attached to a collection, make sure the poster owns the
+	// collection and that it has room for the new copies.
+	nftCollectionEntry, err := bav._getNFTCollectionForCreateNFT(
+		txn, posterPKID.PKID, txMeta.NumCopies, blockHeight)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectCreateNFT: ")
+	}
+
 	// Connect basic txn to get the total input and the total output without
 	// considering the transaction metadata.
 	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
@@ -687,6 +804,23 @@ func (bav *UtxoView) _connectCreateNFT(
 	postEntry.NFTRoyaltyToCoinBasisPoints = txMeta.NFTRoyaltyToCoinBasisPoints
 	postEntry.AdditionalNFTRoyaltiesToCreatorsBasisPoints = additionalDESONFTRoyalties
 	postEntry.AdditionalNFTRoyaltiesToCoinsBasisPoints = additionalCoinNFTRoyalties
+
+	// NFTs in a collection use the collection's royalties instead of their own.
+	var prevNFTCollectionEntry *NFTCollectionEntry
+	if nftCollectionEntry != nil {
+		postEntry.NFTCollectionID = nftCollectionEntry.CollectionID
+		postEntry.NFTRoyaltyToCreatorBasisPoints = nftCollectionEntry.NFTRoyaltyToCreatorBasisPoints
+		postEntry.NFTRoyaltyToCoinBasisPoints = nftCollectionEntry.NFTRoyaltyToCoinBasisPoints
+		postEntry.AdditionalNFTRoyaltiesToCreatorsBasisPoints = nftCollectionEntry.AdditionalNFTRoyaltiesToCreatorsBasisPoints
+		postEntry.AdditionalNFTRoyaltiesToCoinsBasisPoints = nftCollectionEntry.AdditionalNFTRoyaltiesToCoinsBasisPoints
+
+		prevNFTCollectionEntry = &NFTCollectionEntry{}
+		*prevNFTCollectionEntry = *nftCollectionEntry
+
+		newNFTCollectionEntry := *nftCollectionEntry
+		newNFTCollectionEntry.NumNFTCopies += txMeta.NumCopies
+		bav._setNFTCollectionEntryMappings(&newNFTCollectionEntry)
+	}
 	bav._setPostEntryMappings(postEntry)
 
 	// Add the appropriate NFT entries.
@@ -705,8 +839,9 @@ func (bav *UtxoView) _connectCreateNFT(
 
 	// Add an operation to the utxoOps list indicating we've created an NFT.
 	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
-		Type:          OperationTypeCreateNFT,
-		PrevPostEntry: prevPostEntry,
+		Type:                   OperationTypeCreateNFT,
+		PrevPostEntry:          prevPostEntry,
+		PrevNFTCollectionEntry: prevNFTCollectionEntry,
 	})
 
 	return totalInput, totalOutput, utxoOpsForTxn, nil
@@ -731,6 +866,12 @@ func (bav *UtxoView) _connectUpdateNFT(
 		return 0, 0, nil, errors.Wrapf(err, "_connectUpdateNFT: ")
 	}
 
+	auctionEndBlockHeight, auctionReservePrice, auctionMinBidIncrement, err := bav._getNFTAuctionExtraData(
+		txn, blockHeight)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectUpdateNFT: ")
+	}
+
 	// Verify the NFT entry exists.
 	nftKey := MakeNFTKey(txMeta.NFTPostHash, txMeta.SerialNumber)
 	prevNFTEntry := bav.GetNFTEntryForNFTKey(&nftKey)
@@ -743,6 +884,16 @@ func (bav *UtxoView) _connectUpdateNFT(
 		return 0, 0, nil, RuleErrorCannotUpdatePendingNFTTransfer
 	}
 
+	// An NFT that is up for auction stays for sale until the auction settles.
+	if prevNFTEntry.AuctionEndBlockHeight > 0 {
+		return 0, 0, nil, RuleErrorCannotUpdateNFTInAuction
+	}
+
+	// A vaulted NFT stays for sale at its buyout price until it is bought out or redeemed.
+	if prevNFTEntry.IsVaulted {
+		return 0, 0, nil, RuleErrorCannotUpdateVaultedNFT
+	}
+
 	// Get the postEntry so we can update the number of NFT copies for sale.
 	postEntry := bav.GetPostEntryForPostHash(txMeta.NFTPostHash)
 	if postEntry == nil || postEntry.isDeleted {
@@ -760,6 +911,24 @@ func (bav *UtxoView) _connectUpdateNFT(
 		return 0, 0, nil, errors.Wrapf(RuleErrorCannotHaveBuyNowPriceBelowMinBidAmountNanos, "_connectUpdateNFT: ")
 	}
 
+	// Auctions settle automatically, so they can't be combined with Buy Now or with
+	// unlockable content, which the owner would have to encrypt for the winner.
+	if auctionEndBlockHeight > 0 {
+		if !txMeta.IsForSale {
+			return 0, 0, nil, errors.Wrapf(RuleErrorNFTAuctionMustBeForSale, "_connectUpdateNFT: ")
+		}
+		if auctionEndBlockHeight <= uint64(blockHeight) {
+			return 0, 0, nil, errors.Wrapf(RuleErrorNFTAuctionEndBlockHeightInPast, "_connectUpdateNFT: "+
+				"AuctionEndBlockHeight = %d; BlockHeight = %d", auctionEndBlockHeight, blockHeight)
+		}
+		if isBuyNow {
+			return 0, 0, nil, errors.Wrapf(RuleErrorCannotHaveBuyNowAndNFTAuction, "_connectUpdateNFT: ")
+		}
+		if postEntry.HasUnlockable {
+			return 0, 0, nil, errors.Wrapf(RuleErrorCannotHaveUnlockableAndNFTAuction, "_connectUpdateNFT: ")
+		}
+	}
+
 	// Verify that the updater is the owner of the NFT.
 	updaterPKID := bav.GetPKIDForPublicKey(txn.PublicKey)
 	if updaterPKID == nil || updaterPKID.isDeleted {
@@ -820,7 +989,10 @@ func (bav *UtxoView) _connectUpdateNFT(
 		BuyNowPriceNanos:  buyNowPrice,
 		// Keep the last accepted bid amount nanos from the previous entry since this
 		// value is only updated when a new bid is accepted.
-		LastAcceptedBidAmountNanos: prevNFTEntry.LastAcceptedBidAmountNanos,
+		LastAcceptedBidAmountNanos:  prevNFTEntry.LastAcceptedBidAmountNanos,
+		AuctionEndBlockHeight:       auctionEndBlockHeight,
+		AuctionReservePriceNanos:    auctionReservePrice,
+		AuctionMinBidIncrementNanos: auctionMinBidIncrement,
 	}
 	bav._setNFTEntryMappings(newNFTEntry)
 
@@ -891,6 +1063,16 @@ func (bav *UtxoView) _connectAcceptNFTBid(
 		return 0, 0, nil, RuleErrorCannotAcceptBidForPendingNFTTransfer
 	}
 
+	// Auctions are settled automatically once they end.
+	if prevNFTEntry.AuctionEndBlockHeight > 0 {
+		return 0, 0, nil, RuleErrorCannotAcceptBidOnNFTInAuction
+	}
+
+	// A vaulted NFT can only be sold through a buyout, which pays the share holders.
+	if prevNFTEntry.IsVaulted {
+		return 0, 0, nil, RuleErrorCannotAcceptBidOnVaultedNFT
+	}
+
 	// Verify that the updater is the owner of the NFT.
 	updaterPKID := bav.GetPKIDForPublicKey(txn.PublicKey)
 	if updaterPKID == nil || updaterPKID.isDeleted {
@@ -956,11 +1138,22 @@ type HelpConnectNFTSoldStruct struct {
 	Txn              *MsgDeSoTxn
 	TxHash           *BlockHash
 	VerifySignatures bool
+
+	// When an auction settles, the winning bid has already been escrowed by the NFTBid
+	// txn and the payments are attached to the block reward txn of the block that ends
+	// the auction. Since several auctions can settle in the same block, the payment
+	// UTXOs of each one start after the ones created by the settlements before it.
+	IsAuctionSettlement    bool
+	PaymentUtxoIndexOffset uint32
 }
 
 func (bav *UtxoView) _helpConnectNFTSold(args HelpConnectNFTSoldStruct) (
 	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
-	if args.Txn.TxnMeta.GetTxnType() != TxnTypeAcceptNFTBid && args.Txn.TxnMeta.GetTxnType() != TxnTypeNFTBid {
+	if args.IsAuctionSettlement {
+		if args.Txn.TxnMeta.GetTxnType() != TxnTypeBlockReward {
+			return 0, 0, nil, fmt.Errorf("_helpConnectNFTSold: Auctions can only be settled by a BlockReward txn")
+		}
+	} else if args.Txn.TxnMeta.GetTxnType() != TxnTypeAcceptNFTBid && args.Txn.TxnMeta.GetTxnType() != TxnTypeNFTBid {
 		return 0, 0, nil, fmt.Errorf("_helpConnectNFTSold: This transaction must be either an AcceptNFTBid txn or a NFTBid txn")
 	}
 	nftKey := MakeNFTKey(args.NFTPostHash, args.SerialNumber)
@@ -968,12 +1161,14 @@ func (bav *UtxoView) _helpConnectNFTSold(args HelpConnectNFTSoldStruct) (
 	// Get the post entry, verify it exists.
 	nftPostEntry := bav.GetPostEntryForPostHash(args.NFTPostHash)
 
-	// Get the poster's profile.
-	existingProfileEntry := bav.GetProfileEntryForPublicKey(nftPostEntry.PosterPublicKey)
+	// Get the poster's profile. The creator is paid at the key the poster's account
+	// has now, in case it was rotated since the post was made.
+	creatorPublicKey := bav.GetCurrentPublicKey(nftPostEntry.PosterPublicKey)
+	existingProfileEntry := bav.GetProfileEntryForPublicKey(creatorPublicKey)
 	if existingProfileEntry == nil || existingProfileEntry.isDeleted {
 		return 0, 0, nil, fmt.Errorf(
 			"_helpConnectNFTSold: Profile missing for NFT pub key: %v %v",
-			PkToStringMainnet(nftPostEntry.PosterPublicKey), PkToStringTestnet(nftPostEntry.PosterPublicKey))
+			PkToStringMainnet(creatorPublicKey), PkToStringTestnet(creatorPublicKey))
 	}
 	// Save all the old values from the CreatorCoinEntry before we potentially
 	// update them. Note that CreatorCoinEntry doesn't contain any pointers and so
@@ -1045,11 +1240,11 @@ func (bav *UtxoView) _helpConnectNFTSold(args HelpConnectNFTSoldStruct) (
 			PkToStringBoth(bidderPublicKey))
 	}
 	creatorBalanceBefore, err := bav.GetSpendableDeSoBalanceNanosForPublicKey(
-		nftPostEntry.PosterPublicKey, tipHeight)
+		creatorPublicKey, tipHeight)
 	if err != nil {
 		return 0, 0, nil, fmt.Errorf(
 			"_helpConnectNFTSold: Problem getting initial balance for poster pubkey: %v",
-			PkToStringBoth(nftPostEntry.PosterPublicKey))
+			PkToStringBoth(creatorPublicKey))
 	}
 	desoRoyaltiesBalancesBefore := make(map[PKID]uint64)
 	for pkidIter, _ := range nftPostEntry.AdditionalNFTRoyaltiesToCreatorsBasisPoints {
@@ -1066,19 +1261,24 @@ func (bav *UtxoView) _helpConnectNFTSold(args HelpConnectNFTSoldStruct) (
 	}
 
 	// Connect basic txn to get the total input and the total output without
-	// considering the transaction metadata.
+	// considering the transaction metadata. Auction settlements are attached to a
+	// block reward whose basic transfer has already been connected by the caller.
 	utxoOpsForTxn := []*UtxoOperation{}
-	totalInput, totalOutput, utxoOpsFromBasicTransfer, err := bav._connectBasicTransfer(
-		args.Txn, args.TxHash, blockHeight, args.VerifySignatures)
-	if err != nil {
-		return 0, 0, nil, errors.Wrapf(err, "_helpConnectNFTSold: ")
-	}
-	// Append the basic transfer utxoOps to our list
-	utxoOpsForTxn = append(utxoOpsForTxn, utxoOpsFromBasicTransfer...)
+	totalInput, totalOutput := uint64(0), uint64(0)
+	if !args.IsAuctionSettlement {
+		var utxoOpsFromBasicTransfer []*UtxoOperation
+		totalInput, totalOutput, utxoOpsFromBasicTransfer, err = bav._connectBasicTransfer(
+			args.Txn, args.TxHash, blockHeight, args.VerifySignatures)
+		if err != nil {
+			return 0, 0, nil, errors.Wrapf(err, "_helpConnectNFTSold: ")
+		}
+		// Append the basic transfer utxoOps to our list
+		utxoOpsForTxn = append(utxoOpsForTxn, utxoOpsFromBasicTransfer...)
 
-	// Force the input to be non-zero so that we can prevent replay attacks.
-	if totalInput == 0 {
-		return 0, 0, nil, errors.Wrapf(RuleErrorAcceptNFTBidRequiresNonZeroInput, "_helpConnectNFTSold: ")
+		// Force the input to be non-zero so that we can prevent replay attacks.
+		if totalInput == 0 {
+			return 0, 0, nil, errors.Wrapf(RuleErrorAcceptNFTBidRequiresNonZeroInput, "_helpConnectNFTSold: ")
+		}
 	}
 
 	bidderChangeNanos := uint64(0)
@@ -1315,7 +1515,7 @@ func (bav *UtxoView) _helpConnectNFTSold(args HelpConnectNFTSoldStruct) (
 	nftPaymentUtxoKeys := []*UtxoKey{}
 	// This may start negative but that's OK because the first thing we do is increment it
 	// in createUTXO
-	nextUtxoIndex := len(args.Txn.TxOutputs) - 1
+	nextUtxoIndex := len(args.Txn.TxOutputs) + int(args.PaymentUtxoIndexOffset) - 1
 	createUTXO := func(amountNanos uint64, publicKeyArg []byte, utxoType UtxoType) (_err error) {
 		publicKey := publicKeyArg
 
@@ -1350,14 +1550,23 @@ func (bav *UtxoView) _helpConnectNFTSold(args HelpConnectNFTSoldStruct) (
 	}
 
 	// (3) Pay the seller by creating a new entry for this output and add it to the view.
-	if err = createUTXO(bidAmountMinusRoyalties, sellerPublicKey, UtxoTypeNFTSeller); err != nil {
+	// If the NFT is vaulted, the share holders are paid pro rata to their shares instead.
+	var prevVaultShareBalanceEntries []*BalanceEntry
+	if prevNFTEntry.IsVaulted {
+		prevVaultShareBalanceEntries, err = bav._helpConnectNFTVaultBuyout(
+			prevNFTEntry, bidAmountMinusRoyalties, createUTXO)
+		if err != nil {
+			return 0, 0, nil, errors.Wrapf(
+				err, "_helpConnectNFTSold: Problem paying out vault share holders: ")
+		}
+	} else if err = createUTXO(bidAmountMinusRoyalties, sellerPublicKey, UtxoTypeNFTSeller); err != nil {
 		return 0, 0, nil, errors.Wrapf(
 			err, "_helpConnectNFTSold: Problem creating UTXO for seller: ")
 	}
 
 	// (4) Pay royalties to the original artist.
 	if creatorRoyaltyNanos > 0 {
-		if err = createUTXO(creatorRoyaltyNanos, nftPostEntry.PosterPublicKey, UtxoTypeNFTCreatorRoyalty); err != nil {
+		if err = createUTXO(creatorRoyaltyNanos, creatorPublicKey, UtxoTypeNFTCreatorRoyalty); err != nil {
 			return 0, 0, nil, errors.Wrapf(
 				err, "_helpConnectNFTsold: Problem creating UTXO for creator royalty: ")
 		}
@@ -1441,11 +1650,16 @@ func (bav *UtxoView) _helpConnectNFTSold(args HelpConnectNFTSoldStruct) (
 		NFTSpentUtxoEntries:        spentUtxoEntries,
 		PrevAcceptedNFTBidEntries:  prevAcceptedBidHistory,
 		PrevNFTBidEntry:            args.PrevNFTBidEntry,
+
+		PrevNFTVaultShareBalanceEntries: prevVaultShareBalanceEntries,
 	}
-	if args.Txn.TxnMeta.GetTxnType() == TxnTypeAcceptNFTBid {
+	if args.IsAuctionSettlement {
+		transactionUtxoOp.Type = OperationTypeNFTAuctionSettlement
+		transactionUtxoOp.NFTAuctionWinningBidEntry = nftBidEntry
+	} else if args.Txn.TxnMeta.GetTxnType() == TxnTypeAcceptNFTBid {
 		transactionUtxoOp.Type = OperationTypeAcceptNFTBid
 		// Rosetta fields
-		transactionUtxoOp.AcceptNFTBidCreatorPublicKey = nftPostEntry.PosterPublicKey
+		transactionUtxoOp.AcceptNFTBidCreatorPublicKey = creatorPublicKey
 		transactionUtxoOp.AcceptNFTBidBidderPublicKey = bidderPublicKey
 		transactionUtxoOp.AcceptNFTBidCreatorRoyaltyNanos = creatorCoinRoyaltyNanos
 		transactionUtxoOp.AcceptNFTBidCreatorDESORoyaltyNanos = creatorRoyaltyNanos
@@ -1458,7 +1672,7 @@ func (bav *UtxoView) _helpConnectNFTSold(args HelpConnectNFTSoldStruct) (
 	} else if args.Txn.TxnMeta.GetTxnType() == TxnTypeNFTBid {
 		transactionUtxoOp.Type = OperationTypeNFTBid
 		// Rosetta fields
-		transactionUtxoOp.NFTBidCreatorPublicKey = nftPostEntry.PosterPublicKey
+		transactionUtxoOp.NFTBidCreatorPublicKey = creatorPublicKey
 		transactionUtxoOp.NFTBidBidderPublicKey = bidderPublicKey
 		transactionUtxoOp.NFTBidCreatorRoyaltyNanos = creatorCoinRoyaltyNanos
 		transactionUtxoOp.NFTBidCreatorDESORoyaltyNanos = creatorRoyaltyNanos
@@ -1501,13 +1715,13 @@ func (bav *UtxoView) _helpConnectNFTSold(args HelpConnectNFTSoldStruct) (
 	}
 	// Creator balance diff (only relevant if creator != seller and creator != bidder):
 	creatorDiff := int64(0)
-	if !reflect.DeepEqual(nftPostEntry.PosterPublicKey, sellerPublicKey) &&
-		!reflect.DeepEqual(nftPostEntry.PosterPublicKey, bidderPublicKey) {
-		creatorBalanceAfter, err := bav.GetSpendableDeSoBalanceNanosForPublicKey(nftPostEntry.PosterPublicKey, tipHeight)
+	if !reflect.DeepEqual(creatorPublicKey, sellerPublicKey) &&
+		!reflect.DeepEqual(creatorPublicKey, bidderPublicKey) {
+		creatorBalanceAfter, err := bav.GetSpendableDeSoBalanceNanosForPublicKey(creatorPublicKey, tipHeight)
 		if err != nil {
 			return 0, 0, nil, fmt.Errorf(
 				"_helpConnectNFTSold: Problem getting final balance for poster pubkey: %v",
-				PkToStringBoth(nftPostEntry.PosterPublicKey))
+				PkToStringBoth(creatorPublicKey))
 		}
 		creatorDiff = int64(creatorBalanceAfter) - int64(creatorBalanceBefore)
 	}
@@ -1560,6 +1774,11 @@ func (bav *UtxoView) _helpConnectNFTSold(args HelpConnectNFTSoldStruct) (
 
 	totalDiff := big.NewInt(0).Add(sellerPlusBidderDiff, creatorPlusCoinDiff)
 	totalDiff = totalDiff.Add(totalDiff, totalAdditionalRoyaltiesDiff)
+	// The winning bid of an auction left the bidder's balance when it was escrowed,
+	// so the payments made here are expected to add up to the bid amount.
+	if args.IsAuctionSettlement {
+		totalDiff = totalDiff.Sub(totalDiff, big.NewInt(int64(args.BidAmountNanos)))
+	}
 	if totalDiff.Cmp(big.NewInt(0)) > 0 {
 		return 0, 0, nil, fmt.Errorf(
 			"_helpConnectNFTSold: Sum of participant diffs is >0 (%d, %d, %d, %d, %d, %d)",
@@ -1648,6 +1867,11 @@ func (bav *UtxoView) _connectNFTBid(
 		if nftEntry.IsBuyNow && txMeta.BidAmountNanos >= nftEntry.BuyNowPriceNanos && txMeta.BidAmountNanos > 0 {
 			isBuyNowBid = true
 		}
+		// Bids on an NFT auction are escrowed until the auction settles.
+		if nftEntry.AuctionEndBlockHeight > 0 {
+			return bav._connectNFTAuctionBid(
+				txn, txHash, blockHeight, verifySignatures, nftEntry, bidderPKID.PKID, prevNFTBidEntry)
+		}
 	}
 
 	deletePrevBidAndSetNewBid := func() {
@@ -1795,6 +2019,11 @@ func (bav *UtxoView) _connectNFTTransfer(
 				"happen. Receiver pubkey: %v", PkToStringMainnet(txMeta.ReceiverPublicKey))
 	}
 
+	// Make sure that the NFT entry is not vaulted. The shares can be transferred instead.
+	if prevNFTEntry.IsVaulted {
+		return 0, 0, nil, RuleErrorCannotTransferVaultedNFT
+	}
+
 	// Make sure that the NFT entry is not for sale.
 	if prevNFTEntry.IsForSale {
 		return 0, 0, nil, RuleErrorCannotTransferForSaleNFT
@@ -1988,6 +2217,11 @@ func (bav *UtxoView) _connectBurnNFT(
 		return 0, 0, nil, RuleErrorBurnNFTByNonOwner
 	}
 
+	// Verify that the NFT is not vaulted.
+	if nftEntry.IsVaulted {
+		return 0, 0, nil, RuleErrorCannotBurnVaultedNFT
+	}
+
 	// Verify that the NFT is not for sale.
 	if nftEntry.IsForSale {
 		return 0, 0, nil, RuleErrorCannotBurnNFTThatIsForSale
@@ -2079,10 +2313,16 @@ func (bav *UtxoView) _disconnectCreateNFT(
 	// Revert to the old post entry since we changed IsNFT, etc.
 	bav._setPostEntryMappings(operationData.PrevPostEntry)
 
+	// If the NFT was attached to a collection, revert the collection's copy count.
+	if operationData.PrevNFTCollectionEntry != nil {
+		bav._setNFTCollectionEntryMappings(operationData.PrevNFTCollectionEntry)
+	}
+
 	// Delete the NFT entries.
-	posterPKID := bav.GetPKIDForPublicKey(existingPostEntry.PosterPublicKey)
+	posterPublicKey := bav.GetCurrentPublicKey(existingPostEntry.PosterPublicKey)
+	posterPKID := bav.GetPKIDForPublicKey(posterPublicKey)
 	if posterPKID == nil || posterPKID.isDeleted {
-		return fmt.Errorf("_disconnectCreateNFT: PKID for poster public key %v doesn't exist; this should never happen", string(existingPostEntry.PosterPublicKey))
+		return fmt.Errorf("_disconnectCreateNFT: PKID for poster public key %v doesn't exist; this should never happen", string(posterPublicKey))
 	}
 	for ii := uint64(1); ii <= txMeta.NumCopies; ii++ {
 		nftEntry := &NFTEntry{
@@ -2225,6 +2465,12 @@ func (bav *UtxoView) _helpDisconnectNFTSold(operationData *UtxoOperation, nftPos
 	prevNFTEntry := operationData.PrevNFTEntry
 	bav._setNFTEntryMappings(prevNFTEntry)
 
+	// If a vaulted NFT was bought out, give the share holders back their shares.
+	for _, balanceEntry := range operationData.PrevNFTVaultShareBalanceEntries {
+		prevBalanceEntry := *balanceEntry
+		bav._setDAOCoinBalanceEntryMappings(&prevBalanceEntry)
+	}
+
 	// Revert the accepted NFT bid history mappings
 	bav._setAcceptNFTBidHistoryMappings(MakeNFTKey(prevNFTEntry.NFTPostHash, prevNFTEntry.SerialNumber), operationData.PrevAcceptedNFTBidEntries)
 
@@ -2282,6 +2528,16 @@ func (bav *UtxoView) _helpDisconnectNFTSold(operationData *UtxoOperation, nftPos
 			return fmt.Errorf("_helpDisconnectNFTSold: Previous NFT Entry is not buy now, " +
 				"but operation is of type OperationTypeNFTBid; this should never happen")
 		}
+	} else if operationData.Type == OperationTypeNFTAuctionSettlement {
+		// The winning bid was escrowed by the NFTBid txn, so there are no bidder UTXOs to unspend.
+		if len(operationData.NFTSpentUtxoEntries) > 0 {
+			return fmt.Errorf("_helpDisconnectNFTSold: NFT auction settlements should have zero " +
+				"NFTSpentUtxoEntries; this should never happen")
+		}
+		if prevNFTEntry.AuctionEndBlockHeight == 0 {
+			return fmt.Errorf("_helpDisconnectNFTSold: Previous NFT Entry is not an auction, " +
+				"but operation is of type OperationTypeNFTAuctionSettlement; this should never happen")
+		}
 	} else {
 		return fmt.Errorf("_helpDisconnectNFTSold: Invalid Operation type: %s", operationData.Type.String())
 	}
@@ -2294,7 +2550,7 @@ func (bav *UtxoView) _helpDisconnectNFTSold(operationData *UtxoOperation, nftPos
 			return fmt.Errorf("_helpDisconnectNFTSold: nftPostEntry was nil; " +
 				"this should never happen")
 		}
-		existingProfileEntry := bav.GetProfileEntryForPublicKey(nftPostEntry.PosterPublicKey)
+		existingProfileEntry := bav.GetProfileEntryForPublicKey(bav.GetCurrentPublicKey(nftPostEntry.PosterPublicKey))
 		if existingProfileEntry == nil || existingProfileEntry.isDeleted {
 			return fmt.Errorf("_helpDisconnectNFTSold: existingProfileEntry was nil; " +
 				"this should never happen")
@@ -2370,8 +2626,13 @@ func (bav *UtxoView) _disconnectNFTBid(
 			"happen", string(currentTxn.PublicKey))
 	}
 
-	// If an NFT Bid operation has a non-nil PrevNFTEntry, this was bid on a Buy-Now NFT and we need to "unsell" the NFT
-	if operationData.PrevNFTEntry != nil {
+	// If an NFT Bid operation has a non-nil PrevNFTEntry, this was either a bid on an NFT auction or a bid on a
+	// Buy-Now NFT and we need to "unsell" the NFT.
+	if operationData.PrevNFTEntry != nil && operationData.PrevNFTEntry.AuctionEndBlockHeight > 0 {
+		if err := bav._helpDisconnectNFTAuctionBid(operationData); err != nil {
+			return errors.Wrapf(err, "_disconnectNFTBid: ")
+		}
+	} else if operationData.PrevNFTEntry != nil {
 		// If the previous NFT Entry is not a Buy Now NFT, that is an error. A bid on a non-buy-now NFT should never
 		// manipulate an NFT Entry.
 		if !operationData.PrevNFTEntry.IsBuyNow {
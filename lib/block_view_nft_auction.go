@@ -0,0 +1,314 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// _addNFTAuctionRefundUtxo pays an escrowed auction bid back to its bidder. Refunds are
+// implicit outputs of the txn that triggers them so they are keyed by the txn's hash
+// and an index past its explicit outputs.
+func (bav *UtxoView) _addNFTAuctionRefundUtxo(
+	txHash *BlockHash, utxoIndex uint32, bidEntry *NFTBidEntry, blockHeight uint32) (
+	_refundUtxoKey *UtxoKey, _utxoOp *UtxoOperation, _err error) {
+
+	bidderPublicKey := bav.GetPublicKeyForPKID(bidEntry.BidderPKID)
+	if len(bidderPublicKey) == 0 {
+		return nil, nil, fmt.Errorf("_addNFTAuctionRefundUtxo: Missing public key for bidder PKID %v",
+			PkToStringMainnet(bidEntry.BidderPKID[:]))
+	}
+	refundUtxoKey := &UtxoKey{
+		TxID:  *txHash,
+		Index: utxoIndex,
+	}
+	utxoOp, err := bav._addUtxo(&UtxoEntry{
+		AmountNanos: bidEntry.BidAmountNanos,
+		PublicKey:   bidderPublicKey,
+		BlockHeight: blockHeight,
+		UtxoType:    UtxoTypeNFTBidderChange,
+		UtxoKey:     refundUtxoKey,
+		// We leave the position unset and isSpent to false by default.
+		// The position will be set in the call to _addUtxo.
+	})
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "_addNFTAuctionRefundUtxo: Problem adding refund utxo")
+	}
+	return refundUtxoKey, utxoOp, nil
+}
+
+// _connectNFTAuctionBid places a bid on an NFT that is up for auction. Unlike regular
+// bids, auction bids are escrowed: the bid amount is spent by the txn and held until
+// the auction settles or the bid is outbid, at which point it is refunded.
+func (bav *UtxoView) _connectNFTAuctionBid(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool,
+	nftEntry *NFTEntry, bidderPKID *PKID, prevNFTBidEntry *NFTBidEntry) (
+	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
+
+	txMeta := txn.TxnMeta.(*NFTBidMetadata)
+
+	if uint64(blockHeight) >= nftEntry.AuctionEndBlockHeight {
+		return 0, 0, nil, errors.Wrapf(RuleErrorNFTAuctionEnded, "_connectNFTAuctionBid: "+
+			"AuctionEndBlockHeight = %d; BlockHeight = %d", nftEntry.AuctionEndBlockHeight, blockHeight)
+	}
+	// An escrowed bid can only be withdrawn by being outbid.
+	if txMeta.BidAmountNanos == 0 {
+		return 0, 0, nil, RuleErrorNFTAuctionBidCannotBeCancelled
+	}
+
+	// Every bid that is outbid gets refunded right away so there is at most one bid
+	// on an NFT auction at any time.
+	bidEntries := bav.GetAllNFTBidEntries(txMeta.NFTPostHash, txMeta.SerialNumber)
+	if len(bidEntries) > 1 {
+		return 0, 0, nil, fmt.Errorf("_connectNFTAuctionBid: Found %d bids on NFT auction; "+
+			"this should never happen", len(bidEntries))
+	}
+	var highestBidEntry *NFTBidEntry
+	if len(bidEntries) == 1 {
+		highestBidEntry = bidEntries[0]
+		if txMeta.BidAmountNanos <= highestBidEntry.BidAmountNanos ||
+			txMeta.BidAmountNanos-highestBidEntry.BidAmountNanos < nftEntry.AuctionMinBidIncrementNanos {
+
+			return 0, 0, nil, errors.Wrapf(RuleErrorNFTAuctionBidBelowMinIncrement, "_connectNFTAuctionBid: "+
+				"BidAmountNanos = %d; HighestBidAmountNanos = %d; MinBidIncrementNanos = %d",
+				txMeta.BidAmountNanos, highestBidEntry.BidAmountNanos, nftEntry.AuctionMinBidIncrementNanos)
+		}
+	}
+
+	// Connect basic txn to get the total input and the total output without
+	// considering the transaction metadata.
+	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
+		txn, txHash, blockHeight, verifySignatures)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectNFTAuctionBid: ")
+	}
+	// Force the input to be non-zero so that we can prevent replay attacks.
+	if totalInput == 0 {
+		return 0, 0, nil, RuleErrorNFTBidRequiresNonZeroInput
+	}
+
+	// The amount of DeSo being bid counts as output being spent by this transaction,
+	// so add it to the transaction output and check that the resulting output does
+	// not exceed the total input.
+	if totalOutput > math.MaxUint64-txMeta.BidAmountNanos {
+		return 0, 0, nil, errors.Wrapf(RuleErrorNFTBidTxnOutputWithInvalidBidAmount, "_connectNFTAuctionBid: ")
+	}
+	totalOutput += txMeta.BidAmountNanos
+	if totalInput < totalOutput {
+		return 0, 0, nil, errors.Wrapf(RuleErrorNFTAuctionBidTxnOutputExceedsInput,
+			"_connectNFTAuctionBid: Input: %v, Output: %v", totalInput, totalOutput)
+	}
+
+	// Refund the bid that was just outbid.
+	deletedBidEntries := []*NFTBidEntry{}
+	nftPaymentUtxoKeys := []*UtxoKey{}
+	if highestBidEntry != nil {
+		refundUtxoKey, utxoOp, err := bav._addNFTAuctionRefundUtxo(
+			txHash, uint32(len(txn.TxOutputs)), highestBidEntry, blockHeight)
+		if err != nil {
+			return 0, 0, nil, errors.Wrapf(err, "_connectNFTAuctionBid: ")
+		}
+		utxoOpsForTxn = append(utxoOpsForTxn, utxoOp)
+		nftPaymentUtxoKeys = append(nftPaymentUtxoKeys, refundUtxoKey)
+		deletedBidEntries = append(deletedBidEntries, highestBidEntry)
+		bav._deleteNFTBidEntryMappings(highestBidEntry)
+	}
+
+	bav._setNFTBidEntryMappings(&NFTBidEntry{
+		BidderPKID:     bidderPKID,
+		NFTPostHash:    txMeta.NFTPostHash,
+		SerialNumber:   txMeta.SerialNumber,
+		BidAmountNanos: txMeta.BidAmountNanos,
+	})
+
+	// A bid placed near the end of the auction pushes the end back so that the other
+	// bidders have a chance to respond.
+	newNFTEntry := *nftEntry
+	if uint64(blockHeight)+NFTAuctionExtensionBlocks > newNFTEntry.AuctionEndBlockHeight {
+		newNFTEntry.AuctionEndBlockHeight = uint64(blockHeight) + NFTAuctionExtensionBlocks
+	}
+	bav._setNFTEntryMappings(&newNFTEntry)
+
+	// Add an operation to the list at the end indicating we've connected an NFT auction bid.
+	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
+		Type:                 OperationTypeNFTBid,
+		PrevNFTEntry:         nftEntry,
+		PrevNFTBidEntry:      prevNFTBidEntry,
+		DeletedNFTBidEntries: deletedBidEntries,
+		NFTPaymentUtxoKeys:   nftPaymentUtxoKeys,
+	})
+
+	return totalInput, totalOutput, utxoOpsForTxn, nil
+}
+
+// _helpDisconnectNFTAuctionBid reverts the auction-specific parts of an NFTBid. The
+// caller is responsible for deleting the new bid and restoring the bidder's previous one.
+func (bav *UtxoView) _helpDisconnectNFTAuctionBid(operationData *UtxoOperation) error {
+	// Note: these UTXOs need to be unadded in reverse order.
+	for ii := len(operationData.NFTPaymentUtxoKeys) - 1; ii >= 0; ii-- {
+		refundUtxoKey := operationData.NFTPaymentUtxoKeys[ii]
+		if err := bav._unAddUtxo(refundUtxoKey); err != nil {
+			return errors.Wrapf(err, "_helpDisconnectNFTAuctionBid: Problem unAdding utxo %v: ", refundUtxoKey)
+		}
+	}
+	for _, nftBidEntry := range operationData.DeletedNFTBidEntries {
+		bav._setNFTBidEntryMappings(nftBidEntry)
+	}
+
+	// Set the old NFT entry in case the bid extended the auction.
+	bav._setNFTEntryMappings(operationData.PrevNFTEntry)
+	return nil
+}
+
+// _connectNFTAuctionSettlements settles every NFT auction that has ended by blockHeight.
+// It is called when the block reward txn is connected. Auctions whose highest bid meets
+// the reserve price are sold to the highest bidder. All others are taken off sale and
+// any bid is refunded.
+func (bav *UtxoView) _connectNFTAuctionSettlements(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32) (_utxoOps []*UtxoOperation, _err error) {
+
+	if txn.TxnMeta.GetTxnType() != TxnTypeBlockReward {
+		return nil, fmt.Errorf("_connectNFTAuctionSettlements: called with bad TxnType %s",
+			txn.TxnMeta.GetTxnType().String())
+	}
+
+	utxoOps := []*UtxoOperation{}
+	numPaymentUtxos := uint32(0)
+	for _, nftEntry := range bav.GetNFTEntriesWithAuctionsEndingByHeight(uint64(blockHeight)) {
+		bidEntries := bav.GetAllNFTBidEntries(nftEntry.NFTPostHash, nftEntry.SerialNumber)
+		if len(bidEntries) > 1 {
+			return nil, fmt.Errorf("_connectNFTAuctionSettlements: Found %d bids on NFT auction; "+
+				"this should never happen", len(bidEntries))
+		}
+
+		var settlementUtxoOps []*UtxoOperation
+		var err error
+		if len(bidEntries) == 1 && bidEntries[0].BidAmountNanos >= nftEntry.AuctionReservePriceNanos {
+			_, _, settlementUtxoOps, err = bav._helpConnectNFTSold(HelpConnectNFTSoldStruct{
+				NFTPostHash:    nftEntry.NFTPostHash,
+				SerialNumber:   nftEntry.SerialNumber,
+				BidderPKID:     bidEntries[0].BidderPKID,
+				BidAmountNanos: bidEntries[0].BidAmountNanos,
+
+				BidderInputs: []*DeSoInput{},
+
+				BlockHeight:            blockHeight,
+				Txn:                    txn,
+				TxHash:                 txHash,
+				IsAuctionSettlement:    true,
+				PaymentUtxoIndexOffset: numPaymentUtxos,
+			})
+		} else {
+			settlementUtxoOps, err = bav._connectUnsoldNFTAuctionSettlement(
+				txHash, uint32(len(txn.TxOutputs))+numPaymentUtxos, nftEntry, bidEntries, blockHeight)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "_connectNFTAuctionSettlements: Problem settling auction "+
+				"for NFT %v #%d: ", nftEntry.NFTPostHash, nftEntry.SerialNumber)
+		}
+
+		numPaymentUtxos += uint32(len(settlementUtxoOps[len(settlementUtxoOps)-1].NFTPaymentUtxoKeys))
+		utxoOps = append(utxoOps, settlementUtxoOps...)
+	}
+
+	return utxoOps, nil
+}
+
+// _connectUnsoldNFTAuctionSettlement takes an NFT whose auction ended without a bid
+// meeting the reserve price off sale and refunds the bid, if there is one.
+func (bav *UtxoView) _connectUnsoldNFTAuctionSettlement(
+	txHash *BlockHash, firstUtxoIndex uint32, nftEntry *NFTEntry, bidEntries []*NFTBidEntry,
+	blockHeight uint32) (_utxoOps []*UtxoOperation, _err error) {
+
+	postEntry := bav.GetPostEntryForPostHash(nftEntry.NFTPostHash)
+	if postEntry == nil || postEntry.isDeleted {
+		return nil, fmt.Errorf("_connectUnsoldNFTAuctionSettlement: non-existent postEntry for "+
+			"NFTPostHash: %v", nftEntry.NFTPostHash)
+	}
+
+	utxoOps := []*UtxoOperation{}
+	nftPaymentUtxoKeys := []*UtxoKey{}
+	deletedBidEntries := []*NFTBidEntry{}
+	for ii, bidEntry := range bidEntries {
+		refundUtxoKey, utxoOp, err := bav._addNFTAuctionRefundUtxo(
+			txHash, firstUtxoIndex+uint32(ii), bidEntry, blockHeight)
+		if err != nil {
+			return nil, errors.Wrapf(err, "_connectUnsoldNFTAuctionSettlement: ")
+		}
+		utxoOps = append(utxoOps, utxoOp)
+		nftPaymentUtxoKeys = append(nftPaymentUtxoKeys, refundUtxoKey)
+		deletedBidEntries = append(deletedBidEntries, bidEntry)
+		bav._deleteNFTBidEntryMappings(bidEntry)
+	}
+
+	// The NFT stays with its owner and is no longer for sale.
+	newNFTEntry := *nftEntry
+	newNFTEntry.IsForSale = false
+	newNFTEntry.AuctionEndBlockHeight = 0
+	newNFTEntry.AuctionReservePriceNanos = 0
+	newNFTEntry.AuctionMinBidIncrementNanos = 0
+	bav._setNFTEntryMappings(&newNFTEntry)
+
+	// Save a copy of the previous postEntry and then decrement NumNFTCopiesForSale.
+	prevPostEntry := &PostEntry{}
+	*prevPostEntry = *postEntry
+	postEntry.NumNFTCopiesForSale--
+	bav._setPostEntryMappings(postEntry)
+
+	utxoOps = append(utxoOps, &UtxoOperation{
+		Type:                 OperationTypeNFTAuctionSettlement,
+		PrevNFTEntry:         nftEntry,
+		PrevPostEntry:        prevPostEntry,
+		DeletedNFTBidEntries: deletedBidEntries,
+		NFTPaymentUtxoKeys:   nftPaymentUtxoKeys,
+	})
+
+	return utxoOps, nil
+}
+
+// _disconnectNFTAuctionSettlements reverts the NFT auction settlements at the end of a
+// block reward's operations and returns the operations that remain.
+func (bav *UtxoView) _disconnectNFTAuctionSettlements(utxoOpsForTxn []*UtxoOperation) (
+	_remainingUtxoOps []*UtxoOperation, _err error) {
+
+	// Settlements are reverted in the reverse order they were connected in.
+	for len(utxoOpsForTxn) > 0 && utxoOpsForTxn[len(utxoOpsForTxn)-1].Type == OperationTypeNFTAuctionSettlement {
+		operationData := utxoOpsForTxn[len(utxoOpsForTxn)-1]
+		if operationData.PrevNFTEntry == nil || operationData.PrevNFTEntry.AuctionEndBlockHeight == 0 {
+			return nil, fmt.Errorf("_disconnectNFTAuctionSettlements: PrevNFTEntry is not an auction; " +
+				"this should never happen")
+		}
+
+		// Each settlement is preceded by one AddUtxo operation per payment it made.
+		numSettlementUtxoOps := len(operationData.NFTPaymentUtxoKeys) + 1
+		if len(utxoOpsForTxn) < numSettlementUtxoOps {
+			return nil, fmt.Errorf("_disconnectNFTAuctionSettlements: Found %d operations but "+
+				"expected at least %d", len(utxoOpsForTxn), numSettlementUtxoOps)
+		}
+
+		if operationData.NFTAuctionWinningBidEntry != nil {
+			if err := bav._helpDisconnectNFTSold(operationData, operationData.PrevNFTEntry.NFTPostHash); err != nil {
+				return nil, errors.Wrapf(err, "_disconnectNFTAuctionSettlements: ")
+			}
+		} else {
+			// Note: these UTXOs need to be unadded in reverse order.
+			for ii := len(operationData.NFTPaymentUtxoKeys) - 1; ii >= 0; ii-- {
+				refundUtxoKey := operationData.NFTPaymentUtxoKeys[ii]
+				if err := bav._unAddUtxo(refundUtxoKey); err != nil {
+					return nil, errors.Wrapf(err, "_disconnectNFTAuctionSettlements: Problem "+
+						"unAdding utxo %v: ", refundUtxoKey)
+				}
+			}
+			for _, nftBidEntry := range operationData.DeletedNFTBidEntries {
+				bav._setNFTBidEntryMappings(nftBidEntry)
+			}
+			bav._setNFTEntryMappings(operationData.PrevNFTEntry)
+			bav._setPostEntryMappings(operationData.PrevPostEntry)
+		}
+
+		utxoOpsForTxn = utxoOpsForTxn[:len(utxoOpsForTxn)-numSettlementUtxoOps]
+	}
+
+	return utxoOpsForTxn, nil
+}
@@ -0,0 +1,320 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// GetNFTCollectionEntry returns the collection with the given ID, or nil if
+// there isn't one.
+func (bav *UtxoView) GetNFTCollectionEntry(collectionID *BlockHash) *NFTCollectionEntry {
+	// If an entry exists in the in-memory map, return the value of that mapping.
+	if mapValue, existsMapValue := bav.NFTCollectionIDToNFTCollectionEntry[*collectionID]; existsMapValue {
+		if mapValue.isDeleted {
+			return nil
+		}
+		return mapValue
+	}
+
+	// If we get here it means no value exists in our in-memory map. In this case,
+	// defer to the db. If a mapping exists in the db, return it. If not, return
+	// nil. Either way, save the value to the in-memory view mapping got later.
+	var nftCollectionEntry *NFTCollectionEntry
+	if bav.Postgres != nil {
+		if nftCollection := bav.Postgres.GetNFTCollection(collectionID); nftCollection != nil {
+			nftCollectionEntry = nftCollection.NewNFTCollectionEntry()
+		}
+	} else {
+		nftCollectionEntry = DbGetNFTCollectionEntry(bav.Handle, collectionID)
+	}
+	if nftCollectionEntry != nil {
+		bav._setNFTCollectionEntryMappings(nftCollectionEntry)
+	}
+	return nftCollectionEntry
+}
+
+// GetPostEntriesForNFTCollection returns every post attached to the collection,
+// ordered by the time they were created.
+func (bav *UtxoView) GetPostEntriesForNFTCollection(collectionID *BlockHash) []*PostEntry {
+	// Make sure all of the posts in the DB are loaded in the view.
+	if bav.Postgres != nil {
+		for _, post := range bav.Postgres.GetPostsForNFTCollection(collectionID) {
+			if _, exists := bav.PostHashToPostEntry[*post.PostHash]; !exists {
+				bav.setPostMappings(post)
+			}
+		}
+	} else {
+		for _, postHash := range DbGetPostHashesForNFTCollection(bav.Handle, collectionID) {
+			bav.GetPostEntryForPostHash(postHash)
+		}
+	}
+
+	// Loop over the view and build the final set of PostEntries to return.
+	postEntries := []*PostEntry{}
+	for _, postEntry := range bav.PostHashToPostEntry {
+		if !postEntry.isDeleted && postEntry.NFTCollectionID != nil &&
+			*postEntry.NFTCollectionID == *collectionID {
+
+			postEntries = append(postEntries, postEntry)
+		}
+	}
+	sort.Slice(postEntries, func(ii, jj int) bool {
+		if postEntries[ii].TimestampNanos != postEntries[jj].TimestampNanos {
+			return postEntries[ii].TimestampNanos < postEntries[jj].TimestampNanos
+		}
+		return bytes.Compare(postEntries[ii].PostHash[:], postEntries[jj].PostHash[:]) < 0
+	})
+	return postEntries
+}
+
+// GetNFTEntriesForNFTCollection returns every NFT copy minted across the posts
+// attached to the collection.
+func (bav *UtxoView) GetNFTEntriesForNFTCollection(collectionID *BlockHash) []*NFTEntry {
+	nftEntries := []*NFTEntry{}
+	for _, postEntry := range bav.GetPostEntriesForNFTCollection(collectionID) {
+		nftEntries = append(nftEntries, bav.GetNFTEntriesForPostHash(postEntry.PostHash)...)
+	}
+	return nftEntries
+}
+
+// GetHighAndLowBidsForNFTCollectionEntry returns the highest and lowest bids
+// across every post attached to the collection. The low bid is the collection's
+// floor. Both are zero if there are no bids.
+func (bav *UtxoView) GetHighAndLowBidsForNFTCollectionEntry(collectionID *BlockHash) (
+	_highBid uint64, _lowBid uint64) {
+
+	highBid := uint64(0)
+	lowBid := uint64(0)
+	for _, postEntry := range bav.GetPostEntriesForNFTCollection(collectionID) {
+		highBidForPost, lowBidForPost := bav.GetHighAndLowBidsForNFTCollection(postEntry.PostHash)
+		if highBidForPost == 0 {
+			continue
+		}
+		if highBidForPost > highBid {
+			highBid = highBidForPost
+		}
+		if lowBid == 0 || lowBidForPost < lowBid {
+			lowBid = lowBidForPost
+		}
+	}
+	return highBid, lowBid
+}
+
+// GetHoldersForNFTCollection returns the number of NFT copies in the collection
+// owned by each PKID.
+func (bav *UtxoView) GetHoldersForNFTCollection(collectionID *BlockHash) map[PKID]uint64 {
+	holderPKIDToNumNFTs := make(map[PKID]uint64)
+	for _, nftEntry := range bav.GetNFTEntriesForNFTCollection(collectionID) {
+		holderPKIDToNumNFTs[*nftEntry.OwnerPKID]++
+	}
+	return holderPKIDToNumNFTs
+}
+
+func (bav *UtxoView) _setNFTCollectionEntryMappings(nftCollectionEntry *NFTCollectionEntry) {
+	// This function shouldn't be called with nil.
+	if nftCollectionEntry == nil {
+		glog.Errorf("_setNFTCollectionEntryMappings: Called with nil NFTCollectionEntry; " +
+			"this should never happen.")
+		return
+	}
+
+	bav.NFTCollectionIDToNFTCollectionEntry[*nftCollectionEntry.CollectionID] = nftCollectionEntry
+}
+
+func (bav *UtxoView) _deleteNFTCollectionEntryMappings(nftCollectionEntry *NFTCollectionEntry) {
+
+	// Create a tombstone entry.
+	tombstoneNFTCollectionEntry := *nftCollectionEntry
+	tombstoneNFTCollectionEntry.isDeleted = true
+
+	// Set the mappings to point to the tombstone entry.
+	bav._setNFTCollectionEntryMappings(&tombstoneNFTCollectionEntry)
+}
+
+// _getNFTCollectionForCreateNFT returns the collection a CreateNFT transaction
+// attaches its NFT to, or nil if the transaction doesn't specify one. The
+// collection must belong to the poster and have room for numCopies more copies.
+func (bav *UtxoView) _getNFTCollectionForCreateNFT(
+	txn *MsgDeSoTxn, posterPKID *PKID, numCopies uint64, blockHeight uint32) (*NFTCollectionEntry, error) {
+
+	collectionIDBytes, exists := txn.ExtraData[NFTCollectionIDKey]
+	if !exists || blockHeight < bav.Params.ForkHeights.NFTCollectionsBlockHeight {
+		return nil, nil
+	}
+	if len(collectionIDBytes) != HashSizeBytes {
+		return nil, errors.Wrapf(RuleErrorInvalidNFTCollectionID,
+			"_getNFTCollectionForCreateNFT: collection ID has length %d != %d",
+			len(collectionIDBytes), HashSizeBytes)
+	}
+	collectionID := NewBlockHash(collectionIDBytes)
+
+	nftCollectionEntry := bav.GetNFTCollectionEntry(collectionID)
+	if nftCollectionEntry == nil {
+		return nil, errors.Wrapf(RuleErrorNFTCollectionDoesNotExist,
+			"_getNFTCollectionForCreateNFT: collection %v", collectionID)
+	}
+	if *nftCollectionEntry.CreatorPKID != *posterPKID {
+		return nil, errors.Wrapf(RuleErrorNFTCollectionMustBeOwnedByPoster,
+			"_getNFTCollectionForCreateNFT: collection %v", collectionID)
+	}
+	if nftCollectionEntry.MaxSupply > 0 &&
+		nftCollectionEntry.NumNFTCopies+numCopies > nftCollectionEntry.MaxSupply {
+
+		return nil, errors.Wrapf(RuleErrorNFTCollectionMaxSupplyExceeded,
+			"_getNFTCollectionForCreateNFT: %d copies + %d new copies > max supply %d",
+			nftCollectionEntry.NumNFTCopies, numCopies, nftCollectionEntry.MaxSupply)
+	}
+	return nftCollectionEntry, nil
+}
+
+func (bav *UtxoView) _connectCreateNFTCollection(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
+	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
+
+	if blockHeight < bav.Params.ForkHeights.NFTCollectionsBlockHeight {
+		return 0, 0, nil, RuleErrorNFTCollectionBeforeBlockHeight
+	}
+
+	// Check that the transaction has the right TxnType.
+	if txn.TxnMeta.GetTxnType() != TxnTypeCreateNFTCollection {
+		return 0, 0, nil, fmt.Errorf("_connectCreateNFTCollection: called with bad TxnType %s",
+			txn.TxnMeta.GetTxnType().String())
+	}
+	txMeta := txn.TxnMeta.(*CreateNFTCollectionMetadata)
+
+	// Validate the txMeta.
+	if len(txMeta.Name) == 0 {
+		return 0, 0, nil, RuleErrorNFTCollectionNameEmpty
+	}
+	if len(txMeta.Name) > MaxNFTCollectionNameLengthBytes {
+		return 0, 0, nil, errors.Wrapf(RuleErrorNFTCollectionNameTooLong,
+			"_connectCreateNFTCollection: Name length %d > %d",
+			len(txMeta.Name), MaxNFTCollectionNameLengthBytes)
+	}
+
+	creatorPKID := bav.GetPKIDForPublicKey(txn.PublicKey)
+	if creatorPKID == nil || creatorPKID.isDeleted {
+		return 0, 0, nil, fmt.Errorf("_connectCreateNFTCollection: non-existent creatorPKID: %s",
+			PkToString(txn.PublicKey, bav.Params))
+	}
+	profileEntry := bav.GetProfileEntryForPublicKey(txn.PublicKey)
+	if profileEntry == nil || profileEntry.isDeleted {
+		return 0, 0, nil, RuleErrorCantCreateNFTCollectionWithoutProfileEntry
+	}
+
+	// Extract additional DESO royalties
+	additionalDESONFTRoyalties, additionalDESONFTRoyaltiesBasisPoints, err := bav.extractAdditionalRoyaltyMap(
+		DESORoyaltiesMapKey, txn.ExtraData, blockHeight)
+	if err != nil {
+		return 0, 0, nil, errors.Wrap(err,
+			"_connectCreateNFTCollection: Problem extract additional DESO Royalties: ")
+	}
+
+	// Extract additional coin royalties
+	additionalCoinNFTRoyalties, additionalCoinNFTRoyaltiesBasisPoints, err := bav.extractAdditionalRoyaltyMap(
+		CoinRoyaltiesMapKey, txn.ExtraData, blockHeight)
+	if err != nil {
+		return 0, 0, nil, errors.Wrap(err,
+			"_connectCreateNFTCollection: Problem extract additional Coin Royalties: ")
+	}
+
+	// Make sure the creator is not specified in the royalties maps, just like CreateNFT.
+	if _, exists := additionalDESONFTRoyalties[*creatorPKID.PKID]; exists {
+		return 0, 0, nil, errors.Wrapf(RuleErrorCannotSpecifyCreatorAsAdditionalRoyalty,
+			"_connectCreateNFTCollection: cannot specify the creator in the additional DESO royalties map")
+	}
+	if _, exists := additionalCoinNFTRoyalties[*creatorPKID.PKID]; exists {
+		return 0, 0, nil, errors.Wrapf(RuleErrorCannotSpecifyCreatorAsAdditionalRoyalty,
+			"_connectCreateNFTCollection: cannot specify the creator in the additional coin royalties map")
+	}
+
+	// Make sure we won't overflow when we add the royalty basis points.
+	totalRoyaltyBasisPoints := uint64(0)
+	for _, bps := range []uint64{
+		txMeta.NFTRoyaltyToCreatorBasisPoints, txMeta.NFTRoyaltyToCoinBasisPoints,
+		additionalDESONFTRoyaltiesBasisPoints, additionalCoinNFTRoyaltiesBasisPoints,
+	} {
+		if totalRoyaltyBasisPoints > math.MaxUint64-bps {
+			return 0, 0, nil, RuleErrorNFTRoyaltyOverflow
+		}
+		totalRoyaltyBasisPoints += bps
+	}
+	if totalRoyaltyBasisPoints > bav.Params.MaxNFTRoyaltyBasisPoints {
+		return 0, 0, nil, RuleErrorNFTRoyaltyHasTooManyBasisPoints
+	}
+
+	// Connect basic txn to get the total input and the total output without
+	// considering the transaction metadata.
+	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
+		txn, txHash, blockHeight, verifySignatures)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectCreateNFTCollection: ")
+	}
+
+	// Force the input to be non-zero so that we can prevent replay attacks.
+	if totalInput == 0 {
+		return 0, 0, nil, RuleErrorNFTCollectionRequiresNonZeroInput
+	}
+
+	// At this point the inputs and outputs have been processed. Now we
+	// need to handle the metadata. The collection is identified by the
+	// hash of this transaction.
+	bav._setNFTCollectionEntryMappings(&NFTCollectionEntry{
+		CollectionID:                   txHash,
+		CreatorPKID:                    creatorPKID.PKID,
+		Name:                           txMeta.Name,
+		MaxSupply:                      txMeta.MaxSupply,
+		NFTRoyaltyToCreatorBasisPoints: txMeta.NFTRoyaltyToCreatorBasisPoints,
+		NFTRoyaltyToCoinBasisPoints:    txMeta.NFTRoyaltyToCoinBasisPoints,
+		AdditionalNFTRoyaltiesToCreatorsBasisPoints: additionalDESONFTRoyalties,
+		AdditionalNFTRoyaltiesToCoinsBasisPoints:    additionalCoinNFTRoyalties,
+	})
+
+	// Add an operation to the list at the end indicating we've created a collection.
+	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
+		Type: OperationTypeCreateNFTCollection,
+	})
+
+	return totalInput, totalOutput, utxoOpsForTxn, nil
+}
+
+func (bav *UtxoView) _disconnectCreateNFTCollection(
+	operationType OperationType, currentTxn *MsgDeSoTxn, txnHash *BlockHash,
+	utxoOpsForTxn []*UtxoOperation, blockHeight uint32) error {
+
+	// Verify that the last operation is a CreateNFTCollection operation
+	if len(utxoOpsForTxn) == 0 {
+		return fmt.Errorf("_disconnectCreateNFTCollection: utxoOperations are missing")
+	}
+	operationIndex := len(utxoOpsForTxn) - 1
+	if utxoOpsForTxn[operationIndex].Type != OperationTypeCreateNFTCollection {
+		return fmt.Errorf("_disconnectCreateNFTCollection: Trying to revert "+
+			"OperationTypeCreateNFTCollection but found type %v",
+			utxoOpsForTxn[operationIndex].Type)
+	}
+
+	// Sanity check that the collection created by this transaction exists and
+	// that no NFTs are still attached to it.
+	nftCollectionEntry := bav.GetNFTCollectionEntry(txnHash)
+	if nftCollectionEntry == nil {
+		return fmt.Errorf("_disconnectCreateNFTCollection: NFTCollectionEntry for "+
+			"collection %v doesn't exist; this should never happen", txnHash)
+	}
+	if nftCollectionEntry.NumNFTCopies != 0 {
+		return fmt.Errorf("_disconnectCreateNFTCollection: NFTCollectionEntry for "+
+			"collection %v still has %d copies; this should never happen",
+			txnHash, nftCollectionEntry.NumNFTCopies)
+	}
+
+	bav._deleteNFTCollectionEntryMappings(nftCollectionEntry)
+
+	// Now revert the basic transfer with the remaining operations. Cut off
+	// the CreateNFTCollection operation at the end since we just reverted it.
+	return bav._disconnectBasicTransfer(
+		currentTxn, txnHash, utxoOpsForTxn[:operationIndex], blockHeight)
+}
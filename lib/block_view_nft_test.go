@@ -1,7 +1,9 @@
 package lib
 
 import (
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/dgraph-io/badger/v3"
+	"github.com/holiman/uint256"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"math"
@@ -9,11 +11,12 @@ import (
 	"testing"
 )
 
-func _createNFTWithAdditionalRoyalties(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+func _createNFTInCollection(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
 	feeRateNanosPerKB uint64, updaterPkBase58Check string, updaterPrivBase58Check string,
 	nftPostHash *BlockHash, numCopies uint64, hasUnlockable bool, isForSale bool, minBidAmountNanos uint64,
 	nftFee uint64, nftRoyaltyToCreatorBasisPoints uint64, nftRoyaltyToCoinBasisPoints uint64, isBuyNow bool,
 	buyNowPriceNanos uint64, additionalDESORoyaltiesMap map[PublicKey]uint64, additionalCoinRoyaltiesMap map[PublicKey]uint64,
+	nftCollectionID *BlockHash,
 ) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
 
 	assert := assert.New(t)
@@ -41,6 +44,7 @@ func _createNFTWithAdditionalRoyalties(t *testing.T, chain *Blockchain, db *badg
 		buyNowPriceNanos,
 		additionalDESORoyaltiesMap,
 		additionalCoinRoyaltiesMap,
+		nftCollectionID,
 		feeRateNanosPerKB,
 		nil, []*DeSoOutput{})
 	if err != nil {
@@ -78,6 +82,31 @@ func _createNFTWithAdditionalRoyalties(t *testing.T, chain *Blockchain, db *badg
 	return utxoOps, txn, blockHeight, nil
 }
 
+func _createNFTWithAdditionalRoyalties(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+	feeRateNanosPerKB uint64, updaterPkBase58Check string, updaterPrivBase58Check string,
+	nftPostHash *BlockHash, numCopies uint64, hasUnlockable bool, isForSale bool, minBidAmountNanos uint64,
+	nftFee uint64, nftRoyaltyToCreatorBasisPoints uint64, nftRoyaltyToCoinBasisPoints uint64, isBuyNow bool,
+	buyNowPriceNanos uint64, additionalDESORoyaltiesMap map[PublicKey]uint64, additionalCoinRoyaltiesMap map[PublicKey]uint64,
+) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
+
+	return _createNFTInCollection(t, chain, db, params, feeRateNanosPerKB,
+		updaterPkBase58Check,
+		updaterPrivBase58Check,
+		nftPostHash,
+		numCopies,
+		hasUnlockable,
+		isForSale,
+		minBidAmountNanos,
+		nftFee,
+		nftRoyaltyToCreatorBasisPoints,
+		nftRoyaltyToCoinBasisPoints,
+		isBuyNow,
+		buyNowPriceNanos,
+		additionalDESORoyaltiesMap,
+		additionalCoinRoyaltiesMap,
+		nil)
+}
+
 func _createNFT(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
 	feeRateNanosPerKB uint64, updaterPkBase58Check string, updaterPrivBase58Check string,
 	nftPostHash *BlockHash, numCopies uint64, hasUnlockable bool, isForSale bool, minBidAmountNanos uint64,
@@ -471,31 +500,28 @@ func _updateNFTWithTestMeta(
 	testMeta.txns = append(testMeta.txns, currentTxn)
 }
 
-func _transferNFT(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
-	feeRateNanosPerKB uint64, senderPk string, senderPriv string, receiverPk string,
-	nftPostHash *BlockHash, serialNumber uint64, unlockableText string,
+func _startNFTAuction(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+	feeRateNanosPerKB uint64, updaterPkBase58Check string, updaterPrivBase58Check string,
+	nftPostHash *BlockHash, serialNumber uint64, minBidAmountNanos uint64, auctionEndBlockHeight uint64,
+	reservePriceNanos uint64, minBidIncrementNanos uint64,
 ) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
 
-	assert := assert.New(t)
 	require := require.New(t)
-	_ = assert
-	_ = require
 
-	senderPkBytes, _, err := Base58CheckDecode(senderPk)
-	require.NoError(err)
-
-	receiverPkBytes, _, err := Base58CheckDecode(receiverPk)
+	updaterPkBytes, _, err := Base58CheckDecode(updaterPkBase58Check)
 	require.NoError(err)
 
 	utxoView, err := NewUtxoView(db, params, nil)
 	require.NoError(err)
 
-	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateNFTTransferTxn(
-		senderPkBytes,
-		receiverPkBytes,
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateStartNFTAuctionTxn(
+		updaterPkBytes,
 		nftPostHash,
 		serialNumber,
-		[]byte(unlockableText),
+		minBidAmountNanos,
+		auctionEndBlockHeight,
+		reservePriceNanos,
+		minBidIncrementNanos,
 		feeRateNanosPerKB,
 		nil,
 		[]*DeSoOutput{})
@@ -506,7 +532,7 @@ func _transferNFT(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoPa
 	require.Equal(totalInputMake, changeAmountMake+feesMake)
 
 	// Sign the transaction now that its inputs are set up.
-	_signTxn(t, txn, senderPriv)
+	_signTxn(t, txn, updaterPrivBase58Check)
 
 	txHash := txn.Hash()
 	// Always use height+1 for validation since it's assumed the transaction will
@@ -519,66 +545,65 @@ func _transferNFT(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoPa
 	}
 	require.Equal(totalInput, totalOutput+fees)
 	require.Equal(totalInput, totalInputMake)
-
-	// We should have one SPEND UtxoOperation for each input, one ADD operation
-	// for each output, and one OperationTypeNFTTransfer operation at the end.
-	require.Equal(len(txn.TxInputs)+len(txn.TxOutputs)+1, len(utxoOps))
-	for ii := 0; ii < len(txn.TxInputs); ii++ {
-		require.Equal(OperationTypeSpendUtxo, utxoOps[ii].Type)
-	}
-	require.Equal(OperationTypeNFTTransfer, utxoOps[len(utxoOps)-1].Type)
+	require.Equal(OperationTypeUpdateNFT, utxoOps[len(utxoOps)-1].Type)
 
 	require.NoError(utxoView.FlushToDb())
 
 	return utxoOps, txn, blockHeight, nil
 }
 
-func _transferNFTWithTestMeta(
+func _startNFTAuctionWithTestMeta(
 	testMeta *TestMeta,
 	feeRateNanosPerKB uint64,
-	senderPkBase58Check string,
-	senderPrivBase58Check string,
-	receiverPkBase58Check string,
+	updaterPkBase58Check string,
+	updaterPrivBase58Check string,
 	postHash *BlockHash,
 	serialNumber uint64,
-	unlockableText string,
+	minBidAmountNanos uint64,
+	auctionEndBlockHeight uint64,
+	reservePriceNanos uint64,
+	minBidIncrementNanos uint64,
 ) {
 	testMeta.expectedSenderBalances = append(
-		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, senderPkBase58Check))
-	currentOps, currentTxn, _, err := _transferNFT(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, updaterPkBase58Check))
+	currentOps, currentTxn, _, err := _startNFTAuction(
 		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
-		senderPkBase58Check,
-		senderPrivBase58Check,
-		receiverPkBase58Check,
+		updaterPkBase58Check,
+		updaterPrivBase58Check,
 		postHash,
 		serialNumber,
-		unlockableText,
+		minBidAmountNanos,
+		auctionEndBlockHeight,
+		reservePriceNanos,
+		minBidIncrementNanos,
 	)
 	require.NoError(testMeta.t, err)
 	testMeta.txnOps = append(testMeta.txnOps, currentOps)
 	testMeta.txns = append(testMeta.txns, currentTxn)
 }
 
-func _acceptNFTTransfer(t *testing.T, chain *Blockchain, db *badger.DB,
-	params *DeSoParams, feeRateNanosPerKB uint64, updaterPkBase58Check string,
-	updaterPrivBase58Check string, nftPostHash *BlockHash, serialNumber uint64,
+func _createNFTCollection(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+	feeRateNanosPerKB uint64, creatorPkBase58Check string, creatorPrivBase58Check string,
+	name string, maxSupply uint64, nftRoyaltyToCreatorBasisPoints uint64, nftRoyaltyToCoinBasisPoints uint64,
+	additionalDESORoyaltiesMap map[PublicKey]uint64, additionalCoinRoyaltiesMap map[PublicKey]uint64,
 ) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
 
-	assert := assert.New(t)
 	require := require.New(t)
-	_ = assert
-	_ = require
 
-	updaterPkBytes, _, err := Base58CheckDecode(updaterPkBase58Check)
+	creatorPkBytes, _, err := Base58CheckDecode(creatorPkBase58Check)
 	require.NoError(err)
 
 	utxoView, err := NewUtxoView(db, params, nil)
 	require.NoError(err)
 
-	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateAcceptNFTTransferTxn(
-		updaterPkBytes,
-		nftPostHash,
-		serialNumber,
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateCreateNFTCollectionTxn(
+		creatorPkBytes,
+		[]byte(name),
+		maxSupply,
+		nftRoyaltyToCreatorBasisPoints,
+		nftRoyaltyToCoinBasisPoints,
+		additionalDESORoyaltiesMap,
+		additionalCoinRoyaltiesMap,
 		feeRateNanosPerKB,
 		nil,
 		[]*DeSoOutput{})
@@ -589,7 +614,7 @@ func _acceptNFTTransfer(t *testing.T, chain *Blockchain, db *badger.DB,
 	require.Equal(totalInputMake, changeAmountMake+feesMake)
 
 	// Sign the transaction now that its inputs are set up.
-	_signTxn(t, txn, updaterPrivBase58Check)
+	_signTxn(t, txn, creatorPrivBase58Check)
 
 	txHash := txn.Hash()
 	// Always use height+1 for validation since it's assumed the transaction will
@@ -602,51 +627,84 @@ func _acceptNFTTransfer(t *testing.T, chain *Blockchain, db *badger.DB,
 	}
 	require.Equal(totalInput, totalOutput+fees)
 	require.Equal(totalInput, totalInputMake)
-
-	// We should have one SPEND UtxoOperation for each input, one ADD operation
-	// for each output, and one OperationTypeNFTTransfer operation at the end.
-	require.Equal(len(txn.TxInputs)+len(txn.TxOutputs)+1, len(utxoOps))
-	for ii := 0; ii < len(txn.TxInputs); ii++ {
-		require.Equal(OperationTypeSpendUtxo, utxoOps[ii].Type)
-	}
-	require.Equal(OperationTypeAcceptNFTTransfer, utxoOps[len(utxoOps)-1].Type)
+	require.Equal(OperationTypeCreateNFTCollection, utxoOps[len(utxoOps)-1].Type)
 
 	require.NoError(utxoView.FlushToDb())
 
 	return utxoOps, txn, blockHeight, nil
 }
 
-func _acceptNFTTransferWithTestMeta(
+func _createNFTCollectionWithTestMeta(
+	testMeta *TestMeta,
+	feeRateNanosPerKB uint64,
+	creatorPkBase58Check string,
+	creatorPrivBase58Check string,
+	name string,
+	maxSupply uint64,
+	nftRoyaltyToCreatorBasisPoints uint64,
+	nftRoyaltyToCoinBasisPoints uint64,
+	additionalDESORoyaltiesMap map[PublicKey]uint64,
+	additionalCoinRoyaltiesMap map[PublicKey]uint64,
+) {
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, creatorPkBase58Check))
+	currentOps, currentTxn, _, err := _createNFTCollection(
+		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
+		creatorPkBase58Check,
+		creatorPrivBase58Check,
+		name,
+		maxSupply,
+		nftRoyaltyToCreatorBasisPoints,
+		nftRoyaltyToCoinBasisPoints,
+		additionalDESORoyaltiesMap,
+		additionalCoinRoyaltiesMap,
+	)
+	require.NoError(testMeta.t, err)
+	testMeta.txnOps = append(testMeta.txnOps, currentOps)
+	testMeta.txns = append(testMeta.txns, currentTxn)
+}
+
+func _createNFTInCollectionWithTestMeta(
 	testMeta *TestMeta,
 	feeRateNanosPerKB uint64,
 	updaterPkBase58Check string,
 	updaterPrivBase58Check string,
-	postHash *BlockHash,
-	serialNumber uint64,
+	postHashToModify *BlockHash,
+	numCopies uint64,
+	isForSale bool,
+	nftCollectionID *BlockHash,
 ) {
 	testMeta.expectedSenderBalances = append(
 		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, updaterPkBase58Check))
-	currentOps, currentTxn, _, err := _acceptNFTTransfer(
+	currentOps, currentTxn, _, err := _createNFTInCollection(
 		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
 		updaterPkBase58Check,
 		updaterPrivBase58Check,
-		postHash,
-		serialNumber,
+		postHashToModify,
+		numCopies,
+		false, /*HasUnlockable*/
+		isForSale,
+		0,     /*MinBidAmountNanos*/
+		0,     /*nftFee*/
+		0,     /*nftRoyaltyToCreatorBasisPoints*/
+		0,     /*nftRoyaltyToCoinBasisPoints*/
+		false, /*IsBuyNow*/
+		0,     /*BuyNowPriceNanos*/
+		nil,
+		nil,
+		nftCollectionID,
 	)
 	require.NoError(testMeta.t, err)
 	testMeta.txnOps = append(testMeta.txnOps, currentOps)
 	testMeta.txns = append(testMeta.txns, currentTxn)
 }
 
-func _burnNFT(t *testing.T, chain *Blockchain, db *badger.DB,
-	params *DeSoParams, feeRateNanosPerKB uint64, updaterPkBase58Check string,
-	updaterPrivBase58Check string, nftPostHash *BlockHash, serialNumber uint64,
+func _nftVault(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+	feeRateNanosPerKB uint64, updaterPkBase58Check string, updaterPrivBase58Check string,
+	metadata *NFTVaultMetadata,
 ) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
 
-	assert := assert.New(t)
 	require := require.New(t)
-	_ = assert
-	_ = require
 
 	updaterPkBytes, _, err := Base58CheckDecode(updaterPkBase58Check)
 	require.NoError(err)
@@ -654,10 +712,9 @@ func _burnNFT(t *testing.T, chain *Blockchain, db *badger.DB,
 	utxoView, err := NewUtxoView(db, params, nil)
 	require.NoError(err)
 
-	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateBurnNFTTxn(
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateNFTVaultTxn(
 		updaterPkBytes,
-		nftPostHash,
-		serialNumber,
+		metadata,
 		feeRateNanosPerKB,
 		nil,
 		[]*DeSoOutput{})
@@ -681,384 +738,419 @@ func _burnNFT(t *testing.T, chain *Blockchain, db *badger.DB,
 	}
 	require.Equal(totalInput, totalOutput+fees)
 	require.Equal(totalInput, totalInputMake)
-
-	// We should have one SPEND UtxoOperation for each input, one ADD operation
-	// for each output, and one OperationTypeNFTTransfer operation at the end.
-	require.Equal(len(txn.TxInputs)+len(txn.TxOutputs)+1, len(utxoOps))
-	for ii := 0; ii < len(txn.TxInputs); ii++ {
-		require.Equal(OperationTypeSpendUtxo, utxoOps[ii].Type)
-	}
-	require.Equal(OperationTypeBurnNFT, utxoOps[len(utxoOps)-1].Type)
+	require.Equal(OperationTypeNFTVault, utxoOps[len(utxoOps)-1].Type)
 
 	require.NoError(utxoView.FlushToDb())
 
 	return utxoOps, txn, blockHeight, nil
 }
 
-func _burnNFTWithTestMeta(
+func _nftVaultWithTestMeta(
 	testMeta *TestMeta,
 	feeRateNanosPerKB uint64,
 	updaterPkBase58Check string,
 	updaterPrivBase58Check string,
-	postHash *BlockHash,
-	serialNumber uint64,
+	metadata *NFTVaultMetadata,
 ) {
 	testMeta.expectedSenderBalances = append(
 		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, updaterPkBase58Check))
-	currentOps, currentTxn, _, err := _burnNFT(
+	currentOps, currentTxn, _, err := _nftVault(
 		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
 		updaterPkBase58Check,
 		updaterPrivBase58Check,
-		postHash,
-		serialNumber,
+		metadata,
 	)
 	require.NoError(testMeta.t, err)
 	testMeta.txnOps = append(testMeta.txnOps, currentOps)
 	testMeta.txns = append(testMeta.txns, currentTxn)
 }
 
-func TestNFTBasic(t *testing.T) {
-
-	assert := assert.New(t)
+func _signNFTVoucher(t *testing.T, voucher *NFTVoucher, signerPrivBase58Check string) []byte {
 	require := require.New(t)
-	_ = assert
-	_ = require
-
-	chain, params, db := NewLowDifficultyBlockchain()
-	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
-	// Make m3, m4 a paramUpdater for this test
-	params.ParamUpdaterPublicKeys[MakePkMapKey(m3PkBytes)] = true
-	params.ParamUpdaterPublicKeys[MakePkMapKey(m4PkBytes)] = true
-	params.ForkHeights.BrokenNFTBidsFixBlockHeight = uint32(0)
-	params.ForkHeights.BuyNowAndNFTSplitsBlockHeight = uint32(0)
 
-	// Mine a few blocks to give the senderPkString some money.
-	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	signatureData, err := NFTVoucherSignatureData(voucher)
 	require.NoError(err)
-	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
-	require.NoError(err)
-	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	signerPrivBytes, _, err := Base58CheckDecode(signerPrivBase58Check)
 	require.NoError(err)
-	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	signerPriv, _ := btcec.PrivKeyFromBytes(btcec.S256(), signerPrivBytes)
+	signature, err := signerPriv.Sign(Sha256DoubleHash(signatureData)[:])
 	require.NoError(err)
+	return signature.Serialize()
+}
 
-	// We build the testMeta obj after mining blocks so that we save the correct block height.
-	testMeta := &TestMeta{
-		t:           t,
-		chain:       chain,
-		params:      params,
-		db:          db,
-		mempool:     mempool,
-		miner:       miner,
-		savedHeight: chain.blockTip().Height + 1,
-	}
+func _redeemNFTVoucher(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+	feeRateNanosPerKB uint64, redeemerPkBase58Check string, redeemerPrivBase58Check string,
+	voucher *NFTVoucher, creatorSignature []byte,
+) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
 
-	// Fund all the keys.
-	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 70)
-	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 420)
-	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m2Pub, senderPrivString, 140)
-	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m3Pub, senderPrivString, 210)
-	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m4Pub, senderPrivString, 100)
+	require := require.New(t)
 
-	// Set max copies to a non-zero value to activate NFTs.
-	{
-		_updateGlobalParamsEntryWithTestMeta(
-			testMeta,
-			10, /*FeeRateNanosPerKB*/
-			m4Pub,
-			m4Priv,
-			-1, -1, -1, -1,
-			1000, /*maxCopiesPerNFT*/
-		)
-	}
+	redeemerPkBytes, _, err := Base58CheckDecode(redeemerPkBase58Check)
+	require.NoError(err)
 
-	// Create a simple post.
-	{
-		_submitPostWithTestMeta(
-			testMeta,
-			10,                                 /*feeRateNanosPerKB*/
-			m0Pub,                              /*updaterPkBase58Check*/
-			m0Priv,                             /*updaterPrivBase58Check*/
-			[]byte{},                           /*postHashToModify*/
-			[]byte{},                           /*parentStakeID*/
-			&DeSoBodySchema{Body: "m0 post 1"}, /*body*/
-			[]byte{},
-			1502947011*1e9, /*tstampNanos*/
-			false /*isHidden*/)
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateRedeemNFTVoucherTxn(
+		redeemerPkBytes,
+		voucher,
+		creatorSignature,
+		utxoView.GlobalParamsEntry.CreateNFTFeeNanos,
+		feeRateNanosPerKB,
+		nil,
+		[]*DeSoOutput{})
+	if err != nil {
+		return nil, nil, 0, err
 	}
-	post1Hash := testMeta.txns[len(testMeta.txns)-1].Hash()
 
-	// Error case: can't make an NFT without a profile.
-	{
-		_, _, _, err := _createNFT(
-			t, chain, db, params, 10,
-			m0Pub,
-			m0Priv,
-			post1Hash,
-			100,   /*NumCopies*/
-			false, /*HasUnlockable*/
-			true,  /*IsForSale*/
-			0,     /*MinBidAmountNanos*/
-			0,     /*nftFee*/
-			0,     /*nftRoyaltyToCreatorBasisPoints*/
-			0,     /*nftRoyaltyToCoinBasisPoints*/
-			false,
-			0,
-		)
+	require.Equal(totalInputMake, changeAmountMake+feesMake+voucher.PriceNanos+
+		utxoView.GlobalParamsEntry.CreateNFTFeeNanos)
 
-		require.Error(err)
-		require.Contains(err.Error(), RuleErrorCantCreateNFTWithoutProfileEntry)
+	// Sign the transaction now that its inputs are set up.
+	_signTxn(t, txn, redeemerPrivBase58Check)
+
+	txHash := txn.Hash()
+	// Always use height+1 for validation since it's assumed the transaction will
+	// get mined into the next block.
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, 0, err
 	}
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(totalInput, totalInputMake)
+	require.Equal(OperationTypeRedeemNFTVoucher, utxoOps[len(utxoOps)-1].Type)
 
-	// Create a profile so we can make an NFT.
-	{
-		_updateProfileWithTestMeta(
-			testMeta,
-			10,            /*feeRateNanosPerKB*/
-			m0Pub,         /*updaterPkBase58Check*/
-			m0Priv,        /*updaterPrivBase58Check*/
-			[]byte{},      /*profilePubKey*/
-			"m2",          /*newUsername*/
-			"i am the m2", /*newDescription*/
-			shortPic,      /*newProfilePic*/
-			10*100,        /*newCreatorBasisPoints*/
-			1.25*100*100,  /*newStakeMultipleBasisPoints*/
-			false /*isHidden*/)
-	}
+	require.NoError(utxoView.FlushToDb())
 
-	// Error case: m0 cannot turn a vanilla repost of their post into an NFT.
-	{
-		_submitPostWithTestMeta(
-			testMeta,
-			10,                /*feeRateNanosPerKB*/
-			m0Pub,             /*updaterPkBase58Check*/
-			m0Priv,            /*updaterPrivBase58Check*/
-			[]byte{},          /*postHashToModify*/
-			[]byte{},          /*parentStakeID*/
-			&DeSoBodySchema{}, /*body*/
-			post1Hash[:],      /*repostedPostHash*/
-			1502947011*1e9,    /*tstampNanos*/
-			false /*isHidden*/)
+	return utxoOps, txn, blockHeight, nil
+}
 
-		vanillaRepostPostHash := testMeta.txns[len(testMeta.txns)-1].Hash()
-		_, _, _, err := _createNFT(
-			t, chain, db, params, 10,
-			m0Pub,
-			m0Priv,
-			vanillaRepostPostHash,
-			100,   /*NumCopies*/
-			false, /*HasUnlockable*/
-			true,  /*IsForSale*/
-			0,     /*MinBidAmountNanos*/
-			0,     /*nftFee*/
-			0,     /*nftRoyaltyToCreatorBasisPoints*/
-			0,     /*nftRoyaltyToCoinBasisPoints*/
-			false,
-			0,
-		)
+func _redeemNFTVoucherWithTestMeta(
+	testMeta *TestMeta,
+	feeRateNanosPerKB uint64,
+	redeemerPkBase58Check string,
+	redeemerPrivBase58Check string,
+	voucher *NFTVoucher,
+	creatorSignature []byte,
+) {
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, redeemerPkBase58Check))
+	currentOps, currentTxn, _, err := _redeemNFTVoucher(
+		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
+		redeemerPkBase58Check,
+		redeemerPrivBase58Check,
+		voucher,
+		creatorSignature,
+	)
+	require.NoError(testMeta.t, err)
+	testMeta.txnOps = append(testMeta.txnOps, currentOps)
+	testMeta.txns = append(testMeta.txns, currentTxn)
+}
 
-		require.Error(err)
-		require.Contains(err.Error(), RuleErrorCreateNFTOnVanillaRepost)
-	}
+func _transferNFT(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+	feeRateNanosPerKB uint64, senderPk string, senderPriv string, receiverPk string,
+	nftPostHash *BlockHash, serialNumber uint64, unlockableText string,
+) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
 
-	// Error case: m1 should not be able to turn m0's post into an NFT.
-	{
-		_, _, _, err := _createNFT(
-			t, chain, db, params, 10,
-			m1Pub,
-			m1Priv,
-			post1Hash,
-			100,   /*NumCopies*/
-			false, /*HasUnlockable*/
-			true,  /*IsForSale*/
-			0,     /*MinBidAmountNanos*/
-			0,     /*nftFee*/
-			0,     /*nftRoyaltyToCreatorBasisPoints*/
-			0,     /*nftRoyaltyToCoinBasisPoints*/
-			false,
-			0,
-		)
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
 
-		require.Error(err)
-		require.Contains(err.Error(), RuleErrorCreateNFTMustBeCalledByPoster)
-	}
+	senderPkBytes, _, err := Base58CheckDecode(senderPk)
+	require.NoError(err)
 
-	// Error case: m0 should not be able to make more than MaxCopiesPerNFT.
-	{
-		_, _, _, err := _createNFT(
-			t, chain, db, params, 10,
-			m0Pub,
-			m0Priv,
-			post1Hash,
-			1001,  /*NumCopies*/
-			false, /*HasUnlockable*/
-			true,  /*IsForSale*/
-			0,     /*MinBidAmountNanos*/
-			0,     /*nftFee*/
-			0,     /*nftRoyaltyToCreatorBasisPoints*/
-			0,     /*nftRoyaltyToCoinBasisPoints*/
-			false,
-			0,
-		)
+	receiverPkBytes, _, err := Base58CheckDecode(receiverPk)
+	require.NoError(err)
 
-		require.Error(err)
-		require.Contains(err.Error(), RuleErrorTooManyNFTCopies)
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateNFTTransferTxn(
+		senderPkBytes,
+		receiverPkBytes,
+		nftPostHash,
+		serialNumber,
+		[]byte(unlockableText),
+		feeRateNanosPerKB,
+		nil,
+		[]*DeSoOutput{})
+	if err != nil {
+		return nil, nil, 0, err
 	}
 
-	// Error case: m0 should not be able to make an NFT with zero copies.
-	{
-		_, _, _, err := _createNFT(
-			t, chain, db, params, 10,
-			m0Pub,
-			m0Priv,
-			post1Hash,
-			0,     /*NumCopies*/
-			false, /*HasUnlockable*/
-			true,  /*IsForSale*/
-			0,     /*MinBidAmountNanos*/
-			0,     /*nftFee*/
-			0,     /*nftRoyaltyToCreatorBasisPoints*/
-			0,     /*nftRoyaltyToCoinBasisPoints*/
-			false,
-			0,
-		)
+	require.Equal(totalInputMake, changeAmountMake+feesMake)
 
-		require.Error(err)
-		require.Contains(err.Error(), RuleErrorNFTMustHaveNonZeroCopies)
+	// Sign the transaction now that its inputs are set up.
+	_signTxn(t, txn, senderPriv)
+
+	txHash := txn.Hash()
+	// Always use height+1 for validation since it's assumed the transaction will
+	// get mined into the next block.
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, 0, err
 	}
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(totalInput, totalInputMake)
 
-	// Error case: non-existent post.
-	{
+	// We should have one SPEND UtxoOperation for each input, one ADD operation
+	// for each output, and one OperationTypeNFTTransfer operation at the end.
+	require.Equal(len(txn.TxInputs)+len(txn.TxOutputs)+1, len(utxoOps))
+	for ii := 0; ii < len(txn.TxInputs); ii++ {
+		require.Equal(OperationTypeSpendUtxo, utxoOps[ii].Type)
+	}
+	require.Equal(OperationTypeNFTTransfer, utxoOps[len(utxoOps)-1].Type)
 
-		fakePostHash := &BlockHash{
-			0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
-			0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
-			0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1}
+	require.NoError(utxoView.FlushToDb())
 
-		_, _, _, err := _createNFT(
-			t, chain, db, params, 10,
-			m0Pub,
-			m0Priv,
-			fakePostHash,
-			1,     /*NumCopies*/
-			false, /*HasUnlockable*/
-			true,  /*IsForSale*/
-			0,     /*MinBidAmountNanos*/
-			0,     /*nftFee*/
-			0,     /*nftRoyaltyToCreatorBasisPoints*/
-			0,     /*nftRoyaltyToCoinBasisPoints*/
-			false,
-			0,
-		)
+	return utxoOps, txn, blockHeight, nil
+}
 
-		require.Error(err)
-		require.Contains(err.Error(), RuleErrorCreateNFTOnNonexistentPost)
-	}
+func _transferNFTWithTestMeta(
+	testMeta *TestMeta,
+	feeRateNanosPerKB uint64,
+	senderPkBase58Check string,
+	senderPrivBase58Check string,
+	receiverPkBase58Check string,
+	postHash *BlockHash,
+	serialNumber uint64,
+	unlockableText string,
+) {
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, senderPkBase58Check))
+	currentOps, currentTxn, _, err := _transferNFT(
+		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
+		senderPkBase58Check,
+		senderPrivBase58Check,
+		receiverPkBase58Check,
+		postHash,
+		serialNumber,
+		unlockableText,
+	)
+	require.NoError(testMeta.t, err)
+	testMeta.txnOps = append(testMeta.txnOps, currentOps)
+	testMeta.txns = append(testMeta.txns, currentTxn)
+}
 
-	// Error case: can't set BuyNow to true with unlockable content
-	{
-		_, _, _, err := _createNFT(
-			t, chain, db, params, 10,
-			m0Pub,
-			m0Priv,
-			post1Hash,
-			1,    /*NumCopies*/
-			true, /*HasUnlockable*/
-			true, /*IsForSale*/
-			0,    /*MinBidAmountNanos*/
-			0,    /*nftFee*/
-			0,    /*nftRoyaltyToCreatorBasisPoints*/
-			0,    /*nftRoyaltyToCoinBasisPoints*/
-			true, /*IsBuyNow*/
-			0,
-		)
+func _acceptNFTTransfer(t *testing.T, chain *Blockchain, db *badger.DB,
+	params *DeSoParams, feeRateNanosPerKB uint64, updaterPkBase58Check string,
+	updaterPrivBase58Check string, nftPostHash *BlockHash, serialNumber uint64,
+) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
 
-		require.Error(err)
-		require.Contains(err.Error(), RuleErrorCannotHaveUnlockableAndBuyNowNFT)
-	}
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
 
-	// Finally, have m0 turn post1 into an NFT. Woohoo!
-	{
-		// Balance before.
-		m0BalBeforeNFT := _getBalance(t, chain, nil, m0Pub)
-		require.Equal(uint64(28), m0BalBeforeNFT)
+	updaterPkBytes, _, err := Base58CheckDecode(updaterPkBase58Check)
+	require.NoError(err)
 
-		_createNFTWithTestMeta(
-			testMeta,
-			10, /*FeeRateNanosPerKB*/
-			m0Pub,
-			m0Priv,
-			post1Hash,
-			5,     /*NumCopies*/
-			false, /*HasUnlockable*/
-			true,  /*IsForSale*/
-			0,     /*MinBidAmountNanos*/
-			0,     /*nftFee*/
-			0,     /*nftRoyaltyToCreatorBasisPoints*/
-			0,     /*nftRoyaltyToCoinBasisPoints*/
-			false,
-			0,
-		)
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
 
-		// Balance after. Since the default NFT fee is 0, m0 is only charged the nanos per kb fee.
-		m0BalAfterNFT := _getBalance(testMeta.t, testMeta.chain, nil, m0Pub)
-		require.Equal(uint64(27), m0BalAfterNFT)
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateAcceptNFTTransferTxn(
+		updaterPkBytes,
+		nftPostHash,
+		serialNumber,
+		feeRateNanosPerKB,
+		nil,
+		[]*DeSoOutput{})
+	if err != nil {
+		return nil, nil, 0, err
 	}
 
-	// Error case: cannot turn a post into an NFT twice.
-	{
-		_, _, _, err := _createNFT(
-			t, chain, db, params, 10,
-			m0Pub,
-			m0Priv,
-			post1Hash,
-			5,     /*NumCopies*/
-			false, /*HasUnlockable*/
-			true,  /*IsForSale*/
-			0,     /*MinBidAmountNanos*/
-			0,     /*nftFee*/
-			0,     /*nftRoyaltyToCreatorBasisPoints*/
-			0,     /*nftRoyaltyToCoinBasisPoints*/
-			false,
-			0,
-		)
-		require.Error(err)
-		require.Contains(err.Error(), RuleErrorCreateNFTOnPostThatAlreadyIsNFT)
+	require.Equal(totalInputMake, changeAmountMake+feesMake)
+
+	// Sign the transaction now that its inputs are set up.
+	_signTxn(t, txn, updaterPrivBase58Check)
+
+	txHash := txn.Hash()
+	// Always use height+1 for validation since it's assumed the transaction will
+	// get mined into the next block.
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, 0, err
 	}
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(totalInput, totalInputMake)
 
-	// Error case: cannot modify a post after it is NFTed.
-	{
-		_, _, _, err := _submitPost(
-			testMeta.t, testMeta.chain, testMeta.db, testMeta.params,
-			10,
-			m0Pub,
-			m0Priv,
-			post1Hash[:],
-			[]byte{},
-			&DeSoBodySchema{Body: "modified m0 post"},
-			[]byte{},
-			1502947011*1e9,
-			false)
+	// We should have one SPEND UtxoOperation for each input, one ADD operation
+	// for each output, and one OperationTypeNFTTransfer operation at the end.
+	require.Equal(len(txn.TxInputs)+len(txn.TxOutputs)+1, len(utxoOps))
+	for ii := 0; ii < len(txn.TxInputs); ii++ {
+		require.Equal(OperationTypeSpendUtxo, utxoOps[ii].Type)
+	}
+	require.Equal(OperationTypeAcceptNFTTransfer, utxoOps[len(utxoOps)-1].Type)
 
-		require.Error(err)
-		require.Contains(err.Error(), RuleErrorSubmitPostCannotUpdateNFT)
+	require.NoError(utxoView.FlushToDb())
+
+	return utxoOps, txn, blockHeight, nil
+}
+
+func _acceptNFTTransferWithTestMeta(
+	testMeta *TestMeta,
+	feeRateNanosPerKB uint64,
+	updaterPkBase58Check string,
+	updaterPrivBase58Check string,
+	postHash *BlockHash,
+	serialNumber uint64,
+) {
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, updaterPkBase58Check))
+	currentOps, currentTxn, _, err := _acceptNFTTransfer(
+		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
+		updaterPkBase58Check,
+		updaterPrivBase58Check,
+		postHash,
+		serialNumber,
+	)
+	require.NoError(testMeta.t, err)
+	testMeta.txnOps = append(testMeta.txnOps, currentOps)
+	testMeta.txns = append(testMeta.txns, currentTxn)
+}
+
+func _burnNFT(t *testing.T, chain *Blockchain, db *badger.DB,
+	params *DeSoParams, feeRateNanosPerKB uint64, updaterPkBase58Check string,
+	updaterPrivBase58Check string, nftPostHash *BlockHash, serialNumber uint64,
+) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
+
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	updaterPkBytes, _, err := Base58CheckDecode(updaterPkBase58Check)
+	require.NoError(err)
+
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateBurnNFTTxn(
+		updaterPkBytes,
+		nftPostHash,
+		serialNumber,
+		feeRateNanosPerKB,
+		nil,
+		[]*DeSoOutput{})
+	if err != nil {
+		return nil, nil, 0, err
 	}
 
-	// Now let's try adding a fee to creating NFT copies. This fee exists since creating
-	// n-copies of an NFT causes the chain to do n-times as much work.
+	require.Equal(totalInputMake, changeAmountMake+feesMake)
+
+	// Sign the transaction now that its inputs are set up.
+	_signTxn(t, txn, updaterPrivBase58Check)
+
+	txHash := txn.Hash()
+	// Always use height+1 for validation since it's assumed the transaction will
+	// get mined into the next block.
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(totalInput, totalInputMake)
+
+	// We should have one SPEND UtxoOperation for each input, one ADD operation
+	// for each output, and one OperationTypeNFTTransfer operation at the end.
+	require.Equal(len(txn.TxInputs)+len(txn.TxOutputs)+1, len(utxoOps))
+	for ii := 0; ii < len(txn.TxInputs); ii++ {
+		require.Equal(OperationTypeSpendUtxo, utxoOps[ii].Type)
+	}
+	require.Equal(OperationTypeBurnNFT, utxoOps[len(utxoOps)-1].Type)
+
+	require.NoError(utxoView.FlushToDb())
+
+	return utxoOps, txn, blockHeight, nil
+}
+
+func _burnNFTWithTestMeta(
+	testMeta *TestMeta,
+	feeRateNanosPerKB uint64,
+	updaterPkBase58Check string,
+	updaterPrivBase58Check string,
+	postHash *BlockHash,
+	serialNumber uint64,
+) {
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, updaterPkBase58Check))
+	currentOps, currentTxn, _, err := _burnNFT(
+		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
+		updaterPkBase58Check,
+		updaterPrivBase58Check,
+		postHash,
+		serialNumber,
+	)
+	require.NoError(testMeta.t, err)
+	testMeta.txnOps = append(testMeta.txnOps, currentOps)
+	testMeta.txns = append(testMeta.txns, currentTxn)
+}
+
+func TestNFTBasic(t *testing.T) {
+
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	// Make m3, m4 a paramUpdater for this test
+	params.ParamUpdaterPublicKeys[MakePkMapKey(m3PkBytes)] = true
+	params.ParamUpdaterPublicKeys[MakePkMapKey(m4PkBytes)] = true
+	params.ForkHeights.BrokenNFTBidsFixBlockHeight = uint32(0)
+	params.ForkHeights.BuyNowAndNFTSplitsBlockHeight = uint32(0)
+
+	// Mine a few blocks to give the senderPkString some money.
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	// We build the testMeta obj after mining blocks so that we save the correct block height.
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+
+	// Fund all the keys.
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 70)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 420)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m2Pub, senderPrivString, 140)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m3Pub, senderPrivString, 210)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m4Pub, senderPrivString, 100)
+
+	// Set max copies to a non-zero value to activate NFTs.
 	{
 		_updateGlobalParamsEntryWithTestMeta(
 			testMeta,
 			10, /*FeeRateNanosPerKB*/
-			m3Pub,
-			m3Priv,
-			-1, -1, -1,
-			1,  /*createNFTFeeNanos*/
-			-1, /*maxCopiesPerNFT*/
+			m4Pub,
+			m4Priv,
+			-1, -1, -1, -1,
+			1000, /*maxCopiesPerNFT*/
 		)
 	}
 
-	// Have m0 create another post for us to NFTify.
+	// Create a simple post.
 	{
 		_submitPostWithTestMeta(
 			testMeta,
@@ -1067,21 +1159,21 @@ func TestNFTBasic(t *testing.T) {
 			m0Priv,                             /*updaterPrivBase58Check*/
 			[]byte{},                           /*postHashToModify*/
 			[]byte{},                           /*parentStakeID*/
-			&DeSoBodySchema{Body: "m0 post 2"}, /*body*/
+			&DeSoBodySchema{Body: "m0 post 1"}, /*body*/
 			[]byte{},
-			1502947012*1e9, /*tstampNanos*/
+			1502947011*1e9, /*tstampNanos*/
 			false /*isHidden*/)
 	}
-	post2Hash := testMeta.txns[len(testMeta.txns)-1].Hash()
+	post1Hash := testMeta.txns[len(testMeta.txns)-1].Hash()
 
-	// Error case: creating an NFT without paying the nftFee should fail.
+	// Error case: can't make an NFT without a profile.
 	{
 		_, _, _, err := _createNFT(
 			t, chain, db, params, 10,
 			m0Pub,
 			m0Priv,
-			post2Hash,
-			1000,  /*NumCopies*/
+			post1Hash,
+			100,   /*NumCopies*/
 			false, /*HasUnlockable*/
 			true,  /*IsForSale*/
 			0,     /*MinBidAmountNanos*/
@@ -1091,347 +1183,428 @@ func TestNFTBasic(t *testing.T) {
 			false,
 			0,
 		)
+
 		require.Error(err)
-		require.Contains(err.Error(), RuleErrorCreateNFTWithInsufficientFunds)
+		require.Contains(err.Error(), RuleErrorCantCreateNFTWithoutProfileEntry)
 	}
 
-	// Creating an NFT with the correct NFT fee should succeed.
-	// This time set HasUnlockable to 'true'.
+	// Create a profile so we can make an NFT.
 	{
-		utxoView, err := NewUtxoView(db, params, nil)
-		require.NoError(err)
-
-		numCopies := uint64(10)
-		nftFee := utxoView.GlobalParamsEntry.CreateNFTFeeNanos * numCopies
-
-		m0BalBeforeNFT := _getBalance(testMeta.t, testMeta.chain, nil, m0Pub)
-		require.Equal(uint64(26), m0BalBeforeNFT)
-
-		_createNFTWithTestMeta(
+		_updateProfileWithTestMeta(
 			testMeta,
-			10, /*FeeRateNanosPerKB*/
-			m0Pub,
-			m0Priv,
-			post2Hash,
-			10,     /*NumCopies*/
-			true,   /*HasUnlockable*/
-			true,   /*IsForSale*/
-			0,      /*MinBidAmountNanos*/
-			nftFee, /*nftFee*/
-			0,      /*nftRoyaltyToCreatorBasisPoints*/
-			0,      /*nftRoyaltyToCoinBasisPoints*/
-			false,
-			0,
-		)
-
-		// Check that m0 was charged the correct nftFee.
-		m0BalAfterNFT := _getBalance(testMeta.t, testMeta.chain, nil, m0Pub)
-		require.Equal(uint64(25)-nftFee, m0BalAfterNFT)
-	}
-
-	//
-	// Bidding on NFTs
-	//
-
-	// Error case: non-existent NFT.
-	{
-		fakePostHash := &BlockHash{
-			0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
-			0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
-			0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1}
-
-		_, _, _, err := _createNFTBid(
-			t, chain, db, params, 10,
-			m0Pub,
-			m0Priv,
-			fakePostHash,
-			1,          /*SerialNumber*/
-			1000000000, /*BidAmountNanos*/
-		)
-		require.Error(err)
-		require.Contains(err.Error(), RuleErrorNFTBidOnNonExistentPost)
+			10,            /*feeRateNanosPerKB*/
+			m0Pub,         /*updaterPkBase58Check*/
+			m0Priv,        /*updaterPrivBase58Check*/
+			[]byte{},      /*profilePubKey*/
+			"m2",          /*newUsername*/
+			"i am the m2", /*newDescription*/
+			shortPic,      /*newProfilePic*/
+			10*100,        /*newCreatorBasisPoints*/
+			1.25*100*100,  /*newStakeMultipleBasisPoints*/
+			false /*isHidden*/)
 	}
 
-	// Have m0 create another post that has not been NFTed.
+	// Error case: m0 cannot turn a vanilla repost of their post into an NFT.
 	{
 		_submitPostWithTestMeta(
 			testMeta,
-			10,                                 /*feeRateNanosPerKB*/
-			m0Pub,                              /*updaterPkBase58Check*/
-			m0Priv,                             /*updaterPrivBase58Check*/
-			[]byte{},                           /*postHashToModify*/
-			[]byte{},                           /*parentStakeID*/
-			&DeSoBodySchema{Body: "m0 post 3"}, /*body*/
-			[]byte{},
-			1502947013*1e9, /*tstampNanos*/
+			10,                /*feeRateNanosPerKB*/
+			m0Pub,             /*updaterPkBase58Check*/
+			m0Priv,            /*updaterPrivBase58Check*/
+			[]byte{},          /*postHashToModify*/
+			[]byte{},          /*parentStakeID*/
+			&DeSoBodySchema{}, /*body*/
+			post1Hash[:],      /*repostedPostHash*/
+			1502947011*1e9,    /*tstampNanos*/
 			false /*isHidden*/)
-	}
-	post3Hash := testMeta.txns[len(testMeta.txns)-1].Hash()
 
-	// Error case: cannot bid on a post that is not an NFT.
-	{
-		_, _, _, err := _createNFTBid(
+		vanillaRepostPostHash := testMeta.txns[len(testMeta.txns)-1].Hash()
+		_, _, _, err := _createNFT(
 			t, chain, db, params, 10,
-			m1Pub,
-			m1Priv,
-			post3Hash,
-			1,          /*SerialNumber*/
-			1000000000, /*BidAmountNanos*/
+			m0Pub,
+			m0Priv,
+			vanillaRepostPostHash,
+			100,   /*NumCopies*/
+			false, /*HasUnlockable*/
+			true,  /*IsForSale*/
+			0,     /*MinBidAmountNanos*/
+			0,     /*nftFee*/
+			0,     /*nftRoyaltyToCreatorBasisPoints*/
+			0,     /*nftRoyaltyToCoinBasisPoints*/
+			false,
+			0,
 		)
+
 		require.Error(err)
-		require.Contains(err.Error(), RuleErrorNFTBidOnPostThatIsNotAnNFT)
+		require.Contains(err.Error(), RuleErrorCreateNFTOnVanillaRepost)
 	}
 
-	// Error case: Bidding on a serial number that does not exist should fail (post1 has 5 copies).
+	// Error case: m1 should not be able to turn m0's post into an NFT.
 	{
-		_, _, _, err = _createNFTBid(
+		_, _, _, err := _createNFT(
 			t, chain, db, params, 10,
 			m1Pub,
 			m1Priv,
 			post1Hash,
-			6,          /*SerialNumber*/
-			1000000000, /*BidAmountNanos*/
+			100,   /*NumCopies*/
+			false, /*HasUnlockable*/
+			true,  /*IsForSale*/
+			0,     /*MinBidAmountNanos*/
+			0,     /*nftFee*/
+			0,     /*nftRoyaltyToCreatorBasisPoints*/
+			0,     /*nftRoyaltyToCoinBasisPoints*/
+			false,
+			0,
 		)
+
 		require.Error(err)
-		require.Contains(err.Error(), RuleErrorNFTBidOnInvalidSerialNumber)
+		require.Contains(err.Error(), RuleErrorCreateNFTMustBeCalledByPoster)
 	}
 
-	// Error case: cannot make a bid with a sufficient deso balance to fill the bid.
+	// Error case: m0 should not be able to make more than MaxCopiesPerNFT.
 	{
-		_, _, _, err = _createNFTBid(
+		_, _, _, err := _createNFT(
 			t, chain, db, params, 10,
-			m1Pub,
-			m1Priv,
+			m0Pub,
+			m0Priv,
 			post1Hash,
-			1,          /*SerialNumber*/
-			1000000000, /*BidAmountNanos*/
+			1001,  /*NumCopies*/
+			false, /*HasUnlockable*/
+			true,  /*IsForSale*/
+			0,     /*MinBidAmountNanos*/
+			0,     /*nftFee*/
+			0,     /*nftRoyaltyToCreatorBasisPoints*/
+			0,     /*nftRoyaltyToCoinBasisPoints*/
+			false,
+			0,
 		)
+
 		require.Error(err)
-		require.Contains(err.Error(), RuleErrorInsufficientFundsForNFTBid)
+		require.Contains(err.Error(), RuleErrorTooManyNFTCopies)
 	}
 
-	// Error case: m0 cannot bid on its own NFT.
+	// Error case: m0 should not be able to make an NFT with zero copies.
 	{
-		_, _, _, err := _createNFTBid(
+		_, _, _, err := _createNFT(
 			t, chain, db, params, 10,
 			m0Pub,
 			m0Priv,
 			post1Hash,
-			1,          /*SerialNumber*/
-			1000000000, /*BidAmountNanos*/
+			0,     /*NumCopies*/
+			false, /*HasUnlockable*/
+			true,  /*IsForSale*/
+			0,     /*MinBidAmountNanos*/
+			0,     /*nftFee*/
+			0,     /*nftRoyaltyToCreatorBasisPoints*/
+			0,     /*nftRoyaltyToCoinBasisPoints*/
+			false,
+			0,
 		)
+
 		require.Error(err)
-		require.Contains(err.Error(), RuleErrorNFTOwnerCannotBidOnOwnedNFT)
+		require.Contains(err.Error(), RuleErrorNFTMustHaveNonZeroCopies)
 	}
 
-	// Have m1 and m2 bid on post #1 / serial #1.
+	// Error case: non-existent post.
 	{
-		bidEntries := DBGetNFTBidEntries(db, post1Hash, 1)
-		require.Equal(0, len(bidEntries))
-
-		_createNFTBidWithTestMeta(
-			testMeta,
-			10, /*FeeRateNanosPerKB*/
-			m1Pub,
-			m1Priv,
-			post1Hash,
-			1, /*SerialNumber*/
-			1, /*BidAmountNanos*/
-		)
-
-		bidEntries = DBGetNFTBidEntries(db, post1Hash, 1)
-		require.Equal(1, len(bidEntries))
-
-		_createNFTBidWithTestMeta(
-			testMeta,
-			10, /*FeeRateNanosPerKB*/
-			m2Pub,
-			m2Priv,
-			post1Hash,
-			1, /*SerialNumber*/
-			2, /*BidAmountNanos*/
-		)
 
-		bidEntries = DBGetNFTBidEntries(db, post1Hash, 1)
-		require.Equal(2, len(bidEntries))
-	}
+		fakePostHash := &BlockHash{
+			0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
+			0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
+			0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1}
 
-	// Error case: m1 should not be able to accept or update m0's NFTs.
-	{
-		_, _, _, err := _updateNFT(
+		_, _, _, err := _createNFT(
 			t, chain, db, params, 10,
-			m1Pub,
-			m1Priv,
-			post1Hash,
-			1,     /*SerialNumber*/
-			false, /*IsForSale*/
+			m0Pub,
+			m0Priv,
+			fakePostHash,
+			1,     /*NumCopies*/
+			false, /*HasUnlockable*/
+			true,  /*IsForSale*/
 			0,     /*MinBidAmountNanos*/
+			0,     /*nftFee*/
+			0,     /*nftRoyaltyToCreatorBasisPoints*/
+			0,     /*nftRoyaltyToCoinBasisPoints*/
 			false,
 			0,
 		)
-		require.Error(err)
-		require.Contains(err.Error(), RuleErrorUpdateNFTByNonOwner)
 
-		// m1 trying to be sneaky by accepting their own bid.
-		_, _, _, err = _acceptNFTBid(
-			t, chain, db, params, 10,
-			m1Pub,
-			m1Priv,
-			post1Hash,
-			1, /*SerialNumber*/
-			m1Pub,
-			1,  /*BidAmountNanos*/
-			"", /*UnlockableText*/
-		)
 		require.Error(err)
-		require.Contains(err.Error(), RuleErrorAcceptNFTBidByNonOwner)
+		require.Contains(err.Error(), RuleErrorCreateNFTOnNonexistentPost)
 	}
 
-	// Error case: accepting a bid that does not match the bid entry.
+	// Error case: can't set BuyNow to true with unlockable content
 	{
-		// m0 trying to be sneaky by setting m1's bid amount to 100x.
-		_, _, _, err = _acceptNFTBid(
+		_, _, _, err := _createNFT(
 			t, chain, db, params, 10,
 			m0Pub,
 			m0Priv,
 			post1Hash,
-			1, /*SerialNumber*/
-			m1Pub,
-			100, /*BidAmountNanos*/
-			"",  /*UnlockableText*/
+			1,    /*NumCopies*/
+			true, /*HasUnlockable*/
+			true, /*IsForSale*/
+			0,    /*MinBidAmountNanos*/
+			0,    /*nftFee*/
+			0,    /*nftRoyaltyToCreatorBasisPoints*/
+			0,    /*nftRoyaltyToCoinBasisPoints*/
+			true, /*IsBuyNow*/
+			0,
 		)
+
 		require.Error(err)
-		require.Contains(err.Error(), RuleErrorAcceptedNFTBidAmountDoesNotMatch)
+		require.Contains(err.Error(), RuleErrorCannotHaveUnlockableAndBuyNowNFT)
 	}
 
-	// Error case: can't accept a non-existent bid.
+	// Finally, have m0 turn post1 into an NFT. Woohoo!
 	{
-		// m3 has not bid on this NFT.
-		_, _, _, err = _acceptNFTBid(
-			t, chain, db, params, 10,
+		// Balance before.
+		m0BalBeforeNFT := _getBalance(t, chain, nil, m0Pub)
+		require.Equal(uint64(28), m0BalBeforeNFT)
+
+		_createNFTWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
 			m0Pub,
 			m0Priv,
 			post1Hash,
-			1, /*SerialNumber*/
-			m3Pub,
-			200, /*BidAmountNanos*/
-			"",  /*UnlockableText*/
+			5,     /*NumCopies*/
+			false, /*HasUnlockable*/
+			true,  /*IsForSale*/
+			0,     /*MinBidAmountNanos*/
+			0,     /*nftFee*/
+			0,     /*nftRoyaltyToCreatorBasisPoints*/
+			0,     /*nftRoyaltyToCoinBasisPoints*/
+			false,
+			0,
 		)
-		require.Error(err)
-		require.Contains(err.Error(), RuleErrorCantAcceptNonExistentBid)
+
+		// Balance after. Since the default NFT fee is 0, m0 is only charged the nanos per kb fee.
+		m0BalAfterNFT := _getBalance(testMeta.t, testMeta.chain, nil, m0Pub)
+		require.Equal(uint64(27), m0BalAfterNFT)
 	}
 
-	// Error case: can't accept or update a non-existent NFT.
+	// Error case: cannot turn a post into an NFT twice.
 	{
-		_, _, _, err := _updateNFT(
+		_, _, _, err := _createNFT(
 			t, chain, db, params, 10,
 			m0Pub,
 			m0Priv,
 			post1Hash,
-			666,   /*SerialNumber*/
-			false, /*IsForSale*/
+			5,     /*NumCopies*/
+			false, /*HasUnlockable*/
+			true,  /*IsForSale*/
 			0,     /*MinBidAmountNanos*/
+			0,     /*nftFee*/
+			0,     /*nftRoyaltyToCreatorBasisPoints*/
+			0,     /*nftRoyaltyToCoinBasisPoints*/
 			false,
 			0,
 		)
 		require.Error(err)
-		require.Contains(err.Error(), RuleErrorCannotUpdateNonExistentNFT)
+		require.Contains(err.Error(), RuleErrorCreateNFTOnPostThatAlreadyIsNFT)
+	}
 
-		_, _, _, err = _acceptNFTBid(
-			t, chain, db, params, 10,
+	// Error case: cannot modify a post after it is NFTed.
+	{
+		_, _, _, err := _submitPost(
+			testMeta.t, testMeta.chain, testMeta.db, testMeta.params,
+			10,
 			m0Pub,
 			m0Priv,
-			post1Hash,
-			666, /*SerialNumber*/
-			m1Pub,
-			1,  /*BidAmountNanos*/
-			"", /*UnlockableText*/
-		)
+			post1Hash[:],
+			[]byte{},
+			&DeSoBodySchema{Body: "modified m0 post"},
+			[]byte{},
+			1502947011*1e9,
+			false)
+
 		require.Error(err)
-		require.Contains(err.Error(), RuleErrorNFTBidOnNonExistentNFTEntry)
+		require.Contains(err.Error(), RuleErrorSubmitPostCannotUpdateNFT)
 	}
 
-	// Error case: can't submit an update txn that doesn't actually update anything.
+	// Now let's try adding a fee to creating NFT copies. This fee exists since creating
+	// n-copies of an NFT causes the chain to do n-times as much work.
 	{
-		// <post1, #1> is already for sale.
-		_, _, _, err := _updateNFT(
+		_updateGlobalParamsEntryWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m3Pub,
+			m3Priv,
+			-1, -1, -1,
+			1,  /*createNFTFeeNanos*/
+			-1, /*maxCopiesPerNFT*/
+		)
+	}
+
+	// Have m0 create another post for us to NFTify.
+	{
+		_submitPostWithTestMeta(
+			testMeta,
+			10,                                 /*feeRateNanosPerKB*/
+			m0Pub,                              /*updaterPkBase58Check*/
+			m0Priv,                             /*updaterPrivBase58Check*/
+			[]byte{},                           /*postHashToModify*/
+			[]byte{},                           /*parentStakeID*/
+			&DeSoBodySchema{Body: "m0 post 2"}, /*body*/
+			[]byte{},
+			1502947012*1e9, /*tstampNanos*/
+			false /*isHidden*/)
+	}
+	post2Hash := testMeta.txns[len(testMeta.txns)-1].Hash()
+
+	// Error case: creating an NFT without paying the nftFee should fail.
+	{
+		_, _, _, err := _createNFT(
 			t, chain, db, params, 10,
 			m0Pub,
 			m0Priv,
-			post1Hash,
-			1,    /*SerialNumber*/
-			true, /*IsForSale*/
-			0,    /*MinBidAmountNanos*/
+			post2Hash,
+			1000,  /*NumCopies*/
+			false, /*HasUnlockable*/
+			true,  /*IsForSale*/
+			0,     /*MinBidAmountNanos*/
+			0,     /*nftFee*/
+			0,     /*nftRoyaltyToCreatorBasisPoints*/
+			0,     /*nftRoyaltyToCoinBasisPoints*/
 			false,
 			0,
 		)
 		require.Error(err)
-		require.Contains(err.Error(), RuleErrorNFTUpdateMustUpdateIsForSaleStatus)
+		require.Contains(err.Error(), RuleErrorCreateNFTWithInsufficientFunds)
 	}
 
-	// Finally, accept m2's bid on <post1, #1>.
+	// Creating an NFT with the correct NFT fee should succeed.
+	// This time set HasUnlockable to 'true'.
 	{
-		_acceptNFTBidWithTestMeta(
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+
+		numCopies := uint64(10)
+		nftFee := utxoView.GlobalParamsEntry.CreateNFTFeeNanos * numCopies
+
+		m0BalBeforeNFT := _getBalance(testMeta.t, testMeta.chain, nil, m0Pub)
+		require.Equal(uint64(26), m0BalBeforeNFT)
+
+		_createNFTWithTestMeta(
 			testMeta,
 			10, /*FeeRateNanosPerKB*/
 			m0Pub,
 			m0Priv,
-			post1Hash,
-			1, /*SerialNumber*/
-			m2Pub,
-			2,  /*BidAmountNanos*/
-			"", /*UnlockableText*/
+			post2Hash,
+			10,     /*NumCopies*/
+			true,   /*HasUnlockable*/
+			true,   /*IsForSale*/
+			0,      /*MinBidAmountNanos*/
+			nftFee, /*nftFee*/
+			0,      /*nftRoyaltyToCreatorBasisPoints*/
+			0,      /*nftRoyaltyToCoinBasisPoints*/
+			false,
+			0,
 		)
-		bidEntries := DBGetNFTBidEntries(db, post1Hash, 1)
-		require.Equal(0, len(bidEntries))
+
+		// Check that m0 was charged the correct nftFee.
+		m0BalAfterNFT := _getBalance(testMeta.t, testMeta.chain, nil, m0Pub)
+		require.Equal(uint64(25)-nftFee, m0BalAfterNFT)
 	}
 
-	// Update <post1, #2>, so that it is no longer for sale.
+	//
+	// Bidding on NFTs
+	//
+
+	// Error case: non-existent NFT.
 	{
-		_updateNFTWithTestMeta(
-			testMeta,
-			10, /*FeeRateNanosPerKB*/
+		fakePostHash := &BlockHash{
+			0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
+			0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
+			0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1}
+
+		_, _, _, err := _createNFTBid(
+			t, chain, db, params, 10,
 			m0Pub,
 			m0Priv,
-			post1Hash,
-			2,     /*SerialNumber*/
-			false, /*IsForSale*/
-			0,     /*MinBidAmountNanos*/
-			false,
-			0,
+			fakePostHash,
+			1,          /*SerialNumber*/
+			1000000000, /*BidAmountNanos*/
 		)
-		bidEntries := DBGetNFTBidEntries(db, post1Hash, 2)
-		require.Equal(0, len(bidEntries))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTBidOnNonExistentPost)
 	}
 
-	// Error case: <post1, #1> and <post1, #2> are no longer for sale and should not allow bids.
+	// Have m0 create another post that has not been NFTed.
+	{
+		_submitPostWithTestMeta(
+			testMeta,
+			10,                                 /*feeRateNanosPerKB*/
+			m0Pub,                              /*updaterPkBase58Check*/
+			m0Priv,                             /*updaterPrivBase58Check*/
+			[]byte{},                           /*postHashToModify*/
+			[]byte{},                           /*parentStakeID*/
+			&DeSoBodySchema{Body: "m0 post 3"}, /*body*/
+			[]byte{},
+			1502947013*1e9, /*tstampNanos*/
+			false /*isHidden*/)
+	}
+	post3Hash := testMeta.txns[len(testMeta.txns)-1].Hash()
+
+	// Error case: cannot bid on a post that is not an NFT.
 	{
 		_, _, _, err := _createNFTBid(
 			t, chain, db, params, 10,
 			m1Pub,
 			m1Priv,
-			post1Hash,
+			post3Hash,
 			1,          /*SerialNumber*/
 			1000000000, /*BidAmountNanos*/
 		)
 		require.Error(err)
-		require.Contains(err.Error(), RuleErrorNFTBidOnNFTThatIsNotForSale)
+		require.Contains(err.Error(), RuleErrorNFTBidOnPostThatIsNotAnNFT)
+	}
 
+	// Error case: Bidding on a serial number that does not exist should fail (post1 has 5 copies).
+	{
 		_, _, _, err = _createNFTBid(
 			t, chain, db, params, 10,
 			m1Pub,
 			m1Priv,
 			post1Hash,
-			2,          /*SerialNumber*/
+			6,          /*SerialNumber*/
 			1000000000, /*BidAmountNanos*/
 		)
 		require.Error(err)
-		require.Contains(err.Error(), RuleErrorNFTBidOnNFTThatIsNotForSale)
+		require.Contains(err.Error(), RuleErrorNFTBidOnInvalidSerialNumber)
 	}
 
-	// Have m1, m2, and m3 bid on <post #2, #1> (which has an unlockable).
+	// Error case: cannot make a bid with a sufficient deso balance to fill the bid.
 	{
-		bidEntries := DBGetNFTBidEntries(db, post2Hash, 1)
+		_, _, _, err = _createNFTBid(
+			t, chain, db, params, 10,
+			m1Pub,
+			m1Priv,
+			post1Hash,
+			1,          /*SerialNumber*/
+			1000000000, /*BidAmountNanos*/
+		)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorInsufficientFundsForNFTBid)
+	}
+
+	// Error case: m0 cannot bid on its own NFT.
+	{
+		_, _, _, err := _createNFTBid(
+			t, chain, db, params, 10,
+			m0Pub,
+			m0Priv,
+			post1Hash,
+			1,          /*SerialNumber*/
+			1000000000, /*BidAmountNanos*/
+		)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTOwnerCannotBidOnOwnedNFT)
+	}
+
+	// Have m1 and m2 bid on post #1 / serial #1.
+	{
+		bidEntries := DBGetNFTBidEntries(db, post1Hash, 1)
 		require.Equal(0, len(bidEntries))
 
 		_createNFTBidWithTestMeta(
@@ -1439,12 +1612,12 @@ func TestNFTBasic(t *testing.T) {
 			10, /*FeeRateNanosPerKB*/
 			m1Pub,
 			m1Priv,
-			post2Hash,
+			post1Hash,
 			1, /*SerialNumber*/
-			5, /*BidAmountNanos*/
+			1, /*BidAmountNanos*/
 		)
 
-		bidEntries = DBGetNFTBidEntries(db, post2Hash, 1)
+		bidEntries = DBGetNFTBidEntries(db, post1Hash, 1)
 		require.Equal(1, len(bidEntries))
 
 		_createNFTBidWithTestMeta(
@@ -1452,9 +1625,214 @@ func TestNFTBasic(t *testing.T) {
 			10, /*FeeRateNanosPerKB*/
 			m2Pub,
 			m2Priv,
-			post2Hash,
-			1,  /*SerialNumber*/
-			10, /*BidAmountNanos*/
+			post1Hash,
+			1, /*SerialNumber*/
+			2, /*BidAmountNanos*/
+		)
+
+		bidEntries = DBGetNFTBidEntries(db, post1Hash, 1)
+		require.Equal(2, len(bidEntries))
+	}
+
+	// Error case: m1 should not be able to accept or update m0's NFTs.
+	{
+		_, _, _, err := _updateNFT(
+			t, chain, db, params, 10,
+			m1Pub,
+			m1Priv,
+			post1Hash,
+			1,     /*SerialNumber*/
+			false, /*IsForSale*/
+			0,     /*MinBidAmountNanos*/
+			false,
+			0,
+		)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUpdateNFTByNonOwner)
+
+		// m1 trying to be sneaky by accepting their own bid.
+		_, _, _, err = _acceptNFTBid(
+			t, chain, db, params, 10,
+			m1Pub,
+			m1Priv,
+			post1Hash,
+			1, /*SerialNumber*/
+			m1Pub,
+			1,  /*BidAmountNanos*/
+			"", /*UnlockableText*/
+		)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorAcceptNFTBidByNonOwner)
+	}
+
+	// Error case: accepting a bid that does not match the bid entry.
+	{
+		// m0 trying to be sneaky by setting m1's bid amount to 100x.
+		_, _, _, err = _acceptNFTBid(
+			t, chain, db, params, 10,
+			m0Pub,
+			m0Priv,
+			post1Hash,
+			1, /*SerialNumber*/
+			m1Pub,
+			100, /*BidAmountNanos*/
+			"",  /*UnlockableText*/
+		)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorAcceptedNFTBidAmountDoesNotMatch)
+	}
+
+	// Error case: can't accept a non-existent bid.
+	{
+		// m3 has not bid on this NFT.
+		_, _, _, err = _acceptNFTBid(
+			t, chain, db, params, 10,
+			m0Pub,
+			m0Priv,
+			post1Hash,
+			1, /*SerialNumber*/
+			m3Pub,
+			200, /*BidAmountNanos*/
+			"",  /*UnlockableText*/
+		)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorCantAcceptNonExistentBid)
+	}
+
+	// Error case: can't accept or update a non-existent NFT.
+	{
+		_, _, _, err := _updateNFT(
+			t, chain, db, params, 10,
+			m0Pub,
+			m0Priv,
+			post1Hash,
+			666,   /*SerialNumber*/
+			false, /*IsForSale*/
+			0,     /*MinBidAmountNanos*/
+			false,
+			0,
+		)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorCannotUpdateNonExistentNFT)
+
+		_, _, _, err = _acceptNFTBid(
+			t, chain, db, params, 10,
+			m0Pub,
+			m0Priv,
+			post1Hash,
+			666, /*SerialNumber*/
+			m1Pub,
+			1,  /*BidAmountNanos*/
+			"", /*UnlockableText*/
+		)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTBidOnNonExistentNFTEntry)
+	}
+
+	// Error case: can't submit an update txn that doesn't actually update anything.
+	{
+		// <post1, #1> is already for sale.
+		_, _, _, err := _updateNFT(
+			t, chain, db, params, 10,
+			m0Pub,
+			m0Priv,
+			post1Hash,
+			1,    /*SerialNumber*/
+			true, /*IsForSale*/
+			0,    /*MinBidAmountNanos*/
+			false,
+			0,
+		)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTUpdateMustUpdateIsForSaleStatus)
+	}
+
+	// Finally, accept m2's bid on <post1, #1>.
+	{
+		_acceptNFTBidWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m0Pub,
+			m0Priv,
+			post1Hash,
+			1, /*SerialNumber*/
+			m2Pub,
+			2,  /*BidAmountNanos*/
+			"", /*UnlockableText*/
+		)
+		bidEntries := DBGetNFTBidEntries(db, post1Hash, 1)
+		require.Equal(0, len(bidEntries))
+	}
+
+	// Update <post1, #2>, so that it is no longer for sale.
+	{
+		_updateNFTWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m0Pub,
+			m0Priv,
+			post1Hash,
+			2,     /*SerialNumber*/
+			false, /*IsForSale*/
+			0,     /*MinBidAmountNanos*/
+			false,
+			0,
+		)
+		bidEntries := DBGetNFTBidEntries(db, post1Hash, 2)
+		require.Equal(0, len(bidEntries))
+	}
+
+	// Error case: <post1, #1> and <post1, #2> are no longer for sale and should not allow bids.
+	{
+		_, _, _, err := _createNFTBid(
+			t, chain, db, params, 10,
+			m1Pub,
+			m1Priv,
+			post1Hash,
+			1,          /*SerialNumber*/
+			1000000000, /*BidAmountNanos*/
+		)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTBidOnNFTThatIsNotForSale)
+
+		_, _, _, err = _createNFTBid(
+			t, chain, db, params, 10,
+			m1Pub,
+			m1Priv,
+			post1Hash,
+			2,          /*SerialNumber*/
+			1000000000, /*BidAmountNanos*/
+		)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTBidOnNFTThatIsNotForSale)
+	}
+
+	// Have m1, m2, and m3 bid on <post #2, #1> (which has an unlockable).
+	{
+		bidEntries := DBGetNFTBidEntries(db, post2Hash, 1)
+		require.Equal(0, len(bidEntries))
+
+		_createNFTBidWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m1Pub,
+			m1Priv,
+			post2Hash,
+			1, /*SerialNumber*/
+			5, /*BidAmountNanos*/
+		)
+
+		bidEntries = DBGetNFTBidEntries(db, post2Hash, 1)
+		require.Equal(1, len(bidEntries))
+
+		_createNFTBidWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m2Pub,
+			m2Priv,
+			post2Hash,
+			1,  /*SerialNumber*/
+			10, /*BidAmountNanos*/
 		)
 
 		bidEntries = DBGetNFTBidEntries(db, post2Hash, 1)
@@ -6580,22 +6958,723 @@ func TestNFTSplits(t *testing.T) {
 				additionalCoinRoyaltyMap,
 			)
 
-			require.Error(err)
-			require.Contains(err.Error(), RuleErrorNFTRoyaltyHasTooManyBasisPoints)
-		}
+			require.Error(err)
+			require.Contains(err.Error(), RuleErrorNFTRoyaltyHasTooManyBasisPoints)
+		}
+	}
+
+	// Create NFT with 10% royalty to creator, 20% to coin, 5% to m1's coin and 1% to m2
+	{
+		// Balance before.
+		m0BalBeforeNFT := _getBalance(t, chain, nil, m0Pub)
+		require.Equal(uint64(930), m0BalBeforeNFT)
+
+		additionalDESORoyaltyMap := make(map[PublicKey]uint64)
+		additionalDESORoyaltyMap[*NewPublicKey(m2PkBytes)] = 100
+
+		additionalCoinRoyaltyMap := make(map[PublicKey]uint64)
+		additionalCoinRoyaltyMap[*NewPublicKey(m1PkBytes)] = 500
+
+		_createNFTWithAdditionalRoyaltiesWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m0Pub,
+			m0Priv,
+			post1Hash,
+			100,    /*NumCopies*/
+			false,  /*HasUnlockable*/
+			true,   /*IsForSale*/
+			0,      /*MinBidAmountNanos*/
+			0,      /*nftFee*/
+			10*100, /*nftRoyaltyToCreatorBasisPoints*/
+			20*100, /*nftRoyaltyToCoinBasisPoints*/
+			false,  /*IsBuyNow*/
+			0,
+			additionalDESORoyaltyMap,
+			additionalCoinRoyaltyMap,
+		)
+
+		// Balance after. Since the default NFT fee is 0, m0 is only charged the nanos per kb fee.
+		m0BalAfterNFT := _getBalance(t, testMeta.chain, nil, m0Pub)
+		require.Equal(int64(928), int64(m0BalAfterNFT))
+	}
+
+	// Have m3 bid on serial #1. Have m0 accept bid.
+	{
+		bidAmountNanos := uint64(100)
+		// Creator Balance before.
+		m0BalBefore := _getBalance(t, chain, nil, m0Pub)
+		require.Equal(int64(928), int64(m0BalBefore))
+
+		// Bidder Balance before.
+		m3BalBefore := _getBalance(t, chain, nil, m3Pub)
+		require.Equal(int64(1000), int64(m3BalBefore))
+
+		// Creator's DESO locked before
+		m0DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+
+		// M1's DESO locked before
+		m1DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m1Pub)
+
+		// M2's balance before
+		m2BalBefore := _getBalance(t, chain, nil, m2Pub)
+		require.Equal(int64(961), int64(m2BalBefore))
+
+		// There are no bids before.
+		bidEntries := DBGetNFTBidEntries(db, post1Hash, 1)
+		require.Equal(0, len(bidEntries))
+
+		// M3 bids 100 on this NFT.
+		_createNFTBidWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m3Pub,
+			m3Priv,
+			post1Hash,
+			1,              /*SerialNumber*/
+			bidAmountNanos, /*BidAmountNanos*/
+		)
+
+		m3BalAfterBid := _getBalance(t, chain, nil, m3Pub)
+		require.Equal(int64(999), int64(m3BalAfterBid))
+
+		bidEntries = DBGetNFTBidEntries(db, post1Hash, 1)
+		require.Equal(1, len(bidEntries))
+
+		//nftEntry := DBGetNFTEntryByPostHashSerialNumber(db, post1Hash, 1)
+		_acceptNFTBidWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m0Pub,
+			m0Priv,
+			post1Hash,
+			1,     /*SerialNumber*/
+			m3Pub, /*bidderPkBase58Check*/
+			bidAmountNanos,
+			"", /*UnencryptedUnlockableText*/
+		)
+
+		// Check royalties. 10% for the creator, 10% for the coin, 5% to m1's coin, and 1% to m2's wallet
+		m0BalAfter := _getBalance(t, chain, nil, m0Pub)
+		m2BalAfter := _getBalance(t, chain, nil, m2Pub)
+
+		// Creator royalty = 10, coin royalty = 20, m1's coin royalty = 5, m2's royalty = 1
+		expectedCreatorRoyalty := bidAmountNanos / 10
+		require.Equal(int64(10), int64(expectedCreatorRoyalty))
+		expectedCoinRoyalty := bidAmountNanos / 5
+		require.Equal(int64(20), int64(expectedCoinRoyalty))
+		expectedM1CoinRoyalty := bidAmountNanos / 20
+		require.Equal(int64(5), int64(expectedM1CoinRoyalty))
+		expectedM2Royalty := bidAmountNanos / 100
+		require.Equal(int64(1), int64(expectedM2Royalty))
+		// Check creator's balance
+		bidAmountMinusRoyalties := bidAmountNanos - expectedCoinRoyalty - expectedCreatorRoyalty - expectedM1CoinRoyalty - expectedM2Royalty
+		require.Equal(m0BalBefore-2+bidAmountMinusRoyalties+expectedCreatorRoyalty, m0BalAfter)
+		require.Equal(int64(1000), int64(m0BalAfter))
+		// Check m2's balance
+		require.Equal(m2BalBefore+expectedM2Royalty, m2BalAfter)
+		require.Equal(int64(962), int64(m2BalAfter))
+		// Make sure that the bidder's balance decreased by the bid amount.
+		m3BalAfter := _getBalance(t, chain, nil, m3Pub)
+		require.Equal(m3BalAfterBid-bidAmountNanos, m3BalAfter)
+		require.Equal(int64(899), int64(m3BalAfter))
+
+		// Check coin royalties
+		desoLocked, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+		require.Equal(m0DeSoLockedBefore+expectedCoinRoyalty, desoLocked)
+
+		desoLockedM1, _ := _getCreatorCoinInfo(t, db, params, m1Pub)
+		require.Equal(m1DeSoLockedBefore+expectedM1CoinRoyalty, desoLockedM1)
+	}
+
+	// M3 puts it on sale and m2 buys it. Test that when additional royalty is paid to bidder that things work as
+	// expected.
+	{
+		// m3 puts it on sale as buy now at 200 nanos
+		_updateNFTWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m3Pub,
+			m3Priv,
+			post1Hash,
+			1,    /*SerialNumber*/
+			true, /*IsForSale*/
+			100,  /*MinBidAmountNanos*/
+			true, /*IsBuyNow*/
+			200,
+		)
+
+		// M2 bids 200 to buy the NFT outright.
+		bidAmountNanos := uint64(200)
+		// Creator Balance before.
+		m0BalBefore := _getBalance(t, chain, nil, m0Pub)
+		require.Equal(int64(1000), int64(m0BalBefore))
+
+		// Bidder/m2 Balance before.
+		m2BalBefore := _getBalance(t, chain, nil, m2Pub)
+		require.Equal(int64(962), int64(m2BalBefore))
+
+		// Seller balance before
+		m3BalBefore := _getBalance(t, chain, nil, m3Pub)
+		require.Equal(int64(897), int64(m3BalBefore))
+
+		// Creator's DESO locked before
+		m0DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+
+		// M1's DESO locked before
+		m1DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m1Pub)
+
+		// There are no bids before.
+		bidEntries := DBGetNFTBidEntries(db, post1Hash, 1)
+		require.Equal(0, len(bidEntries))
+
+		// M2 buys this NFT for 200.
+		_createNFTBidWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m2Pub,
+			m2Priv,
+			post1Hash,
+			1,              /*SerialNumber*/
+			bidAmountNanos, /*BidAmountNanos*/
+		)
+
+		bidEntries = DBGetNFTBidEntries(db, post1Hash, 1)
+		require.Equal(0, len(bidEntries))
+
+		// Check royalties. 10% for the creator, 20% for the coin, 5% to m1's coin, and 1% to m2's wallet
+		m0BalAfter := _getBalance(t, chain, nil, m0Pub)
+		m2BalAfter := _getBalance(t, chain, nil, m2Pub)
+
+		// Creator royalty = 20, coin royalty = 40, m1's coin royalty = 10, m2's royalty = 2
+		expectedCreatorRoyalty := bidAmountNanos / 10
+		require.Equal(int64(20), int64(expectedCreatorRoyalty))
+		expectedCoinRoyalty := bidAmountNanos / 5
+		require.Equal(int64(40), int64(expectedCoinRoyalty))
+		expectedM1CoinRoyalty := bidAmountNanos / 20
+		require.Equal(int64(10), int64(expectedM1CoinRoyalty))
+		expectedM2Royalty := bidAmountNanos / 100
+		require.Equal(int64(2), int64(expectedM2Royalty))
+		// Check creator's balance
+		bidAmountMinusRoyalties := bidAmountNanos - expectedCoinRoyalty - expectedCreatorRoyalty - expectedM1CoinRoyalty - expectedM2Royalty
+		require.Equal(m0BalBefore+expectedCreatorRoyalty, m0BalAfter)
+		require.Equal(int64(1020), int64(m0BalAfter))
+		// Check m2's balance - m2 is the buyer but also receives a royalty (and pays 2 nanos in fees for the bid)
+		require.Equal(m2BalBefore+expectedM2Royalty-bidAmountNanos-2, m2BalAfter)
+		require.Equal(int64(762), int64(m2BalAfter))
+		// Make sure that the seller's balance decreased by the bid amount minus royalties.
+		m3BalAfter := _getBalance(t, chain, nil, m3Pub)
+		require.Equal(m3BalBefore+bidAmountMinusRoyalties, m3BalAfter)
+		require.Equal(int64(1025), int64(m3BalAfter))
+
+		// Check coin royalties
+		desoLocked, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+		require.Equal(m0DeSoLockedBefore+expectedCoinRoyalty, desoLocked)
+
+		desoLockedM1, _ := _getCreatorCoinInfo(t, db, params, m1Pub)
+		require.Equal(m1DeSoLockedBefore+expectedM1CoinRoyalty, desoLockedM1)
+	}
+
+	// M2 puts it on sale and m0 buys it. test that creator buying it still works properly and
+	// seller = additional DESO royalty works.
+	{
+		// m2 puts it on sale as buy now at 500 nanos
+		_updateNFTWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m2Pub,
+			m2Priv,
+			post1Hash,
+			1,    /*SerialNumber*/
+			true, /*IsForSale*/
+			100,  /*MinBidAmountNanos*/
+			true, /*IsBuyNow*/
+			500,
+		)
+
+		// M0 bids 500 to buy the NFT outright.
+		bidAmountNanos := uint64(500)
+		// Creator/Bidder Balance before.
+		m0BalBefore := _getBalance(t, chain, nil, m0Pub)
+		require.Equal(int64(1020), int64(m0BalBefore))
+
+		// Seller/m2 Balance before.
+		m2BalBefore := _getBalance(t, chain, nil, m2Pub)
+		require.Equal(int64(760), int64(m2BalBefore))
+
+		// Creator's DESO locked before
+		m0DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+
+		// M1's DESO locked before
+		m1DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m1Pub)
+
+		// There are no bids before.
+		bidEntries := DBGetNFTBidEntries(db, post1Hash, 1)
+		require.Equal(0, len(bidEntries))
+
+		// M0 buys this NFT for 500.
+		_createNFTBidWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m0Pub,
+			m0Priv,
+			post1Hash,
+			1,              /*SerialNumber*/
+			bidAmountNanos, /*BidAmountNanos*/
+		)
+
+		bidEntries = DBGetNFTBidEntries(db, post1Hash, 1)
+		require.Equal(0, len(bidEntries))
+
+		// Check royalties. 10% for the creator, 20% for the coin, 5% to m1's coin, and 1% to m2's wallet
+		m0BalAfter := _getBalance(t, chain, nil, m0Pub)
+		m2BalAfter := _getBalance(t, chain, nil, m2Pub)
+
+		// Creator royalty = 50, coin royalty = 100, m1's coin royalty = 25, m2's royalty = 5
+		expectedCreatorRoyalty := bidAmountNanos / 10
+		require.Equal(int64(50), int64(expectedCreatorRoyalty))
+		expectedCoinRoyalty := bidAmountNanos / 5
+		require.Equal(int64(100), int64(expectedCoinRoyalty))
+		expectedM1CoinRoyalty := bidAmountNanos / 20
+		require.Equal(int64(25), int64(expectedM1CoinRoyalty))
+		expectedM2Royalty := bidAmountNanos / 100
+		require.Equal(int64(5), int64(expectedM2Royalty))
+		// Check creator's balance
+		bidAmountMinusRoyalties := bidAmountNanos - expectedCoinRoyalty - expectedCreatorRoyalty - expectedM1CoinRoyalty - expectedM2Royalty
+		require.Equal(m0BalBefore+expectedCreatorRoyalty-bidAmountNanos-2, m0BalAfter)
+		require.Equal(int64(568), int64(m0BalAfter))
+		// Check m2's balance - m2 is the seller and also receives a royalty
+		require.Equal(m2BalBefore+expectedM2Royalty+bidAmountMinusRoyalties, m2BalAfter)
+		require.Equal(int64(1085), int64(m2BalAfter))
+
+		// Check coin royalties
+		desoLocked, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+		require.Equal(m0DeSoLockedBefore+expectedCoinRoyalty, desoLocked)
+
+		desoLockedM1, _ := _getCreatorCoinInfo(t, db, params, m1Pub)
+		require.Equal(m1DeSoLockedBefore+expectedM1CoinRoyalty, desoLockedM1)
+	}
+
+	// Create a new post for testing.
+	{
+		_submitPostWithTestMeta(
+			testMeta,
+			10,                                 /*feeRateNanosPerKB*/
+			m0Pub,                              /*updaterPkBase58Check*/
+			m0Priv,                             /*updaterPrivBase58Check*/
+			[]byte{},                           /*postHashToModify*/
+			[]byte{},                           /*parentStakeID*/
+			&DeSoBodySchema{Body: "m0 post 2"}, /*body*/
+			[]byte{},
+			1502947012*1e9, /*tstampNanos*/
+			false /*isHidden*/)
+	}
+	post2Hash := testMeta.txns[len(testMeta.txns)-1].Hash()
+
+	// Create NFT with 10% royalty to creator, 20% to coin, 5% to m2's coin
+	{
+		// Balance before.
+		m0BalBeforeNFT := _getBalance(t, chain, nil, m0Pub)
+		require.Equal(int64(567), int64(m0BalBeforeNFT))
+
+		additionalCoinRoyaltyMap := make(map[PublicKey]uint64)
+		additionalCoinRoyaltyMap[*NewPublicKey(m2PkBytes)] = 500
+
+		_createNFTWithAdditionalRoyaltiesWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m0Pub,
+			m0Priv,
+			post2Hash,
+			100,    /*NumCopies*/
+			false,  /*HasUnlockable*/
+			true,   /*IsForSale*/
+			0,      /*MinBidAmountNanos*/
+			0,      /*nftFee*/
+			10*100, /*nftRoyaltyToCreatorBasisPoints*/
+			20*100, /*nftRoyaltyToCoinBasisPoints*/
+			true,   /*IsBuyNow*/
+			100,
+			nil,
+			additionalCoinRoyaltyMap,
+		)
+
+		// Balance after. Since the default NFT fee is 0, m0 is only charged the nanos per kb fee.
+		m0BalAfterNFT := _getBalance(t, testMeta.chain, nil, m0Pub)
+		require.Equal(int64(565), int64(m0BalAfterNFT))
+	}
+
+	// Have m3 buy it "now"
+	{
+		// M3 bids 100 to buy the NFT outright.
+		bidAmountNanos := uint64(100)
+		// Creator Balance before.
+		m0BalBefore := _getBalance(t, chain, nil, m0Pub)
+		require.Equal(int64(565), int64(m0BalBefore))
+
+		// Seller balance before
+		m3BalBefore := _getBalance(t, chain, nil, m3Pub)
+		require.Equal(int64(1025), int64(m3BalBefore))
+
+		// Creator's DESO locked before
+		m0DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+
+		// M2's DESO locked before -- should be 0
+		m2DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m2Pub)
+
+		// There are no bids before.
+		bidEntries := DBGetNFTBidEntries(db, post2Hash, 1)
+		require.Equal(0, len(bidEntries))
+
+		// M3 buys this NFT for 100.
+		_createNFTBidWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m3Pub,
+			m3Priv,
+			post2Hash,
+			1,              /*SerialNumber*/
+			bidAmountNanos, /*BidAmountNanos*/
+		)
+
+		// Check royalties. 10% for the creator, 20% for the coin, 5% to m2's coin
+		m0BalAfter := _getBalance(t, chain, nil, m0Pub)
+		m3BalAfter := _getBalance(t, chain, nil, m3Pub)
+
+		// Creator royalty = 10, coin royalty = 20, m2's coin royalty = 5
+		expectedCreatorRoyalty := bidAmountNanos / 10
+		require.Equal(int64(10), int64(expectedCreatorRoyalty))
+		expectedCoinRoyalty := bidAmountNanos / 5
+		require.Equal(int64(20), int64(expectedCoinRoyalty))
+		expectedM2CoinRoyalty := bidAmountNanos / 20
+		require.Equal(int64(5), int64(expectedM2CoinRoyalty))
+
+		// Check creator's balance
+		bidAmountMinusRoyalties := bidAmountNanos - expectedCoinRoyalty - expectedCreatorRoyalty - expectedM2CoinRoyalty
+		require.Equal(m0BalBefore+expectedCreatorRoyalty+bidAmountMinusRoyalties, m0BalAfter)
+		require.Equal(int64(640), int64(m0BalAfter))
+
+		// Check bidder's balance
+		require.Equal(m3BalBefore-bidAmountNanos-1, m3BalAfter)
+		require.Equal(int64(924), int64(m3BalAfter))
+
+		// Check coin royalties
+		desoLocked, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+		require.Equal(m0DeSoLockedBefore+expectedCoinRoyalty, desoLocked)
+
+		// Because there is no DeSo locked in M2's coin, we don't add to their DESO locked.
+		desoLockedM2, _ := _getCreatorCoinInfo(t, db, params, m2Pub)
+		require.Equal(m2DeSoLockedBefore, desoLockedM2)
+	}
+
+	// Have M2 buy some of their own coin so they can take advantage of the royalties
+	{
+		_creatorCoinTxnWithTestMeta(
+			testMeta,
+			10,     /*feeRateNanosPerKB*/
+			m2Pub,  /*updaterPkBase58Check*/
+			m2Priv, /*updaterPrivBase58Check*/
+			m2Pub,  /*profilePubKeyBase58Check*/
+			CreatorCoinOperationTypeBuy,
+			29, /*DeSoToSellNanos*/
+			0,  /*CreatorCoinToSellNanos*/
+			0,  /*DeSoToAddNanos*/
+			0,  /*MinDeSoExpectedNanos*/
+			10, /*MinCreatorCoinExpectedNanos*/
+		)
+
+		m2Bal := _getBalance(t, testMeta.chain, nil, m2Pub)
+		require.Equal(int64(1054), int64(m2Bal))
+	}
+
+	// Swap identity of m3 and m2.
+	// m2 now owns the NFT that m3 had.
+	// This will also cause m3's coin to wind up with m2's royalties.
+	params.ParamUpdaterPublicKeys[MakePkMapKey(paramUpdaterPkBytes)] = true
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, paramUpdaterPub, senderPrivString, 100)
+	_swapIdentityWithTestMeta(testMeta, 10, paramUpdaterPub, paramUpdaterPriv, m2PkBytes, m3PkBytes)
+
+	// Now M2 (formerly m3) puts it on sale and m4 buys this NFT and watch M2 get some royalties
+	{
+		// m2 puts it on sale as buy now at 200 nanos
+		_updateNFTWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m2Pub,
+			m2Priv,
+			post2Hash,
+			1,    /*SerialNumber*/
+			true, /*IsForSale*/
+			100,  /*MinBidAmountNanos*/
+			true, /*IsBuyNow*/
+			200,
+		)
+
+		// M2 bids 200 to buy the NFT outright.
+		bidAmountNanos := uint64(200)
+		// Creator Balance before.
+		m0BalBefore := _getBalance(t, chain, nil, m0Pub)
+		require.Equal(int64(640), int64(m0BalBefore))
+
+		// Seller Balance before.
+		m2BalBefore := _getBalance(t, chain, nil, m2Pub)
+		m3BalBefore := _getBalance(t, chain, nil, m3Pub)
+		require.Equal(int64(1052), int64(m2BalBefore))
+		require.Equal(int64(924), int64(m3BalBefore))
+
+		// Bidder balance before
+		m4BalBefore := _getBalance(t, chain, nil, m4Pub)
+		require.Equal(int64(999), int64(m4BalBefore))
+
+		// Creator's DESO locked before
+		m0DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+
+		// M2's DESO locked before
+		m2DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m2Pub)
+		m3DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m3Pub)
+
+		// There are no bids before.
+		bidEntries := DBGetNFTBidEntries(db, post1Hash, 1)
+		require.Equal(0, len(bidEntries))
+
+		// M4 buys this NFT for 100.
+		_createNFTBidWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m4Pub,
+			m4Priv,
+			post2Hash,
+			1,              /*SerialNumber*/
+			bidAmountNanos, /*BidAmountNanos*/
+		)
+
+		// Check royalties. 10% for the creator, 20% for the coin, 5% to m2's coin
+		m0BalAfter := _getBalance(t, chain, nil, m0Pub)
+		m2BalAfter := _getBalance(t, chain, nil, m2Pub)
+		m3BalAfter := _getBalance(t, chain, nil, m3Pub)
+		m4BalAfter := _getBalance(t, chain, nil, m4Pub)
+
+		// Creator royalty = 20, coin royalty = 40, m2's coin royalty = 10
+		expectedCreatorRoyalty := bidAmountNanos / 10
+		require.Equal(int64(20), int64(expectedCreatorRoyalty))
+		expectedCoinRoyalty := bidAmountNanos / 5
+		require.Equal(int64(40), int64(expectedCoinRoyalty))
+		expectedM2CoinRoyalty := bidAmountNanos / 20
+		require.Equal(int64(10), int64(expectedM2CoinRoyalty))
+
+		// Check creator's balance
+		bidAmountMinusRoyalties := bidAmountNanos - expectedCoinRoyalty - expectedCreatorRoyalty - expectedM2CoinRoyalty
+		require.Equal(m0BalBefore+expectedCreatorRoyalty, m0BalAfter)
+		require.Equal(int64(660), int64(m0BalAfter))
+
+		// Check seller's balance
+		require.Equal(m2BalBefore+bidAmountMinusRoyalties, m2BalAfter)
+		require.Equal(int64(1182), int64(m2BalAfter))
+
+		require.Equal(m3BalBefore, m3BalAfter)
+		require.Equal(int64(924), int64(m3BalAfter))
+
+		// Check bidder's balance
+		require.Equal(m4BalBefore-bidAmountNanos-1, m4BalAfter)
+		require.Equal(int64(798), int64(m4BalAfter))
+
+		// Check coin royalties
+		desoLocked, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+		require.Equal(m0DeSoLockedBefore+expectedCoinRoyalty, desoLocked)
+
+		desoLockedM2, _ := _getCreatorCoinInfo(t, db, params, m2Pub)
+		require.Equal(m2DeSoLockedBefore, desoLockedM2)
+
+		// Now that there is DESO locked in M2's coin, we can add the royalties to their coin
+		desoLockedM3, _ := _getCreatorCoinInfo(t, db, params, m3Pub)
+		require.Equal(m3DeSoLockedBefore+expectedM2CoinRoyalty, desoLockedM3)
+	}
+
+	// Roll all successful txns through connect and disconnect loops to make sure nothing breaks.
+	_rollBackTestMetaTxnsAndFlush(testMeta)
+	_applyTestMetaTxnsToMempool(testMeta)
+	_applyTestMetaTxnsToViewAndFlush(testMeta)
+	_disconnectTestMetaTxnsFromViewAndFlush(testMeta)
+	_connectBlockThenDisconnectBlockAndFlush(testMeta)
+}
+
+func TestNFTSplitsSerializers(t *testing.T) {
+	require := require.New(t)
+
+	mm := make(map[PublicKey]uint64)
+	mm[*NewPublicKey(m0PkBytes)] = 123456
+	mm[*NewPublicKey(m1PkBytes)] = 7890123
+	mm[*NewPublicKey(m2PkBytes)] = 34834983
+	bb, err := SerializePubKeyToUint64Map(mm)
+	require.NoError(err)
+
+	newMM, err := DeserializePubKeyToUint64Map(bb)
+	require.NoError(err)
+
+	require.Equal(mm, newMM)
+}
+
+// Set up this test to catch a very hardcore pkid/pubkey bug that only
+// showed up in prod.
+func TestNFTSplitsHardcorePKIDBug(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	// Make m3, m4 a paramUpdater for this test
+	params.ParamUpdaterPublicKeys[MakePkMapKey(m3PkBytes)] = true
+	params.ParamUpdaterPublicKeys[MakePkMapKey(m4PkBytes)] = true
+	params.ForkHeights.BuyNowAndNFTSplitsBlockHeight = uint32(0)
+	params.ForkHeights.NFTTransferOrBurnAndDerivedKeysBlockHeight = uint32(0)
+
+	// Mine a few blocks to give the senderPkString some money.
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	// We build the testMeta obj after mining blocks so that we save the correct block height.
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+
+	// Fund all the keys.
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 10000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 10000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m2Pub, senderPrivString, 10000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m3Pub, senderPrivString, 10000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m4Pub, senderPrivString, 10000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m5Pub, senderPrivString, 10000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m6Pub, senderPrivString, 10000)
+
+	//m4PKID := DBGetPKIDEntryForPublicKey(db, m4PkBytes)
+	//m5PKID := DBGetPKIDEntryForPublicKey(db, m5PkBytes)
+	//m6PKID := DBGetPKIDEntryForPublicKey(db, m6PkBytes)
+
+	// Set max copies to a non-zero value to activate NFTs.
+	{
+		_updateGlobalParamsEntryWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m4Pub,
+			m4Priv,
+			-1, -1, -1, -1,
+			1000, /*maxCopiesPerNFT*/
+		)
 	}
 
-	// Create NFT with 10% royalty to creator, 20% to coin, 5% to m1's coin and 1% to m2
+	// Create a post for testing.
 	{
-		// Balance before.
-		m0BalBeforeNFT := _getBalance(t, chain, nil, m0Pub)
-		require.Equal(uint64(930), m0BalBeforeNFT)
+		_submitPostWithTestMeta(
+			testMeta,
+			10,                                 /*feeRateNanosPerKB*/
+			m0Pub,                              /*updaterPkBase58Check*/
+			m0Priv,                             /*updaterPrivBase58Check*/
+			[]byte{},                           /*postHashToModify*/
+			[]byte{},                           /*parentStakeID*/
+			&DeSoBodySchema{Body: "m0 post 1"}, /*body*/
+			[]byte{},
+			1502947011*1e9, /*tstampNanos*/
+			false /*isHidden*/)
+	}
+	post1Hash := testMeta.txns[len(testMeta.txns)-1].Hash()
+
+	_ = post1Hash
+	// NFT the post.
+	{
+		// Create a profile for m0, m1, m2, m3, m4
+		_updateProfileWithTestMeta(
+			testMeta,
+			10,            /*feeRateNanosPerKB*/
+			m0Pub,         /*updaterPkBase58Check*/
+			m0Priv,        /*updaterPrivBase58Check*/
+			[]byte{},      /*profilePubKey*/
+			"m0",          /*newUsername*/
+			"i am the m0", /*newDescription*/
+			shortPic,      /*newProfilePic*/
+			10*100,        /*newCreatorBasisPoints*/
+			1.25*100*100,  /*newStakeMultipleBasisPoints*/
+			false /*isHidden*/)
+		_updateProfileWithTestMeta(
+			testMeta,
+			10,            /*feeRateNanosPerKB*/
+			m1Pub,         /*updaterPkBase58Check*/
+			m1Priv,        /*updaterPrivBase58Check*/
+			[]byte{},      /*profilePubKey*/
+			"m1",          /*newUsername*/
+			"i am the m1", /*newDescription*/
+			shortPic,      /*newProfilePic*/
+			10*100,        /*newCreatorBasisPoints*/
+			1.25*100*100,  /*newStakeMultipleBasisPoints*/
+			false /*isHidden*/)
+
+		_updateProfileWithTestMeta(
+			testMeta,
+			10,            /*feeRateNanosPerKB*/
+			m2Pub,         /*updaterPkBase58Check*/
+			m2Priv,        /*updaterPrivBase58Check*/
+			[]byte{},      /*profilePubKey*/
+			"m2",          /*newUsername*/
+			"i am the m2", /*newDescription*/
+			shortPic,      /*newProfilePic*/
+			10*100,        /*newCreatorBasisPoints*/
+			1.25*100*100,  /*newStakeMultipleBasisPoints*/
+			false /*isHidden*/)
+		_updateProfileWithTestMeta(
+			testMeta,
+			10,            /*feeRateNanosPerKB*/
+			m3Pub,         /*updaterPkBase58Check*/
+			m3Priv,        /*updaterPrivBase58Check*/
+			[]byte{},      /*profilePubKey*/
+			"m3",          /*newUsername*/
+			"i am the m3", /*newDescription*/
+			shortPic,      /*newProfilePic*/
+			10*100,        /*newCreatorBasisPoints*/
+			1.25*100*100,  /*newStakeMultipleBasisPoints*/
+			false /*isHidden*/)
+		_updateProfileWithTestMeta(
+			testMeta,
+			10,            /*feeRateNanosPerKB*/
+			m4Pub,         /*updaterPkBase58Check*/
+			m4Priv,        /*updaterPrivBase58Check*/
+			[]byte{},      /*profilePubKey*/
+			"m4",          /*newUsername*/
+			"i am the m4", /*newDescription*/
+			shortPic,      /*newProfilePic*/
+			10*100,        /*newCreatorBasisPoints*/
+			1.25*100*100,  /*newStakeMultipleBasisPoints*/
+			false /*isHidden*/)
+	}
 
+	// Make a really, really complicated NFT
+	{
+		// DESO royalties
 		additionalDESORoyaltyMap := make(map[PublicKey]uint64)
-		additionalDESORoyaltyMap[*NewPublicKey(m2PkBytes)] = 100
+		additionalDESORoyaltyMap[*NewPublicKey(m1PkBytes)] = 200
+		additionalDESORoyaltyMap[*NewPublicKey(m2PkBytes)] = 300
+		additionalDESORoyaltyMap[*NewPublicKey(m3PkBytes)] = 300
+		additionalDESORoyaltyMap[*NewPublicKey(m4PkBytes)] = 400
+		additionalDESORoyaltyMap[*NewPublicKey(m5PkBytes)] = 500
 
+		// Creator royalties
 		additionalCoinRoyaltyMap := make(map[PublicKey]uint64)
-		additionalCoinRoyaltyMap[*NewPublicKey(m1PkBytes)] = 500
+		additionalDESORoyaltyMap[*NewPublicKey(m1PkBytes)] = 200
+		additionalDESORoyaltyMap[*NewPublicKey(m3PkBytes)] = 300
+		additionalDESORoyaltyMap[*NewPublicKey(m4PkBytes)] = 300
+		additionalDESORoyaltyMap[*NewPublicKey(m6PkBytes)] = 500
 
 		_createNFTWithAdditionalRoyaltiesWithTestMeta(
 			testMeta,
@@ -6615,269 +7694,352 @@ func TestNFTSplits(t *testing.T) {
 			additionalDESORoyaltyMap,
 			additionalCoinRoyaltyMap,
 		)
-
-		// Balance after. Since the default NFT fee is 0, m0 is only charged the nanos per kb fee.
-		m0BalAfterNFT := _getBalance(t, testMeta.chain, nil, m0Pub)
-		require.Equal(int64(928), int64(m0BalAfterNFT))
 	}
 
-	// Have m3 bid on serial #1. Have m0 accept bid.
+	// Now do a bunch of buy and sell operations
 	{
+		serialNum := uint64(1)
 		bidAmountNanos := uint64(100)
-		// Creator Balance before.
-		m0BalBefore := _getBalance(t, chain, nil, m0Pub)
-		require.Equal(int64(928), int64(m0BalBefore))
-
-		// Bidder Balance before.
-		m3BalBefore := _getBalance(t, chain, nil, m3Pub)
-		require.Equal(int64(1000), int64(m3BalBefore))
-
-		// Creator's DESO locked before
-		m0DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
-
-		// M1's DESO locked before
-		m1DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m1Pub)
-
-		// M2's balance before
-		m2BalBefore := _getBalance(t, chain, nil, m2Pub)
-		require.Equal(int64(961), int64(m2BalBefore))
-
-		// There are no bids before.
-		bidEntries := DBGetNFTBidEntries(db, post1Hash, 1)
-		require.Equal(0, len(bidEntries))
-
-		// M3 bids 100 on this NFT.
-		_createNFTBidWithTestMeta(
-			testMeta,
-			10, /*FeeRateNanosPerKB*/
-			m3Pub,
-			m3Priv,
+		// M1 bids for it and m0 accepts the bid
+		_createNFTBidWithTestMeta(testMeta,
+			10,
+			m1Pub,
+			m1Priv,
 			post1Hash,
-			1,              /*SerialNumber*/
-			bidAmountNanos, /*BidAmountNanos*/
+			serialNum,
+			bidAmountNanos,
 		)
 
-		m3BalAfterBid := _getBalance(t, chain, nil, m3Pub)
-		require.Equal(int64(999), int64(m3BalAfterBid))
-
-		bidEntries = DBGetNFTBidEntries(db, post1Hash, 1)
-		require.Equal(1, len(bidEntries))
-
-		//nftEntry := DBGetNFTEntryByPostHashSerialNumber(db, post1Hash, 1)
-		_acceptNFTBidWithTestMeta(
-			testMeta,
-			10, /*FeeRateNanosPerKB*/
+		// M0 accepts this
+		_acceptNFTBidWithTestMeta(testMeta,
+			10,
 			m0Pub,
 			m0Priv,
 			post1Hash,
-			1,     /*SerialNumber*/
-			m3Pub, /*bidderPkBase58Check*/
+			1,
+			m1Pub,
 			bidAmountNanos,
-			"", /*UnencryptedUnlockableText*/
+			"",
 		)
+	}
 
-		// Check royalties. 10% for the creator, 10% for the coin, 5% to m1's coin, and 1% to m2's wallet
-		m0BalAfter := _getBalance(t, chain, nil, m0Pub)
-		m2BalAfter := _getBalance(t, chain, nil, m2Pub)
+	// M1 puts it on sale as a buy now NFT
+	{
+		_updateNFTWithTestMeta(testMeta,
+			10,
+			m1Pub,
+			m1Priv,
+			post1Hash,
+			1,
+			true,
+			0,
+			true,
+			100,
+		)
+	}
 
-		// Creator royalty = 10, coin royalty = 20, m1's coin royalty = 5, m2's royalty = 1
-		expectedCreatorRoyalty := bidAmountNanos / 10
-		require.Equal(int64(10), int64(expectedCreatorRoyalty))
-		expectedCoinRoyalty := bidAmountNanos / 5
-		require.Equal(int64(20), int64(expectedCoinRoyalty))
-		expectedM1CoinRoyalty := bidAmountNanos / 20
-		require.Equal(int64(5), int64(expectedM1CoinRoyalty))
-		expectedM2Royalty := bidAmountNanos / 100
-		require.Equal(int64(1), int64(expectedM2Royalty))
-		// Check creator's balance
-		bidAmountMinusRoyalties := bidAmountNanos - expectedCoinRoyalty - expectedCreatorRoyalty - expectedM1CoinRoyalty - expectedM2Royalty
-		require.Equal(m0BalBefore-2+bidAmountMinusRoyalties+expectedCreatorRoyalty, m0BalAfter)
-		require.Equal(int64(1000), int64(m0BalAfter))
-		// Check m2's balance
-		require.Equal(m2BalBefore+expectedM2Royalty, m2BalAfter)
-		require.Equal(int64(962), int64(m2BalAfter))
-		// Make sure that the bidder's balance decreased by the bid amount.
-		m3BalAfter := _getBalance(t, chain, nil, m3Pub)
-		require.Equal(m3BalAfterBid-bidAmountNanos, m3BalAfter)
-		require.Equal(int64(899), int64(m3BalAfter))
+	// M2 buys it
+	{
+		_createNFTBidWithTestMeta(testMeta,
+			10,
+			m2Pub,
+			m2Priv,
+			post1Hash,
+			1,
+			100)
+	}
+
+	// M2 puts it on sale in an auction style
+	{
+		_updateNFTWithTestMeta(testMeta,
+			10,
+			m2Pub,
+			m2Priv,
+			post1Hash,
+			1,
+			true,
+			100,
+			false,
+			0,
+		)
+	}
+
+	// M0 and M3 bid on it
+	{
+		_createNFTBidWithTestMeta(testMeta,
+			10,
+			m0Pub,
+			m0Priv,
+			post1Hash,
+			1,
+			200,
+		)
 
-		// Check coin royalties
-		desoLocked, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
-		require.Equal(m0DeSoLockedBefore+expectedCoinRoyalty, desoLocked)
+		_createNFTBidWithTestMeta(testMeta,
+			10,
+			m3Pub,
+			m3Priv,
+			post1Hash,
+			1,
+			250,
+		)
+	}
 
-		desoLockedM1, _ := _getCreatorCoinInfo(t, db, params, m1Pub)
-		require.Equal(m1DeSoLockedBefore+expectedM1CoinRoyalty, desoLockedM1)
+	// M2 accepts M3's bid
+	{
+		_acceptNFTBidWithTestMeta(testMeta,
+			10,
+			m2Pub,
+			m2Priv,
+			post1Hash,
+			1,
+			m3Pub,
+			250,
+			"",
+		)
 	}
 
-	// M3 puts it on sale and m2 buys it. Test that when additional royalty is paid to bidder that things work as
-	// expected.
+	// M3 puts it on sale as a buy now NFT
 	{
-		// m3 puts it on sale as buy now at 200 nanos
-		_updateNFTWithTestMeta(
-			testMeta,
-			10, /*FeeRateNanosPerKB*/
+		_updateNFTWithTestMeta(testMeta,
+			10,
 			m3Pub,
 			m3Priv,
 			post1Hash,
-			1,    /*SerialNumber*/
-			true, /*IsForSale*/
-			100,  /*MinBidAmountNanos*/
-			true, /*IsBuyNow*/
+			1,
+			true,
+			0,
+			true,
 			200,
-		)
-
-		// M2 bids 200 to buy the NFT outright.
-		bidAmountNanos := uint64(200)
-		// Creator Balance before.
-		m0BalBefore := _getBalance(t, chain, nil, m0Pub)
-		require.Equal(int64(1000), int64(m0BalBefore))
+			)
+	}
 
-		// Bidder/m2 Balance before.
-		m2BalBefore := _getBalance(t, chain, nil, m2Pub)
-		require.Equal(int64(962), int64(m2BalBefore))
+	// M0 buys their NFT back
+	{
+		_createNFTBidWithTestMeta(testMeta,
+			10,
+			m0Pub,
+			m0Priv,
+			post1Hash,
+			1,
+			200,
+			)
+	}
 
-		// Seller balance before
-		m3BalBefore := _getBalance(t, chain, nil, m3Pub)
-		require.Equal(int64(897), int64(m3BalBefore))
+	// M0 puts it on sale again
+	{
+		_updateNFTWithTestMeta(testMeta,
+			10,
+			m0Pub,
+			m0Priv,
+			post1Hash,
+			1,
+			true,
+			10,
+			true,
+			200,
+			)
+	}
 
-		// Creator's DESO locked before
-		m0DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+	// M3 buys it back again
+	{
+		_createNFTBidWithTestMeta(testMeta,
+			10,
+			m3Pub,
+			m3Priv,
+			post1Hash,
+			1,
+			200,
+			)
+	}
 
-		// M1's DESO locked before
-		m1DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m1Pub)
+	// M3 puts it on sale and m2 buys it this time
+	{
+		_updateNFTWithTestMeta(testMeta,
+			10,
+			m3Pub,
+			m3Priv,
+			post1Hash,
+			1,
+			true,
+			0,
+			true,
+			150,
+			)
 
-		// There are no bids before.
-		bidEntries := DBGetNFTBidEntries(db, post1Hash, 1)
-		require.Equal(0, len(bidEntries))
+		_createNFTBidWithTestMeta(testMeta,
+			10,
+			m2Pub,
+			m2Priv,
+			post1Hash,
+			1,
+			150,
+			)
+	}
 
-		// M2 buys this NFT for 200.
-		_createNFTBidWithTestMeta(
-			testMeta,
-			10, /*FeeRateNanosPerKB*/
+	// M2 transfers it to M1 and m1 Accepts
+	{
+		_transferNFTWithTestMeta(testMeta,
+			10,
 			m2Pub,
 			m2Priv,
+			m1Pub,
 			post1Hash,
-			1,              /*SerialNumber*/
-			bidAmountNanos, /*BidAmountNanos*/
-		)
+			1,
+			"",
+			)
 
-		bidEntries = DBGetNFTBidEntries(db, post1Hash, 1)
-		require.Equal(0, len(bidEntries))
+		_acceptNFTTransferWithTestMeta(testMeta,
+			10,
+			m1Pub,
+			m1Priv,
+			post1Hash,
+			1,
+			)
+	}
 
-		// Check royalties. 10% for the creator, 20% for the coin, 5% to m1's coin, and 1% to m2's wallet
-		m0BalAfter := _getBalance(t, chain, nil, m0Pub)
-		m2BalAfter := _getBalance(t, chain, nil, m2Pub)
+	// M1 puts it on sale as an auction
+	{
+		_updateNFTWithTestMeta(testMeta,
+			10,
+			m1Pub,
+			m1Priv,
+			post1Hash,
+			1,
+			true,
+			100,
+			false,
+			0,
+			)
+	}
 
-		// Creator royalty = 20, coin royalty = 40, m1's coin royalty = 10, m2's royalty = 2
-		expectedCreatorRoyalty := bidAmountNanos / 10
-		require.Equal(int64(20), int64(expectedCreatorRoyalty))
-		expectedCoinRoyalty := bidAmountNanos / 5
-		require.Equal(int64(40), int64(expectedCoinRoyalty))
-		expectedM1CoinRoyalty := bidAmountNanos / 20
-		require.Equal(int64(10), int64(expectedM1CoinRoyalty))
-		expectedM2Royalty := bidAmountNanos / 100
-		require.Equal(int64(2), int64(expectedM2Royalty))
-		// Check creator's balance
-		bidAmountMinusRoyalties := bidAmountNanos - expectedCoinRoyalty - expectedCreatorRoyalty - expectedM1CoinRoyalty - expectedM2Royalty
-		require.Equal(m0BalBefore+expectedCreatorRoyalty, m0BalAfter)
-		require.Equal(int64(1020), int64(m0BalAfter))
-		// Check m2's balance - m2 is the buyer but also receives a royalty (and pays 2 nanos in fees for the bid)
-		require.Equal(m2BalBefore+expectedM2Royalty-bidAmountNanos-2, m2BalAfter)
-		require.Equal(int64(762), int64(m2BalAfter))
-		// Make sure that the seller's balance decreased by the bid amount minus royalties.
-		m3BalAfter := _getBalance(t, chain, nil, m3Pub)
-		require.Equal(m3BalBefore+bidAmountMinusRoyalties, m3BalAfter)
-		require.Equal(int64(1025), int64(m3BalAfter))
+	// M4, M5, and M6 submit bids
+	{
+		_createNFTBidWithTestMeta(testMeta,
+			10,
+			m4Pub,
+			m4Priv,
+			post1Hash,
+			1,
+			100,
+			)
 
-		// Check coin royalties
-		desoLocked, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
-		require.Equal(m0DeSoLockedBefore+expectedCoinRoyalty, desoLocked)
+		_createNFTBidWithTestMeta(testMeta,
+			10,
+			m5Pub,
+			m5Priv,
+			post1Hash,
+			1,
+			120,
+		)
 
-		desoLockedM1, _ := _getCreatorCoinInfo(t, db, params, m1Pub)
-		require.Equal(m1DeSoLockedBefore+expectedM1CoinRoyalty, desoLockedM1)
+		_createNFTBidWithTestMeta(testMeta,
+			10,
+			m6Pub,
+			m6Priv,
+			post1Hash,
+			1,
+			110,
+		)
 	}
 
-	// M2 puts it on sale and m0 buys it. test that creator buying it still works properly and
-	// seller = additional DESO royalty works.
+	// M1 accepts M5's bid
 	{
-		// m2 puts it on sale as buy now at 500 nanos
-		_updateNFTWithTestMeta(
-			testMeta,
-			10, /*FeeRateNanosPerKB*/
-			m2Pub,
-			m2Priv,
+		_acceptNFTBidWithTestMeta(testMeta,
+			10,
+			m1Pub,
+			m1Priv,
 			post1Hash,
-			1,    /*SerialNumber*/
-			true, /*IsForSale*/
-			100,  /*MinBidAmountNanos*/
-			true, /*IsBuyNow*/
-			500,
+			1,
+			m5Pub,
+			120,
+			"",
+		)
+	}
+
+	// M5 puts it on sale as a buy now NFT and M4 buys it
+	{
+		_updateNFTWithTestMeta(testMeta,
+			10,
+			m5Pub,
+			m5Priv,
+			post1Hash,
+			1,
+			true,
+			0,
+			true,
+			100,
 		)
 
-		// M0 bids 500 to buy the NFT outright.
-		bidAmountNanos := uint64(500)
-		// Creator/Bidder Balance before.
-		m0BalBefore := _getBalance(t, chain, nil, m0Pub)
-		require.Equal(int64(1020), int64(m0BalBefore))
-
-		// Seller/m2 Balance before.
-		m2BalBefore := _getBalance(t, chain, nil, m2Pub)
-		require.Equal(int64(760), int64(m2BalBefore))
-
-		// Creator's DESO locked before
-		m0DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+		_createNFTBidWithTestMeta(testMeta,
+			10,
+			m4Pub,
+			m4Priv,
+			post1Hash,
+			1,
+			100,
+			)
+	}
 
-		// M1's DESO locked before
-		m1DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m1Pub)
+	_rollBackTestMetaTxnsAndFlush(testMeta)
+	_applyTestMetaTxnsToMempool(testMeta)
+	_applyTestMetaTxnsToViewAndFlush(testMeta)
+	_disconnectTestMetaTxnsFromViewAndFlush(testMeta)
+	_connectBlockThenDisconnectBlockAndFlush(testMeta)
+}
 
-		// There are no bids before.
-		bidEntries := DBGetNFTBidEntries(db, post1Hash, 1)
-		require.Equal(0, len(bidEntries))
 
-		// M0 buys this NFT for 500.
-		_createNFTBidWithTestMeta(
-			testMeta,
-			10, /*FeeRateNanosPerKB*/
-			m0Pub,
-			m0Priv,
-			post1Hash,
-			1,              /*SerialNumber*/
-			bidAmountNanos, /*BidAmountNanos*/
-		)
+func TestNFTAuctions(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
 
-		bidEntries = DBGetNFTBidEntries(db, post1Hash, 1)
-		require.Equal(0, len(bidEntries))
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	// Make m3 a paramUpdater for this test
+	params.ParamUpdaterPublicKeys[MakePkMapKey(m3PkBytes)] = true
+	params.ForkHeights.NFTAuctionsBlockHeight = uint32(0)
 
-		// Check royalties. 10% for the creator, 20% for the coin, 5% to m1's coin, and 1% to m2's wallet
-		m0BalAfter := _getBalance(t, chain, nil, m0Pub)
-		m2BalAfter := _getBalance(t, chain, nil, m2Pub)
+	// Mine a few blocks to give the senderPkString some money.
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
 
-		// Creator royalty = 50, coin royalty = 100, m1's coin royalty = 25, m2's royalty = 5
-		expectedCreatorRoyalty := bidAmountNanos / 10
-		require.Equal(int64(50), int64(expectedCreatorRoyalty))
-		expectedCoinRoyalty := bidAmountNanos / 5
-		require.Equal(int64(100), int64(expectedCoinRoyalty))
-		expectedM1CoinRoyalty := bidAmountNanos / 20
-		require.Equal(int64(25), int64(expectedM1CoinRoyalty))
-		expectedM2Royalty := bidAmountNanos / 100
-		require.Equal(int64(5), int64(expectedM2Royalty))
-		// Check creator's balance
-		bidAmountMinusRoyalties := bidAmountNanos - expectedCoinRoyalty - expectedCreatorRoyalty - expectedM1CoinRoyalty - expectedM2Royalty
-		require.Equal(m0BalBefore+expectedCreatorRoyalty-bidAmountNanos-2, m0BalAfter)
-		require.Equal(int64(568), int64(m0BalAfter))
-		// Check m2's balance - m2 is the seller and also receives a royalty
-		require.Equal(m2BalBefore+expectedM2Royalty+bidAmountMinusRoyalties, m2BalAfter)
-		require.Equal(int64(1085), int64(m2BalAfter))
+	// We build the testMeta obj after mining blocks so that we save the correct block height.
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+	blockHeight := uint64(testMeta.savedHeight)
+	m0PKID := DBGetPKIDEntryForPublicKey(db, m0PkBytes).PKID
+	m2PKID := DBGetPKIDEntryForPublicKey(db, m2PkBytes).PKID
 
-		// Check coin royalties
-		desoLocked, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
-		require.Equal(m0DeSoLockedBefore+expectedCoinRoyalty, desoLocked)
+	// Fund all the keys.
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 1000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 1000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m2Pub, senderPrivString, 1000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m3Pub, senderPrivString, 100)
 
-		desoLockedM1, _ := _getCreatorCoinInfo(t, db, params, m1Pub)
-		require.Equal(m1DeSoLockedBefore+expectedM1CoinRoyalty, desoLockedM1)
+	// Set max copies to a non-zero value to activate NFTs.
+	{
+		_updateGlobalParamsEntryWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m3Pub,
+			m3Priv,
+			-1, -1, -1, -1,
+			1000, /*maxCopiesPerNFT*/
+		)
 	}
 
-	// Create a new post for testing.
+	// Create a post and a profile for m0 and NFT the post.
 	{
 		_submitPostWithTestMeta(
 			testMeta,
@@ -6886,233 +8048,448 @@ func TestNFTSplits(t *testing.T) {
 			m0Priv,                             /*updaterPrivBase58Check*/
 			[]byte{},                           /*postHashToModify*/
 			[]byte{},                           /*parentStakeID*/
-			&DeSoBodySchema{Body: "m0 post 2"}, /*body*/
+			&DeSoBodySchema{Body: "m0 post 1"}, /*body*/
 			[]byte{},
-			1502947012*1e9, /*tstampNanos*/
+			1502947011*1e9, /*tstampNanos*/
 			false /*isHidden*/)
 	}
-	post2Hash := testMeta.txns[len(testMeta.txns)-1].Hash()
-
-	// Create NFT with 10% royalty to creator, 20% to coin, 5% to m2's coin
+	post1Hash := testMeta.txns[len(testMeta.txns)-1].Hash()
 	{
-		// Balance before.
-		m0BalBeforeNFT := _getBalance(t, chain, nil, m0Pub)
-		require.Equal(int64(567), int64(m0BalBeforeNFT))
+		_updateProfileWithTestMeta(
+			testMeta,
+			10,            /*feeRateNanosPerKB*/
+			m0Pub,         /*updaterPkBase58Check*/
+			m0Priv,        /*updaterPrivBase58Check*/
+			[]byte{},      /*profilePubKey*/
+			"m0",          /*newUsername*/
+			"i am the m0", /*newDescription*/
+			shortPic,      /*newProfilePic*/
+			10*100,        /*newCreatorBasisPoints*/
+			1.25*100*100,  /*newStakeMultipleBasisPoints*/
+			false /*isHidden*/)
 
-		additionalCoinRoyaltyMap := make(map[PublicKey]uint64)
-		additionalCoinRoyaltyMap[*NewPublicKey(m2PkBytes)] = 500
+		_createNFTWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m0Pub,
+			m0Priv,
+			post1Hash,
+			3,     /*NumCopies*/
+			false, /*HasUnlockable*/
+			false, /*IsForSale*/
+			0,     /*MinBidAmountNanos*/
+			0,     /*nftFee*/
+			0,     /*nftRoyaltyToCreatorBasisPoints*/
+			0,     /*nftRoyaltyToCoinBasisPoints*/
+			false, /*IsBuyNow*/
+			0,     /*BuyNowPriceNanos*/
+		)
+	}
 
-		_createNFTWithAdditionalRoyaltiesWithTestMeta(
+	// Serial #1 is auctioned with a reserve that will be met and serial #2 with
+	// a reserve that won't be.
+	serialOneEndHeight := blockHeight + 10
+	serialTwoEndHeight := blockHeight + 5
+	{
+		_startNFTAuctionWithTestMeta(
 			testMeta,
 			10, /*FeeRateNanosPerKB*/
 			m0Pub,
 			m0Priv,
-			post2Hash,
-			100,    /*NumCopies*/
-			false,  /*HasUnlockable*/
-			true,   /*IsForSale*/
-			0,      /*MinBidAmountNanos*/
-			0,      /*nftFee*/
-			10*100, /*nftRoyaltyToCreatorBasisPoints*/
-			20*100, /*nftRoyaltyToCoinBasisPoints*/
-			true,   /*IsBuyNow*/
-			100,
-			nil,
-			additionalCoinRoyaltyMap,
+			post1Hash,
+			1,                  /*SerialNumber*/
+			100,                /*MinBidAmountNanos*/
+			serialOneEndHeight, /*AuctionEndBlockHeight*/
+			250,                /*ReservePriceNanos*/
+			50,                 /*MinBidIncrementNanos*/
+		)
+		_startNFTAuctionWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m0Pub,
+			m0Priv,
+			post1Hash,
+			2,                  /*SerialNumber*/
+			100,                /*MinBidAmountNanos*/
+			serialTwoEndHeight, /*AuctionEndBlockHeight*/
+			1000,               /*ReservePriceNanos*/
+			0,                  /*MinBidIncrementNanos*/
 		)
 
-		// Balance after. Since the default NFT fee is 0, m0 is only charged the nanos per kb fee.
-		m0BalAfterNFT := _getBalance(t, testMeta.chain, nil, m0Pub)
-		require.Equal(int64(565), int64(m0BalAfterNFT))
+		nftEntry := DBGetNFTEntryByPostHashSerialNumber(db, post1Hash, 1)
+		require.True(nftEntry.IsForSale)
+		require.Equal(serialOneEndHeight, nftEntry.AuctionEndBlockHeight)
+		require.Equal(uint64(250), nftEntry.AuctionReservePriceNanos)
+		require.Equal(uint64(50), nftEntry.AuctionMinBidIncrementNanos)
+		require.Equal(2, len(DBGetNFTKeysWithAuctionsEndingByHeight(db, serialOneEndHeight)))
+		require.Equal(1, len(DBGetNFTKeysWithAuctionsEndingByHeight(db, serialTwoEndHeight)))
 	}
 
-	// Have m3 buy it "now"
+	// Error case: an auction can't end at or before the current block.
 	{
-		// M3 bids 100 to buy the NFT outright.
-		bidAmountNanos := uint64(100)
-		// Creator Balance before.
-		m0BalBefore := _getBalance(t, chain, nil, m0Pub)
-		require.Equal(int64(565), int64(m0BalBefore))
+		_, _, _, err = _startNFTAuction(
+			t, chain, db, params, 10, m0Pub, m0Priv, post1Hash, 3, 100, blockHeight, 0, 0)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTAuctionEndBlockHeightInPast)
+	}
 
-		// Seller balance before
-		m3BalBefore := _getBalance(t, chain, nil, m3Pub)
-		require.Equal(int64(1025), int64(m3BalBefore))
+	// Error case: an NFT can't be updated while it is in an auction.
+	{
+		_, _, _, err = _updateNFT(
+			t, chain, db, params, 10, m0Pub, m0Priv, post1Hash, 1, false, 0, false, 0)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorCannotUpdateNFTInAuction)
+	}
 
-		// Creator's DESO locked before
-		m0DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+	// m1 places the first bid on serial #1.
+	m1BalanceBeforeBid := _getBalance(t, chain, nil, m1Pub)
+	{
+		_createNFTBidWithTestMeta(testMeta, 10, m1Pub, m1Priv, post1Hash, 1, 200)
+		// The bid is escrowed.
+		require.Less(_getBalance(t, chain, nil, m1Pub), m1BalanceBeforeBid-200)
+	}
+	m1BalanceAfterBid := _getBalance(t, chain, nil, m1Pub)
 
-		// M2's DESO locked before -- should be 0
-		m2DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m2Pub)
+	// Error case: escrowed bids can't be cancelled.
+	{
+		_, _, _, err = _createNFTBid(t, chain, db, params, 10, m1Pub, m1Priv, post1Hash, 1, 0)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTAuctionBidCannotBeCancelled)
+	}
 
-		// There are no bids before.
-		bidEntries := DBGetNFTBidEntries(db, post2Hash, 1)
-		require.Equal(0, len(bidEntries))
+	// Error case: a new bid must beat the highest bid by the minimum increment.
+	{
+		_, _, _, err = _createNFTBid(t, chain, db, params, 10, m2Pub, m2Priv, post1Hash, 1, 220)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTAuctionBidBelowMinIncrement)
+	}
 
-		// M3 buys this NFT for 100.
-		_createNFTBidWithTestMeta(
-			testMeta,
-			10, /*FeeRateNanosPerKB*/
-			m3Pub,
-			m3Priv,
-			post2Hash,
-			1,              /*SerialNumber*/
-			bidAmountNanos, /*BidAmountNanos*/
-		)
+	// Error case: bids on an auction can't be accepted by the owner.
+	{
+		_, _, _, err = _acceptNFTBid(t, chain, db, params, 10, m0Pub, m0Priv, post1Hash, 1, m1Pub, 200, "")
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorCannotAcceptBidOnNFTInAuction)
+	}
 
-		// Check royalties. 10% for the creator, 20% for the coin, 5% to m2's coin
-		m0BalAfter := _getBalance(t, chain, nil, m0Pub)
-		m3BalAfter := _getBalance(t, chain, nil, m3Pub)
+	// m2 outbids m1, which refunds m1's bid.
+	{
+		_createNFTBidWithTestMeta(testMeta, 10, m2Pub, m2Priv, post1Hash, 1, 300)
+		require.Equal(m1BalanceAfterBid+200, _getBalance(t, chain, nil, m1Pub))
 
-		// Creator royalty = 10, coin royalty = 20, m2's coin royalty = 5
-		expectedCreatorRoyalty := bidAmountNanos / 10
-		require.Equal(int64(10), int64(expectedCreatorRoyalty))
-		expectedCoinRoyalty := bidAmountNanos / 5
-		require.Equal(int64(20), int64(expectedCoinRoyalty))
-		expectedM2CoinRoyalty := bidAmountNanos / 20
-		require.Equal(int64(5), int64(expectedM2CoinRoyalty))
+		bidEntries := DBGetNFTBidEntries(db, post1Hash, 1)
+		require.Equal(1, len(bidEntries))
+		require.Equal(uint64(300), bidEntries[0].BidAmountNanos)
+	}
 
-		// Check creator's balance
-		bidAmountMinusRoyalties := bidAmountNanos - expectedCoinRoyalty - expectedCreatorRoyalty - expectedM2CoinRoyalty
-		require.Equal(m0BalBefore+expectedCreatorRoyalty+bidAmountMinusRoyalties, m0BalAfter)
-		require.Equal(int64(640), int64(m0BalAfter))
+	// m1 bids on serial #2 without meeting the reserve.
+	{
+		_createNFTBidWithTestMeta(testMeta, 10, m1Pub, m1Priv, post1Hash, 2, 300)
+	}
 
-		// Check bidder's balance
-		require.Equal(m3BalBefore-bidAmountNanos-1, m3BalAfter)
-		require.Equal(int64(924), int64(m3BalAfter))
+	// Bids made near the end of an auction extend it, and bids made after the end fail.
+	{
+		bidTxn, _, _, _, err := chain.CreateNFTBidTxn(
+			m1PkBytes, post1Hash, 1, 400, 10, nil, []*DeSoOutput{})
+		require.NoError(err)
+		_signTxn(t, bidTxn, m1Priv)
 
-		// Check coin royalties
-		desoLocked, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
-		require.Equal(m0DeSoLockedBefore+expectedCoinRoyalty, desoLocked)
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		_, _, _, _, err = utxoView.ConnectTransaction(
+			bidTxn, bidTxn.Hash(), getTxnSize(*bidTxn), uint32(serialOneEndHeight), true, false)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTAuctionEnded)
+
+		utxoView, err = NewUtxoView(db, params, nil)
+		require.NoError(err)
+		_, _, _, _, err = utxoView.ConnectTransaction(
+			bidTxn, bidTxn.Hash(), getTxnSize(*bidTxn), uint32(serialOneEndHeight-2), true, false)
+		require.NoError(err)
+		nftKey := MakeNFTKey(post1Hash, 1)
+		require.Equal(serialOneEndHeight-2+NFTAuctionExtensionBlocks,
+			utxoView.GetNFTEntryForNFTKey(&nftKey).AuctionEndBlockHeight)
+	}
+
+	// Connect a block reward at the end of serial #1's auction, which settles both auctions.
+	blockRewardTxn := &MsgDeSoTxn{
+		TxOutputs: []*DeSoOutput{{PublicKey: m3PkBytes, AmountNanos: 1}},
+		TxnMeta:   &BlockRewardMetadataa{ExtraData: []byte{0x00}},
+	}
+	m0BalanceBeforeSettlement := _getBalance(t, chain, nil, m0Pub)
+	m1BalanceBeforeSettlement := _getBalance(t, chain, nil, m1Pub)
+	m2BalanceBeforeSettlement := _getBalance(t, chain, nil, m2Pub)
+	var settlementUtxoOps []*UtxoOperation
+	{
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		settlementUtxoOps, _, _, _, err = utxoView.ConnectTransaction(
+			blockRewardTxn, blockRewardTxn.Hash(), 0, uint32(serialOneEndHeight), false, false)
+		require.NoError(err)
+		require.Equal(OperationTypeNFTAuctionSettlement, settlementUtxoOps[len(settlementUtxoOps)-1].Type)
+		require.NoError(utxoView.FlushToDb())
+
+		// m2 won serial #1 and m0 was paid the winning bid.
+		nftEntry := DBGetNFTEntryByPostHashSerialNumber(db, post1Hash, 1)
+		require.Equal(*m2PKID, *nftEntry.OwnerPKID)
+		require.False(nftEntry.IsForSale)
+		require.Equal(uint64(0), nftEntry.AuctionEndBlockHeight)
+		require.Equal(uint64(300), nftEntry.LastAcceptedBidAmountNanos)
+		require.Equal(m0BalanceBeforeSettlement+300, _getBalance(t, chain, nil, m0Pub))
+		require.Equal(m2BalanceBeforeSettlement, _getBalance(t, chain, nil, m2Pub))
 
-		// Because there is no DeSo locked in M2's coin, we don't add to their DESO locked.
-		desoLockedM2, _ := _getCreatorCoinInfo(t, db, params, m2Pub)
-		require.Equal(m2DeSoLockedBefore, desoLockedM2)
+		// Serial #2 didn't meet its reserve so m0 keeps it and m1 gets their bid back.
+		nftEntry = DBGetNFTEntryByPostHashSerialNumber(db, post1Hash, 2)
+		require.Equal(*m0PKID, *nftEntry.OwnerPKID)
+		require.False(nftEntry.IsForSale)
+		require.Equal(uint64(0), nftEntry.AuctionEndBlockHeight)
+		require.Equal(m1BalanceBeforeSettlement+300, _getBalance(t, chain, nil, m1Pub))
+
+		require.Equal(0, len(DBGetNFTBidEntries(db, post1Hash, 1)))
+		require.Equal(0, len(DBGetNFTBidEntries(db, post1Hash, 2)))
+		require.Equal(0, len(DBGetNFTKeysWithAuctionsEndingByHeight(db, serialOneEndHeight)))
+		require.Equal(uint64(0), DBGetPostEntryByPostHash(db, post1Hash).NumNFTCopiesForSale)
 	}
 
-	// Have M2 buy some of their own coin so they can take advantage of the royalties
+	// Disconnecting the block reward restores both auctions.
 	{
-		_creatorCoinTxnWithTestMeta(
-			testMeta,
-			10,     /*feeRateNanosPerKB*/
-			m2Pub,  /*updaterPkBase58Check*/
-			m2Priv, /*updaterPrivBase58Check*/
-			m2Pub,  /*profilePubKeyBase58Check*/
-			CreatorCoinOperationTypeBuy,
-			29, /*DeSoToSellNanos*/
-			0,  /*CreatorCoinToSellNanos*/
-			0,  /*DeSoToAddNanos*/
-			0,  /*MinDeSoExpectedNanos*/
-			10, /*MinCreatorCoinExpectedNanos*/
-		)
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		require.NoError(utxoView.DisconnectTransaction(
+			blockRewardTxn, blockRewardTxn.Hash(), settlementUtxoOps, uint32(serialOneEndHeight)))
+		require.NoError(utxoView.FlushToDb())
 
-		m2Bal := _getBalance(t, testMeta.chain, nil, m2Pub)
-		require.Equal(int64(1054), int64(m2Bal))
+		nftEntry := DBGetNFTEntryByPostHashSerialNumber(db, post1Hash, 1)
+		require.Equal(*m0PKID, *nftEntry.OwnerPKID)
+		require.True(nftEntry.IsForSale)
+		require.Equal(serialOneEndHeight, nftEntry.AuctionEndBlockHeight)
+		require.Equal(1, len(DBGetNFTBidEntries(db, post1Hash, 1)))
+		require.Equal(1, len(DBGetNFTBidEntries(db, post1Hash, 2)))
+		require.Equal(2, len(DBGetNFTKeysWithAuctionsEndingByHeight(db, serialOneEndHeight)))
+		require.Equal(uint64(2), DBGetPostEntryByPostHash(db, post1Hash).NumNFTCopiesForSale)
+		require.Equal(m0BalanceBeforeSettlement, _getBalance(t, chain, nil, m0Pub))
+		require.Equal(m1BalanceBeforeSettlement, _getBalance(t, chain, nil, m1Pub))
+		require.Equal(m2BalanceBeforeSettlement, _getBalance(t, chain, nil, m2Pub))
 	}
 
-	// Swap identity of m3 and m2.
-	// m2 now owns the NFT that m3 had.
-	// This will also cause m3's coin to wind up with m2's royalties.
-	params.ParamUpdaterPublicKeys[MakePkMapKey(paramUpdaterPkBytes)] = true
-	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, paramUpdaterPub, senderPrivString, 100)
-	_swapIdentityWithTestMeta(testMeta, 10, paramUpdaterPub, paramUpdaterPriv, m2PkBytes, m3PkBytes)
+	_rollBackTestMetaTxnsAndFlush(testMeta)
+	_applyTestMetaTxnsToMempool(testMeta)
+	_applyTestMetaTxnsToViewAndFlush(testMeta)
+	_disconnectTestMetaTxnsFromViewAndFlush(testMeta)
+	_connectBlockThenDisconnectBlockAndFlush(testMeta)
+}
 
-	// Now M2 (formerly m3) puts it on sale and m4 buys this NFT and watch M2 get some royalties
+func TestNFTCollections(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	// Make m3 a paramUpdater for this test
+	params.ParamUpdaterPublicKeys[MakePkMapKey(m3PkBytes)] = true
+	params.ForkHeights.NFTCollectionsBlockHeight = uint32(0)
+
+	// Mine a few blocks to give the senderPkString some money.
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	// We build the testMeta obj after mining blocks so that we save the correct block height.
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+	m0PKID := DBGetPKIDEntryForPublicKey(db, m0PkBytes).PKID
+	m1PKID := DBGetPKIDEntryForPublicKey(db, m1PkBytes).PKID
+	m2PKID := DBGetPKIDEntryForPublicKey(db, m2PkBytes).PKID
+
+	// Fund all the keys.
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 1000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 1000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m2Pub, senderPrivString, 1000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m3Pub, senderPrivString, 100)
+
+	// Set max copies to a non-zero value to activate NFTs.
 	{
-		// m2 puts it on sale as buy now at 200 nanos
-		_updateNFTWithTestMeta(
+		_updateGlobalParamsEntryWithTestMeta(
 			testMeta,
 			10, /*FeeRateNanosPerKB*/
-			m2Pub,
-			m2Priv,
-			post2Hash,
-			1,    /*SerialNumber*/
-			true, /*IsForSale*/
-			100,  /*MinBidAmountNanos*/
-			true, /*IsBuyNow*/
-			200,
+			m3Pub,
+			m3Priv,
+			-1, -1, -1, -1,
+			1000, /*maxCopiesPerNFT*/
 		)
+	}
 
-		// M2 bids 200 to buy the NFT outright.
-		bidAmountNanos := uint64(200)
-		// Creator Balance before.
-		m0BalBefore := _getBalance(t, chain, nil, m0Pub)
-		require.Equal(int64(640), int64(m0BalBefore))
+	additionalDESORoyaltyMap := make(map[PublicKey]uint64)
+	additionalDESORoyaltyMap[*NewPublicKey(m2PkBytes)] = 200
 
-		// Seller Balance before.
-		m2BalBefore := _getBalance(t, chain, nil, m2Pub)
-		m3BalBefore := _getBalance(t, chain, nil, m3Pub)
-		require.Equal(int64(1052), int64(m2BalBefore))
-		require.Equal(int64(924), int64(m3BalBefore))
+	// Error case: a collection can't be created without a profile.
+	{
+		_, _, _, err = _createNFTCollection(
+			t, chain, db, params, 10, m0Pub, m0Priv, "passes", 5, 1000, 500, nil, nil)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorCantCreateNFTCollectionWithoutProfileEntry)
+	}
 
-		// Bidder balance before
-		m4BalBefore := _getBalance(t, chain, nil, m4Pub)
-		require.Equal(int64(999), int64(m4BalBefore))
+	// Create profiles for m0 and m1.
+	{
+		_updateProfileWithTestMeta(
+			testMeta,
+			10,            /*feeRateNanosPerKB*/
+			m0Pub,         /*updaterPkBase58Check*/
+			m0Priv,        /*updaterPrivBase58Check*/
+			[]byte{},      /*profilePubKey*/
+			"m0",          /*newUsername*/
+			"i am the m0", /*newDescription*/
+			shortPic,      /*newProfilePic*/
+			10*100,        /*newCreatorBasisPoints*/
+			1.25*100*100,  /*newStakeMultipleBasisPoints*/
+			false /*isHidden*/)
+		_updateProfileWithTestMeta(
+			testMeta,
+			10,            /*feeRateNanosPerKB*/
+			m1Pub,         /*updaterPkBase58Check*/
+			m1Priv,        /*updaterPrivBase58Check*/
+			[]byte{},      /*profilePubKey*/
+			"m1",          /*newUsername*/
+			"i am the m1", /*newDescription*/
+			shortPic,      /*newProfilePic*/
+			10*100,        /*newCreatorBasisPoints*/
+			1.25*100*100,  /*newStakeMultipleBasisPoints*/
+			false /*isHidden*/)
+	}
 
-		// Creator's DESO locked before
-		m0DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
+	// Error case: the name can't be empty.
+	{
+		_, _, _, err = _createNFTCollection(
+			t, chain, db, params, 10, m0Pub, m0Priv, "", 5, 1000, 500, nil, nil)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTCollectionNameEmpty)
+	}
 
-		// M2's DESO locked before
-		m2DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m2Pub)
-		m3DeSoLockedBefore, _ := _getCreatorCoinInfo(t, db, params, m3Pub)
+	// Error case: the collection's royalties can't exceed the max.
+	{
+		_, _, _, err = _createNFTCollection(
+			t, chain, db, params, 10, m0Pub, m0Priv, "passes", 5, params.MaxNFTRoyaltyBasisPoints, 1, nil, nil)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTRoyaltyHasTooManyBasisPoints)
+	}
 
-		// There are no bids before.
-		bidEntries := DBGetNFTBidEntries(db, post1Hash, 1)
-		require.Equal(0, len(bidEntries))
+	// m0 creates a collection capped at 5 copies.
+	{
+		_createNFTCollectionWithTestMeta(
+			testMeta, 10, m0Pub, m0Priv, "passes", 5, 1000, 500, additionalDESORoyaltyMap, nil)
+	}
+	collectionID := testMeta.txns[len(testMeta.txns)-1].Hash()
+	{
+		nftCollectionEntry := DbGetNFTCollectionEntry(db, collectionID)
+		require.NotNil(nftCollectionEntry)
+		require.Equal(*m0PKID, *nftCollectionEntry.CreatorPKID)
+		require.Equal([]byte("passes"), nftCollectionEntry.Name)
+		require.Equal(uint64(5), nftCollectionEntry.MaxSupply)
+		require.Equal(uint64(0), nftCollectionEntry.NumNFTCopies)
+		require.Equal(uint64(200), nftCollectionEntry.AdditionalNFTRoyaltiesToCreatorsBasisPoints[*m2PKID])
+	}
 
-		// M4 buys this NFT for 100.
-		_createNFTBidWithTestMeta(
+	// Create posts for m0 and m1.
+	var postHashes []*BlockHash
+	for _, poster := range []struct{ pub, priv string }{{m0Pub, m0Priv}, {m0Pub, m0Priv}, {m1Pub, m1Priv}} {
+		_submitPostWithTestMeta(
 			testMeta,
-			10, /*FeeRateNanosPerKB*/
-			m4Pub,
-			m4Priv,
-			post2Hash,
-			1,              /*SerialNumber*/
-			bidAmountNanos, /*BidAmountNanos*/
-		)
+			10,                            /*feeRateNanosPerKB*/
+			poster.pub,                    /*updaterPkBase58Check*/
+			poster.priv,                   /*updaterPrivBase58Check*/
+			[]byte{},                      /*postHashToModify*/
+			[]byte{},                      /*parentStakeID*/
+			&DeSoBodySchema{Body: "post"}, /*body*/
+			[]byte{},
+			uint64(1502947011+len(postHashes))*1e9, /*tstampNanos*/
+			false /*isHidden*/)
+		postHashes = append(postHashes, testMeta.txns[len(testMeta.txns)-1].Hash())
+	}
+	post1Hash, post2Hash, post3Hash := postHashes[0], postHashes[1], postHashes[2]
 
-		// Check royalties. 10% for the creator, 20% for the coin, 5% to m2's coin
-		m0BalAfter := _getBalance(t, chain, nil, m0Pub)
-		m2BalAfter := _getBalance(t, chain, nil, m2Pub)
-		m3BalAfter := _getBalance(t, chain, nil, m3Pub)
-		m4BalAfter := _getBalance(t, chain, nil, m4Pub)
+	// m0 attaches 3 copies of post 1 to the collection. The collection's royalties
+	// override the ones on the transaction.
+	{
+		_createNFTInCollectionWithTestMeta(testMeta, 10, m0Pub, m0Priv, post1Hash, 3, true, collectionID)
 
-		// Creator royalty = 20, coin royalty = 40, m2's coin royalty = 10
-		expectedCreatorRoyalty := bidAmountNanos / 10
-		require.Equal(int64(20), int64(expectedCreatorRoyalty))
-		expectedCoinRoyalty := bidAmountNanos / 5
-		require.Equal(int64(40), int64(expectedCoinRoyalty))
-		expectedM2CoinRoyalty := bidAmountNanos / 20
-		require.Equal(int64(10), int64(expectedM2CoinRoyalty))
+		postEntry := DBGetPostEntryByPostHash(db, post1Hash)
+		require.Equal(*collectionID, *postEntry.NFTCollectionID)
+		require.Equal(uint64(1000), postEntry.NFTRoyaltyToCreatorBasisPoints)
+		require.Equal(uint64(500), postEntry.NFTRoyaltyToCoinBasisPoints)
+		require.Equal(uint64(200), postEntry.AdditionalNFTRoyaltiesToCreatorsBasisPoints[*m2PKID])
+		require.Equal(uint64(3), DbGetNFTCollectionEntry(db, collectionID).NumNFTCopies)
+	}
 
-		// Check creator's balance
-		bidAmountMinusRoyalties := bidAmountNanos - expectedCoinRoyalty - expectedCreatorRoyalty - expectedM2CoinRoyalty
-		require.Equal(m0BalBefore+expectedCreatorRoyalty, m0BalAfter)
-		require.Equal(int64(660), int64(m0BalAfter))
+	// Error case: the collection only has room for 2 more copies.
+	{
+		_, _, _, err = _createNFTInCollection(
+			t, chain, db, params, 10, m0Pub, m0Priv, post2Hash, 3,
+			false, true, 0, 0, 0, 0, false, 0, nil, nil, collectionID)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTCollectionMaxSupplyExceeded)
+	}
 
-		// Check seller's balance
-		require.Equal(m2BalBefore+bidAmountMinusRoyalties, m2BalAfter)
-		require.Equal(int64(1182), int64(m2BalAfter))
+	// Error case: m1 can't attach an NFT to m0's collection.
+	{
+		_, _, _, err = _createNFTInCollection(
+			t, chain, db, params, 10, m1Pub, m1Priv, post3Hash, 1,
+			false, true, 0, 0, 0, 0, false, 0, nil, nil, collectionID)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTCollectionMustBeOwnedByPoster)
+	}
 
-		require.Equal(m3BalBefore, m3BalAfter)
-		require.Equal(int64(924), int64(m3BalAfter))
+	// Error case: the collection has to exist.
+	{
+		_, _, _, err = _createNFTInCollection(
+			t, chain, db, params, 10, m0Pub, m0Priv, post2Hash, 1,
+			false, true, 0, 0, 0, 0, false, 0, nil, nil, post1Hash)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTCollectionDoesNotExist)
+	}
 
-		// Check bidder's balance
-		require.Equal(m4BalBefore-bidAmountNanos-1, m4BalAfter)
-		require.Equal(int64(798), int64(m4BalAfter))
+	// m0 fills the collection with 2 copies of post 2.
+	{
+		_createNFTInCollectionWithTestMeta(testMeta, 10, m0Pub, m0Priv, post2Hash, 2, true, collectionID)
+		require.Equal(uint64(5), DbGetNFTCollectionEntry(db, collectionID).NumNFTCopies)
+		require.Equal(2, len(DbGetPostHashesForNFTCollection(db, collectionID)))
+	}
 
-		// Check coin royalties
-		desoLocked, _ := _getCreatorCoinInfo(t, db, params, m0Pub)
-		require.Equal(m0DeSoLockedBefore+expectedCoinRoyalty, desoLocked)
+	// m1 and m2 bid on NFTs in the collection. The floor is the lowest bid.
+	{
+		_createNFTBidWithTestMeta(testMeta, 10, m1Pub, m1Priv, post1Hash, 1, 100)
+		_createNFTBidWithTestMeta(testMeta, 10, m2Pub, m2Priv, post2Hash, 2, 300)
 
-		desoLockedM2, _ := _getCreatorCoinInfo(t, db, params, m2Pub)
-		require.Equal(m2DeSoLockedBefore, desoLockedM2)
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		require.Equal(2, len(utxoView.GetPostEntriesForNFTCollection(collectionID)))
+		require.Equal(5, len(utxoView.GetNFTEntriesForNFTCollection(collectionID)))
+		highBid, lowBid := utxoView.GetHighAndLowBidsForNFTCollectionEntry(collectionID)
+		require.Equal(uint64(300), highBid)
+		require.Equal(uint64(100), lowBid)
+	}
 
-		// Now that there is DESO locked in M2's coin, we can add the royalties to their coin
-		desoLockedM3, _ := _getCreatorCoinInfo(t, db, params, m3Pub)
-		require.Equal(m3DeSoLockedBefore+expectedM2CoinRoyalty, desoLockedM3)
+	// m0 accepts m1's bid, so m1 now holds one NFT in the collection.
+	{
+		_acceptNFTBidWithTestMeta(testMeta, 10, m0Pub, m0Priv, post1Hash, 1, m1Pub, 100, "")
+
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		holders := utxoView.GetHoldersForNFTCollection(collectionID)
+		require.Equal(2, len(holders))
+		require.Equal(uint64(4), holders[*m0PKID])
+		require.Equal(uint64(1), holders[*m1PKID])
 	}
 
-	// Roll all successful txns through connect and disconnect loops to make sure nothing breaks.
 	_rollBackTestMetaTxnsAndFlush(testMeta)
 	_applyTestMetaTxnsToMempool(testMeta)
 	_applyTestMetaTxnsToViewAndFlush(testMeta)
@@ -7120,25 +8497,7 @@ func TestNFTSplits(t *testing.T) {
 	_connectBlockThenDisconnectBlockAndFlush(testMeta)
 }
 
-func TestNFTSplitsSerializers(t *testing.T) {
-	require := require.New(t)
-
-	mm := make(map[PublicKey]uint64)
-	mm[*NewPublicKey(m0PkBytes)] = 123456
-	mm[*NewPublicKey(m1PkBytes)] = 7890123
-	mm[*NewPublicKey(m2PkBytes)] = 34834983
-	bb, err := SerializePubKeyToUint64Map(mm)
-	require.NoError(err)
-
-	newMM, err := DeserializePubKeyToUint64Map(bb)
-	require.NoError(err)
-
-	require.Equal(mm, newMM)
-}
-
-// Set up this test to catch a very hardcore pkid/pubkey bug that only
-// showed up in prod.
-func TestNFTSplitsHardcorePKIDBug(t *testing.T) {
+func TestNFTVaults(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
 	_ = assert
@@ -7146,78 +8505,50 @@ func TestNFTSplitsHardcorePKIDBug(t *testing.T) {
 
 	chain, params, db := NewLowDifficultyBlockchain()
 	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
-	// Make m3, m4 a paramUpdater for this test
+	// Make m3 a paramUpdater for this test
 	params.ParamUpdaterPublicKeys[MakePkMapKey(m3PkBytes)] = true
-	params.ParamUpdaterPublicKeys[MakePkMapKey(m4PkBytes)] = true
-	params.ForkHeights.BuyNowAndNFTSplitsBlockHeight = uint32(0)
-	params.ForkHeights.NFTTransferOrBurnAndDerivedKeysBlockHeight = uint32(0)
+	params.ForkHeights.NFTVaultsBlockHeight = uint32(0)
 
 	// Mine a few blocks to give the senderPkString some money.
 	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
 	require.NoError(err)
 	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
 	require.NoError(err)
-	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
-	require.NoError(err)
-	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
-	require.NoError(err)
 
 	// We build the testMeta obj after mining blocks so that we save the correct block height.
 	testMeta := &TestMeta{
 		t:           t,
 		chain:       chain,
 		params:      params,
-		db:          db,
-		mempool:     mempool,
-		miner:       miner,
-		savedHeight: chain.blockTip().Height + 1,
-	}
-
-	// Fund all the keys.
-	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 10000)
-	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 10000)
-	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m2Pub, senderPrivString, 10000)
-	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m3Pub, senderPrivString, 10000)
-	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m4Pub, senderPrivString, 10000)
-	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m5Pub, senderPrivString, 10000)
-	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m6Pub, senderPrivString, 10000)
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+	m0PKID := DBGetPKIDEntryForPublicKey(db, m0PkBytes).PKID
+	m1PKID := DBGetPKIDEntryForPublicKey(db, m1PkBytes).PKID
+	m2PKID := DBGetPKIDEntryForPublicKey(db, m2PkBytes).PKID
 
-	//m4PKID := DBGetPKIDEntryForPublicKey(db, m4PkBytes)
-	//m5PKID := DBGetPKIDEntryForPublicKey(db, m5PkBytes)
-	//m6PKID := DBGetPKIDEntryForPublicKey(db, m6PkBytes)
+	// Fund all the keys.
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 1000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 1000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m2Pub, senderPrivString, 1000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m3Pub, senderPrivString, 100)
 
 	// Set max copies to a non-zero value to activate NFTs.
 	{
 		_updateGlobalParamsEntryWithTestMeta(
 			testMeta,
 			10, /*FeeRateNanosPerKB*/
-			m4Pub,
-			m4Priv,
+			m3Pub,
+			m3Priv,
 			-1, -1, -1, -1,
 			1000, /*maxCopiesPerNFT*/
 		)
 	}
 
-	// Create a post for testing.
-	{
-		_submitPostWithTestMeta(
-			testMeta,
-			10,                                 /*feeRateNanosPerKB*/
-			m0Pub,                              /*updaterPkBase58Check*/
-			m0Priv,                             /*updaterPrivBase58Check*/
-			[]byte{},                           /*postHashToModify*/
-			[]byte{},                           /*parentStakeID*/
-			&DeSoBodySchema{Body: "m0 post 1"}, /*body*/
-			[]byte{},
-			1502947011*1e9, /*tstampNanos*/
-			false /*isHidden*/)
-	}
-	post1Hash := testMeta.txns[len(testMeta.txns)-1].Hash()
-
-	_ = post1Hash
-	// NFT the post.
+	// Create a profile and a post for m0.
 	{
-		// Create a profile for m0, m1, m2, m3, m4
 		_updateProfileWithTestMeta(
 			testMeta,
 			10,            /*feeRateNanosPerKB*/
@@ -7230,383 +8561,505 @@ func TestNFTSplitsHardcorePKIDBug(t *testing.T) {
 			10*100,        /*newCreatorBasisPoints*/
 			1.25*100*100,  /*newStakeMultipleBasisPoints*/
 			false /*isHidden*/)
-		_updateProfileWithTestMeta(
-			testMeta,
-			10,            /*feeRateNanosPerKB*/
-			m1Pub,         /*updaterPkBase58Check*/
-			m1Priv,        /*updaterPrivBase58Check*/
-			[]byte{},      /*profilePubKey*/
-			"m1",          /*newUsername*/
-			"i am the m1", /*newDescription*/
-			shortPic,      /*newProfilePic*/
-			10*100,        /*newCreatorBasisPoints*/
-			1.25*100*100,  /*newStakeMultipleBasisPoints*/
-			false /*isHidden*/)
-
-		_updateProfileWithTestMeta(
-			testMeta,
-			10,            /*feeRateNanosPerKB*/
-			m2Pub,         /*updaterPkBase58Check*/
-			m2Priv,        /*updaterPrivBase58Check*/
-			[]byte{},      /*profilePubKey*/
-			"m2",          /*newUsername*/
-			"i am the m2", /*newDescription*/
-			shortPic,      /*newProfilePic*/
-			10*100,        /*newCreatorBasisPoints*/
-			1.25*100*100,  /*newStakeMultipleBasisPoints*/
-			false /*isHidden*/)
-		_updateProfileWithTestMeta(
-			testMeta,
-			10,            /*feeRateNanosPerKB*/
-			m3Pub,         /*updaterPkBase58Check*/
-			m3Priv,        /*updaterPrivBase58Check*/
-			[]byte{},      /*profilePubKey*/
-			"m3",          /*newUsername*/
-			"i am the m3", /*newDescription*/
-			shortPic,      /*newProfilePic*/
-			10*100,        /*newCreatorBasisPoints*/
-			1.25*100*100,  /*newStakeMultipleBasisPoints*/
-			false /*isHidden*/)
-		_updateProfileWithTestMeta(
+		_submitPostWithTestMeta(
 			testMeta,
-			10,            /*feeRateNanosPerKB*/
-			m4Pub,         /*updaterPkBase58Check*/
-			m4Priv,        /*updaterPrivBase58Check*/
-			[]byte{},      /*profilePubKey*/
-			"m4",          /*newUsername*/
-			"i am the m4", /*newDescription*/
-			shortPic,      /*newProfilePic*/
-			10*100,        /*newCreatorBasisPoints*/
-			1.25*100*100,  /*newStakeMultipleBasisPoints*/
+			10,                           /*feeRateNanosPerKB*/
+			m0Pub,                        /*updaterPkBase58Check*/
+			m0Priv,                       /*updaterPrivBase58Check*/
+			[]byte{},                     /*postHashToModify*/
+			[]byte{},                     /*parentStakeID*/
+			&DeSoBodySchema{Body: "art"}, /*body*/
+			[]byte{},
+			1502947011*1e9, /*tstampNanos*/
 			false /*isHidden*/)
 	}
+	postHash := testMeta.txns[len(testMeta.txns)-1].Hash()
 
-	// Make a really, really complicated NFT
+	// m0 turns the post into 2 NFT copies that are not for sale with a 10% creator royalty.
 	{
-		// DESO royalties
-		additionalDESORoyaltyMap := make(map[PublicKey]uint64)
-		additionalDESORoyaltyMap[*NewPublicKey(m1PkBytes)] = 200
-		additionalDESORoyaltyMap[*NewPublicKey(m2PkBytes)] = 300
-		additionalDESORoyaltyMap[*NewPublicKey(m3PkBytes)] = 300
-		additionalDESORoyaltyMap[*NewPublicKey(m4PkBytes)] = 400
-		additionalDESORoyaltyMap[*NewPublicKey(m5PkBytes)] = 500
-
-		// Creator royalties
-		additionalCoinRoyaltyMap := make(map[PublicKey]uint64)
-		additionalDESORoyaltyMap[*NewPublicKey(m1PkBytes)] = 200
-		additionalDESORoyaltyMap[*NewPublicKey(m3PkBytes)] = 300
-		additionalDESORoyaltyMap[*NewPublicKey(m4PkBytes)] = 300
-		additionalDESORoyaltyMap[*NewPublicKey(m6PkBytes)] = 500
-
-		_createNFTWithAdditionalRoyaltiesWithTestMeta(
+		_createNFTWithTestMeta(
 			testMeta,
 			10, /*FeeRateNanosPerKB*/
 			m0Pub,
 			m0Priv,
-			post1Hash,
-			100,    /*NumCopies*/
-			false,  /*HasUnlockable*/
-			true,   /*IsForSale*/
-			0,      /*MinBidAmountNanos*/
-			0,      /*nftFee*/
-			10*100, /*nftRoyaltyToCreatorBasisPoints*/
-			20*100, /*nftRoyaltyToCoinBasisPoints*/
-			false,  /*IsBuyNow*/
-			0,
-			additionalDESORoyaltyMap,
-			additionalCoinRoyaltyMap,
+			postHash,
+			2,     /*NumCopies*/
+			false, /*HasUnlockable*/
+			false, /*IsForSale*/
+			0,     /*MinBidAmountNanos*/
+			0,     /*nftFee*/
+			1000,  /*nftRoyaltyToCreatorBasisPoints*/
+			0,     /*nftRoyaltyToCoinBasisPoints*/
+			false, /*IsBuyNow*/
+			0,     /*BuyNowPriceNanos*/
 		)
 	}
 
-	// Now do a bunch of buy and sell operations
+	lockMetadata := func(serialNumber uint64, totalShares uint64, buyoutPrice uint64) *NFTVaultMetadata {
+		return &NFTVaultMetadata{
+			NFTPostHash:      postHash,
+			SerialNumber:     serialNumber,
+			OperationType:    NFTVaultOperationTypeLock,
+			TotalSharesNanos: *uint256.NewInt().SetUint64(totalShares),
+			BuyoutPriceNanos: buyoutPrice,
+		}
+	}
+	transferMetadata := func(serialNumber uint64, receiverPkBytes []byte, shares uint64) *NFTVaultMetadata {
+		return &NFTVaultMetadata{
+			NFTPostHash:           postHash,
+			SerialNumber:          serialNumber,
+			OperationType:         NFTVaultOperationTypeTransferShares,
+			SharesToTransferNanos: *uint256.NewInt().SetUint64(shares),
+			ReceiverPublicKey:     receiverPkBytes,
+		}
+	}
+	redeemMetadata := func(serialNumber uint64) *NFTVaultMetadata {
+		return &NFTVaultMetadata{
+			NFTPostHash:   postHash,
+			SerialNumber:  serialNumber,
+			OperationType: NFTVaultOperationTypeRedeem,
+		}
+	}
+	shareBalance := func(holderPKID *PKID, serialNumber uint64) uint64 {
+		balanceEntry := DbGetBalanceEntry(db, holderPKID, NFTVaultPKID(postHash, serialNumber), true)
+		if balanceEntry == nil {
+			return 0
+		}
+		return balanceEntry.BalanceNanos.Uint64()
+	}
+
+	// Error case: m1 can't vault an NFT they don't own.
 	{
-		serialNum := uint64(1)
-		bidAmountNanos := uint64(100)
-		// M1 bids for it and m0 accepts the bid
-		_createNFTBidWithTestMeta(testMeta,
-			10,
-			m1Pub,
-			m1Priv,
-			post1Hash,
-			serialNum,
-			bidAmountNanos,
-		)
+		_, _, _, err = _nftVault(t, chain, db, params, 10, m1Pub, m1Priv, lockMetadata(1, 1000, 300))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTVaultLockByNonOwner)
+	}
 
-		// M0 accepts this
-		_acceptNFTBidWithTestMeta(testMeta,
-			10,
-			m0Pub,
-			m0Priv,
-			post1Hash,
-			1,
-			m1Pub,
-			bidAmountNanos,
-			"",
-		)
+	// Error case: a vault must mint shares and have a buyout price.
+	{
+		_, _, _, err = _nftVault(t, chain, db, params, 10, m0Pub, m0Priv, lockMetadata(1, 0, 300))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTVaultMustMintNonZeroShares)
+
+		_, _, _, err = _nftVault(t, chain, db, params, 10, m0Pub, m0Priv, lockMetadata(1, 1000, 0))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTVaultBuyoutPriceMustBeNonZero)
 	}
 
-	// M1 puts it on sale as a buy now NFT
+	// m0 vaults NFT #1 into 1000 shares with a buyout price of 300 nanos.
 	{
-		_updateNFTWithTestMeta(testMeta,
-			10,
-			m1Pub,
-			m1Priv,
-			post1Hash,
-			1,
-			true,
-			0,
-			true,
-			100,
-		)
+		_nftVaultWithTestMeta(testMeta, 10, m0Pub, m0Priv, lockMetadata(1, 1000, 300))
+
+		nftEntry := DBGetNFTEntryByPostHashSerialNumber(db, postHash, 1)
+		require.True(nftEntry.IsVaulted)
+		require.True(nftEntry.IsForSale)
+		require.True(nftEntry.IsBuyNow)
+		require.Equal(uint64(300), nftEntry.BuyNowPriceNanos)
+		require.Equal(uint64(1000), nftEntry.VaultTotalSharesNanos.Uint64())
+		require.Equal(uint64(1000), shareBalance(m0PKID, 1))
+		require.Equal(uint64(1), DBGetPostEntryByPostHash(db, postHash).NumNFTCopiesForSale)
 	}
 
-	// M2 buys it
+	// Error case: a vaulted NFT can't be vaulted again, transferred, burned, or updated.
 	{
-		_createNFTBidWithTestMeta(testMeta,
-			10,
-			m2Pub,
-			m2Priv,
-			post1Hash,
-			1,
-			100)
+		_, _, _, err = _nftVault(t, chain, db, params, 10, m0Pub, m0Priv, lockMetadata(1, 1000, 300))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTIsAlreadyVaulted)
+
+		_, _, _, err = _transferNFT(t, chain, db, params, 10, m0Pub, m0Priv, m1Pub, postHash, 1, "")
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorCannotTransferVaultedNFT)
+
+		_, _, _, err = _burnNFT(t, chain, db, params, 10, m0Pub, m0Priv, postHash, 1)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorCannotBurnVaultedNFT)
+
+		_, _, _, err = _updateNFT(t, chain, db, params, 10, m0Pub, m0Priv, postHash, 1, false, 0, false, 0)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorCannotUpdateVaultedNFT)
+	}
+
+	// m0 sells 600 shares to m1 and 100 shares to m2.
+	{
+		_nftVaultWithTestMeta(testMeta, 10, m0Pub, m0Priv, transferMetadata(1, m1PkBytes, 600))
+		_nftVaultWithTestMeta(testMeta, 10, m0Pub, m0Priv, transferMetadata(1, m2PkBytes, 100))
+		require.Equal(uint64(300), shareBalance(m0PKID, 1))
+		require.Equal(uint64(600), shareBalance(m1PKID, 1))
+		require.Equal(uint64(100), shareBalance(m2PKID, 1))
+	}
+
+	// Error case: shares can't be overdrawn or sent to yourself.
+	{
+		_, _, _, err = _nftVault(t, chain, db, params, 10, m2Pub, m2Priv, transferMetadata(1, m1PkBytes, 101))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTVaultInsufficientShares)
+
+		_, _, _, err = _nftVault(t, chain, db, params, 10, m2Pub, m2Priv, transferMetadata(1, m2PkBytes, 1))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTVaultCannotTransferSharesToSelf)
+	}
+
+	// Error case: m1 can't redeem the NFT without every share.
+	{
+		_, _, _, err = _nftVault(t, chain, db, params, 10, m1Pub, m1Priv, redeemMetadata(1))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTVaultRedeemRequiresAllShares)
+	}
+
+	// Error case: bids below the buyout price are rejected.
+	{
+		_, _, _, err = _createNFTBid(t, chain, db, params, 10, m2Pub, m2Priv, postHash, 1, 299)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTBidLessThanMinBidAmountNanos)
+	}
+
+	// m2 buys NFT #1 out of the vault. After the 30 nano creator royalty, the remaining
+	// 270 nanos are split 81/162/27 between the holders of 300/600/100 shares.
+	{
+		m0BalanceBefore := _getBalance(t, chain, nil, m0Pub)
+		m1BalanceBefore := _getBalance(t, chain, nil, m1Pub)
+		_createNFTBidWithTestMeta(testMeta, 10, m2Pub, m2Priv, postHash, 1, 300)
+
+		require.Equal(m0BalanceBefore+30+81, _getBalance(t, chain, nil, m0Pub))
+		require.Equal(m1BalanceBefore+162, _getBalance(t, chain, nil, m1Pub))
+
+		nftEntry := DBGetNFTEntryByPostHashSerialNumber(db, postHash, 1)
+		require.Equal(*m2PKID, *nftEntry.OwnerPKID)
+		require.False(nftEntry.IsVaulted)
+		require.False(nftEntry.IsForSale)
+		require.Equal(uint64(0), shareBalance(m0PKID, 1))
+		require.Equal(uint64(0), shareBalance(m1PKID, 1))
+		require.Equal(uint64(0), shareBalance(m2PKID, 1))
+		require.Equal(uint64(0), DBGetPostEntryByPostHash(db, postHash).NumNFTCopiesForSale)
+	}
+
+	// m0 vaults NFT #2, sends all of the shares to m1, and m1 redeems it.
+	{
+		_nftVaultWithTestMeta(testMeta, 10, m0Pub, m0Priv, lockMetadata(2, 10, 500))
+		_nftVaultWithTestMeta(testMeta, 10, m0Pub, m0Priv, transferMetadata(2, m1PkBytes, 10))
+		require.Equal(uint64(0), shareBalance(m0PKID, 2))
+		require.Equal(uint64(10), shareBalance(m1PKID, 2))
+
+		_nftVaultWithTestMeta(testMeta, 10, m1Pub, m1Priv, redeemMetadata(2))
+
+		nftEntry := DBGetNFTEntryByPostHashSerialNumber(db, postHash, 2)
+		require.Equal(*m1PKID, *nftEntry.OwnerPKID)
+		require.False(nftEntry.IsVaulted)
+		require.False(nftEntry.IsForSale)
+		require.Equal(uint64(0), shareBalance(m1PKID, 2))
+		require.Equal(uint64(0), DBGetPostEntryByPostHash(db, postHash).NumNFTCopiesForSale)
+	}
+
+	// Error case: an NFT that isn't vaulted can't be redeemed.
+	{
+		_, _, _, err = _nftVault(t, chain, db, params, 10, m1Pub, m1Priv, redeemMetadata(2))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTIsNotVaulted)
+	}
+
+	// m1 vaults NFT #2 into 1000 shares with a buyout price of 10 nanos and sends
+	// one share to m0. When m2 buys it out, m0's share of the 9 nanos left after
+	// royalties rounds down to zero so m0 only gets the 1 nano creator royalty.
+	{
+		_nftVaultWithTestMeta(testMeta, 10, m1Pub, m1Priv, lockMetadata(2, 1000, 10))
+		_nftVaultWithTestMeta(testMeta, 10, m1Pub, m1Priv, transferMetadata(2, m0PkBytes, 1))
+
+		m0BalanceBefore := _getBalance(t, chain, nil, m0Pub)
+		m1BalanceBefore := _getBalance(t, chain, nil, m1Pub)
+		_createNFTBidWithTestMeta(testMeta, 10, m2Pub, m2Priv, postHash, 2, 10)
+		buyoutTxn := testMeta.txns[len(testMeta.txns)-1]
+
+		require.Equal(m0BalanceBefore+1, _getBalance(t, chain, nil, m0Pub))
+		require.Equal(m1BalanceBefore+9, _getBalance(t, chain, nil, m1Pub))
+		// The outputs are numbered consecutively so stop at the first gap.
+		for ii := uint32(0); ; ii++ {
+			utxoEntry := DbGetUtxoEntryForUtxoKey(db, &UtxoKey{TxID: *buyoutTxn.Hash(), Index: ii})
+			if utxoEntry == nil {
+				break
+			}
+			require.NotEqual(uint64(0), utxoEntry.AmountNanos)
+		}
+
+		nftEntry := DBGetNFTEntryByPostHashSerialNumber(db, postHash, 2)
+		require.Equal(*m2PKID, *nftEntry.OwnerPKID)
+		require.False(nftEntry.IsVaulted)
+		require.Equal(uint64(0), shareBalance(m0PKID, 2))
+		require.Equal(uint64(0), shareBalance(m1PKID, 2))
+	}
+
+	// Roll all successful txns through connect and disconnect loops to make sure nothing breaks.
+	_rollBackTestMetaTxnsAndFlush(testMeta)
+	_applyTestMetaTxnsToMempool(testMeta)
+	_applyTestMetaTxnsToViewAndFlush(testMeta)
+	_disconnectTestMetaTxnsFromViewAndFlush(testMeta)
+	_connectBlockThenDisconnectBlockAndFlush(testMeta)
+}
+
+func TestNFTVouchers(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	// Make m3 a paramUpdater for this test
+	params.ParamUpdaterPublicKeys[MakePkMapKey(m3PkBytes)] = true
+	params.ForkHeights.NFTVouchersBlockHeight = uint32(0)
+
+	// Mine a few blocks to give the senderPkString some money.
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	// We build the testMeta obj after mining blocks so that we save the correct block height.
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
 	}
+	m1PKID := DBGetPKIDEntryForPublicKey(db, m1PkBytes).PKID
+	m2PKID := DBGetPKIDEntryForPublicKey(db, m2PkBytes).PKID
 
-	// M2 puts it on sale in an auction style
-	{
-		_updateNFTWithTestMeta(testMeta,
-			10,
-			m2Pub,
-			m2Priv,
-			post1Hash,
-			1,
-			true,
-			100,
-			false,
-			0,
-		)
-	}
+	// Fund all the keys.
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 1000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 1000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m2Pub, senderPrivString, 1000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m3Pub, senderPrivString, 100)
 
-	// M0 and M3 bid on it
+	// Set max copies to a non-zero value to activate NFTs and charge 2 nanos per copy minted.
 	{
-		_createNFTBidWithTestMeta(testMeta,
-			10,
-			m0Pub,
-			m0Priv,
-			post1Hash,
-			1,
-			200,
-		)
-
-		_createNFTBidWithTestMeta(testMeta,
-			10,
+		_updateGlobalParamsEntryWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
 			m3Pub,
 			m3Priv,
-			post1Hash,
-			1,
-			250,
+			-1, -1, -1,
+			2,    /*createNFTFeeNanos*/
+			1000, /*maxCopiesPerNFT*/
 		)
 	}
 
-	// M2 accepts M3's bid
+	// Create a profile and two posts for m0.
 	{
-		_acceptNFTBidWithTestMeta(testMeta,
-			10,
-			m2Pub,
-			m2Priv,
-			post1Hash,
-			1,
-			m3Pub,
-			250,
-			"",
-		)
+		_updateProfileWithTestMeta(
+			testMeta,
+			10,            /*feeRateNanosPerKB*/
+			m0Pub,         /*updaterPkBase58Check*/
+			m0Priv,        /*updaterPrivBase58Check*/
+			[]byte{},      /*profilePubKey*/
+			"m0",          /*newUsername*/
+			"i am the m0", /*newDescription*/
+			shortPic,      /*newProfilePic*/
+			10*100,        /*newCreatorBasisPoints*/
+			1.25*100*100,  /*newStakeMultipleBasisPoints*/
+			false /*isHidden*/)
+	}
+	submitPost := func(body string) *BlockHash {
+		_submitPostWithTestMeta(
+			testMeta,
+			10,                          /*feeRateNanosPerKB*/
+			m0Pub,                       /*updaterPkBase58Check*/
+			m0Priv,                      /*updaterPrivBase58Check*/
+			[]byte{},                    /*postHashToModify*/
+			[]byte{},                    /*parentStakeID*/
+			&DeSoBodySchema{Body: body}, /*body*/
+			[]byte{},
+			1502947011*1e9, /*tstampNanos*/
+			false /*isHidden*/)
+		return testMeta.txns[len(testMeta.txns)-1].Hash()
+	}
+	lazyPostHash := submitPost("station passes")
+	mintedPostHash := submitPost("minted art")
+
+	voucherForSerialNumber := func(serialNumber uint64, priceNanos uint64) *NFTVoucher {
+		return &NFTVoucher{
+			NFTPostHash:                    lazyPostHash,
+			SerialNumber:                   serialNumber,
+			PriceNanos:                     priceNanos,
+			NumCopies:                      3,
+			NFTRoyaltyToCreatorBasisPoints: 1000,
+			NFTRoyaltyToCoinBasisPoints:    1000,
+		}
 	}
+	voucher1 := voucherForSerialNumber(1, 100)
+	voucher1Signature := _signNFTVoucher(t, voucher1, m0Priv)
 
-	// M3 puts it on sale as a buy now NFT
+	// Error case: the creator can't redeem their own voucher.
 	{
-		_updateNFTWithTestMeta(testMeta,
-			10,
-			m3Pub,
-			m3Priv,
-			post1Hash,
-			1,
-			true,
-			0,
-			true,
-			200,
-			)
+		_, _, _, err = _redeemNFTVoucher(t, chain, db, params, 10, m0Pub, m0Priv, voucher1, voucher1Signature)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTVoucherCannotBeRedeemedByCreator)
 	}
 
-	// M0 buys their NFT back
+	// Error case: the voucher must be signed by the creator and can't be tampered with.
 	{
-		_createNFTBidWithTestMeta(testMeta,
-			10,
-			m0Pub,
-			m0Priv,
-			post1Hash,
-			1,
-			200,
-			)
+		_, _, _, err = _redeemNFTVoucher(t, chain, db, params, 10, m1Pub, m1Priv,
+			voucher1, _signNFTVoucher(t, voucher1, m1Priv))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTVoucherInvalidCreatorSignature)
+
+		_, _, _, err = _redeemNFTVoucher(t, chain, db, params, 10, m1Pub, m1Priv,
+			voucherForSerialNumber(1, 1), voucher1Signature)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTVoucherInvalidCreatorSignature)
 	}
 
-	// M0 puts it on sale again
+	// Error case: a signature over the voucher's bytes without the voucher prefix isn't valid.
 	{
-		_updateNFTWithTestMeta(testMeta,
-			10,
-			m0Pub,
-			m0Priv,
-			post1Hash,
-			1,
-			true,
-			10,
-			true,
-			200,
-			)
+		voucherBytes, err := voucher1.ToBytes()
+		require.NoError(err)
+		m0PrivBytes, _, err := Base58CheckDecode(m0Priv)
+		require.NoError(err)
+		m0PrivKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), m0PrivBytes)
+		signature, err := m0PrivKey.Sign(Sha256DoubleHash(voucherBytes)[:])
+		require.NoError(err)
+
+		_, _, _, err = _redeemNFTVoucher(t, chain, db, params, 10, m1Pub, m1Priv,
+			voucher1, signature.Serialize())
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTVoucherInvalidCreatorSignature)
 	}
 
-	// M3 buys it back again
+	// Error case: the redeemer can't add royalties that the creator didn't sign.
 	{
-		_createNFTBidWithTestMeta(testMeta,
-			10,
-			m3Pub,
-			m3Priv,
-			post1Hash,
-			1,
-			200,
-			)
+		for _, royaltiesMapKey := range []string{DESORoyaltiesMapKey, CoinRoyaltiesMapKey} {
+			royaltiesMapBytes, err := SerializePubKeyToUint64Map(
+				map[PublicKey]uint64{*NewPublicKey(m1PkBytes): 1000})
+			require.NoError(err)
+
+			utxoView, err := NewUtxoView(db, params, nil)
+			require.NoError(err)
+			txn, _, _, _, err := chain.CreateRedeemNFTVoucherTxn(
+				m1PkBytes, voucher1, voucher1Signature,
+				utxoView.GlobalParamsEntry.CreateNFTFeeNanos, 10, nil, []*DeSoOutput{})
+			require.NoError(err)
+			txn.ExtraData = map[string][]byte{royaltiesMapKey: royaltiesMapBytes}
+			_signTxn(t, txn, m1Priv)
+
+			_, _, _, _, err = utxoView.ConnectTransaction(txn, txn.Hash(), getTxnSize(*txn),
+				chain.blockTip().Height+1, true /*verifySignature*/, false /*ignoreUtxos*/)
+			require.Error(err)
+			require.Contains(err.Error(), RuleErrorNFTVoucherCannotHaveAdditionalRoyalties)
+		}
 	}
 
-	// M3 puts it on sale and m2 buys it this time
+	// Error case: the serial number must be within the voucher's copies.
 	{
-		_updateNFTWithTestMeta(testMeta,
-			10,
-			m3Pub,
-			m3Priv,
-			post1Hash,
-			1,
-			true,
-			0,
-			true,
-			150,
-			)
-
-		_createNFTBidWithTestMeta(testMeta,
-			10,
-			m2Pub,
-			m2Priv,
-			post1Hash,
-			1,
-			150,
-			)
+		voucher := voucherForSerialNumber(4, 100)
+		_, _, _, err = _redeemNFTVoucher(t, chain, db, params, 10, m1Pub, m1Priv,
+			voucher, _signNFTVoucher(t, voucher, m0Priv))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTVoucherInvalidSerialNumber)
 	}
 
-	// M2 transfers it to M1 and m1 Accepts
+	// m1 redeems the voucher for serial number 1. The copy is minted directly to m1 and
+	// m0 is paid the full price since m0's coin has no holders to pay a coin royalty to.
 	{
-		_transferNFTWithTestMeta(testMeta,
-			10,
-			m2Pub,
-			m2Priv,
-			m1Pub,
-			post1Hash,
-			1,
-			"",
-			)
+		m0BalanceBefore := _getBalance(t, chain, nil, m0Pub)
+		m1BalanceBefore := _getBalance(t, chain, nil, m1Pub)
+		_redeemNFTVoucherWithTestMeta(testMeta, 10, m1Pub, m1Priv, voucher1, voucher1Signature)
 
-		_acceptNFTTransferWithTestMeta(testMeta,
-			10,
-			m1Pub,
-			m1Priv,
-			post1Hash,
-			1,
-			)
+		require.Equal(m0BalanceBefore+100, _getBalance(t, chain, nil, m0Pub))
+		// m1 pays the price, the 2 nano mint fee and the txn fee.
+		require.Less(_getBalance(t, chain, nil, m1Pub), m1BalanceBefore-102)
+
+		nftEntry := DBGetNFTEntryByPostHashSerialNumber(db, lazyPostHash, 1)
+		require.Equal(*m1PKID, *nftEntry.OwnerPKID)
+		require.False(nftEntry.IsForSale)
+		require.Equal(uint64(100), nftEntry.LastAcceptedBidAmountNanos)
+
+		postEntry := DBGetPostEntryByPostHash(db, lazyPostHash)
+		require.True(postEntry.IsNFT)
+		require.True(postEntry.IsLazyMintedNFT)
+		require.Equal(uint64(3), postEntry.NumNFTCopies)
+		require.Equal(uint64(1000), postEntry.NFTRoyaltyToCreatorBasisPoints)
+		require.Equal(uint64(1000), postEntry.NFTRoyaltyToCoinBasisPoints)
+
+		redemptionEntry := DbGetNFTVoucherRedemptionEntry(db, lazyPostHash, 1)
+		require.NotNil(redemptionEntry)
+		require.Equal(*m1PKID, *redemptionEntry.RedeemerPKID)
 	}
 
-	// M1 puts it on sale as an auction
+	// Error case: the voucher can't be replayed.
 	{
-		_updateNFTWithTestMeta(testMeta,
-			10,
-			m1Pub,
-			m1Priv,
-			post1Hash,
-			1,
-			true,
-			100,
-			false,
-			0,
-			)
+		_, _, _, err = _redeemNFTVoucher(t, chain, db, params, 10, m2Pub, m2Priv, voucher1, voucher1Signature)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTVoucherAlreadyRedeemed)
 	}
 
-	// M4, M5, and M6 submit bids
+	// Error case: later vouchers must agree with the NFT created by the first redemption.
 	{
-		_createNFTBidWithTestMeta(testMeta,
-			10,
-			m4Pub,
-			m4Priv,
-			post1Hash,
-			1,
-			100,
-			)
+		voucher := voucherForSerialNumber(2, 50)
+		voucher.NumCopies = 5
+		_, _, _, err = _redeemNFTVoucher(t, chain, db, params, 10, m2Pub, m2Priv,
+			voucher, _signNFTVoucher(t, voucher, m0Priv))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTVoucherDoesNotMatchNFT)
+	}
 
-		_createNFTBidWithTestMeta(testMeta,
-			10,
-			m5Pub,
-			m5Priv,
-			post1Hash,
-			1,
-			120,
-		)
+	// m2 redeems a cheaper voucher for serial number 2.
+	{
+		voucher := voucherForSerialNumber(2, 50)
+		m0BalanceBefore := _getBalance(t, chain, nil, m0Pub)
+		_redeemNFTVoucherWithTestMeta(testMeta, 10, m2Pub, m2Priv, voucher, _signNFTVoucher(t, voucher, m0Priv))
 
-		_createNFTBidWithTestMeta(testMeta,
-			10,
-			m6Pub,
-			m6Priv,
-			post1Hash,
-			1,
-			110,
-		)
+		require.Equal(m0BalanceBefore+50, _getBalance(t, chain, nil, m0Pub))
+		nftEntry := DBGetNFTEntryByPostHashSerialNumber(db, lazyPostHash, 2)
+		require.Equal(*m2PKID, *nftEntry.OwnerPKID)
+		require.Nil(DBGetNFTEntryByPostHashSerialNumber(db, lazyPostHash, 3))
 	}
 
-	// M1 accepts M5's bid
+	// Error case: once m1 burns their copy, the voucher still can't be replayed.
 	{
-		_acceptNFTBidWithTestMeta(testMeta,
-			10,
-			m1Pub,
-			m1Priv,
-			post1Hash,
-			1,
-			m5Pub,
-			120,
-			"",
-		)
+		_burnNFTWithTestMeta(testMeta, 10, m1Pub, m1Priv, lazyPostHash, 1)
+		require.Nil(DBGetNFTEntryByPostHashSerialNumber(db, lazyPostHash, 1))
+
+		_, _, _, err = _redeemNFTVoucher(t, chain, db, params, 10, m2Pub, m2Priv, voucher1, voucher1Signature)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTVoucherAlreadyRedeemed)
 	}
 
-	// M5 puts it on sale as a buy now NFT and M4 buys it
+	// Error case: posts minted with CreateNFT can't be lazily minted.
 	{
-		_updateNFTWithTestMeta(testMeta,
-			10,
-			m5Pub,
-			m5Priv,
-			post1Hash,
-			1,
-			true,
-			0,
-			true,
-			100,
+		_createNFTWithTestMeta(
+			testMeta,
+			10, /*FeeRateNanosPerKB*/
+			m0Pub,
+			m0Priv,
+			mintedPostHash,
+			1,     /*NumCopies*/
+			false, /*HasUnlockable*/
+			false, /*IsForSale*/
+			0,     /*MinBidAmountNanos*/
+			2,     /*nftFee*/
+			0,     /*nftRoyaltyToCreatorBasisPoints*/
+			0,     /*nftRoyaltyToCoinBasisPoints*/
+			false, /*IsBuyNow*/
+			0,     /*BuyNowPriceNanos*/
 		)
 
-		_createNFTBidWithTestMeta(testMeta,
-			10,
-			m4Pub,
-			m4Priv,
-			post1Hash,
-			1,
-			100,
-			)
+		voucher := &NFTVoucher{
+			NFTPostHash:  mintedPostHash,
+			SerialNumber: 1,
+			PriceNanos:   10,
+			NumCopies:    1,
+		}
+		_, _, _, err = _redeemNFTVoucher(t, chain, db, params, 10, m1Pub, m1Priv,
+			voucher, _signNFTVoucher(t, voucher, m0Priv))
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorNFTVoucherOnNonLazyMintedNFT)
 	}
 
+	// Roll all successful txns through connect and disconnect loops to make sure nothing breaks.
 	_rollBackTestMetaTxnsAndFlush(testMeta)
 	_applyTestMetaTxnsToMempool(testMeta)
 	_applyTestMetaTxnsToViewAndFlush(testMeta)
 	_disconnectTestMetaTxnsFromViewAndFlush(testMeta)
 	_connectBlockThenDisconnectBlockAndFlush(testMeta)
 }
-
@@ -0,0 +1,503 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/holiman/uint256"
+	"github.com/pkg/errors"
+)
+
+// NFTVaultPKID returns the PKID that the shares of a vaulted NFT are issued under.
+// Shares are stored as DAO coin balances with this PKID as the creator. The first
+// byte is always zero so that it can never collide with the PKID of a public key,
+// since compressed public keys always start with 0x02 or 0x03.
+func NFTVaultPKID(nftPostHash *BlockHash, serialNumber uint64) *PKID {
+	preimage := append(append([]byte{}, nftPostHash[:]...), UintToBuf(serialNumber)...)
+	vaultHash := Sha256DoubleHash(preimage)
+	vaultPKID := &PKID{}
+	copy(vaultPKID[1:], vaultHash[:])
+	return vaultPKID
+}
+
+// GetNFTVaultShareBalanceEntry returns the number of shares the holder owns in the
+// vaulted NFT, or nil if they don't own any.
+func (bav *UtxoView) GetNFTVaultShareBalanceEntry(
+	holderPKID *PKID, nftPostHash *BlockHash, serialNumber uint64) *BalanceEntry {
+
+	balanceEntry := bav._getBalanceEntryForHODLerPKIDAndCreatorPKID(
+		holderPKID, NFTVaultPKID(nftPostHash, serialNumber), true)
+	if balanceEntry == nil || balanceEntry.isDeleted || balanceEntry.BalanceNanos.IsZero() {
+		return nil
+	}
+	return balanceEntry
+}
+
+// GetNFTVaultShareHolders returns the share balances of everyone who owns part of
+// the vaulted NFT, ordered by holder PKID.
+func (bav *UtxoView) GetNFTVaultShareHolders(nftPostHash *BlockHash, serialNumber uint64) ([]*BalanceEntry, error) {
+	holderEntries, _, err := bav.GetDAOCoinHolders(NFTVaultPKID(nftPostHash, serialNumber), false)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetNFTVaultShareHolders: Problem fetching holders: ")
+	}
+
+	shareHolders := []*BalanceEntry{}
+	for _, balanceEntry := range holderEntries {
+		if balanceEntry.isDeleted || balanceEntry.BalanceNanos.IsZero() {
+			continue
+		}
+		shareHolders = append(shareHolders, balanceEntry)
+	}
+	sort.Slice(shareHolders, func(ii, jj int) bool {
+		return bytes.Compare(shareHolders[ii].HODLerPKID[:], shareHolders[jj].HODLerPKID[:]) < 0
+	})
+	return shareHolders, nil
+}
+
+// _setNFTVaultShareBalanceEntry sets the holder's share balance, deleting it once it
+// reaches zero so that the holder no longer shows up in the vault's holder list.
+func (bav *UtxoView) _setNFTVaultShareBalanceEntry(balanceEntry *BalanceEntry) {
+	if balanceEntry.BalanceNanos.IsZero() {
+		bav._deleteBalanceEntryMappingsWithPKIDs(
+			balanceEntry, balanceEntry.HODLerPKID, balanceEntry.CreatorPKID, true)
+		return
+	}
+	bav._setDAOCoinBalanceEntryMappings(balanceEntry)
+}
+
+// _revertNFTVaultShareBalanceEntry restores the holder's share balance to what it was
+// before a transaction. A nil prevBalanceEntry means the holder had no shares.
+func (bav *UtxoView) _revertNFTVaultShareBalanceEntry(
+	holderPKID *PKID, vaultPKID *PKID, prevBalanceEntry *BalanceEntry) {
+
+	if prevBalanceEntry != nil {
+		balanceEntry := *prevBalanceEntry
+		bav._setNFTVaultShareBalanceEntry(&balanceEntry)
+		return
+	}
+	bav._setNFTVaultShareBalanceEntry(&BalanceEntry{
+		HODLerPKID:   holderPKID,
+		CreatorPKID:  vaultPKID,
+		BalanceNanos: *uint256.NewInt(),
+	})
+}
+
+// _helpConnectNFTVaultBuyout pays the proceeds from the sale of a vaulted NFT out to
+// its share holders pro rata and burns their shares. The nanos lost to rounding go to
+// the holder with the most shares. The burned balances are returned so that the sale
+// can be disconnected.
+func (bav *UtxoView) _helpConnectNFTVaultBuyout(
+	nftEntry *NFTEntry, proceedsNanos uint64,
+	createUTXO func(amountNanos uint64, publicKey []byte, utxoType UtxoType) error) (
+	_prevShareBalanceEntries []*BalanceEntry, _err error) {
+
+	shareHolders, err := bav.GetNFTVaultShareHolders(nftEntry.NFTPostHash, nftEntry.SerialNumber)
+	if err != nil {
+		return nil, errors.Wrapf(err, "_helpConnectNFTVaultBuyout: ")
+	}
+	if len(shareHolders) == 0 {
+		return nil, fmt.Errorf("_helpConnectNFTVaultBuyout: vaulted NFT %v #%d has no share holders; "+
+			"this should never happen", nftEntry.NFTPostHash, nftEntry.SerialNumber)
+	}
+
+	// Sanity-check that the holders account for every share.
+	totalShares := big.NewInt(0)
+	largestHolderIndex := 0
+	for ii, balanceEntry := range shareHolders {
+		totalShares.Add(totalShares, balanceEntry.BalanceNanos.ToBig())
+		if balanceEntry.BalanceNanos.Gt(&shareHolders[largestHolderIndex].BalanceNanos) {
+			largestHolderIndex = ii
+		}
+	}
+	if totalShares.Cmp(nftEntry.VaultTotalSharesNanos.ToBig()) != 0 {
+		return nil, fmt.Errorf("_helpConnectNFTVaultBuyout: holders own %v shares but the vault "+
+			"issued %v; this should never happen", totalShares, nftEntry.VaultTotalSharesNanos.ToBig())
+	}
+
+	// Calculated as: (proceedsNanos * BalanceNanos) / VaultTotalSharesNanos
+	payouts := make([]uint64, len(shareHolders))
+	totalPayoutNanos := uint64(0)
+	for ii, balanceEntry := range shareHolders {
+		payouts[ii] = IntDiv(
+			IntMul(big.NewInt(0).SetUint64(proceedsNanos), balanceEntry.BalanceNanos.ToBig()),
+			totalShares).Uint64()
+		totalPayoutNanos += payouts[ii]
+	}
+	payouts[largestHolderIndex] += proceedsNanos - totalPayoutNanos
+
+	prevShareBalanceEntries := []*BalanceEntry{}
+	for ii, balanceEntry := range shareHolders {
+		// Holders with too few shares to be owed anything don't get an empty UTXO.
+		// Disconnect only unadds the UTXOs recorded in NFTPaymentUtxoKeys so it
+		// skips them too.
+		if payouts[ii] > 0 {
+			holderPublicKey := bav.GetPublicKeyForPKID(balanceEntry.HODLerPKID)
+			if err = createUTXO(payouts[ii], holderPublicKey, UtxoTypeNFTSeller); err != nil {
+				return nil, errors.Wrapf(err, "_helpConnectNFTVaultBuyout: Problem creating UTXO for "+
+					"share holder %v: ", PkToStringBoth(holderPublicKey))
+			}
+		}
+
+		// Burn the holder's shares, even if they weren't owed anything.
+		prevBalanceEntry := *balanceEntry
+		prevShareBalanceEntries = append(prevShareBalanceEntries, &prevBalanceEntry)
+		bav._setNFTVaultShareBalanceEntry(&BalanceEntry{
+			HODLerPKID:   balanceEntry.HODLerPKID,
+			CreatorPKID:  balanceEntry.CreatorPKID,
+			BalanceNanos: *uint256.NewInt(),
+		})
+	}
+
+	return prevShareBalanceEntries, nil
+}
+
+func (bav *UtxoView) _connectNFTVault(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
+	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
+
+	if blockHeight < bav.Params.ForkHeights.NFTVaultsBlockHeight {
+		return 0, 0, nil, RuleErrorNFTVaultBeforeBlockHeight
+	}
+
+	// Check that the transaction has the right TxnType.
+	if txn.TxnMeta.GetTxnType() != TxnTypeNFTVault {
+		return 0, 0, nil, fmt.Errorf("_connectNFTVault: called with bad TxnType %s",
+			txn.TxnMeta.GetTxnType().String())
+	}
+	txMeta := txn.TxnMeta.(*NFTVaultMetadata)
+
+	// Verify the NFT entry exists.
+	nftKey := MakeNFTKey(txMeta.NFTPostHash, txMeta.SerialNumber)
+	prevNFTEntry := bav.GetNFTEntryForNFTKey(&nftKey)
+	if prevNFTEntry == nil || prevNFTEntry.isDeleted {
+		return 0, 0, nil, RuleErrorNFTVaultOnNonExistentNFT
+	}
+
+	transactorPKID := bav.GetPKIDForPublicKey(txn.PublicKey)
+	if transactorPKID == nil || transactorPKID.isDeleted {
+		return 0, 0, nil, fmt.Errorf("_connectNFTVault: non-existent transactorPKID: %s",
+			PkToString(txn.PublicKey, bav.Params))
+	}
+
+	// Connect basic txn to get the total input and the total output without
+	// considering the transaction metadata.
+	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
+		txn, txHash, blockHeight, verifySignatures)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectNFTVault: ")
+	}
+
+	// Force the input to be non-zero so that we can prevent replay attacks.
+	if totalInput == 0 {
+		return 0, 0, nil, RuleErrorNFTVaultRequiresNonZeroInput
+	}
+
+	if verifySignatures {
+		// _connectBasicTransfer has already checked that the transaction is
+		// signed by the top-level public key, which we take to be the owner
+		// of the NFT or of the shares.
+	}
+
+	var utxoOp *UtxoOperation
+	switch txMeta.OperationType {
+	case NFTVaultOperationTypeLock:
+		utxoOp, err = bav._helpConnectNFTVaultLock(txMeta, prevNFTEntry, transactorPKID.PKID)
+	case NFTVaultOperationTypeRedeem:
+		utxoOp, err = bav._helpConnectNFTVaultRedeem(txMeta, prevNFTEntry, transactorPKID.PKID)
+	case NFTVaultOperationTypeTransferShares:
+		utxoOp, err = bav._helpConnectNFTVaultTransferShares(
+			txn, txMeta, prevNFTEntry, transactorPKID.PKID)
+	default:
+		err = errors.Wrapf(RuleErrorNFTVaultInvalidOperationType,
+			"OperationType = %d", txMeta.OperationType)
+	}
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectNFTVault: ")
+	}
+
+	// Add an operation to the list at the end indicating we've connected an NFT vault txn.
+	utxoOpsForTxn = append(utxoOpsForTxn, utxoOp)
+
+	return totalInput, totalOutput, utxoOpsForTxn, nil
+}
+
+func (bav *UtxoView) _helpConnectNFTVaultLock(
+	txMeta *NFTVaultMetadata, prevNFTEntry *NFTEntry, transactorPKID *PKID) (*UtxoOperation, error) {
+
+	// Verify that the transactor owns the NFT and that it can be vaulted.
+	if !reflect.DeepEqual(prevNFTEntry.OwnerPKID, transactorPKID) {
+		return nil, RuleErrorNFTVaultLockByNonOwner
+	}
+	if prevNFTEntry.IsVaulted {
+		return nil, RuleErrorNFTIsAlreadyVaulted
+	}
+	if prevNFTEntry.IsPending {
+		return nil, RuleErrorCannotVaultPendingNFT
+	}
+	if prevNFTEntry.IsForSale {
+		return nil, RuleErrorCannotVaultNFTThatIsForSale
+	}
+	if txMeta.TotalSharesNanos.IsZero() {
+		return nil, RuleErrorNFTVaultMustMintNonZeroShares
+	}
+	if txMeta.BuyoutPriceNanos == 0 {
+		return nil, RuleErrorNFTVaultBuyoutPriceMustBeNonZero
+	}
+
+	// Get the postEntry so we can update the number of NFT copies for sale.
+	nftPostEntry := bav.GetPostEntryForPostHash(txMeta.NFTPostHash)
+	if nftPostEntry == nil || nftPostEntry.isDeleted {
+		return nil, fmt.Errorf("_helpConnectNFTVaultLock: non-existent nftPostEntry for NFTPostHash: %s",
+			txMeta.NFTPostHash.String())
+	}
+
+	// A buyout happens without the owner, so there is no one to provide unlockable text.
+	if nftPostEntry.HasUnlockable {
+		return nil, RuleErrorCannotVaultUnlockableNFT
+	}
+
+	// Sanity-check that the vault doesn't have any shares outstanding.
+	prevBalanceEntry := bav.GetNFTVaultShareBalanceEntry(
+		transactorPKID, txMeta.NFTPostHash, txMeta.SerialNumber)
+	if prevBalanceEntry != nil {
+		return nil, fmt.Errorf("_helpConnectNFTVaultLock: owner already holds %v shares of an "+
+			"unvaulted NFT; this should never happen", prevBalanceEntry.BalanceNanos.ToBig())
+	}
+
+	// Mint every share to the owner.
+	bav._setNFTVaultShareBalanceEntry(&BalanceEntry{
+		HODLerPKID:   transactorPKID,
+		CreatorPKID:  NFTVaultPKID(txMeta.NFTPostHash, txMeta.SerialNumber),
+		BalanceNanos: txMeta.TotalSharesNanos,
+	})
+
+	// Put the NFT up for sale as a Buy Now NFT at the buyout price. Since the min bid
+	// is also the buyout price, every bid on a vaulted NFT is a buyout.
+	newNFTEntry := *prevNFTEntry
+	newNFTEntry.IsForSale = true
+	newNFTEntry.IsBuyNow = true
+	newNFTEntry.BuyNowPriceNanos = txMeta.BuyoutPriceNanos
+	newNFTEntry.MinBidAmountNanos = txMeta.BuyoutPriceNanos
+	newNFTEntry.IsVaulted = true
+	newNFTEntry.VaultTotalSharesNanos = txMeta.TotalSharesNanos
+	newNFTEntry.VaultBuyoutPriceNanos = txMeta.BuyoutPriceNanos
+	bav._setNFTEntryMappings(&newNFTEntry)
+
+	// Save a copy of the post entry so that we can safely modify it.
+	prevPostEntry := &PostEntry{}
+	*prevPostEntry = *nftPostEntry
+
+	// Not for sale --> For sale.
+	nftPostEntry.NumNFTCopiesForSale++
+	bav._setPostEntryMappings(nftPostEntry)
+
+	return &UtxoOperation{
+		Type:          OperationTypeNFTVault,
+		PrevNFTEntry:  prevNFTEntry,
+		PrevPostEntry: prevPostEntry,
+	}, nil
+}
+
+func (bav *UtxoView) _helpConnectNFTVaultRedeem(
+	txMeta *NFTVaultMetadata, prevNFTEntry *NFTEntry, transactorPKID *PKID) (*UtxoOperation, error) {
+
+	if !prevNFTEntry.IsVaulted {
+		return nil, RuleErrorNFTIsNotVaulted
+	}
+
+	// Verify that the transactor holds every share.
+	prevBalanceEntry := bav.GetNFTVaultShareBalanceEntry(
+		transactorPKID, txMeta.NFTPostHash, txMeta.SerialNumber)
+	if prevBalanceEntry == nil || !prevBalanceEntry.BalanceNanos.Eq(&prevNFTEntry.VaultTotalSharesNanos) {
+		return nil, RuleErrorNFTVaultRedeemRequiresAllShares
+	}
+
+	// Get the postEntry so we can update the number of NFT copies for sale.
+	nftPostEntry := bav.GetPostEntryForPostHash(txMeta.NFTPostHash)
+	if nftPostEntry == nil || nftPostEntry.isDeleted {
+		return nil, fmt.Errorf("_helpConnectNFTVaultRedeem: non-existent nftPostEntry for NFTPostHash: %s",
+			txMeta.NFTPostHash.String())
+	}
+
+	// Burn the shares. Save a copy of the balance first since we take it out of the view.
+	prevBalanceEntryCopy := *prevBalanceEntry
+	bav._setNFTVaultShareBalanceEntry(&BalanceEntry{
+		HODLerPKID:   transactorPKID,
+		CreatorPKID:  prevBalanceEntry.CreatorPKID,
+		BalanceNanos: *uint256.NewInt(),
+	})
+
+	// Hand the NFT to the redeemer and take it off the market.
+	newNFTEntry := *prevNFTEntry
+	newNFTEntry.LastOwnerPKID = prevNFTEntry.OwnerPKID
+	newNFTEntry.OwnerPKID = transactorPKID
+	newNFTEntry.IsForSale = false
+	newNFTEntry.IsBuyNow = false
+	newNFTEntry.BuyNowPriceNanos = 0
+	newNFTEntry.MinBidAmountNanos = 0
+	newNFTEntry.IsVaulted = false
+	newNFTEntry.VaultTotalSharesNanos = *uint256.NewInt()
+	newNFTEntry.VaultBuyoutPriceNanos = 0
+	bav._setNFTEntryMappings(&newNFTEntry)
+
+	// Save a copy of the post entry so that we can safely modify it.
+	prevPostEntry := &PostEntry{}
+	*prevPostEntry = *nftPostEntry
+
+	// For sale --> Not for sale.
+	nftPostEntry.NumNFTCopiesForSale--
+	bav._setPostEntryMappings(nftPostEntry)
+
+	return &UtxoOperation{
+		Type:                       OperationTypeNFTVault,
+		PrevNFTEntry:               prevNFTEntry,
+		PrevPostEntry:              prevPostEntry,
+		PrevTransactorBalanceEntry: &prevBalanceEntryCopy,
+	}, nil
+}
+
+func (bav *UtxoView) _helpConnectNFTVaultTransferShares(
+	txn *MsgDeSoTxn, txMeta *NFTVaultMetadata, prevNFTEntry *NFTEntry, senderPKID *PKID) (
+	*UtxoOperation, error) {
+
+	if !prevNFTEntry.IsVaulted {
+		return nil, RuleErrorNFTIsNotVaulted
+	}
+
+	// Check that the specified receiver public key is valid.
+	if len(txMeta.ReceiverPublicKey) != btcec.PubKeyBytesLenCompressed {
+		return nil, RuleErrorNFTVaultInvalidReceiverPubKeySize
+	}
+	if _, err := btcec.ParsePubKey(txMeta.ReceiverPublicKey, btcec.S256()); err != nil {
+		return nil, errors.Wrapf(RuleErrorNFTVaultInvalidReceiverPubKeySize, "%v", err)
+	}
+
+	// Check that the sender and receiver public keys are different.
+	if reflect.DeepEqual(txn.PublicKey, txMeta.ReceiverPublicKey) {
+		return nil, RuleErrorNFTVaultCannotTransferSharesToSelf
+	}
+
+	if txMeta.SharesToTransferNanos.IsZero() {
+		return nil, RuleErrorNFTVaultMustTransferNonZeroShares
+	}
+
+	receiverPKID := bav.GetPKIDForPublicKey(txMeta.ReceiverPublicKey)
+	if receiverPKID == nil || receiverPKID.isDeleted {
+		return nil, fmt.Errorf(
+			"_helpConnectNFTVaultTransferShares: Found nil or deleted PKID for receiver, this should never "+
+				"happen. Receiver pubkey: %v", PkToStringMainnet(txMeta.ReceiverPublicKey))
+	}
+
+	// Verify that the sender has enough shares.
+	senderBalanceEntry := bav.GetNFTVaultShareBalanceEntry(
+		senderPKID, txMeta.NFTPostHash, txMeta.SerialNumber)
+	if senderBalanceEntry == nil || senderBalanceEntry.BalanceNanos.Lt(&txMeta.SharesToTransferNanos) {
+		return nil, RuleErrorNFTVaultInsufficientShares
+	}
+	receiverBalanceEntry := bav.GetNFTVaultShareBalanceEntry(
+		receiverPKID.PKID, txMeta.NFTPostHash, txMeta.SerialNumber)
+
+	// Save copies of the balances so that we can safely modify them.
+	prevSenderBalanceEntry := *senderBalanceEntry
+	var prevReceiverBalanceEntry *BalanceEntry
+	if receiverBalanceEntry != nil {
+		prevReceiverBalanceEntryCopy := *receiverBalanceEntry
+		prevReceiverBalanceEntry = &prevReceiverBalanceEntryCopy
+	}
+
+	newSenderBalanceEntry := prevSenderBalanceEntry
+	newSenderBalanceEntry.BalanceNanos = *uint256.NewInt().Sub(
+		&prevSenderBalanceEntry.BalanceNanos, &txMeta.SharesToTransferNanos)
+	bav._setNFTVaultShareBalanceEntry(&newSenderBalanceEntry)
+
+	newReceiverBalanceEntry := &BalanceEntry{
+		HODLerPKID:   receiverPKID.PKID,
+		CreatorPKID:  NFTVaultPKID(txMeta.NFTPostHash, txMeta.SerialNumber),
+		BalanceNanos: *uint256.NewInt(),
+	}
+	if prevReceiverBalanceEntry != nil {
+		*newReceiverBalanceEntry = *prevReceiverBalanceEntry
+	}
+	// Shares can't overflow since the receiver can never hold more than the vault issued.
+	newReceiverBalanceEntry.BalanceNanos = *uint256.NewInt().Add(
+		&newReceiverBalanceEntry.BalanceNanos, &txMeta.SharesToTransferNanos)
+	bav._setNFTVaultShareBalanceEntry(newReceiverBalanceEntry)
+
+	return &UtxoOperation{
+		Type:                     OperationTypeNFTVault,
+		PrevSenderBalanceEntry:   &prevSenderBalanceEntry,
+		PrevReceiverBalanceEntry: prevReceiverBalanceEntry,
+	}, nil
+}
+
+func (bav *UtxoView) _disconnectNFTVault(
+	operationType OperationType, currentTxn *MsgDeSoTxn, txnHash *BlockHash,
+	utxoOpsForTxn []*UtxoOperation, blockHeight uint32) error {
+
+	// Verify that the last operation is an NFTVault operation
+	if len(utxoOpsForTxn) == 0 {
+		return fmt.Errorf("_disconnectNFTVault: utxoOperations are missing")
+	}
+	operationIndex := len(utxoOpsForTxn) - 1
+	if utxoOpsForTxn[operationIndex].Type != OperationTypeNFTVault {
+		return fmt.Errorf("_disconnectNFTVault: Trying to revert "+
+			"OperationTypeNFTVault but found type %v",
+			utxoOpsForTxn[operationIndex].Type)
+	}
+	txMeta := currentTxn.TxnMeta.(*NFTVaultMetadata)
+	operationData := utxoOpsForTxn[operationIndex]
+
+	transactorPKID := bav.GetPKIDForPublicKey(currentTxn.PublicKey)
+	if transactorPKID == nil || transactorPKID.isDeleted {
+		return fmt.Errorf("_disconnectNFTVault: PKID for transactor public key %v doesn't exist; "+
+			"this should never happen", PkToStringBoth(currentTxn.PublicKey))
+	}
+	vaultPKID := NFTVaultPKID(txMeta.NFTPostHash, txMeta.SerialNumber)
+
+	switch txMeta.OperationType {
+	case NFTVaultOperationTypeLock, NFTVaultOperationTypeRedeem:
+		// Revert the NFT entry and the post entry since NumNFTCopiesForSale changed.
+		if operationData.PrevNFTEntry == nil || operationData.PrevNFTEntry.isDeleted {
+			return fmt.Errorf("_disconnectNFTVault: prev NFT entry doesn't exist; " +
+				"this should never happen")
+		}
+		if operationData.PrevPostEntry == nil || operationData.PrevPostEntry.isDeleted {
+			return fmt.Errorf("_disconnectNFTVault: prev post entry doesn't exist; " +
+				"this should never happen")
+		}
+		bav._setNFTEntryMappings(operationData.PrevNFTEntry)
+		bav._setPostEntryMappings(operationData.PrevPostEntry)
+
+		// Unmint the owner's shares, or give the redeemer back the shares they burned.
+		bav._revertNFTVaultShareBalanceEntry(
+			transactorPKID.PKID, vaultPKID, operationData.PrevTransactorBalanceEntry)
+
+	case NFTVaultOperationTypeTransferShares:
+		if operationData.PrevSenderBalanceEntry == nil {
+			return fmt.Errorf("_disconnectNFTVault: prev sender balance entry doesn't exist; " +
+				"this should never happen")
+		}
+		receiverPKID := bav.GetPKIDForPublicKey(txMeta.ReceiverPublicKey)
+		if receiverPKID == nil || receiverPKID.isDeleted {
+			return fmt.Errorf("_disconnectNFTVault: PKID for receiver public key %v doesn't exist; "+
+				"this should never happen", PkToStringBoth(txMeta.ReceiverPublicKey))
+		}
+		bav._revertNFTVaultShareBalanceEntry(
+			transactorPKID.PKID, vaultPKID, operationData.PrevSenderBalanceEntry)
+		bav._revertNFTVaultShareBalanceEntry(
+			receiverPKID.PKID, vaultPKID, operationData.PrevReceiverBalanceEntry)
+
+	default:
+		return fmt.Errorf("_disconnectNFTVault: invalid OperationType %d; "+
+			"this should never happen", txMeta.OperationType)
+	}
+
+	// Now revert the basic transfer with the remaining operations. Cut off
+	// the NFTVault operation at the end since we just reverted it.
+	return bav._disconnectBasicTransfer(
+		currentTxn, txnHash, utxoOpsForTxn[:operationIndex], blockHeight)
+}
@@ -0,0 +1,393 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// NFTVoucherSignaturePrefix is prepended to a voucher's bytes before the creator
+// signs them so that a signature made for some other purpose can't be passed off
+// as a voucher.
+var NFTVoucherSignaturePrefix = []byte("DeSoNFTVoucher")
+
+// NFTVoucherSignatureData returns the bytes the creator signs to issue the voucher.
+func NFTVoucherSignatureData(voucher *NFTVoucher) ([]byte, error) {
+	voucherBytes, err := voucher.ToBytes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "NFTVoucherSignatureData: ")
+	}
+	return append(append([]byte{}, NFTVoucherSignaturePrefix...), voucherBytes...), nil
+}
+
+// GetNFTVoucherRedemptionEntry returns the redemption of the voucher for the given
+// NFT copy, or nil if the copy hasn't been minted from a voucher.
+func (bav *UtxoView) GetNFTVoucherRedemptionEntry(
+	nftPostHash *BlockHash, serialNumber uint64) *NFTVoucherRedemptionEntry {
+
+	// If an entry exists in the in-memory map, return the value of that mapping.
+	nftKey := MakeNFTKey(nftPostHash, serialNumber)
+	if mapValue, existsMapValue := bav.NFTKeyToNFTVoucherRedemptionEntry[nftKey]; existsMapValue {
+		if mapValue.isDeleted {
+			return nil
+		}
+		return mapValue
+	}
+
+	// If we get here it means no value exists in our in-memory map. In this case,
+	// defer to the db. If a mapping exists in the db, return it. If not, return
+	// nil. Either way, save the value to the in-memory view mapping got later.
+	var redemptionEntry *NFTVoucherRedemptionEntry
+	if bav.Postgres != nil {
+		if redemption := bav.Postgres.GetNFTVoucherRedemption(nftPostHash, serialNumber); redemption != nil {
+			redemptionEntry = redemption.NewNFTVoucherRedemptionEntry()
+		}
+	} else {
+		redemptionEntry = DbGetNFTVoucherRedemptionEntry(bav.Handle, nftPostHash, serialNumber)
+	}
+	if redemptionEntry != nil {
+		bav._setNFTVoucherRedemptionEntryMappings(redemptionEntry)
+	}
+	return redemptionEntry
+}
+
+func (bav *UtxoView) _setNFTVoucherRedemptionEntryMappings(redemptionEntry *NFTVoucherRedemptionEntry) {
+	// This function shouldn't be called with nil.
+	if redemptionEntry == nil {
+		glog.Errorf("_setNFTVoucherRedemptionEntryMappings: Called with nil NFTVoucherRedemptionEntry; " +
+			"this should never happen.")
+		return
+	}
+
+	nftKey := MakeNFTKey(redemptionEntry.NFTPostHash, redemptionEntry.SerialNumber)
+	bav.NFTKeyToNFTVoucherRedemptionEntry[nftKey] = redemptionEntry
+}
+
+func (bav *UtxoView) _deleteNFTVoucherRedemptionEntryMappings(redemptionEntry *NFTVoucherRedemptionEntry) {
+
+	// Create a tombstone entry.
+	tombstoneRedemptionEntry := *redemptionEntry
+	tombstoneRedemptionEntry.isDeleted = true
+
+	// Set the mappings to point to the tombstone entry.
+	bav._setNFTVoucherRedemptionEntryMappings(&tombstoneRedemptionEntry)
+}
+
+func (bav *UtxoView) _connectRedeemNFTVoucher(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
+	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
+
+	if blockHeight < bav.Params.ForkHeights.NFTVouchersBlockHeight {
+		return 0, 0, nil, RuleErrorRedeemNFTVoucherBeforeBlockHeight
+	}
+	if bav.GlobalParamsEntry.MaxCopiesPerNFT == 0 {
+		return 0, 0, nil, fmt.Errorf("_connectRedeemNFTVoucher: called with zero MaxCopiesPerNFT")
+	}
+
+	// Check that the transaction has the right TxnType.
+	if txn.TxnMeta.GetTxnType() != TxnTypeRedeemNFTVoucher {
+		return 0, 0, nil, fmt.Errorf("_connectRedeemNFTVoucher: called with bad TxnType %s",
+			txn.TxnMeta.GetTxnType().String())
+	}
+	txMeta := txn.TxnMeta.(*RedeemNFTVoucherMetadata)
+	voucher := txMeta.Voucher
+	if voucher == nil {
+		return 0, 0, nil, fmt.Errorf("_connectRedeemNFTVoucher: called with nil Voucher")
+	}
+
+	postEntry := bav.GetPostEntryForPostHash(voucher.NFTPostHash)
+	if postEntry == nil || postEntry.isDeleted {
+		return 0, 0, nil, RuleErrorNFTVoucherOnNonexistentPost
+	}
+	if IsVanillaRepost(postEntry) {
+		return 0, 0, nil, RuleErrorNFTVoucherOnVanillaRepost
+	}
+
+	// The creator signs and is paid with the key the poster's account has now, in
+	// case it was rotated since the post was made.
+	creatorPublicKey := bav.GetCurrentPublicKey(postEntry.PosterPublicKey)
+	posterPKID := bav.GetPKIDForPublicKey(creatorPublicKey)
+	if posterPKID == nil || posterPKID.isDeleted {
+		return 0, 0, nil, fmt.Errorf("_connectRedeemNFTVoucher: non-existent posterPKID: %s",
+			PkToString(creatorPublicKey, bav.Params))
+	}
+	redeemerPKID := bav.GetPKIDForPublicKey(txn.PublicKey)
+	if redeemerPKID == nil || redeemerPKID.isDeleted {
+		return 0, 0, nil, fmt.Errorf("_connectRedeemNFTVoucher: non-existent redeemerPKID: %s",
+			PkToString(txn.PublicKey, bav.Params))
+	}
+	// Creators mint their own copies with a CreateNFT txn.
+	if reflect.DeepEqual(creatorPublicKey, txn.PublicKey) {
+		return 0, 0, nil, RuleErrorNFTVoucherCannotBeRedeemedByCreator
+	}
+
+	// The voucher must be signed by the creator of the post.
+	signatureData, err := NFTVoucherSignatureData(voucher)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectRedeemNFTVoucher: ")
+	}
+	if err = _verifyBytesSignature(creatorPublicKey, signatureData, txMeta.CreatorSignature); err != nil {
+		return 0, 0, nil, errors.Wrapf(RuleErrorNFTVoucherInvalidCreatorSignature,
+			"_connectRedeemNFTVoucher: %v", err)
+	}
+
+	// The creator only signs the voucher, so the redeemer can't add royalties of their
+	// own the way a CreateNFT txn can with its ExtraData.
+	if _, exists := txn.ExtraData[DESORoyaltiesMapKey]; exists {
+		return 0, 0, nil, RuleErrorNFTVoucherCannotHaveAdditionalRoyalties
+	}
+	if _, exists := txn.ExtraData[CoinRoyaltiesMapKey]; exists {
+		return 0, 0, nil, RuleErrorNFTVoucherCannotHaveAdditionalRoyalties
+	}
+
+	// Validate the voucher.
+	if voucher.NumCopies == 0 {
+		return 0, 0, nil, RuleErrorNFTVoucherMustHaveNonZeroCopies
+	}
+	if voucher.NumCopies > bav.GlobalParamsEntry.MaxCopiesPerNFT {
+		return 0, 0, nil, RuleErrorNFTVoucherTooManyCopies
+	}
+	if voucher.SerialNumber == 0 || voucher.SerialNumber > voucher.NumCopies {
+		return 0, 0, nil, RuleErrorNFTVoucherInvalidSerialNumber
+	}
+	// Make sure we won't overflow when we add the royalty basis points.
+	if math.MaxUint64-voucher.NFTRoyaltyToCreatorBasisPoints < voucher.NFTRoyaltyToCoinBasisPoints {
+		return 0, 0, nil, RuleErrorNFTRoyaltyOverflow
+	}
+	if voucher.NFTRoyaltyToCreatorBasisPoints+voucher.NFTRoyaltyToCoinBasisPoints >
+		bav.Params.MaxNFTRoyaltyBasisPoints {
+		return 0, 0, nil, RuleErrorNFTVoucherRoyaltyHasTooManyBasisPoints
+	}
+
+	// If a voucher for this post has already been redeemed, the voucher must agree with
+	// the NFT it created. Posts that were turned into NFTs with CreateNFT can't be lazily minted.
+	if postEntry.IsNFT {
+		if !postEntry.IsLazyMintedNFT {
+			return 0, 0, nil, RuleErrorNFTVoucherOnNonLazyMintedNFT
+		}
+		if postEntry.NumNFTCopies != voucher.NumCopies ||
+			postEntry.NFTRoyaltyToCreatorBasisPoints != voucher.NFTRoyaltyToCreatorBasisPoints ||
+			postEntry.NFTRoyaltyToCoinBasisPoints != voucher.NFTRoyaltyToCoinBasisPoints {
+			return 0, 0, nil, RuleErrorNFTVoucherDoesNotMatchNFT
+		}
+	}
+
+	// Each copy can only be minted once, even if it has since been burned.
+	if bav.GetNFTVoucherRedemptionEntry(voucher.NFTPostHash, voucher.SerialNumber) != nil {
+		return 0, 0, nil, RuleErrorNFTVoucherAlreadyRedeemed
+	}
+	nftKey := MakeNFTKey(voucher.NFTPostHash, voucher.SerialNumber)
+	if nftEntry := bav.GetNFTEntryForNFTKey(&nftKey); nftEntry != nil && !nftEntry.isDeleted {
+		return 0, 0, nil, fmt.Errorf("_connectRedeemNFTVoucher: NFT entry for post hash %v serial "+
+			"number %d exists without a redemption; this should never happen",
+			voucher.NFTPostHash, voucher.SerialNumber)
+	}
+
+	profileEntry := bav.GetProfileEntryForPublicKey(creatorPublicKey)
+	if profileEntry == nil || profileEntry.isDeleted {
+		return 0, 0, nil, RuleErrorCantRedeemNFTVoucherWithoutProfileEntry
+	}
+
+	// Connect basic txn to get the total input and the total output without
+	// considering the transaction metadata.
+	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
+		txn, txHash, blockHeight, verifySignatures)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectRedeemNFTVoucher: ")
+	}
+
+	// Force the input to be non-zero so that we can prevent replay attacks.
+	if totalInput == 0 {
+		return 0, 0, nil, RuleErrorRedeemNFTVoucherRequiresNonZeroInput
+	}
+
+	// The redeemer pays the voucher's price plus the fee that CreateNFT would have
+	// charged the creator for minting the copy. The price is paid out below and the
+	// fee is burned. We do not need to check the fee for overflow as it is managed
+	// by the ParamUpdater.
+	nftFee := bav.GlobalParamsEntry.CreateNFTFeeNanos
+	if math.MaxUint64-totalOutput < nftFee ||
+		math.MaxUint64-totalOutput-nftFee < voucher.PriceNanos {
+		return 0, 0, nil, fmt.Errorf("_connectRedeemNFTVoucher: price and nft fee overflow")
+	}
+	totalOutput += nftFee + voucher.PriceNanos
+	if totalInput < totalOutput {
+		return 0, 0, nil, RuleErrorNFTVoucherWithInsufficientFunds
+	}
+
+	// The amount of deso that should go to the creator's coin from this purchase.
+	// Calculated as: (PriceNanos * NFTRoyaltyToCoinBasisPoints) / (100 * 100)
+	//
+	// We don't do a royalty if the number of coins in circulation is too low. Since
+	// the creator is also the seller, they simply keep the royalty in that case.
+	creatorCoinRoyaltyNanos := IntDiv(
+		IntMul(
+			big.NewInt(int64(voucher.PriceNanos)),
+			big.NewInt(int64(voucher.NFTRoyaltyToCoinBasisPoints))),
+		big.NewInt(100*100)).Uint64()
+	if profileEntry.CreatorCoinEntry.CoinsInCirculationNanos.Uint64() < bav.Params.CreatorCoinAutoSellThresholdNanos {
+		creatorCoinRoyaltyNanos = 0
+	}
+	// The creator royalty goes to the creator as well, so it doesn't need to be split out.
+	creatorPaymentNanos := voucher.PriceNanos - creatorCoinRoyaltyNanos
+
+	// Now we are ready to mint the copy. When we do, the following must happen:
+	//  (1) Turn the post into a lazily minted NFT if this is its first redemption.
+	//  (2) Mint the NFT entry directly to the redeemer and record the redemption.
+	//  (3) Pay the creator.
+	//  (4) Add the creator coin royalty to deso locked.
+
+	// (1) Save a copy of the post entry so that we can safely modify it.
+	prevPostEntry := &PostEntry{}
+	*prevPostEntry = *postEntry
+	if !postEntry.IsNFT {
+		postEntry.IsNFT = true
+		postEntry.IsLazyMintedNFT = true
+		postEntry.NumNFTCopies = voucher.NumCopies
+		postEntry.NFTRoyaltyToCreatorBasisPoints = voucher.NFTRoyaltyToCreatorBasisPoints
+		postEntry.NFTRoyaltyToCoinBasisPoints = voucher.NFTRoyaltyToCoinBasisPoints
+		bav._setPostEntryMappings(postEntry)
+	}
+
+	// (2) Mint the copy to the redeemer.
+	bav._setNFTEntryMappings(&NFTEntry{
+		LastOwnerPKID: posterPKID.PKID,
+		OwnerPKID:     redeemerPKID.PKID,
+		NFTPostHash:   voucher.NFTPostHash,
+		SerialNumber:  voucher.SerialNumber,
+		IsForSale:     false,
+
+		LastAcceptedBidAmountNanos: voucher.PriceNanos,
+	})
+	bav._setNFTVoucherRedemptionEntryMappings(&NFTVoucherRedemptionEntry{
+		NFTPostHash:  voucher.NFTPostHash,
+		SerialNumber: voucher.SerialNumber,
+		RedeemerPKID: redeemerPKID.PKID,
+		PriceNanos:   voucher.PriceNanos,
+	})
+
+	// (3) Pay the creator by creating a new entry for this output and add it to the view.
+	nftPaymentUtxoKeys := []*UtxoKey{}
+	if creatorPaymentNanos > 0 {
+		creatorOutputKey := &UtxoKey{
+			TxID:  *txHash,
+			Index: uint32(len(txn.TxOutputs)),
+		}
+		utxoEntry := UtxoEntry{
+			AmountNanos: creatorPaymentNanos,
+			PublicKey:   creatorPublicKey,
+			BlockHeight: blockHeight,
+			UtxoType:    UtxoTypeNFTSeller,
+
+			UtxoKey: creatorOutputKey,
+			// We leave the position unset and isSpent to false by default.
+			// The position will be set in the call to _addUtxo.
+		}
+		utxoOp, err := bav._addUtxo(&utxoEntry)
+		if err != nil {
+			return 0, 0, nil, errors.Wrapf(err, "_connectRedeemNFTVoucher: Problem adding creator utxo")
+		}
+		nftPaymentUtxoKeys = append(nftPaymentUtxoKeys, creatorOutputKey)
+
+		// Rosetta uses this UtxoOperation to provide INPUT amounts
+		utxoOpsForTxn = append(utxoOpsForTxn, utxoOp)
+	}
+
+	// (4) Add the creator coin royalty to deso locked. CoinEntry doesn't contain any
+	// pointers and so a direct copy is OK.
+	prevCoinEntry := profileEntry.CreatorCoinEntry
+	if creatorCoinRoyaltyNanos > 0 {
+		newCoinEntry := prevCoinEntry
+		newCoinEntry.DeSoLockedNanos += creatorCoinRoyaltyNanos
+		profileEntry.CreatorCoinEntry = newCoinEntry
+		bav._setProfileEntryMappings(profileEntry)
+	}
+
+	// Add an operation to the list at the end indicating we've redeemed a voucher.
+	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
+		Type:               OperationTypeRedeemNFTVoucher,
+		PrevPostEntry:      prevPostEntry,
+		PrevCoinEntry:      &prevCoinEntry,
+		NFTPaymentUtxoKeys: nftPaymentUtxoKeys,
+	})
+
+	return totalInput, totalOutput, utxoOpsForTxn, nil
+}
+
+func (bav *UtxoView) _disconnectRedeemNFTVoucher(
+	operationType OperationType, currentTxn *MsgDeSoTxn, txnHash *BlockHash,
+	utxoOpsForTxn []*UtxoOperation, blockHeight uint32) error {
+
+	// Verify that the last operation is a RedeemNFTVoucher operation
+	if len(utxoOpsForTxn) == 0 {
+		return fmt.Errorf("_disconnectRedeemNFTVoucher: utxoOperations are missing")
+	}
+	operationIndex := len(utxoOpsForTxn) - 1
+	if utxoOpsForTxn[operationIndex].Type != OperationTypeRedeemNFTVoucher {
+		return fmt.Errorf("_disconnectRedeemNFTVoucher: Trying to revert "+
+			"OperationTypeRedeemNFTVoucher but found type %v",
+			utxoOpsForTxn[operationIndex].Type)
+	}
+	txMeta := currentTxn.TxnMeta.(*RedeemNFTVoucherMetadata)
+	voucher := txMeta.Voucher
+	operationData := utxoOpsForTxn[operationIndex]
+	operationIndex--
+
+	// The creator's payment is an "implicit" output that occurs at the end of the
+	// list of UtxoOperations, so we skip over it along with the operation above.
+	numUtxoAdds := 0
+	for _, utxoOp := range utxoOpsForTxn {
+		if utxoOp.Type == OperationTypeAddUtxo {
+			numUtxoAdds += 1
+		}
+	}
+	operationIndex -= numUtxoAdds - len(currentTxn.TxOutputs)
+
+	// Delete the minted copy and its redemption.
+	nftKey := MakeNFTKey(voucher.NFTPostHash, voucher.SerialNumber)
+	nftEntry := bav.GetNFTEntryForNFTKey(&nftKey)
+	if nftEntry == nil || nftEntry.isDeleted {
+		return fmt.Errorf("_disconnectRedeemNFTVoucher: NFT entry for post hash %v serial "+
+			"number %d doesn't exist; this should never happen", voucher.NFTPostHash, voucher.SerialNumber)
+	}
+	bav._deleteNFTEntryMappings(nftEntry)
+
+	redemptionEntry := bav.GetNFTVoucherRedemptionEntry(voucher.NFTPostHash, voucher.SerialNumber)
+	if redemptionEntry == nil {
+		return fmt.Errorf("_disconnectRedeemNFTVoucher: redemption for post hash %v serial "+
+			"number %d doesn't exist; this should never happen", voucher.NFTPostHash, voucher.SerialNumber)
+	}
+	bav._deleteNFTVoucherRedemptionEntryMappings(redemptionEntry)
+
+	// Revert the creator's payment.
+	// Note: these UTXOs need to be unadded in reverse order.
+	for ii := len(operationData.NFTPaymentUtxoKeys) - 1; ii >= 0; ii-- {
+		paymentUtxoKey := operationData.NFTPaymentUtxoKeys[ii]
+		if err := bav._unAddUtxo(paymentUtxoKey); err != nil {
+			return errors.Wrapf(err, "_disconnectRedeemNFTVoucher: Problem unAdding utxo %v: ", paymentUtxoKey)
+		}
+	}
+
+	// Revert the creator's CreatorCoinEntry and the post entry.
+	if operationData.PrevPostEntry == nil || operationData.PrevCoinEntry == nil {
+		return fmt.Errorf("_disconnectRedeemNFTVoucher: PrevPostEntry or PrevCoinEntry is nil; " +
+			"this should never happen")
+	}
+	existingProfileEntry := bav.GetProfileEntryForPublicKey(bav.GetCurrentPublicKey(operationData.PrevPostEntry.PosterPublicKey))
+	if existingProfileEntry == nil || existingProfileEntry.isDeleted {
+		return fmt.Errorf("_disconnectRedeemNFTVoucher: existingProfileEntry was nil; " +
+			"this should never happen")
+	}
+	existingProfileEntry.CreatorCoinEntry = *operationData.PrevCoinEntry
+	bav._setProfileEntryMappings(existingProfileEntry)
+
+	bav._setPostEntryMappings(operationData.PrevPostEntry)
+
+	// Now revert the basic transfer with the remaining operations.
+	return bav._disconnectBasicTransfer(
+		currentTxn, txnHash, utxoOpsForTxn[:operationIndex+1], blockHeight)
+}
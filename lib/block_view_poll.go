@@ -0,0 +1,358 @@
+package lib
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/golang/glog"
+	"github.com/holiman/uint256"
+	"github.com/pkg/errors"
+)
+
+func (bav *UtxoView) _setPollEntryMappings(pollEntry *PollEntry) {
+	// This function shouldn't be called with nil.
+	if pollEntry == nil {
+		glog.Errorf("_setPollEntryMappings: Called with nil PollEntry; " +
+			"this should never happen.")
+		return
+	}
+
+	bav.PostHashToPollEntry[*pollEntry.PostHash] = pollEntry
+}
+
+func (bav *UtxoView) _deletePollEntryMappings(pollEntry *PollEntry) {
+
+	// Create a tombstone entry.
+	tombstonePollEntry := *pollEntry
+	tombstonePollEntry.isDeleted = true
+
+	// Set the mappings to point to the tombstone entry.
+	bav._setPollEntryMappings(&tombstonePollEntry)
+}
+
+func (bav *UtxoView) _setPollVoteEntryMappings(pollVoteEntry *PollVoteEntry) {
+	// This function shouldn't be called with nil.
+	if pollVoteEntry == nil {
+		glog.Errorf("_setPollVoteEntryMappings: Called with nil PollVoteEntry; " +
+			"this should never happen.")
+		return
+	}
+
+	pollVoteKey := MakePollVoteKey(pollVoteEntry.PostHash, pollVoteEntry.VoterPKID)
+	bav.PollVoteKeyToPollVoteEntry[pollVoteKey] = pollVoteEntry
+}
+
+func (bav *UtxoView) _deletePollVoteEntryMappings(pollVoteEntry *PollVoteEntry) {
+
+	// Create a tombstone entry.
+	tombstonePollVoteEntry := *pollVoteEntry
+	tombstonePollVoteEntry.isDeleted = true
+
+	// Set the mappings to point to the tombstone entry.
+	bav._setPollVoteEntryMappings(&tombstonePollVoteEntry)
+}
+
+// GetPollEntryForPostHash returns the poll declared by a post, or nil if the post
+// didn't declare one.
+func (bav *UtxoView) GetPollEntryForPostHash(postHash *BlockHash) *PollEntry {
+	// If an entry exists in the in-memory map, return the value of that mapping.
+	if mapValue, existsMapValue := bav.PostHashToPollEntry[*postHash]; existsMapValue {
+		if mapValue.isDeleted {
+			return nil
+		}
+		return mapValue
+	}
+
+	// If we get here it means no value exists in our in-memory map. In this case,
+	// defer to the db. If a mapping exists in the db, return it. If not, return
+	// nil.
+	var pollEntry *PollEntry
+	if bav.Postgres != nil {
+		if poll := bav.Postgres.GetPoll(postHash); poll != nil {
+			pollEntry = poll.NewPollEntry()
+		}
+	} else {
+		pollEntry = DbGetPollEntryForPostHash(bav.Handle, postHash)
+	}
+	if pollEntry != nil {
+		bav._setPollEntryMappings(pollEntry)
+	}
+	return pollEntry
+}
+
+// GetPollVoteEntry returns the vote a PKID cast in a poll, or nil if it hasn't
+// voted.
+func (bav *UtxoView) GetPollVoteEntry(postHash *BlockHash, voterPKID *PKID) *PollVoteEntry {
+	// If an entry exists in the in-memory map, return the value of that mapping.
+	pollVoteKey := MakePollVoteKey(postHash, voterPKID)
+	if mapValue, existsMapValue := bav.PollVoteKeyToPollVoteEntry[pollVoteKey]; existsMapValue {
+		if mapValue.isDeleted {
+			return nil
+		}
+		return mapValue
+	}
+
+	// If we get here it means no value exists in our in-memory map. In this case,
+	// defer to the db. If a mapping exists in the db, return it. If not, return
+	// nil.
+	var pollVoteEntry *PollVoteEntry
+	if bav.Postgres != nil {
+		if pollVote := bav.Postgres.GetPollVote(postHash, voterPKID); pollVote != nil {
+			pollVoteEntry = pollVote.NewPollVoteEntry()
+		}
+	} else {
+		pollVoteEntry = DbGetPollVoteEntry(bav.Handle, postHash, voterPKID)
+	}
+	if pollVoteEntry != nil {
+		bav._setPollVoteEntryMappings(pollVoteEntry)
+	}
+	return pollVoteEntry
+}
+
+// GetPollVoteEntriesForPostHash returns every vote cast in the poll declared by a
+// post.
+func (bav *UtxoView) GetPollVoteEntriesForPostHash(postHash *BlockHash) ([]*PollVoteEntry, error) {
+	// Load the votes from the db into the view first so that entries modified in
+	// the view take precedence.
+	var dbPollVoteEntries []*PollVoteEntry
+	if bav.Postgres != nil {
+		for _, pollVote := range bav.Postgres.GetPollVotesForPost(postHash) {
+			dbPollVoteEntries = append(dbPollVoteEntries, pollVote.NewPollVoteEntry())
+		}
+	} else {
+		var err error
+		dbPollVoteEntries, err = DbGetPollVoteEntriesForPostHash(bav.Handle, postHash)
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetPollVoteEntriesForPostHash: Problem fetching votes for post %v", postHash)
+		}
+	}
+	for _, pollVoteEntry := range dbPollVoteEntries {
+		pollVoteKey := MakePollVoteKey(pollVoteEntry.PostHash, pollVoteEntry.VoterPKID)
+		if _, exists := bav.PollVoteKeyToPollVoteEntry[pollVoteKey]; !exists {
+			bav._setPollVoteEntryMappings(pollVoteEntry)
+		}
+	}
+
+	var pollVoteEntries []*PollVoteEntry
+	for pollVoteKey, pollVoteEntry := range bav.PollVoteKeyToPollVoteEntry {
+		if pollVoteEntry.isDeleted || pollVoteKey.PostHash != *postHash {
+			continue
+		}
+		pollVoteEntries = append(pollVoteEntries, pollVoteEntry)
+	}
+
+	return pollVoteEntries, nil
+}
+
+// _createPollEntry validates a poll declared by a new post and returns the entry
+// for it. The caller is responsible for setting the mappings.
+func (bav *UtxoView) _createPollEntry(
+	postEntry *PostEntry, postPoll *PostPoll, blockHeight uint32) (*PollEntry, error) {
+
+	if len(postPoll.Question) == 0 {
+		return nil, RuleErrorPollQuestionEmpty
+	}
+	if len(postPoll.Question) > MaxPollQuestionLengthBytes {
+		return nil, errors.Wrapf(RuleErrorPollQuestionTooLong,
+			"_createPollEntry: Question has length %d > %d",
+			len(postPoll.Question), MaxPollQuestionLengthBytes)
+	}
+	if len(postPoll.Options) < MinPollOptions {
+		return nil, errors.Wrapf(RuleErrorPollTooFewOptions,
+			"_createPollEntry: %d options < %d", len(postPoll.Options), MinPollOptions)
+	}
+	if len(postPoll.Options) > MaxPollOptions {
+		return nil, errors.Wrapf(RuleErrorPollTooManyOptions,
+			"_createPollEntry: %d options > %d", len(postPoll.Options), MaxPollOptions)
+	}
+	for ii, option := range postPoll.Options {
+		if len(option) == 0 {
+			return nil, errors.Wrapf(RuleErrorPollOptionEmpty, "_createPollEntry: Option %d", ii)
+		}
+		if len(option) > MaxPollOptionLengthBytes {
+			return nil, errors.Wrapf(RuleErrorPollOptionTooLong,
+				"_createPollEntry: Option %d has length %d > %d",
+				ii, len(option), MaxPollOptionLengthBytes)
+		}
+	}
+	if postPoll.ClosingBlockHeight <= blockHeight {
+		return nil, errors.Wrapf(RuleErrorPollClosingBlockHeightNotInFuture,
+			"_createPollEntry: Closing block height %d <= block height %d",
+			postPoll.ClosingBlockHeight, blockHeight)
+	}
+
+	switch postPoll.WeightType {
+	case PollWeightTypeOneVotePerPKID:
+	case PollWeightTypeCreatorCoin, PollWeightTypeDAOCoin:
+		// Votes are weighted by the poster's coin so the poster needs a profile.
+		profileEntry := bav.GetProfileEntryForPublicKey(postEntry.PosterPublicKey)
+		if profileEntry == nil || profileEntry.isDeleted {
+			return nil, errors.Wrapf(RuleErrorPollWeightedPollRequiresProfile,
+				"_createPollEntry: Poster %v", PkToStringBoth(postEntry.PosterPublicKey))
+		}
+	default:
+		return nil, errors.Wrapf(RuleErrorPollInvalidWeightType,
+			"_createPollEntry: Weight type %d", postPoll.WeightType)
+	}
+
+	return &PollEntry{
+		PostHash:           postEntry.PostHash,
+		PosterPKID:         bav.GetPKIDForPublicKey(postEntry.PosterPublicKey).PKID,
+		Question:           postPoll.Question,
+		Options:            postPoll.Options,
+		ClosingBlockHeight: postPoll.ClosingBlockHeight,
+		WeightType:         postPoll.WeightType,
+		OptionTallies:      make([]uint256.Int, len(postPoll.Options)),
+	}, nil
+}
+
+// _getPollVoteWeight returns how much a PKID's vote counts for in a poll.
+func (bav *UtxoView) _getPollVoteWeight(pollEntry *PollEntry, voterPKID *PKID) *uint256.Int {
+	if pollEntry.WeightType == PollWeightTypeOneVotePerPKID {
+		return uint256.NewInt().SetUint64(1)
+	}
+
+	isDAOCoin := pollEntry.WeightType == PollWeightTypeDAOCoin
+	balanceEntry := bav._getBalanceEntryForHODLerPKIDAndCreatorPKID(
+		voterPKID, pollEntry.PosterPKID, isDAOCoin)
+	if balanceEntry == nil || balanceEntry.isDeleted {
+		return uint256.NewInt()
+	}
+	return uint256.NewInt().Set(&balanceEntry.BalanceNanos)
+}
+
+func (bav *UtxoView) _connectPollVote(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
+	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
+
+	if blockHeight < bav.Params.ForkHeights.PollsBlockHeight {
+		return 0, 0, nil, RuleErrorPollBeforeBlockHeight
+	}
+
+	// Check that the transaction has the right TxnType.
+	if txn.TxnMeta.GetTxnType() != TxnTypePollVote {
+		return 0, 0, nil, fmt.Errorf("_connectPollVote: called with bad TxnType %s",
+			txn.TxnMeta.GetTxnType().String())
+	}
+	txMeta := txn.TxnMeta.(*PollVoteMetadata)
+
+	// Connect basic txn to get the total input and the total output without
+	// considering the transaction metadata.
+	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
+		txn, txHash, blockHeight, verifySignatures)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectPollVote: ")
+	}
+
+	// Force the input to be non-zero so that we can prevent replay attacks.
+	if totalInput == 0 {
+		return 0, 0, nil, RuleErrorPollVoteRequiresNonZeroInput
+	}
+
+	// The poll must exist and still be open.
+	pollEntry := bav.GetPollEntryForPostHash(txMeta.PostHash)
+	if pollEntry == nil {
+		return 0, 0, nil, errors.Wrapf(RuleErrorPollVoteOnNonexistentPoll,
+			"_connectPollVote: Post hash: %v", txMeta.PostHash)
+	}
+	if blockHeight >= pollEntry.ClosingBlockHeight {
+		return 0, 0, nil, errors.Wrapf(RuleErrorPollVoteAfterPollClosed,
+			"_connectPollVote: Block height %d >= closing block height %d",
+			blockHeight, pollEntry.ClosingBlockHeight)
+	}
+	if txMeta.OptionIndex >= uint32(len(pollEntry.Options)) {
+		return 0, 0, nil, errors.Wrapf(RuleErrorPollVoteInvalidOptionIndex,
+			"_connectPollVote: Option index %d with %d options",
+			txMeta.OptionIndex, len(pollEntry.Options))
+	}
+
+	// Each PKID can only vote once.
+	voterPKID := bav.GetPKIDForPublicKey(txn.PublicKey).PKID
+	if existingPollVoteEntry := bav.GetPollVoteEntry(txMeta.PostHash, voterPKID); existingPollVoteEntry != nil {
+		return 0, 0, nil, errors.Wrapf(RuleErrorPollVoteAlreadyExists,
+			"_connectPollVote: Voter %v, post hash %v", voterPKID, txMeta.PostHash)
+	}
+
+	weight := bav._getPollVoteWeight(pollEntry, voterPKID)
+	if weight.IsZero() {
+		return 0, 0, nil, errors.Wrapf(RuleErrorPollVoteZeroWeight,
+			"_connectPollVote: Voter %v holds none of the poster's coin", voterPKID)
+	}
+
+	// Add the vote to the tally. The previous entry is saved as-is so we make a
+	// copy before modifying the tallies.
+	newPollEntry := pollEntry.Copy()
+	tally := &newPollEntry.OptionTallies[txMeta.OptionIndex]
+	if tally.Gt(uint256.NewInt().Sub(MaxUint256, weight)) {
+		return 0, 0, nil, errors.Wrapf(RuleErrorPollVoteTallyOverflow,
+			"_connectPollVote: Overflow while adding weight %v to tally %v", weight, tally)
+	}
+	tally.Add(tally, weight)
+	newPollEntry.NumVotes += 1
+	bav._setPollEntryMappings(newPollEntry)
+
+	bav._setPollVoteEntryMappings(&PollVoteEntry{
+		PostHash:    txMeta.PostHash,
+		VoterPKID:   voterPKID,
+		OptionIndex: txMeta.OptionIndex,
+		Weight:      *weight,
+		BlockHeight: blockHeight,
+	})
+
+	// Add an operation to the list at the end indicating we've added a vote.
+	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
+		Type:          OperationTypePollVote,
+		PrevPollEntry: pollEntry,
+	})
+
+	return totalInput, totalOutput, utxoOpsForTxn, nil
+}
+
+func (bav *UtxoView) _disconnectPollVote(
+	operationType OperationType, currentTxn *MsgDeSoTxn, txnHash *BlockHash,
+	utxoOpsForTxn []*UtxoOperation, blockHeight uint32) error {
+
+	// Verify that the last operation is a PollVote operation
+	if len(utxoOpsForTxn) == 0 {
+		return fmt.Errorf("_disconnectPollVote: utxoOperations are missing")
+	}
+	operationIndex := len(utxoOpsForTxn) - 1
+	currentOperation := utxoOpsForTxn[operationIndex]
+	if currentOperation.Type != OperationTypePollVote {
+		return fmt.Errorf("_disconnectPollVote: Trying to revert "+
+			"OperationTypePollVote but found type %v",
+			currentOperation.Type)
+	}
+
+	// Now we know the txMeta is a PollVote
+	txMeta := currentTxn.TxnMeta.(*PollVoteMetadata)
+
+	// Get the PollVoteEntry. If we don't find it that's an error.
+	voterPKID := bav.GetPKIDForPublicKey(currentTxn.PublicKey).PKID
+	pollVoteEntry := bav.GetPollVoteEntry(txMeta.PostHash, voterPKID)
+	if pollVoteEntry == nil {
+		return fmt.Errorf("_disconnectPollVote: PollVoteEntry for post hash %v "+
+			"and voter %v was not found", txMeta.PostHash, voterPKID)
+	}
+
+	// Sanity check that the vote lines up with the transaction we're rolling back.
+	if pollVoteEntry.OptionIndex != txMeta.OptionIndex {
+		return fmt.Errorf("_disconnectPollVote: Option index on PollVoteEntry was %d "+
+			"but the option index on the txn was %d", pollVoteEntry.OptionIndex, txMeta.OptionIndex)
+	}
+	if currentOperation.PrevPollEntry == nil ||
+		!reflect.DeepEqual(currentOperation.PrevPollEntry.PostHash, txMeta.PostHash) {
+
+		return fmt.Errorf("_disconnectPollVote: PrevPollEntry %v doesn't match "+
+			"post hash %v", currentOperation.PrevPollEntry, txMeta.PostHash)
+	}
+
+	// Delete the vote and restore the tally.
+	bav._deletePollVoteEntryMappings(pollVoteEntry)
+	bav._setPollEntryMappings(currentOperation.PrevPollEntry)
+
+	// Now revert the basic transfer with the remaining operations. Cut off
+	// the PollVote operation at the end since we just reverted it.
+	return bav._disconnectBasicTransfer(
+		currentTxn, txnHash, utxoOpsForTxn[:operationIndex], blockHeight)
+}
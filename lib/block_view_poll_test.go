@@ -0,0 +1,299 @@
+package lib
+
+import (
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func _submitPollPost(t *testing.T, chain *Blockchain, db *badger.DB,
+	params *DeSoParams, feeRateNanosPerKB uint64, posterPkBase58Check string,
+	posterPrivBase58Check string, postPoll *PostPoll, tstampNanos uint64) (
+	_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
+
+	require := require.New(t)
+
+	posterPkBytes, _, err := Base58CheckDecode(posterPkBase58Check)
+	require.NoError(err)
+
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+
+	postExtraData := map[string][]byte{
+		PollKey: postPoll.ToBytes(),
+	}
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateSubmitPostTxn(
+		posterPkBytes,
+		[]byte{}, /*postHashToModify*/
+		[]byte{}, /*parentStakeID*/
+		[]byte(`{"Body":"poll"}`),
+		[]byte{}, /*repostedPostHash*/
+		false,    /*isQuotedRepost*/
+		tstampNanos,
+		postExtraData,
+		false, /*isHidden*/
+		feeRateNanosPerKB,
+		nil,
+		[]*DeSoOutput{})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	require.Equal(totalInputMake, changeAmountMake+feesMake)
+
+	// Sign the transaction now that its inputs are set up.
+	_signTxn(t, txn, posterPrivBase58Check)
+
+	txHash := txn.Hash()
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(totalInput, totalInputMake)
+	require.Equal(OperationTypeSubmitPost, utxoOps[len(utxoOps)-1].Type)
+
+	require.NoError(utxoView.FlushToDb())
+
+	return utxoOps, txn, blockHeight, nil
+}
+
+func _submitPollPostWithTestMeta(
+	testMeta *TestMeta,
+	feeRateNanosPerKB uint64,
+	posterPkBase58Check string,
+	posterPrivBase58Check string,
+	postPoll *PostPoll,
+	tstampNanos uint64) {
+
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, posterPkBase58Check))
+
+	currentOps, currentTxn, _, err := _submitPollPost(
+		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
+		posterPkBase58Check, posterPrivBase58Check, postPoll, tstampNanos)
+	require.NoError(testMeta.t, err)
+
+	testMeta.txnOps = append(testMeta.txnOps, currentOps)
+	testMeta.txns = append(testMeta.txns, currentTxn)
+}
+
+func _doPollVoteTxn(t *testing.T, chain *Blockchain, db *badger.DB,
+	params *DeSoParams, feeRateNanosPerKB uint64, voterPkBase58Check string,
+	voterPrivBase58Check string, postHash BlockHash, optionIndex uint32) (
+	_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
+
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	voterPkBytes, _, err := Base58CheckDecode(voterPkBase58Check)
+	require.NoError(err)
+
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreatePollVoteTxn(
+		voterPkBytes, postHash, optionIndex, feeRateNanosPerKB, nil, []*DeSoOutput{})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	require.Equal(totalInputMake, changeAmountMake+feesMake)
+
+	// Sign the transaction now that its inputs are set up.
+	_signTxn(t, txn, voterPrivBase58Check)
+
+	txHash := txn.Hash()
+	// Always use height+1 for validation since it's assumed the transaction will
+	// get mined into the next block.
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(totalInput, totalInputMake)
+
+	// We should have one SPEND UtxoOperation for each input, one ADD operation
+	// for each output, and one OperationTypePollVote operation at the end.
+	require.Equal(len(txn.TxInputs)+len(txn.TxOutputs)+1, len(utxoOps))
+	for ii := 0; ii < len(txn.TxInputs); ii++ {
+		require.Equal(OperationTypeSpendUtxo, utxoOps[ii].Type)
+	}
+	require.Equal(OperationTypePollVote, utxoOps[len(utxoOps)-1].Type)
+
+	require.NoError(utxoView.FlushToDb())
+
+	return utxoOps, txn, blockHeight, nil
+}
+
+func _doPollVoteTxnWithTestMeta(
+	testMeta *TestMeta,
+	feeRateNanosPerKB uint64,
+	voterPkBase58Check string,
+	voterPrivBase58Check string,
+	postHash BlockHash,
+	optionIndex uint32) {
+
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, voterPkBase58Check))
+
+	currentOps, currentTxn, _, err := _doPollVoteTxn(
+		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
+		voterPkBase58Check, voterPrivBase58Check, postHash, optionIndex)
+	require.NoError(testMeta.t, err)
+
+	testMeta.txnOps = append(testMeta.txnOps, currentOps)
+	testMeta.txns = append(testMeta.txns, currentTxn)
+}
+
+func TestPollVotes(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	params.ForkHeights.PollsBlockHeight = 0
+
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 1000000000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 1000000000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m2Pub, senderPrivString, 1000000000)
+
+	m0PKID := DBGetPKIDEntryForPublicKey(db, m0PkBytes).PKID
+	m1PKID := DBGetPKIDEntryForPublicKey(db, m1PkBytes).PKID
+
+	getPollEntry := func(postHash *BlockHash) *PollEntry {
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		return utxoView.GetPollEntryForPostHash(postHash)
+	}
+	getPollVoteEntries := func(postHash *BlockHash) []*PollVoteEntry {
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		pollVoteEntries, err := utxoView.GetPollVoteEntriesForPostHash(postHash)
+		require.NoError(err)
+		return pollVoteEntries
+	}
+
+	postPoll := &PostPoll{
+		Question:           []byte("Which one?"),
+		Options:            [][]byte{[]byte("this"), []byte("that"), []byte("neither")},
+		ClosingBlockHeight: chain.blockTip().Height + 2,
+		WeightType:         PollWeightTypeOneVotePerPKID,
+	}
+
+	// Invalid polls are rejected along with the post.
+	{
+		tooFewOptions := *postPoll
+		tooFewOptions.Options = [][]byte{[]byte("only")}
+		_, _, _, err = _submitPollPost(t, chain, db, params, 10, m0Pub, m0Priv, &tooFewOptions, 1502947011*1e9)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorPollTooFewOptions)
+
+		alreadyClosed := *postPoll
+		alreadyClosed.ClosingBlockHeight = chain.blockTip().Height + 1
+		_, _, _, err = _submitPollPost(t, chain, db, params, 10, m0Pub, m0Priv, &alreadyClosed, 1502947011*1e9)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorPollClosingBlockHeightNotInFuture)
+
+		// m0 has no profile so their polls can't be weighted by their coin.
+		coinWeighted := *postPoll
+		coinWeighted.WeightType = PollWeightTypeCreatorCoin
+		_, _, _, err = _submitPollPost(t, chain, db, params, 10, m0Pub, m0Priv, &coinWeighted, 1502947011*1e9)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorPollWeightedPollRequiresProfile)
+	}
+
+	_submitPollPostWithTestMeta(testMeta, 10, m0Pub, m0Priv, postPoll, 1502947011*1e9)
+	postHash := testMeta.txns[len(testMeta.txns)-1].Hash()
+
+	{
+		pollEntry := getPollEntry(postHash)
+		require.NotNil(pollEntry)
+		require.Equal(m0PKID, pollEntry.PosterPKID)
+		require.Equal(postPoll.Question, pollEntry.Question)
+		require.Equal(postPoll.Options, pollEntry.Options)
+		require.Equal(uint64(0), pollEntry.NumVotes)
+	}
+
+	_doPollVoteTxnWithTestMeta(testMeta, 10, m0Pub, m0Priv, *postHash, 1)
+	_doPollVoteTxnWithTestMeta(testMeta, 10, m1Pub, m1Priv, *postHash, 1)
+
+	// Voting twice, voting for an option that doesn't exist and voting on a post
+	// without a poll all fail.
+	{
+		_, _, _, err = _doPollVoteTxn(t, chain, db, params, 10, m1Pub, m1Priv, *postHash, 0)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorPollVoteAlreadyExists)
+
+		_, _, _, err = _doPollVoteTxn(t, chain, db, params, 10, m2Pub, m2Priv, *postHash, 3)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorPollVoteInvalidOptionIndex)
+
+		_, _, _, err = _doPollVoteTxn(t, chain, db, params, 10, m2Pub, m2Priv, *testMeta.txns[0].Hash(), 0)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorPollVoteOnNonexistentPoll)
+	}
+
+	{
+		pollEntry := getPollEntry(postHash)
+		require.Equal(uint64(2), pollEntry.NumVotes)
+		require.Equal(uint64(0), pollEntry.OptionTallies[0].Uint64())
+		require.Equal(uint64(2), pollEntry.OptionTallies[1].Uint64())
+		require.Equal(uint64(0), pollEntry.OptionTallies[2].Uint64())
+
+		pollVoteEntries := getPollVoteEntries(postHash)
+		require.Len(pollVoteEntries, 2)
+		voterPKIDs := []PKID{*pollVoteEntries[0].VoterPKID, *pollVoteEntries[1].VoterPKID}
+		require.ElementsMatch([]PKID{*m0PKID, *m1PKID}, voterPKIDs)
+		for _, pollVoteEntry := range pollVoteEntries {
+			require.Equal(uint32(1), pollVoteEntry.OptionIndex)
+			require.Equal(uint64(1), pollVoteEntry.Weight.Uint64())
+		}
+	}
+
+	// Once the closing block height is reached no more votes are accepted.
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	{
+		_, _, _, err = _doPollVoteTxn(t, chain, db, params, 10, m2Pub, m2Priv, *postHash, 0)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorPollVoteAfterPollClosed)
+	}
+
+	// Disconnecting the votes and the post removes the poll and its votes.
+	_rollBackTestMetaTxnsAndFlush(testMeta)
+	require.Nil(getPollEntry(postHash))
+	require.Len(getPollVoteEntries(postHash), 0)
+
+	// Connecting and disconnecting in a single view should do the same.
+	_applyTestMetaTxnsToViewAndFlush(testMeta)
+	require.Equal(uint64(2), getPollEntry(postHash).NumVotes)
+	require.Len(getPollVoteEntries(postHash), 2)
+	_disconnectTestMetaTxnsFromViewAndFlush(testMeta)
+	require.Nil(getPollEntry(postHash))
+	require.Len(getPollVoteEntries(postHash), 0)
+}
@@ -245,6 +245,65 @@ func (bav *UtxoView) _deletePostEntryMappings(postEntry *PostEntry) {
 	bav._setPostEntryMappings(&tombstonePostEntry)
 }
 
+func (bav *UtxoView) _setPostVersionEntryMappings(versionEntry *PostVersionEntry) {
+	// This function shouldn't be called with nil.
+	if versionEntry == nil {
+		glog.Errorf("_setPostVersionEntryMappings: Called with nil PostVersionEntry; this should never happen.")
+		return
+	}
+
+	versionKey := MakePostVersionKey(versionEntry.PostHash, versionEntry.Version)
+	bav.PostVersionKeyToPostVersionEntry[versionKey] = versionEntry
+}
+
+func (bav *UtxoView) _deletePostVersionEntryMappings(versionEntry *PostVersionEntry) {
+
+	// Create a tombstone entry.
+	tombstoneVersionEntry := *versionEntry
+	tombstoneVersionEntry.isDeleted = true
+
+	// Set the mappings to point to the tombstone entry.
+	bav._setPostVersionEntryMappings(&tombstoneVersionEntry)
+}
+
+// GetPostVersionEntriesForPostHash returns the edit history of a post ordered by
+// version. Posts that have never been edited have no versions.
+func (bav *UtxoView) GetPostVersionEntriesForPostHash(postHash *BlockHash) ([]*PostVersionEntry, error) {
+	// Load the versions from the db into the view first so that entries modified
+	// in the view take precedence.
+	var dbVersionEntries []*PostVersionEntry
+	if bav.Postgres != nil {
+		for _, version := range bav.Postgres.GetPostVersions(postHash) {
+			dbVersionEntries = append(dbVersionEntries, version.NewPostVersionEntry())
+		}
+	} else {
+		var err error
+		dbVersionEntries, err = DbGetPostVersionEntriesForPostHash(bav.Handle, postHash)
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetPostVersionEntriesForPostHash: Problem fetching versions for post %v", postHash)
+		}
+	}
+	for _, versionEntry := range dbVersionEntries {
+		versionKey := MakePostVersionKey(versionEntry.PostHash, versionEntry.Version)
+		if _, exists := bav.PostVersionKeyToPostVersionEntry[versionKey]; !exists {
+			bav._setPostVersionEntryMappings(versionEntry)
+		}
+	}
+
+	var versionEntries []*PostVersionEntry
+	for versionKey, versionEntry := range bav.PostVersionKeyToPostVersionEntry {
+		if versionEntry.isDeleted || versionKey.PostHash != *postHash {
+			continue
+		}
+		versionEntries = append(versionEntries, versionEntry)
+	}
+	sort.Slice(versionEntries, func(ii, jj int) bool {
+		return versionEntries[ii].Version < versionEntries[jj].Version
+	})
+
+	return versionEntries, nil
+}
+
 func (bav *UtxoView) setPostMappings(post *PGPost) *PostEntry {
 	postEntry := post.NewPostEntry()
 
@@ -266,7 +325,7 @@ func (bav *UtxoView) GetPostEntryReaderState(
 
 	// Get diamond state.
 	senderPKID := bav.GetPKIDForPublicKey(readerPK)
-	receiverPKID := bav.GetPKIDForPublicKey(postEntry.PosterPublicKey)
+	receiverPKID := bav.GetPKIDForPublicKey(bav.GetCurrentPublicKey(postEntry.PosterPublicKey))
 	if senderPKID == nil || receiverPKID == nil {
 		glog.V(1).Infof(
 			"GetPostEntryReaderState: Could not find PKID for reader PK: %s or poster PK: %s",
@@ -570,7 +629,7 @@ func (bav *UtxoView) GetDiamondSendersForPostHash(postHash *BlockHash) (_pkidToD
 	keysFound, _ := EnumerateKeysForPrefix(handle, dbPrefix)
 
 	diamondPostEntry := bav.GetPostEntryForPostHash(postHash)
-	receiverPKIDEntry := bav.GetPKIDForPublicKey(diamondPostEntry.PosterPublicKey)
+	receiverPKIDEntry := bav.GetPKIDForPublicKey(bav.GetCurrentPublicKey(diamondPostEntry.PosterPublicKey))
 
 	// Iterate over all the db keys & values and load them into the view.
 	expectedKeyLength := 1 + HashSizeBytes + btcec.PubKeyBytesLenCompressed + 8
@@ -690,6 +749,12 @@ func (bav *UtxoView) _connectSubmitPost(
 	}
 	txMeta := txn.TxnMeta.(*SubmitPostMetadata)
 
+	// A key that was rotated away from its account may be in someone else's hands.
+	if bav.GetRotatedPublicKeyEntry(txn.PublicKey) != nil {
+		return 0, 0, nil, errors.Wrapf(RuleErrorSubmitPostFromRotatedPublicKey,
+			"_connectSubmitPost: Public key: %v", PkToStringBoth(txn.PublicKey))
+	}
+
 	// Connect basic txn to get the total input and the total output without
 	// considering the transaction metadata.
 	//
@@ -735,6 +800,16 @@ func (bav *UtxoView) _connectSubmitPost(
 		copy(repostedPostHash[:], repostedPostHashBytes)
 		delete(extraData, RepostedPostHash)
 	}
+	// A poll is declared by a new post. Like the repost keys, the declaration is
+	// removed from the PostExtraData since it's stored in its own PollEntry.
+	var postPoll *PostPoll
+	if pollBytes, hasPoll := extraData[PollKey]; hasPoll && blockHeight >= bav.Params.ForkHeights.PollsBlockHeight {
+		postPoll = &PostPoll{}
+		if err := postPoll.FromBytes(pollBytes); err != nil {
+			return 0, 0, nil, errors.Wrapf(RuleErrorPollInvalidDeclaration, "_connectSubmitPost: %v", err)
+		}
+		delete(extraData, PollKey)
+	}
 
 	// At this point the inputs and outputs have been processed. Now we
 	// need to handle the metadata.
@@ -752,6 +827,9 @@ func (bav *UtxoView) _connectSubmitPost(
 	var newGrandparentPostEntry *PostEntry
 	var newRepostedPostEntry *PostEntry
 	var newRepostEntry *RepostEntry
+	var newPostVersionEntries []*PostVersionEntry
+	var prevNumPostVersions uint64
+	var newPollEntry *PollEntry
 	if len(txMeta.PostHashToModify) != 0 {
 		// Make sure the post hash is valid
 		if len(txMeta.PostHashToModify) != HashSizeBytes {
@@ -770,8 +848,9 @@ func (bav *UtxoView) _connectSubmitPost(
 				"_connectSubmitPost: Post hash: %v", postHash)
 		}
 
-		// Post modification is only allowed by the original poster.
-		if !reflect.DeepEqual(txn.PublicKey, existingPostEntryy.PosterPublicKey) {
+		// Post modification is only allowed by the original poster, or by the key
+		// their account was rotated to.
+		if !reflect.DeepEqual(txn.PublicKey, bav.GetCurrentPublicKey(existingPostEntryy.PosterPublicKey)) {
 
 			return 0, 0, nil, errors.Wrapf(
 				RuleErrorSubmitPostPostModificationNotAuthorized,
@@ -786,6 +865,12 @@ func (bav *UtxoView) _connectSubmitPost(
 			return 0, 0, nil, errors.Wrapf(RuleErrorSubmitPostCannotUpdateNFT, "_connectSubmitPost: ")
 		}
 
+		// Polls can only be declared when a post is created.
+		if postPoll != nil {
+			return 0, 0, nil, errors.Wrapf(
+				RuleErrorPollCannotBeAddedToExistingPost, "_connectSubmitPost: Post hash: %v", postHash)
+		}
+
 		// It's an error if we are updating the value of RepostedPostHash. A post can only ever repost a single post.
 		if !reflect.DeepEqual(repostedPostHash, existingPostEntryy.RepostedPostHash) {
 			return 0, 0, nil, errors.Wrapf(
@@ -836,6 +921,34 @@ func (bav *UtxoView) _connectSubmitPost(
 		// spam
 		newPostEntry.IsHidden = txMeta.IsHidden
 
+		// Append the edit to the post's history. The first edit also records the
+		// original content as version zero.
+		postVersionEntries, err := bav.GetPostVersionEntriesForPostHash(postHash)
+		if err != nil {
+			return 0, 0, nil, errors.Wrapf(err, "_connectSubmitPost: ")
+		}
+		prevNumPostVersions = uint64(len(postVersionEntries))
+		if prevNumPostVersions == 0 {
+			newPostVersionEntries = append(newPostVersionEntries, &PostVersionEntry{
+				PostHash:       postHash,
+				Version:        0,
+				Body:           prevPostEntry.Body,
+				PostExtraData:  prevPostEntry.PostExtraData,
+				IsHidden:       prevPostEntry.IsHidden,
+				TimestampNanos: prevPostEntry.TimestampNanos,
+				BlockHeight:    prevPostEntry.ConfirmationBlockHeight,
+			})
+		}
+		newPostVersionEntries = append(newPostVersionEntries, &PostVersionEntry{
+			PostHash:       postHash,
+			Version:        prevNumPostVersions + uint64(len(newPostVersionEntries)),
+			Body:           newPostEntry.Body,
+			PostExtraData:  newPostEntry.PostExtraData,
+			IsHidden:       newPostEntry.IsHidden,
+			TimestampNanos: txMeta.TimestampNanos,
+			BlockHeight:    blockHeight,
+		})
+
 		// Obtain the parent posts
 		newParentPostEntry, newGrandparentPostEntry, err = bav._getParentAndGrandparentPostEntry(newPostEntry)
 		if err != nil {
@@ -991,6 +1104,13 @@ func (bav *UtxoView) _connectSubmitPost(
 			// Don't set IsHidden on new posts.
 		}
 
+		if postPoll != nil {
+			newPollEntry, err = bav._createPollEntry(newPostEntry, postPoll, blockHeight)
+			if err != nil {
+				return 0, 0, nil, errors.Wrapf(err, "_connectSubmitPost: ")
+			}
+		}
+
 		// Obtain the parent posts
 		newParentPostEntry, newGrandparentPostEntry, err = bav._getParentAndGrandparentPostEntry(newPostEntry)
 		if err != nil {
@@ -1056,6 +1176,12 @@ func (bav *UtxoView) _connectSubmitPost(
 	if newRepostEntry != nil {
 		bav._setRepostEntryMappings(newRepostEntry)
 	}
+	for _, versionEntry := range newPostVersionEntries {
+		bav._setPostVersionEntryMappings(versionEntry)
+	}
+	if newPollEntry != nil {
+		bav._setPollEntryMappings(newPollEntry)
+	}
 
 	// Add an operation to the list at the end indicating we've added a post.
 	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
@@ -1066,6 +1192,7 @@ func (bav *UtxoView) _connectSubmitPost(
 		PrevGrandparentPostEntry: prevGrandparentPostEntry,
 		PrevRepostedPostEntry:    prevRepostedPostEntry,
 		PrevRepostEntry:          prevRepostEntry,
+		PrevNumPostVersions:      prevNumPostVersions,
 		Type:                     OperationTypeSubmitPost,
 	})
 
@@ -1219,6 +1346,27 @@ func (bav *UtxoView) _disconnectSubmitPost(
 		bav._setRepostEntryMappings(currentOperation.PrevRepostEntry)
 	}
 
+	// If the post declared a poll, delete it. Any votes have already been
+	// disconnected since they came after the post.
+	if len(txMeta.PostHashToModify) == 0 {
+		if pollEntry := bav.GetPollEntryForPostHash(postHashModified); pollEntry != nil {
+			bav._deletePollEntryMappings(pollEntry)
+		}
+	}
+
+	// If this was an edit, pop the versions it added to the post's history.
+	if len(txMeta.PostHashToModify) != 0 {
+		postVersionEntries, err := bav.GetPostVersionEntriesForPostHash(postHashModified)
+		if err != nil {
+			return errors.Wrapf(err, "_disconnectSubmitPost: ")
+		}
+		for _, versionEntry := range postVersionEntries {
+			if versionEntry.Version >= currentOperation.PrevNumPostVersions {
+				bav._deletePostVersionEntryMappings(versionEntry)
+			}
+		}
+	}
+
 	// Now revert the basic transfer with the remaining operations. Cut off
 	// the SubmitPost operation at the end since we just reverted it.
 	return bav._disconnectBasicTransfer(
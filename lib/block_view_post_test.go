@@ -2074,3 +2074,102 @@ func TestDeSoDiamondErrorCases(t *testing.T) {
 		require.Contains(err.Error(), RuleErrorBasicTransferInsufficientDeSoForDiamondLevel)
 	}
 }
+
+func TestPostEditHistory(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 1000000000)
+
+	getPostVersions := func(postHash *BlockHash) []*PostVersionEntry {
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		versionEntries, err := utxoView.GetPostVersionEntriesForPostHash(postHash)
+		require.NoError(err)
+		return versionEntries
+	}
+
+	_submitPostWithTestMeta(
+		testMeta,
+		10,                                /*feeRateNanosPerKB*/
+		m0Pub,                             /*updaterPkBase58Check*/
+		m0Priv,                            /*updaterPrivBase58Check*/
+		[]byte{},                          /*postHashToModify*/
+		[]byte{},                          /*parentStakeID*/
+		&DeSoBodySchema{Body: "original"}, /*body*/
+		[]byte{},                          /*repostedPostHash*/
+		1502947011*1e9,                    /*tstampNanos*/
+		false /*isHidden*/)
+	postHash := testMeta.txns[len(testMeta.txns)-1].Hash()
+
+	// A post that was never edited has no history.
+	require.Len(getPostVersions(postHash), 0)
+
+	_submitPostWithTestMeta(
+		testMeta,
+		10,                                  /*feeRateNanosPerKB*/
+		m0Pub,                               /*updaterPkBase58Check*/
+		m0Priv,                              /*updaterPrivBase58Check*/
+		postHash[:],                         /*postHashToModify*/
+		[]byte{},                            /*parentStakeID*/
+		&DeSoBodySchema{Body: "first edit"}, /*body*/
+		[]byte{},                            /*repostedPostHash*/
+		1502947012*1e9,                      /*tstampNanos*/
+		false /*isHidden*/)
+	_submitPostWithTestMeta(
+		testMeta,
+		10,                                   /*feeRateNanosPerKB*/
+		m0Pub,                                /*updaterPkBase58Check*/
+		m0Priv,                               /*updaterPrivBase58Check*/
+		postHash[:],                          /*postHashToModify*/
+		[]byte{},                             /*parentStakeID*/
+		&DeSoBodySchema{Body: "second edit"}, /*body*/
+		[]byte{},                             /*repostedPostHash*/
+		1502947013*1e9,                       /*tstampNanos*/
+		true /*isHidden*/)
+
+	// The first edit records the original as version zero.
+	{
+		versionEntries := getPostVersions(postHash)
+		require.Len(versionEntries, 3)
+		for ii, expectedBody := range []string{"original", "first edit", "second edit"} {
+			bodyObj := &DeSoBodySchema{}
+			require.NoError(json.Unmarshal(versionEntries[ii].Body, bodyObj))
+			require.Equal(uint64(ii), versionEntries[ii].Version)
+			require.Equal(expectedBody, bodyObj.Body)
+			require.Equal(uint64(1502947011+ii)*1e9, versionEntries[ii].TimestampNanos)
+		}
+		require.False(versionEntries[1].IsHidden)
+		require.True(versionEntries[2].IsHidden)
+	}
+
+	// Disconnecting an edit pops the versions it added.
+	_rollBackTestMetaTxnsAndFlush(testMeta)
+	require.Len(getPostVersions(postHash), 0)
+
+	// Connecting and disconnecting in a single view should do the same.
+	_applyTestMetaTxnsToViewAndFlush(testMeta)
+	require.Len(getPostVersions(postHash), 3)
+	_disconnectTestMetaTxnsFromViewAndFlush(testMeta)
+	require.Len(getPostVersions(postHash), 0)
+}
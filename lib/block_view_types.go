@@ -29,8 +29,10 @@ const (
 	UtxoTypeNFTBidderChange          UtxoType = 7
 	UtxoTypeNFTCreatorRoyalty        UtxoType = 8
 	UtxoTypeNFTAdditionalDESORoyalty UtxoType = 9
+	UtxoTypeBlockProducerBondRefund  UtxoType = 10
+	UtxoTypeUsernameSale             UtxoType = 11
 
-	// NEXT_TAG = 10
+	// NEXT_TAG = 12
 )
 
 func (mm UtxoType) String() string {
@@ -107,8 +109,25 @@ const (
 	OperationTypeMessagingKey                 OperationType = 24
 	OperationTypeDAOCoin                      OperationType = 25
 	OperationTypeDAOCoinTransfer              OperationType = 26
-
-	// NEXT_TAG = 27
+	OperationTypePollVote                     OperationType = 27
+	OperationTypeUserBlock                    OperationType = 28
+	OperationTypeMessagingGroupUpdate         OperationType = 29
+	OperationTypeMessageRead                  OperationType = 30
+	OperationTypeNFTAuctionSettlement         OperationType = 31
+	OperationTypeCreateNFTCollection          OperationType = 32
+	OperationTypeNFTVault                     OperationType = 33
+	OperationTypeRedeemNFTVoucher             OperationType = 34
+	OperationTypeRegisterBlockProducer        OperationType = 35
+	OperationTypeDeregisterBlockProducer      OperationType = 36
+	OperationTypeBlockProducerSchedule        OperationType = 37
+	OperationTypeUsernameListing              OperationType = 38
+	OperationTypeAcceptUsernameListing        OperationType = 39
+	OperationTypeKeyRotation                  OperationType = 40
+	OperationTypeRecoveryGuardians            OperationType = 41
+	OperationTypeAccountRecovery              OperationType = 42
+	OperationTypeClaimBlockProducerBond       OperationType = 43
+
+	// NEXT_TAG = 44
 )
 
 func (op OperationType) String() string {
@@ -217,6 +236,74 @@ func (op OperationType) String() string {
 		{
 			return "OperationTypeDAOCoinTransfer"
 		}
+	case OperationTypePollVote:
+		{
+			return "OperationTypePollVote"
+		}
+	case OperationTypeUserBlock:
+		{
+			return "OperationTypeUserBlock"
+		}
+	case OperationTypeMessagingGroupUpdate:
+		{
+			return "OperationTypeMessagingGroupUpdate"
+		}
+	case OperationTypeMessageRead:
+		{
+			return "OperationTypeMessageRead"
+		}
+	case OperationTypeNFTAuctionSettlement:
+		{
+			return "OperationTypeNFTAuctionSettlement"
+		}
+	case OperationTypeCreateNFTCollection:
+		{
+			return "OperationTypeCreateNFTCollection"
+		}
+	case OperationTypeNFTVault:
+		{
+			return "OperationTypeNFTVault"
+		}
+	case OperationTypeRedeemNFTVoucher:
+		{
+			return "OperationTypeRedeemNFTVoucher"
+		}
+	case OperationTypeRegisterBlockProducer:
+		{
+			return "OperationTypeRegisterBlockProducer"
+		}
+	case OperationTypeDeregisterBlockProducer:
+		{
+			return "OperationTypeDeregisterBlockProducer"
+		}
+	case OperationTypeBlockProducerSchedule:
+		{
+			return "OperationTypeBlockProducerSchedule"
+		}
+	case OperationTypeUsernameListing:
+		{
+			return "OperationTypeUsernameListing"
+		}
+	case OperationTypeAcceptUsernameListing:
+		{
+			return "OperationTypeAcceptUsernameListing"
+		}
+	case OperationTypeKeyRotation:
+		{
+			return "OperationTypeKeyRotation"
+		}
+	case OperationTypeRecoveryGuardians:
+		{
+			return "OperationTypeRecoveryGuardians"
+		}
+	case OperationTypeAccountRecovery:
+		{
+			return "OperationTypeAccountRecovery"
+		}
+	case OperationTypeClaimBlockProducerBond:
+		{
+			return "OperationTypeClaimBlockProducerBond"
+		}
 	}
 	return "OperationTypeUNKNOWN"
 }
@@ -281,6 +368,10 @@ type UtxoOperation struct {
 	NFTSpentUtxoEntries       []*UtxoEntry
 	PrevAcceptedNFTBidEntries *[]*NFTBidEntry
 
+	// For disconnecting NFT auction settlements. This is nil if the auction
+	// ended without a bid at or above the reserve price.
+	NFTAuctionWinningBidEntry *NFTBidEntry
+
 	// For disconnecting AuthorizeDerivedKey transactions.
 	PrevDerivedKeyEntry *DerivedKeyEntry
 
@@ -291,10 +382,76 @@ type UtxoOperation struct {
 	PrevRepostEntry *RepostEntry
 	PrevRepostCount uint64
 
+	// The number of versions a post had before it was edited. Disconnecting the
+	// edit deletes every version at or above this number.
+	PrevNumPostVersions uint64
+
+	// For disconnecting PollVote transactions.
+	PrevPollEntry *PollEntry
+
+	// For disconnecting UserBlock transactions. This is nil if there was no
+	// block or mute between the two users before the txn.
+	PrevUserBlockEntry *UserBlockEntry
+
+	// For disconnecting MessageRead transactions. This is nil if the reader
+	// had no read marker for the thread before the txn.
+	PrevMessageReadEntry *MessageReadEntry
+
+	// For disconnecting CreateNFT transactions that attach the NFT to a
+	// collection. This is nil if the NFT isn't part of a collection.
+	PrevNFTCollectionEntry *NFTCollectionEntry
+
+	// For disconnecting the buyout of a vaulted NFT. These are the share
+	// balances that were burned when the buyout was paid out.
+	PrevNFTVaultShareBalanceEntries []*BalanceEntry
+
+	// For disconnecting RegisterBlockProducer, DeregisterBlockProducer and
+	// ClaimBlockProducerBond transactions. This is nil if the producer had never
+	// registered before.
+	PrevBlockProducerEntry *BlockProducerEntry
+
+	// For disconnecting the block producer schedule at the end of a block. These
+	// are the entries of the producer that signed the block and of the producers
+	// whose slots it missed.
+	PrevBlockProducerEntries []*BlockProducerEntry
+
+	// For disconnecting UsernameListing and AcceptUsernameListing transactions.
+	// This is nil if there was no listing for the username before.
+	PrevUsernameListingEntry *UsernameListingEntry
+	// For disconnecting AcceptUsernameListing transactions. The seller's profile
+	// is saved in PrevProfileEntry and the buyer's is saved here.
+	PrevUsernameBuyerProfileEntry *ProfileEntry
+
+	// For disconnecting KeyRotation transactions. PrevKeyRotationEntry is the
+	// pending rotation before the txn, or nil if there was none. The derived keys
+	// and messaging groups are the ones that were moved to the new key, as they
+	// were under the old key.
+	PrevKeyRotationEntry             *KeyRotationEntry
+	KeyRotationDerivedKeyEntries     []*DerivedKeyEntry
+	KeyRotationMessagingGroupEntries []*MessagingGroupEntry
+	// For disconnecting KeyRotation transactions that set the minimum timelock.
+	// This is nil if the account had never set one.
+	PrevKeyRotationTimelockEntry *KeyRotationTimelockEntry
+
+	// For disconnecting RecoveryGuardians and AccountRecovery transactions. A
+	// completed recovery also uses the KeyRotation fields above to save what it
+	// moved and the pending key rotation it cleared. A completed KeyRotation uses
+	// PrevAccountRecoveryEntry to save a recovery to the same new key it cleared.
+	PrevRecoveryGuardianSetEntry *RecoveryGuardianSetEntry
+	PrevAccountRecoveryEntry     *AccountRecoveryEntry
+	// For disconnecting completed AccountRecovery transactions. This is the key
+	// the account was moved away from, which isn't the txn's OwnerPublicKey if
+	// the account had been rotated to another key since.
+	AccountRecoveryOldPublicKey []byte
+
 	// Save the state of a creator coin prior to updating it due to a
 	// buy/sell/add transaction.
 	PrevCoinEntry *CoinEntry
 
+	// Save the creator coin's price candle for the block prior to a buy, sell or
+	// diamond transfer updating it. Nil if the txn created the candle.
+	PrevCreatorCoinPriceCandleEntry *CreatorCoinPriceCandleEntry
+
 	// Save the state of coin entries associated with a PKID prior to updating
 	// it due to an additional coin royalty when an NFT is sold.
 	PrevCoinRoyaltyCoinEntries map[PKID]CoinEntry
@@ -522,6 +679,38 @@ func (message *MessageEntry) Decode(data []byte) error {
 	return nil
 }
 
+func MakeMessageReadKey(readerPublicKey []byte, threadPublicKey []byte) MessageReadKey {
+	return MessageReadKey{
+		ReaderPublicKey: *NewPublicKey(readerPublicKey),
+		ThreadPublicKey: *NewPublicKey(threadPublicKey),
+	}
+}
+
+type MessageReadKey struct {
+	ReaderPublicKey PublicKey
+	ThreadPublicKey PublicKey
+}
+
+func (key *MessageReadKey) String() string {
+	return fmt.Sprintf("<ReaderPublicKey: %s, ThreadPublicKey: %s>",
+		PkToStringMainnet(key.ReaderPublicKey[:]), PkToStringMainnet(key.ThreadPublicKey[:]))
+}
+
+// MessageReadEntry is a read marker for a message thread. The ThreadPublicKey is the
+// counterparty's main public key for a direct message thread, and the group's
+// messaging public key for a group chat.
+type MessageReadEntry struct {
+	ReaderPublicKey *PublicKey
+	ThreadPublicKey *PublicKey
+
+	// Every message in the thread with a TstampNanos less than or equal to this
+	// has been read by the reader.
+	LastReadTstampNanos uint64
+
+	// Whether or not this entry is deleted in the view.
+	isDeleted bool
+}
+
 // GroupKeyName helps with handling key names in MessagingGroupKey
 type GroupKeyName [MaxMessagingKeyNameCharacters]byte
 
@@ -619,6 +808,10 @@ type MessagingGroupEntry struct {
 	// is given to all group members.
 	MessagingGroupMembers []*MessagingGroupMember
 
+	// MessagingGroupAdmins is a list of members, besides the owner, who are allowed
+	// to add new members to the group. Admins are set with MessagingGroupUpdate txns.
+	MessagingGroupAdmins []*PublicKey
+
 	// Whether this entry should be deleted when the view is flushed
 	// to the db. This is initially set to false, but can become true if
 	// we disconnect the messaging key from UtxoView
@@ -640,6 +833,14 @@ func (entry *MessagingGroupEntry) Encode() []byte {
 	for ii := 0; ii < len(entry.MessagingGroupMembers); ii++ {
 		entryBytes = append(entryBytes, entry.MessagingGroupMembers[ii].Encode()...)
 	}
+	// Admins were added after groups were first stored in the db, so we only append
+	// them when there are some. This keeps the encoding of existing entries unchanged.
+	if len(entry.MessagingGroupAdmins) > 0 {
+		entryBytes = append(entryBytes, UintToBuf(uint64(len(entry.MessagingGroupAdmins)))...)
+		for _, admin := range entry.MessagingGroupAdmins {
+			entryBytes = append(entryBytes, EncodeByteArray(admin[:])...)
+		}
+	}
 	return entryBytes
 }
 
@@ -678,6 +879,22 @@ func (entry *MessagingGroupEntry) Decode(data []byte) error {
 		entry.MessagingGroupMembers = append(entry.MessagingGroupMembers, &recipient)
 	}
 
+	// Entries without admins end after the members.
+	if rr.Len() == 0 {
+		return nil
+	}
+	adminsLen, err := ReadUvarint(rr)
+	if err != nil {
+		return errors.Wrapf(err, "MessagingGroupEntry.Decode: Problem decoding admins length")
+	}
+	for ; adminsLen > 0; adminsLen-- {
+		adminPublicKeyBytes, err := DecodeByteArray(rr)
+		if err != nil {
+			return errors.Wrapf(err, "MessagingGroupEntry.Decode: Problem decoding admin")
+		}
+		entry.MessagingGroupAdmins = append(entry.MessagingGroupAdmins, NewPublicKey(adminPublicKeyBytes))
+	}
+
 	return nil
 }
 
@@ -805,6 +1022,25 @@ type NFTEntry struct {
 	// If an NFT is a Buy Now NFT, it can be purchased for this price.
 	BuyNowPriceNanos uint64
 
+	// If an NFT is up for auction, bids are accepted until this block height and the
+	// highest bid is settled automatically once a block at or past it is connected.
+	// Zero means the NFT is not up for auction.
+	AuctionEndBlockHeight uint64
+
+	// An auction only sells the NFT if the highest bid is at least this amount.
+	AuctionReservePriceNanos uint64
+
+	// Each new bid in an auction must beat the current highest bid by at least this amount.
+	AuctionMinBidIncrementNanos uint64
+
+	// If an NFT is vaulted, its ownership is split into VaultTotalSharesNanos shares that are
+	// held as DAO coin balances under NFTVaultPKID. It stays locked until someone holding every
+	// share redeems it, or a bid at or above VaultBuyoutPriceNanos buys it out on behalf of
+	// the share holders.
+	IsVaulted             bool
+	VaultTotalSharesNanos uint256.Int
+	VaultBuyoutPriceNanos uint64
+
 	// Whether or not this entry is deleted in the view.
 	isDeleted bool
 }
@@ -834,6 +1070,166 @@ type NFTBidEntry struct {
 	isDeleted bool
 }
 
+// NFTCollectionEntry groups NFTs minted by the same creator under a common name.
+// Every NFT attached to a collection uses the collection's royalties, and the
+// total number of copies minted across the collection can be capped.
+type NFTCollectionEntry struct {
+	// The collection ID is the hash of the transaction that created it.
+	CollectionID *BlockHash
+	CreatorPKID  *PKID
+	Name         []byte
+
+	// MaxSupply caps NumNFTCopies. Zero means the supply is unlimited.
+	MaxSupply    uint64
+	NumNFTCopies uint64
+
+	NFTRoyaltyToCreatorBasisPoints              uint64
+	NFTRoyaltyToCoinBasisPoints                 uint64
+	AdditionalNFTRoyaltiesToCreatorsBasisPoints map[PKID]uint64
+	AdditionalNFTRoyaltiesToCoinsBasisPoints    map[PKID]uint64
+
+	// Whether or not this entry is deleted in the view.
+	isDeleted bool
+}
+
+// NFTVoucherRedemptionEntry records that a copy of a lazily minted NFT has been
+// minted by redeeming the creator's voucher for it. The entry outlives the
+// NFTEntry so that a voucher can't be replayed once the copy is burned.
+type NFTVoucherRedemptionEntry struct {
+	NFTPostHash  *BlockHash
+	SerialNumber uint64
+	RedeemerPKID *PKID
+	PriceNanos   uint64
+
+	// Whether or not this entry is deleted in the view.
+	isDeleted bool
+}
+
+// UsernameListingEntry is an offer by a profile owner to hand their username over
+// to another user, either to anyone who pays PriceNanos or only to RecipientPKID.
+type UsernameListingEntry struct {
+	// The username as it appears on the owner's profile.
+	Username []byte
+	// The username the owner's profile takes once the listing is accepted.
+	ReplacementUsername []byte
+
+	OwnerPKID *PKID
+	// RecipientPKID is nil if anyone can accept the listing.
+	RecipientPKID *PKID
+	PriceNanos    uint64
+
+	// Whether or not this entry is deleted in the view.
+	isDeleted bool
+}
+
+// KeyRotationEntry is a rotation of OldPublicKey's PKID to NewPublicKey that is
+// waiting on its timelock. It can be completed at UnlockBlockHeight and until then
+// the old key can cancel it.
+type KeyRotationEntry struct {
+	OldPublicKey      []byte
+	NewPublicKey      []byte
+	UnlockBlockHeight uint64
+
+	// Whether or not this entry is deleted in the view.
+	isDeleted bool
+}
+
+// KeyRotationTimelockEntry is the smallest timelock OwnerPKID has committed to for
+// rotating its key. Raising MinTimelockBlocks takes effect right away, but lowering
+// it only takes effect at PendingUnlockBlockHeight, once the old minimum has passed.
+// PendingUnlockBlockHeight is zero if no lower minimum is pending.
+type KeyRotationTimelockEntry struct {
+	OwnerPKID                *PKID
+	MinTimelockBlocks        uint64
+	PendingMinTimelockBlocks uint64
+	PendingUnlockBlockHeight uint64
+
+	// Whether or not this entry is deleted in the view.
+	isDeleted bool
+}
+
+// RotatedPublicKeyEntry records that OldPublicKey was rotated away from the account
+// with PKID. The old key can't write posts or NFTs after that, and anything still
+// keyed by it, like the PosterPublicKey of the account's old posts, resolves to the
+// public key PKID has now.
+type RotatedPublicKeyEntry struct {
+	OldPublicKey []byte
+	PKID         *PKID
+
+	// Whether or not this entry is deleted in the view.
+	isDeleted bool
+}
+
+// RecoveryGuardianSetEntry is the set of guardians that can jointly recover the
+// account of OwnerPKID. It's keyed by PKID so it stays with the account when the
+// account's public key changes. An owner who removes their guardians keeps an
+// entry with no guardians so that RecoveryNonce never goes back down.
+type RecoveryGuardianSetEntry struct {
+	OwnerPKID          *PKID
+	GuardianPublicKeys [][]byte
+	Threshold          uint64
+	DelayBlocks        uint64
+
+	// RecoveryNonce is the number of recoveries the guardians have started. It's
+	// part of the data they sign so their signatures can't be replayed after the
+	// owner cancels a recovery.
+	RecoveryNonce uint64
+
+	// Once an account has guardians, changing them only takes effect at
+	// PendingUnlockBlockHeight, DelayBlocks after the change was made, so that a
+	// stolen owner key can't get rid of the guardians before they can step in.
+	// PendingUnlockBlockHeight is zero if no change is pending.
+	PendingGuardianPublicKeys [][]byte
+	PendingThreshold          uint64
+	PendingDelayBlocks        uint64
+	PendingUnlockBlockHeight  uint64
+
+	// Whether or not this entry is deleted in the view.
+	isDeleted bool
+}
+
+// AccountRecoveryEntry is a recovery of OwnerPKID to NewPublicKey started by the
+// owner's guardians. It can be completed at UnlockBlockHeight and until then the
+// owner can cancel it.
+type AccountRecoveryEntry struct {
+	OwnerPKID         *PKID
+	NewPublicKey      []byte
+	UnlockBlockHeight uint64
+
+	// Whether or not this entry is deleted in the view.
+	isDeleted bool
+}
+
+// BlockProducerEntry tracks a public key that has registered on-chain to produce
+// blocks. The entry is kept after the producer deregisters so that its record of
+// produced blocks and missed slots survives for later slashing decisions.
+type BlockProducerEntry struct {
+	PublicKey []byte
+
+	// The bond locked up by the producer. This is zero while the producer is
+	// deregistered.
+	BondNanos             uint64
+	IsRegistered          bool
+	RegisteredBlockHeight uint32
+
+	// When the producer deregisters, its bond is held here until
+	// BlockProducerUnbondingBlocks have passed since UnbondingBlockHeight so that
+	// the producer can still be slashed for what it did while registered. The
+	// producer then releases it with a ClaimBlockProducerBond txn.
+	UnbondingNanos       uint64
+	UnbondingBlockHeight uint32
+
+	// NumBlocksProduced counts the blocks this producer signed in its slot, and
+	// NumMissedSlots counts the slots that passed to the next producer because
+	// this producer didn't produce a block in time.
+	NumBlocksProduced     uint64
+	NumMissedSlots        uint64
+	LastMissedBlockHeight uint32
+
+	// Whether or not this entry is deleted in the view.
+	isDeleted bool
+}
+
 type DerivedKeyEntry struct {
 	// Owner public key
 	OwnerPublicKey PublicKey
@@ -890,6 +1286,29 @@ type FollowEntry struct {
 	isDeleted bool
 }
 
+func MakeUserBlockKey(blockerPKID *PKID, blockedPKID *PKID) UserBlockKey {
+	return UserBlockKey{
+		BlockerPKID: *blockerPKID,
+		BlockedPKID: *blockedPKID,
+	}
+}
+
+type UserBlockKey struct {
+	BlockerPKID PKID
+	BlockedPKID PKID
+}
+
+// UserBlockEntry stores the content of a user block transaction. A blocker has at
+// most one entry per blocked PKID, which is either a block or a mute.
+type UserBlockEntry struct {
+	BlockerPKID *PKID
+	BlockedPKID *PKID
+	BlockType   UserBlockType
+
+	// Whether or not this entry is deleted in the view.
+	isDeleted bool
+}
+
 type DiamondKey struct {
 	SenderPKID      PKID
 	ReceiverPKID    PKID
@@ -921,6 +1340,97 @@ type DiamondEntry struct {
 	isDeleted bool
 }
 
+type PostVersionKey struct {
+	PostHash BlockHash
+	Version  uint64
+}
+
+func MakePostVersionKey(postHash *BlockHash, version uint64) PostVersionKey {
+	return PostVersionKey{
+		PostHash: *postHash,
+		Version:  version,
+	}
+}
+
+// PostVersionEntry is one version of a post's content. Versions are only recorded
+// once a post is edited. The first edit records the original content as version
+// zero and the edited content as version one, and each later edit appends one
+// more version.
+type PostVersionEntry struct {
+	PostHash      *BlockHash
+	Version       uint64
+	Body          []byte
+	PostExtraData map[string][]byte
+	IsHidden      bool
+
+	// For version zero this is the post's own timestamp. For later versions it's
+	// the timestamp on the txn that made the edit.
+	TimestampNanos uint64
+
+	// The height of the block the version was confirmed in.
+	BlockHeight uint32
+
+	// Whether or not this entry is deleted in the view.
+	isDeleted bool
+}
+
+// PollEntry is a poll declared by a post along with its running tally.
+type PollEntry struct {
+	PostHash   *BlockHash
+	PosterPKID *PKID
+
+	Question           []byte
+	Options            [][]byte
+	ClosingBlockHeight uint32
+	WeightType         PollWeightType
+
+	// The sum of the weights of the votes cast for each option, indexed like
+	// Options. Weights are balances in nanos for coin-weighted polls, which is
+	// why these are uint256s.
+	OptionTallies []uint256.Int
+
+	// The number of PKIDs that have voted.
+	NumVotes uint64
+
+	// Whether or not this entry is deleted in the view.
+	isDeleted bool
+}
+
+// Copy returns a deep copy so a vote can update the tallies without modifying
+// the entry saved in the UtxoOperation.
+func (pollEntry *PollEntry) Copy() *PollEntry {
+	newPollEntry := *pollEntry
+	newPollEntry.OptionTallies = append([]uint256.Int{}, pollEntry.OptionTallies...)
+	return &newPollEntry
+}
+
+type PollVoteKey struct {
+	PostHash  BlockHash
+	VoterPKID PKID
+}
+
+func MakePollVoteKey(postHash *BlockHash, voterPKID *PKID) PollVoteKey {
+	return PollVoteKey{
+		PostHash:  *postHash,
+		VoterPKID: *voterPKID,
+	}
+}
+
+// PollVoteEntry is a single PKID's vote in a poll. The weight is fixed when the
+// vote is cast, so selling coins afterwards doesn't change the tally.
+type PollVoteEntry struct {
+	PostHash    *BlockHash
+	VoterPKID   *PKID
+	OptionIndex uint32
+	Weight      uint256.Int
+
+	// The height of the block the vote was confirmed in.
+	BlockHeight uint32
+
+	// Whether or not this entry is deleted in the view.
+	isDeleted bool
+}
+
 func MakeRepostKey(userPk []byte, RepostedPostHash BlockHash) RepostKey {
 	return RepostKey{
 		ReposterPubKey:   MakePkMapKey(userPk),
@@ -1074,6 +1584,13 @@ type PostEntry struct {
 	// the DESO locked in their profile anytime this NFT is sold. This map must not contain the post creator.
 	AdditionalNFTRoyaltiesToCoinsBasisPoints map[PKID]uint64
 
+	// If this NFT belongs to a collection, the ID of the collection. Nil otherwise.
+	NFTCollectionID *BlockHash
+
+	// IsLazyMintedNFT is true if the NFT's copies are minted one at a time as the
+	// creator's vouchers are redeemed instead of all at once by a CreateNFT txn.
+	IsLazyMintedNFT bool
+
 	// ExtraData map to hold arbitrary attributes of a post. Holds non-consensus related information about a post.
 	PostExtraData map[string][]byte
 }
@@ -1223,6 +1740,46 @@ type CoinEntry struct {
 	TransferRestrictionStatus TransferRestrictionStatus
 }
 
+type CreatorCoinPriceCandleKey struct {
+	CreatorPKID PKID
+	BlockHeight uint32
+}
+
+func MakeCreatorCoinPriceCandleKey(creatorPKID *PKID, blockHeight uint32) CreatorCoinPriceCandleKey {
+	return CreatorCoinPriceCandleKey{
+		CreatorPKID: *creatorPKID,
+		BlockHeight: blockHeight,
+	}
+}
+
+// CreatorCoinPriceCandleEntry is an OHLC candle for a creator coin. A candle is
+// stored for every block in which a buy, sell or diamond transfer changed the
+// coin's CoinEntry, and those per-block candles are merged into wider candles when
+// they're fetched. Prices are the spot price of one whole coin in DeSo nanos. The
+// open is the price before the first trade in the candle so that consecutive
+// candles line up.
+type CreatorCoinPriceCandleEntry struct {
+	CreatorPKID *PKID
+
+	// The first and last block heights the candle covers. The candles stored for
+	// each block have both set to the block's height.
+	StartBlockHeight uint32
+	EndBlockHeight   uint32
+
+	OpenPriceNanos  uint64
+	HighPriceNanos  uint64
+	LowPriceNanos   uint64
+	ClosePriceNanos uint64
+
+	// The DeSo moved by the trades in the candle. For buys and sells this is the
+	// change in DeSoLockedNanos and for diamonds it's the value of the coins sent.
+	VolumeNanos uint64
+	NumTrades   uint64
+
+	// Whether or not this entry is deleted in the view.
+	isDeleted bool
+}
+
 type PublicKeyRoyaltyPair struct {
 	PublicKey          []byte
 	RoyaltyAmountNanos uint64
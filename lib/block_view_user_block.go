@@ -0,0 +1,307 @@
+package lib
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// GetUserBlockEntry returns the block or mute that blockerPKID has placed on
+// blockedPKID, or nil if there isn't one.
+func (bav *UtxoView) GetUserBlockEntry(blockerPKID *PKID, blockedPKID *PKID) *UserBlockEntry {
+	// If an entry exists in the in-memory map, return the value of that mapping.
+	userBlockKey := MakeUserBlockKey(blockerPKID, blockedPKID)
+	if mapValue, existsMapValue := bav.UserBlockKeyToUserBlockEntry[userBlockKey]; existsMapValue {
+		if mapValue.isDeleted {
+			return nil
+		}
+		return mapValue
+	}
+
+	// If we get here it means no value exists in our in-memory map. In this case,
+	// defer to the db. If a mapping exists in the db, return it. If not, return
+	// nil. Either way, save the value to the in-memory view mapping got later.
+	var userBlockEntry *UserBlockEntry
+	if bav.Postgres != nil {
+		if userBlock := bav.Postgres.GetUserBlock(blockerPKID, blockedPKID); userBlock != nil {
+			userBlockEntry = userBlock.NewUserBlockEntry()
+		}
+	} else {
+		userBlockEntry = DbGetUserBlockEntry(bav.Handle, blockerPKID, blockedPKID)
+	}
+	if userBlockEntry != nil {
+		bav._setUserBlockEntryMappings(userBlockEntry)
+	}
+	return userBlockEntry
+}
+
+// IsPublicKeyBlockedByPublicKey returns true if blockerPublicKey has blocked
+// blockedPublicKey. Mutes aren't enforced so they don't count.
+func (bav *UtxoView) IsPublicKeyBlockedByPublicKey(blockedPublicKey []byte, blockerPublicKey []byte) bool {
+	blockerPKID := bav.GetPKIDForPublicKey(blockerPublicKey)
+	blockedPKID := bav.GetPKIDForPublicKey(blockedPublicKey)
+	if blockerPKID == nil || blockerPKID.isDeleted || blockedPKID == nil || blockedPKID.isDeleted {
+		return false
+	}
+
+	userBlockEntry := bav.GetUserBlockEntry(blockerPKID.PKID, blockedPKID.PKID)
+	return userBlockEntry != nil && userBlockEntry.BlockType == UserBlockTypeBlock
+}
+
+// getEntriesBlockingPublicKey == true => Returns UserBlockEntries for people that have blocked or muted publicKey
+// getEntriesBlockingPublicKey == false => Returns UserBlockEntries for people that publicKey has blocked or muted
+func (bav *UtxoView) GetUserBlockEntriesForPublicKey(publicKey []byte, getEntriesBlockingPublicKey bool) (
+	_userBlockEntries []*UserBlockEntry, _err error) {
+
+	// If the public key is not set then there are no UserBlockEntries to return.
+	if len(publicKey) == 0 {
+		return []*UserBlockEntry{}, nil
+	}
+
+	// Look up the PKID for the public key. This should always be set.
+	pkidForPublicKey := bav.GetPKIDForPublicKey(publicKey)
+	if pkidForPublicKey == nil || pkidForPublicKey.isDeleted {
+		return nil, fmt.Errorf("GetUserBlockEntriesForPublicKey: PKID for public key %v was nil "+
+			"or deleted on the view; this should never happen",
+			PkToString(publicKey, bav.Params))
+	}
+	pkid := pkidForPublicKey.PKID
+
+	// Start by fetching all the blocks we have in the db.
+	var dbUserBlockEntries []*UserBlockEntry
+	if bav.Postgres != nil {
+		var userBlocks []*PGUserBlock
+		if getEntriesBlockingPublicKey {
+			userBlocks = bav.Postgres.GetUserBlockers(pkid)
+		} else {
+			userBlocks = bav.Postgres.GetUserBlocked(pkid)
+		}
+		for _, userBlock := range userBlocks {
+			dbUserBlockEntries = append(dbUserBlockEntries, userBlock.NewUserBlockEntry())
+		}
+	} else {
+		var err error
+		dbUserBlockEntries, err = DbGetUserBlockEntriesForPKID(bav.Handle, pkid, getEntriesBlockingPublicKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetUserBlockEntriesForPublicKey: Problem fetching "+
+				"UserBlockEntries from db: ")
+		}
+	}
+
+	// Load the entries found in the db into the view without overwriting the
+	// entries the view already has. After this the view contains the union of
+	// what it had before plus what was in the db.
+	for _, userBlockEntry := range dbUserBlockEntries {
+		userBlockKey := MakeUserBlockKey(userBlockEntry.BlockerPKID, userBlockEntry.BlockedPKID)
+		if _, exists := bav.UserBlockKeyToUserBlockEntry[userBlockKey]; !exists {
+			bav._setUserBlockEntryMappings(userBlockEntry)
+		}
+	}
+
+	// Now that the view mappings are a complete picture, skip the entries that
+	// don't involve our PKID or that are deleted.
+	userBlockEntriesToReturn := []*UserBlockEntry{}
+	for _, userBlockEntry := range bav.UserBlockKeyToUserBlockEntry {
+		if userBlockEntry.isDeleted {
+			continue
+		}
+		if getEntriesBlockingPublicKey && *userBlockEntry.BlockedPKID != *pkid {
+			continue
+		}
+		if !getEntriesBlockingPublicKey && *userBlockEntry.BlockerPKID != *pkid {
+			continue
+		}
+		userBlockEntriesToReturn = append(userBlockEntriesToReturn, userBlockEntry)
+	}
+
+	return userBlockEntriesToReturn, nil
+}
+
+func (bav *UtxoView) _setUserBlockEntryMappings(userBlockEntry *UserBlockEntry) {
+	// This function shouldn't be called with nil.
+	if userBlockEntry == nil {
+		glog.Errorf("_setUserBlockEntryMappings: Called with nil UserBlockEntry; " +
+			"this should never happen.")
+		return
+	}
+
+	userBlockKey := MakeUserBlockKey(userBlockEntry.BlockerPKID, userBlockEntry.BlockedPKID)
+	bav.UserBlockKeyToUserBlockEntry[userBlockKey] = userBlockEntry
+}
+
+func (bav *UtxoView) _deleteUserBlockEntryMappings(userBlockEntry *UserBlockEntry) {
+
+	// Create a tombstone entry.
+	tombstoneUserBlockEntry := *userBlockEntry
+	tombstoneUserBlockEntry.isDeleted = true
+
+	// Set the mappings to point to the tombstone entry.
+	bav._setUserBlockEntryMappings(&tombstoneUserBlockEntry)
+}
+
+func (bav *UtxoView) _connectUserBlock(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
+	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
+
+	if blockHeight < bav.Params.ForkHeights.UserBlocksBlockHeight {
+		return 0, 0, nil, RuleErrorUserBlockBeforeBlockHeight
+	}
+
+	// Check that the transaction has the right TxnType.
+	if txn.TxnMeta.GetTxnType() != TxnTypeUserBlock {
+		return 0, 0, nil, fmt.Errorf("_connectUserBlock: called with bad TxnType %s",
+			txn.TxnMeta.GetTxnType().String())
+	}
+	txMeta := txn.TxnMeta.(*UserBlockMetadata)
+
+	// Check that a proper public key is provided in the message metadata. Unlike
+	// follows, the blocked public key doesn't need to have a profile.
+	if len(txMeta.BlockedPublicKey) != btcec.PubKeyBytesLenCompressed {
+		return 0, 0, nil, errors.Wrapf(
+			RuleErrorUserBlockPubKeyLen, "_connectUserBlock: "+
+				"BlockedPubKeyLen = %d; Expected length = %d",
+			len(txMeta.BlockedPublicKey), btcec.PubKeyBytesLenCompressed)
+	}
+	if txMeta.BlockType != UserBlockTypeBlock && txMeta.BlockType != UserBlockTypeMute {
+		return 0, 0, nil, errors.Wrapf(
+			RuleErrorUserBlockInvalidBlockType, "_connectUserBlock: BlockType = %d", txMeta.BlockType)
+	}
+	if reflect.DeepEqual(txn.PublicKey, txMeta.BlockedPublicKey) {
+		return 0, 0, nil, RuleErrorUserBlockCannotBlockSelf
+	}
+
+	// Connect basic txn to get the total input and the total output without
+	// considering the transaction metadata.
+	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
+		txn, txHash, blockHeight, verifySignatures)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectUserBlock: ")
+	}
+
+	// Force the input to be non-zero so that an old unblock can't be replayed
+	// after the user blocks again.
+	if totalInput == 0 {
+		return 0, 0, nil, RuleErrorUserBlockRequiresNonZeroInput
+	}
+
+	// At this point the inputs and outputs have been processed. Now we
+	// need to handle the metadata.
+
+	// Get the PKIDs for the public keys associated with the blocker and the blocked.
+	blockerPKID := bav.GetPKIDForPublicKey(txn.PublicKey)
+	if blockerPKID == nil || blockerPKID.isDeleted {
+		return 0, 0, nil, fmt.Errorf("_connectUserBlock: blockerPKID was nil or deleted; this should never happen")
+	}
+	blockedPKID := bav.GetPKIDForPublicKey(txMeta.BlockedPublicKey)
+	if blockedPKID == nil || blockedPKID.isDeleted {
+		return 0, 0, nil, fmt.Errorf("_connectUserBlock: blockedPKID was nil or deleted; this should never happen")
+	}
+
+	// Here we consider the existing entry. A pair of users has at most one entry,
+	// so blocking someone you've muted replaces the mute and vice versa.
+	userBlockKey := MakeUserBlockKey(blockerPKID.PKID, blockedPKID.PKID)
+	existingUserBlockEntry := bav.GetUserBlockEntry(blockerPKID.PKID, blockedPKID.PKID)
+	if txMeta.IsUnblock {
+		// If this is an unblock, an entry of the same type *should* exist.
+		if existingUserBlockEntry == nil {
+			return 0, 0, nil, errors.Wrapf(
+				RuleErrorCannotUnblockNonexistentUserBlockEntry,
+				"_connectUserBlock: UserBlock key: %v", &userBlockKey)
+		}
+		if existingUserBlockEntry.BlockType != txMeta.BlockType {
+			return 0, 0, nil, errors.Wrapf(
+				RuleErrorUserBlockTypeMismatch,
+				"_connectUserBlock: Trying to remove a %v but found a %v",
+				txMeta.BlockType, existingUserBlockEntry.BlockType)
+		}
+
+		// Now that we know that this is a valid unblock, delete the mapping.
+		bav._deleteUserBlockEntryMappings(existingUserBlockEntry)
+	} else {
+		if existingUserBlockEntry != nil && existingUserBlockEntry.BlockType == txMeta.BlockType {
+			return 0, 0, nil, errors.Wrapf(
+				RuleErrorUserBlockEntryAlreadyExists,
+				"_connectUserBlock: UserBlock key: %v", &userBlockKey)
+		}
+
+		// Now that we know that this is a valid block, update the mapping.
+		bav._setUserBlockEntryMappings(&UserBlockEntry{
+			BlockerPKID: blockerPKID.PKID,
+			BlockedPKID: blockedPKID.PKID,
+			BlockType:   txMeta.BlockType,
+		})
+	}
+
+	// Add an operation to the list at the end indicating we've updated a block.
+	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
+		Type:               OperationTypeUserBlock,
+		PrevUserBlockEntry: existingUserBlockEntry,
+	})
+
+	return totalInput, totalOutput, utxoOpsForTxn, nil
+}
+
+func (bav *UtxoView) _disconnectUserBlock(
+	operationType OperationType, currentTxn *MsgDeSoTxn, txnHash *BlockHash,
+	utxoOpsForTxn []*UtxoOperation, blockHeight uint32) error {
+
+	// Verify that the last operation is a UserBlock operation
+	if len(utxoOpsForTxn) == 0 {
+		return fmt.Errorf("_disconnectUserBlock: utxoOperations are missing")
+	}
+	operationIndex := len(utxoOpsForTxn) - 1
+	currentOperation := utxoOpsForTxn[operationIndex]
+	if currentOperation.Type != OperationTypeUserBlock {
+		return fmt.Errorf("_disconnectUserBlock: Trying to revert "+
+			"OperationTypeUserBlock but found type %v",
+			currentOperation.Type)
+	}
+
+	// Now we know the txMeta is a UserBlock
+	txMeta := currentTxn.TxnMeta.(*UserBlockMetadata)
+
+	// Get the PKIDs for the public keys associated with the blocker and the blocked.
+	blockerPKID := bav.GetPKIDForPublicKey(currentTxn.PublicKey)
+	if blockerPKID == nil || blockerPKID.isDeleted {
+		return fmt.Errorf("_disconnectUserBlock: blockerPKID was nil or deleted; this should never happen")
+	}
+	blockedPKID := bav.GetPKIDForPublicKey(txMeta.BlockedPublicKey)
+	if blockedPKID == nil || blockedPKID.isDeleted {
+		return fmt.Errorf("_disconnectUserBlock: blockedPKID was nil or deleted; this should never happen")
+	}
+
+	// Sanity check that the current state lines up with the transaction we're
+	// rolling back. An unblock leaves no entry and a block leaves an entry of
+	// the txn's type.
+	userBlockKey := MakeUserBlockKey(blockerPKID.PKID, blockedPKID.PKID)
+	userBlockEntry := bav.GetUserBlockEntry(blockerPKID.PKID, blockedPKID.PKID)
+	if txMeta.IsUnblock {
+		if userBlockEntry != nil {
+			return fmt.Errorf("_disconnectUserBlock: Found UserBlockEntry %v for "+
+				"key %v after an unblock", userBlockEntry, &userBlockKey)
+		}
+		if currentOperation.PrevUserBlockEntry == nil {
+			return fmt.Errorf("_disconnectUserBlock: PrevUserBlockEntry missing "+
+				"for unblock with key %v", &userBlockKey)
+		}
+	} else if userBlockEntry == nil || userBlockEntry.BlockType != txMeta.BlockType {
+		return fmt.Errorf("_disconnectUserBlock: UserBlockEntry for key %v was %v "+
+			"but expected a %v", &userBlockKey, userBlockEntry, txMeta.BlockType)
+	}
+
+	// Now that we are confident the UserBlockEntry lines up with the transaction
+	// we're rolling back, restore the previous entry.
+	if currentOperation.PrevUserBlockEntry != nil {
+		bav._setUserBlockEntryMappings(currentOperation.PrevUserBlockEntry)
+	} else {
+		bav._deleteUserBlockEntryMappings(userBlockEntry)
+	}
+
+	// Now revert the basic transfer with the remaining operations. Cut off
+	// the UserBlock operation at the end since we just reverted it.
+	return bav._disconnectBasicTransfer(
+		currentTxn, txnHash, utxoOpsForTxn[:operationIndex], blockHeight)
+}
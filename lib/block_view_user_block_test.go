@@ -0,0 +1,256 @@
+package lib
+
+import (
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func _doUserBlockTxn(t *testing.T, chain *Blockchain, db *badger.DB,
+	params *DeSoParams, feeRateNanosPerKB uint64, blockerPkBase58Check string,
+	blockedPkBase58Check string, blockerPrivBase58Check string, blockType UserBlockType,
+	isUnblock bool) (
+	_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
+
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	blockerPkBytes, _, err := Base58CheckDecode(blockerPkBase58Check)
+	require.NoError(err)
+
+	blockedPkBytes, _, err := Base58CheckDecode(blockedPkBase58Check)
+	require.NoError(err)
+
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateUserBlockTxn(
+		blockerPkBytes, blockedPkBytes, blockType, isUnblock, feeRateNanosPerKB, nil, []*DeSoOutput{})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	require.Equal(totalInputMake, changeAmountMake+feesMake)
+
+	// Sign the transaction now that its inputs are set up.
+	_signTxn(t, txn, blockerPrivBase58Check)
+
+	txHash := txn.Hash()
+	// Always use height+1 for validation since it's assumed the transaction will
+	// get mined into the next block.
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(totalInput, totalInputMake)
+
+	// We should have one SPEND UtxoOperation for each input, one ADD operation
+	// for each output, and one OperationTypeUserBlock operation at the end.
+	require.Equal(len(txn.TxInputs)+len(txn.TxOutputs)+1, len(utxoOps))
+	for ii := 0; ii < len(txn.TxInputs); ii++ {
+		require.Equal(OperationTypeSpendUtxo, utxoOps[ii].Type)
+	}
+	require.Equal(OperationTypeUserBlock, utxoOps[len(utxoOps)-1].Type)
+
+	require.NoError(utxoView.FlushToDb())
+
+	return utxoOps, txn, blockHeight, nil
+}
+
+func _doUserBlockTxnWithTestMeta(
+	testMeta *TestMeta,
+	feeRateNanosPerKB uint64,
+	blockerPkBase58Check string,
+	blockedPkBase58Check string,
+	blockerPrivBase58Check string,
+	blockType UserBlockType,
+	isUnblock bool) {
+
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, blockerPkBase58Check))
+
+	currentOps, currentTxn, _, err := _doUserBlockTxn(
+		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
+		blockerPkBase58Check, blockedPkBase58Check, blockerPrivBase58Check, blockType, isUnblock)
+	require.NoError(testMeta.t, err)
+
+	testMeta.txnOps = append(testMeta.txnOps, currentOps)
+	testMeta.txns = append(testMeta.txns, currentTxn)
+}
+
+func TestUserBlocks(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	params.ForkHeights.UserBlocksBlockHeight = 0
+
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 1000000000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 1000000000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m2Pub, senderPrivString, 1000000000)
+
+	m0PKID := DBGetPKIDEntryForPublicKey(db, m0PkBytes).PKID
+	m1PKID := DBGetPKIDEntryForPublicKey(db, m1PkBytes).PKID
+	m2PKID := DBGetPKIDEntryForPublicKey(db, m2PkBytes).PKID
+
+	getUserBlockEntries := func(publicKey []byte, getEntriesBlockingPublicKey bool) map[PKID]UserBlockType {
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		userBlockEntries, err := utxoView.GetUserBlockEntriesForPublicKey(publicKey, getEntriesBlockingPublicKey)
+		require.NoError(err)
+		blockTypes := make(map[PKID]UserBlockType)
+		for _, userBlockEntry := range userBlockEntries {
+			if getEntriesBlockingPublicKey {
+				blockTypes[*userBlockEntry.BlockerPKID] = userBlockEntry.BlockType
+			} else {
+				blockTypes[*userBlockEntry.BlockedPKID] = userBlockEntry.BlockType
+			}
+		}
+		return blockTypes
+	}
+
+	// m0 creates a profile and a post, and m1 buys some of m0's coin so there is
+	// something to transfer back.
+	_updateProfileWithTestMeta(
+		testMeta,
+		10,            /*feeRateNanosPerKB*/
+		m0Pub,         /*updaterPkBase58Check*/
+		m0Priv,        /*updaterPrivBase58Check*/
+		[]byte{},      /*profilePubKey*/
+		"m0",          /*newUsername*/
+		"i am the m0", /*newDescription*/
+		shortPic,      /*newProfilePic*/
+		10*100,        /*newCreatorBasisPoints*/
+		1.25*100*100,  /*newStakeMultipleBasisPoints*/
+		false /*isHidden*/)
+	_submitPostWithTestMeta(
+		testMeta,
+		10,                            /*feeRateNanosPerKB*/
+		m0Pub,                         /*updaterPkBase58Check*/
+		m0Priv,                        /*updaterPrivBase58Check*/
+		[]byte{},                      /*postHashToModify*/
+		[]byte{},                      /*parentStakeID*/
+		&DeSoBodySchema{Body: "post"}, /*body*/
+		[]byte{},                      /*repostedPostHash*/
+		1502947011*1e9,                /*tstampNanos*/
+		false /*isHidden*/)
+	postHash := testMeta.txns[len(testMeta.txns)-1].Hash()
+	_creatorCoinTxnWithTestMeta(
+		testMeta, 10, m1Pub, m1Priv, m0Pub, CreatorCoinOperationTypeBuy,
+		100000000 /*DeSoToSellNanos*/, 0, 0, 0, 0)
+
+	// m0 blocks m1 and mutes m2.
+	_doUserBlockTxnWithTestMeta(testMeta, 10, m0Pub, m1Pub, m0Priv, UserBlockTypeBlock, false /*isUnblock*/)
+	_doUserBlockTxnWithTestMeta(testMeta, 10, m0Pub, m2Pub, m0Priv, UserBlockTypeMute, false /*isUnblock*/)
+
+	require.Equal(map[PKID]UserBlockType{
+		*m1PKID: UserBlockTypeBlock,
+		*m2PKID: UserBlockTypeMute,
+	}, getUserBlockEntries(m0PkBytes, false))
+	require.Equal(map[PKID]UserBlockType{*m0PKID: UserBlockTypeBlock}, getUserBlockEntries(m1PkBytes, true))
+	require.Equal(map[PKID]UserBlockType{*m0PKID: UserBlockTypeMute}, getUserBlockEntries(m2PkBytes, true))
+	require.Len(getUserBlockEntries(m1PkBytes, false), 0)
+
+	// Invalid blocks and unblocks fail.
+	{
+		_, _, _, err = _doUserBlockTxn(t, chain, db, params, 10, m0Pub, m1Pub, m0Priv, UserBlockTypeBlock, false)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUserBlockEntryAlreadyExists)
+
+		_, _, _, err = _doUserBlockTxn(t, chain, db, params, 10, m0Pub, m1Pub, m0Priv, UserBlockTypeMute, true)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUserBlockTypeMismatch)
+
+		_, _, _, err = _doUserBlockTxn(t, chain, db, params, 10, m1Pub, m0Pub, m1Priv, UserBlockTypeBlock, true)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorCannotUnblockNonexistentUserBlockEntry)
+
+		_, _, _, err = _doUserBlockTxn(t, chain, db, params, 10, m0Pub, m0Pub, m0Priv, UserBlockTypeBlock, false)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUserBlockCannotBlockSelf)
+
+		_, _, _, err = _doUserBlockTxn(t, chain, db, params, 10, m0Pub, m1Pub, m0Priv, UserBlockType(2), false)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUserBlockInvalidBlockType)
+	}
+
+	// m1 can't message m0, diamond m0's post or send m0 creator coins.
+	{
+		_, _, _, err = _privateMessage(t, chain, db, params, 10, m1Pub, m0Pub, m1Priv, "hi", 1502947012*1e9)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorPrivateMessageSenderBlockedByRecipient)
+
+		_, _, _, err = _giveDeSoDiamonds(t, chain, db, params, 10, m1Pub, m1Priv, postHash, 1, false)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorDiamondSenderBlockedByPoster)
+
+		_, _, _, err = _doCreatorCoinTransferTxn(t, chain, db, params, 10, m1Pub, m1Priv, m0Pub, m0Pub, 1000000)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorCreatorCoinTransferSenderBlockedByReceiver)
+	}
+
+	// Mutes aren't enforced so m2 can still message m0.
+	{
+		testMeta.expectedSenderBalances = append(
+			testMeta.expectedSenderBalances, _getBalance(t, chain, nil, m2Pub))
+		currentOps, currentTxn, _, err := _privateMessage(
+			t, chain, db, params, 10, m2Pub, m0Pub, m2Priv, "hi", 1502947013*1e9)
+		require.NoError(err)
+		testMeta.txnOps = append(testMeta.txnOps, currentOps)
+		testMeta.txns = append(testMeta.txns, currentTxn)
+	}
+
+	// Turning m1's block into a mute replaces it, after which m1 can message m0.
+	_doUserBlockTxnWithTestMeta(testMeta, 10, m0Pub, m1Pub, m0Priv, UserBlockTypeMute, false /*isUnblock*/)
+	require.Equal(map[PKID]UserBlockType{*m0PKID: UserBlockTypeMute}, getUserBlockEntries(m1PkBytes, true))
+	{
+		testMeta.expectedSenderBalances = append(
+			testMeta.expectedSenderBalances, _getBalance(t, chain, nil, m1Pub))
+		currentOps, currentTxn, _, err := _privateMessage(
+			t, chain, db, params, 10, m1Pub, m0Pub, m1Priv, "hi again", 1502947014*1e9)
+		require.NoError(err)
+		testMeta.txnOps = append(testMeta.txnOps, currentOps)
+		testMeta.txns = append(testMeta.txns, currentTxn)
+	}
+
+	// Unmuting m2 leaves just m1's mute.
+	_doUserBlockTxnWithTestMeta(testMeta, 10, m0Pub, m2Pub, m0Priv, UserBlockTypeMute, true /*isUnblock*/)
+	require.Equal(map[PKID]UserBlockType{*m1PKID: UserBlockTypeMute}, getUserBlockEntries(m0PkBytes, false))
+	require.Len(getUserBlockEntries(m2PkBytes, true), 0)
+
+	// Rolling back restores the block before the mute replaced it, and then
+	// removes everything.
+	_rollBackTestMetaTxnsAndFlush(testMeta)
+	require.Len(getUserBlockEntries(m0PkBytes, false), 0)
+	require.Len(getUserBlockEntries(m1PkBytes, true), 0)
+
+	// Connecting and disconnecting in a single view should do the same.
+	_applyTestMetaTxnsToViewAndFlush(testMeta)
+	require.Equal(map[PKID]UserBlockType{*m1PKID: UserBlockTypeMute}, getUserBlockEntries(m0PkBytes, false))
+	_disconnectTestMetaTxnsFromViewAndFlush(testMeta)
+	require.Len(getUserBlockEntries(m0PkBytes, false), 0)
+}
@@ -0,0 +1,491 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// block_view_username_listing.go lets a profile owner hand their username over to
+// another user. A UsernameListing txn either offers the username to anyone willing
+// to pay PriceNanos or, when it names a recipient, only to that recipient, which
+// with a zero price is a plain transfer. An AcceptUsernameListing txn from the buyer
+// pays the seller and moves the username onto the buyer's profile in one step.
+//
+// Every profile needs a username, so a listing also names the ReplacementUsername
+// the seller's profile takes when the listing is accepted. The username the buyer
+// held before is freed, unless it is the replacement, in which case the two
+// profiles simply swap usernames.
+
+// GetUsernameListingEntry returns the listing for a username, or nil if the username
+// isn't listed. The lookup is case-insensitive.
+func (bav *UtxoView) GetUsernameListingEntry(nonLowercaseUsername []byte) *UsernameListingEntry {
+	// If an entry exists in the in-memory map, return the value of that mapping.
+	usernameMapKey := MakeUsernameMapKey(nonLowercaseUsername)
+	if mapValue, existsMapValue := bav.UsernameToUsernameListingEntry[usernameMapKey]; existsMapValue {
+		if mapValue.isDeleted {
+			return nil
+		}
+		return mapValue
+	}
+
+	// If we get here it means no value exists in our in-memory map. In this case,
+	// defer to the db. If a mapping exists in the db, return it. If not, return
+	// nil. Either way, save the value to the in-memory view mapping got later.
+	var listingEntry *UsernameListingEntry
+	if bav.Postgres != nil {
+		if listing := bav.Postgres.GetUsernameListing(nonLowercaseUsername); listing != nil {
+			listingEntry = listing.NewUsernameListingEntry()
+		}
+	} else {
+		listingEntry = DbGetUsernameListingEntry(bav.Handle, nonLowercaseUsername)
+	}
+	if listingEntry != nil {
+		bav._setUsernameListingEntryMappings(listingEntry)
+	}
+	return listingEntry
+}
+
+// GetAllUsernameListingEntries returns every username that's currently listed,
+// sorted by lowercase username.
+func (bav *UtxoView) GetAllUsernameListingEntries() ([]*UsernameListingEntry, error) {
+	// Load all of the entries from the db into the view so that the view's
+	// entries take precedence over them.
+	var dbListingEntries []*UsernameListingEntry
+	if bav.Postgres != nil {
+		for _, listing := range bav.Postgres.GetAllUsernameListings() {
+			dbListingEntries = append(dbListingEntries, listing.NewUsernameListingEntry())
+		}
+	} else {
+		var err error
+		dbListingEntries, err = DbGetAllUsernameListingEntries(bav.Handle)
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetAllUsernameListingEntries: ")
+		}
+	}
+	for _, listingEntry := range dbListingEntries {
+		if _, exists := bav.UsernameToUsernameListingEntry[MakeUsernameMapKey(listingEntry.Username)]; !exists {
+			bav._setUsernameListingEntryMappings(listingEntry)
+		}
+	}
+
+	listingEntries := []*UsernameListingEntry{}
+	for _, listingEntry := range bav.UsernameToUsernameListingEntry {
+		if listingEntry.isDeleted {
+			continue
+		}
+		listingEntries = append(listingEntries, listingEntry)
+	}
+	sort.Slice(listingEntries, func(ii, jj int) bool {
+		return bytes.Compare(bytes.ToLower(listingEntries[ii].Username),
+			bytes.ToLower(listingEntries[jj].Username)) < 0
+	})
+	return listingEntries, nil
+}
+
+func (bav *UtxoView) _setUsernameListingEntryMappings(listingEntry *UsernameListingEntry) {
+	// This function shouldn't be called with nil.
+	if listingEntry == nil {
+		glog.Errorf("_setUsernameListingEntryMappings: Called with nil UsernameListingEntry; " +
+			"this should never happen.")
+		return
+	}
+
+	bav.UsernameToUsernameListingEntry[MakeUsernameMapKey(listingEntry.Username)] = listingEntry
+}
+
+func (bav *UtxoView) _deleteUsernameListingEntryMappings(listingEntry *UsernameListingEntry) {
+
+	// Create a tombstone entry.
+	tombstoneListingEntry := *listingEntry
+	tombstoneListingEntry.isDeleted = true
+
+	// Set the mappings to point to the tombstone entry.
+	bav._setUsernameListingEntryMappings(&tombstoneListingEntry)
+}
+
+// _revertUsernameListingEntry restores the listing for a username to what it was
+// before a transaction. A nil prevListingEntry means the username wasn't listed.
+func (bav *UtxoView) _revertUsernameListingEntry(username []byte, prevListingEntry *UsernameListingEntry) {
+	if listingEntry := bav.GetUsernameListingEntry(username); listingEntry != nil {
+		bav._deleteUsernameListingEntryMappings(listingEntry)
+	}
+	if prevListingEntry != nil {
+		prevEntry := *prevListingEntry
+		bav._setUsernameListingEntryMappings(&prevEntry)
+	}
+}
+
+func _isReservedUsername(username []byte) bool {
+	_, isReserved := IsReserved[strings.ToLower(string(username))]
+	return isReserved
+}
+
+// _isUsernameHeldByOtherProfile returns true if a profile other than the one with
+// the given PKID currently holds the username. A nil pkid matches no profile.
+func (bav *UtxoView) _isUsernameHeldByOtherProfile(username []byte, pkid *PKID) bool {
+	// Note that this check is case-insensitive
+	existingProfileEntry := bav.GetProfileEntryForUsername(username)
+	if existingProfileEntry == nil || existingProfileEntry.isDeleted {
+		return false
+	}
+	if pkid == nil {
+		return true
+	}
+	existingPKIDEntry := bav.GetPKIDForPublicKey(existingProfileEntry.PublicKey)
+	return existingPKIDEntry == nil || existingPKIDEntry.isDeleted || *existingPKIDEntry.PKID != *pkid
+}
+
+func (bav *UtxoView) _connectUsernameListing(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
+	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
+
+	if blockHeight < bav.Params.ForkHeights.UsernameMarketplaceBlockHeight {
+		return 0, 0, nil, RuleErrorUsernameListingBeforeBlockHeight
+	}
+
+	// Check that the transaction has the right TxnType.
+	if txn.TxnMeta.GetTxnType() != TxnTypeUsernameListing {
+		return 0, 0, nil, fmt.Errorf("_connectUsernameListing: called with bad TxnType %s",
+			txn.TxnMeta.GetTxnType().String())
+	}
+	txMeta := txn.TxnMeta.(*UsernameListingMetadata)
+
+	ownerPKID := bav.GetPKIDForPublicKey(txn.PublicKey).PKID
+	prevListingEntry := bav.GetUsernameListingEntry(txMeta.Username)
+
+	var newListingEntry *UsernameListingEntry
+	if txMeta.IsRemoved {
+		// The owner can remove their listing even if it has gone stale because they
+		// changed their username since listing it.
+		if prevListingEntry == nil {
+			return 0, 0, nil, errors.Wrapf(RuleErrorUsernameListingDoesNotExist,
+				"_connectUsernameListing: Username: %v", string(txMeta.Username))
+		}
+		if *prevListingEntry.OwnerPKID != *ownerPKID {
+			return 0, 0, nil, errors.Wrapf(RuleErrorUsernameListingNotOwner,
+				"_connectUsernameListing: Username: %v", string(txMeta.Username))
+		}
+	} else {
+		// Only the profile that currently holds a username can list it.
+		ownerProfileEntry := bav.GetProfileEntryForPKID(ownerPKID)
+		if ownerProfileEntry == nil || ownerProfileEntry.isDeleted {
+			return 0, 0, nil, RuleErrorUsernameListingRequiresProfile
+		}
+		if !strings.EqualFold(string(ownerProfileEntry.Username), string(txMeta.Username)) {
+			return 0, 0, nil, errors.Wrapf(RuleErrorUsernameListingNotOwner,
+				"_connectUsernameListing: Username: %v, Profile username: %v",
+				string(txMeta.Username), string(ownerProfileEntry.Username))
+		}
+		if _isReservedUsername(txMeta.Username) {
+			return 0, 0, nil, errors.Wrapf(RuleErrorUsernameListingReservedUsername,
+				"_connectUsernameListing: Username: %v", string(txMeta.Username))
+		}
+
+		// Validate the username the owner's profile falls back to.
+		if len(txMeta.ReplacementUsername) == 0 ||
+			uint64(len(txMeta.ReplacementUsername)) > bav.Params.MaxUsernameLengthBytes ||
+			!UsernameRegex.Match(txMeta.ReplacementUsername) ||
+			strings.EqualFold(string(txMeta.ReplacementUsername), string(txMeta.Username)) ||
+			_isReservedUsername(txMeta.ReplacementUsername) {
+
+			return 0, 0, nil, errors.Wrapf(RuleErrorUsernameListingInvalidReplacementUsername,
+				"_connectUsernameListing: ReplacementUsername: %v", string(txMeta.ReplacementUsername))
+		}
+
+		var recipientPKID *PKID
+		if len(txMeta.RecipientPublicKey) != 0 {
+			if len(txMeta.RecipientPublicKey) != btcec.PubKeyBytesLenCompressed {
+				return 0, 0, nil, RuleErrorUsernameListingInvalidRecipientPublicKey
+			}
+			if _, err := btcec.ParsePubKey(txMeta.RecipientPublicKey, btcec.S256()); err != nil {
+				return 0, 0, nil, errors.Wrapf(RuleErrorUsernameListingInvalidRecipientPublicKey, err.Error())
+			}
+			if reflect.DeepEqual(txMeta.RecipientPublicKey, txn.PublicKey) {
+				return 0, 0, nil, RuleErrorUsernameListingInvalidRecipientPublicKey
+			}
+			recipientPKID = bav.GetPKIDForPublicKey(txMeta.RecipientPublicKey).PKID
+		} else if txMeta.PriceNanos == 0 {
+			// Giving a username away to whoever asks first would just invite squatting.
+			return 0, 0, nil, RuleErrorUsernameListingZeroPriceWithoutRecipient
+		}
+
+		// The replacement may only be taken by the recipient, in which case the
+		// two profiles swap usernames.
+		if bav._isUsernameHeldByOtherProfile(txMeta.ReplacementUsername, recipientPKID) {
+			return 0, 0, nil, errors.Wrapf(RuleErrorUsernameListingReplacementUsernameTaken,
+				"_connectUsernameListing: ReplacementUsername: %v", string(txMeta.ReplacementUsername))
+		}
+
+		newListingEntry = &UsernameListingEntry{
+			Username:            ownerProfileEntry.Username,
+			ReplacementUsername: txMeta.ReplacementUsername,
+			OwnerPKID:           ownerPKID,
+			RecipientPKID:       recipientPKID,
+			PriceNanos:          txMeta.PriceNanos,
+		}
+	}
+
+	// Connect basic txn to get the total input and the total output without
+	// considering the transaction metadata.
+	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
+		txn, txHash, blockHeight, verifySignatures)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectUsernameListing: ")
+	}
+
+	// Force the input to be non-zero so that we can prevent replay attacks.
+	if totalInput == 0 {
+		return 0, 0, nil, RuleErrorUsernameListingRequiresNonZeroInput
+	}
+
+	var prevListingEntryCopy *UsernameListingEntry
+	if prevListingEntry != nil {
+		prevEntry := *prevListingEntry
+		prevListingEntryCopy = &prevEntry
+		bav._deleteUsernameListingEntryMappings(prevListingEntry)
+	}
+	if newListingEntry != nil {
+		bav._setUsernameListingEntryMappings(newListingEntry)
+	}
+
+	// Add an operation to the list at the end indicating we've updated a listing.
+	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
+		Type:                     OperationTypeUsernameListing,
+		PrevUsernameListingEntry: prevListingEntryCopy,
+	})
+
+	return totalInput, totalOutput, utxoOpsForTxn, nil
+}
+
+func (bav *UtxoView) _disconnectUsernameListing(
+	operationType OperationType, currentTxn *MsgDeSoTxn, txnHash *BlockHash,
+	utxoOpsForTxn []*UtxoOperation, blockHeight uint32) error {
+
+	// Verify that the last operation is a UsernameListing operation
+	if len(utxoOpsForTxn) == 0 {
+		return fmt.Errorf("_disconnectUsernameListing: utxoOperations are missing")
+	}
+	operationIndex := len(utxoOpsForTxn) - 1
+	if utxoOpsForTxn[operationIndex].Type != OperationTypeUsernameListing {
+		return fmt.Errorf("_disconnectUsernameListing: Trying to revert "+
+			"OperationTypeUsernameListing but found type %v",
+			utxoOpsForTxn[operationIndex].Type)
+	}
+	txMeta := currentTxn.TxnMeta.(*UsernameListingMetadata)
+	operationData := utxoOpsForTxn[operationIndex]
+
+	bav._revertUsernameListingEntry(txMeta.Username, operationData.PrevUsernameListingEntry)
+
+	// Now revert the basic transfer with the remaining operations. Cut off
+	// the UsernameListing operation at the end since we just reverted it.
+	return bav._disconnectBasicTransfer(
+		currentTxn, txnHash, utxoOpsForTxn[:operationIndex], blockHeight)
+}
+
+func (bav *UtxoView) _connectAcceptUsernameListing(
+	txn *MsgDeSoTxn, txHash *BlockHash, blockHeight uint32, verifySignatures bool) (
+	_totalInput uint64, _totalOutput uint64, _utxoOps []*UtxoOperation, _err error) {
+
+	if blockHeight < bav.Params.ForkHeights.UsernameMarketplaceBlockHeight {
+		return 0, 0, nil, RuleErrorUsernameListingBeforeBlockHeight
+	}
+
+	// Check that the transaction has the right TxnType.
+	if txn.TxnMeta.GetTxnType() != TxnTypeAcceptUsernameListing {
+		return 0, 0, nil, fmt.Errorf("_connectAcceptUsernameListing: called with bad TxnType %s",
+			txn.TxnMeta.GetTxnType().String())
+	}
+	txMeta := txn.TxnMeta.(*AcceptUsernameListingMetadata)
+
+	listingEntry := bav.GetUsernameListingEntry(txMeta.Username)
+	if listingEntry == nil {
+		return 0, 0, nil, errors.Wrapf(RuleErrorUsernameListingDoesNotExist,
+			"_connectAcceptUsernameListing: Username: %v", string(txMeta.Username))
+	}
+
+	// The seller must still hold the username. It may have been changed with an
+	// UpdateProfile or a SwapIdentity since it was listed.
+	prevSellerProfileEntry := bav.GetProfileEntryForPKID(listingEntry.OwnerPKID)
+	if prevSellerProfileEntry == nil || prevSellerProfileEntry.isDeleted ||
+		!strings.EqualFold(string(prevSellerProfileEntry.Username), string(listingEntry.Username)) {
+
+		return 0, 0, nil, errors.Wrapf(RuleErrorUsernameListingStale,
+			"_connectAcceptUsernameListing: Username: %v", string(txMeta.Username))
+	}
+	if _isReservedUsername(listingEntry.Username) {
+		return 0, 0, nil, errors.Wrapf(RuleErrorUsernameListingReservedUsername,
+			"_connectAcceptUsernameListing: Username: %v", string(txMeta.Username))
+	}
+
+	buyerPKID := bav.GetPKIDForPublicKey(txn.PublicKey).PKID
+	if *buyerPKID == *listingEntry.OwnerPKID {
+		return 0, 0, nil, RuleErrorUsernameListingCannotAcceptOwnListing
+	}
+	if listingEntry.RecipientPKID != nil && *listingEntry.RecipientPKID != *buyerPKID {
+		return 0, 0, nil, RuleErrorUsernameListingNotRecipient
+	}
+	// The buyer states the price they're paying so that the seller can't raise it
+	// while the txn is in flight.
+	if txMeta.PriceNanos != listingEntry.PriceNanos {
+		return 0, 0, nil, errors.Wrapf(RuleErrorUsernameListingPriceMismatch,
+			"_connectAcceptUsernameListing: Price: %d, Listed price: %d",
+			txMeta.PriceNanos, listingEntry.PriceNanos)
+	}
+	prevBuyerProfileEntry := bav.GetProfileEntryForPKID(buyerPKID)
+	if prevBuyerProfileEntry == nil || prevBuyerProfileEntry.isDeleted {
+		return 0, 0, nil, RuleErrorUsernameListingRequiresProfile
+	}
+	if bav._isUsernameHeldByOtherProfile(listingEntry.ReplacementUsername, buyerPKID) {
+		return 0, 0, nil, errors.Wrapf(RuleErrorUsernameListingReplacementUsernameTaken,
+			"_connectAcceptUsernameListing: ReplacementUsername: %v",
+			string(listingEntry.ReplacementUsername))
+	}
+	sellerPublicKey := bav.GetPublicKeyForPKID(listingEntry.OwnerPKID)
+
+	// Connect basic txn to get the total input and the total output without
+	// considering the transaction metadata.
+	totalInput, totalOutput, utxoOpsForTxn, err := bav._connectBasicTransfer(
+		txn, txHash, blockHeight, verifySignatures)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "_connectAcceptUsernameListing: ")
+	}
+
+	// Force the input to be non-zero so that we can prevent replay attacks.
+	if totalInput == 0 {
+		return 0, 0, nil, RuleErrorUsernameListingRequiresNonZeroInput
+	}
+
+	// The price counts as output being spent by this transaction. It is paid to
+	// the seller as an implicit output at the end of the transaction.
+	if totalOutput > math.MaxUint64-listingEntry.PriceNanos {
+		return 0, 0, nil, errors.Wrapf(RuleErrorAcceptUsernameListingTxnOutputExceedsInput,
+			"_connectAcceptUsernameListing: Price %d overflows the output", listingEntry.PriceNanos)
+	}
+	totalOutput += listingEntry.PriceNanos
+	if totalInput < totalOutput {
+		return 0, 0, nil, errors.Wrapf(RuleErrorAcceptUsernameListingTxnOutputExceedsInput,
+			"_connectAcceptUsernameListing: Input: %d, Output: %d", totalInput, totalOutput)
+	}
+	if listingEntry.PriceNanos > 0 {
+		saleUtxoKey := UtxoKey{
+			TxID:  *txHash,
+			Index: uint32(len(txn.TxOutputs)),
+		}
+		saleUtxoEntry := UtxoEntry{
+			AmountNanos: listingEntry.PriceNanos,
+			PublicKey:   sellerPublicKey,
+			BlockHeight: blockHeight,
+			UtxoType:    UtxoTypeUsernameSale,
+			UtxoKey:     &saleUtxoKey,
+			// We leave the position unset and isSpent to false by default.
+			// The position will be set in the call to _addUtxo.
+		}
+		utxoOp, err := bav._addUtxo(&saleUtxoEntry)
+		if err != nil {
+			return 0, 0, nil, errors.Wrapf(err, "_connectAcceptUsernameListing: Problem "+
+				"adding sale utxo: ")
+		}
+		utxoOpsForTxn = append(utxoOpsForTxn, utxoOp)
+	}
+
+	// Move the username over. Both profiles' mappings are deleted before either is
+	// set so that a swap of usernames doesn't clobber the new mappings.
+	prevSellerEntry := *prevSellerProfileEntry
+	prevBuyerEntry := *prevBuyerProfileEntry
+	bav._deleteProfileEntryMappings(prevSellerProfileEntry)
+	bav._deleteProfileEntryMappings(prevBuyerProfileEntry)
+
+	newSellerProfileEntry := prevSellerEntry
+	newSellerProfileEntry.Username = listingEntry.ReplacementUsername
+	bav._setProfileEntryMappings(&newSellerProfileEntry)
+
+	newBuyerProfileEntry := prevBuyerEntry
+	newBuyerProfileEntry.Username = listingEntry.Username
+	bav._setProfileEntryMappings(&newBuyerProfileEntry)
+
+	prevListingEntry := *listingEntry
+	bav._deleteUsernameListingEntryMappings(listingEntry)
+
+	// Add an operation to the list at the end indicating we've accepted a listing.
+	utxoOpsForTxn = append(utxoOpsForTxn, &UtxoOperation{
+		Type:                          OperationTypeAcceptUsernameListing,
+		PrevProfileEntry:              &prevSellerEntry,
+		PrevUsernameBuyerProfileEntry: &prevBuyerEntry,
+		PrevUsernameListingEntry:      &prevListingEntry,
+	})
+
+	return totalInput, totalOutput, utxoOpsForTxn, nil
+}
+
+func (bav *UtxoView) _disconnectAcceptUsernameListing(
+	operationType OperationType, currentTxn *MsgDeSoTxn, txnHash *BlockHash,
+	utxoOpsForTxn []*UtxoOperation, blockHeight uint32) error {
+
+	// Verify that the last operation is an AcceptUsernameListing operation
+	if len(utxoOpsForTxn) == 0 {
+		return fmt.Errorf("_disconnectAcceptUsernameListing: utxoOperations are missing")
+	}
+	operationIndex := len(utxoOpsForTxn) - 1
+	if utxoOpsForTxn[operationIndex].Type != OperationTypeAcceptUsernameListing {
+		return fmt.Errorf("_disconnectAcceptUsernameListing: Trying to revert "+
+			"OperationTypeAcceptUsernameListing but found type %v",
+			utxoOpsForTxn[operationIndex].Type)
+	}
+	operationData := utxoOpsForTxn[operationIndex]
+	operationIndex--
+	prevSellerProfileEntry := operationData.PrevProfileEntry
+	prevBuyerProfileEntry := operationData.PrevUsernameBuyerProfileEntry
+	prevListingEntry := operationData.PrevUsernameListingEntry
+	if prevSellerProfileEntry == nil || prevBuyerProfileEntry == nil || prevListingEntry == nil {
+		return fmt.Errorf("_disconnectAcceptUsernameListing: Previous entries are missing; " +
+			"this should never happen")
+	}
+
+	// Delete the current mappings of both profiles before restoring the previous
+	// ones. This frees the replacement username unless the buyer held it before.
+	buyerPKID := bav.GetPKIDForPublicKey(currentTxn.PublicKey).PKID
+	for _, pkid := range []*PKID{prevListingEntry.OwnerPKID, buyerPKID} {
+		profileEntry := bav.GetProfileEntryForPKID(pkid)
+		if profileEntry == nil || profileEntry.isDeleted {
+			return fmt.Errorf("_disconnectAcceptUsernameListing: Profile for PKID %v "+
+				"doesn't exist; this should never happen", PkToStringBoth(pkid[:]))
+		}
+		bav._deleteProfileEntryMappings(profileEntry)
+	}
+	prevSellerEntry := *prevSellerProfileEntry
+	bav._setProfileEntryMappings(&prevSellerEntry)
+	prevBuyerEntry := *prevBuyerProfileEntry
+	bav._setProfileEntryMappings(&prevBuyerEntry)
+
+	// Revert the payment to the seller, which is an "implicit" output at the end
+	// of the list of UtxoOperations.
+	if prevListingEntry.PriceNanos > 0 {
+		if operationIndex < 0 || utxoOpsForTxn[operationIndex].Type != OperationTypeAddUtxo {
+			return fmt.Errorf("_disconnectAcceptUsernameListing: Expected an ADD operation " +
+				"for the sale payment; this should never happen")
+		}
+		saleUtxoKey := &UtxoKey{
+			TxID:  *txnHash,
+			Index: uint32(len(currentTxn.TxOutputs)),
+		}
+		if err := bav._unAddUtxo(saleUtxoKey); err != nil {
+			return errors.Wrapf(err, "_disconnectAcceptUsernameListing: Problem unAdding "+
+				"sale utxo %v: ", saleUtxoKey)
+		}
+		operationIndex--
+	}
+
+	bav._revertUsernameListingEntry(prevListingEntry.Username, prevListingEntry)
+
+	// Now revert the basic transfer with the remaining operations.
+	return bav._disconnectBasicTransfer(
+		currentTxn, txnHash, utxoOpsForTxn[:operationIndex+1], blockHeight)
+}
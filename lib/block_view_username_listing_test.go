@@ -0,0 +1,352 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func _usernameListing(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+	feeRateNanosPerKB uint64, ownerPkBase58Check string, ownerPrivBase58Check string,
+	username string, replacementUsername string, recipientPublicKey []byte, priceNanos uint64,
+	isRemoved bool,
+) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
+
+	require := require.New(t)
+
+	ownerPkBytes, _, err := Base58CheckDecode(ownerPkBase58Check)
+	require.NoError(err)
+
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateUsernameListingTxn(
+		ownerPkBytes,
+		[]byte(username),
+		[]byte(replacementUsername),
+		recipientPublicKey,
+		priceNanos,
+		isRemoved,
+		feeRateNanosPerKB,
+		nil,
+		[]*DeSoOutput{})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	require.Equal(totalInputMake, changeAmountMake+feesMake)
+
+	// Sign the transaction now that its inputs are set up.
+	_signTxn(t, txn, ownerPrivBase58Check)
+
+	txHash := txn.Hash()
+	// Always use height+1 for validation since it's assumed the transaction will
+	// get mined into the next block.
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(totalInput, totalInputMake)
+	require.Equal(OperationTypeUsernameListing, utxoOps[len(utxoOps)-1].Type)
+
+	require.NoError(utxoView.FlushToDb())
+
+	return utxoOps, txn, blockHeight, nil
+}
+
+func _usernameListingWithTestMeta(
+	testMeta *TestMeta,
+	feeRateNanosPerKB uint64,
+	ownerPkBase58Check string,
+	ownerPrivBase58Check string,
+	username string,
+	replacementUsername string,
+	recipientPublicKey []byte,
+	priceNanos uint64,
+	isRemoved bool,
+) {
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, ownerPkBase58Check))
+	currentOps, currentTxn, _, err := _usernameListing(
+		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
+		ownerPkBase58Check,
+		ownerPrivBase58Check,
+		username,
+		replacementUsername,
+		recipientPublicKey,
+		priceNanos,
+		isRemoved,
+	)
+	require.NoError(testMeta.t, err)
+	testMeta.txnOps = append(testMeta.txnOps, currentOps)
+	testMeta.txns = append(testMeta.txns, currentTxn)
+}
+
+func _acceptUsernameListing(t *testing.T, chain *Blockchain, db *badger.DB, params *DeSoParams,
+	feeRateNanosPerKB uint64, buyerPkBase58Check string, buyerPrivBase58Check string,
+	username string, priceNanos uint64,
+) (_utxoOps []*UtxoOperation, _txn *MsgDeSoTxn, _height uint32, _err error) {
+
+	require := require.New(t)
+
+	buyerPkBytes, _, err := Base58CheckDecode(buyerPkBase58Check)
+	require.NoError(err)
+
+	utxoView, err := NewUtxoView(db, params, nil)
+	require.NoError(err)
+
+	txn, totalInputMake, changeAmountMake, feesMake, err := chain.CreateAcceptUsernameListingTxn(
+		buyerPkBytes,
+		[]byte(username),
+		priceNanos,
+		feeRateNanosPerKB,
+		nil,
+		[]*DeSoOutput{})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	require.Equal(totalInputMake, changeAmountMake+feesMake+priceNanos)
+
+	// Sign the transaction now that its inputs are set up.
+	_signTxn(t, txn, buyerPrivBase58Check)
+
+	txHash := txn.Hash()
+	// Always use height+1 for validation since it's assumed the transaction will
+	// get mined into the next block.
+	blockHeight := chain.blockTip().Height + 1
+	utxoOps, totalInput, totalOutput, fees, err :=
+		utxoView.ConnectTransaction(txn, txHash, getTxnSize(*txn), blockHeight, true /*verifySignature*/, false /*ignoreUtxos*/)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	require.Equal(totalInput, totalOutput+fees)
+	require.Equal(totalInput, totalInputMake)
+	require.Equal(OperationTypeAcceptUsernameListing, utxoOps[len(utxoOps)-1].Type)
+
+	require.NoError(utxoView.FlushToDb())
+
+	return utxoOps, txn, blockHeight, nil
+}
+
+func _acceptUsernameListingWithTestMeta(
+	testMeta *TestMeta,
+	feeRateNanosPerKB uint64,
+	buyerPkBase58Check string,
+	buyerPrivBase58Check string,
+	username string,
+	priceNanos uint64,
+) {
+	testMeta.expectedSenderBalances = append(
+		testMeta.expectedSenderBalances, _getBalance(testMeta.t, testMeta.chain, nil, buyerPkBase58Check))
+	currentOps, currentTxn, _, err := _acceptUsernameListing(
+		testMeta.t, testMeta.chain, testMeta.db, testMeta.params, feeRateNanosPerKB,
+		buyerPkBase58Check,
+		buyerPrivBase58Check,
+		username,
+		priceNanos,
+	)
+	require.NoError(testMeta.t, err)
+	testMeta.txnOps = append(testMeta.txnOps, currentOps)
+	testMeta.txns = append(testMeta.txns, currentTxn)
+}
+
+func TestUsernameListing(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	params.ForkHeights.UsernameMarketplaceBlockHeight = uint32(0)
+
+	// Mine a few blocks to give the senderPkString some money.
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+	_, err = miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	// We build the testMeta obj after mining blocks so that we save the correct block height.
+	testMeta := &TestMeta{
+		t:           t,
+		chain:       chain,
+		params:      params,
+		db:          db,
+		mempool:     mempool,
+		miner:       miner,
+		savedHeight: chain.blockTip().Height + 1,
+	}
+
+	_profileUsername := func(pkBytes []byte) string {
+		pkidEntry := DBGetPKIDEntryForPublicKey(db, pkBytes)
+		require.NotNil(pkidEntry)
+		profileEntry := DBGetProfileEntryForPKID(db, pkidEntry.PKID)
+		require.NotNil(profileEntry)
+		return string(profileEntry.Username)
+	}
+
+	// Fund all the keys and give each of them a profile.
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m0Pub, senderPrivString, 10000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m1Pub, senderPrivString, 10000)
+	_registerOrTransferWithTestMeta(testMeta, "", senderPkString, m2Pub, senderPrivString, 10000)
+	_updateProfileWithTestMeta(testMeta, 10, m0Pub, m0Priv, []byte{}, "m0", "i am the m0",
+		shortPic, 10*100, 1.25*100*100, false)
+	_updateProfileWithTestMeta(testMeta, 10, m1Pub, m1Priv, []byte{}, "m1", "i am the m1",
+		shortPic, 10*100, 1.25*100*100, false)
+	_updateProfileWithTestMeta(testMeta, 10, m2Pub, m2Priv, []byte{}, "m2", "i am the m2",
+		shortPic, 10*100, 1.25*100*100, false)
+
+	// Error case: only the profile holding a username can list it.
+	{
+		_, _, _, err = _usernameListing(t, chain, db, params, 10, m1Pub, m1Priv,
+			"m0", "m1_new", nil, 500, false)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUsernameListingNotOwner)
+	}
+
+	// Error case: reserved usernames can't change hands.
+	{
+		IsReserved["m0"] = false
+		_, _, _, err = _usernameListing(t, chain, db, params, 10, m0Pub, m0Priv,
+			"m0", "m0_new", nil, 500, false)
+		delete(IsReserved, "m0")
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUsernameListingReservedUsername)
+	}
+
+	// Error case: the replacement must be a valid username other than the listed one.
+	{
+		_, _, _, err = _usernameListing(t, chain, db, params, 10, m0Pub, m0Priv,
+			"m0", "M0", nil, 500, false)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUsernameListingInvalidReplacementUsername)
+
+		_, _, _, err = _usernameListing(t, chain, db, params, 10, m0Pub, m0Priv,
+			"m0", "", nil, 500, false)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUsernameListingInvalidReplacementUsername)
+	}
+
+	// Error case: the replacement can't be held by anyone but the recipient.
+	{
+		_, _, _, err = _usernameListing(t, chain, db, params, 10, m0Pub, m0Priv,
+			"m0", "m2", nil, 500, false)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUsernameListingReplacementUsernameTaken)
+	}
+
+	// Error case: a free listing needs a recipient.
+	{
+		_, _, _, err = _usernameListing(t, chain, db, params, 10, m0Pub, m0Priv,
+			"m0", "m0_old", nil, 0, false)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUsernameListingZeroPriceWithoutRecipient)
+	}
+
+	// m0 lists their username for 500 nanos.
+	{
+		_usernameListingWithTestMeta(testMeta, 10, m0Pub, m0Priv, "M0", "m0_old", nil, 500, false)
+
+		listingEntry := DbGetUsernameListingEntry(db, []byte("m0"))
+		require.NotNil(listingEntry)
+		// The listing keeps the casing of the profile.
+		require.Equal([]byte("m0"), listingEntry.Username)
+		require.Equal([]byte("m0_old"), listingEntry.ReplacementUsername)
+		require.Nil(listingEntry.RecipientPKID)
+		require.Equal(uint64(500), listingEntry.PriceNanos)
+	}
+
+	// Error case: the buyer must pay the listed price.
+	{
+		_, _, _, err = _acceptUsernameListing(t, chain, db, params, 10, m1Pub, m1Priv, "m0", 400)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUsernameListingPriceMismatch)
+	}
+
+	// Error case: the owner can't accept their own listing.
+	{
+		_, _, _, err = _acceptUsernameListing(t, chain, db, params, 10, m0Pub, m0Priv, "m0", 500)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUsernameListingCannotAcceptOwnListing)
+	}
+
+	// m1 buys the username. m0 is paid and falls back to the replacement.
+	{
+		m0BalanceBefore := _getBalance(t, chain, nil, m0Pub)
+		m1BalanceBefore := _getBalance(t, chain, nil, m1Pub)
+		_acceptUsernameListingWithTestMeta(testMeta, 10, m1Pub, m1Priv, "m0", 500)
+
+		require.Equal(m0BalanceBefore+500, _getBalance(t, chain, nil, m0Pub))
+		require.Less(_getBalance(t, chain, nil, m1Pub), m1BalanceBefore-500)
+
+		require.Equal("m0_old", _profileUsername(m0PkBytes))
+		require.Equal("m0", _profileUsername(m1PkBytes))
+		require.Nil(DBGetProfileEntryForUsername(db, []byte("m1")))
+		require.Nil(DbGetUsernameListingEntry(db, []byte("m0")))
+
+		// Disconnecting the sale should restore both profiles and the listing.
+		utxoView, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		lastTxn := testMeta.txns[len(testMeta.txns)-1]
+		require.NoError(utxoView.DisconnectTransaction(lastTxn, lastTxn.Hash(),
+			testMeta.txnOps[len(testMeta.txnOps)-1], chain.blockTip().Height+1))
+		require.Equal(m0PkBytes, utxoView.GetProfileEntryForUsername([]byte("m0")).PublicKey)
+		require.Equal(m1PkBytes, utxoView.GetProfileEntryForUsername([]byte("m1")).PublicKey)
+		require.True(utxoView.GetProfileEntryForUsername([]byte("m0_old")).isDeleted)
+		require.NotNil(utxoView.GetUsernameListingEntry([]byte("m0")))
+	}
+
+	// Error case: the listing is gone once it's been accepted.
+	{
+		_, _, _, err = _acceptUsernameListing(t, chain, db, params, 10, m2Pub, m2Priv, "m0", 500)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUsernameListingDoesNotExist)
+	}
+
+	// m1 gives the username to m2 for free, taking m2's username in return.
+	{
+		_usernameListingWithTestMeta(testMeta, 10, m1Pub, m1Priv, "m0", "m2", m2PkBytes, 0, false)
+
+		// Error case: only the recipient can accept.
+		_, _, _, err = _acceptUsernameListing(t, chain, db, params, 10, m0Pub, m0Priv, "m0", 0)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUsernameListingNotRecipient)
+
+		_acceptUsernameListingWithTestMeta(testMeta, 10, m2Pub, m2Priv, "m0", 0)
+		require.Equal("m2", _profileUsername(m1PkBytes))
+		require.Equal("m0", _profileUsername(m2PkBytes))
+	}
+
+	// A listing goes stale once the owner changes their username.
+	{
+		_usernameListingWithTestMeta(testMeta, 10, m2Pub, m2Priv, "m0", "m2_old", nil, 100, false)
+		_updateProfileWithTestMeta(testMeta, 10, m2Pub, m2Priv, []byte{}, "m2_new", "i am the m2",
+			shortPic, 10*100, 1.25*100*100, false)
+
+		_, _, _, err = _acceptUsernameListing(t, chain, db, params, 10, m0Pub, m0Priv, "m0", 100)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUsernameListingStale)
+
+		// Error case: only the owner can remove a listing.
+		_, _, _, err = _usernameListing(t, chain, db, params, 10, m0Pub, m0Priv,
+			"m0", "", nil, 0, true)
+		require.Error(err)
+		require.Contains(err.Error(), RuleErrorUsernameListingNotOwner)
+
+		_usernameListingWithTestMeta(testMeta, 10, m2Pub, m2Priv, "m0", "", nil, 0, true)
+		require.Nil(DbGetUsernameListingEntry(db, []byte("m0")))
+	}
+
+	// Roll all successful txns through connect and disconnect loops to make sure nothing breaks.
+	_rollBackTestMetaTxnsAndFlush(testMeta)
+	_applyTestMetaTxnsToMempool(testMeta)
+	_applyTestMetaTxnsToViewAndFlush(testMeta)
+	_disconnectTestMetaTxnsFromViewAndFlush(testMeta)
+	_connectBlockThenDisconnectBlockAndFlush(testMeta)
+}
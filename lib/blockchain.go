@@ -344,7 +344,19 @@ func CalcNextDifficultyTarget(
 	}
 	var minDiffHash BlockHash
 	copy(minDiffHash[:], minDiffBytes)
-	if lastNode == nil || lastNode.Height <= blocksPerRetarget {
+	if lastNode == nil {
+		return &minDiffHash, nil
+	}
+
+	// Once the EWMA retarget is enabled, it replaces the windowed retarget below,
+	// including the skipped first cycle.
+	if params.DifficultyEWMAWindowBlocks > 0 &&
+		uint64(lastNode.Height)+1 >= uint64(params.ForkHeights.EWMADifficultyRetargetBlockHeight) {
+
+		return calcNextDifficultyTargetEWMA(lastNode, &minDiffHash, params), nil
+	}
+
+	if lastNode.Height <= blocksPerRetarget {
 		return &minDiffHash, nil
 	}
 
@@ -394,6 +406,57 @@ func CalcNextDifficultyTarget(
 	return BigintToHash(nextDiffBigint), nil
 }
 
+// The longest solve time, as a multiple of TimeBetweenBlocks, that a single block
+// can feed into the EWMA retarget. This keeps one block with a timestamp far in
+// the future from making the difficulty much easier.
+const MaxEWMASolveTimeMultiple = 6
+
+// calcNextDifficultyTargetEWMA retargets the difficulty after every block. With
+// a window of N blocks and a target block time of T, each block moves the target
+// 1/N of the way toward the target implied by its own solve time:
+//
+//	nextTarget = lastTarget * (N*T + solveTime - T) / (N*T)
+//
+// A block solved in exactly T leaves the target unchanged, a faster block makes
+// the next one harder, and a slower block makes the next one easier. Because each
+// adjustment compounds on the last, the target responds to a change in hashrate
+// within a few windows rather than waiting for a full retarget period.
+func calcNextDifficultyTargetEWMA(
+	lastNode *BlockNode, minDiffHash *BlockHash, params *DeSoParams) *BlockHash {
+
+	// We need the parent's timestamp to compute a solve time.
+	if lastNode.Parent == nil {
+		return lastNode.DifficultyTarget
+	}
+
+	targetSecs := int64(params.TimeBetweenBlocks / time.Second)
+	if targetSecs < 1 {
+		targetSecs = 1
+	}
+	solveTimeSecs := int64(lastNode.Header.TstampSecs) - int64(lastNode.Parent.Header.TstampSecs)
+	if solveTimeSecs < 0 {
+		solveTimeSecs = 0
+	} else if solveTimeSecs > MaxEWMASolveTimeMultiple*targetSecs {
+		solveTimeSecs = MaxEWMASolveTimeMultiple * targetSecs
+	}
+
+	denominator := targetSecs * params.DifficultyEWMAWindowBlocks
+	numerator := new(big.Int).Mul(
+		HashToBigint(lastNode.DifficultyTarget),
+		big.NewInt(denominator+solveTimeSecs-targetSecs))
+	nextDiffBigint := numerator.Div(numerator, big.NewInt(denominator))
+
+	// Keep the target between one and the min difficulty.
+	if nextDiffBigint.Cmp(HashToBigint(minDiffHash)) > 0 {
+		nextDiffBigint = HashToBigint(minDiffHash)
+	}
+	if nextDiffBigint.Sign() <= 0 {
+		nextDiffBigint = big.NewInt(1)
+	}
+
+	return BigintToHash(nextDiffBigint)
+}
+
 type OrphanBlock struct {
 	Block *MsgDeSoBlock
 	Hash  *BlockHash
@@ -407,6 +470,10 @@ type Blockchain struct {
 	trustedBlockProducerStartHeight uint64
 	params                          *DeSoParams
 	eventManager                    *EventManager
+	// When non-zero, the node only keeps the bodies and UtxoOperations of the
+	// last pruneDepth blocks on the main chain. Reorgs deeper than this are
+	// rejected since we can no longer roll back the blocks they would detach.
+	pruneDepth uint64
 	// Returns true once all of the housekeeping in creating the
 	// blockchain is complete. This includes setting up the genesis block.
 	isInitialized bool
@@ -579,6 +646,7 @@ func NewBlockchain(
 	timeSource chainlib.MedianTimeSource,
 	db *badger.DB,
 	postgres *Postgres,
+	pruneDepth uint64,
 	eventManager *EventManager,
 ) (*Blockchain, error) {
 
@@ -599,6 +667,7 @@ func NewBlockchain(
 		trustedBlockProducerStartHeight: trustedBlockProducerStartHeight,
 		params:                          params,
 		eventManager:                    eventManager,
+		pruneDepth:                      pruneDepth,
 
 		blockIndex:   make(map[BlockHash]*BlockNode),
 		bestChainMap: make(map[BlockHash]*BlockNode),
@@ -949,7 +1018,8 @@ func (bc *Blockchain) HasBlock(blockHash *BlockHash) bool {
 	return true
 }
 
-// Don't need a lock because blocks don't get removed from the db after they're added
+// Don't need a lock because blocks only get removed from the db by the BlockPruner,
+// in which case we simply return nil as if we never had the block.
 func (bc *Blockchain) GetBlock(blockHash *BlockHash) *MsgDeSoBlock {
 	blk, err := GetBlock(blockHash, bc.db)
 	if err != nil {
@@ -970,6 +1040,97 @@ func (bc *Blockchain) GetBlockAtHeight(height uint32) *MsgDeSoBlock {
 	return bc.GetBlock(bc.bestChain[height].Hash)
 }
 
+// BlockPositionTxn is a main chain txn along with its position in the chain.
+type BlockPositionTxn struct {
+	Height          uint64
+	TxnIndexInBlock uint32
+	Txn             *MsgDeSoTxn
+	// Only set if a TXIndex was passed to IterateTxnsByBlockPosition and the
+	// txindex has caught up to this txn.
+	TxnMeta *TransactionMetadata
+}
+
+// IterateTxnsByBlockPosition streams every main chain txn with a height in
+// [startHeight, endHeight] to fn in canonical (height, txn index) order without
+// loading full blocks. If txnTypes is non-empty, only txns of those types are
+// returned. Iteration stops early if fn returns false or an error. The iteration
+// runs over a consistent snapshot of the db so it's safe to call while the
+// chain is advancing.
+func (bc *Blockchain) IterateTxnsByBlockPosition(startHeight uint64, endHeight uint64,
+	txnTypes []TxnType, txindex *TXIndex, fn func(*BlockPositionTxn) (bool, error)) error {
+
+	if startHeight > endHeight {
+		return fmt.Errorf("IterateTxnsByBlockPosition: startHeight %d is greater "+
+			"than endHeight %d", startHeight, endHeight)
+	}
+
+	return DbEnumerateBlockPositionTxns(bc.db, startHeight, endHeight, txnTypes,
+		func(height uint64, txnIndex uint32, desoTxn *MsgDeSoTxn) (bool, error) {
+			positionTxn := &BlockPositionTxn{
+				Height:          height,
+				TxnIndexInBlock: txnIndex,
+				Txn:             desoTxn,
+			}
+			if txindex != nil {
+				positionTxn.TxnMeta = DbGetTxindexTransactionRefByTxID(
+					txindex.TXIndexChain.DB(), desoTxn.Hash())
+			}
+			return fn(positionTxn)
+		})
+}
+
+// The number of blocks ReindexBlockPositionTxns writes in a single badger txn.
+const blockPositionReindexBatchSize = 100
+
+// ReindexBlockPositionTxns rebuilds the (height, txn index) position index
+// for every main chain block whose body we still have. Chains that were synced
+// before the index existed need this run once, which is done by starting the
+// node with --reindex-block-position-txns.
+func (bc *Blockchain) ReindexBlockPositionTxns() error {
+	bc.ChainLock.RLock()
+	tipHeight := uint64(bc.blockTip().Height)
+	bc.ChainLock.RUnlock()
+
+	startHeight := DbGetPrunedBlockHeight(bc.db)
+	for batchStart := startHeight; batchStart <= tipHeight; batchStart += blockPositionReindexBatchSize {
+		batchEnd := batchStart + blockPositionReindexBatchSize
+		if batchEnd > tipHeight+1 {
+			batchEnd = tipHeight + 1
+		}
+
+		// Hold the read lock for each batch so a reorg can't change the blocks
+		// we're indexing out from under us.
+		bc.ChainLock.RLock()
+		err := bc.db.Update(func(txn *badger.Txn) error {
+			for height := batchStart; height < batchEnd && height < uint64(len(bc.bestChain)); height++ {
+				node := bc.bestChain[height]
+				if err := DbDeleteBlockPositionTxnsForHeightWithTxn(txn, height); err != nil {
+					return errors.Wrapf(err, "ReindexBlockPositionTxns: Problem deleting "+
+						"entries at height %d", height)
+				}
+				blk := GetBlockWithTxn(txn, node.Hash)
+				if blk == nil {
+					return fmt.Errorf("ReindexBlockPositionTxns: Block %v at height %d "+
+						"is missing", node.Hash, height)
+				}
+				if err := DbPutBlockPositionTxnsWithTxn(txn, blk); err != nil {
+					return errors.Wrapf(err, "ReindexBlockPositionTxns: Problem indexing "+
+						"block %v", node.Hash)
+				}
+			}
+			return nil
+		})
+		bc.ChainLock.RUnlock()
+		if err != nil {
+			return err
+		}
+
+		glog.V(1).Infof("ReindexBlockPositionTxns: Indexed blocks up to height %d of %d",
+			batchEnd-1, tipHeight)
+	}
+	return nil
+}
+
 func (bc *Blockchain) isTipCurrent(tip *BlockNode) bool {
 	minChainWorkBytes, _ := hex.DecodeString(bc.params.MinChainWorkHex)
 
@@ -1086,6 +1247,21 @@ func (bc *Blockchain) DB() *badger.DB {
 	return bc.db
 }
 
+// PruneDepth returns the number of recent blocks whose bodies and UtxoOperations
+// we keep around. Zero means pruning is disabled.
+func (bc *Blockchain) PruneDepth() uint64 {
+	return bc.pruneDepth
+}
+
+// PrunedBlockHeight returns the height below which block bodies have been
+// deleted from the db. It returns zero if nothing has been pruned.
+func (bc *Blockchain) PrunedBlockHeight() uint64 {
+	if bc.pruneDepth == 0 {
+		return 0
+	}
+	return DbGetPrunedBlockHeight(bc.db)
+}
+
 // blockTip returns the tip of the main block chain. We fetch headers first
 // and then, once the header chain looks good, we fetch blocks. As such, we
 // store two separate "best" chains: One containing the best headers, and
@@ -1519,6 +1695,24 @@ func (bc *Blockchain) processHeader(blockHeader *MsgDeSoHeader, headerHash *Bloc
 		return false, false, HeaderErrorTimestampTooEarly
 	}
 
+	// Once the block producer schedule is active, a block dated a slot timeout or more
+	// after its parent may be signed by a fallback producer. Don't let a header claim
+	// this with a timestamp in the future, or a producer could take the slot early.
+	if blockHeader.Height >= uint64(bc.params.ForkHeights.BlockProducerScheduleBlockHeight) &&
+		bc.params.BlockProducerSlotTimeout > 0 {
+
+		slotTimeoutSecs := uint64(bc.params.BlockProducerSlotTimeout / time.Second)
+		adjustedTimeSecs := bc.timeSource.AdjustedTime().Unix()
+		if blockHeader.TstampSecs-parentHeader.TstampSecs >= slotTimeoutSecs &&
+			int64(blockHeader.TstampSecs) > adjustedTimeSecs {
+
+			glog.V(1).Infof("HeaderErrorBlockProducerFallbackInTheFuture: "+
+				"blockHeader.TstampSecs=%d; parentHeader.TstampSecs=%d; adjustedTime=%d",
+				blockHeader.TstampSecs, parentHeader.TstampSecs, adjustedTimeSecs)
+			return false, false, HeaderErrorBlockProducerFallbackInTheFuture
+		}
+	}
+
 	// Check that the proof of work beats the difficulty as calculated from
 	// the parent block. Note that if the parent block is in the block index
 	// then it has necessarily had its difficulty validated, and so using it to
@@ -1909,7 +2103,11 @@ func (bc *Blockchain) ProcessBlock(desoBlock *MsgDeSoBlock, verifySignatures boo
 				"not the current tip hash (%v)", utxoView.TipHash, currentTip.Hash)
 		}
 
+		// Only time connects of main chain blocks here. ConnectBlock is also used
+		// by the mempool and block producer, which shouldn't skew the metric.
+		connectStartTime := time.Now()
 		utxoOpsForBlock, err := utxoView.ConnectBlock(desoBlock, txHashes, verifySignatures, nil)
+		observeSecondsSince(metricBlockConnectSeconds, connectStartTime)
 		if err != nil {
 			if IsRuleError(err) {
 				// If we have a RuleError, mark the block as invalid before
@@ -1967,6 +2165,11 @@ func (bc *Blockchain) ProcessBlock(desoBlock *MsgDeSoBlock, verifySignatures boo
 					return errors.Wrapf(err, "ProcessBlock: Problem writing utxo operations to db on simple add to tip")
 				}
 
+				// Index the block's txns by their position in the chain.
+				if err := DbPutBlockPositionTxnsWithTxn(txn, desoBlock); err != nil {
+					return errors.Wrapf(err, "ProcessBlock: Problem writing block position txns to db on simple add to tip")
+				}
+
 				return nil
 			})
 		}
@@ -2047,6 +2250,16 @@ func (bc *Blockchain) ProcessBlock(desoBlock *MsgDeSoBlock, verifySignatures boo
 		commonAncestor, detachBlocks, attachBlocks := GetReorgBlocks(currentTip, nodeToValidate)
 		// Log a warning if the reorg is going to be a big one.
 		numBlocks := currentTip.Height - commonAncestor.Height
+		// A pruned node can't detach blocks whose UtxoOperations have already been
+		// deleted. Reject the block without marking it invalid since there is
+		// nothing wrong with it per se.
+		if bc.pruneDepth > 0 && (uint64(numBlocks) > bc.pruneDepth ||
+			uint64(commonAncestor.Height) < bc.PrunedBlockHeight()) {
+
+			return false, false, errors.Wrapf(RuleErrorReorgDeeperThanPruneDepth, "ProcessBlock: "+
+				"Reorg of (%d) blocks from block (%v) to block (%v) exceeds prune depth (%d)",
+				numBlocks, currentTip, nodeToValidate, bc.pruneDepth)
+		}
 		if numBlocks > 10 {
 			glog.Warningf("ProcessBlock: Proceeding with reorg of (%d) blocks from "+
 				"block (%v) at height (%d) to block (%v) at height of (%d)",
@@ -2073,6 +2286,10 @@ func (bc *Blockchain) ProcessBlock(desoBlock *MsgDeSoBlock, verifySignatures boo
 		// Go through and detach all of the blocks down to the common ancestor. We
 		// shouldn't encounter any errors but if we do, return without marking the
 		// block as invalid.
+		//
+		// Keep track of the detached blocks so we can remove their txns from the
+		// block position index.
+		detachedBlocks := []*MsgDeSoBlock{}
 		for _, nodeToDetach := range detachBlocks {
 			// Fetch the utxo operations for the block we're detaching. We need these
 			// in order to be able to detach the block.
@@ -2109,6 +2326,7 @@ func (bc *Blockchain) ProcessBlock(desoBlock *MsgDeSoBlock, verifySignatures boo
 					"does not match parent block hash (%v) after executing "+
 					"DisconnectBlock", utxoView.TipHash, blockToDetach.Header.PrevBlockHash)
 			}
+			detachedBlocks = append(detachedBlocks, blockToDetach)
 		}
 
 		// If we made it here, we were able to successfully detach all of the blocks
@@ -2125,6 +2343,8 @@ func (bc *Blockchain) ProcessBlock(desoBlock *MsgDeSoBlock, verifySignatures boo
 		//
 		// Keep track of the utxo operations we get from attaching the blocks.
 		utxoOpsForAttachBlocks := [][][]*UtxoOperation{}
+		// And the blocks themselves so we can add them to the block position index.
+		attachedBlocks := []*MsgDeSoBlock{}
 		// Also keep track of any errors that we might have come across.
 		ruleErrorsFound := []RuleError{}
 		// The first element will be the node right after the common ancestor and
@@ -2155,8 +2375,10 @@ func (bc *Blockchain) ProcessBlock(desoBlock *MsgDeSoBlock, verifySignatures boo
 			}
 
 			// Initialize the utxo operations slice.
+			connectStartTime := time.Now()
 			utxoOps, err := utxoView.ConnectBlock(
 				blockToAttach, txHashes, verifySignatures, nil)
+			observeSecondsSince(metricBlockConnectSeconds, connectStartTime)
 			if err != nil {
 				if IsRuleError(err) {
 					// If we have a RuleError, mark the block as invalid. But don't return
@@ -2183,6 +2405,7 @@ func (bc *Blockchain) ProcessBlock(desoBlock *MsgDeSoBlock, verifySignatures boo
 
 			// Add the utxo operations to our list.
 			utxoOpsForAttachBlocks = append(utxoOpsForAttachBlocks, utxoOps)
+			attachedBlocks = append(attachedBlocks, blockToAttach)
 		}
 
 		// At this point, either we were able to attach all of the blocks OR the block
@@ -2205,6 +2428,18 @@ func (bc *Blockchain) ProcessBlock(desoBlock *MsgDeSoBlock, verifySignatures boo
 		// the state after applying the reorg. With this information, it is possible to
 		// roll back the blocks and fast forward the db to the post-reorg state with a
 		// single transaction.
+		if bc.postgres != nil {
+			// Postgres gets the same net changes. This also records the reverted
+			// blocks in the change log so replicas follow the reorg.
+			for ii, attachNode := range attachBlocks {
+				if err = bc.postgres.UpsertBlockAndTransactions(attachNode, attachedBlocks[ii]); err != nil {
+					return false, false, errors.Wrapf(err, "ProcessBlock: Problem upserting block and transactions in reorg")
+				}
+			}
+			if err = bc.postgres.FlushView(utxoView); err != nil {
+				return false, false, errors.Wrapf(err, "ProcessBlock: Problem flushing view to postgres in reorg")
+			}
+		}
 		err = bc.db.Update(func(txn *badger.Txn) error {
 			// Set the best node hash to the new tip.
 			if err := PutBestHashWithTxn(txn, newTipNode.Hash, ChainTypeDeSoBlock); err != nil {
@@ -2224,12 +2459,23 @@ func (bc *Blockchain) ProcessBlock(desoBlock *MsgDeSoBlock, verifySignatures boo
 				// the minor cost of side chains not being retained by the network as reliably.
 			}
 
+			// Remove the detached blocks' txns from the block position index before
+			// adding the attached ones since they may share heights.
+			for _, detachedBlock := range detachedBlocks {
+				if err := DbDeleteBlockPositionTxnsWithTxn(txn, detachedBlock); err != nil {
+					return errors.Wrapf(err, "ProcessBlock: Problem deleting block position txns for block")
+				}
+			}
+
 			for ii, attachNode := range attachBlocks {
 				// Add the utxo operations for the blocks we're attaching so we can roll them back
 				// in the future if necessary.
 				if err := PutUtxoOperationsForBlockWithTxn(txn, attachNode.Hash, utxoOpsForAttachBlocks[ii]); err != nil {
 					return errors.Wrapf(err, "ProcessBlock: Problem putting utxo operations for block")
 				}
+				if err := DbPutBlockPositionTxnsWithTxn(txn, attachedBlocks[ii]); err != nil {
+					return errors.Wrapf(err, "ProcessBlock: Problem putting block position txns for block")
+				}
 			}
 
 			// Write the modified utxo set to the view.
@@ -2642,6 +2888,73 @@ func (bc *Blockchain) CreatePrivateMessageTxn(
 	return txn, totalInput, changeAmount, fees, nil
 }
 
+func (bc *Blockchain) CreateUserBlockTxn(
+	blockerPublicKey []byte, blockedPublicKey []byte, blockType UserBlockType, isUnblock bool,
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64,
+	_err error) {
+
+	// A UserBlock transaction doesn't need any inputs or outputs (except additionalOutputs provided).
+	txn := &MsgDeSoTxn{
+		PublicKey: blockerPublicKey,
+		TxnMeta: &UserBlockMetadata{
+			BlockedPublicKey: blockedPublicKey,
+			BlockType:        blockType,
+			IsUnblock:        isUnblock,
+		},
+		TxOutputs: additionalOutputs,
+		// We wait to compute the signature until we've added all the
+		// inputs and change.
+	}
+
+	totalInput, spendAmount, changeAmount, fees, err :=
+		bc.AddInputsAndChangeToTransaction(txn, minFeeRateNanosPerKB, mempool)
+	if err != nil {
+		return nil, 0, 0, 0, errors.Wrapf(
+			err, "CreateUserBlockTxn: Problem adding inputs: ")
+	}
+
+	// Sanity-check that the spendAmount is zero.
+	if err = amountEqualsAdditionalOutputs(spendAmount, additionalOutputs); err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("CreateUserBlockTxn: %v", err)
+	}
+
+	return txn, totalInput, changeAmount, fees, nil
+}
+
+func (bc *Blockchain) CreateMessageReadTxn(
+	readerPublicKey []byte, threadPublicKey []byte, lastReadTstampNanos uint64,
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64,
+	_err error) {
+
+	// A MessageRead transaction doesn't need any inputs or outputs (except additionalOutputs provided).
+	txn := &MsgDeSoTxn{
+		PublicKey: readerPublicKey,
+		TxnMeta: &MessageReadMetadata{
+			ThreadPublicKey:     threadPublicKey,
+			LastReadTstampNanos: lastReadTstampNanos,
+		},
+		TxOutputs: additionalOutputs,
+		// We wait to compute the signature until we've added all the
+		// inputs and change.
+	}
+
+	totalInput, spendAmount, changeAmount, fees, err :=
+		bc.AddInputsAndChangeToTransaction(txn, minFeeRateNanosPerKB, mempool)
+	if err != nil {
+		return nil, 0, 0, 0, errors.Wrapf(
+			err, "CreateMessageReadTxn: Problem adding inputs: ")
+	}
+
+	// Sanity-check that the spendAmount is zero.
+	if err = amountEqualsAdditionalOutputs(spendAmount, additionalOutputs); err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("CreateMessageReadTxn: %v", err)
+	}
+
+	return txn, totalInput, changeAmount, fees, nil
+}
+
 func (bc *Blockchain) CreateLikeTxn(
 	userPublicKey []byte, likedPostHash BlockHash, isUnlike bool,
 	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
@@ -2675,6 +2988,39 @@ func (bc *Blockchain) CreateLikeTxn(
 	return txn, totalInput, changeAmount, fees, nil
 }
 
+func (bc *Blockchain) CreatePollVoteTxn(
+	voterPublicKey []byte, postHash BlockHash, optionIndex uint32,
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64,
+	_err error) {
+
+	// A PollVote transaction doesn't need any inputs or outputs (except additionalOutputs provided).
+	txn := &MsgDeSoTxn{
+		PublicKey: voterPublicKey,
+		TxnMeta: &PollVoteMetadata{
+			PostHash:    &postHash,
+			OptionIndex: optionIndex,
+		},
+		TxOutputs: additionalOutputs,
+		// We wait to compute the signature until we've added all the
+		// inputs and change.
+	}
+
+	totalInput, spendAmount, changeAmount, fees, err :=
+		bc.AddInputsAndChangeToTransaction(txn, minFeeRateNanosPerKB, mempool)
+	if err != nil {
+		return nil, 0, 0, 0, errors.Wrapf(
+			err, "CreatePollVoteTxn: Problem adding inputs: ")
+	}
+
+	// Sanity-check that the spendAmount is zero.
+	if err = amountEqualsAdditionalOutputs(spendAmount, additionalOutputs); err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("CreatePollVoteTxn: %v", err)
+	}
+
+	return txn, totalInput, changeAmount, fees, nil
+}
+
 func (bc *Blockchain) CreateFollowTxn(
 	senderPublicKey []byte, followedPublicKey []byte, isUnfollow bool,
 	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
@@ -2983,6 +3329,49 @@ func (bc *Blockchain) CreateCreatorCoinTxn(
 		// inputs and change.
 	}
 
+	return bc._addInputsToCreatorCoinTxn(txn, minFeeRateNanosPerKB, mempool)
+}
+
+// CreateCreatorCoinTxnWithDeadline is like CreateCreatorCoinTxn but the trade fails
+// if it isn't mined by DeadlineBlockHeight. The deadline is carried in the txn's
+// extra data under CreatorCoinDeadlineBlockHeightKey.
+func (bc *Blockchain) CreateCreatorCoinTxnWithDeadline(
+	UpdaterPublicKey []byte,
+	// See CreatorCoinMetadataa for an explanation of these fields.
+	ProfilePublicKey []byte,
+	OperationType CreatorCoinOperationType,
+	DeSoToSellNanos uint64,
+	CreatorCoinToSellNanos uint64,
+	MinDeSoExpectedNanos uint64,
+	MinCreatorCoinExpectedNanos uint64,
+	DeadlineBlockHeight uint64,
+	// Standard transaction fields
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
+
+	txn := &MsgDeSoTxn{
+		PublicKey: UpdaterPublicKey,
+		TxnMeta: &CreatorCoinMetadataa{
+			ProfilePublicKey:            ProfilePublicKey,
+			OperationType:               OperationType,
+			DeSoToSellNanos:             DeSoToSellNanos,
+			CreatorCoinToSellNanos:      CreatorCoinToSellNanos,
+			MinDeSoExpectedNanos:        MinDeSoExpectedNanos,
+			MinCreatorCoinExpectedNanos: MinCreatorCoinExpectedNanos,
+		},
+		TxOutputs: additionalOutputs,
+		ExtraData: map[string][]byte{
+			CreatorCoinDeadlineBlockHeightKey: UintToBuf(DeadlineBlockHeight),
+		},
+	}
+
+	return bc._addInputsToCreatorCoinTxn(txn, minFeeRateNanosPerKB, mempool)
+}
+
+func (bc *Blockchain) _addInputsToCreatorCoinTxn(
+	txn *MsgDeSoTxn, minFeeRateNanosPerKB uint64, mempool *DeSoMempool) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
+
 	// We don't need to make any tweaks to the amount because it's basically
 	// a standard "pay per kilobyte" transaction.
 	totalInput, spendAmount, changeAmount, fees, err :=
@@ -3134,6 +3523,7 @@ func (bc *Blockchain) CreateCreateNFTTxn(
 	BuyNowPriceNanos uint64,
 	AdditionalDESORoyalties map[PublicKey]uint64,
 	AdditionalCoinRoyalties map[PublicKey]uint64,
+	NFTCollectionID *BlockHash,
 	// Standard transaction fields
 	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
 	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
@@ -3181,6 +3571,11 @@ func (bc *Blockchain) CreateCreateNFTTxn(
 		extraData[CoinRoyaltiesMapKey] = additionalCoinRoyaltiesBuf
 	}
 
+	// If this NFT is being attached to a collection, set the extra data appropriately.
+	if NFTCollectionID != nil {
+		extraData[NFTCollectionIDKey] = NFTCollectionID[:]
+	}
+
 	if len(extraData) > 0 {
 		txn.ExtraData = extraData
 	}
@@ -3203,80 +3598,70 @@ func (bc *Blockchain) CreateCreateNFTTxn(
 	return txn, totalInput, changeAmount, fees, nil
 }
 
-func (bc *Blockchain) GetInputsToCoverAmount(spenderPublicKey []byte, utxoView *UtxoView, amountToCover uint64) (
-	_inputs []*DeSoInput, _err error) {
-	// Get the spendable UtxoEntrys.
-	spenderSpendableUtxos, err := bc.GetSpendableUtxosForPublicKey(spenderPublicKey, nil, utxoView)
-	if err != nil {
-		return nil, errors.Wrapf(err, "Problem getting spendable UtxoEntrys: ")
-	}
-
-	// Add input utxos to the transaction until we have enough total input to cover
-	// the amount we want to spend plus the maximum fee (or until we've exhausted
-	// all the utxos available).
-	spenderInputs := []*DeSoInput{}
-	totalSpenderInput := uint64(0)
-	for _, utxoEntry := range spenderSpendableUtxos {
-
-		// If the amount of input we have isn't enough to cover the bid amount, add an input and continue.
-		if totalSpenderInput < amountToCover {
-			spenderInputs = append(spenderInputs, (*DeSoInput)(utxoEntry.UtxoKey))
-
-			amountToAdd := utxoEntry.AmountNanos
-			// For Bitcoin burns, we subtract a tiny amount of slippage to the amount we can
-			// spend. This makes reorderings more forgiving.
-			if utxoEntry.UtxoType == UtxoTypeBitcoinBurn {
-				amountToAdd = uint64(float64(amountToAdd) * .999)
-			}
-
-			totalSpenderInput += amountToAdd
-			continue
-		}
-
-		// If we get here, we know we have enough input to cover the upper bound
-		// estimate of our amount needed so break.
-		break
-	}
-	// If we get here and we don't have sufficient input to cover the bid, error.
-	if totalSpenderInput < amountToCover {
-		return nil, fmt.Errorf("Spender has insufficient "+
-			"UTXOs (%d total) to cover amount %d: ", totalSpenderInput, amountToCover)
-	}
-	return spenderInputs, nil
-}
-
-func (bc *Blockchain) CreateNFTBidTxn(
-	UpdaterPublicKey []byte,
-	NFTPostHash *BlockHash,
-	SerialNumber uint64,
-	BidAmountNanos uint64,
+func (bc *Blockchain) CreateCreateNFTCollectionTxn(
+	creatorPublicKey []byte,
+	name []byte,
+	maxSupply uint64,
+	nftRoyaltyToCreatorBasisPoints uint64,
+	nftRoyaltyToCoinBasisPoints uint64,
+	additionalDESORoyalties map[PublicKey]uint64,
+	additionalCoinRoyalties map[PublicKey]uint64,
 	// Standard transaction fields
 	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
 	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
-	// Create a transaction containing the NFT bid fields.
+
+	// A CreateNFTCollection transaction doesn't need any inputs or outputs (except additionalOutputs provided).
 	txn := &MsgDeSoTxn{
-		PublicKey: UpdaterPublicKey,
-		TxnMeta: &NFTBidMetadata{
-			NFTPostHash,
-			SerialNumber,
-			BidAmountNanos,
+		PublicKey: creatorPublicKey,
+		TxnMeta: &CreateNFTCollectionMetadata{
+			Name:                           name,
+			MaxSupply:                      maxSupply,
+			NFTRoyaltyToCreatorBasisPoints: nftRoyaltyToCreatorBasisPoints,
+			NFTRoyaltyToCoinBasisPoints:    nftRoyaltyToCoinBasisPoints,
 		},
 		TxOutputs: additionalOutputs,
 		// We wait to compute the signature until we've added all the
 		// inputs and change.
 	}
 
-	// Add inputs and change for a standard pay per KB transaction.
-	totalInput, _, changeAmount, fees, err :=
+	// Additional royalties are passed in the extra data just like they are for CreateNFT.
+	extraData := make(map[string][]byte)
+	if len(additionalDESORoyalties) > 0 {
+		additionalDESORoyaltiesBuf, err := SerializePubKeyToUint64Map(additionalDESORoyalties)
+		if err != nil {
+			return nil, 0, 0, 0, errors.Wrapf(err,
+				"CreateCreateNFTCollectionTxn: Problem encoding additional DESO Royalties map: ")
+		}
+		extraData[DESORoyaltiesMapKey] = additionalDESORoyaltiesBuf
+	}
+	if len(additionalCoinRoyalties) > 0 {
+		additionalCoinRoyaltiesBuf, err := SerializePubKeyToUint64Map(additionalCoinRoyalties)
+		if err != nil {
+			return nil, 0, 0, 0, errors.Wrapf(err,
+				"CreateCreateNFTCollectionTxn: Problem encoding additional Coin Royalties map: ")
+		}
+		extraData[CoinRoyaltiesMapKey] = additionalCoinRoyaltiesBuf
+	}
+	if len(extraData) > 0 {
+		txn.ExtraData = extraData
+	}
+
+	totalInput, spendAmount, changeAmount, fees, err :=
 		bc.AddInputsAndChangeToTransaction(txn, minFeeRateNanosPerKB, mempool)
 	if err != nil {
-		return nil, 0, 0, 0, errors.Wrapf(err, "CreateNFTBidTxn: Problem adding inputs: ")
+		return nil, 0, 0, 0, errors.Wrapf(
+			err, "CreateCreateNFTCollectionTxn: Problem adding inputs: ")
+	}
+
+	// Sanity-check that the spendAmount is zero.
+	if err = amountEqualsAdditionalOutputs(spendAmount, additionalOutputs); err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("CreateCreateNFTCollectionTxn: %v", err)
 	}
 
 	// We want our transaction to have at least one input, even if it all
 	// goes to change. This ensures that the transaction will not be "replayable."
 	if len(txn.TxInputs) == 0 {
-		return nil, 0, 0, 0, fmt.Errorf("CreateNFTBidTxn: NFTBid txn " +
+		return nil, 0, 0, 0, fmt.Errorf("CreateCreateNFTCollectionTxn: CreateNFTCollection txn " +
 			"must have at least one input but had zero inputs " +
 			"instead. Try increasing the fee rate.")
 	}
@@ -3284,12 +3669,473 @@ func (bc *Blockchain) CreateNFTBidTxn(
 	return txn, totalInput, changeAmount, fees, nil
 }
 
-func (bc *Blockchain) CreateNFTTransferTxn(
-	SenderPublicKey []byte,
-	ReceiverPublicKey []byte,
-	NFTPostHash *BlockHash,
-	SerialNumber uint64,
-	EncryptedUnlockableTextBytes []byte,
+func (bc *Blockchain) CreateNFTVaultTxn(
+	UpdaterPublicKey []byte,
+	// See NFTVaultMetadata for an explanation of these fields.
+	metadata *NFTVaultMetadata,
+	// Standard transaction fields
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
+
+	// Create a transaction containing the NFT vault fields.
+	txn := &MsgDeSoTxn{
+		PublicKey: UpdaterPublicKey,
+		TxnMeta:   metadata,
+		TxOutputs: additionalOutputs,
+		// We wait to compute the signature until we've added all the
+		// inputs and change.
+	}
+
+	// Add inputs and change for a standard pay per KB transaction.
+	totalInput, spendAmount, changeAmount, fees, err :=
+		bc.AddInputsAndChangeToTransaction(txn, minFeeRateNanosPerKB, mempool)
+	if err != nil {
+		return nil, 0, 0, 0, errors.Wrapf(err, "CreateNFTVaultTxn: Problem adding inputs: ")
+	}
+
+	// Sanity-check that the spendAmount is zero.
+	if err = amountEqualsAdditionalOutputs(spendAmount, additionalOutputs); err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("CreateNFTVaultTxn: %v", err)
+	}
+
+	// We want our transaction to have at least one input, even if it all
+	// goes to change. This ensures that the transaction will not be "replayable."
+	if len(txn.TxInputs) == 0 {
+		return nil, 0, 0, 0, fmt.Errorf("CreateNFTVaultTxn: NFTVault txn " +
+			"must have at least one input but had zero inputs " +
+			"instead. Try increasing the fee rate.")
+	}
+
+	return txn, totalInput, changeAmount, fees, nil
+}
+
+func (bc *Blockchain) CreateRedeemNFTVoucherTxn(
+	RedeemerPublicKey []byte,
+	Voucher *NFTVoucher,
+	CreatorSignature []byte,
+	NFTFee uint64,
+	// Standard transaction fields
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
+
+	// Create a transaction containing the voucher and the creator's signature.
+	txn := &MsgDeSoTxn{
+		PublicKey: RedeemerPublicKey,
+		TxnMeta: &RedeemNFTVoucherMetadata{
+			Voucher:          Voucher,
+			CreatorSignature: CreatorSignature,
+		},
+		TxOutputs: additionalOutputs,
+		// We wait to compute the signature until we've added all the
+		// inputs and change.
+	}
+
+	// We directly call AddInputsAndChangeToTransactionWithSubsidy so we can pass through
+	// the voucher's price and the NFT fee.
+	totalInput, _, changeAmount, fees, err :=
+		bc.AddInputsAndChangeToTransactionWithSubsidy(
+			txn, minFeeRateNanosPerKB, 0, mempool, Voucher.PriceNanos+NFTFee)
+	if err != nil {
+		return nil, 0, 0, 0, errors.Wrapf(err, "CreateRedeemNFTVoucherTxn: Problem adding inputs: ")
+	}
+
+	// We want our transaction to have at least one input, even if it all
+	// goes to change. This ensures that the transaction will not be "replayable."
+	if len(txn.TxInputs) == 0 {
+		return nil, 0, 0, 0, fmt.Errorf("CreateRedeemNFTVoucherTxn: RedeemNFTVoucher txn " +
+			"must have at least one input but had zero inputs " +
+			"instead. Try increasing the fee rate.")
+	}
+
+	return txn, totalInput, changeAmount, fees, nil
+}
+
+func (bc *Blockchain) CreateRegisterBlockProducerTxn(
+	BlockProducerPublicKey []byte,
+	BondNanos uint64,
+	// Standard transaction fields
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
+
+	// Create a transaction containing the bond.
+	txn := &MsgDeSoTxn{
+		PublicKey: BlockProducerPublicKey,
+		TxnMeta: &RegisterBlockProducerMetadata{
+			BondNanos: BondNanos,
+		},
+		TxOutputs: additionalOutputs,
+		// We wait to compute the signature until we've added all the
+		// inputs and change.
+	}
+
+	// We directly call AddInputsAndChangeToTransactionWithSubsidy so we can pass
+	// through the bond.
+	totalInput, _, changeAmount, fees, err :=
+		bc.AddInputsAndChangeToTransactionWithSubsidy(txn, minFeeRateNanosPerKB, 0, mempool, BondNanos)
+	if err != nil {
+		return nil, 0, 0, 0, errors.Wrapf(err, "CreateRegisterBlockProducerTxn: Problem adding inputs: ")
+	}
+
+	// We want our transaction to have at least one input, even if it all
+	// goes to change. This ensures that the transaction will not be "replayable."
+	if len(txn.TxInputs) == 0 {
+		return nil, 0, 0, 0, fmt.Errorf("CreateRegisterBlockProducerTxn: RegisterBlockProducer txn " +
+			"must have at least one input but had zero inputs " +
+			"instead. Try increasing the fee rate.")
+	}
+
+	return txn, totalInput, changeAmount, fees, nil
+}
+
+func (bc *Blockchain) CreateDeregisterBlockProducerTxn(
+	BlockProducerPublicKey []byte,
+	// Standard transaction fields
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
+
+	txn := &MsgDeSoTxn{
+		PublicKey: BlockProducerPublicKey,
+		TxnMeta:   &DeregisterBlockProducerMetadata{},
+		TxOutputs: additionalOutputs,
+		// We wait to compute the signature until we've added all the
+		// inputs and change.
+	}
+
+	totalInput, _, changeAmount, fees, err :=
+		bc.AddInputsAndChangeToTransaction(txn, minFeeRateNanosPerKB, mempool)
+	if err != nil {
+		return nil, 0, 0, 0, errors.Wrapf(err, "CreateDeregisterBlockProducerTxn: Problem adding inputs: ")
+	}
+
+	// We want our transaction to have at least one input, even if it all
+	// goes to change. This ensures that the transaction will not be "replayable."
+	if len(txn.TxInputs) == 0 {
+		return nil, 0, 0, 0, fmt.Errorf("CreateDeregisterBlockProducerTxn: DeregisterBlockProducer txn " +
+			"must have at least one input but had zero inputs " +
+			"instead. Try increasing the fee rate.")
+	}
+
+	return txn, totalInput, changeAmount, fees, nil
+}
+
+func (bc *Blockchain) CreateClaimBlockProducerBondTxn(
+	BlockProducerPublicKey []byte,
+	// Standard transaction fields
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
+
+	txn := &MsgDeSoTxn{
+		PublicKey: BlockProducerPublicKey,
+		TxnMeta:   &ClaimBlockProducerBondMetadata{},
+		TxOutputs: additionalOutputs,
+		// We wait to compute the signature until we've added all the
+		// inputs and change.
+	}
+
+	totalInput, _, changeAmount, fees, err :=
+		bc.AddInputsAndChangeToTransaction(txn, minFeeRateNanosPerKB, mempool)
+	if err != nil {
+		return nil, 0, 0, 0, errors.Wrapf(err, "CreateClaimBlockProducerBondTxn: Problem adding inputs: ")
+	}
+
+	// We want our transaction to have at least one input, even if it all
+	// goes to change. This ensures that the transaction will not be "replayable."
+	if len(txn.TxInputs) == 0 {
+		return nil, 0, 0, 0, fmt.Errorf("CreateClaimBlockProducerBondTxn: ClaimBlockProducerBond txn " +
+			"must have at least one input but had zero inputs " +
+			"instead. Try increasing the fee rate.")
+	}
+
+	return txn, totalInput, changeAmount, fees, nil
+}
+
+func (bc *Blockchain) CreateUsernameListingTxn(
+	OwnerPublicKey []byte,
+	Username []byte,
+	ReplacementUsername []byte,
+	RecipientPublicKey []byte,
+	PriceNanos uint64,
+	IsRemoved bool,
+	// Standard transaction fields
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
+
+	txn := &MsgDeSoTxn{
+		PublicKey: OwnerPublicKey,
+		TxnMeta: &UsernameListingMetadata{
+			Username:            Username,
+			ReplacementUsername: ReplacementUsername,
+			RecipientPublicKey:  RecipientPublicKey,
+			PriceNanos:          PriceNanos,
+			IsRemoved:           IsRemoved,
+		},
+		TxOutputs: additionalOutputs,
+		// We wait to compute the signature until we've added all the
+		// inputs and change.
+	}
+
+	totalInput, _, changeAmount, fees, err :=
+		bc.AddInputsAndChangeToTransaction(txn, minFeeRateNanosPerKB, mempool)
+	if err != nil {
+		return nil, 0, 0, 0, errors.Wrapf(err, "CreateUsernameListingTxn: Problem adding inputs: ")
+	}
+
+	// We want our transaction to have at least one input, even if it all
+	// goes to change. This ensures that the transaction will not be "replayable."
+	if len(txn.TxInputs) == 0 {
+		return nil, 0, 0, 0, fmt.Errorf("CreateUsernameListingTxn: UsernameListing txn " +
+			"must have at least one input but had zero inputs " +
+			"instead. Try increasing the fee rate.")
+	}
+
+	return txn, totalInput, changeAmount, fees, nil
+}
+
+func (bc *Blockchain) CreateAcceptUsernameListingTxn(
+	BuyerPublicKey []byte,
+	Username []byte,
+	PriceNanos uint64,
+	// Standard transaction fields
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
+
+	txn := &MsgDeSoTxn{
+		PublicKey: BuyerPublicKey,
+		TxnMeta: &AcceptUsernameListingMetadata{
+			Username:   Username,
+			PriceNanos: PriceNanos,
+		},
+		TxOutputs: additionalOutputs,
+		// We wait to compute the signature until we've added all the
+		// inputs and change.
+	}
+
+	// We directly call AddInputsAndChangeToTransactionWithSubsidy so we can pass
+	// through the price paid to the seller.
+	totalInput, _, changeAmount, fees, err :=
+		bc.AddInputsAndChangeToTransactionWithSubsidy(txn, minFeeRateNanosPerKB, 0, mempool, PriceNanos)
+	if err != nil {
+		return nil, 0, 0, 0, errors.Wrapf(err, "CreateAcceptUsernameListingTxn: Problem adding inputs: ")
+	}
+
+	// We want our transaction to have at least one input, even if it all
+	// goes to change. This ensures that the transaction will not be "replayable."
+	if len(txn.TxInputs) == 0 {
+		return nil, 0, 0, 0, fmt.Errorf("CreateAcceptUsernameListingTxn: AcceptUsernameListing txn " +
+			"must have at least one input but had zero inputs " +
+			"instead. Try increasing the fee rate.")
+	}
+
+	return txn, totalInput, changeAmount, fees, nil
+}
+
+func (bc *Blockchain) CreateKeyRotationTxn(
+	OldPublicKey []byte,
+	OperationType KeyRotationOperationType,
+	NewPublicKey []byte,
+	TimelockBlocks uint64,
+	NewKeySignature []byte,
+	// Standard transaction fields
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
+
+	txn := &MsgDeSoTxn{
+		PublicKey: OldPublicKey,
+		TxnMeta: &KeyRotationMetadata{
+			OperationType:   OperationType,
+			NewPublicKey:    NewPublicKey,
+			TimelockBlocks:  TimelockBlocks,
+			NewKeySignature: NewKeySignature,
+		},
+		TxOutputs: additionalOutputs,
+		// We wait to compute the signature until we've added all the
+		// inputs and change.
+	}
+
+	totalInput, _, changeAmount, fees, err :=
+		bc.AddInputsAndChangeToTransaction(txn, minFeeRateNanosPerKB, mempool)
+	if err != nil {
+		return nil, 0, 0, 0, errors.Wrapf(err, "CreateKeyRotationTxn: Problem adding inputs: ")
+	}
+
+	// We want our transaction to have at least one input, even if it all
+	// goes to change. This ensures that the transaction will not be "replayable."
+	if len(txn.TxInputs) == 0 {
+		return nil, 0, 0, 0, fmt.Errorf("CreateKeyRotationTxn: KeyRotation txn " +
+			"must have at least one input but had zero inputs " +
+			"instead. Try increasing the fee rate.")
+	}
+
+	return txn, totalInput, changeAmount, fees, nil
+}
+
+func (bc *Blockchain) CreateRecoveryGuardiansTxn(
+	OwnerPublicKey []byte,
+	GuardianPublicKeys [][]byte,
+	Threshold uint64,
+	DelayBlocks uint64,
+	// Standard transaction fields
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
+
+	txn := &MsgDeSoTxn{
+		PublicKey: OwnerPublicKey,
+		TxnMeta: &RecoveryGuardiansMetadata{
+			GuardianPublicKeys: GuardianPublicKeys,
+			Threshold:          Threshold,
+			DelayBlocks:        DelayBlocks,
+		},
+		TxOutputs: additionalOutputs,
+		// We wait to compute the signature until we've added all the
+		// inputs and change.
+	}
+
+	totalInput, _, changeAmount, fees, err :=
+		bc.AddInputsAndChangeToTransaction(txn, minFeeRateNanosPerKB, mempool)
+	if err != nil {
+		return nil, 0, 0, 0, errors.Wrapf(err, "CreateRecoveryGuardiansTxn: Problem adding inputs: ")
+	}
+
+	// We want our transaction to have at least one input, even if it all
+	// goes to change. This ensures that the transaction will not be "replayable."
+	if len(txn.TxInputs) == 0 {
+		return nil, 0, 0, 0, fmt.Errorf("CreateRecoveryGuardiansTxn: RecoveryGuardians txn " +
+			"must have at least one input but had zero inputs " +
+			"instead. Try increasing the fee rate.")
+	}
+
+	return txn, totalInput, changeAmount, fees, nil
+}
+
+func (bc *Blockchain) CreateAccountRecoveryTxn(
+	TransactorPublicKey []byte,
+	OperationType AccountRecoveryOperationType,
+	OwnerPublicKey []byte,
+	NewPublicKey []byte,
+	NewKeySignature []byte,
+	GuardianSignatures []*GuardianSignature,
+	// Standard transaction fields
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
+
+	txn := &MsgDeSoTxn{
+		PublicKey: TransactorPublicKey,
+		TxnMeta: &AccountRecoveryMetadata{
+			OperationType:      OperationType,
+			OwnerPublicKey:     OwnerPublicKey,
+			NewPublicKey:       NewPublicKey,
+			NewKeySignature:    NewKeySignature,
+			GuardianSignatures: GuardianSignatures,
+		},
+		TxOutputs: additionalOutputs,
+		// We wait to compute the signature until we've added all the
+		// inputs and change.
+	}
+
+	totalInput, _, changeAmount, fees, err :=
+		bc.AddInputsAndChangeToTransaction(txn, minFeeRateNanosPerKB, mempool)
+	if err != nil {
+		return nil, 0, 0, 0, errors.Wrapf(err, "CreateAccountRecoveryTxn: Problem adding inputs: ")
+	}
+
+	// We want our transaction to have at least one input, even if it all
+	// goes to change. This ensures that the transaction will not be "replayable."
+	if len(txn.TxInputs) == 0 {
+		return nil, 0, 0, 0, fmt.Errorf("CreateAccountRecoveryTxn: AccountRecovery txn " +
+			"must have at least one input but had zero inputs " +
+			"instead. Try increasing the fee rate.")
+	}
+
+	return txn, totalInput, changeAmount, fees, nil
+}
+
+func (bc *Blockchain) GetInputsToCoverAmount(spenderPublicKey []byte, utxoView *UtxoView, amountToCover uint64) (
+	_inputs []*DeSoInput, _err error) {
+	// Get the spendable UtxoEntrys.
+	spenderSpendableUtxos, err := bc.GetSpendableUtxosForPublicKey(spenderPublicKey, nil, utxoView)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Problem getting spendable UtxoEntrys: ")
+	}
+
+	// Add input utxos to the transaction until we have enough total input to cover
+	// the amount we want to spend plus the maximum fee (or until we've exhausted
+	// all the utxos available).
+	spenderInputs := []*DeSoInput{}
+	totalSpenderInput := uint64(0)
+	for _, utxoEntry := range spenderSpendableUtxos {
+
+		// If the amount of input we have isn't enough to cover the bid amount, add an input and continue.
+		if totalSpenderInput < amountToCover {
+			spenderInputs = append(spenderInputs, (*DeSoInput)(utxoEntry.UtxoKey))
+
+			amountToAdd := utxoEntry.AmountNanos
+			// For Bitcoin burns, we subtract a tiny amount of slippage to the amount we can
+			// spend. This makes reorderings more forgiving.
+			if utxoEntry.UtxoType == UtxoTypeBitcoinBurn {
+				amountToAdd = uint64(float64(amountToAdd) * .999)
+			}
+
+			totalSpenderInput += amountToAdd
+			continue
+		}
+
+		// If we get here, we know we have enough input to cover the upper bound
+		// estimate of our amount needed so break.
+		break
+	}
+	// If we get here and we don't have sufficient input to cover the bid, error.
+	if totalSpenderInput < amountToCover {
+		return nil, fmt.Errorf("Spender has insufficient "+
+			"UTXOs (%d total) to cover amount %d: ", totalSpenderInput, amountToCover)
+	}
+	return spenderInputs, nil
+}
+
+func (bc *Blockchain) CreateNFTBidTxn(
+	UpdaterPublicKey []byte,
+	NFTPostHash *BlockHash,
+	SerialNumber uint64,
+	BidAmountNanos uint64,
+	// Standard transaction fields
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
+	// Create a transaction containing the NFT bid fields.
+	txn := &MsgDeSoTxn{
+		PublicKey: UpdaterPublicKey,
+		TxnMeta: &NFTBidMetadata{
+			NFTPostHash,
+			SerialNumber,
+			BidAmountNanos,
+		},
+		TxOutputs: additionalOutputs,
+		// We wait to compute the signature until we've added all the
+		// inputs and change.
+	}
+
+	// Add inputs and change for a standard pay per KB transaction.
+	totalInput, _, changeAmount, fees, err :=
+		bc.AddInputsAndChangeToTransaction(txn, minFeeRateNanosPerKB, mempool)
+	if err != nil {
+		return nil, 0, 0, 0, errors.Wrapf(err, "CreateNFTBidTxn: Problem adding inputs: ")
+	}
+
+	// We want our transaction to have at least one input, even if it all
+	// goes to change. This ensures that the transaction will not be "replayable."
+	if len(txn.TxInputs) == 0 {
+		return nil, 0, 0, 0, fmt.Errorf("CreateNFTBidTxn: NFTBid txn " +
+			"must have at least one input but had zero inputs " +
+			"instead. Try increasing the fee rate.")
+	}
+
+	return txn, totalInput, changeAmount, fees, nil
+}
+
+func (bc *Blockchain) CreateNFTTransferTxn(
+	SenderPublicKey []byte,
+	ReceiverPublicKey []byte,
+	NFTPostHash *BlockHash,
+	SerialNumber uint64,
+	EncryptedUnlockableTextBytes []byte,
 	// Standard transaction fields
 	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
 	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
@@ -3520,6 +4366,60 @@ func (bc *Blockchain) CreateUpdateNFTTxn(
 	return txn, totalInput, changeAmount, fees, nil
 }
 
+// CreateStartNFTAuctionTxn puts an NFT up for a timed auction that ends at
+// AuctionEndBlockHeight. The highest bid at or above ReservePriceNanos wins
+// the NFT once the auction ends, and each new bid must beat the previous one
+// by at least MinBidIncrementNanos.
+func (bc *Blockchain) CreateStartNFTAuctionTxn(
+	UpdaterPublicKey []byte,
+	NFTPostHash *BlockHash,
+	SerialNumber uint64,
+	MinBidAmountNanos uint64,
+	AuctionEndBlockHeight uint64,
+	ReservePriceNanos uint64,
+	MinBidIncrementNanos uint64,
+	// Standard transaction fields
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
+
+	// An auction is started by putting the NFT up for sale with the auction
+	// parameters set in the extra data.
+	txn := &MsgDeSoTxn{
+		PublicKey: UpdaterPublicKey,
+		TxnMeta: &UpdateNFTMetadata{
+			NFTPostHash:       NFTPostHash,
+			SerialNumber:      SerialNumber,
+			IsForSale:         true,
+			MinBidAmountNanos: MinBidAmountNanos,
+		},
+		ExtraData: map[string][]byte{
+			AuctionEndBlockHeightKey:       UintToBuf(AuctionEndBlockHeight),
+			AuctionReservePriceNanosKey:    UintToBuf(ReservePriceNanos),
+			AuctionMinBidIncrementNanosKey: UintToBuf(MinBidIncrementNanos),
+		},
+		TxOutputs: additionalOutputs,
+		// We wait to compute the signature until we've added all the
+		// inputs and change.
+	}
+
+	// Add inputs and change for a standard pay per KB transaction.
+	totalInput, _, changeAmount, fees, err :=
+		bc.AddInputsAndChangeToTransaction(txn, minFeeRateNanosPerKB, mempool)
+	if err != nil {
+		return nil, 0, 0, 0, errors.Wrapf(err, "CreateStartNFTAuctionTxn: Problem adding inputs: ")
+	}
+
+	// We want our transaction to have at least one input, even if it all
+	// goes to change. This ensures that the transaction will not be "replayable."
+	if len(txn.TxInputs) == 0 {
+		return nil, 0, 0, 0, fmt.Errorf("CreateStartNFTAuctionTxn: UpdateNFT txn " +
+			"must have at least one input but had zero inputs " +
+			"instead. Try increasing the fee rate.")
+	}
+
+	return txn, totalInput, changeAmount, fees, nil
+}
+
 // Each diamond level is worth a fixed amount of DeSo. These amounts can be changed
 // in the future by simply returning a new set of values after a particular block height.
 func GetDeSoNanosDiamondLevelMapAtBlockHeight(
@@ -3766,6 +4666,52 @@ func (bc *Blockchain) CreateMessagingKeyTxn(
 	return txn, totalInput, changeAmount, fees, nil
 }
 
+func (bc *Blockchain) CreateMessagingGroupUpdateTxn(
+	senderPublicKey []byte,
+	groupOwnerPublicKey []byte,
+	messagingGroupKeyName []byte,
+	newMessagingPublicKey []byte,
+	groupOwnerSignature []byte,
+	members []*MessagingGroupMember,
+	removedMemberPublicKeys [][]byte,
+	addedAdminPublicKeys [][]byte,
+	removedAdminPublicKeys [][]byte,
+	minFeeRateNanosPerKB uint64, mempool *DeSoMempool, additionalOutputs []*DeSoOutput) (
+	_txn *MsgDeSoTxn, _totalInput uint64, _changeAmount uint64, _fees uint64, _err error) {
+
+	// We don't need to validate info here, so just construct the transaction instead.
+	txn := &MsgDeSoTxn{
+		PublicKey: senderPublicKey,
+		TxnMeta: &MessagingGroupUpdateMetadata{
+			GroupOwnerPublicKey:     groupOwnerPublicKey,
+			MessagingGroupKeyName:   messagingGroupKeyName,
+			NewMessagingPublicKey:   newMessagingPublicKey,
+			GroupOwnerSignature:     groupOwnerSignature,
+			MessagingGroupMembers:   members,
+			RemovedMemberPublicKeys: removedMemberPublicKeys,
+			AddedAdminPublicKeys:    addedAdminPublicKeys,
+			RemovedAdminPublicKeys:  removedAdminPublicKeys,
+		},
+		TxOutputs: additionalOutputs,
+	}
+
+	// We don't need to make any tweaks to the amount because it's basically
+	// a standard "pay per kilobyte" transaction.
+	totalInput, spendAmount, changeAmount, fees, err :=
+		bc.AddInputsAndChangeToTransaction(txn, minFeeRateNanosPerKB, mempool)
+	if err != nil {
+		return nil, 0, 0, 0, errors.Wrapf(err, "Blockchain.CreateMessagingGroupUpdateTxn: Problem adding inputs: ")
+	}
+
+	// Sanity-check that the spendAmount is zero.
+	if spendAmount != 0 {
+		return nil, 0, 0, 0, fmt.Errorf("Blockchain.CreateMessagingGroupUpdateTxn: Spend amount "+
+			"should be zero but was %d instead: ", spendAmount)
+	}
+
+	return txn, totalInput, changeAmount, fees, nil
+}
+
 func (bc *Blockchain) CreateBasicTransferTxnWithDiamonds(
 	SenderPublicKey []byte,
 	DiamondPostHash *BlockHash,
@@ -3799,9 +4745,12 @@ func (bc *Blockchain) CreateBasicTransferTxnWithDiamonds(
 				"Problem getting post entry for post hash")
 	}
 
+	// If the poster has rotated their key since, the diamond goes to their current key.
+	receiverPublicKey := utxoView.GetCurrentPublicKey(diamondPostEntry.PosterPublicKey)
+
 	blockHeight := bc.blockTip().Height + 1
 	desoToTransferNanos, _, err := utxoView.ValidateDiamondsAndGetNumDeSoNanos(
-		SenderPublicKey, diamondPostEntry.PosterPublicKey, DiamondPostHash, DiamondLevel, blockHeight)
+		SenderPublicKey, receiverPublicKey, DiamondPostHash, DiamondLevel, blockHeight)
 	if err != nil {
 		return nil, 0, 0, 0, 0, errors.Wrapf(
 			err, "Blockchain.CreateBasicTransferTxnWithDiamonds: Problem getting deso nanos: ")
@@ -3812,7 +4761,7 @@ func (bc *Blockchain) CreateBasicTransferTxnWithDiamonds(
 		PublicKey: SenderPublicKey,
 		TxnMeta:   &BasicTransferMetadata{},
 		TxOutputs: append(additionalOutputs, &DeSoOutput{
-			PublicKey:   diamondPostEntry.PosterPublicKey,
+			PublicKey:   receiverPublicKey,
 			AmountNanos: desoToTransferNanos,
 		}),
 		// TxInputs and TxOutputs will be set below.
@@ -3993,6 +4942,11 @@ func (bc *Blockchain) AddInputsAndChangeToTransactionWithSubsidy(
 		if nftEntry != nil && nftEntry.IsBuyNow && nftEntry.BuyNowPriceNanos <= txMeta.BidAmountNanos {
 			spendAmount += txMeta.BidAmountNanos
 		}
+
+		// Bids on NFT auctions are escrowed, so the bid must be covered by the txn's inputs.
+		if nftEntry != nil && nftEntry.AuctionEndBlockHeight > 0 {
+			spendAmount += txMeta.BidAmountNanos
+		}
 	}
 
 	// Add additional fees to the spend amount.
@@ -145,7 +145,7 @@ func NewTestBlockchain() (*Blockchain, *DeSoParams, *badger.DB) {
 	paramsCopy := DeSoTestnetParams
 
 	chain, err := NewBlockchain([]string{blockSignerPk}, 0, &paramsCopy,
-		timesource, db, nil, nil)
+		timesource, db, nil, 0, nil)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -216,7 +216,7 @@ func NewLowDifficultyBlockchainWithParams(params *DeSoParams) (
 	// Temporarily modify the seed balances to make a specific public
 	// key have some DeSo
 	chain, err := NewBlockchain([]string{blockSignerPk}, 0,
-		&paramsCopy, timesource, db, nil, nil)
+		&paramsCopy, timesource, db, nil, 0, nil)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -232,7 +232,7 @@ func NewTestMiner(t *testing.T, chain *Blockchain, params *DeSoParams, isSender
 
 	mempool := NewDeSoMempool(
 		chain, 0, /* rateLimitFeeRateNanosPerKB */
-		0 /* minFeeRateNanosPerKB */, "", true,
+		0 /* minFeeRateNanosPerKB */, true,
 		"" /*dataDir*/, "")
 	minerPubKeys := []string{}
 	if isSender {
@@ -1427,6 +1427,71 @@ func TestCalcNextDifficultyTargetSlightlyOff(t *testing.T) {
 	}, diffsAsInts)
 }
 
+func TestCalcNextDifficultyTargetEWMA(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	fakeParams := &DeSoParams{
+		MinDifficultyTargetHex:         hex.EncodeToString(BigintToHash(big.NewInt(100000))[:]),
+		TimeBetweenDifficultyRetargets: 6 * time.Second,
+		TimeBetweenBlocks:              2 * time.Second,
+		MaxDifficultyRetargetFactor:    2,
+		DifficultyEWMAWindowBlocks:     4,
+	}
+
+	// Returns the next target after a block at the given height that took
+	// solveTimeSecs to mine on top of a parent with the same target.
+	nextTarget := func(height uint32, target int64, solveTimeSecs int64) int64 {
+		parent := NewBlockNode(nil, nil, height-1, BigintToHash(big.NewInt(target)), nil,
+			&MsgDeSoHeader{TstampSecs: uint64(1000)}, StatusNone)
+		lastNode := NewBlockNode(parent, nil, height, BigintToHash(big.NewInt(target)), nil,
+			&MsgDeSoHeader{TstampSecs: uint64(1000 + solveTimeSecs)}, StatusNone)
+		nextDiff, err := CalcNextDifficultyTarget(lastNode, HeaderVersion1, fakeParams)
+		require.NoError(err)
+		return HashToBigint(nextDiff).Int64()
+	}
+
+	// With a window of 4 blocks and a 2 second block time, each block moves the
+	// target by (8 + solveTime - 2) / 8.
+	fakeParams.ForkHeights.EWMADifficultyRetargetBlockHeight = 0
+	{
+		// A block right on time leaves the target alone.
+		assert.Equal(int64(80000), nextTarget(10, 80000, 2))
+		// A fast block makes the next one harder.
+		assert.Equal(int64(60000), nextTarget(10, 80000, 0))
+		assert.Equal(int64(70000), nextTarget(10, 80000, 1))
+		// A slow block makes the next one easier.
+		assert.Equal(int64(50000), nextTarget(10, 40000, 4))
+		// Solve times are clipped to six block times, and a timestamp that goes
+		// backwards counts as a solve time of zero.
+		assert.Equal(int64(90000), nextTarget(10, 40000, 12))
+		assert.Equal(int64(90000), nextTarget(10, 40000, 1000))
+		assert.Equal(int64(30000), nextTarget(10, 40000, -100))
+		// The target never gets easier than the min difficulty.
+		assert.Equal(int64(100000), nextTarget(10, 80000, 12))
+		// Every block is a retarget point, including those in the first cycle.
+		assert.Equal(int64(60000), nextTarget(1, 80000, 0))
+	}
+
+	// Before the fork height the windowed retarget is used, which only changes
+	// the target every third block.
+	fakeParams.ForkHeights.EWMADifficultyRetargetBlockHeight = 11
+	{
+		assert.Equal(int64(80000), nextTarget(8, 80000, 0))
+		assert.Equal(int64(80000), nextTarget(7, 80000, 0))
+		// The block at height 11 is the first one retargeted with the EWMA.
+		assert.Equal(int64(60000), nextTarget(10, 80000, 0))
+	}
+
+	// A window of zero disables the EWMA retarget even past the fork height.
+	fakeParams.DifficultyEWMAWindowBlocks = 0
+	{
+		assert.Equal(int64(80000), nextTarget(10, 80000, 0))
+	}
+}
+
 func _testMerkleRoot(t *testing.T, shouldFail bool, blk *MsgDeSoBlock) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -1566,3 +1631,186 @@ func TestForbiddenBlockSignaturePubKey(t *testing.T) {
 	require.Error(err)
 	require.Contains(err.Error(), RuleErrorForbiddenBlockProducerPublicKey)
 }
+
+func TestBlockPrunerDeletesOldBlocks(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	chain, params, db := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+
+	// Keep only the last two blocks.
+	chain.pruneDepth = 2
+	pruner, err := NewBlockPruner(chain, 2)
+	require.NoError(err)
+
+	for ii := 0; ii < 6; ii++ {
+		_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+		require.NoError(err)
+	}
+	require.Equal(uint32(6), chain.BlockTip().Height)
+
+	numPruned, err := pruner.PruneOnce()
+	require.NoError(err)
+	require.Equal(3, numPruned)
+	require.Equal(uint64(4), chain.PrunedBlockHeight())
+
+	// A second pass should be a noop until more blocks are mined.
+	numPruned, err = pruner.PruneOnce()
+	require.NoError(err)
+	require.Equal(0, numPruned)
+
+	// The genesis block is never pruned.
+	require.NotNil(chain.GetBlock(chain.bestChain[0].Hash))
+	for height := 1; height < 4; height++ {
+		blockHash := chain.bestChain[height].Hash
+		require.Nil(chain.GetBlock(blockHash))
+		_, err := GetUtxoOperationsForBlock(db, blockHash)
+		require.Error(err)
+	}
+	for height := 4; height <= 6; height++ {
+		blockHash := chain.bestChain[height].Hash
+		require.NotNil(chain.GetBlock(blockHash))
+		_, err := GetUtxoOperationsForBlock(db, blockHash)
+		require.NoError(err)
+	}
+
+	// The pruned heights are dropped from the block position index, and a
+	// reindex shouldn't bring them back.
+	expectedNumFound := 0
+	for _, height := range []int{0, 4, 5, 6} {
+		expectedNumFound += len(chain.GetBlock(chain.bestChain[height].Hash).Txns)
+	}
+	checkPositionTxnHeights := func() {
+		numFound := 0
+		err := chain.IterateTxnsByBlockPosition(0, 6, nil, nil, func(positionTxn *BlockPositionTxn) (bool, error) {
+			require.True(positionTxn.Height == 0 || positionTxn.Height >= 4)
+			numFound++
+			return true, nil
+		})
+		require.NoError(err)
+		require.Equal(expectedNumFound, numFound)
+	}
+	checkPositionTxnHeights()
+	require.NoError(chain.ReindexBlockPositionTxns())
+	checkPositionTxnHeights()
+}
+
+func TestIterateTxnsByBlockPosition(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	chain, params, _ := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+
+	minedBlocks := []*MsgDeSoBlock{params.GenesisBlock}
+	for ii := 0; ii < 4; ii++ {
+		blk, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+		require.NoError(err)
+		minedBlocks = append(minedBlocks, blk)
+	}
+
+	// Every txn should come back in (height, txn index) order.
+	expectedTxns := []*MsgDeSoTxn{}
+	for _, blk := range minedBlocks {
+		expectedTxns = append(expectedTxns, blk.Txns...)
+	}
+	foundTxns := []*BlockPositionTxn{}
+	err := chain.IterateTxnsByBlockPosition(0, 4, nil, nil, func(positionTxn *BlockPositionTxn) (bool, error) {
+		foundTxns = append(foundTxns, positionTxn)
+		return true, nil
+	})
+	require.NoError(err)
+	require.Equal(len(expectedTxns), len(foundTxns))
+	for ii, positionTxn := range foundTxns {
+		require.Equal(*expectedTxns[ii].Hash(), *positionTxn.Txn.Hash())
+		require.Nil(positionTxn.TxnMeta)
+		if ii > 0 {
+			require.True(positionTxn.Height >= foundTxns[ii-1].Height)
+		}
+	}
+
+	// A sub-range filtered by type should only return block rewards in it.
+	numFound := 0
+	err = chain.IterateTxnsByBlockPosition(2, 3, []TxnType{TxnTypeBlockReward}, nil,
+		func(positionTxn *BlockPositionTxn) (bool, error) {
+			require.Equal(TxnTypeBlockReward, positionTxn.Txn.TxnMeta.GetTxnType())
+			require.True(positionTxn.Height >= 2 && positionTxn.Height <= 3)
+			numFound++
+			return true, nil
+		})
+	require.NoError(err)
+	require.Equal(2, numFound)
+
+	// Returning false stops the iteration.
+	numFound = 0
+	err = chain.IterateTxnsByBlockPosition(0, 4, nil, nil, func(positionTxn *BlockPositionTxn) (bool, error) {
+		numFound++
+		return false, nil
+	})
+	require.NoError(err)
+	require.Equal(1, numFound)
+
+	// Txns are read from the index alone, so they still come back with the
+	// block bodies gone.
+	require.NoError(chain.db.Update(func(txn *badger.Txn) error {
+		for _, blk := range minedBlocks[1:] {
+			blockHash, err := blk.Header.Hash()
+			if err != nil {
+				return err
+			}
+			if err := DeleteBlockBodyWithTxn(txn, blockHash); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	foundTxns = []*BlockPositionTxn{}
+	err = chain.IterateTxnsByBlockPosition(0, 4, nil, nil, func(positionTxn *BlockPositionTxn) (bool, error) {
+		foundTxns = append(foundTxns, positionTxn)
+		return true, nil
+	})
+	require.NoError(err)
+	require.Equal(len(expectedTxns), len(foundTxns))
+	for ii, positionTxn := range foundTxns {
+		require.Equal(*expectedTxns[ii].Hash(), *positionTxn.Txn.Hash())
+	}
+	for _, blk := range minedBlocks[1:] {
+		require.NoError(chain.db.Update(func(txn *badger.Txn) error {
+			return PutBlockWithTxn(txn, blk)
+		}))
+	}
+
+	// Dropping the index and reindexing should give back the same txns.
+	require.NoError(chain.db.Update(func(txn *badger.Txn) error {
+		for height := uint64(0); height <= 4; height++ {
+			if err := DbDeleteBlockPositionTxnsForHeightWithTxn(txn, height); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	numFound = 0
+	err = chain.IterateTxnsByBlockPosition(0, 4, nil, nil, func(positionTxn *BlockPositionTxn) (bool, error) {
+		numFound++
+		return true, nil
+	})
+	require.NoError(err)
+	require.Equal(0, numFound)
+
+	require.NoError(chain.ReindexBlockPositionTxns())
+	foundTxns = []*BlockPositionTxn{}
+	err = chain.IterateTxnsByBlockPosition(0, 4, nil, nil, func(positionTxn *BlockPositionTxn) (bool, error) {
+		foundTxns = append(foundTxns, positionTxn)
+		return true, nil
+	})
+	require.NoError(err)
+	require.Equal(len(expectedTxns), len(foundTxns))
+	for ii, positionTxn := range foundTxns {
+		require.Equal(*expectedTxns[ii].Hash(), *positionTxn.Txn.Hash())
+	}
+}
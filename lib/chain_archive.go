@@ -0,0 +1,334 @@
+package lib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// A chain archive is a portable, single-file copy of the main chain that can be
+// used to seed a node without connecting to any peers. The archive is a gzip
+// stream with the following uncompressed layout:
+//
+// 1) ChainArchiveMagic
+// 2) ChainArchiveVersion as a uvarint
+// 3) The NetworkType of the chain as a uvarint
+// 4) The genesis block hash [32]byte
+// 5) The number of blocks that follow as a uvarint
+// 6) For each block after genesis, in height order, the header bytes followed
+// by the full block bytes, each as a length-prefixed byte array
+// 7) A sha256 checksum [32]byte of everything above
+//
+// The genesis block is not included since every node creates it from its params.
+var ChainArchiveMagic = []byte("DESOCHAINARCHIVE")
+
+const ChainArchiveVersion = uint64(1)
+
+// The number of blocks we process between progress log lines.
+const chainArchiveLogInterval = 1000
+
+type ChainArchiveHeader struct {
+	Version          uint64
+	NetworkType      NetworkType
+	GenesisBlockHash *BlockHash
+	NumBlocks        uint64
+}
+
+// ExportChainArchive writes every block on the main chain after genesis to w.
+// It returns the number of blocks written.
+func ExportChainArchive(bc *Blockchain, ww io.Writer) (_numBlocks uint64, _err error) {
+	// Snapshot the main chain so we don't hold the lock while reading blocks.
+	bc.ChainLock.RLock()
+	bestChain := append([]*BlockNode{}, bc.bestChain...)
+	bc.ChainLock.RUnlock()
+
+	if len(bestChain) == 0 {
+		return 0, fmt.Errorf("ExportChainArchive: Best chain is empty")
+	}
+
+	gzipWriter := gzip.NewWriter(ww)
+	checksum := sha256.New()
+	out := io.MultiWriter(gzipWriter, checksum)
+
+	headerBytes := append([]byte{}, ChainArchiveMagic...)
+	headerBytes = append(headerBytes, UintToBuf(ChainArchiveVersion)...)
+	headerBytes = append(headerBytes, UintToBuf(uint64(bc.params.NetworkType))...)
+	headerBytes = append(headerBytes, bestChain[0].Hash[:]...)
+	headerBytes = append(headerBytes, UintToBuf(uint64(len(bestChain)-1))...)
+	if _, err := out.Write(headerBytes); err != nil {
+		return 0, errors.Wrapf(err, "ExportChainArchive: Problem writing archive header")
+	}
+
+	numBlocks := uint64(0)
+	for _, node := range bestChain[1:] {
+		blk := bc.GetBlock(node.Hash)
+		if blk == nil {
+			return numBlocks, fmt.Errorf("ExportChainArchive: Block %v at height %d "+
+				"is missing; is this a pruned node?", node.Hash, node.Height)
+		}
+		blkHeaderBytes, err := blk.Header.ToBytes(false)
+		if err != nil {
+			return numBlocks, errors.Wrapf(err, "ExportChainArchive: Problem "+
+				"serializing header for block %v", node.Hash)
+		}
+		blkBytes, err := blk.ToBytes(false)
+		if err != nil {
+			return numBlocks, errors.Wrapf(err, "ExportChainArchive: Problem "+
+				"serializing block %v", node.Hash)
+		}
+
+		recordBytes := EncodeByteArray(blkHeaderBytes)
+		recordBytes = append(recordBytes, EncodeByteArray(blkBytes)...)
+		if _, err := out.Write(recordBytes); err != nil {
+			return numBlocks, errors.Wrapf(err, "ExportChainArchive: Problem "+
+				"writing block %v", node.Hash)
+		}
+
+		numBlocks++
+		if numBlocks%chainArchiveLogInterval == 0 {
+			glog.Infof("ExportChainArchive: Exported %d of %d blocks", numBlocks, len(bestChain)-1)
+		}
+	}
+
+	// The checksum goes to the gzip stream only, not into itself.
+	if _, err := gzipWriter.Write(checksum.Sum(nil)); err != nil {
+		return numBlocks, errors.Wrapf(err, "ExportChainArchive: Problem writing checksum")
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return numBlocks, errors.Wrapf(err, "ExportChainArchive: Problem closing gzip stream")
+	}
+
+	return numBlocks, nil
+}
+
+// chainArchiveReader decodes the records of an archive while keeping a running
+// checksum of everything it reads.
+type chainArchiveReader struct {
+	gzipReader *gzip.Reader
+	checksum   hash.Hash
+	rr         io.Reader
+
+	// The checksum can only be checked once the whole archive has been read, so
+	// record lengths are capped here to keep a corrupt or malicious length
+	// prefix from making us allocate an arbitrarily large buffer.
+	maxRecordBytes uint64
+}
+
+func newChainArchiveReader(rr io.Reader, params *DeSoParams) (*chainArchiveReader, *ChainArchiveHeader, error) {
+	gzipReader, err := gzip.NewReader(rr)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "newChainArchiveReader: Problem opening gzip stream")
+	}
+	checksum := sha256.New()
+	car := &chainArchiveReader{
+		gzipReader: gzipReader,
+		checksum:   checksum,
+		rr:         io.TeeReader(gzipReader, checksum),
+
+		maxRecordBytes: params.MaxBlockSizeBytes,
+	}
+
+	magic := make([]byte, len(ChainArchiveMagic))
+	if _, err := io.ReadFull(car.rr, magic); err != nil {
+		return nil, nil, errors.Wrapf(err, "newChainArchiveReader: Problem reading magic")
+	}
+	if !bytes.Equal(magic, ChainArchiveMagic) {
+		return nil, nil, fmt.Errorf("newChainArchiveReader: File is not a chain archive")
+	}
+
+	header := &ChainArchiveHeader{}
+	header.Version, err = ReadUvarint(car.rr)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "newChainArchiveReader: Problem reading version")
+	}
+	if header.Version != ChainArchiveVersion {
+		return nil, nil, fmt.Errorf("newChainArchiveReader: Unsupported archive "+
+			"version %d; expected %d", header.Version, ChainArchiveVersion)
+	}
+	networkType, err := ReadUvarint(car.rr)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "newChainArchiveReader: Problem reading network type")
+	}
+	header.NetworkType = NetworkType(networkType)
+	header.GenesisBlockHash = &BlockHash{}
+	if _, err := io.ReadFull(car.rr, header.GenesisBlockHash[:]); err != nil {
+		return nil, nil, errors.Wrapf(err, "newChainArchiveReader: Problem reading genesis hash")
+	}
+	header.NumBlocks, err = ReadUvarint(car.rr)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "newChainArchiveReader: Problem reading block count")
+	}
+
+	return car, header, nil
+}
+
+// readRecord reads a length-prefixed byte array, rejecting the length before
+// anything is allocated if it's larger than any valid block.
+func (car *chainArchiveReader) readRecord() ([]byte, error) {
+	recordLen, err := ReadUvarint(car.rr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "readRecord: Problem reading length")
+	}
+	if recordLen > car.maxRecordBytes {
+		return nil, fmt.Errorf("readRecord: Record length %d exceeds max %d",
+			recordLen, car.maxRecordBytes)
+	}
+	recordBytes := make([]byte, recordLen)
+	if _, err := io.ReadFull(car.rr, recordBytes); err != nil {
+		return nil, errors.Wrapf(err, "readRecord: Problem reading %d bytes", recordLen)
+	}
+	return recordBytes, nil
+}
+
+func (car *chainArchiveReader) readBlock() (*MsgDeSoBlock, error) {
+	headerBytes, err := car.readRecord()
+	if err != nil {
+		return nil, errors.Wrapf(err, "readBlock: Problem reading header")
+	}
+	blkBytes, err := car.readRecord()
+	if err != nil {
+		return nil, errors.Wrapf(err, "readBlock: Problem reading block")
+	}
+
+	header := NewMessage(MsgTypeHeader).(*MsgDeSoHeader)
+	if err := header.FromBytes(headerBytes); err != nil {
+		return nil, errors.Wrapf(err, "readBlock: Problem decoding header")
+	}
+	blk := NewMessage(MsgTypeBlock).(*MsgDeSoBlock)
+	if err := blk.FromBytes(blkBytes); err != nil {
+		return nil, errors.Wrapf(err, "readBlock: Problem decoding block")
+	}
+
+	// The standalone header must match the one embedded in the block.
+	headerHash, err := header.Hash()
+	if err != nil {
+		return nil, errors.Wrapf(err, "readBlock: Problem hashing header")
+	}
+	blkHash, err := blk.Header.Hash()
+	if err != nil {
+		return nil, errors.Wrapf(err, "readBlock: Problem hashing block header")
+	}
+	if *headerHash != *blkHash {
+		return nil, fmt.Errorf("readBlock: Header hash %v does not match block "+
+			"hash %v", headerHash, blkHash)
+	}
+
+	return blk, nil
+}
+
+// verifyChecksum must be called after all the blocks have been read.
+func (car *chainArchiveReader) verifyChecksum() error {
+	expected := car.checksum.Sum(nil)
+	found := make([]byte, sha256.Size)
+	// Read from the gzip stream directly so the checksum isn't hashed.
+	if _, err := io.ReadFull(car.gzipReader, found); err != nil {
+		return errors.Wrapf(err, "verifyChecksum: Problem reading checksum")
+	}
+	if !bytes.Equal(expected, found) {
+		return fmt.Errorf("verifyChecksum: Archive checksum %x does not match "+
+			"computed checksum %x", found, expected)
+	}
+	return nil
+}
+
+func checkChainArchiveHeader(header *ChainArchiveHeader, params *DeSoParams) error {
+	if header.NetworkType != params.NetworkType {
+		return fmt.Errorf("Archive is for network %v but node is running %v",
+			header.NetworkType, params.NetworkType)
+	}
+	genesisHash := MustDecodeHexBlockHash(params.GenesisBlockHashHex)
+	if *header.GenesisBlockHash != *genesisHash {
+		return fmt.Errorf("Archive genesis hash %v does not match params "+
+			"genesis hash %v", header.GenesisBlockHash, genesisHash)
+	}
+	return nil
+}
+
+// VerifyChainArchive reads an entire archive and checks its checksum and that
+// every block decodes, without touching any chain state.
+func VerifyChainArchive(rr io.Reader, params *DeSoParams) (*ChainArchiveHeader, error) {
+	car, header, err := newChainArchiveReader(rr, params)
+	if err != nil {
+		return nil, errors.Wrapf(err, "VerifyChainArchive: ")
+	}
+	if err := checkChainArchiveHeader(header, params); err != nil {
+		return nil, errors.Wrapf(err, "VerifyChainArchive: ")
+	}
+	for ii := uint64(0); ii < header.NumBlocks; ii++ {
+		if _, err := car.readBlock(); err != nil {
+			return nil, errors.Wrapf(err, "VerifyChainArchive: Block %d: ", ii+1)
+		}
+	}
+	if err := car.verifyChecksum(); err != nil {
+		return nil, errors.Wrapf(err, "VerifyChainArchive: ")
+	}
+	return header, nil
+}
+
+// ImportChainArchive feeds every block in the archive through ProcessBlock.
+// The whole archive is verified in a first pass before any block is processed
+// so a corrupt file can't leave us with a partially imported chain, which is
+// why it needs an io.ReadSeeker. Blocks we already have are skipped so an
+// interrupted import can be resumed. Setting verifySignatures to false skips
+// txn signature checks, which should only be done for archives from a trusted
+// source. It returns the number of blocks that were processed.
+func ImportChainArchive(bc *Blockchain, rr io.ReadSeeker, verifySignatures bool) (_numProcessed uint64, _err error) {
+	if _, err := VerifyChainArchive(rr, bc.params); err != nil {
+		return 0, errors.Wrapf(err, "ImportChainArchive: ")
+	}
+	if _, err := rr.Seek(0, io.SeekStart); err != nil {
+		return 0, errors.Wrapf(err, "ImportChainArchive: Problem rewinding archive")
+	}
+
+	car, header, err := newChainArchiveReader(rr, bc.params)
+	if err != nil {
+		return 0, errors.Wrapf(err, "ImportChainArchive: ")
+	}
+	if err := checkChainArchiveHeader(header, bc.params); err != nil {
+		return 0, errors.Wrapf(err, "ImportChainArchive: ")
+	}
+
+	numProcessed := uint64(0)
+	for ii := uint64(0); ii < header.NumBlocks; ii++ {
+		blk, err := car.readBlock()
+		if err != nil {
+			return numProcessed, errors.Wrapf(err, "ImportChainArchive: Block %d: ", ii+1)
+		}
+		blockHash, _ := blk.Header.Hash()
+
+		bc.ChainLock.RLock()
+		hasBlock := bc.HasBlock(blockHash)
+		bc.ChainLock.RUnlock()
+		if hasBlock {
+			continue
+		}
+
+		_, isOrphan, err := bc.ProcessBlock(blk, verifySignatures)
+		if err != nil {
+			return numProcessed, errors.Wrapf(err, "ImportChainArchive: Problem "+
+				"processing block %v at height %d", blockHash, blk.Header.Height)
+		}
+		if isOrphan {
+			return numProcessed, fmt.Errorf("ImportChainArchive: Block %v at height "+
+				"%d is an orphan; archive is out of order", blockHash, blk.Header.Height)
+		}
+
+		numProcessed++
+		if numProcessed%chainArchiveLogInterval == 0 {
+			glog.Infof("ImportChainArchive: Processed block %d of %d", ii+1, header.NumBlocks)
+		}
+	}
+	// The archive was verified above, but check again in case the file changed
+	// between the two passes.
+	if err := car.verifyChecksum(); err != nil {
+		return numProcessed, errors.Wrapf(err, "ImportChainArchive: ")
+	}
+
+	return numProcessed, nil
+}
@@ -0,0 +1,76 @@
+package lib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainArchiveRoundTrip(t *testing.T) {
+	require := require.New(t)
+
+	chain, params, _ := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	for ii := 0; ii < 5; ii++ {
+		_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+		require.NoError(err)
+	}
+
+	archiveBuf := bytes.NewBuffer([]byte{})
+	numBlocks, err := ExportChainArchive(chain, archiveBuf)
+	require.NoError(err)
+	require.Equal(uint64(5), numBlocks)
+	archiveBytes := archiveBuf.Bytes()
+
+	header, err := VerifyChainArchive(bytes.NewReader(archiveBytes), params)
+	require.NoError(err)
+	require.Equal(uint64(5), header.NumBlocks)
+
+	// Import into a fresh chain and make sure we end up with the same tip.
+	newChain, _, _ := NewLowDifficultyBlockchain()
+	numProcessed, err := ImportChainArchive(newChain, bytes.NewReader(archiveBytes), false /*verifySignatures*/)
+	require.NoError(err)
+	require.Equal(uint64(5), numProcessed)
+	require.Equal(*chain.BlockTip().Hash, *newChain.BlockTip().Hash)
+
+	// Importing a second time is a noop.
+	numProcessed, err = ImportChainArchive(newChain, bytes.NewReader(archiveBytes), false /*verifySignatures*/)
+	require.NoError(err)
+	require.Equal(uint64(0), numProcessed)
+
+	// Flipping a byte anywhere in the archive should cause verification to fail.
+	{
+		corruptChain, _, _ := NewLowDifficultyBlockchain()
+		corruptBuf := bytes.NewBuffer([]byte{})
+		_, err := ExportChainArchive(chain, corruptBuf)
+		require.NoError(err)
+		corruptBytes := corruptBuf.Bytes()
+		corruptBytes[len(corruptBytes)/2] ^= 0xff
+		_, err = VerifyChainArchive(bytes.NewReader(corruptBytes), params)
+		require.Error(err)
+		numProcessed, err := ImportChainArchive(corruptChain, bytes.NewReader(corruptBytes), true /*verifySignatures*/)
+		require.Error(err)
+		require.Equal(uint64(0), numProcessed)
+		require.Equal(uint32(0), corruptChain.BlockTip().Height)
+	}
+
+	// A huge length prefix is rejected before we try to allocate for it.
+	{
+		hugeBuf := bytes.NewBuffer([]byte{})
+		gzipWriter := gzip.NewWriter(hugeBuf)
+		recordBytes := append([]byte{}, ChainArchiveMagic...)
+		recordBytes = append(recordBytes, UintToBuf(ChainArchiveVersion)...)
+		recordBytes = append(recordBytes, UintToBuf(uint64(params.NetworkType))...)
+		recordBytes = append(recordBytes, MustDecodeHexBlockHash(params.GenesisBlockHashHex)[:]...)
+		recordBytes = append(recordBytes, UintToBuf(1)...)
+		recordBytes = append(recordBytes, UintToBuf(uint64(1)<<60)...)
+		_, err := gzipWriter.Write(recordBytes)
+		require.NoError(err)
+		require.NoError(gzipWriter.Close())
+		_, err = VerifyChainArchive(bytes.NewReader(hugeBuf.Bytes()), params)
+		require.Error(err)
+		require.Contains(err.Error(), "exceeds max")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/holiman/uint256"
 	"log"
+	"math"
 	"math/big"
 	"os"
 	"path/filepath"
@@ -170,6 +171,73 @@ type ForkHeights struct {
 	// DAOCoinBlockHeight defines the height at which DAO Coin and DAO Coin Transfer
 	// transactions will be accepted.
 	DAOCoinBlockHeight uint32
+
+	// PollsBlockHeight defines the height at which posts can declare polls and
+	// PollVote transactions will be accepted.
+	PollsBlockHeight uint32
+
+	// UserBlocksBlockHeight defines the height at which users can block and
+	// mute each other.
+	UserBlocksBlockHeight uint32
+
+	// MessagingGroupUpdateBlockHeight defines the height at which group owners can remove
+	// members, re-key their groups and designate admins who can add members.
+	MessagingGroupUpdateBlockHeight uint32
+
+	// MessageReadReceiptsBlockHeight defines the height at which users can record how far
+	// they've read a message thread.
+	MessageReadReceiptsBlockHeight uint32
+
+	// NFTAuctionsBlockHeight defines the height at which NFTs can be put up for timed
+	// English auctions that settle automatically once their end height is reached.
+	NFTAuctionsBlockHeight uint32
+
+	// NFTCollectionsBlockHeight defines the height at which NFT collections can be
+	// created and NFTs can be attached to them.
+	NFTCollectionsBlockHeight uint32
+
+	// NFTVaultsBlockHeight defines the height at which NFTs can be locked into a vault that
+	// issues fungible shares to the owner and can be bought out on behalf of the share holders.
+	NFTVaultsBlockHeight uint32
+
+	// NFTVouchersBlockHeight defines the height at which creators can sign
+	// off-chain NFT vouchers that buyers redeem to lazily mint NFT copies.
+	NFTVouchersBlockHeight uint32
+
+	// EWMADifficultyRetargetBlockHeight defines the height from which the difficulty
+	// is retargeted every block using an exponentially weighted moving average of
+	// block times rather than once per TimeBetweenDifficultyRetargets. It only takes
+	// effect when DifficultyEWMAWindowBlocks is non-zero.
+	EWMADifficultyRetargetBlockHeight uint32
+
+	// BlockProducerScheduleBlockHeight defines the height at which block producers
+	// can register on-chain with a bond. Once any producer is registered, blocks
+	// must be signed by the producer scheduled for their height.
+	BlockProducerScheduleBlockHeight uint32
+
+	// BitcoinExchangeSPVBlockHeight defines the height at which BitcoinExchange burns must
+	// be proven against the Bitcoin header chain the node has stored locally, with enough
+	// confirmations on top of the block they were mined in. Past this height a node
+	// needs a Bitcoin header source to connect blocks that contain burns.
+	BitcoinExchangeSPVBlockHeight uint32
+
+	// CreatorCoinTradeDeadlineBlockHeight defines the height at which a creator coin buy or
+	// sell can set a deadline height in its extra data. Past the deadline the trade fails
+	// rather than executing at whatever price the coin has moved to.
+	CreatorCoinTradeDeadlineBlockHeight uint32
+
+	// UsernameMarketplaceBlockHeight defines the height at which a profile owner can list
+	// their username for sale or offer it to another user, who takes it over by
+	// accepting the listing.
+	UsernameMarketplaceBlockHeight uint32
+
+	// KeyRotationBlockHeight defines the height at which a user can rotate the public key that
+	// owns their PKID to a new key without the help of a param updater.
+	KeyRotationBlockHeight uint32
+
+	// AccountRecoveryBlockHeight defines the height at which a user can register recovery guardians
+	// who can jointly move their PKID to a new public key if they lose their key.
+	AccountRecoveryBlockHeight uint32
 }
 
 // DeSoParams defines the full list of possible parameters for the
@@ -219,9 +287,11 @@ type DeSoParams struct {
 	// the user's public key while 1 DeSo would be left as a transaction fee to the miner.
 	BitcoinExchangeFeeBasisPoints uint64
 
-	// The amount of time to wait for a Bitcoin txn to broadcast throughout the Bitcoin
-	// network before checking for double-spends.
-	BitcoinDoubleSpendWaitSeconds float64
+	// The number of Bitcoin blocks, including the one a burn was mined in, that must be
+	// on the node's best Bitcoin header chain before a BitcoinExchange can be connected.
+	// Before BitcoinExchangeSPVBlockHeight this is only enforced by the mempools of
+	// nodes that sync Bitcoin headers.
+	BitcoinMinNumConfirmations uint32
 
 	// This field allows us to set the amount purchased at genesis to a non-zero
 	// value.
@@ -264,6 +334,11 @@ type DeSoParams struct {
 	// Do not allow the difficulty to change by more than a factor of this
 	// variable during each adjustment period.
 	MaxDifficultyRetargetFactor int64
+	// The number of blocks over which the per-block EWMA retarget smooths block
+	// times once ForkHeights.EWMADifficultyRetargetBlockHeight is reached. Each
+	// block moves the target 1/DifficultyEWMAWindowBlocks of the way toward what
+	// its solve time implies. Zero disables the EWMA retarget.
+	DifficultyEWMAWindowBlocks int64
 	// Amount of time one must wait before a block reward can be spent.
 	BlockRewardMaturity time.Duration
 	// When shifting from v0 blocks to v1 blocks, we changed the hash function to
@@ -275,6 +350,16 @@ type DeSoParams struct {
 	// to be before it is rejected.
 	MaxTstampOffsetSeconds uint64
 
+	// The minimum bond a block producer must lock up to register on-chain.
+	BlockProducerMinBondNanos uint64
+	// How long the producer scheduled for a height has to produce its block before
+	// the next registered producer may produce it instead. Each timeout that elapses
+	// between a block and its parent lets one more producer take the slot.
+	BlockProducerSlotTimeout time.Duration
+	// How many blocks a deregistered producer's bond stays locked up before the
+	// producer can claim it back.
+	BlockProducerUnbondingBlocks uint32
+
 	// The maximum number of bytes that can be allocated to transactions in
 	// a block.
 	MaxBlockSizeBytes uint64
@@ -398,6 +483,21 @@ func (params *DeSoParams) EnableRegtest() {
 		DeSoV3MessagesBlockHeight:                            uint32(0),
 		BuyNowAndNFTSplitsBlockHeight:                        uint32(0),
 		DAOCoinBlockHeight:                                   uint32(0),
+		PollsBlockHeight:                                     uint32(0),
+		UserBlocksBlockHeight:                                uint32(0),
+		MessagingGroupUpdateBlockHeight:                      uint32(0),
+		MessageReadReceiptsBlockHeight:                       uint32(0),
+		NFTAuctionsBlockHeight:                               uint32(0),
+		NFTCollectionsBlockHeight:                            uint32(0),
+		NFTVaultsBlockHeight:                                 uint32(0),
+		NFTVouchersBlockHeight:                               uint32(0),
+		EWMADifficultyRetargetBlockHeight:                    uint32(0),
+		BlockProducerScheduleBlockHeight:                     uint32(0),
+		BitcoinExchangeSPVBlockHeight:                        uint32(0),
+		CreatorCoinTradeDeadlineBlockHeight:                  uint32(0),
+		UsernameMarketplaceBlockHeight:                       uint32(0),
+		KeyRotationBlockHeight:                               uint32(0),
+		AccountRecoveryBlockHeight:                           uint32(0),
 	}
 }
 
@@ -526,7 +626,7 @@ var DeSoMainnetParams = DeSoParams{
 	),
 
 	BitcoinExchangeFeeBasisPoints: 10,
-	BitcoinDoubleSpendWaitSeconds: 5.0,
+	BitcoinMinNumConfirmations:    6,
 	DeSoNanosPurchasedAtGenesis:   uint64(6000000000000000),
 	DefaultSocketPort:             uint16(17000),
 	DefaultJSONPort:               uint16(17001),
@@ -553,12 +653,21 @@ var DeSoMainnetParams = DeSoParams{
 	// Difficulty can't decrease to below 25% of its previous value or increase
 	// to above 400% of its previous value.
 	MaxDifficultyRetargetFactor: 4,
-	Base58PrefixPublicKey:       [3]byte{0xcd, 0x14, 0x0},
-	Base58PrefixPrivateKey:      [3]byte{0x35, 0x0, 0x0},
+	// When the EWMA retarget is enabled, smooth block times over about half a day.
+	DifficultyEWMAWindowBlocks: 144,
+	Base58PrefixPublicKey:      [3]byte{0xcd, 0x14, 0x0},
+	Base58PrefixPrivateKey:     [3]byte{0x35, 0x0, 0x0},
 
 	// Reject blocks that are more than two hours in the future.
 	MaxTstampOffsetSeconds: 2 * 60 * 60,
 
+	// Block producers must lock up 1,000 DESO, and give up their slot if they
+	// haven't produced a block within three block times. Their bond stays locked
+	// up for two weeks after they deregister.
+	BlockProducerMinBondNanos:    1000 * NanosPerUnit,
+	BlockProducerSlotTimeout:     15 * time.Minute,
+	BlockProducerUnbondingBlocks: 12 * 24 * 14,
+
 	// We use a max block size of 16MB. This translates to 100-200 posts per
 	// second depending on the size of the post, which should support around
 	// ten million active users. We compute this by taking Twitter, which averages
@@ -640,6 +749,23 @@ var DeSoMainnetParams = DeSoParams{
 		DeSoV3MessagesBlockHeight:                            uint32(98474),
 		BuyNowAndNFTSplitsBlockHeight:                        uint32(98474),
 		DAOCoinBlockHeight:                                   uint32(98474),
+
+		// Not yet scheduled.
+		PollsBlockHeight:                    uint32(math.MaxUint32),
+		UserBlocksBlockHeight:               uint32(math.MaxUint32),
+		MessagingGroupUpdateBlockHeight:     uint32(math.MaxUint32),
+		MessageReadReceiptsBlockHeight:      uint32(math.MaxUint32),
+		NFTAuctionsBlockHeight:              uint32(math.MaxUint32),
+		NFTCollectionsBlockHeight:           uint32(math.MaxUint32),
+		NFTVaultsBlockHeight:                uint32(math.MaxUint32),
+		NFTVouchersBlockHeight:              uint32(math.MaxUint32),
+		EWMADifficultyRetargetBlockHeight:   uint32(math.MaxUint32),
+		BlockProducerScheduleBlockHeight:    uint32(math.MaxUint32),
+		BitcoinExchangeSPVBlockHeight:       uint32(math.MaxUint32),
+		CreatorCoinTradeDeadlineBlockHeight: uint32(math.MaxUint32),
+		UsernameMarketplaceBlockHeight:      uint32(math.MaxUint32),
+		KeyRotationBlockHeight:              uint32(math.MaxUint32),
+		AccountRecoveryBlockHeight:          uint32(math.MaxUint32),
 	},
 }
 
@@ -685,7 +811,7 @@ var DeSoTestnetParams = DeSoParams{
 	BitcoinBtcdParams:             &chaincfg.TestNet3Params,
 	BitcoinBurnAddress:            "mhziDsPWSMwUqvZkVdKY92CjesziGP3wHL",
 	BitcoinExchangeFeeBasisPoints: 10,
-	BitcoinDoubleSpendWaitSeconds: 5.0,
+	BitcoinMinNumConfirmations:    6,
 	DeSoNanosPurchasedAtGenesis:   uint64(6000000000000000),
 
 	// See comment in mainnet config.
@@ -736,6 +862,8 @@ var DeSoTestnetParams = DeSoParams{
 	// Difficulty can't decrease to below 50% of its previous value or increase
 	// to above 200% of its previous value.
 	MaxDifficultyRetargetFactor: 2,
+	// When the EWMA retarget is enabled, smooth block times over about half an hour.
+	DifficultyEWMAWindowBlocks: 36,
 	// Miners need to wait some time before spending their block reward.
 	BlockRewardMaturity: 5 * time.Minute,
 
@@ -744,6 +872,10 @@ var DeSoTestnetParams = DeSoParams{
 	// Reject blocks that are more than two hours in the future.
 	MaxTstampOffsetSeconds: 2 * 60 * 60,
 
+	BlockProducerMinBondNanos:    10 * NanosPerUnit,
+	BlockProducerSlotTimeout:     3 * time.Minute,
+	BlockProducerUnbondingBlocks: 60 * 24,
+
 	// We use a max block size of 1MB. This seems to work well for BTC and
 	// most of our data doesn't need to be stored on the blockchain anyway.
 	MaxBlockSizeBytes: 1000000,
@@ -825,6 +957,23 @@ var DeSoTestnetParams = DeSoParams{
 		DeSoV3MessagesBlockHeight:                            uint32(97322),
 		BuyNowAndNFTSplitsBlockHeight:                        uint32(97322),
 		DAOCoinBlockHeight:                                   uint32(97322),
+
+		// Not yet scheduled.
+		PollsBlockHeight:                    uint32(math.MaxUint32),
+		UserBlocksBlockHeight:               uint32(math.MaxUint32),
+		MessagingGroupUpdateBlockHeight:     uint32(math.MaxUint32),
+		MessageReadReceiptsBlockHeight:      uint32(math.MaxUint32),
+		NFTAuctionsBlockHeight:              uint32(math.MaxUint32),
+		NFTCollectionsBlockHeight:           uint32(math.MaxUint32),
+		NFTVaultsBlockHeight:                uint32(math.MaxUint32),
+		NFTVouchersBlockHeight:              uint32(math.MaxUint32),
+		EWMADifficultyRetargetBlockHeight:   uint32(math.MaxUint32),
+		BlockProducerScheduleBlockHeight:    uint32(math.MaxUint32),
+		BitcoinExchangeSPVBlockHeight:       uint32(math.MaxUint32),
+		CreatorCoinTradeDeadlineBlockHeight: uint32(math.MaxUint32),
+		UsernameMarketplaceBlockHeight:      uint32(math.MaxUint32),
+		KeyRotationBlockHeight:              uint32(math.MaxUint32),
+		AccountRecoveryBlockHeight:          uint32(math.MaxUint32),
 	},
 }
 
@@ -880,6 +1029,24 @@ const (
 	// the amount of royalties that should be added to pkid's creator coin upon sale of this NFT.
 	CoinRoyaltiesMapKey = "CoinRoyaltiesMap"
 
+	// Key in a SubmitPost transaction's extra data map. If present, the value is an encoded PostPoll and the
+	// new post declares a poll.
+	PollKey = "Poll"
+
+	// Keys in an UpdateNFT transaction's extra data map. If AuctionEndBlockHeightKey is present, the NFT is put up
+	// for a timed English auction that ends at that height. The reserve price and minimum bid increment are optional.
+	AuctionEndBlockHeightKey       = "AuctionEndBlockHeight"
+	AuctionReservePriceNanosKey    = "AuctionReservePriceNanos"
+	AuctionMinBidIncrementNanosKey = "AuctionMinBidIncrementNanos"
+
+	// Key in a CreateNFT transaction's extra data map. If present, the value is the 32-byte ID of the NFT collection
+	// the new NFT belongs to. The collection's royalties override the ones set on the transaction.
+	NFTCollectionIDKey = "NFTCollectionID"
+
+	// Key in a CreatorCoin transaction's extra data map. If present, the buy or sell fails if it's connected in a
+	// block above this height so that a trade that sits around doesn't execute at a stale price.
+	CreatorCoinDeadlineBlockHeightKey = "CreatorCoinDeadlineBlockHeight"
+
 	// Used to distinguish v3 messages from previous iterations
 	MessagesVersionString = "V"
 	MessagesVersion1 = 1
@@ -935,4 +1102,14 @@ const (
 	// Messaging key constants
 	MinMessagingKeyNameCharacters = 1
 	MaxMessagingKeyNameCharacters = 32
+	// Poll constants
+	MinPollOptions             = 2
+	MaxPollOptions             = 10
+	MaxPollQuestionLengthBytes = 1000
+	MaxPollOptionLengthBytes   = 200
+	// NFT auction constants. A bid placed within NFTAuctionExtensionBlocks of the end of
+	// an auction pushes the end back so that there are NFTAuctionExtensionBlocks left.
+	NFTAuctionExtensionBlocks = 6
+	// NFT collection constants
+	MaxNFTCollectionNameLengthBytes = 200
 )
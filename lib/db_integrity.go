@@ -0,0 +1,800 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// Most state in db_utils.go is stored as a primary entry plus one or more
+// secondary indices that are written in the same badger transaction. If a node
+// crashes mid-flush these can drift apart. The checks below walk each primary
+// prefix to find secondary entries that are missing or stale, and then walk each
+// secondary prefix to find entries that no longer have a primary entry backing
+// them. Since the primary entries are authoritative, repairing simply rewrites
+// the secondary indices from them.
+
+type DbIntegrityIssueType uint8
+
+const (
+	// A secondary index entry implied by a primary entry doesn't exist.
+	DbIntegrityIssueMissingEntry DbIntegrityIssueType = iota
+	// A secondary index entry exists but its value doesn't match the primary entry.
+	DbIntegrityIssueMismatchedEntry
+	// An entry that isn't backed by the entries it depends on, e.g. a secondary
+	// index entry with no primary entry or an NFT bid for an NFT that doesn't exist.
+	DbIntegrityIssueDanglingEntry
+	// A primary entry that can't be decoded. These can't be repaired automatically.
+	DbIntegrityIssueUndecodableEntry
+	// The best block hash doesn't line up with the block index. These can't be
+	// repaired automatically.
+	DbIntegrityIssueInconsistentTip
+	// A primary entry that references data that doesn't exist but that holds
+	// state we can't throw away, e.g. a post in an NFT collection that doesn't
+	// exist. These can't be repaired automatically.
+	DbIntegrityIssueBrokenReference
+)
+
+func (issueType DbIntegrityIssueType) String() string {
+	switch issueType {
+	case DbIntegrityIssueMissingEntry:
+		return "MISSING"
+	case DbIntegrityIssueMismatchedEntry:
+		return "MISMATCHED"
+	case DbIntegrityIssueDanglingEntry:
+		return "DANGLING"
+	case DbIntegrityIssueUndecodableEntry:
+		return "UNDECODABLE"
+	case DbIntegrityIssueInconsistentTip:
+		return "INCONSISTENT_TIP"
+	case DbIntegrityIssueBrokenReference:
+		return "BROKEN_REFERENCE"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", issueType)
+	}
+}
+
+type DbIntegrityIssue struct {
+	CheckName string
+	Type      DbIntegrityIssueType
+	Key       []byte
+	// For missing and mismatched entries, the value the key should have.
+	ExpectedValue []byte
+	Details       string
+}
+
+func (issue *DbIntegrityIssue) String() string {
+	return fmt.Sprintf("< Check: %s, Type: %v, Key: %x, Details: %s >",
+		issue.CheckName, issue.Type, issue.Key, issue.Details)
+}
+
+func (issue *DbIntegrityIssue) IsRepairable() bool {
+	return issue.Type == DbIntegrityIssueMissingEntry ||
+		issue.Type == DbIntegrityIssueMismatchedEntry ||
+		issue.Type == DbIntegrityIssueDanglingEntry
+}
+
+type dbKeyValue struct {
+	key []byte
+	val []byte
+}
+
+type dbIndexPairCheck struct {
+	name              string
+	primaryPrefix     []byte
+	secondaryPrefixes [][]byte
+
+	// Returns the secondary entries implied by a primary entry. Returning
+	// isValid=false means the primary entry itself should not exist.
+	expectedSecondaryEntries func(txn *badger.Txn, key []byte, val []byte) (
+		_entries []*dbKeyValue, _isValid bool, _err error)
+
+	// Set when deleting an invalid primary entry would lose more than the bad
+	// reference, in which case it's reported as a broken reference instead of
+	// a dangling entry so that repairing leaves it alone.
+	keepInvalidPrimary bool
+
+	// Returns true if the secondary entry is backed by a primary entry.
+	isSecondaryBacked func(txn *badger.Txn, key []byte, val []byte) bool
+}
+
+func _dbGetValueWithTxn(txn *badger.Txn, key []byte) ([]byte, bool) {
+	item, err := txn.Get(key)
+	if err != nil {
+		return nil, false
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, false
+	}
+	return val, true
+}
+
+func _dbHasKeyWithTxn(txn *badger.Txn, key []byte) bool {
+	_, err := txn.Get(key)
+	return err == nil
+}
+
+// _dbSplitKey strips the prefix off of a key and returns it in chunks of the
+// given sizes. It returns nil if the key doesn't have the expected length.
+func _dbSplitKey(key []byte, prefix []byte, sizes ...int) [][]byte {
+	rest := key[len(prefix):]
+	totalSize := 0
+	for _, size := range sizes {
+		totalSize += size
+	}
+	if len(rest) != totalSize {
+		return nil
+	}
+	chunks := [][]byte{}
+	for _, size := range sizes {
+		chunks = append(chunks, rest[:size])
+		rest = rest[size:]
+	}
+	return chunks
+}
+
+func _balanceEntryIndexCheck(isDAOCoin bool) *dbIndexPairCheck {
+	name := "creator-coin-balance"
+	if isDAOCoin {
+		name = "dao-coin-balance"
+	}
+	primaryPrefix := _dbGetPrefixForHODLerPKIDCreatorPKIDToBalanceEntry(isDAOCoin)
+	secondaryPrefix := _dbGetPrefixForCreatorPKIDHODLerPKIDToBalanceEntry(isDAOCoin)
+
+	return &dbIndexPairCheck{
+		name:              name,
+		primaryPrefix:     primaryPrefix,
+		secondaryPrefixes: [][]byte{secondaryPrefix},
+		expectedSecondaryEntries: func(txn *badger.Txn, key []byte, val []byte) ([]*dbKeyValue, bool, error) {
+			chunks := _dbSplitKey(key, primaryPrefix, btcec.PubKeyBytesLenCompressed, btcec.PubKeyBytesLenCompressed)
+			if chunks == nil {
+				return nil, false, fmt.Errorf("invalid key length %d", len(key))
+			}
+			hodlerPKID, creatorPKID := PublicKeyToPKID(chunks[0]), PublicKeyToPKID(chunks[1])
+			return []*dbKeyValue{{
+				key: _dbKeyForCreatorPKIDHODLerPKIDToBalanceEntry(creatorPKID, hodlerPKID, isDAOCoin),
+				val: val,
+			}}, true, nil
+		},
+		isSecondaryBacked: func(txn *badger.Txn, key []byte, val []byte) bool {
+			chunks := _dbSplitKey(key, secondaryPrefix, btcec.PubKeyBytesLenCompressed, btcec.PubKeyBytesLenCompressed)
+			if chunks == nil {
+				return false
+			}
+			creatorPKID, hodlerPKID := PublicKeyToPKID(chunks[0]), PublicKeyToPKID(chunks[1])
+			return _dbHasKeyWithTxn(txn, _dbKeyForHODLerPKIDCreatorPKIDToBalanceEntry(hodlerPKID, creatorPKID, isDAOCoin))
+		},
+	}
+}
+
+func _dbIndexPairChecks() []*dbIndexPairCheck {
+	return []*dbIndexPairCheck{
+		{
+			name:          "profile",
+			primaryPrefix: _PrefixPKIDToProfileEntry,
+			secondaryPrefixes: [][]byte{
+				_PrefixProfileUsernameToPKID,
+				_PrefixCreatorDeSoLockedNanosCreatorPKID,
+			},
+			expectedSecondaryEntries: func(txn *badger.Txn, key []byte, val []byte) ([]*dbKeyValue, bool, error) {
+				chunks := _dbSplitKey(key, _PrefixPKIDToProfileEntry, btcec.PubKeyBytesLenCompressed)
+				if chunks == nil {
+					return nil, false, fmt.Errorf("invalid key length %d", len(key))
+				}
+				pkid := PublicKeyToPKID(chunks[0])
+				profileEntry := &ProfileEntry{}
+				if err := gob.NewDecoder(bytes.NewReader(val)).Decode(profileEntry); err != nil {
+					return nil, false, err
+				}
+				return []*dbKeyValue{
+					{key: _dbKeyForProfileUsernameToPKID(profileEntry.Username), val: pkid[:]},
+					{key: _dbKeyForCreatorDeSoLockedNanosCreatorPKID(
+						profileEntry.CreatorCoinEntry.DeSoLockedNanos, pkid), val: []byte{}},
+				}, true, nil
+			},
+			isSecondaryBacked: func(txn *badger.Txn, key []byte, val []byte) bool {
+				var pkid *PKID
+				if bytes.HasPrefix(key, _PrefixProfileUsernameToPKID) {
+					if len(val) != btcec.PubKeyBytesLenCompressed {
+						return false
+					}
+					pkid = PublicKeyToPKID(val)
+				} else {
+					chunks := _dbSplitKey(key, _PrefixCreatorDeSoLockedNanosCreatorPKID, 8, btcec.PubKeyBytesLenCompressed)
+					if chunks == nil {
+						return false
+					}
+					pkid = PublicKeyToPKID(chunks[1])
+				}
+				profileEntry := DBGetProfileEntryForPKIDWithTxn(txn, pkid)
+				if profileEntry == nil {
+					return false
+				}
+				// The entry must match the profile's current username and coin.
+				if bytes.HasPrefix(key, _PrefixProfileUsernameToPKID) {
+					return bytes.Equal(key, _dbKeyForProfileUsernameToPKID(profileEntry.Username))
+				}
+				return bytes.Equal(key, _dbKeyForCreatorDeSoLockedNanosCreatorPKID(
+					profileEntry.CreatorCoinEntry.DeSoLockedNanos, pkid))
+			},
+		},
+		_balanceEntryIndexCheck(false /*isDAOCoin*/),
+		_balanceEntryIndexCheck(true /*isDAOCoin*/),
+		{
+			name:              "pkid",
+			primaryPrefix:     _PrefixPublicKeyToPKID,
+			secondaryPrefixes: [][]byte{_PrefixPKIDToPublicKey},
+			expectedSecondaryEntries: func(txn *badger.Txn, key []byte, val []byte) ([]*dbKeyValue, bool, error) {
+				pkidEntry := &PKIDEntry{}
+				if err := gob.NewDecoder(bytes.NewReader(val)).Decode(pkidEntry); err != nil {
+					return nil, false, err
+				}
+				publicKey := key[len(_PrefixPublicKeyToPKID):]
+				return []*dbKeyValue{{
+					key: append(append([]byte{}, _PrefixPKIDToPublicKey...), pkidEntry.PKID[:]...),
+					val: append([]byte{}, publicKey...),
+				}}, true, nil
+			},
+			isSecondaryBacked: func(txn *badger.Txn, key []byte, val []byte) bool {
+				pkidEntry := DBGetPKIDEntryForPublicKeyWithTxn(txn, val)
+				return pkidEntry != nil &&
+					bytes.Equal(pkidEntry.PKID[:], key[len(_PrefixPKIDToPublicKey):])
+			},
+		},
+		{
+			name:              "follow",
+			primaryPrefix:     _PrefixFollowerPKIDToFollowedPKID,
+			secondaryPrefixes: [][]byte{_PrefixFollowedPKIDToFollowerPKID},
+			expectedSecondaryEntries: func(txn *badger.Txn, key []byte, val []byte) ([]*dbKeyValue, bool, error) {
+				chunks := _dbSplitKey(key, _PrefixFollowerPKIDToFollowedPKID, btcec.PubKeyBytesLenCompressed, btcec.PubKeyBytesLenCompressed)
+				if chunks == nil {
+					return nil, false, fmt.Errorf("invalid key length %d", len(key))
+				}
+				return []*dbKeyValue{{
+					key: _dbKeyForFollowedToFollowerMapping(PublicKeyToPKID(chunks[1]), PublicKeyToPKID(chunks[0])),
+					val: []byte{},
+				}}, true, nil
+			},
+			isSecondaryBacked: func(txn *badger.Txn, key []byte, val []byte) bool {
+				chunks := _dbSplitKey(key, _PrefixFollowedPKIDToFollowerPKID, btcec.PubKeyBytesLenCompressed, btcec.PubKeyBytesLenCompressed)
+				return chunks != nil && _dbHasKeyWithTxn(txn, _dbKeyForFollowerToFollowedMapping(
+					PublicKeyToPKID(chunks[1]), PublicKeyToPKID(chunks[0])))
+			},
+		},
+		{
+			name:              "user-block",
+			primaryPrefix:     _PrefixBlockerPKIDToBlockedPKID,
+			secondaryPrefixes: [][]byte{_PrefixBlockedPKIDToBlockerPKID},
+			expectedSecondaryEntries: func(txn *badger.Txn, key []byte, val []byte) ([]*dbKeyValue, bool, error) {
+				chunks := _dbSplitKey(key, _PrefixBlockerPKIDToBlockedPKID, btcec.PubKeyBytesLenCompressed, btcec.PubKeyBytesLenCompressed)
+				if chunks == nil {
+					return nil, false, fmt.Errorf("invalid key length %d", len(key))
+				}
+				return []*dbKeyValue{{
+					key: _dbKeyForBlockedToBlockerMapping(PublicKeyToPKID(chunks[1]), PublicKeyToPKID(chunks[0])),
+					val: val,
+				}}, true, nil
+			},
+			isSecondaryBacked: func(txn *badger.Txn, key []byte, val []byte) bool {
+				chunks := _dbSplitKey(key, _PrefixBlockedPKIDToBlockerPKID, btcec.PubKeyBytesLenCompressed, btcec.PubKeyBytesLenCompressed)
+				return chunks != nil && _dbHasKeyWithTxn(txn, _dbKeyForBlockerToBlockedMapping(
+					PublicKeyToPKID(chunks[1]), PublicKeyToPKID(chunks[0])))
+			},
+		},
+		{
+			name:              "like",
+			primaryPrefix:     _PrefixLikerPubKeyToLikedPostHash,
+			secondaryPrefixes: [][]byte{_PrefixLikedPostHashToLikerPubKey},
+			expectedSecondaryEntries: func(txn *badger.Txn, key []byte, val []byte) ([]*dbKeyValue, bool, error) {
+				chunks := _dbSplitKey(key, _PrefixLikerPubKeyToLikedPostHash, btcec.PubKeyBytesLenCompressed, HashSizeBytes)
+				if chunks == nil {
+					return nil, false, fmt.Errorf("invalid key length %d", len(key))
+				}
+				return []*dbKeyValue{{
+					key: _dbKeyForLikedPostHashToLikerPubKeyMapping(*NewBlockHash(chunks[1]), chunks[0]),
+					val: []byte{},
+				}}, true, nil
+			},
+			isSecondaryBacked: func(txn *badger.Txn, key []byte, val []byte) bool {
+				chunks := _dbSplitKey(key, _PrefixLikedPostHashToLikerPubKey, HashSizeBytes, btcec.PubKeyBytesLenCompressed)
+				return chunks != nil && _dbHasKeyWithTxn(txn, _dbKeyForLikerPubKeyToLikedPostHashMapping(
+					chunks[1], *NewBlockHash(chunks[0])))
+			},
+		},
+		{
+			name:          "nft",
+			primaryPrefix: _PrefixPostHashSerialNumberToNFTEntry,
+			secondaryPrefixes: [][]byte{
+				_PrefixPKIDIsForSaleBidAmountNanosPostHashSerialNumberToNFTEntry,
+				_PrefixAuctionEndBlockHeightPostHashSerialNumber,
+			},
+			expectedSecondaryEntries: func(txn *badger.Txn, key []byte, val []byte) ([]*dbKeyValue, bool, error) {
+				nftEntry := &NFTEntry{}
+				if err := gob.NewDecoder(bytes.NewReader(val)).Decode(nftEntry); err != nil {
+					return nil, false, err
+				}
+				expectedEntries := []*dbKeyValue{{
+					key: _dbKeyForPKIDIsForSaleBidAmountNanosNFTPostHashSerialNumber(
+						nftEntry.OwnerPKID, nftEntry.IsForSale, nftEntry.LastAcceptedBidAmountNanos,
+						nftEntry.NFTPostHash, nftEntry.SerialNumber),
+					val: val,
+				}}
+				if nftEntry.AuctionEndBlockHeight > 0 {
+					expectedEntries = append(expectedEntries, &dbKeyValue{
+						key: _dbKeyForAuctionEndBlockHeightNFTPostHashSerialNumber(
+							nftEntry.AuctionEndBlockHeight, nftEntry.NFTPostHash, nftEntry.SerialNumber),
+						val: []byte{},
+					})
+				}
+				return expectedEntries, true, nil
+			},
+			isSecondaryBacked: func(txn *badger.Txn, key []byte, val []byte) bool {
+				if bytes.HasPrefix(key, _PrefixAuctionEndBlockHeightPostHashSerialNumber) {
+					chunks := _dbSplitKey(key, _PrefixAuctionEndBlockHeightPostHashSerialNumber, 8, HashSizeBytes, 8)
+					if chunks == nil {
+						return false
+					}
+					nftEntry := DBGetNFTEntryByPostHashSerialNumberWithTxn(
+						txn, NewBlockHash(chunks[1]), DecodeUint64(chunks[2]))
+					// The auction must still be running and end at the height in the key.
+					return nftEntry != nil && nftEntry.AuctionEndBlockHeight == DecodeUint64(chunks[0])
+				}
+				chunks := _dbSplitKey(key, _PrefixPKIDIsForSaleBidAmountNanosPostHashSerialNumberToNFTEntry,
+					btcec.PubKeyBytesLenCompressed, 1, 8, HashSizeBytes, 8)
+				if chunks == nil {
+					return false
+				}
+				nftEntry := DBGetNFTEntryByPostHashSerialNumberWithTxn(
+					txn, NewBlockHash(chunks[3]), DecodeUint64(chunks[4]))
+				// The owner, sale status, and price in the key must all be current.
+				return nftEntry != nil && bytes.Equal(key,
+					_dbKeyForPKIDIsForSaleBidAmountNanosNFTPostHashSerialNumber(
+						nftEntry.OwnerPKID, nftEntry.IsForSale, nftEntry.LastAcceptedBidAmountNanos,
+						nftEntry.NFTPostHash, nftEntry.SerialNumber))
+			},
+		},
+		{
+			name:              "nft-bid",
+			primaryPrefix:     _PrefixPostHashSerialNumberBidNanosBidderPKID,
+			secondaryPrefixes: [][]byte{_PrefixBidderPKIDPostHashSerialNumberToBidNanos},
+			expectedSecondaryEntries: func(txn *badger.Txn, key []byte, val []byte) ([]*dbKeyValue, bool, error) {
+				chunks := _dbSplitKey(key, _PrefixPostHashSerialNumberBidNanosBidderPKID,
+					HashSizeBytes, 8, 8, btcec.PubKeyBytesLenCompressed)
+				if chunks == nil {
+					return nil, false, fmt.Errorf("invalid key length %d", len(key))
+				}
+				nftPostHash := NewBlockHash(chunks[0])
+				serialNumber := DecodeUint64(chunks[1])
+				if !_dbNFTBidTargetExistsWithTxn(txn, nftPostHash, serialNumber) {
+					return nil, false, nil
+				}
+				return []*dbKeyValue{{
+					key: _dbKeyForNFTBidderPKIDPostHashSerialNumber(
+						PublicKeyToPKID(chunks[3]), nftPostHash, serialNumber),
+					val: chunks[2],
+				}}, true, nil
+			},
+			isSecondaryBacked: func(txn *badger.Txn, key []byte, val []byte) bool {
+				chunks := _dbSplitKey(key, _PrefixBidderPKIDPostHashSerialNumberToBidNanos,
+					btcec.PubKeyBytesLenCompressed, HashSizeBytes, 8)
+				if chunks == nil || len(val) != 8 {
+					return false
+				}
+				nftPostHash := NewBlockHash(chunks[1])
+				serialNumber := DecodeUint64(chunks[2])
+				bidEntry := &NFTBidEntry{
+					BidderPKID:     PublicKeyToPKID(chunks[0]),
+					NFTPostHash:    nftPostHash,
+					SerialNumber:   serialNumber,
+					BidAmountNanos: DecodeUint64(val),
+				}
+				return _dbNFTBidTargetExistsWithTxn(txn, nftPostHash, serialNumber) &&
+					_dbHasKeyWithTxn(txn, _dbKeyForNFTPostHashSerialNumberBidNanosBidderPKID(bidEntry))
+			},
+		},
+		{
+			name:          "diamond",
+			primaryPrefix: _PrefixDiamondReceiverPKIDDiamondSenderPKIDPostHash,
+			// Missing entries in the post hash index are found from the primary entry,
+			// but since that index doesn't include the receiver, stale entries in it
+			// can't be traced back to a primary entry.
+			secondaryPrefixes: [][]byte{_PrefixDiamondSenderPKIDDiamondReceiverPKIDPostHash},
+			expectedSecondaryEntries: func(txn *badger.Txn, key []byte, val []byte) ([]*dbKeyValue, bool, error) {
+				diamondEntry := &DiamondEntry{}
+				if err := gob.NewDecoder(bytes.NewReader(val)).Decode(diamondEntry); err != nil {
+					return nil, false, err
+				}
+				if !bytes.Equal(key, _dbKeyForDiamondReceiverToDiamondSenderMapping(diamondEntry)) {
+					return nil, false, fmt.Errorf("key doesn't match DiamondEntry %v", diamondEntry)
+				}
+				return []*dbKeyValue{
+					{key: _dbKeyForDiamondSenderToDiamondReceiverMapping(diamondEntry), val: val},
+					{key: _dbKeyForDiamondedPostHashDiamonderPKIDDiamondLevel(diamondEntry), val: []byte{}},
+				}, true, nil
+			},
+			isSecondaryBacked: func(txn *badger.Txn, key []byte, val []byte) bool {
+				chunks := _dbSplitKey(key, _PrefixDiamondSenderPKIDDiamondReceiverPKIDPostHash,
+					btcec.PubKeyBytesLenCompressed, btcec.PubKeyBytesLenCompressed, HashSizeBytes)
+				return chunks != nil && _dbHasKeyWithTxn(txn, _dbKeyForDiamondReceiverToDiamondSenderMappingWithoutEntry(
+					PublicKeyToPKID(chunks[1]), PublicKeyToPKID(chunks[0]), NewBlockHash(chunks[2])))
+			},
+		},
+		{
+			name:              "repost",
+			primaryPrefix:     _PrefixReposterPubKeyRepostedPostHashToRepostPostHash,
+			secondaryPrefixes: [][]byte{_PrefixRepostedPostHashReposterPubKey},
+			expectedSecondaryEntries: func(txn *badger.Txn, key []byte, val []byte) ([]*dbKeyValue, bool, error) {
+				chunks := _dbSplitKey(key, _PrefixReposterPubKeyRepostedPostHashToRepostPostHash,
+					btcec.PubKeyBytesLenCompressed, HashSizeBytes)
+				if chunks == nil {
+					return nil, false, fmt.Errorf("invalid key length %d", len(key))
+				}
+				return []*dbKeyValue{{
+					key: _dbKeyForRepostedPostHashReposterPubKey(NewBlockHash(chunks[1]), chunks[0]),
+					val: []byte{},
+				}}, true, nil
+			},
+			isSecondaryBacked: func(txn *badger.Txn, key []byte, val []byte) bool {
+				chunks := _dbSplitKey(key, _PrefixRepostedPostHashReposterPubKey,
+					HashSizeBytes, btcec.PubKeyBytesLenCompressed)
+				return chunks != nil && _dbHasKeyWithTxn(txn, _dbKeyForReposterPubKeyRepostedPostHashToRepostPostHash(
+					chunks[1], *NewBlockHash(chunks[0])))
+			},
+		},
+		{
+			name:              "messaging-group-member",
+			primaryPrefix:     _PrefixMessagingGroupEntriesByOwnerPubKeyAndGroupKeyName,
+			secondaryPrefixes: [][]byte{_PrefixMessagingGroupMetadataByMemberPubKeyAndGroupMessagingPubKey},
+			expectedSecondaryEntries: func(txn *badger.Txn, key []byte, val []byte) ([]*dbKeyValue, bool, error) {
+				chunks := _dbSplitKey(key, _PrefixMessagingGroupEntriesByOwnerPubKeyAndGroupKeyName,
+					btcec.PubKeyBytesLenCompressed, MaxMessagingKeyNameCharacters)
+				if chunks == nil {
+					return nil, false, fmt.Errorf("invalid key length %d", len(key))
+				}
+				ownerPublicKey := NewPublicKey(chunks[0])
+				groupEntry := &MessagingGroupEntry{}
+				if err := groupEntry.Decode(val); err != nil {
+					return nil, false, err
+				}
+				expectedEntries := []*dbKeyValue{}
+				for _, member := range groupEntry.MessagingGroupMembers {
+					// The owner isn't indexed as a member of their own group.
+					if bytes.Equal(member.GroupMemberPublicKey[:], ownerPublicKey[:]) {
+						continue
+					}
+					// This mirrors the "hacked" entry DBPutMessagingGroupMemberWithTxn stores.
+					memberGroupEntry := MessagingGroupEntry{
+						GroupOwnerPublicKey:   ownerPublicKey,
+						MessagingPublicKey:    groupEntry.MessagingPublicKey,
+						MessagingGroupKeyName: groupEntry.MessagingGroupKeyName,
+						MessagingGroupMembers: []*MessagingGroupMember{member},
+					}
+					expectedEntries = append(expectedEntries, &dbKeyValue{
+						key: _dbKeyForMessagingGroupMember(member.GroupMemberPublicKey, groupEntry.MessagingPublicKey),
+						val: memberGroupEntry.Encode(),
+					})
+				}
+				return expectedEntries, true, nil
+			},
+			isSecondaryBacked: func(txn *badger.Txn, key []byte, val []byte) bool {
+				chunks := _dbSplitKey(key, _PrefixMessagingGroupMetadataByMemberPubKeyAndGroupMessagingPubKey,
+					btcec.PubKeyBytesLenCompressed, btcec.PubKeyBytesLenCompressed)
+				if chunks == nil {
+					return false
+				}
+				memberGroupEntry := &MessagingGroupEntry{}
+				if err := memberGroupEntry.Decode(val); err != nil {
+					return false
+				}
+				groupEntry := DBGetMessagingGroupEntryWithTxn(txn, &MessagingGroupKey{
+					OwnerPublicKey: *memberGroupEntry.GroupOwnerPublicKey,
+					GroupKeyName:   *memberGroupEntry.MessagingGroupKeyName,
+				})
+				if groupEntry == nil || !bytes.Equal(groupEntry.MessagingPublicKey[:], chunks[1]) {
+					return false
+				}
+				for _, member := range groupEntry.MessagingGroupMembers {
+					if bytes.Equal(member.GroupMemberPublicKey[:], chunks[0]) {
+						return true
+					}
+				}
+				return false
+			},
+		},
+		{
+			name:              "nft-collection",
+			primaryPrefix:     _PrefixPostHashToPostEntry,
+			secondaryPrefixes: [][]byte{_PrefixNFTCollectionIDPostHash},
+			// The post is still valid without its collection so it must never
+			// be deleted to fix a missing collection.
+			keepInvalidPrimary: true,
+			expectedSecondaryEntries: func(txn *badger.Txn, key []byte, val []byte) ([]*dbKeyValue, bool, error) {
+				postEntry := &PostEntry{}
+				if err := gob.NewDecoder(bytes.NewReader(val)).Decode(postEntry); err != nil {
+					return nil, false, err
+				}
+				if postEntry.NFTCollectionID == nil {
+					return nil, true, nil
+				}
+				// A post can't be in a collection that doesn't exist.
+				if !_dbHasKeyWithTxn(txn, _dbKeyForNFTCollectionID(postEntry.NFTCollectionID)) {
+					return nil, false, nil
+				}
+				return []*dbKeyValue{{
+					key: _dbKeyForNFTCollectionIDPostHash(postEntry.NFTCollectionID, postEntry.PostHash),
+					val: []byte{},
+				}}, true, nil
+			},
+			isSecondaryBacked: func(txn *badger.Txn, key []byte, val []byte) bool {
+				chunks := _dbSplitKey(key, _PrefixNFTCollectionIDPostHash, HashSizeBytes, HashSizeBytes)
+				if chunks == nil {
+					return false
+				}
+				postEntry := DBGetPostEntryByPostHashWithTxn(txn, NewBlockHash(chunks[1]))
+				return postEntry != nil && postEntry.NFTCollectionID != nil &&
+					bytes.Equal(postEntry.NFTCollectionID[:], chunks[0])
+			},
+		},
+	}
+}
+
+// DbIntegrityUncheckedIndices lists the secondary indices that CheckDbIntegrity
+// doesn't verify, along with why, so that operators know what a clean report
+// does and doesn't cover.
+func DbIntegrityUncheckedIndices() []string {
+	return []string{
+		"post: the poster, timestamp, creator bps, stake multiple, comment and quote repost indices",
+		"private-message: sender and recipient copies share a single prefix",
+		"derived-key: no secondary index",
+		"diamond: stale entries in the post hash index",
+		"txindex: rebuilt by the txindex itself",
+		"block-position-txn: rebuilt with --reindex-block-position-txns",
+		"poll-vote, post-version, username-listing, key-rotation, recovery-guardian, " +
+			"account-recovery, block-producer and price-candle: no secondary index",
+	}
+}
+
+// A bid on serial number zero is a blanket bid on any serial number of the NFT
+// so it only requires the post to exist.
+func _dbNFTBidTargetExistsWithTxn(txn *badger.Txn, nftPostHash *BlockHash, serialNumber uint64) bool {
+	if serialNumber == 0 {
+		return _dbHasKeyWithTxn(txn, _dbKeyForPostEntryHash(nftPostHash))
+	}
+	return _dbHasKeyWithTxn(txn, _dbKeyForNFTPostHashSerialNumber(nftPostHash, serialNumber))
+}
+
+func _runDbIndexPairCheck(handle *badger.DB, check *dbIndexPairCheck) ([]*DbIntegrityIssue, error) {
+	issues := []*DbIntegrityIssue{}
+
+	err := handle.View(func(txn *badger.Txn) error {
+		// Pass 1: Every primary entry should have all of its secondary entries.
+		{
+			opts := badger.DefaultIteratorOptions
+			nodeIterator := txn.NewIterator(opts)
+			defer nodeIterator.Close()
+			for nodeIterator.Seek(check.primaryPrefix); nodeIterator.ValidForPrefix(check.primaryPrefix); nodeIterator.Next() {
+				key := nodeIterator.Item().KeyCopy(nil)
+				val, err := nodeIterator.Item().ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+
+				expectedEntries, isValid, err := check.expectedSecondaryEntries(txn, key, val)
+				if err != nil {
+					issues = append(issues, &DbIntegrityIssue{
+						CheckName: check.name,
+						Type:      DbIntegrityIssueUndecodableEntry,
+						Key:       key,
+						Details:   err.Error(),
+					})
+					continue
+				}
+				if !isValid {
+					issueType := DbIntegrityIssueDanglingEntry
+					if check.keepInvalidPrimary {
+						issueType = DbIntegrityIssueBrokenReference
+					}
+					issues = append(issues, &DbIntegrityIssue{
+						CheckName: check.name,
+						Type:      issueType,
+						Key:       key,
+						Details:   "primary entry references data that doesn't exist",
+					})
+					continue
+				}
+
+				for _, expected := range expectedEntries {
+					foundVal, exists := _dbGetValueWithTxn(txn, expected.key)
+					if !exists {
+						issues = append(issues, &DbIntegrityIssue{
+							CheckName:     check.name,
+							Type:          DbIntegrityIssueMissingEntry,
+							Key:           expected.key,
+							ExpectedValue: expected.val,
+							Details:       fmt.Sprintf("implied by primary key %x", key),
+						})
+					} else if !bytes.Equal(foundVal, expected.val) {
+						issues = append(issues, &DbIntegrityIssue{
+							CheckName:     check.name,
+							Type:          DbIntegrityIssueMismatchedEntry,
+							Key:           expected.key,
+							ExpectedValue: expected.val,
+							Details:       fmt.Sprintf("value differs from primary key %x", key),
+						})
+					}
+				}
+			}
+		}
+
+		// Pass 2: Every secondary entry should be backed by a primary entry.
+		for _, secondaryPrefix := range check.secondaryPrefixes {
+			opts := badger.DefaultIteratorOptions
+			nodeIterator := txn.NewIterator(opts)
+			for nodeIterator.Seek(secondaryPrefix); nodeIterator.ValidForPrefix(secondaryPrefix); nodeIterator.Next() {
+				key := nodeIterator.Item().KeyCopy(nil)
+				val, err := nodeIterator.Item().ValueCopy(nil)
+				if err != nil {
+					nodeIterator.Close()
+					return err
+				}
+				if !check.isSecondaryBacked(txn, key, val) {
+					issues = append(issues, &DbIntegrityIssue{
+						CheckName: check.name,
+						Type:      DbIntegrityIssueDanglingEntry,
+						Key:       key,
+						Details:   "secondary entry has no matching primary entry",
+					})
+				}
+			}
+			nodeIterator.Close()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "_runDbIndexPairCheck: Problem running check %v", check.name)
+	}
+
+	return issues, nil
+}
+
+// _checkChainTipIntegrity makes sure the best block hash points at a validated
+// node in the block index and that we have what we'd need to disconnect it.
+func _checkChainTipIntegrity(handle *badger.DB) ([]*DbIntegrityIssue, error) {
+	bestHash := DbGetBestHash(handle, ChainTypeDeSoBlock)
+	if bestHash == nil {
+		return []*DbIntegrityIssue{{
+			CheckName: "chain-tip",
+			Type:      DbIntegrityIssueInconsistentTip,
+			Key:       _KeyBestDeSoBlockHash,
+			Details:   "no best block hash is set",
+		}}, nil
+	}
+
+	blockIndex, err := GetBlockIndex(handle, false /*bitcoinNodes*/)
+	if err != nil {
+		return nil, errors.Wrapf(err, "_checkChainTipIntegrity: Problem loading block index")
+	}
+	tipNode, exists := blockIndex[*bestHash]
+	if !exists {
+		return []*DbIntegrityIssue{{
+			CheckName: "chain-tip",
+			Type:      DbIntegrityIssueInconsistentTip,
+			Key:       _KeyBestDeSoBlockHash,
+			Details:   fmt.Sprintf("best hash %v is not in the block index", bestHash),
+		}}, nil
+	}
+
+	issues := []*DbIntegrityIssue{}
+	if tipNode.Status&StatusBlockValidated == 0 {
+		issues = append(issues, &DbIntegrityIssue{
+			CheckName: "chain-tip",
+			Type:      DbIntegrityIssueInconsistentTip,
+			Key:       _heightHashToNodeIndexKey(tipNode.Height, bestHash, false /*bitcoinNodes*/),
+			Details:   fmt.Sprintf("tip %v does not have StatusBlockValidated", tipNode),
+		})
+	}
+	if tipNode.Height > 0 {
+		if _, err := GetUtxoOperationsForBlock(handle, bestHash); err != nil {
+			issues = append(issues, &DbIntegrityIssue{
+				CheckName: "chain-tip",
+				Type:      DbIntegrityIssueInconsistentTip,
+				Key:       _DbKeyForUtxoOps(bestHash),
+				Details:   fmt.Sprintf("tip %v has no UtxoOperations", tipNode),
+			})
+		}
+	}
+	return issues, nil
+}
+
+// CheckDbIntegrity runs every integrity check against the db and returns all of
+// the issues it finds. The node should not be running while this is called.
+func CheckDbIntegrity(handle *badger.DB) ([]*DbIntegrityIssue, error) {
+	issues, err := _checkChainTipIntegrity(handle)
+	if err != nil {
+		return nil, errors.Wrapf(err, "CheckDbIntegrity: ")
+	}
+
+	for _, check := range _dbIndexPairChecks() {
+		glog.Infof("CheckDbIntegrity: Running check %v", check.name)
+		checkIssues, err := _runDbIndexPairCheck(handle, check)
+		if err != nil {
+			return nil, errors.Wrapf(err, "CheckDbIntegrity: ")
+		}
+		glog.Infof("CheckDbIntegrity: Check %v found %d issues", check.name, len(checkIssues))
+		issues = append(issues, checkIssues...)
+	}
+
+	return issues, nil
+}
+
+// The maximum number of fixes we apply in a single badger transaction.
+const maxDbIntegrityFixesPerTxn = 1000
+
+// RepairDbIntegrityIssues rebuilds secondary index entries from their primary
+// entries and deletes dangling entries. Issues that can't be repaired are
+// skipped. It returns the number of issues that were repaired.
+func RepairDbIntegrityIssues(handle *badger.DB, issues []*DbIntegrityIssue) (int, error) {
+	repairable := []*DbIntegrityIssue{}
+	for _, issue := range issues {
+		if issue.IsRepairable() {
+			repairable = append(repairable, issue)
+		}
+	}
+
+	numRepaired := 0
+	for len(repairable) > 0 {
+		batchSize := len(repairable)
+		if batchSize > maxDbIntegrityFixesPerTxn {
+			batchSize = maxDbIntegrityFixesPerTxn
+		}
+		batch := repairable[:batchSize]
+		repairable = repairable[batchSize:]
+
+		err := handle.Update(func(txn *badger.Txn) error {
+			for _, issue := range batch {
+				if issue.Type == DbIntegrityIssueDanglingEntry {
+					if err := txn.Delete(issue.Key); err != nil {
+						return err
+					}
+				} else {
+					if err := txn.Set(issue.Key, issue.ExpectedValue); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return numRepaired, errors.Wrapf(err, "RepairDbIntegrityIssues: Problem applying fixes")
+		}
+		numRepaired += len(batch)
+	}
+
+	return numRepaired, nil
+}
+
+// SummarizeDbIntegrityIssues returns a human-readable count of issues by check and type.
+func SummarizeDbIntegrityIssues(issues []*DbIntegrityIssue) string {
+	counts := make(map[string]int)
+	order := []string{}
+	for _, issue := range issues {
+		summaryKey := fmt.Sprintf("%s %v", issue.CheckName, issue.Type)
+		if _, exists := counts[summaryKey]; !exists {
+			order = append(order, summaryKey)
+		}
+		counts[summaryKey]++
+	}
+
+	lines := []string{}
+	for _, summaryKey := range order {
+		lines = append(lines, fmt.Sprintf("%s: %d", summaryKey, counts[summaryKey]))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -285,10 +285,113 @@ var (
 	// <prefix, OwnerPublicKey [33]byte, GroupMessagingPublicKey [33]byte> -> <HackedMessagingKeyEntry>
 	_PrefixMessagingGroupMetadataByMemberPubKeyAndGroupMessagingPubKey = []byte{58}
 
+	// When running in pruned mode, this key stores the height below which block
+	// bodies and UtxoOperations have been deleted. Blocks at or above this height
+	// are still available. Nodes that have never pruned don't have this key set.
+	// Value format: uint64
+	_KeyPrunedBlockHeight = []byte{59}
+
+	// Main chain transactions in canonical order. This lets indexers scan every
+	// transaction between two heights in order. The txn bytes are stored in the
+	// value so that iterating never has to load and decode the full block. Entries
+	// are added when a block is connected and removed when it is disconnected or
+	// when its body is pruned. The TxnType is stored first so that callers can
+	// filter without decoding the txn.
+	// <prefix, height uint64, txnIndexInBlock uint32> -> <TxnType uint8, TxnBytes []byte>
+	_PrefixBlockHeightTxnIndexToTxn = []byte{60}
+
+	// The edit history of a post. Nothing is stored until a post is first edited,
+	// at which point the original content is stored as version zero and the new
+	// content as version one. Every later edit appends another version.
+	// <prefix, PostHash [32]byte, Version uint64> -> <PostVersionEntry>
+	_PrefixPostHashVersionToPostVersionEntry = []byte{61}
+
+	// Polls declared by posts, including their running tallies.
+	// <prefix, PostHash [32]byte> -> <PollEntry>
+	_PrefixPostHashToPollEntry = []byte{62}
+
+	// Votes cast in a poll, one per PKID.
+	// <prefix, PostHash [32]byte, VoterPKID [33]byte> -> <PollVoteEntry>
+	_PrefixPollPostHashVoterPKIDToPollVoteEntry = []byte{63}
+
+	// Prefixes for user blocks and mutes. The value is the UserBlockType.
+	// <prefix, blocker PKID [33]byte, blocked PKID [33]byte> -> <UserBlockType>
+	// <prefix, blocked PKID [33]byte, blocker PKID [33]byte> -> <UserBlockType>
+	_PrefixBlockerPKIDToBlockedPKID = []byte{64}
+	_PrefixBlockedPKIDToBlockerPKID = []byte{65}
+
+	// Prefix for message read markers. The thread public key is the counterparty's
+	// main public key for a direct message thread, or the group's messaging public
+	// key for a group chat.
+	// <prefix, reader public key [33]byte, thread public key [33]byte> -> <LastReadTstampNanos uint64>
+	_PrefixReaderPubKeyThreadPubKeyToLastReadTstamp = []byte{66}
+
+	// Prefix for NFTs that are up for auction, ordered by the height at which the
+	// auction ends so that the auctions that need to be settled can be found quickly.
+	// <prefix, AuctionEndBlockHeight uint64, NFTPostHash [32]byte, SerialNumber uint64> -> <>
+	_PrefixAuctionEndBlockHeightPostHashSerialNumber = []byte{67}
+
+	// Prefixes for NFT collections. The collection ID is the hash of the
+	// transaction that created the collection.
+	// <prefix, CollectionID [32]byte> -> <NFTCollectionEntry>
+	// <prefix, CollectionID [32]byte, NFTPostHash [32]byte> -> <>
+	_PrefixNFTCollectionIDToNFTCollectionEntry = []byte{68}
+	_PrefixNFTCollectionIDPostHash             = []byte{69}
+
+	// Prefix for the redemptions of lazily minted NFT vouchers. An entry is kept
+	// even after the minted copy is burned so that the voucher can't be replayed.
+	// <prefix, NFTPostHash [32]byte, SerialNumber uint64> -> <NFTVoucherRedemptionEntry>
+	_PrefixPostHashSerialNumberToNFTVoucherRedemptionEntry = []byte{70}
+
+	// Prefix for the block producers that have registered on-chain. An entry is
+	// kept after its producer deregisters so that its missed slots aren't lost.
+	// <prefix, PublicKey [33]byte> -> <BlockProducerEntry>
+	_PrefixPublicKeyToBlockProducerEntry = []byte{71}
+
+	// Prefix for the Bitcoin headers on the node's best Bitcoin header chain. Used
+	// with _PrefixBitcoinHeightHashToNodeInfo to look up a Bitcoin header by its
+	// hash and check that it hasn't been reorged out when validating BitcoinExchange
+	// burns. Updated whenever the Bitcoin header tip changes.
+	// <prefix, BitcoinBlockHash [32]byte> -> <Height uint32>
+	_PrefixBitcoinBestChainHashToHeight = []byte{72}
+
+	// Per-block OHLC price candles for creator coins. A candle is only stored for
+	// blocks in which a buy, sell or diamond transfer changed the coin.
+	// <prefix, CreatorPKID [33]byte, BlockHeight uint32> -> <CreatorCoinPriceCandleEntry>
+	_PrefixCreatorPKIDBlockHeightToPriceCandle = []byte{73}
+
+	// Prefix for usernames that their owners have listed for sale or offered to
+	// another user. The username is lowercased like in _PrefixProfileUsernameToPKID.
+	// <prefix, lowercase username []byte> -> <UsernameListingEntry>
+	_PrefixUsernameToUsernameListingEntry = []byte{74}
+
+	// Prefix for key rotations that are waiting on their timelock, keyed by the
+	// public key being rotated away from.
+	// <prefix, OldPublicKey [33]byte> -> <KeyRotationEntry>
+	_PrefixPublicKeyToPendingKeyRotationEntry = []byte{75}
+
+	// Prefix for the guardians that can recover an account.
+	// <prefix, OwnerPKID [33]byte> -> <RecoveryGuardianSetEntry>
+	_PrefixPKIDToRecoveryGuardianSetEntry = []byte{76}
+
+	// Prefix for account recoveries started by guardians that are waiting on
+	// the owner's delay.
+	// <prefix, OwnerPKID [33]byte> -> <AccountRecoveryEntry>
+	_PrefixPKIDToPendingAccountRecoveryEntry = []byte{77}
+
+	// Prefix for the minimum timelock an account has committed to for rotating its key.
+	// <prefix, OwnerPKID [33]byte> -> <KeyRotationTimelockEntry>
+	_PrefixPKIDToKeyRotationTimelockEntry = []byte{78}
+
+	// Prefix for public keys that were rotated away from their account, and the PKID they
+	// were rotated away from.
+	// <prefix, OldPublicKey [33]byte> -> <RotatedPublicKeyEntry>
+	_PrefixPublicKeyToRotatedPublicKeyEntry = []byte{79}
+
 	// TODO: This process is a bit error-prone. We should come up with a test or
 	// something to at least catch cases where people have two prefixes with the
 	// same ID.
-	// NEXT_TAG: 59
+	// NEXT_TAG: 80
 )
 
 func DBGetPKIDEntryForPublicKeyWithTxn(txn *badger.Txn, publicKey []byte) *PKIDEntry {
@@ -1194,6 +1297,97 @@ func DBDeleteMessagingGroupMemberMappings(
 	})
 }
 
+// -------------------------------------------------------------------------------------
+// Message read marker mapping functions
+// <prefix, reader public key [33]byte, thread public key [33]byte> -> <LastReadTstampNanos uint64>
+// -------------------------------------------------------------------------------------
+
+func _dbKeyForMessageReadEntry(readerPublicKey *PublicKey, threadPublicKey *PublicKey) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	prefixCopy := append([]byte{}, _PrefixReaderPubKeyThreadPubKeyToLastReadTstamp...)
+	key := append(prefixCopy, readerPublicKey[:]...)
+	key = append(key, threadPublicKey[:]...)
+	return key
+}
+
+func _dbSeekPrefixForMessageReadEntries(readerPublicKey *PublicKey) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	prefixCopy := append([]byte{}, _PrefixReaderPubKeyThreadPubKeyToLastReadTstamp...)
+	return append(prefixCopy, readerPublicKey[:]...)
+}
+
+func DbPutMessageReadEntryWithTxn(txn *badger.Txn, messageReadEntry *MessageReadEntry) error {
+	if err := txn.Set(_dbKeyForMessageReadEntry(
+		messageReadEntry.ReaderPublicKey, messageReadEntry.ThreadPublicKey),
+		EncodeUint64(messageReadEntry.LastReadTstampNanos)); err != nil {
+
+		return errors.Wrapf(err, "DbPutMessageReadEntryWithTxn: Problem adding "+
+			"mapping for %v: ", messageReadEntry)
+	}
+	return nil
+}
+
+func DbGetMessageReadEntryWithTxn(
+	txn *badger.Txn, readerPublicKey *PublicKey, threadPublicKey *PublicKey) *MessageReadEntry {
+
+	tstampBytes, exists := _dbGetValueWithTxn(txn, _dbKeyForMessageReadEntry(readerPublicKey, threadPublicKey))
+	if !exists || len(tstampBytes) != 8 {
+		return nil
+	}
+
+	return &MessageReadEntry{
+		ReaderPublicKey:     NewPublicKey(readerPublicKey[:]),
+		ThreadPublicKey:     NewPublicKey(threadPublicKey[:]),
+		LastReadTstampNanos: DecodeUint64(tstampBytes),
+	}
+}
+
+func DbGetMessageReadEntry(
+	db *badger.DB, readerPublicKey *PublicKey, threadPublicKey *PublicKey) *MessageReadEntry {
+
+	var ret *MessageReadEntry
+	db.View(func(txn *badger.Txn) error {
+		ret = DbGetMessageReadEntryWithTxn(txn, readerPublicKey, threadPublicKey)
+		return nil
+	})
+	return ret
+}
+
+func DbDeleteMessageReadEntryWithTxn(
+	txn *badger.Txn, readerPublicKey *PublicKey, threadPublicKey *PublicKey) error {
+
+	if err := txn.Delete(_dbKeyForMessageReadEntry(readerPublicKey, threadPublicKey)); err != nil {
+		return errors.Wrapf(err, "DbDeleteMessageReadEntryWithTxn: Deleting "+
+			"reader %s and thread %s failed",
+			PkToStringMainnet(readerPublicKey[:]), PkToStringMainnet(threadPublicKey[:]))
+	}
+	return nil
+}
+
+// DbGetMessageReadEntriesForReader returns the read markers for every thread
+// the reader has marked as read.
+func DbGetMessageReadEntriesForReader(handle *badger.DB, readerPublicKey *PublicKey) (
+	_messageReadEntries []*MessageReadEntry, _err error) {
+
+	keysFound, valsFound := _enumerateKeysForPrefix(handle, _dbSeekPrefixForMessageReadEntries(readerPublicKey))
+
+	messageReadEntries := []*MessageReadEntry{}
+	for ii, keyBytes := range keysFound {
+		if len(valsFound[ii]) != 8 {
+			return nil, fmt.Errorf("DbGetMessageReadEntriesForReader: Invalid value %v "+
+				"for key %v", valsFound[ii], keyBytes)
+		}
+		// We must slice off the first byte and the reader's public key to get the thread.
+		messageReadEntries = append(messageReadEntries, &MessageReadEntry{
+			ReaderPublicKey:     NewPublicKey(readerPublicKey[:]),
+			ThreadPublicKey:     NewPublicKey(keyBytes[1+btcec.PubKeyBytesLenCompressed:]),
+			LastReadTstampNanos: DecodeUint64(valsFound[ii]),
+		})
+	}
+
+	return messageReadEntries, nil
+}
+
 // -------------------------------------------------------------------------------------
 // Forbidden block signature public key functions
 // <prefix, public key> -> <>
@@ -1573,6 +1767,153 @@ func DbGetPostHashesYouRepost(handle *badger.DB, yourPublicKey []byte) (
 	return postHashesYouRepost, nil
 }
 
+// -------------------------------------------------------------------------------------
+// User block mapping functions
+// 		<prefix, blocker PKID [33]byte, blocked PKID [33]byte> -> <UserBlockType>
+// 		<prefix, blocked PKID [33]byte, blocker PKID [33]byte> -> <UserBlockType>
+// -------------------------------------------------------------------------------------
+
+func _dbKeyForBlockerToBlockedMapping(
+	blockerPKID *PKID, blockedPKID *PKID) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	prefixCopy := append([]byte{}, _PrefixBlockerPKIDToBlockedPKID...)
+	key := append(prefixCopy, blockerPKID[:]...)
+	key = append(key, blockedPKID[:]...)
+	return key
+}
+
+func _dbKeyForBlockedToBlockerMapping(
+	blockedPKID *PKID, blockerPKID *PKID) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	prefixCopy := append([]byte{}, _PrefixBlockedPKIDToBlockerPKID...)
+	key := append(prefixCopy, blockedPKID[:]...)
+	key = append(key, blockerPKID[:]...)
+	return key
+}
+
+func _dbSeekPrefixForPKIDsYouBlock(yourPKID *PKID) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	prefixCopy := append([]byte{}, _PrefixBlockerPKIDToBlockedPKID...)
+	return append(prefixCopy, yourPKID[:]...)
+}
+
+func _dbSeekPrefixForPKIDsBlockingYou(yourPKID *PKID) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	prefixCopy := append([]byte{}, _PrefixBlockedPKIDToBlockerPKID...)
+	return append(prefixCopy, yourPKID[:]...)
+}
+
+// Note that this adds a mapping for the blocker *and* the PKID being blocked.
+func DbPutUserBlockMappingsWithTxn(txn *badger.Txn, userBlockEntry *UserBlockEntry) error {
+	if len(userBlockEntry.BlockerPKID) != btcec.PubKeyBytesLenCompressed {
+		return fmt.Errorf("DbPutUserBlockMappingsWithTxn: Blocker PKID "+
+			"length %d != %d", len(userBlockEntry.BlockerPKID), btcec.PubKeyBytesLenCompressed)
+	}
+	if len(userBlockEntry.BlockedPKID) != btcec.PubKeyBytesLenCompressed {
+		return fmt.Errorf("DbPutUserBlockMappingsWithTxn: Blocked PKID "+
+			"length %d != %d", len(userBlockEntry.BlockedPKID), btcec.PubKeyBytesLenCompressed)
+	}
+
+	blockTypeBytes := []byte{byte(userBlockEntry.BlockType)}
+	if err := txn.Set(_dbKeyForBlockerToBlockedMapping(
+		userBlockEntry.BlockerPKID, userBlockEntry.BlockedPKID), blockTypeBytes); err != nil {
+
+		return errors.Wrapf(
+			err, "DbPutUserBlockMappingsWithTxn: Problem adding blocker to blocked mapping: ")
+	}
+	if err := txn.Set(_dbKeyForBlockedToBlockerMapping(
+		userBlockEntry.BlockedPKID, userBlockEntry.BlockerPKID), blockTypeBytes); err != nil {
+
+		return errors.Wrapf(
+			err, "DbPutUserBlockMappingsWithTxn: Problem adding blocked to blocker mapping: ")
+	}
+
+	return nil
+}
+
+func DbGetUserBlockEntryWithTxn(
+	txn *badger.Txn, blockerPKID *PKID, blockedPKID *PKID) *UserBlockEntry {
+
+	blockTypeBytes, exists := _dbGetValueWithTxn(
+		txn, _dbKeyForBlockerToBlockedMapping(blockerPKID, blockedPKID))
+	if !exists || len(blockTypeBytes) != 1 {
+		return nil
+	}
+
+	return &UserBlockEntry{
+		BlockerPKID: blockerPKID.NewPKID(),
+		BlockedPKID: blockedPKID.NewPKID(),
+		BlockType:   UserBlockType(blockTypeBytes[0]),
+	}
+}
+
+func DbGetUserBlockEntry(db *badger.DB, blockerPKID *PKID, blockedPKID *PKID) *UserBlockEntry {
+	var ret *UserBlockEntry
+	db.View(func(txn *badger.Txn) error {
+		ret = DbGetUserBlockEntryWithTxn(txn, blockerPKID, blockedPKID)
+		return nil
+	})
+	return ret
+}
+
+// Note this deletes the block for the blocker *and* blocked since a mapping
+// should exist for each.
+func DbDeleteUserBlockMappingsWithTxn(
+	txn *badger.Txn, blockerPKID *PKID, blockedPKID *PKID) error {
+
+	if err := txn.Delete(_dbKeyForBlockerToBlockedMapping(blockerPKID, blockedPKID)); err != nil {
+		return errors.Wrapf(err, "DbDeleteUserBlockMappingsWithTxn: Deleting "+
+			"blockerPKID %s and blockedPKID %s failed",
+			PkToStringMainnet(blockerPKID[:]), PkToStringMainnet(blockedPKID[:]))
+	}
+	if err := txn.Delete(_dbKeyForBlockedToBlockerMapping(blockedPKID, blockerPKID)); err != nil {
+		return errors.Wrapf(err, "DbDeleteUserBlockMappingsWithTxn: Deleting "+
+			"blockedPKID %s and blockerPKID %s failed",
+			PkToStringMainnet(blockedPKID[:]), PkToStringMainnet(blockerPKID[:]))
+	}
+
+	return nil
+}
+
+// DbGetUserBlockEntriesForPKID returns the blocks and mutes made by a PKID, or the
+// blocks and mutes made against it if getEntriesBlockingPKID is set.
+func DbGetUserBlockEntriesForPKID(handle *badger.DB, pkid *PKID, getEntriesBlockingPKID bool) (
+	_userBlockEntries []*UserBlockEntry, _err error) {
+
+	var prefix []byte
+	if getEntriesBlockingPKID {
+		prefix = _dbSeekPrefixForPKIDsBlockingYou(pkid)
+	} else {
+		prefix = _dbSeekPrefixForPKIDsYouBlock(pkid)
+	}
+	keysFound, valsFound := _enumerateKeysForPrefix(handle, prefix)
+
+	userBlockEntries := []*UserBlockEntry{}
+	for ii, keyBytes := range keysFound {
+		if len(valsFound[ii]) != 1 {
+			return nil, fmt.Errorf("DbGetUserBlockEntriesForPKID: Invalid value %v "+
+				"for key %v", valsFound[ii], keyBytes)
+		}
+		// We must slice off the first byte and our PKID to get the other PKID.
+		otherPKID := &PKID{}
+		copy(otherPKID[:], keyBytes[1+btcec.PubKeyBytesLenCompressed:])
+
+		userBlockEntry := &UserBlockEntry{
+			BlockType: UserBlockType(valsFound[ii][0]),
+		}
+		if getEntriesBlockingPKID {
+			userBlockEntry.BlockerPKID = otherPKID
+			userBlockEntry.BlockedPKID = pkid.NewPKID()
+		} else {
+			userBlockEntry.BlockerPKID = pkid.NewPKID()
+			userBlockEntry.BlockedPKID = otherPKID
+		}
+		userBlockEntries = append(userBlockEntries, userBlockEntry)
+	}
+
+	return userBlockEntries, nil
+}
+
 // -------------------------------------------------------------------------------------
 // Follows mapping functions
 // 		<prefix, follower pub key [33]byte, followed pub key [33]byte> -> <>
@@ -2265,6 +2606,39 @@ func DbGetNanosPurchased(handle *badger.DB) uint64 {
 	return nanosPurchased
 }
 
+func DbPutPrunedBlockHeightWithTxn(txn *badger.Txn, prunedHeight uint64) error {
+	return txn.Set(_KeyPrunedBlockHeight, EncodeUint64(prunedHeight))
+}
+
+func DbGetPrunedBlockHeightWithTxn(txn *badger.Txn) uint64 {
+	prunedHeightItem, err := txn.Get(_KeyPrunedBlockHeight)
+	if err != nil {
+		return 0
+	}
+	prunedHeightBuf, err := prunedHeightItem.ValueCopy(nil)
+	if err != nil {
+		return 0
+	}
+
+	return DecodeUint64(prunedHeightBuf)
+}
+
+func DbGetPrunedBlockHeight(handle *badger.DB) uint64 {
+	var prunedHeight uint64
+	handle.View(func(txn *badger.Txn) error {
+		prunedHeight = DbGetPrunedBlockHeightWithTxn(txn)
+		return nil
+	})
+
+	return prunedHeight
+}
+
+// DeleteBlockBodyWithTxn removes the body of a block without touching its
+// BlockNode or its block reward index entries. It is used by pruned nodes.
+func DeleteBlockBodyWithTxn(txn *badger.Txn, blockHash *BlockHash) error {
+	return txn.Delete(BlockHashToBlockKey(blockHash))
+}
+
 func DbPutGlobalParamsEntry(handle *badger.DB, globalParamsEntry GlobalParamsEntry) error {
 	return handle.Update(func(txn *badger.Txn) error {
 		return DbPutGlobalParamsEntryWithTxn(txn, globalParamsEntry)
@@ -2919,6 +3293,124 @@ func PutBlock(desoBlock *MsgDeSoBlock, handle *badger.DB) error {
 	return nil
 }
 
+func _dbKeyForBlockPositionTxn(height uint64, txnIndex uint32) []byte {
+	key := append([]byte{}, _PrefixBlockHeightTxnIndexToTxn...)
+	key = append(key, EncodeUint64(height)...)
+	key = append(key, _EncodeUint32(txnIndex)...)
+	return key
+}
+
+// DbPutBlockPositionTxnsWithTxn adds every txn in the block to the
+// (height, txn index) position index. It should be called when the block is
+// connected to the main chain.
+func DbPutBlockPositionTxnsWithTxn(txn *badger.Txn, desoBlock *MsgDeSoBlock) error {
+	for txnIndex, desoTxn := range desoBlock.Txns {
+		txnBytes, err := desoTxn.ToBytes(false /*preSignature*/)
+		if err != nil {
+			return errors.Wrapf(err, "DbPutBlockPositionTxnsWithTxn: Problem "+
+				"serializing txn %d at height %d", txnIndex, desoBlock.Header.Height)
+		}
+		val := append([]byte{byte(desoTxn.TxnMeta.GetTxnType())}, txnBytes...)
+		key := _dbKeyForBlockPositionTxn(desoBlock.Header.Height, uint32(txnIndex))
+		if err := txn.Set(key, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func DbPutBlockPositionTxns(handle *badger.DB, desoBlock *MsgDeSoBlock) error {
+	return handle.Update(func(txn *badger.Txn) error {
+		return DbPutBlockPositionTxnsWithTxn(txn, desoBlock)
+	})
+}
+
+// DbDeleteBlockPositionTxnsWithTxn removes the block's txns from the
+// (height, txn index) position index. It should be called when the block is
+// disconnected from the main chain.
+func DbDeleteBlockPositionTxnsWithTxn(txn *badger.Txn, desoBlock *MsgDeSoBlock) error {
+	for txnIndex := range desoBlock.Txns {
+		key := _dbKeyForBlockPositionTxn(desoBlock.Header.Height, uint32(txnIndex))
+		if err := txn.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DbDeleteBlockPositionTxnsForHeightWithTxn removes every entry at the given
+// height. Unlike DbDeleteBlockPositionTxnsWithTxn it doesn't need the block,
+// which is what we want when the block's body is being pruned.
+func DbDeleteBlockPositionTxnsForHeightWithTxn(txn *badger.Txn, height uint64) error {
+	prefix := append([]byte{}, _PrefixBlockHeightTxnIndexToTxn...)
+	prefix = append(prefix, EncodeUint64(height)...)
+	keysToDelete, _, err := _enumerateKeysForPrefixWithTxn(txn, prefix)
+	if err != nil {
+		return errors.Wrapf(err, "DbDeleteBlockPositionTxnsForHeightWithTxn: ")
+	}
+	for _, key := range keysToDelete {
+		if err := txn.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DbEnumerateBlockPositionTxns calls fn on every main chain txn with a height in
+// [startHeight, endHeight], in (height, txn index) order. If txnTypes is non-empty,
+// only txns of those types are decoded and passed to fn. Iteration stops early
+// if fn returns false or an error. Only the index values are read, never the
+// blocks. Heights whose bodies have been pruned have no entries.
+func DbEnumerateBlockPositionTxns(handle *badger.DB, startHeight uint64, endHeight uint64,
+	txnTypes []TxnType, fn func(height uint64, txnIndex uint32, desoTxn *MsgDeSoTxn) (bool, error)) error {
+
+	typeFilter := make(map[TxnType]bool)
+	for _, txnType := range txnTypes {
+		typeFilter[txnType] = true
+	}
+
+	return handle.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		nodeIterator := txn.NewIterator(opts)
+		defer nodeIterator.Close()
+		prefix := _PrefixBlockHeightTxnIndexToTxn
+		for nodeIterator.Seek(_dbKeyForBlockPositionTxn(startHeight, 0)); nodeIterator.ValidForPrefix(prefix); nodeIterator.Next() {
+			key := nodeIterator.Item().Key()
+			height := DecodeUint64(key[len(prefix) : len(prefix)+8])
+			if height > endHeight {
+				break
+			}
+			txnIndex := DecodeUint32(key[len(prefix)+8:])
+
+			val, err := nodeIterator.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if len(val) < 1 {
+				return fmt.Errorf("DbEnumerateBlockPositionTxns: Empty value "+
+					"for height %d txn index %d", height, txnIndex)
+			}
+			if len(typeFilter) > 0 && !typeFilter[TxnType(val[0])] {
+				continue
+			}
+			desoTxn := NewMessage(MsgTypeTxn).(*MsgDeSoTxn)
+			if err := desoTxn.FromBytes(val[1:]); err != nil {
+				return errors.Wrapf(err, "DbEnumerateBlockPositionTxns: Problem "+
+					"decoding txn at height %d txn index %d", height, txnIndex)
+			}
+
+			keepGoing, err := fn(height, txnIndex, desoTxn)
+			if err != nil {
+				return err
+			}
+			if !keepGoing {
+				break
+			}
+		}
+		return nil
+	})
+}
+
 func DbGetBlockRewardForPublicKeyBlockHashWithTxn(txn *badger.Txn, publicKey []byte, blockHash *BlockHash,
 ) (_balance uint64, _err error) {
 	key := PublicKeyBlockHashToBlockRewardKey(publicKey, blockHash)
@@ -3089,6 +3581,10 @@ func InitDbWithDeSoGenesisBlock(params *DeSoParams, handle *badger.DB, eventMana
 	if err := PutBlock(genesisBlock, handle); err != nil {
 		return errors.Wrapf(err, "InitDbWithGenesisBlock: Problem putting genesis block into db")
 	}
+	// Add the genesis block's txns to the (height, txn index -> txn hash) index.
+	if err := DbPutBlockPositionTxns(handle, genesisBlock); err != nil {
+		return errors.Wrapf(err, "InitDbWithGenesisBlock: Problem putting genesis txns into db")
+	}
 	// Add the genesis block to the (height, hash -> node info) index in the db.
 	if err := PutHeightHashToNodeInfo(genesisNode, handle, false /*bitcoinNodes*/); err != nil {
 		return errors.Wrapf(err, "InitDbWithGenesisBlock: Problem putting (height, hash -> node) in db")
@@ -3644,6 +4140,34 @@ type FollowTxindexMetadata struct {
 
 	IsUnfollow bool
 }
+type UserBlockTxindexMetadata struct {
+	// BlockerPublicKeyBase58Check = TransactorPublicKeyBase58Check
+	BlockedPublicKeyBase58Check string
+	BlockType                   string
+	IsUnblock                   bool
+}
+type MessagingGroupUpdateTxindexMetadata struct {
+	// The updater is the TransactorPublicKeyBase58Check. Removed members and changed
+	// admins are in AffectedPublicKeys.
+	GroupOwnerPublicKeyBase58Check string
+	MessagingGroupKeyName          string
+
+	// PrevMessagingPublicKeyBase58Check and NewMessagingPublicKeyBase58Check differ
+	// when the group is re-keyed, which leaves an audit trail of the group's keys.
+	PrevMessagingPublicKeyBase58Check string
+	NewMessagingPublicKeyBase58Check  string
+
+	// Members that were added, or given the new key when the group was re-keyed.
+	MemberPublicKeysBase58Check        []string
+	RemovedMemberPublicKeysBase58Check []string
+	AddedAdminPublicKeysBase58Check    []string
+	RemovedAdminPublicKeysBase58Check  []string
+}
+type MessageReadTxindexMetadata struct {
+	// ReaderPublicKeyBase58Check = TransactorPublicKeyBase58Check
+	ThreadPublicKeyBase58Check string
+	LastReadTstampNanos        uint64
+}
 type PrivateMessageTxindexMetadata struct {
 	// SenderPublicKeyBase58Check = TransactorPublicKeyBase58Check
 	// RecipientPublicKeyBase58Check in AffectedPublicKeys
@@ -3696,6 +4220,84 @@ type CreateNFTTxindexMetadata struct {
 	NFTPostHashHex             string
 	AdditionalCoinRoyaltiesMap map[string]uint64 `json:",omitempty"`
 	AdditionalDESORoyaltiesMap map[string]uint64 `json:",omitempty"`
+	NFTCollectionIDHex         string            `json:",omitempty"`
+}
+
+type CreateNFTCollectionTxindexMetadata struct {
+	// CreatorPublicKeyBase58Check = TransactorPublicKeyBase58Check
+	// The collection ID is the hash of the transaction.
+	NFTCollectionIDHex         string
+	Name                       string
+	MaxSupply                  uint64
+	AdditionalCoinRoyaltiesMap map[string]uint64 `json:",omitempty"`
+	AdditionalDESORoyaltiesMap map[string]uint64 `json:",omitempty"`
+}
+
+type NFTVaultTxindexMetadata struct {
+	NFTPostHashHex        string
+	SerialNumber          uint64
+	OperationType         string
+	TotalSharesNanos      uint256.Int
+	BuyoutPriceNanos      uint64
+	SharesToTransferNanos uint256.Int
+	// ReceiverPublicKeyBase58Check in AffectedPublicKeys
+}
+
+type RedeemNFTVoucherTxindexMetadata struct {
+	// RedeemerPublicKeyBase58Check = TransactorPublicKeyBase58Check
+	// CreatorPublicKeyBase58Check in AffectedPublicKeys
+	NFTPostHashHex string
+	SerialNumber   uint64
+	PriceNanos     uint64
+}
+
+type RegisterBlockProducerTxindexMetadata struct {
+	// BlockProducerPublicKeyBase58Check = TransactorPublicKeyBase58Check
+	BondNanos uint64
+}
+
+type DeregisterBlockProducerTxindexMetadata struct {
+	// BlockProducerPublicKeyBase58Check = TransactorPublicKeyBase58Check
+	UnbondingNanos uint64
+}
+
+type ClaimBlockProducerBondTxindexMetadata struct {
+	// BlockProducerPublicKeyBase58Check = TransactorPublicKeyBase58Check
+	BondRefundNanos uint64
+}
+
+type UsernameListingTxindexMetadata struct {
+	// OwnerPublicKeyBase58Check = TransactorPublicKeyBase58Check
+	Username            string
+	ReplacementUsername string
+	PriceNanos          uint64
+	IsRemoved           bool
+}
+
+type AcceptUsernameListingTxindexMetadata struct {
+	// BuyerPublicKeyBase58Check = TransactorPublicKeyBase58Check
+	Username   string
+	PriceNanos uint64
+}
+
+type KeyRotationTxindexMetadata struct {
+	// OldPublicKeyBase58Check = TransactorPublicKeyBase58Check
+	NewPublicKeyBase58Check string
+	OperationType           string
+	TimelockBlocks          uint64
+}
+
+type RecoveryGuardiansTxindexMetadata struct {
+	// OwnerPublicKeyBase58Check = TransactorPublicKeyBase58Check
+	GuardianPublicKeysBase58Check []string
+	Threshold                     uint64
+	DelayBlocks                   uint64
+}
+
+type AccountRecoveryTxindexMetadata struct {
+	OwnerPublicKeyBase58Check string
+	NewPublicKeyBase58Check   string
+	OperationType             string
 }
 
 type UpdateNFTTxindexMetadata struct {
@@ -3703,6 +4305,13 @@ type UpdateNFTTxindexMetadata struct {
 	IsForSale      bool
 }
 
+type PollVoteTxindexMetadata struct {
+	// VoterPublicKeyBase58Check = TransactorPublicKeyBase58Check
+	PostHashHex string
+	OptionIndex uint32
+	// PosterPublicKeyBase58Check in AffectedPublicKeys
+}
+
 type TransactionMetadata struct {
 	BlockHashHex    string
 	TxnIndexInBlock uint64
@@ -3718,23 +4327,38 @@ type TransactionMetadata struct {
 	// when looking up output amounts
 	TxnOutputs []*DeSoOutput
 
-	BasicTransferTxindexMetadata       *BasicTransferTxindexMetadata       `json:",omitempty"`
-	BitcoinExchangeTxindexMetadata     *BitcoinExchangeTxindexMetadata     `json:",omitempty"`
-	CreatorCoinTxindexMetadata         *CreatorCoinTxindexMetadata         `json:",omitempty"`
-	CreatorCoinTransferTxindexMetadata *CreatorCoinTransferTxindexMetadata `json:",omitempty"`
-	UpdateProfileTxindexMetadata       *UpdateProfileTxindexMetadata       `json:",omitempty"`
-	SubmitPostTxindexMetadata          *SubmitPostTxindexMetadata          `json:",omitempty"`
-	LikeTxindexMetadata                *LikeTxindexMetadata                `json:",omitempty"`
-	FollowTxindexMetadata              *FollowTxindexMetadata              `json:",omitempty"`
-	PrivateMessageTxindexMetadata      *PrivateMessageTxindexMetadata      `json:",omitempty"`
-	SwapIdentityTxindexMetadata        *SwapIdentityTxindexMetadata        `json:",omitempty"`
-	NFTBidTxindexMetadata              *NFTBidTxindexMetadata              `json:",omitempty"`
-	AcceptNFTBidTxindexMetadata        *AcceptNFTBidTxindexMetadata        `json:",omitempty"`
-	NFTTransferTxindexMetadata         *NFTTransferTxindexMetadata         `json:",omitempty"`
-	DAOCoinTxindexMetadata             *DAOCoinTxindexMetadata             `json:",omitempty"`
-	DAOCoinTransferTxindexMetadata     *DAOCoinTransferTxindexMetadata     `json:",omitempty"`
-	CreateNFTTxindexMetadata           *CreateNFTTxindexMetadata           `json:",omitempty"`
-	UpdateNFTTxindexMetadata           *UpdateNFTTxindexMetadata           `json:",omitempty"`
+	BasicTransferTxindexMetadata           *BasicTransferTxindexMetadata           `json:",omitempty"`
+	BitcoinExchangeTxindexMetadata         *BitcoinExchangeTxindexMetadata         `json:",omitempty"`
+	CreatorCoinTxindexMetadata             *CreatorCoinTxindexMetadata             `json:",omitempty"`
+	CreatorCoinTransferTxindexMetadata     *CreatorCoinTransferTxindexMetadata     `json:",omitempty"`
+	UpdateProfileTxindexMetadata           *UpdateProfileTxindexMetadata           `json:",omitempty"`
+	SubmitPostTxindexMetadata              *SubmitPostTxindexMetadata              `json:",omitempty"`
+	LikeTxindexMetadata                    *LikeTxindexMetadata                    `json:",omitempty"`
+	FollowTxindexMetadata                  *FollowTxindexMetadata                  `json:",omitempty"`
+	PrivateMessageTxindexMetadata          *PrivateMessageTxindexMetadata          `json:",omitempty"`
+	SwapIdentityTxindexMetadata            *SwapIdentityTxindexMetadata            `json:",omitempty"`
+	NFTBidTxindexMetadata                  *NFTBidTxindexMetadata                  `json:",omitempty"`
+	AcceptNFTBidTxindexMetadata            *AcceptNFTBidTxindexMetadata            `json:",omitempty"`
+	NFTTransferTxindexMetadata             *NFTTransferTxindexMetadata             `json:",omitempty"`
+	DAOCoinTxindexMetadata                 *DAOCoinTxindexMetadata                 `json:",omitempty"`
+	DAOCoinTransferTxindexMetadata         *DAOCoinTransferTxindexMetadata         `json:",omitempty"`
+	CreateNFTTxindexMetadata               *CreateNFTTxindexMetadata               `json:",omitempty"`
+	UpdateNFTTxindexMetadata               *UpdateNFTTxindexMetadata               `json:",omitempty"`
+	PollVoteTxindexMetadata                *PollVoteTxindexMetadata                `json:",omitempty"`
+	UserBlockTxindexMetadata               *UserBlockTxindexMetadata               `json:",omitempty"`
+	MessagingGroupUpdateTxindexMetadata    *MessagingGroupUpdateTxindexMetadata    `json:",omitempty"`
+	MessageReadTxindexMetadata             *MessageReadTxindexMetadata             `json:",omitempty"`
+	CreateNFTCollectionTxindexMetadata     *CreateNFTCollectionTxindexMetadata     `json:",omitempty"`
+	NFTVaultTxindexMetadata                *NFTVaultTxindexMetadata                `json:",omitempty"`
+	RedeemNFTVoucherTxindexMetadata        *RedeemNFTVoucherTxindexMetadata        `json:",omitempty"`
+	RegisterBlockProducerTxindexMetadata   *RegisterBlockProducerTxindexMetadata   `json:",omitempty"`
+	DeregisterBlockProducerTxindexMetadata *DeregisterBlockProducerTxindexMetadata `json:",omitempty"`
+	ClaimBlockProducerBondTxindexMetadata  *ClaimBlockProducerBondTxindexMetadata  `json:",omitempty"`
+	UsernameListingTxindexMetadata         *UsernameListingTxindexMetadata         `json:",omitempty"`
+	AcceptUsernameListingTxindexMetadata   *AcceptUsernameListingTxindexMetadata   `json:",omitempty"`
+	KeyRotationTxindexMetadata             *KeyRotationTxindexMetadata             `json:",omitempty"`
+	RecoveryGuardiansTxindexMetadata       *RecoveryGuardiansTxindexMetadata       `json:",omitempty"`
+	AccountRecoveryTxindexMetadata         *AccountRecoveryTxindexMetadata         `json:",omitempty"`
 }
 
 func DBCheckTxnExistenceWithTxn(txn *badger.Txn, txID *BlockHash) bool {
@@ -4048,6 +4672,16 @@ func DBDeletePostEntryMappingsWithTxn(
 			"post mapping for post hash %v", postHash)
 	}
 
+	// If the post is an NFT in a collection, delete its collection mapping.
+	if postEntry.NFTCollectionID != nil {
+		if err := txn.Delete(_dbKeyForNFTCollectionIDPostHash(
+			postEntry.NFTCollectionID, postEntry.PostHash)); err != nil {
+
+			return errors.Wrapf(err, "DbDeletePostEntryMappingsWithTxn: Deleting "+
+				"NFT collection mapping for post hash %v", postHash)
+		}
+	}
+
 	// If the post is a comment we store it in a separate index. Comments are
 	// technically posts but they really should be treated as their own entity.
 	// The only reason they're not actually implemented that way is so that we
@@ -4139,6 +4773,16 @@ func DBPutPostEntryMappingsWithTxn(
 			"adding mapping for post: %v", postEntry.PostHash)
 	}
 
+	// If the post is an NFT in a collection, index it under the collection.
+	if postEntry.NFTCollectionID != nil {
+		if err := txn.Set(_dbKeyForNFTCollectionIDPostHash(
+			postEntry.NFTCollectionID, postEntry.PostHash), []byte{}); err != nil {
+
+			return errors.Wrapf(err, "DbPutPostEntryMappingsWithTxn: Problem "+
+				"adding NFT collection mapping for post: %v", postEntry.PostHash)
+		}
+	}
+
 	// If the post is a comment we store it in a separate index. Comments are
 	// technically posts but they really should be treated as their own entity.
 	// The only reason they're not actually implemented that way is so that we
@@ -4232,26 +4876,944 @@ func DBPutPostEntryMappings(handle *badger.DB, postEntry *PostEntry, params *DeS
 	})
 }
 
-// Specifying minTimestampNanos gives you all posts after minTimestampNanos
-// Pass minTimestampNanos = 0 && maxTimestampNanos = 0 if you want all posts
-// Setting maxTimestampNanos = 0, will default maxTimestampNanos to the current time.
-func DBGetAllPostsAndCommentsForPublicKeyOrderedByTimestamp(
-	handle *badger.DB, publicKey []byte, fetchEntries bool, minTimestampNanos uint64, maxTimestampNanos uint64) (
-	_tstamps []uint64, _postAndCommentHashes []*BlockHash, _postAndCommentEntries []*PostEntry, _err error) {
-
-	tstampsFetched := []uint64{}
-	postAndCommentHashesFetched := []*BlockHash{}
-	postAndCommentEntriesFetched := []*PostEntry{}
-	dbPrefixx := append([]byte{}, _PrefixPosterPublicKeyTimestampPostHash...)
-	dbPrefixx = append(dbPrefixx, publicKey...)
+// -------------------------------------------------------------------------------------
+// Post version mapping functions
+// 		<prefix, PostHash [32]byte, Version uint64> -> <PostVersionEntry>
+// -------------------------------------------------------------------------------------
 
-	err := handle.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
+func _dbKeyForPostHashVersion(postHash *BlockHash, version uint64) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	prefixCopy := append([]byte{}, _PrefixPostHashVersionToPostVersionEntry...)
+	key := append(prefixCopy, postHash[:]...)
+	key = append(key, EncodeUint64(version)...)
+	return key
+}
 
-		opts.PrefetchValues = false
+func DbPutPostVersionEntryWithTxn(txn *badger.Txn, postVersionEntry *PostVersionEntry) error {
+	postVersionDataBuf := bytes.NewBuffer([]byte{})
+	gob.NewEncoder(postVersionDataBuf).Encode(postVersionEntry)
 
-		// Go in reverse order since a larger count is better.
-		opts.Reverse = true
+	if err := txn.Set(_dbKeyForPostHashVersion(
+		postVersionEntry.PostHash, postVersionEntry.Version), postVersionDataBuf.Bytes()); err != nil {
+
+		return errors.Wrapf(err, "DbPutPostVersionEntryWithTxn: Problem adding version %d "+
+			"for post hash %v", postVersionEntry.Version, postVersionEntry.PostHash)
+	}
+	return nil
+}
+
+func DbDeletePostVersionEntryWithTxn(txn *badger.Txn, postHash *BlockHash, version uint64) error {
+	if err := txn.Delete(_dbKeyForPostHashVersion(postHash, version)); err != nil {
+		return errors.Wrapf(err, "DbDeletePostVersionEntryWithTxn: Problem deleting version %d "+
+			"for post hash %v", version, postHash)
+	}
+	return nil
+}
+
+// DbGetPostVersionEntriesForPostHash returns every version of a post in version
+// order. Posts that have never been edited have no versions.
+func DbGetPostVersionEntriesForPostHash(handle *badger.DB, postHash *BlockHash) ([]*PostVersionEntry, error) {
+	prefix := append(append([]byte{}, _PrefixPostHashVersionToPostVersionEntry...), postHash[:]...)
+	_, valsFound := _enumerateKeysForPrefix(handle, prefix)
+
+	postVersionEntries := []*PostVersionEntry{}
+	for _, valBytes := range valsFound {
+		postVersionEntry := &PostVersionEntry{}
+		if err := gob.NewDecoder(bytes.NewReader(valBytes)).Decode(postVersionEntry); err != nil {
+			return nil, errors.Wrapf(err, "DbGetPostVersionEntriesForPostHash: Problem decoding "+
+				"version for post hash %v", postHash)
+		}
+		postVersionEntries = append(postVersionEntries, postVersionEntry)
+	}
+	return postVersionEntries, nil
+}
+
+// -------------------------------------------------------------------------------------
+// Poll mapping functions
+// 		<prefix, PostHash [32]byte> -> <PollEntry>
+// 		<prefix, PostHash [32]byte, VoterPKID [33]byte> -> <PollVoteEntry>
+// -------------------------------------------------------------------------------------
+
+func _dbKeyForPollPostHash(postHash *BlockHash) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	prefixCopy := append([]byte{}, _PrefixPostHashToPollEntry...)
+	return append(prefixCopy, postHash[:]...)
+}
+
+func _dbKeyForPollPostHashVoterPKID(postHash *BlockHash, voterPKID *PKID) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	prefixCopy := append([]byte{}, _PrefixPollPostHashVoterPKIDToPollVoteEntry...)
+	key := append(prefixCopy, postHash[:]...)
+	key = append(key, voterPKID[:]...)
+	return key
+}
+
+func DbPutPollEntryWithTxn(txn *badger.Txn, pollEntry *PollEntry) error {
+	pollDataBuf := bytes.NewBuffer([]byte{})
+	gob.NewEncoder(pollDataBuf).Encode(pollEntry)
+
+	if err := txn.Set(_dbKeyForPollPostHash(pollEntry.PostHash), pollDataBuf.Bytes()); err != nil {
+		return errors.Wrapf(err, "DbPutPollEntryWithTxn: Problem adding poll for post hash %v",
+			pollEntry.PostHash)
+	}
+	return nil
+}
+
+func DbDeletePollEntryWithTxn(txn *badger.Txn, postHash *BlockHash) error {
+	if err := txn.Delete(_dbKeyForPollPostHash(postHash)); err != nil {
+		return errors.Wrapf(err, "DbDeletePollEntryWithTxn: Problem deleting poll for post hash %v",
+			postHash)
+	}
+	return nil
+}
+
+func DbGetPollEntryForPostHashWithTxn(txn *badger.Txn, postHash *BlockHash) *PollEntry {
+	pollItem, err := txn.Get(_dbKeyForPollPostHash(postHash))
+	if err != nil {
+		return nil
+	}
+	pollEntry := &PollEntry{}
+	err = pollItem.Value(func(valBytes []byte) error {
+		return gob.NewDecoder(bytes.NewReader(valBytes)).Decode(pollEntry)
+	})
+	if err != nil {
+		glog.Errorf("DbGetPollEntryForPostHashWithTxn: Problem reading "+
+			"PollEntry for postHash %v", postHash)
+		return nil
+	}
+	return pollEntry
+}
+
+func DbGetPollEntryForPostHash(handle *badger.DB, postHash *BlockHash) *PollEntry {
+	var ret *PollEntry
+	handle.View(func(txn *badger.Txn) error {
+		ret = DbGetPollEntryForPostHashWithTxn(txn, postHash)
+		return nil
+	})
+	return ret
+}
+
+func DbPutPollVoteEntryWithTxn(txn *badger.Txn, pollVoteEntry *PollVoteEntry) error {
+	pollVoteDataBuf := bytes.NewBuffer([]byte{})
+	gob.NewEncoder(pollVoteDataBuf).Encode(pollVoteEntry)
+
+	if err := txn.Set(_dbKeyForPollPostHashVoterPKID(
+		pollVoteEntry.PostHash, pollVoteEntry.VoterPKID), pollVoteDataBuf.Bytes()); err != nil {
+
+		return errors.Wrapf(err, "DbPutPollVoteEntryWithTxn: Problem adding vote by %v "+
+			"for post hash %v", pollVoteEntry.VoterPKID, pollVoteEntry.PostHash)
+	}
+	return nil
+}
+
+func DbDeletePollVoteEntryWithTxn(txn *badger.Txn, postHash *BlockHash, voterPKID *PKID) error {
+	if err := txn.Delete(_dbKeyForPollPostHashVoterPKID(postHash, voterPKID)); err != nil {
+		return errors.Wrapf(err, "DbDeletePollVoteEntryWithTxn: Problem deleting vote by %v "+
+			"for post hash %v", voterPKID, postHash)
+	}
+	return nil
+}
+
+func DbGetPollVoteEntryWithTxn(txn *badger.Txn, postHash *BlockHash, voterPKID *PKID) *PollVoteEntry {
+	pollVoteItem, err := txn.Get(_dbKeyForPollPostHashVoterPKID(postHash, voterPKID))
+	if err != nil {
+		return nil
+	}
+	pollVoteEntry := &PollVoteEntry{}
+	err = pollVoteItem.Value(func(valBytes []byte) error {
+		return gob.NewDecoder(bytes.NewReader(valBytes)).Decode(pollVoteEntry)
+	})
+	if err != nil {
+		glog.Errorf("DbGetPollVoteEntryWithTxn: Problem reading "+
+			"PollVoteEntry for postHash %v and voter %v", postHash, voterPKID)
+		return nil
+	}
+	return pollVoteEntry
+}
+
+func DbGetPollVoteEntry(handle *badger.DB, postHash *BlockHash, voterPKID *PKID) *PollVoteEntry {
+	var ret *PollVoteEntry
+	handle.View(func(txn *badger.Txn) error {
+		ret = DbGetPollVoteEntryWithTxn(txn, postHash, voterPKID)
+		return nil
+	})
+	return ret
+}
+
+// DbGetPollVoteEntriesForPostHash returns every vote cast in the poll declared by
+// the post.
+func DbGetPollVoteEntriesForPostHash(handle *badger.DB, postHash *BlockHash) ([]*PollVoteEntry, error) {
+	prefix := append(append([]byte{}, _PrefixPollPostHashVoterPKIDToPollVoteEntry...), postHash[:]...)
+	_, valsFound := _enumerateKeysForPrefix(handle, prefix)
+
+	pollVoteEntries := []*PollVoteEntry{}
+	for _, valBytes := range valsFound {
+		pollVoteEntry := &PollVoteEntry{}
+		if err := gob.NewDecoder(bytes.NewReader(valBytes)).Decode(pollVoteEntry); err != nil {
+			return nil, errors.Wrapf(err, "DbGetPollVoteEntriesForPostHash: Problem decoding "+
+				"vote for post hash %v", postHash)
+		}
+		pollVoteEntries = append(pollVoteEntries, pollVoteEntry)
+	}
+	return pollVoteEntries, nil
+}
+
+// -------------------------------------------------------------------------------------
+// NFT collection mapping functions
+// 		<prefix, CollectionID [32]byte> -> <NFTCollectionEntry>
+// 		<prefix, CollectionID [32]byte, NFTPostHash [32]byte> -> <>
+// -------------------------------------------------------------------------------------
+
+func _dbKeyForNFTCollectionID(collectionID *BlockHash) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	prefixCopy := append([]byte{}, _PrefixNFTCollectionIDToNFTCollectionEntry...)
+	return append(prefixCopy, collectionID[:]...)
+}
+
+func _dbKeyForNFTCollectionIDPostHash(collectionID *BlockHash, postHash *BlockHash) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	prefixCopy := append([]byte{}, _PrefixNFTCollectionIDPostHash...)
+	key := append(prefixCopy, collectionID[:]...)
+	key = append(key, postHash[:]...)
+	return key
+}
+
+func DbPutNFTCollectionEntryWithTxn(txn *badger.Txn, nftCollectionEntry *NFTCollectionEntry) error {
+	nftCollectionDataBuf := bytes.NewBuffer([]byte{})
+	gob.NewEncoder(nftCollectionDataBuf).Encode(nftCollectionEntry)
+
+	if err := txn.Set(_dbKeyForNFTCollectionID(nftCollectionEntry.CollectionID), nftCollectionDataBuf.Bytes()); err != nil {
+		return errors.Wrapf(err, "DbPutNFTCollectionEntryWithTxn: Problem adding NFT collection %v",
+			nftCollectionEntry.CollectionID)
+	}
+	return nil
+}
+
+func DbDeleteNFTCollectionEntryWithTxn(txn *badger.Txn, collectionID *BlockHash) error {
+	if err := txn.Delete(_dbKeyForNFTCollectionID(collectionID)); err != nil {
+		return errors.Wrapf(err, "DbDeleteNFTCollectionEntryWithTxn: Problem deleting NFT collection %v",
+			collectionID)
+	}
+	return nil
+}
+
+func DbGetNFTCollectionEntryWithTxn(txn *badger.Txn, collectionID *BlockHash) *NFTCollectionEntry {
+	nftCollectionItem, err := txn.Get(_dbKeyForNFTCollectionID(collectionID))
+	if err != nil {
+		return nil
+	}
+	nftCollectionEntry := &NFTCollectionEntry{}
+	err = nftCollectionItem.Value(func(valBytes []byte) error {
+		return gob.NewDecoder(bytes.NewReader(valBytes)).Decode(nftCollectionEntry)
+	})
+	if err != nil {
+		glog.Errorf("DbGetNFTCollectionEntryWithTxn: Problem reading "+
+			"NFTCollectionEntry for collection %v", collectionID)
+		return nil
+	}
+	return nftCollectionEntry
+}
+
+func DbGetNFTCollectionEntry(handle *badger.DB, collectionID *BlockHash) *NFTCollectionEntry {
+	var ret *NFTCollectionEntry
+	handle.View(func(txn *badger.Txn) error {
+		ret = DbGetNFTCollectionEntryWithTxn(txn, collectionID)
+		return nil
+	})
+	return ret
+}
+
+// DbGetPostHashesForNFTCollection returns the hashes of every post attached to
+// the collection.
+func DbGetPostHashesForNFTCollection(handle *badger.DB, collectionID *BlockHash) []*BlockHash {
+	prefix := append(append([]byte{}, _PrefixNFTCollectionIDPostHash...), collectionID[:]...)
+	keysFound, _ := _enumerateKeysForPrefix(handle, prefix)
+
+	postHashes := []*BlockHash{}
+	for _, keyBytes := range keysFound {
+		postHash := &BlockHash{}
+		copy(postHash[:], keyBytes[len(prefix):])
+		postHashes = append(postHashes, postHash)
+	}
+	return postHashes
+}
+
+// -------------------------------------------------------------------------------------
+// NFT voucher redemption mapping functions
+// 		<prefix, NFTPostHash [32]byte, SerialNumber uint64> -> <NFTVoucherRedemptionEntry>
+// -------------------------------------------------------------------------------------
+
+func _dbKeyForNFTVoucherRedemption(nftPostHash *BlockHash, serialNumber uint64) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	prefixCopy := append([]byte{}, _PrefixPostHashSerialNumberToNFTVoucherRedemptionEntry...)
+	key := append(prefixCopy, nftPostHash[:]...)
+	key = append(key, EncodeUint64(serialNumber)...)
+	return key
+}
+
+func DbPutNFTVoucherRedemptionEntryWithTxn(txn *badger.Txn, redemptionEntry *NFTVoucherRedemptionEntry) error {
+	redemptionDataBuf := bytes.NewBuffer([]byte{})
+	gob.NewEncoder(redemptionDataBuf).Encode(redemptionEntry)
+
+	if err := txn.Set(_dbKeyForNFTVoucherRedemption(
+		redemptionEntry.NFTPostHash, redemptionEntry.SerialNumber), redemptionDataBuf.Bytes()); err != nil {
+
+		return errors.Wrapf(err, "DbPutNFTVoucherRedemptionEntryWithTxn: Problem adding "+
+			"redemption for post hash %v serial number %d", redemptionEntry.NFTPostHash,
+			redemptionEntry.SerialNumber)
+	}
+	return nil
+}
+
+func DbDeleteNFTVoucherRedemptionEntryWithTxn(txn *badger.Txn, nftPostHash *BlockHash, serialNumber uint64) error {
+	if err := txn.Delete(_dbKeyForNFTVoucherRedemption(nftPostHash, serialNumber)); err != nil {
+		return errors.Wrapf(err, "DbDeleteNFTVoucherRedemptionEntryWithTxn: Problem deleting "+
+			"redemption for post hash %v serial number %d", nftPostHash, serialNumber)
+	}
+	return nil
+}
+
+func DbGetNFTVoucherRedemptionEntryWithTxn(
+	txn *badger.Txn, nftPostHash *BlockHash, serialNumber uint64) *NFTVoucherRedemptionEntry {
+
+	redemptionItem, err := txn.Get(_dbKeyForNFTVoucherRedemption(nftPostHash, serialNumber))
+	if err != nil {
+		return nil
+	}
+	redemptionEntry := &NFTVoucherRedemptionEntry{}
+	err = redemptionItem.Value(func(valBytes []byte) error {
+		return gob.NewDecoder(bytes.NewReader(valBytes)).Decode(redemptionEntry)
+	})
+	if err != nil {
+		glog.Errorf("DbGetNFTVoucherRedemptionEntryWithTxn: Problem reading "+
+			"NFTVoucherRedemptionEntry for post hash %v serial number %d", nftPostHash, serialNumber)
+		return nil
+	}
+	return redemptionEntry
+}
+
+func DbGetNFTVoucherRedemptionEntry(
+	handle *badger.DB, nftPostHash *BlockHash, serialNumber uint64) *NFTVoucherRedemptionEntry {
+
+	var ret *NFTVoucherRedemptionEntry
+	handle.View(func(txn *badger.Txn) error {
+		ret = DbGetNFTVoucherRedemptionEntryWithTxn(txn, nftPostHash, serialNumber)
+		return nil
+	})
+	return ret
+}
+
+// -------------------------------------------------------------------------------------
+// Block producer mapping functions
+// 		<prefix, PublicKey [33]byte> -> <BlockProducerEntry>
+// -------------------------------------------------------------------------------------
+
+func _dbKeyForBlockProducerEntry(publicKey []byte) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	prefixCopy := append([]byte{}, _PrefixPublicKeyToBlockProducerEntry...)
+	key := append(prefixCopy, publicKey...)
+	return key
+}
+
+func DbPutBlockProducerEntryWithTxn(txn *badger.Txn, blockProducerEntry *BlockProducerEntry) error {
+	blockProducerDataBuf := bytes.NewBuffer([]byte{})
+	gob.NewEncoder(blockProducerDataBuf).Encode(blockProducerEntry)
+
+	if err := txn.Set(_dbKeyForBlockProducerEntry(blockProducerEntry.PublicKey), blockProducerDataBuf.Bytes()); err != nil {
+		return errors.Wrapf(err, "DbPutBlockProducerEntryWithTxn: Problem adding "+
+			"block producer %v", PkToStringBoth(blockProducerEntry.PublicKey))
+	}
+	return nil
+}
+
+func DbDeleteBlockProducerEntryWithTxn(txn *badger.Txn, publicKey []byte) error {
+	if err := txn.Delete(_dbKeyForBlockProducerEntry(publicKey)); err != nil {
+		return errors.Wrapf(err, "DbDeleteBlockProducerEntryWithTxn: Problem deleting "+
+			"block producer %v", PkToStringBoth(publicKey))
+	}
+	return nil
+}
+
+func DbGetBlockProducerEntryWithTxn(txn *badger.Txn, publicKey []byte) *BlockProducerEntry {
+	blockProducerItem, err := txn.Get(_dbKeyForBlockProducerEntry(publicKey))
+	if err != nil {
+		return nil
+	}
+	blockProducerEntry := &BlockProducerEntry{}
+	err = blockProducerItem.Value(func(valBytes []byte) error {
+		return gob.NewDecoder(bytes.NewReader(valBytes)).Decode(blockProducerEntry)
+	})
+	if err != nil {
+		glog.Errorf("DbGetBlockProducerEntryWithTxn: Problem reading "+
+			"BlockProducerEntry for public key %v", PkToStringBoth(publicKey))
+		return nil
+	}
+	return blockProducerEntry
+}
+
+func DbGetBlockProducerEntry(handle *badger.DB, publicKey []byte) *BlockProducerEntry {
+	var ret *BlockProducerEntry
+	handle.View(func(txn *badger.Txn) error {
+		ret = DbGetBlockProducerEntryWithTxn(txn, publicKey)
+		return nil
+	})
+	return ret
+}
+
+// DbGetAllBlockProducerEntries returns every block producer that has ever
+// registered, including the ones that have since deregistered.
+func DbGetAllBlockProducerEntries(handle *badger.DB) ([]*BlockProducerEntry, error) {
+	_, valsFound := _enumerateKeysForPrefix(handle, _PrefixPublicKeyToBlockProducerEntry)
+
+	blockProducerEntries := []*BlockProducerEntry{}
+	for _, valBytes := range valsFound {
+		blockProducerEntry := &BlockProducerEntry{}
+		if err := gob.NewDecoder(bytes.NewReader(valBytes)).Decode(blockProducerEntry); err != nil {
+			return nil, errors.Wrapf(err, "DbGetAllBlockProducerEntries: Problem decoding "+
+				"BlockProducerEntry: ")
+		}
+		blockProducerEntries = append(blockProducerEntries, blockProducerEntry)
+	}
+	return blockProducerEntries, nil
+}
+
+// -------------------------------------------------------------------------------------
+// Bitcoin best chain mapping functions
+// 		<prefix, BitcoinBlockHash [32]byte> -> <Height uint32>
+// -------------------------------------------------------------------------------------
+
+func _dbKeyForBitcoinBestChainHash(bitcoinBlockHash *BlockHash) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	prefixCopy := append([]byte{}, _PrefixBitcoinBestChainHashToHeight...)
+	key := append(prefixCopy, bitcoinBlockHash[:]...)
+	return key
+}
+
+func DbPutBitcoinBestChainHeightWithTxn(txn *badger.Txn, bitcoinBlockHash *BlockHash, height uint32) error {
+	if err := txn.Set(_dbKeyForBitcoinBestChainHash(bitcoinBlockHash), _EncodeUint32(height)); err != nil {
+		return errors.Wrapf(err, "DbPutBitcoinBestChainHeightWithTxn: Problem adding "+
+			"Bitcoin block %v at height %d", bitcoinBlockHash, height)
+	}
+	return nil
+}
+
+func DbDeleteBitcoinBestChainHeightWithTxn(txn *badger.Txn, bitcoinBlockHash *BlockHash) error {
+	if err := txn.Delete(_dbKeyForBitcoinBestChainHash(bitcoinBlockHash)); err != nil {
+		return errors.Wrapf(err, "DbDeleteBitcoinBestChainHeightWithTxn: Problem deleting "+
+			"Bitcoin block %v", bitcoinBlockHash)
+	}
+	return nil
+}
+
+func DbGetBitcoinBestChainHeightWithTxn(txn *badger.Txn, bitcoinBlockHash *BlockHash) (
+	_height uint32, _exists bool) {
+
+	heightItem, err := txn.Get(_dbKeyForBitcoinBestChainHash(bitcoinBlockHash))
+	if err != nil {
+		return 0, false
+	}
+	heightBytes, err := heightItem.ValueCopy(nil)
+	if err != nil || len(heightBytes) != 4 {
+		glog.Errorf("DbGetBitcoinBestChainHeightWithTxn: Problem reading "+
+			"height for Bitcoin block %v", bitcoinBlockHash)
+		return 0, false
+	}
+	return DecodeUint32(heightBytes), true
+}
+
+// DbGetBitcoinBestChainHeight returns the height of the Bitcoin block with the given
+// hash if it's on the node's best Bitcoin header chain.
+func DbGetBitcoinBestChainHeight(handle *badger.DB, bitcoinBlockHash *BlockHash) (
+	_height uint32, _exists bool) {
+
+	var height uint32
+	var exists bool
+	handle.View(func(txn *badger.Txn) error {
+		height, exists = DbGetBitcoinBestChainHeightWithTxn(txn, bitcoinBlockHash)
+		return nil
+	})
+	return height, exists
+}
+
+// -------------------------------------------------------------------------------------
+// Creator coin price candle mapping functions
+// 		<prefix, CreatorPKID [33]byte, BlockHeight uint32> -> <CreatorCoinPriceCandleEntry>
+// -------------------------------------------------------------------------------------
+
+func _dbKeyForCreatorCoinPriceCandle(creatorPKID *PKID, blockHeight uint32) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	prefixCopy := append([]byte{}, _PrefixCreatorPKIDBlockHeightToPriceCandle...)
+	key := append(prefixCopy, creatorPKID[:]...)
+	key = append(key, _EncodeUint32(blockHeight)...)
+	return key
+}
+
+func DbPutCreatorCoinPriceCandleEntryWithTxn(txn *badger.Txn, candleEntry *CreatorCoinPriceCandleEntry) error {
+	candleDataBuf := bytes.NewBuffer([]byte{})
+	gob.NewEncoder(candleDataBuf).Encode(candleEntry)
+
+	if err := txn.Set(_dbKeyForCreatorCoinPriceCandle(
+		candleEntry.CreatorPKID, candleEntry.StartBlockHeight), candleDataBuf.Bytes()); err != nil {
+
+		return errors.Wrapf(err, "DbPutCreatorCoinPriceCandleEntryWithTxn: Problem adding candle "+
+			"at height %d for creator PKID %v", candleEntry.StartBlockHeight, candleEntry.CreatorPKID)
+	}
+	return nil
+}
+
+func DbDeleteCreatorCoinPriceCandleEntryWithTxn(txn *badger.Txn, creatorPKID *PKID, blockHeight uint32) error {
+	if err := txn.Delete(_dbKeyForCreatorCoinPriceCandle(creatorPKID, blockHeight)); err != nil {
+		return errors.Wrapf(err, "DbDeleteCreatorCoinPriceCandleEntryWithTxn: Problem deleting candle "+
+			"at height %d for creator PKID %v", blockHeight, creatorPKID)
+	}
+	return nil
+}
+
+func DbGetCreatorCoinPriceCandleEntryWithTxn(txn *badger.Txn, creatorPKID *PKID,
+	blockHeight uint32) *CreatorCoinPriceCandleEntry {
+
+	candleItem, err := txn.Get(_dbKeyForCreatorCoinPriceCandle(creatorPKID, blockHeight))
+	if err != nil {
+		return nil
+	}
+	candleEntry := &CreatorCoinPriceCandleEntry{}
+	err = candleItem.Value(func(valBytes []byte) error {
+		return gob.NewDecoder(bytes.NewReader(valBytes)).Decode(candleEntry)
+	})
+	if err != nil {
+		glog.Errorf("DbGetCreatorCoinPriceCandleEntryWithTxn: Problem reading candle "+
+			"at height %d for creator PKID %v", blockHeight, creatorPKID)
+		return nil
+	}
+	return candleEntry
+}
+
+func DbGetCreatorCoinPriceCandleEntry(handle *badger.DB, creatorPKID *PKID,
+	blockHeight uint32) *CreatorCoinPriceCandleEntry {
+
+	var ret *CreatorCoinPriceCandleEntry
+	handle.View(func(txn *badger.Txn) error {
+		ret = DbGetCreatorCoinPriceCandleEntryWithTxn(txn, creatorPKID, blockHeight)
+		return nil
+	})
+	return ret
+}
+
+// DbGetCreatorCoinPriceCandleEntriesForHeightRange returns the per-block candles for
+// a creator coin with a height in [startHeight, endHeight], in height order.
+func DbGetCreatorCoinPriceCandleEntriesForHeightRange(handle *badger.DB, creatorPKID *PKID,
+	startHeight uint32, endHeight uint32) ([]*CreatorCoinPriceCandleEntry, error) {
+
+	candleEntries := []*CreatorCoinPriceCandleEntry{}
+	err := handle.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		nodeIterator := txn.NewIterator(opts)
+		defer nodeIterator.Close()
+		prefix := append(append([]byte{}, _PrefixCreatorPKIDBlockHeightToPriceCandle...), creatorPKID[:]...)
+		for nodeIterator.Seek(_dbKeyForCreatorCoinPriceCandle(creatorPKID, startHeight)); nodeIterator.ValidForPrefix(prefix); nodeIterator.Next() {
+			key := nodeIterator.Item().Key()
+			if DecodeUint32(key[len(prefix):]) > endHeight {
+				break
+			}
+
+			candleEntry := &CreatorCoinPriceCandleEntry{}
+			err := nodeIterator.Item().Value(func(valBytes []byte) error {
+				return gob.NewDecoder(bytes.NewReader(valBytes)).Decode(candleEntry)
+			})
+			if err != nil {
+				return errors.Wrapf(err, "DbGetCreatorCoinPriceCandleEntriesForHeightRange: "+
+					"Problem decoding candle for creator PKID %v: ", creatorPKID)
+			}
+			candleEntries = append(candleEntries, candleEntry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return candleEntries, nil
+}
+
+// -------------------------------------------------------------------------------------
+// Username listing mapping functions
+// 		<prefix, lowercase username []byte> -> <UsernameListingEntry>
+// -------------------------------------------------------------------------------------
+
+func _dbKeyForUsernameListingEntry(nonLowercaseUsername []byte) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	key := append([]byte{}, _PrefixUsernameToUsernameListingEntry...)
+	// Lowercase the username so that a listing is found regardless of case.
+	lowercaseUsername := []byte(strings.ToLower(string(nonLowercaseUsername)))
+	key = append(key, lowercaseUsername...)
+	return key
+}
+
+func DbPutUsernameListingEntryWithTxn(txn *badger.Txn, listingEntry *UsernameListingEntry) error {
+	listingDataBuf := bytes.NewBuffer([]byte{})
+	gob.NewEncoder(listingDataBuf).Encode(listingEntry)
+
+	if err := txn.Set(_dbKeyForUsernameListingEntry(listingEntry.Username), listingDataBuf.Bytes()); err != nil {
+		return errors.Wrapf(err, "DbPutUsernameListingEntryWithTxn: Problem adding "+
+			"listing for username %v", string(listingEntry.Username))
+	}
+	return nil
+}
+
+func DbDeleteUsernameListingEntryWithTxn(txn *badger.Txn, nonLowercaseUsername []byte) error {
+	if err := txn.Delete(_dbKeyForUsernameListingEntry(nonLowercaseUsername)); err != nil {
+		return errors.Wrapf(err, "DbDeleteUsernameListingEntryWithTxn: Problem deleting "+
+			"listing for username %v", string(nonLowercaseUsername))
+	}
+	return nil
+}
+
+func DbGetUsernameListingEntryWithTxn(txn *badger.Txn, nonLowercaseUsername []byte) *UsernameListingEntry {
+	listingItem, err := txn.Get(_dbKeyForUsernameListingEntry(nonLowercaseUsername))
+	if err != nil {
+		return nil
+	}
+	listingEntry := &UsernameListingEntry{}
+	err = listingItem.Value(func(valBytes []byte) error {
+		return gob.NewDecoder(bytes.NewReader(valBytes)).Decode(listingEntry)
+	})
+	if err != nil {
+		glog.Errorf("DbGetUsernameListingEntryWithTxn: Problem reading "+
+			"UsernameListingEntry for username %v", string(nonLowercaseUsername))
+		return nil
+	}
+	return listingEntry
+}
+
+func DbGetUsernameListingEntry(handle *badger.DB, nonLowercaseUsername []byte) *UsernameListingEntry {
+	var ret *UsernameListingEntry
+	handle.View(func(txn *badger.Txn) error {
+		ret = DbGetUsernameListingEntryWithTxn(txn, nonLowercaseUsername)
+		return nil
+	})
+	return ret
+}
+
+// DbGetAllUsernameListingEntries returns every username that's currently listed.
+func DbGetAllUsernameListingEntries(handle *badger.DB) ([]*UsernameListingEntry, error) {
+	_, valsFound := _enumerateKeysForPrefix(handle, _PrefixUsernameToUsernameListingEntry)
+
+	listingEntries := []*UsernameListingEntry{}
+	for _, valBytes := range valsFound {
+		listingEntry := &UsernameListingEntry{}
+		if err := gob.NewDecoder(bytes.NewReader(valBytes)).Decode(listingEntry); err != nil {
+			return nil, errors.Wrapf(err, "DbGetAllUsernameListingEntries: Problem decoding "+
+				"UsernameListingEntry: ")
+		}
+		listingEntries = append(listingEntries, listingEntry)
+	}
+	return listingEntries, nil
+}
+
+// -------------------------------------------------------------------------------------
+// Pending key rotation mapping functions
+// 		<prefix, OldPublicKey [33]byte> -> <KeyRotationEntry>
+// -------------------------------------------------------------------------------------
+
+func _dbKeyForPendingKeyRotationEntry(oldPublicKey []byte) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	key := append([]byte{}, _PrefixPublicKeyToPendingKeyRotationEntry...)
+	key = append(key, oldPublicKey...)
+	return key
+}
+
+func DbPutPendingKeyRotationEntryWithTxn(txn *badger.Txn, keyRotationEntry *KeyRotationEntry) error {
+	keyRotationDataBuf := bytes.NewBuffer([]byte{})
+	gob.NewEncoder(keyRotationDataBuf).Encode(keyRotationEntry)
+
+	if err := txn.Set(_dbKeyForPendingKeyRotationEntry(keyRotationEntry.OldPublicKey), keyRotationDataBuf.Bytes()); err != nil {
+		return errors.Wrapf(err, "DbPutPendingKeyRotationEntryWithTxn: Problem adding "+
+			"pending key rotation for public key %v", PkToStringBoth(keyRotationEntry.OldPublicKey))
+	}
+	return nil
+}
+
+func DbDeletePendingKeyRotationEntryWithTxn(txn *badger.Txn, oldPublicKey []byte) error {
+	if err := txn.Delete(_dbKeyForPendingKeyRotationEntry(oldPublicKey)); err != nil {
+		return errors.Wrapf(err, "DbDeletePendingKeyRotationEntryWithTxn: Problem deleting "+
+			"pending key rotation for public key %v", PkToStringBoth(oldPublicKey))
+	}
+	return nil
+}
+
+func DbGetPendingKeyRotationEntryWithTxn(txn *badger.Txn, oldPublicKey []byte) *KeyRotationEntry {
+	keyRotationItem, err := txn.Get(_dbKeyForPendingKeyRotationEntry(oldPublicKey))
+	if err != nil {
+		return nil
+	}
+	keyRotationEntry := &KeyRotationEntry{}
+	err = keyRotationItem.Value(func(valBytes []byte) error {
+		return gob.NewDecoder(bytes.NewReader(valBytes)).Decode(keyRotationEntry)
+	})
+	if err != nil {
+		glog.Errorf("DbGetPendingKeyRotationEntryWithTxn: Problem reading "+
+			"KeyRotationEntry for public key %v", PkToStringBoth(oldPublicKey))
+		return nil
+	}
+	return keyRotationEntry
+}
+
+func DbGetPendingKeyRotationEntry(handle *badger.DB, oldPublicKey []byte) *KeyRotationEntry {
+	var ret *KeyRotationEntry
+	handle.View(func(txn *badger.Txn) error {
+		ret = DbGetPendingKeyRotationEntryWithTxn(txn, oldPublicKey)
+		return nil
+	})
+	return ret
+}
+
+// -------------------------------------------------------------------------------------
+// Recovery guardian mapping functions
+// 		<prefix, OwnerPKID [33]byte> -> <RecoveryGuardianSetEntry>
+// -------------------------------------------------------------------------------------
+
+func _dbKeyForRecoveryGuardianSetEntry(ownerPKID *PKID) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	key := append([]byte{}, _PrefixPKIDToRecoveryGuardianSetEntry...)
+	key = append(key, ownerPKID[:]...)
+	return key
+}
+
+func DbPutRecoveryGuardianSetEntryWithTxn(txn *badger.Txn, guardianSetEntry *RecoveryGuardianSetEntry) error {
+	guardianSetDataBuf := bytes.NewBuffer([]byte{})
+	gob.NewEncoder(guardianSetDataBuf).Encode(guardianSetEntry)
+
+	if err := txn.Set(_dbKeyForRecoveryGuardianSetEntry(guardianSetEntry.OwnerPKID), guardianSetDataBuf.Bytes()); err != nil {
+		return errors.Wrapf(err, "DbPutRecoveryGuardianSetEntryWithTxn: Problem adding "+
+			"recovery guardians for PKID %v", PkToStringBoth(guardianSetEntry.OwnerPKID[:]))
+	}
+	return nil
+}
+
+func DbDeleteRecoveryGuardianSetEntryWithTxn(txn *badger.Txn, ownerPKID *PKID) error {
+	if err := txn.Delete(_dbKeyForRecoveryGuardianSetEntry(ownerPKID)); err != nil {
+		return errors.Wrapf(err, "DbDeleteRecoveryGuardianSetEntryWithTxn: Problem deleting "+
+			"recovery guardians for PKID %v", PkToStringBoth(ownerPKID[:]))
+	}
+	return nil
+}
+
+func DbGetRecoveryGuardianSetEntryWithTxn(txn *badger.Txn, ownerPKID *PKID) *RecoveryGuardianSetEntry {
+	guardianSetItem, err := txn.Get(_dbKeyForRecoveryGuardianSetEntry(ownerPKID))
+	if err != nil {
+		return nil
+	}
+	guardianSetEntry := &RecoveryGuardianSetEntry{}
+	err = guardianSetItem.Value(func(valBytes []byte) error {
+		return gob.NewDecoder(bytes.NewReader(valBytes)).Decode(guardianSetEntry)
+	})
+	if err != nil {
+		glog.Errorf("DbGetRecoveryGuardianSetEntryWithTxn: Problem reading "+
+			"RecoveryGuardianSetEntry for PKID %v", PkToStringBoth(ownerPKID[:]))
+		return nil
+	}
+	return guardianSetEntry
+}
+
+func DbGetRecoveryGuardianSetEntry(handle *badger.DB, ownerPKID *PKID) *RecoveryGuardianSetEntry {
+	var ret *RecoveryGuardianSetEntry
+	handle.View(func(txn *badger.Txn) error {
+		ret = DbGetRecoveryGuardianSetEntryWithTxn(txn, ownerPKID)
+		return nil
+	})
+	return ret
+}
+
+// -------------------------------------------------------------------------------------
+// Pending account recovery mapping functions
+// 		<prefix, OwnerPKID [33]byte> -> <AccountRecoveryEntry>
+// -------------------------------------------------------------------------------------
+
+func _dbKeyForPendingAccountRecoveryEntry(ownerPKID *PKID) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	key := append([]byte{}, _PrefixPKIDToPendingAccountRecoveryEntry...)
+	key = append(key, ownerPKID[:]...)
+	return key
+}
+
+func DbPutPendingAccountRecoveryEntryWithTxn(txn *badger.Txn, accountRecoveryEntry *AccountRecoveryEntry) error {
+	accountRecoveryDataBuf := bytes.NewBuffer([]byte{})
+	gob.NewEncoder(accountRecoveryDataBuf).Encode(accountRecoveryEntry)
+
+	if err := txn.Set(_dbKeyForPendingAccountRecoveryEntry(accountRecoveryEntry.OwnerPKID), accountRecoveryDataBuf.Bytes()); err != nil {
+		return errors.Wrapf(err, "DbPutPendingAccountRecoveryEntryWithTxn: Problem adding "+
+			"pending account recovery for PKID %v", PkToStringBoth(accountRecoveryEntry.OwnerPKID[:]))
+	}
+	return nil
+}
+
+func DbDeletePendingAccountRecoveryEntryWithTxn(txn *badger.Txn, ownerPKID *PKID) error {
+	if err := txn.Delete(_dbKeyForPendingAccountRecoveryEntry(ownerPKID)); err != nil {
+		return errors.Wrapf(err, "DbDeletePendingAccountRecoveryEntryWithTxn: Problem deleting "+
+			"pending account recovery for PKID %v", PkToStringBoth(ownerPKID[:]))
+	}
+	return nil
+}
+
+func DbGetPendingAccountRecoveryEntryWithTxn(txn *badger.Txn, ownerPKID *PKID) *AccountRecoveryEntry {
+	accountRecoveryItem, err := txn.Get(_dbKeyForPendingAccountRecoveryEntry(ownerPKID))
+	if err != nil {
+		return nil
+	}
+	accountRecoveryEntry := &AccountRecoveryEntry{}
+	err = accountRecoveryItem.Value(func(valBytes []byte) error {
+		return gob.NewDecoder(bytes.NewReader(valBytes)).Decode(accountRecoveryEntry)
+	})
+	if err != nil {
+		glog.Errorf("DbGetPendingAccountRecoveryEntryWithTxn: Problem reading "+
+			"AccountRecoveryEntry for PKID %v", PkToStringBoth(ownerPKID[:]))
+		return nil
+	}
+	return accountRecoveryEntry
+}
+
+func DbGetPendingAccountRecoveryEntry(handle *badger.DB, ownerPKID *PKID) *AccountRecoveryEntry {
+	var ret *AccountRecoveryEntry
+	handle.View(func(txn *badger.Txn) error {
+		ret = DbGetPendingAccountRecoveryEntryWithTxn(txn, ownerPKID)
+		return nil
+	})
+	return ret
+}
+
+// -------------------------------------------------------------------------------------
+// Key rotation timelock mapping functions
+// 		<prefix, OwnerPKID [33]byte> -> <KeyRotationTimelockEntry>
+// -------------------------------------------------------------------------------------
+
+func _dbKeyForKeyRotationTimelockEntry(ownerPKID *PKID) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	key := append([]byte{}, _PrefixPKIDToKeyRotationTimelockEntry...)
+	key = append(key, ownerPKID[:]...)
+	return key
+}
+
+func DbPutKeyRotationTimelockEntryWithTxn(txn *badger.Txn, timelockEntry *KeyRotationTimelockEntry) error {
+	timelockDataBuf := bytes.NewBuffer([]byte{})
+	gob.NewEncoder(timelockDataBuf).Encode(timelockEntry)
+
+	if err := txn.Set(_dbKeyForKeyRotationTimelockEntry(timelockEntry.OwnerPKID), timelockDataBuf.Bytes()); err != nil {
+		return errors.Wrapf(err, "DbPutKeyRotationTimelockEntryWithTxn: Problem adding "+
+			"key rotation timelock for PKID %v", PkToStringBoth(timelockEntry.OwnerPKID[:]))
+	}
+	return nil
+}
+
+func DbDeleteKeyRotationTimelockEntryWithTxn(txn *badger.Txn, ownerPKID *PKID) error {
+	if err := txn.Delete(_dbKeyForKeyRotationTimelockEntry(ownerPKID)); err != nil {
+		return errors.Wrapf(err, "DbDeleteKeyRotationTimelockEntryWithTxn: Problem deleting "+
+			"key rotation timelock for PKID %v", PkToStringBoth(ownerPKID[:]))
+	}
+	return nil
+}
+
+func DbGetKeyRotationTimelockEntryWithTxn(txn *badger.Txn, ownerPKID *PKID) *KeyRotationTimelockEntry {
+	timelockItem, err := txn.Get(_dbKeyForKeyRotationTimelockEntry(ownerPKID))
+	if err != nil {
+		return nil
+	}
+	timelockEntry := &KeyRotationTimelockEntry{}
+	err = timelockItem.Value(func(valBytes []byte) error {
+		return gob.NewDecoder(bytes.NewReader(valBytes)).Decode(timelockEntry)
+	})
+	if err != nil {
+		glog.Errorf("DbGetKeyRotationTimelockEntryWithTxn: Problem reading "+
+			"KeyRotationTimelockEntry for PKID %v", PkToStringBoth(ownerPKID[:]))
+		return nil
+	}
+	return timelockEntry
+}
+
+func DbGetKeyRotationTimelockEntry(handle *badger.DB, ownerPKID *PKID) *KeyRotationTimelockEntry {
+	var ret *KeyRotationTimelockEntry
+	handle.View(func(txn *badger.Txn) error {
+		ret = DbGetKeyRotationTimelockEntryWithTxn(txn, ownerPKID)
+		return nil
+	})
+	return ret
+}
+
+// -------------------------------------------------------------------------------------
+// Rotated public key mapping functions
+// 		<prefix, OldPublicKey [33]byte> -> <RotatedPublicKeyEntry>
+// -------------------------------------------------------------------------------------
+
+func _dbKeyForRotatedPublicKeyEntry(oldPublicKey []byte) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	key := append([]byte{}, _PrefixPublicKeyToRotatedPublicKeyEntry...)
+	key = append(key, oldPublicKey...)
+	return key
+}
+
+func DbPutRotatedPublicKeyEntryWithTxn(txn *badger.Txn, rotatedPublicKeyEntry *RotatedPublicKeyEntry) error {
+	rotatedPublicKeyDataBuf := bytes.NewBuffer([]byte{})
+	gob.NewEncoder(rotatedPublicKeyDataBuf).Encode(rotatedPublicKeyEntry)
+
+	if err := txn.Set(_dbKeyForRotatedPublicKeyEntry(rotatedPublicKeyEntry.OldPublicKey), rotatedPublicKeyDataBuf.Bytes()); err != nil {
+		return errors.Wrapf(err, "DbPutRotatedPublicKeyEntryWithTxn: Problem adding "+
+			"rotated public key %v", PkToStringBoth(rotatedPublicKeyEntry.OldPublicKey))
+	}
+	return nil
+}
+
+func DbDeleteRotatedPublicKeyEntryWithTxn(txn *badger.Txn, oldPublicKey []byte) error {
+	if err := txn.Delete(_dbKeyForRotatedPublicKeyEntry(oldPublicKey)); err != nil {
+		return errors.Wrapf(err, "DbDeleteRotatedPublicKeyEntryWithTxn: Problem deleting "+
+			"rotated public key %v", PkToStringBoth(oldPublicKey))
+	}
+	return nil
+}
+
+func DbGetRotatedPublicKeyEntryWithTxn(txn *badger.Txn, oldPublicKey []byte) *RotatedPublicKeyEntry {
+	rotatedPublicKeyItem, err := txn.Get(_dbKeyForRotatedPublicKeyEntry(oldPublicKey))
+	if err != nil {
+		return nil
+	}
+	rotatedPublicKeyEntry := &RotatedPublicKeyEntry{}
+	err = rotatedPublicKeyItem.Value(func(valBytes []byte) error {
+		return gob.NewDecoder(bytes.NewReader(valBytes)).Decode(rotatedPublicKeyEntry)
+	})
+	if err != nil {
+		glog.Errorf("DbGetRotatedPublicKeyEntryWithTxn: Problem reading "+
+			"RotatedPublicKeyEntry for public key %v", PkToStringBoth(oldPublicKey))
+		return nil
+	}
+	return rotatedPublicKeyEntry
+}
+
+func DbGetRotatedPublicKeyEntry(handle *badger.DB, oldPublicKey []byte) *RotatedPublicKeyEntry {
+	var ret *RotatedPublicKeyEntry
+	handle.View(func(txn *badger.Txn) error {
+		ret = DbGetRotatedPublicKeyEntryWithTxn(txn, oldPublicKey)
+		return nil
+	})
+	return ret
+}
+
+// Specifying minTimestampNanos gives you all posts after minTimestampNanos
+// Pass minTimestampNanos = 0 && maxTimestampNanos = 0 if you want all posts
+// Setting maxTimestampNanos = 0, will default maxTimestampNanos to the current time.
+func DBGetAllPostsAndCommentsForPublicKeyOrderedByTimestamp(
+	handle *badger.DB, publicKey []byte, fetchEntries bool, minTimestampNanos uint64, maxTimestampNanos uint64) (
+	_tstamps []uint64, _postAndCommentHashes []*BlockHash, _postAndCommentEntries []*PostEntry, _err error) {
+
+	tstampsFetched := []uint64{}
+	postAndCommentHashesFetched := []*BlockHash{}
+	postAndCommentEntriesFetched := []*PostEntry{}
+	dbPrefixx := append([]byte{}, _PrefixPosterPublicKeyTimestampPostHash...)
+	dbPrefixx = append(dbPrefixx, publicKey...)
+
+	err := handle.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+
+		opts.PrefetchValues = false
+
+		// Go in reverse order since a larger count is better.
+		opts.Reverse = true
 
 		it := txn.NewIterator(opts)
 		defer it.Close()
@@ -4528,6 +6090,15 @@ func DBDeleteNFTMappingsWithTxn(txn *badger.Txn, nftPostHash *BlockHash, serialN
 			"nft mapping for post hash %v serial number %d", nftPostHash, serialNumber)
 	}
 
+	// If the nftEntry was up for auction, delete the auction mapping.
+	if nftEntry.AuctionEndBlockHeight > 0 {
+		if err := txn.Delete(_dbKeyForAuctionEndBlockHeightNFTPostHashSerialNumber(
+			nftEntry.AuctionEndBlockHeight, nftPostHash, serialNumber)); err != nil {
+			return errors.Wrapf(err, "DbDeleteNFTMappingsWithTxn: Deleting "+
+				"auction mapping for post hash %v serial number %d", nftPostHash, serialNumber)
+		}
+	}
+
 	return nil
 }
 
@@ -4539,6 +6110,16 @@ func DBDeleteNFTMappings(
 	})
 }
 
+func _dbKeyForAuctionEndBlockHeightNFTPostHashSerialNumber(
+	auctionEndBlockHeight uint64, nftPostHash *BlockHash, serialNumber uint64) []byte {
+	// Make a copy to avoid multiple calls to this function re-using the same slice.
+	prefixCopy := append([]byte{}, _PrefixAuctionEndBlockHeightPostHashSerialNumber...)
+	key := append(prefixCopy, EncodeUint64(auctionEndBlockHeight)...)
+	key = append(key, nftPostHash[:]...)
+	key = append(key, EncodeUint64(serialNumber)...)
+	return key
+}
+
 func DBPutNFTEntryMappingsWithTxn(txn *badger.Txn, nftEntry *NFTEntry) error {
 
 	nftDataBuf := bytes.NewBuffer([]byte{})
@@ -4558,6 +6139,14 @@ func DBPutNFTEntryMappingsWithTxn(txn *badger.Txn, nftEntry *NFTEntry) error {
 			"adding mapping for pkid: %v, post: %v, serial number: %d", nftEntry.OwnerPKID, nftEntry.NFTPostHash, nftEntry.SerialNumber)
 	}
 
+	if nftEntry.AuctionEndBlockHeight > 0 {
+		if err := txn.Set(_dbKeyForAuctionEndBlockHeightNFTPostHashSerialNumber(
+			nftEntry.AuctionEndBlockHeight, nftEntry.NFTPostHash, nftEntry.SerialNumber), []byte{}); err != nil {
+			return errors.Wrapf(err, "DbPutNFTEntryMappingsWithTxn: Problem "+
+				"adding auction mapping for post: %v, serial number: %d", nftEntry.NFTPostHash, nftEntry.SerialNumber)
+		}
+	}
+
 	return nil
 }
 
@@ -4582,6 +6171,36 @@ func DBGetNFTEntriesForPostHash(handle *badger.DB, nftPostHash *BlockHash) (_nft
 	return nftEntries
 }
 
+// DBGetNFTKeysWithAuctionsEndingByHeight returns the keys of all the NFTs whose auctions
+// end at or before blockHeight, ordered by end height, post hash and serial number.
+func DBGetNFTKeysWithAuctionsEndingByHeight(handle *badger.DB, blockHeight uint64) (_nftKeys []NFTKey) {
+	nftKeys := []NFTKey{}
+	handle.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		nodeIterator := txn.NewIterator(opts)
+		defer nodeIterator.Close()
+		prefix := _PrefixAuctionEndBlockHeightPostHashSerialNumber
+		for nodeIterator.Seek(prefix); nodeIterator.ValidForPrefix(prefix); nodeIterator.Next() {
+			key := nodeIterator.Item().Key()
+			if len(key) != 1+8+HashSizeBytes+8 {
+				glog.Errorf("DBGetNFTKeysWithAuctionsEndingByHeight: Invalid key length %d", len(key))
+				continue
+			}
+			// The keys are sorted by end height so we can stop at the first auction
+			// that ends after blockHeight.
+			if DecodeUint64(key[1:9]) > blockHeight {
+				break
+			}
+			nftPostHash := &BlockHash{}
+			copy(nftPostHash[:], key[9:9+HashSizeBytes])
+			nftKeys = append(nftKeys, MakeNFTKey(nftPostHash, DecodeUint64(key[9+HashSizeBytes:])))
+		}
+		return nil
+	})
+	return nftKeys
+}
+
 // =======================================================================================
 // NFTOwnership db functions
 // NOTE: This index is not essential to running the protocol and should be computed
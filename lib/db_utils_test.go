@@ -570,3 +570,113 @@ func TestFollows(t *testing.T) {
 		require.Equal(len(pubKeys), 0)
 	}
 }
+
+func TestDbIntegrityCheckAndRepair(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_ = assert
+	_ = require
+
+	_, _, db := NewLowDifficultyBlockchain()
+	params := &DeSoTestnetParams
+
+	// A freshly initialized chain should be consistent.
+	issues, err := CheckDbIntegrity(db)
+	require.NoError(err)
+	require.Equal(0, len(issues), "%v", issues)
+
+	priv1, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(err)
+	pk1 := priv1.PubKey().SerializeCompressed()
+	priv2, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(err)
+	pk2 := priv2.PubKey().SerializeCompressed()
+	pkid1 := PublicKeyToPKID(pk1)
+	pkid2 := PublicKeyToPKID(pk2)
+
+	profileEntry := &ProfileEntry{
+		PublicKey: pk1,
+		Username:  []byte("Alice"),
+		CreatorCoinEntry: CoinEntry{
+			DeSoLockedNanos: 123,
+		},
+	}
+	require.NoError(DBPutProfileEntryMappings(db, profileEntry, pkid1, params))
+	require.NoError(DbPutFollowMappings(db, pkid1, pkid2))
+
+	// Simulate a crash that dropped the username index and one direction of
+	// the follow index, and a bid left behind for an NFT that doesn't exist.
+	require.NoError(db.Update(func(txn *badger.Txn) error {
+		if err := txn.Delete(_dbKeyForProfileUsernameToPKID(profileEntry.Username)); err != nil {
+			return err
+		}
+		return txn.Delete(_dbKeyForFollowerToFollowedMapping(pkid1, pkid2))
+	}))
+	require.NoError(DBPutNFTBidEntryMappings(db, &NFTBidEntry{
+		BidderPKID:     pkid2,
+		NFTPostHash:    &BlockHash{0x01},
+		SerialNumber:   1,
+		BidAmountNanos: 100,
+	}))
+	// A diamond that lost its sender index and a repost that lost its primary entry.
+	diamondEntry := &DiamondEntry{
+		SenderPKID:      pkid2,
+		ReceiverPKID:    pkid1,
+		DiamondPostHash: &BlockHash{0x02},
+		DiamondLevel:    1,
+	}
+	require.NoError(DbPutDiamondMappings(db, diamondEntry))
+	require.NoError(db.Update(func(txn *badger.Txn) error {
+		if err := txn.Delete(_dbKeyForDiamondSenderToDiamondReceiverMapping(diamondEntry)); err != nil {
+			return err
+		}
+		return txn.Set(_dbKeyForRepostedPostHashReposterPubKey(&BlockHash{0x03}, pk2), []byte{})
+	}))
+	// A post in an NFT collection that doesn't exist.
+	collectionPostEntry := &PostEntry{
+		PostHash:        &BlockHash{0x04},
+		PosterPublicKey: pk1,
+		NFTCollectionID: &BlockHash{0x05},
+	}
+	require.NoError(DBPutPostEntryMappings(db, collectionPostEntry, params))
+
+	issues, err = CheckDbIntegrity(db)
+	require.NoError(err)
+	issueTypes := make(map[string]DbIntegrityIssueType)
+	for _, issue := range issues {
+		issueTypes[issue.CheckName+string(issue.Key[:1])] = issue.Type
+	}
+	require.Equal(DbIntegrityIssueMissingEntry,
+		issueTypes["profile"+string(_PrefixProfileUsernameToPKID)])
+	require.Equal(DbIntegrityIssueDanglingEntry,
+		issueTypes["follow"+string(_PrefixFollowedPKIDToFollowerPKID)])
+	require.Equal(DbIntegrityIssueDanglingEntry,
+		issueTypes["nft-bid"+string(_PrefixPostHashSerialNumberBidNanosBidderPKID)])
+	require.Equal(DbIntegrityIssueDanglingEntry,
+		issueTypes["nft-bid"+string(_PrefixBidderPKIDPostHashSerialNumberToBidNanos)])
+	require.Equal(DbIntegrityIssueMissingEntry,
+		issueTypes["diamond"+string(_PrefixDiamondSenderPKIDDiamondReceiverPKIDPostHash)])
+	require.Equal(DbIntegrityIssueDanglingEntry,
+		issueTypes["repost"+string(_PrefixRepostedPostHashReposterPubKey)])
+	require.Equal(DbIntegrityIssueBrokenReference,
+		issueTypes["nft-collection"+string(_PrefixPostHashToPostEntry)])
+	require.Equal(7, len(issues), "%v", issues)
+
+	numRepaired, err := RepairDbIntegrityIssues(db, issues)
+	require.NoError(err)
+	require.Equal(6, numRepaired)
+
+	// The username index is rebuilt and the dangling entries are gone, but the
+	// post in the missing collection is left for an operator to look at.
+	issues, err = CheckDbIntegrity(db)
+	require.NoError(err)
+	require.Equal(1, len(issues), "%v", issues)
+	require.Equal(DbIntegrityIssueBrokenReference, issues[0].Type)
+	require.NotNil(DBGetPostEntryByPostHash(db, collectionPostEntry.PostHash))
+	require.NoError(db.View(func(txn *badger.Txn) error {
+		pkidBytes, exists := _dbGetValueWithTxn(txn, _dbKeyForProfileUsernameToPKID([]byte("alice")))
+		require.True(exists)
+		require.Equal(pkid1[:], pkidBytes)
+		return nil
+	}))
+}
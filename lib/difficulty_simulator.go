@@ -0,0 +1,160 @@
+package lib
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// difficulty_simulator.go replays hashrate scenarios through
+// CalcNextDifficultyTarget so that retarget algorithms and their parameters can
+// be compared without running real miners. Block solve times are drawn from
+// the exponential distribution that proof-of-work mining follows, so the
+// simulated block times are as noisy as they would be on a real network.
+
+// HashrateScenarioPhase is a run of blocks mined at a constant hashrate.
+type HashrateScenarioPhase struct {
+	NumBlocks int
+	// The hashrate as a multiple of the hashrate that mines one block every
+	// TimeBetweenBlocks at the min difficulty target. A multiplier of 4 mines
+	// blocks four times too fast until the difficulty catches up.
+	HashrateMultiplier float64
+}
+
+// DifficultySimulationStats summarizes the block times of a run of blocks.
+type DifficultySimulationStats struct {
+	NumBlocks          int
+	HashrateMultiplier float64
+	MeanBlockTimeSecs  float64
+	// The variance is in seconds squared.
+	BlockTimeVariance   float64
+	StdDevBlockTimeSecs float64
+}
+
+type DifficultySimulationResult struct {
+	// The solve time and the difficulty target of every simulated block in order.
+	BlockTimesSecs    []float64
+	DifficultyTargets []*BlockHash
+
+	// Stats for each phase of the scenario followed by stats for all the blocks.
+	PhaseStats   []*DifficultySimulationStats
+	OverallStats *DifficultySimulationStats
+}
+
+// ParseHashrateScenario parses a scenario of the form "blocks:multiplier,..."
+// such as "200:1,200:4,200:0.5".
+func ParseHashrateScenario(scenario string) ([]*HashrateScenarioPhase, error) {
+	phases := []*HashrateScenarioPhase{}
+	for _, phaseStr := range strings.Split(scenario, ",") {
+		parts := strings.Split(strings.TrimSpace(phaseStr), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("ParseHashrateScenario: Phase %q must be of the "+
+				"form blocks:multiplier", phaseStr)
+		}
+		numBlocks, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "ParseHashrateScenario: Problem parsing "+
+				"number of blocks in phase %q: ", phaseStr)
+		}
+		multiplier, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "ParseHashrateScenario: Problem parsing "+
+				"hashrate multiplier in phase %q: ", phaseStr)
+		}
+		if numBlocks <= 0 || multiplier <= 0 {
+			return nil, fmt.Errorf("ParseHashrateScenario: Phase %q must have a "+
+				"positive number of blocks and hashrate multiplier", phaseStr)
+		}
+		phases = append(phases, &HashrateScenarioPhase{
+			NumBlocks:          numBlocks,
+			HashrateMultiplier: multiplier,
+		})
+	}
+	return phases, nil
+}
+
+// SimulateDifficultyAdjustment mines the blocks described by the phases on top of
+// a genesis block at the min difficulty and returns the resulting block times.
+// The same seed always produces the same result.
+func SimulateDifficultyAdjustment(
+	params *DeSoParams, phases []*HashrateScenarioPhase, seed int64) (
+	*DifficultySimulationResult, error) {
+
+	minDiffBytes, err := hex.DecodeString(params.MinDifficultyTargetHex)
+	if err != nil {
+		return nil, errors.Wrapf(err, "SimulateDifficultyAdjustment: Problem decoding min difficulty: ")
+	}
+	// Add one to every target since a target of t is met by t+1 hash values.
+	minDiffPlusOne := new(big.Float).SetInt(new(big.Int).Add(new(big.Int).SetBytes(minDiffBytes), big.NewInt(1)))
+	targetBlockTimeSecs := params.TimeBetweenBlocks.Seconds()
+
+	rr := rand.New(rand.NewSource(seed))
+	lastNode := NewBlockNode(
+		nil, nil, 0, CopyBytesIntoBlockHash(minDiffBytes), nil,
+		&MsgDeSoHeader{TstampSecs: 0, Height: 0}, StatusNone)
+	clockSecs := float64(0)
+
+	result := &DifficultySimulationResult{}
+	for _, phase := range phases {
+		phaseStart := len(result.BlockTimesSecs)
+		for ii := 0; ii < phase.NumBlocks; ii++ {
+			diffTarget, err := CalcNextDifficultyTarget(lastNode, CurrentHeaderVersion, params)
+			if err != nil {
+				return nil, errors.Wrapf(err, "SimulateDifficultyAdjustment: ")
+			}
+
+			// At the min difficulty and a multiplier of one, blocks take
+			// TimeBetweenBlocks on average. A harder target takes proportionally longer.
+			targetPlusOne := new(big.Float).SetInt(new(big.Int).Add(HashToBigint(diffTarget), big.NewInt(1)))
+			difficultyRatio, _ := new(big.Float).Quo(minDiffPlusOne, targetPlusOne).Float64()
+			solveTimeSecs := rr.ExpFloat64() * targetBlockTimeSecs * difficultyRatio / phase.HashrateMultiplier
+
+			clockSecs += solveTimeSecs
+			height := lastNode.Height + 1
+			lastNode = NewBlockNode(
+				lastNode, nil, height, diffTarget, nil,
+				&MsgDeSoHeader{TstampSecs: uint64(clockSecs), Height: uint64(height)}, StatusNone)
+
+			result.BlockTimesSecs = append(result.BlockTimesSecs, solveTimeSecs)
+			result.DifficultyTargets = append(result.DifficultyTargets, diffTarget)
+		}
+		phaseStats := ComputeBlockTimeStats(result.BlockTimesSecs[phaseStart:])
+		phaseStats.HashrateMultiplier = phase.HashrateMultiplier
+		result.PhaseStats = append(result.PhaseStats, phaseStats)
+	}
+	result.OverallStats = ComputeBlockTimeStats(result.BlockTimesSecs)
+
+	return result, nil
+}
+
+// ComputeBlockTimeStats computes the mean and variance of the block times passed in.
+func ComputeBlockTimeStats(blockTimesSecs []float64) *DifficultySimulationStats {
+	stats := &DifficultySimulationStats{
+		NumBlocks: len(blockTimesSecs),
+	}
+	if len(blockTimesSecs) == 0 {
+		return stats
+	}
+
+	sum := float64(0)
+	for _, blockTime := range blockTimesSecs {
+		sum += blockTime
+	}
+	stats.MeanBlockTimeSecs = sum / float64(len(blockTimesSecs))
+
+	sumSquaredDiffs := float64(0)
+	for _, blockTime := range blockTimesSecs {
+		diff := blockTime - stats.MeanBlockTimeSecs
+		sumSquaredDiffs += diff * diff
+	}
+	stats.BlockTimeVariance = sumSquaredDiffs / float64(len(blockTimesSecs))
+	stats.StdDevBlockTimeSecs = math.Sqrt(stats.BlockTimeVariance)
+
+	return stats
+}
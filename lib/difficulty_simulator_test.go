@@ -0,0 +1,92 @@
+package lib
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHashrateScenario(t *testing.T) {
+	require := require.New(t)
+
+	phases, err := ParseHashrateScenario("200:1, 300:4,100:0.5")
+	require.NoError(err)
+	require.Equal([]*HashrateScenarioPhase{
+		{NumBlocks: 200, HashrateMultiplier: 1},
+		{NumBlocks: 300, HashrateMultiplier: 4},
+		{NumBlocks: 100, HashrateMultiplier: 0.5},
+	}, phases)
+
+	for _, badScenario := range []string{"", "200", "200:1:2", "abc:1", "200:abc", "0:1", "200:0"} {
+		_, err := ParseHashrateScenario(badScenario)
+		require.Errorf(err, "Scenario: %q", badScenario)
+	}
+}
+
+func TestSimulateDifficultyAdjustmentEWMAConverges(t *testing.T) {
+	require := require.New(t)
+
+	// A one minute block time with a 100 block retarget period, which reacts to
+	// hashrate swings about as slowly as our small testnets do.
+	minDiff := new(big.Int).Lsh(big.NewInt(1), 240)
+	windowedParams := &DeSoParams{
+		MinDifficultyTargetHex:         hex.EncodeToString(BigintToHash(minDiff)[:]),
+		TimeBetweenBlocks:              1 * time.Minute,
+		TimeBetweenDifficultyRetargets: 100 * time.Minute,
+		MaxDifficultyRetargetFactor:    2,
+	}
+	ewmaParams := *windowedParams
+	ewmaParams.DifficultyEWMAWindowBlocks = 20
+
+	// The hashrate quadruples after 300 blocks.
+	phases := []*HashrateScenarioPhase{
+		{NumBlocks: 300, HashrateMultiplier: 1},
+		{NumBlocks: 600, HashrateMultiplier: 4},
+	}
+	windowedResult, err := SimulateDifficultyAdjustment(windowedParams, phases, 1)
+	require.NoError(err)
+	ewmaResult, err := SimulateDifficultyAdjustment(&ewmaParams, phases, 1)
+	require.NoError(err)
+	require.Equal(900, len(ewmaResult.BlockTimesSecs))
+	require.Equal(900, len(ewmaResult.DifficultyTargets))
+	require.Equal(2, len(ewmaResult.PhaseStats))
+	require.Equal(float64(4), ewmaResult.PhaseStats[1].HashrateMultiplier)
+	require.Equal(900, ewmaResult.OverallStats.NumBlocks)
+
+	// The same seed produces the same result.
+	ewmaResultAgain, err := SimulateDifficultyAdjustment(&ewmaParams, phases, 1)
+	require.NoError(err)
+	require.Equal(ewmaResult.BlockTimesSecs, ewmaResultAgain.BlockTimesSecs)
+
+	// Right after the hashrate jumps, the windowed retarget keeps mining blocks
+	// about four times too fast until its next retarget. The EWMA retarget starts
+	// catching up right away.
+	windowedAfterJump := ComputeBlockTimeStats(windowedResult.BlockTimesSecs[300:400])
+	ewmaAfterJump := ComputeBlockTimeStats(ewmaResult.BlockTimesSecs[300:400])
+	require.Less(windowedAfterJump.MeanBlockTimeSecs, float64(25))
+	require.Greater(ewmaAfterJump.MeanBlockTimeSecs, 2*windowedAfterJump.MeanBlockTimeSecs)
+
+	// Once it has converged, the EWMA retarget mines blocks close to the target
+	// block time at about a quarter of the min difficulty target.
+	ewmaConverged := ComputeBlockTimeStats(ewmaResult.BlockTimesSecs[700:])
+	require.InDelta(float64(60), ewmaConverged.MeanBlockTimeSecs, 9)
+	finalTarget := new(big.Float).SetInt(HashToBigint(ewmaResult.DifficultyTargets[899]))
+	finalRatio, _ := finalTarget.Quo(finalTarget, new(big.Float).SetInt(minDiff)).Float64()
+	require.Greater(finalRatio, float64(1)/6)
+	require.Less(finalRatio, float64(1)/3)
+}
+
+func TestComputeBlockTimeStats(t *testing.T) {
+	require := require.New(t)
+
+	stats := ComputeBlockTimeStats([]float64{30, 60, 90})
+	require.Equal(3, stats.NumBlocks)
+	require.Equal(float64(60), stats.MeanBlockTimeSecs)
+	require.Equal(float64(600), stats.BlockTimeVariance)
+	require.InDelta(24.49, stats.StdDevBlockTimeSecs, 0.01)
+
+	require.Equal(0, ComputeBlockTimeStats(nil).NumBlocks)
+}
@@ -64,6 +64,7 @@ const (
 	RuleErrorInvalidBlockProducerSIgnature                      RuleError = "RuleErrorInvalidBlockProducerSIgnature"
 	RuleErrorInvalidBlockHeader                                 RuleError = "RuleErrorInvalidBlockHeader"
 	RuleErrorOrphanBlock                                        RuleError = "RuleErrorOrphanBlock"
+	RuleErrorReorgDeeperThanPruneDepth                          RuleError = "RuleErrorReorgDeeperThanPruneDepth"
 	RuleErrorInputWithPublicKeyDifferentFromTxnPublicKey        RuleError = "RuleErrorInputWithPublicKeyDifferentFromTxnPublicKey"
 	RuleErrorBlockRewardTxnNotAllowedToHaveInputs               RuleError = "RuleErrorBlockRewardTxnNotAllowedToHaveInputs"
 	RuleErrorBlockRewardTxnNotAllowedToHaveSignature            RuleError = "RuleErrorBlockRewardTxnNotAllowedToHaveSignature"
@@ -138,6 +139,7 @@ const (
 	RuleErrorSubmitPostInvalidPostHashToModify       RuleError = "RuleErrorSubmitPostInvalidPostHashToModify"
 	RuleErrorSubmitPostModifyingNonexistentPost      RuleError = "RuleErrorSubmitPostModifyingNonexistentPost"
 	RuleErrorSubmitPostPostModificationNotAuthorized RuleError = "RuleErrorSubmitPostPostModificationNotAuthorized"
+	RuleErrorSubmitPostFromRotatedPublicKey          RuleError = "RuleErrorSubmitPostFromRotatedPublicKey"
 	RuleErrorSubmitPostInvalidParentStakeIDLength    RuleError = "RuleErrorSubmitPostInvalidParentStakeIDLength"
 	RuleErrorSubmitPostParentNotFound                RuleError = "RuleErrorSubmitPostParentNotFound"
 	RuleErrorSubmitPostRepostPostNotFound            RuleError = "RuleErrorSubmitPostRepostPostNotFound"
@@ -221,6 +223,8 @@ const (
 	RuleErrorCreatorCoinSellerBalanceEntryDoesNotExist                 RuleError = "RuleErrorCreatorCoinSellerBalanceEntryDoesNotExist"
 	RuleErrorCreatorCoinSellInsufficientCoins                          RuleError = "RuleErrorCreatorCoinSellInsufficientCoins"
 	RuleErrorCreatorCoinSellNotAllowedWhenZeroDeSoLocked               RuleError = "RuleErrorCreatorCoinSellNotAllowedWhenZeroDeSoLocked"
+	RuleErrorCreatorCoinInvalidDeadlineBlockHeight                     RuleError = "RuleErrorCreatorCoinInvalidDeadlineBlockHeight"
+	RuleErrorCreatorCoinPastDeadlineBlockHeight                        RuleError = "RuleErrorCreatorCoinPastDeadlineBlockHeight"
 	RuleErrorDeSoReceivedIsLessThanMinimumSetBySeller                  RuleError = "RuleErrorDeSoReceivedIsLessThanMinimumSetBySeller"
 
 	// DAO Coins
@@ -276,6 +280,28 @@ const (
 	RuleErrorMessagingKeySignatureNotProvided       RuleError = "RuleErrorMessagingKeySignatureNotProvided"
 	RuleErrorMessagingKeyBeforeBlockHeight          RuleError = "RuleErrorMessagingKeyBeforeBlockHeight"
 
+	// Messaging group updates
+	RuleErrorMessagingGroupUpdateBeforeBlockHeight      RuleError = "RuleErrorMessagingGroupUpdateBeforeBlockHeight"
+	RuleErrorMessagingGroupUpdateRequiresNonZeroInput   RuleError = "RuleErrorMessagingGroupUpdateRequiresNonZeroInput"
+	RuleErrorMessagingGroupUpdateUnencryptedGroup       RuleError = "RuleErrorMessagingGroupUpdateUnencryptedGroup"
+	RuleErrorMessagingGroupUpdateNoChanges              RuleError = "RuleErrorMessagingGroupUpdateNoChanges"
+	RuleErrorMessagingGroupUpdateNotOwnerOrAdmin        RuleError = "RuleErrorMessagingGroupUpdateNotOwnerOrAdmin"
+	RuleErrorMessagingGroupUpdateAdminCanOnlyAddMembers RuleError = "RuleErrorMessagingGroupUpdateAdminCanOnlyAddMembers"
+	RuleErrorMessagingGroupDoesntExist                  RuleError = "RuleErrorMessagingGroupDoesntExist"
+	RuleErrorMessagingGroupDuplicatePublicKey           RuleError = "RuleErrorMessagingGroupDuplicatePublicKey"
+	RuleErrorMessagingGroupMemberDoesntExist            RuleError = "RuleErrorMessagingGroupMemberDoesntExist"
+	RuleErrorMessagingGroupMemberRemovedAndAdded        RuleError = "RuleErrorMessagingGroupMemberRemovedAndAdded"
+	RuleErrorMessagingGroupRekeySameKey                 RuleError = "RuleErrorMessagingGroupRekeySameKey"
+	RuleErrorMessagingGroupRekeyMissingMember           RuleError = "RuleErrorMessagingGroupRekeyMissingMember"
+	RuleErrorMessagingGroupAdminNotMember               RuleError = "RuleErrorMessagingGroupAdminNotMember"
+	RuleErrorMessagingGroupAdminAlreadyExists           RuleError = "RuleErrorMessagingGroupAdminAlreadyExists"
+	RuleErrorMessagingGroupAdminDoesntExist             RuleError = "RuleErrorMessagingGroupAdminDoesntExist"
+
+	// Message read receipts
+	RuleErrorMessageReadBeforeBlockHeight   RuleError = "RuleErrorMessageReadBeforeBlockHeight"
+	RuleErrorMessageReadThreadPubKeyLen     RuleError = "RuleErrorMessageReadThreadPubKeyLen"
+	RuleErrorMessageReadTstampNotIncreasing RuleError = "RuleErrorMessageReadTstampNotIncreasing"
+
 	// NFTs
 	RuleErrorTooManyNFTCopies                            RuleError = "RuleErrorTooManyNFTCopies"
 	RuleErrorCreateNFTRequiresNonZeroInput               RuleError = "RuleErrorCreateNFTRequiresNonZeroInput"
@@ -329,6 +355,19 @@ const (
 	RuleErrorNFTBidLessThanMinBidAmountNanos               RuleError = "RuleErrorNFTBidLessThanMinBidAmountNanos"
 	RuleErrorZeroBidOnBuyNowNFT                            RuleError = "RuleErrorZeroBidOnBuyNowNFT"
 
+	// NFT Auctions
+	RuleErrorNFTAuctionEndBlockHeightInPast     RuleError = "RuleErrorNFTAuctionEndBlockHeightInPast"
+	RuleErrorNFTAuctionMustBeForSale            RuleError = "RuleErrorNFTAuctionMustBeForSale"
+	RuleErrorCannotHaveUnlockableAndNFTAuction  RuleError = "RuleErrorCannotHaveUnlockableAndNFTAuction"
+	RuleErrorCannotHaveBuyNowAndNFTAuction      RuleError = "RuleErrorCannotHaveBuyNowAndNFTAuction"
+	RuleErrorInvalidNFTAuctionExtraData         RuleError = "RuleErrorInvalidNFTAuctionExtraData"
+	RuleErrorCannotUpdateNFTInAuction           RuleError = "RuleErrorCannotUpdateNFTInAuction"
+	RuleErrorCannotAcceptBidOnNFTInAuction      RuleError = "RuleErrorCannotAcceptBidOnNFTInAuction"
+	RuleErrorNFTAuctionEnded                    RuleError = "RuleErrorNFTAuctionEnded"
+	RuleErrorNFTAuctionBidCannotBeCancelled     RuleError = "RuleErrorNFTAuctionBidCannotBeCancelled"
+	RuleErrorNFTAuctionBidBelowMinIncrement     RuleError = "RuleErrorNFTAuctionBidBelowMinIncrement"
+	RuleErrorNFTAuctionBidTxnOutputExceedsInput RuleError = "RuleErrorNFTAuctionBidTxnOutputExceedsInput"
+
 	// NFT Transfers
 	RuleErrorNFTTransferBeforeBlockHeight                 RuleError = "RuleErrorNFTTranserBeforeBlockHeight"
 	RuleErrorAcceptNFTTransferBeforeBlockHeight           RuleError = "RuleErrorAcceptNFTTranserBeforeBlockHeight"
@@ -351,6 +390,171 @@ const (
 	RuleErrorCannotBurnNFTThatIsForSale  RuleError = "RuleErrorCannotBurnNFTThatIsForSale"
 	RuleErrorBurnNFTRequiresNonZeroInput RuleError = "RuleErrorBurnNFTRequiresNonZeroInput"
 
+	// NFT Collections
+	RuleErrorNFTCollectionBeforeBlockHeight             RuleError = "RuleErrorNFTCollectionBeforeBlockHeight"
+	RuleErrorNFTCollectionNameEmpty                     RuleError = "RuleErrorNFTCollectionNameEmpty"
+	RuleErrorNFTCollectionNameTooLong                   RuleError = "RuleErrorNFTCollectionNameTooLong"
+	RuleErrorNFTCollectionRequiresNonZeroInput          RuleError = "RuleErrorNFTCollectionRequiresNonZeroInput"
+	RuleErrorCantCreateNFTCollectionWithoutProfileEntry RuleError = "RuleErrorCantCreateNFTCollectionWithoutProfileEntry"
+	RuleErrorInvalidNFTCollectionID                     RuleError = "RuleErrorInvalidNFTCollectionID"
+	RuleErrorNFTCollectionDoesNotExist                  RuleError = "RuleErrorNFTCollectionDoesNotExist"
+	RuleErrorNFTCollectionMustBeOwnedByPoster           RuleError = "RuleErrorNFTCollectionMustBeOwnedByPoster"
+	RuleErrorNFTCollectionMaxSupplyExceeded             RuleError = "RuleErrorNFTCollectionMaxSupplyExceeded"
+
+	// NFT Vaults
+	RuleErrorNFTVaultBeforeBlockHeight          RuleError = "RuleErrorNFTVaultBeforeBlockHeight"
+	RuleErrorNFTVaultRequiresNonZeroInput       RuleError = "RuleErrorNFTVaultRequiresNonZeroInput"
+	RuleErrorNFTVaultOnNonExistentNFT           RuleError = "RuleErrorNFTVaultOnNonExistentNFT"
+	RuleErrorNFTVaultInvalidOperationType       RuleError = "RuleErrorNFTVaultInvalidOperationType"
+	RuleErrorNFTVaultLockByNonOwner             RuleError = "RuleErrorNFTVaultLockByNonOwner"
+	RuleErrorCannotVaultNFTThatIsForSale        RuleError = "RuleErrorCannotVaultNFTThatIsForSale"
+	RuleErrorCannotVaultPendingNFT              RuleError = "RuleErrorCannotVaultPendingNFT"
+	RuleErrorCannotVaultUnlockableNFT           RuleError = "RuleErrorCannotVaultUnlockableNFT"
+	RuleErrorNFTIsAlreadyVaulted                RuleError = "RuleErrorNFTIsAlreadyVaulted"
+	RuleErrorNFTVaultMustMintNonZeroShares      RuleError = "RuleErrorNFTVaultMustMintNonZeroShares"
+	RuleErrorNFTVaultBuyoutPriceMustBeNonZero   RuleError = "RuleErrorNFTVaultBuyoutPriceMustBeNonZero"
+	RuleErrorNFTIsNotVaulted                    RuleError = "RuleErrorNFTIsNotVaulted"
+	RuleErrorNFTVaultRedeemRequiresAllShares    RuleError = "RuleErrorNFTVaultRedeemRequiresAllShares"
+	RuleErrorNFTVaultInvalidReceiverPubKeySize  RuleError = "RuleErrorNFTVaultInvalidReceiverPubKeySize"
+	RuleErrorNFTVaultCannotTransferSharesToSelf RuleError = "RuleErrorNFTVaultCannotTransferSharesToSelf"
+	RuleErrorNFTVaultMustTransferNonZeroShares  RuleError = "RuleErrorNFTVaultMustTransferNonZeroShares"
+	RuleErrorNFTVaultInsufficientShares         RuleError = "RuleErrorNFTVaultInsufficientShares"
+	RuleErrorCannotUpdateVaultedNFT             RuleError = "RuleErrorCannotUpdateVaultedNFT"
+	RuleErrorCannotAcceptBidOnVaultedNFT        RuleError = "RuleErrorCannotAcceptBidOnVaultedNFT"
+	RuleErrorCannotTransferVaultedNFT           RuleError = "RuleErrorCannotTransferVaultedNFT"
+	RuleErrorCannotBurnVaultedNFT               RuleError = "RuleErrorCannotBurnVaultedNFT"
+
+	// NFT Vouchers
+	RuleErrorRedeemNFTVoucherBeforeBlockHeight       RuleError = "RuleErrorRedeemNFTVoucherBeforeBlockHeight"
+	RuleErrorRedeemNFTVoucherRequiresNonZeroInput    RuleError = "RuleErrorRedeemNFTVoucherRequiresNonZeroInput"
+	RuleErrorNFTVoucherOnNonexistentPost             RuleError = "RuleErrorNFTVoucherOnNonexistentPost"
+	RuleErrorNFTVoucherOnVanillaRepost               RuleError = "RuleErrorNFTVoucherOnVanillaRepost"
+	RuleErrorNFTVoucherInvalidCreatorSignature       RuleError = "RuleErrorNFTVoucherInvalidCreatorSignature"
+	RuleErrorNFTVoucherCannotBeRedeemedByCreator     RuleError = "RuleErrorNFTVoucherCannotBeRedeemedByCreator"
+	RuleErrorNFTVoucherMustHaveNonZeroCopies         RuleError = "RuleErrorNFTVoucherMustHaveNonZeroCopies"
+	RuleErrorNFTVoucherTooManyCopies                 RuleError = "RuleErrorNFTVoucherTooManyCopies"
+	RuleErrorNFTVoucherInvalidSerialNumber           RuleError = "RuleErrorNFTVoucherInvalidSerialNumber"
+	RuleErrorNFTVoucherRoyaltyHasTooManyBasisPoints  RuleError = "RuleErrorNFTVoucherRoyaltyHasTooManyBasisPoints"
+	RuleErrorNFTVoucherOnNonLazyMintedNFT            RuleError = "RuleErrorNFTVoucherOnNonLazyMintedNFT"
+	RuleErrorNFTVoucherDoesNotMatchNFT               RuleError = "RuleErrorNFTVoucherDoesNotMatchNFT"
+	RuleErrorNFTVoucherAlreadyRedeemed               RuleError = "RuleErrorNFTVoucherAlreadyRedeemed"
+	RuleErrorNFTVoucherWithInsufficientFunds         RuleError = "RuleErrorNFTVoucherWithInsufficientFunds"
+	RuleErrorCantRedeemNFTVoucherWithoutProfileEntry RuleError = "RuleErrorCantRedeemNFTVoucherWithoutProfileEntry"
+	RuleErrorNFTVoucherCannotHaveAdditionalRoyalties RuleError = "RuleErrorNFTVoucherCannotHaveAdditionalRoyalties"
+
+	// Block Producers
+	RuleErrorBlockProducerRegistrationBeforeBlockHeight    RuleError = "RuleErrorBlockProducerRegistrationBeforeBlockHeight"
+	RuleErrorBlockProducerRegistrationRequiresNonZeroInput RuleError = "RuleErrorBlockProducerRegistrationRequiresNonZeroInput"
+	RuleErrorBlockProducerAlreadyRegistered                RuleError = "RuleErrorBlockProducerAlreadyRegistered"
+	RuleErrorBlockProducerNotRegistered                    RuleError = "RuleErrorBlockProducerNotRegistered"
+	RuleErrorBlockProducerBondBelowMinimum                 RuleError = "RuleErrorBlockProducerBondBelowMinimum"
+	RuleErrorBlockProducerBondExceedsInput                 RuleError = "RuleErrorBlockProducerBondExceedsInput"
+	RuleErrorBlockProducerNoBondToClaim                    RuleError = "RuleErrorBlockProducerNoBondToClaim"
+	RuleErrorBlockProducerBondStillUnbonding               RuleError = "RuleErrorBlockProducerBondStillUnbonding"
+	RuleErrorBlockProducerPublicKeyForbidden               RuleError = "RuleErrorBlockProducerPublicKeyForbidden"
+	RuleErrorBlockProducerNotScheduled                     RuleError = "RuleErrorBlockProducerNotScheduled"
+
+	// Username marketplace
+	RuleErrorUsernameListingBeforeBlockHeight           RuleError = "RuleErrorUsernameListingBeforeBlockHeight"
+	RuleErrorUsernameListingRequiresNonZeroInput        RuleError = "RuleErrorUsernameListingRequiresNonZeroInput"
+	RuleErrorUsernameListingRequiresProfile             RuleError = "RuleErrorUsernameListingRequiresProfile"
+	RuleErrorUsernameListingNotOwner                    RuleError = "RuleErrorUsernameListingNotOwner"
+	RuleErrorUsernameListingReservedUsername            RuleError = "RuleErrorUsernameListingReservedUsername"
+	RuleErrorUsernameListingInvalidReplacementUsername  RuleError = "RuleErrorUsernameListingInvalidReplacementUsername"
+	RuleErrorUsernameListingReplacementUsernameTaken    RuleError = "RuleErrorUsernameListingReplacementUsernameTaken"
+	RuleErrorUsernameListingInvalidRecipientPublicKey   RuleError = "RuleErrorUsernameListingInvalidRecipientPublicKey"
+	RuleErrorUsernameListingZeroPriceWithoutRecipient   RuleError = "RuleErrorUsernameListingZeroPriceWithoutRecipient"
+	RuleErrorUsernameListingDoesNotExist                RuleError = "RuleErrorUsernameListingDoesNotExist"
+	RuleErrorUsernameListingStale                       RuleError = "RuleErrorUsernameListingStale"
+	RuleErrorUsernameListingCannotAcceptOwnListing      RuleError = "RuleErrorUsernameListingCannotAcceptOwnListing"
+	RuleErrorUsernameListingNotRecipient                RuleError = "RuleErrorUsernameListingNotRecipient"
+	RuleErrorUsernameListingPriceMismatch               RuleError = "RuleErrorUsernameListingPriceMismatch"
+	RuleErrorAcceptUsernameListingTxnOutputExceedsInput RuleError = "RuleErrorAcceptUsernameListingTxnOutputExceedsInput"
+
+	// Key rotation
+	RuleErrorKeyRotationBeforeBlockHeight      RuleError = "RuleErrorKeyRotationBeforeBlockHeight"
+	RuleErrorKeyRotationRequiresNonZeroInput   RuleError = "RuleErrorKeyRotationRequiresNonZeroInput"
+	RuleErrorKeyRotationInvalidOperationType   RuleError = "RuleErrorKeyRotationInvalidOperationType"
+	RuleErrorKeyRotationCannotUseDerivedKey    RuleError = "RuleErrorKeyRotationCannotUseDerivedKey"
+	RuleErrorKeyRotationInvalidNewPublicKey    RuleError = "RuleErrorKeyRotationInvalidNewPublicKey"
+	RuleErrorKeyRotationInvalidNewKeySignature RuleError = "RuleErrorKeyRotationInvalidNewKeySignature"
+	RuleErrorKeyRotationNewKeyHasProfile       RuleError = "RuleErrorKeyRotationNewKeyHasProfile"
+	RuleErrorKeyRotationNewKeyHasDeletedPKID   RuleError = "RuleErrorKeyRotationNewKeyHasDeletedPKID"
+	RuleErrorKeyRotationOldKeyHasDeletedPKID   RuleError = "RuleErrorKeyRotationOldKeyHasDeletedPKID"
+	RuleErrorKeyRotationInvalidTimelock        RuleError = "RuleErrorKeyRotationInvalidTimelock"
+	RuleErrorKeyRotationAlreadyPending         RuleError = "RuleErrorKeyRotationAlreadyPending"
+	RuleErrorKeyRotationNotPending             RuleError = "RuleErrorKeyRotationNotPending"
+	RuleErrorKeyRotationNewPublicKeyMismatch   RuleError = "RuleErrorKeyRotationNewPublicKeyMismatch"
+	RuleErrorKeyRotationTimelockNotExpired     RuleError = "RuleErrorKeyRotationTimelockNotExpired"
+	RuleErrorKeyRotationDerivedKeyConflict     RuleError = "RuleErrorKeyRotationDerivedKeyConflict"
+	RuleErrorKeyRotationMessagingGroupConflict RuleError = "RuleErrorKeyRotationMessagingGroupConflict"
+	RuleErrorKeyRotationTimelockBelowMinimum   RuleError = "RuleErrorKeyRotationTimelockBelowMinimum"
+	RuleErrorKeyRotationOldKeyWasRotatedAway   RuleError = "RuleErrorKeyRotationOldKeyWasRotatedAway"
+	RuleErrorKeyRotationNewKeyWasRotatedAway   RuleError = "RuleErrorKeyRotationNewKeyWasRotatedAway"
+
+	// Account recovery
+	RuleErrorRecoveryGuardiansBeforeBlockHeight        RuleError = "RuleErrorRecoveryGuardiansBeforeBlockHeight"
+	RuleErrorRecoveryGuardiansRequiresNonZeroInput     RuleError = "RuleErrorRecoveryGuardiansRequiresNonZeroInput"
+	RuleErrorRecoveryGuardiansCannotUseDerivedKey      RuleError = "RuleErrorRecoveryGuardiansCannotUseDerivedKey"
+	RuleErrorRecoveryGuardiansTooMany                  RuleError = "RuleErrorRecoveryGuardiansTooMany"
+	RuleErrorRecoveryGuardiansInvalidGuardianPublicKey RuleError = "RuleErrorRecoveryGuardiansInvalidGuardianPublicKey"
+	RuleErrorRecoveryGuardiansDuplicateGuardian        RuleError = "RuleErrorRecoveryGuardiansDuplicateGuardian"
+	RuleErrorRecoveryGuardiansOwnerCannotBeGuardian    RuleError = "RuleErrorRecoveryGuardiansOwnerCannotBeGuardian"
+	RuleErrorRecoveryGuardiansInvalidThreshold         RuleError = "RuleErrorRecoveryGuardiansInvalidThreshold"
+	RuleErrorRecoveryGuardiansInvalidDelay             RuleError = "RuleErrorRecoveryGuardiansInvalidDelay"
+	RuleErrorRecoveryGuardiansNotRegistered            RuleError = "RuleErrorRecoveryGuardiansNotRegistered"
+	RuleErrorAccountRecoveryBeforeBlockHeight          RuleError = "RuleErrorAccountRecoveryBeforeBlockHeight"
+	RuleErrorAccountRecoveryRequiresNonZeroInput       RuleError = "RuleErrorAccountRecoveryRequiresNonZeroInput"
+	RuleErrorAccountRecoveryCannotUseDerivedKey        RuleError = "RuleErrorAccountRecoveryCannotUseDerivedKey"
+	RuleErrorAccountRecoveryInvalidOperationType       RuleError = "RuleErrorAccountRecoveryInvalidOperationType"
+	RuleErrorAccountRecoveryInvalidOwnerPublicKey      RuleError = "RuleErrorAccountRecoveryInvalidOwnerPublicKey"
+	RuleErrorAccountRecoveryNoGuardians                RuleError = "RuleErrorAccountRecoveryNoGuardians"
+	RuleErrorAccountRecoveryInvalidNewPublicKey        RuleError = "RuleErrorAccountRecoveryInvalidNewPublicKey"
+	RuleErrorAccountRecoveryInvalidNewKeySignature     RuleError = "RuleErrorAccountRecoveryInvalidNewKeySignature"
+	RuleErrorAccountRecoveryNotGuardian                RuleError = "RuleErrorAccountRecoveryNotGuardian"
+	RuleErrorAccountRecoveryDuplicateGuardianSignature RuleError = "RuleErrorAccountRecoveryDuplicateGuardianSignature"
+	RuleErrorAccountRecoveryInvalidGuardianSignature   RuleError = "RuleErrorAccountRecoveryInvalidGuardianSignature"
+	RuleErrorAccountRecoveryBelowThreshold             RuleError = "RuleErrorAccountRecoveryBelowThreshold"
+	RuleErrorAccountRecoveryInvalidDelay               RuleError = "RuleErrorAccountRecoveryInvalidDelay"
+	RuleErrorAccountRecoveryNotPending                 RuleError = "RuleErrorAccountRecoveryNotPending"
+	RuleErrorAccountRecoveryNewPublicKeyMismatch       RuleError = "RuleErrorAccountRecoveryNewPublicKeyMismatch"
+	RuleErrorAccountRecoveryCancelNotOwner             RuleError = "RuleErrorAccountRecoveryCancelNotOwner"
+	RuleErrorAccountRecoveryDelayNotExpired            RuleError = "RuleErrorAccountRecoveryDelayNotExpired"
+
+	// Polls
+	RuleErrorPollBeforeBlockHeight             RuleError = "RuleErrorPollBeforeBlockHeight"
+	RuleErrorPollInvalidDeclaration            RuleError = "RuleErrorPollInvalidDeclaration"
+	RuleErrorPollQuestionEmpty                 RuleError = "RuleErrorPollQuestionEmpty"
+	RuleErrorPollQuestionTooLong               RuleError = "RuleErrorPollQuestionTooLong"
+	RuleErrorPollTooFewOptions                 RuleError = "RuleErrorPollTooFewOptions"
+	RuleErrorPollTooManyOptions                RuleError = "RuleErrorPollTooManyOptions"
+	RuleErrorPollOptionEmpty                   RuleError = "RuleErrorPollOptionEmpty"
+	RuleErrorPollOptionTooLong                 RuleError = "RuleErrorPollOptionTooLong"
+	RuleErrorPollClosingBlockHeightNotInFuture RuleError = "RuleErrorPollClosingBlockHeightNotInFuture"
+	RuleErrorPollInvalidWeightType             RuleError = "RuleErrorPollInvalidWeightType"
+	RuleErrorPollWeightedPollRequiresProfile   RuleError = "RuleErrorPollWeightedPollRequiresProfile"
+	RuleErrorPollCannotBeAddedToExistingPost   RuleError = "RuleErrorPollCannotBeAddedToExistingPost"
+	RuleErrorPollVoteRequiresNonZeroInput      RuleError = "RuleErrorPollVoteRequiresNonZeroInput"
+	RuleErrorPollVoteOnNonexistentPoll         RuleError = "RuleErrorPollVoteOnNonexistentPoll"
+	RuleErrorPollVoteAfterPollClosed           RuleError = "RuleErrorPollVoteAfterPollClosed"
+	RuleErrorPollVoteInvalidOptionIndex        RuleError = "RuleErrorPollVoteInvalidOptionIndex"
+	RuleErrorPollVoteAlreadyExists             RuleError = "RuleErrorPollVoteAlreadyExists"
+	RuleErrorPollVoteZeroWeight                RuleError = "RuleErrorPollVoteZeroWeight"
+	RuleErrorPollVoteTallyOverflow             RuleError = "RuleErrorPollVoteTallyOverflow"
+
+	// User blocks
+	RuleErrorUserBlockBeforeBlockHeight                 RuleError = "RuleErrorUserBlockBeforeBlockHeight"
+	RuleErrorUserBlockPubKeyLen                         RuleError = "RuleErrorUserBlockPubKeyLen"
+	RuleErrorUserBlockInvalidBlockType                  RuleError = "RuleErrorUserBlockInvalidBlockType"
+	RuleErrorUserBlockCannotBlockSelf                   RuleError = "RuleErrorUserBlockCannotBlockSelf"
+	RuleErrorUserBlockRequiresNonZeroInput              RuleError = "RuleErrorUserBlockRequiresNonZeroInput"
+	RuleErrorUserBlockEntryAlreadyExists                RuleError = "RuleErrorUserBlockEntryAlreadyExists"
+	RuleErrorCannotUnblockNonexistentUserBlockEntry     RuleError = "RuleErrorCannotUnblockNonexistentUserBlockEntry"
+	RuleErrorUserBlockTypeMismatch                      RuleError = "RuleErrorUserBlockTypeMismatch"
+	RuleErrorPrivateMessageSenderBlockedByRecipient     RuleError = "RuleErrorPrivateMessageSenderBlockedByRecipient"
+	RuleErrorDiamondSenderBlockedByPoster               RuleError = "RuleErrorDiamondSenderBlockedByPoster"
+	RuleErrorCreatorCoinTransferSenderBlockedByReceiver RuleError = "RuleErrorCreatorCoinTransferSenderBlockedByReceiver"
+
 	RuleErrorSwapIdentityIsParamUpdaterOnly RuleError = "RuleErrorSwapIdentityIsParamUpdaterOnly"
 	RuleErrorFromPublicKeyIsRequired        RuleError = "RuleErrorFromPublicKeyIsRequired"
 	RuleErrorInvalidFromPublicKey           RuleError = "RuleErrorInvalidFromPublicKey"
@@ -363,6 +567,7 @@ const (
 	HeaderErrorNilPrevHash                                                       RuleError = "HeaderErrorNilPrevHash"
 	HeaderErrorInvalidParent                                                     RuleError = "HeaderErrorInvalidParent"
 	HeaderErrorBlockTooFarInTheFuture                                            RuleError = "HeaderErrorBlockTooFarInTheFuture"
+	HeaderErrorBlockProducerFallbackInTheFuture                                  RuleError = "HeaderErrorBlockProducerFallbackInTheFuture"
 	HeaderErrorTimestampTooEarly                                                 RuleError = "HeaderErrorTimestampTooEarly"
 	HeaderErrorBlockDifficultyAboveTarget                                        RuleError = "HeaderErrorBlockDifficultyAboveTarget"
 	HeaderErrorHeightInvalid                                                     RuleError = "HeaderErrorHeightInvalid"
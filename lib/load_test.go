@@ -149,7 +149,7 @@ func TestComputeMaxTPS(t *testing.T) {
 	// long it takes to add them all to the mempool.
 	mempool.resetPool(NewDeSoMempool(mempool.bc, 0, /* rateLimitFeeRateNanosPerKB */
 		0, /* minFeeRateNanosPerKB */
-		"" /*blockCypherAPIKey*/, false,
+		false,
 		"" /*dataDir*/, ""))
 	{
 		timeStart := time.Now()
@@ -206,8 +206,9 @@ type DeSoMempool struct {
 	// The next time the unconnectTxn pool will be scanned for expired unconnectedTxns.
 	nextExpireScan time.Time
 
-	// Optional. When set, we use the BlockCypher API to detect double-spends.
-	blockCypherAPIKey string
+	// Optional. When set, BitcoinExchange txns are only accepted once their burns
+	// can be verified against this Bitcoin header chain.
+	bitcoinHeaderChain *BitcoinHeaderChain
 
 	// These two views are used to check whether a transaction is valid before
 	// adding it to the mempool. This is done by applying the transaction to the
@@ -321,8 +322,7 @@ func (mp *DeSoMempool) resetPool(newPool *DeSoMempool) {
 	mp.universalUtxoView = newPool.universalUtxoView
 	mp.universalTransactionList = newPool.universalTransactionList
 
-	// We don't adjust blockCypherAPIKey or blockCypherCheckDoubleSpendChan
-	// since those should be unaffected
+	// We don't adjust bitcoinHeaderChain since it should be unaffected
 
 	// We don't adjust the following fields without an explicit call to
 	// UpdateReadOnlyView.
@@ -373,12 +373,9 @@ func (mp *DeSoMempool) UpdateAfterConnectBlock(blk *MsgDeSoBlock) (_txnsAddedToM
 
 	// Create a new pool object. No need to set the min fees as we're just using this
 	// as a temporary data structure for validation.
-	//
-	// Don't make the new pool object deal with the BlockCypher API.
 	newPool := NewDeSoMempool(
 		mp.bc, 0, /* rateLimitFeeRateNanosPerKB */
 		0,     /* minFeeRateNanosPerKB */
-		"",    /*blockCypherAPIKey*/
 		false, /*runReadOnlyViewUpdater*/
 		"" /*dataDir*/, "")
 
@@ -478,11 +475,9 @@ func (mp *DeSoMempool) UpdateAfterDisconnectBlock(blk *MsgDeSoBlock) {
 
 	// Create a new DeSoMempool. No need to set the min fees since we're just using
 	// this as a temporary data structure for validation.
-	//
-	// Don't make the new pool object deal with the BlockCypher API.
 	newPool := NewDeSoMempool(mp.bc, 0, /* rateLimitFeeRateNanosPerKB */
 		0, /* minFeeRateNanosPerKB */
-		"" /*blockCypherAPIKey*/, false,
+		false,
 		"" /*dataDir*/, "")
 
 	// Add the transactions from the block to the new pool (except for the block reward,
@@ -910,6 +905,20 @@ func (mp *DeSoMempool) GetAugmentedUniversalView() (*UtxoView, error) {
 	return newView, nil
 }
 
+// GetCreatorCoinQuote returns what a creator coin trade would yield if it were mined
+// into the next block after everything currently in the mempool. See
+// UtxoView.GetCreatorCoinQuote.
+func (mp *DeSoMempool) GetCreatorCoinQuote(transactorPublicKey []byte, profilePublicKey []byte,
+	operationType CreatorCoinOperationType, amountNanos uint64) (*CreatorCoinQuote, error) {
+
+	utxoView, err := mp.GetAugmentedUniversalView()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetCreatorCoinQuote: Problem getting augmented view: ")
+	}
+	return utxoView.GetCreatorCoinQuote(transactorPublicKey, profilePublicKey, operationType,
+		amountNanos, uint32(mp.bc.blockTip().Height+1))
+}
+
 func (mp *DeSoMempool) FetchTransaction(txHash *BlockHash) *MempoolTx {
 	if mempoolTx, exists := mp.readOnlyUniversalTransactionMap[*txHash]; exists {
 		return mempoolTx
@@ -997,6 +1006,19 @@ func (mp *DeSoMempool) tryAcceptTransaction(
 		return nil, nil, TxErrorDuplicate
 	}
 
+	// If we're syncing Bitcoin headers, don't accept a BitcoinExchange until its burn
+	// checks out against them. Once BitcoinExchangeSPVBlockHeight has passed the same
+	// check is also run when the txn is connected, but before that this is only a
+	// mempool policy and blocks containing burns we can't verify are still accepted.
+	if mp.bitcoinHeaderChain != nil && tx.TxnMeta != nil &&
+		tx.TxnMeta.GetTxnType() == TxnTypeBitcoinExchange {
+
+		if err := mp.bitcoinHeaderChain.VerifyBitcoinExchange(
+			tx.TxnMeta.(*BitcoinExchangeMetadata)); err != nil {
+			return nil, nil, errors.Wrapf(err, "tryAcceptTransaction: ")
+		}
+	}
+
 	// Iterate over the transaction's inputs. If any of them don't have utxos in the
 	// UtxoView that are unspent at this point then the transaction is an unconnected
 	// txn. Use a map to ensure there are no duplicates.
@@ -1403,6 +1425,116 @@ func ComputeTransactionMetadata(txn *MsgDeSoTxn, utxoView *UtxoView, blockHash *
 			Metadata:             "FollowedPublicKeyBase58Check",
 		})
 	}
+	if txn.TxnMeta.GetTxnType() == TxnTypeUserBlock {
+		realTxMeta := txn.TxnMeta.(*UserBlockMetadata)
+
+		// BlockerPublicKeyBase58Check = TransactorPublicKeyBase58Check
+
+		// The blocked public key is deliberately left out of AffectedPublicKeys so
+		// that blocking someone doesn't send them a notification.
+		txnMeta.UserBlockTxindexMetadata = &UserBlockTxindexMetadata{
+			BlockedPublicKeyBase58Check: PkToString(realTxMeta.BlockedPublicKey, utxoView.Params),
+			BlockType:                   realTxMeta.BlockType.String(),
+			IsUnblock:                   realTxMeta.IsUnblock,
+		}
+	}
+	if txn.TxnMeta.GetTxnType() == TxnTypeMessageRead {
+		realTxMeta := txn.TxnMeta.(*MessageReadMetadata)
+
+		// ReaderPublicKeyBase58Check = TransactorPublicKeyBase58Check
+
+		// The thread public key is deliberately left out of AffectedPublicKeys so
+		// that reading a thread doesn't send the counterparty a notification.
+		txnMeta.MessageReadTxindexMetadata = &MessageReadTxindexMetadata{
+			ThreadPublicKeyBase58Check: PkToString(realTxMeta.ThreadPublicKey, utxoView.Params),
+			LastReadTstampNanos:        realTxMeta.LastReadTstampNanos,
+		}
+	}
+	if txn.TxnMeta.GetTxnType() == TxnTypeCreateNFTCollection {
+		realTxMeta := txn.TxnMeta.(*CreateNFTCollectionMetadata)
+
+		// CreatorPublicKeyBase58Check = TransactorPublicKeyBase58Check
+
+		txnHash := txn.Hash()
+		txnMeta.CreateNFTCollectionTxindexMetadata = &CreateNFTCollectionTxindexMetadata{
+			NFTCollectionIDHex: hex.EncodeToString(txnHash[:]),
+			Name:               string(realTxMeta.Name),
+			MaxSupply:          realTxMeta.MaxSupply,
+		}
+		if nftCollectionEntry := utxoView.GetNFTCollectionEntry(txnHash); nftCollectionEntry != nil {
+			txnMeta.CreateNFTCollectionTxindexMetadata.AdditionalDESORoyaltiesMap = pkidRoyaltyMapToBase58CheckToRoyaltyMap(
+				nftCollectionEntry.AdditionalNFTRoyaltiesToCreatorsBasisPoints, utxoView)
+			txnMeta.CreateNFTCollectionTxindexMetadata.AdditionalCoinRoyaltiesMap = pkidRoyaltyMapToBase58CheckToRoyaltyMap(
+				nftCollectionEntry.AdditionalNFTRoyaltiesToCoinsBasisPoints, utxoView)
+		}
+	}
+	if txn.TxnMeta.GetTxnType() == TxnTypeMessagingGroupUpdate {
+		realTxMeta := txn.TxnMeta.(*MessagingGroupUpdateMetadata)
+
+		// UpdaterPublicKeyBase58Check = TransactorPublicKeyBase58Check
+
+		txnMeta.MessagingGroupUpdateTxindexMetadata = &MessagingGroupUpdateTxindexMetadata{
+			GroupOwnerPublicKeyBase58Check: PkToString(realTxMeta.GroupOwnerPublicKey, utxoView.Params),
+			MessagingGroupKeyName: string(MessagingKeyNameDecode(
+				NewGroupKeyName(realTxMeta.MessagingGroupKeyName))),
+		}
+		// The previous messaging public key comes from the UtxoOperation so that a
+		// re-key records both the old and the new key.
+		if len(utxoOps) > 0 && utxoOps[len(utxoOps)-1].PrevMessagingKeyEntry != nil {
+			prevMessagingPublicKey := utxoOps[len(utxoOps)-1].PrevMessagingKeyEntry.MessagingPublicKey
+			txnMeta.MessagingGroupUpdateTxindexMetadata.PrevMessagingPublicKeyBase58Check =
+				PkToString(prevMessagingPublicKey[:], utxoView.Params)
+			txnMeta.MessagingGroupUpdateTxindexMetadata.NewMessagingPublicKeyBase58Check =
+				PkToString(prevMessagingPublicKey[:], utxoView.Params)
+		}
+		if len(realTxMeta.NewMessagingPublicKey) > 0 {
+			txnMeta.MessagingGroupUpdateTxindexMetadata.NewMessagingPublicKeyBase58Check =
+				PkToString(realTxMeta.NewMessagingPublicKey, utxoView.Params)
+		}
+
+		// GroupOwnerPublicKeyBase58Check in AffectedPublicKeys
+		txnMeta.AffectedPublicKeys = append(txnMeta.AffectedPublicKeys, &AffectedPublicKey{
+			PublicKeyBase58Check: PkToString(realTxMeta.GroupOwnerPublicKey, utxoView.Params),
+			Metadata:             "GroupOwnerPublicKeyBase58Check",
+		})
+		// Members and admins whose status changed in AffectedPublicKeys
+		for _, member := range realTxMeta.MessagingGroupMembers {
+			memberPublicKeyBase58Check := PkToString(member.GroupMemberPublicKey[:], utxoView.Params)
+			txnMeta.MessagingGroupUpdateTxindexMetadata.MemberPublicKeysBase58Check = append(
+				txnMeta.MessagingGroupUpdateTxindexMetadata.MemberPublicKeysBase58Check, memberPublicKeyBase58Check)
+			txnMeta.AffectedPublicKeys = append(txnMeta.AffectedPublicKeys, &AffectedPublicKey{
+				PublicKeyBase58Check: memberPublicKeyBase58Check,
+				Metadata:             "MemberPublicKeyBase58Check",
+			})
+		}
+		for _, publicKey := range realTxMeta.RemovedMemberPublicKeys {
+			publicKeyBase58Check := PkToString(publicKey, utxoView.Params)
+			txnMeta.MessagingGroupUpdateTxindexMetadata.RemovedMemberPublicKeysBase58Check = append(
+				txnMeta.MessagingGroupUpdateTxindexMetadata.RemovedMemberPublicKeysBase58Check, publicKeyBase58Check)
+			txnMeta.AffectedPublicKeys = append(txnMeta.AffectedPublicKeys, &AffectedPublicKey{
+				PublicKeyBase58Check: publicKeyBase58Check,
+				Metadata:             "RemovedMemberPublicKeyBase58Check",
+			})
+		}
+		for _, publicKey := range realTxMeta.AddedAdminPublicKeys {
+			publicKeyBase58Check := PkToString(publicKey, utxoView.Params)
+			txnMeta.MessagingGroupUpdateTxindexMetadata.AddedAdminPublicKeysBase58Check = append(
+				txnMeta.MessagingGroupUpdateTxindexMetadata.AddedAdminPublicKeysBase58Check, publicKeyBase58Check)
+			txnMeta.AffectedPublicKeys = append(txnMeta.AffectedPublicKeys, &AffectedPublicKey{
+				PublicKeyBase58Check: publicKeyBase58Check,
+				Metadata:             "AddedAdminPublicKeyBase58Check",
+			})
+		}
+		for _, publicKey := range realTxMeta.RemovedAdminPublicKeys {
+			publicKeyBase58Check := PkToString(publicKey, utxoView.Params)
+			txnMeta.MessagingGroupUpdateTxindexMetadata.RemovedAdminPublicKeysBase58Check = append(
+				txnMeta.MessagingGroupUpdateTxindexMetadata.RemovedAdminPublicKeysBase58Check, publicKeyBase58Check)
+			txnMeta.AffectedPublicKeys = append(txnMeta.AffectedPublicKeys, &AffectedPublicKey{
+				PublicKeyBase58Check: publicKeyBase58Check,
+				Metadata:             "RemovedAdminPublicKeyBase58Check",
+			})
+		}
+	}
 	if txn.TxnMeta.GetTxnType() == TxnTypePrivateMessage {
 		realTxMeta := txn.TxnMeta.(*PrivateMessageMetadata)
 
@@ -1606,6 +1738,9 @@ func ComputeTransactionMetadata(txn *MsgDeSoTxn, utxoView *UtxoView, blockHash *
 			AdditionalDESORoyaltiesMap: additionalDESORoyaltiesMap,
 			AdditionalCoinRoyaltiesMap: additionalCoinRoyaltiesMap,
 		}
+		if postEntry.NFTCollectionID != nil {
+			txnMeta.CreateNFTTxindexMetadata.NFTCollectionIDHex = hex.EncodeToString(postEntry.NFTCollectionID[:])
+		}
 		for pubKeyIter, _ := range additionalDESORoyaltiesMap {
 			pubKey := pubKeyIter
 			txnMeta.AffectedPublicKeys = append(txnMeta.AffectedPublicKeys, &AffectedPublicKey{
@@ -1667,6 +1802,194 @@ func ComputeTransactionMetadata(txn *MsgDeSoTxn, utxoView *UtxoView, blockHash *
 		})
 
 	}
+	if txn.TxnMeta.GetTxnType() == TxnTypeNFTVault {
+		realTxMeta := txn.TxnMeta.(*NFTVaultMetadata)
+
+		var operationString string
+		switch realTxMeta.OperationType {
+		case NFTVaultOperationTypeLock:
+			operationString = "lock"
+		case NFTVaultOperationTypeRedeem:
+			operationString = "redeem"
+		case NFTVaultOperationTypeTransferShares:
+			operationString = "transfer_shares"
+		}
+
+		txnMeta.NFTVaultTxindexMetadata = &NFTVaultTxindexMetadata{
+			NFTPostHashHex:        hex.EncodeToString(realTxMeta.NFTPostHash[:]),
+			SerialNumber:          realTxMeta.SerialNumber,
+			OperationType:         operationString,
+			TotalSharesNanos:      realTxMeta.TotalSharesNanos,
+			BuyoutPriceNanos:      realTxMeta.BuyoutPriceNanos,
+			SharesToTransferNanos: realTxMeta.SharesToTransferNanos,
+		}
+
+		if realTxMeta.OperationType == NFTVaultOperationTypeTransferShares {
+			txnMeta.AffectedPublicKeys = append(txnMeta.AffectedPublicKeys, &AffectedPublicKey{
+				PublicKeyBase58Check: PkToString(realTxMeta.ReceiverPublicKey, utxoView.Params),
+				Metadata:             "NFTVaultSharesRecipientPublicKeyBase58Check",
+			})
+		}
+	}
+	if txn.TxnMeta.GetTxnType() == TxnTypeRedeemNFTVoucher {
+		realTxMeta := txn.TxnMeta.(*RedeemNFTVoucherMetadata)
+
+		txnMeta.RedeemNFTVoucherTxindexMetadata = &RedeemNFTVoucherTxindexMetadata{
+			NFTPostHashHex: hex.EncodeToString(realTxMeta.Voucher.NFTPostHash[:]),
+			SerialNumber:   realTxMeta.Voucher.SerialNumber,
+			PriceNanos:     realTxMeta.Voucher.PriceNanos,
+		}
+
+		postEntry := utxoView.GetPostEntryForPostHash(realTxMeta.Voucher.NFTPostHash)
+		if postEntry != nil {
+			txnMeta.AffectedPublicKeys = append(txnMeta.AffectedPublicKeys, &AffectedPublicKey{
+				PublicKeyBase58Check: PkToString(postEntry.PosterPublicKey, utxoView.Params),
+				Metadata:             "NFTCreatorPublicKeyBase58Check",
+			})
+		}
+	}
+	if txn.TxnMeta.GetTxnType() == TxnTypeRegisterBlockProducer {
+		realTxMeta := txn.TxnMeta.(*RegisterBlockProducerMetadata)
+
+		txnMeta.RegisterBlockProducerTxindexMetadata = &RegisterBlockProducerTxindexMetadata{
+			BondNanos: realTxMeta.BondNanos,
+		}
+	}
+	if txn.TxnMeta.GetTxnType() == TxnTypeDeregisterBlockProducer {
+		unbondingNanos := uint64(0)
+		if len(utxoOps) > 0 && utxoOps[len(utxoOps)-1].PrevBlockProducerEntry != nil {
+			unbondingNanos = utxoOps[len(utxoOps)-1].PrevBlockProducerEntry.BondNanos
+		}
+
+		txnMeta.DeregisterBlockProducerTxindexMetadata = &DeregisterBlockProducerTxindexMetadata{
+			UnbondingNanos: unbondingNanos,
+		}
+	}
+	if txn.TxnMeta.GetTxnType() == TxnTypeClaimBlockProducerBond {
+		bondRefundNanos := uint64(0)
+		if len(utxoOps) > 0 && utxoOps[len(utxoOps)-1].PrevBlockProducerEntry != nil {
+			bondRefundNanos = utxoOps[len(utxoOps)-1].PrevBlockProducerEntry.UnbondingNanos
+		}
+
+		txnMeta.ClaimBlockProducerBondTxindexMetadata = &ClaimBlockProducerBondTxindexMetadata{
+			BondRefundNanos: bondRefundNanos,
+		}
+	}
+	if txn.TxnMeta.GetTxnType() == TxnTypeUsernameListing {
+		realTxMeta := txn.TxnMeta.(*UsernameListingMetadata)
+
+		txnMeta.UsernameListingTxindexMetadata = &UsernameListingTxindexMetadata{
+			Username:            string(realTxMeta.Username),
+			ReplacementUsername: string(realTxMeta.ReplacementUsername),
+			PriceNanos:          realTxMeta.PriceNanos,
+			IsRemoved:           realTxMeta.IsRemoved,
+		}
+
+		// Notify the recipient of a username that's been offered to them.
+		if len(realTxMeta.RecipientPublicKey) != 0 {
+			txnMeta.AffectedPublicKeys = append(txnMeta.AffectedPublicKeys, &AffectedPublicKey{
+				PublicKeyBase58Check: PkToString(realTxMeta.RecipientPublicKey, utxoView.Params),
+				Metadata:             "RecipientPublicKeyBase58Check",
+			})
+		}
+	}
+	if txn.TxnMeta.GetTxnType() == TxnTypeAcceptUsernameListing {
+		realTxMeta := txn.TxnMeta.(*AcceptUsernameListingMetadata)
+
+		txnMeta.AcceptUsernameListingTxindexMetadata = &AcceptUsernameListingTxindexMetadata{
+			Username:   string(realTxMeta.Username),
+			PriceNanos: realTxMeta.PriceNanos,
+		}
+
+		// Add the seller to the AffectedPublicKeys.
+		if len(utxoOps) > 0 && utxoOps[len(utxoOps)-1].PrevUsernameListingEntry != nil {
+			sellerPKID := utxoOps[len(utxoOps)-1].PrevUsernameListingEntry.OwnerPKID
+			txnMeta.AffectedPublicKeys = append(txnMeta.AffectedPublicKeys, &AffectedPublicKey{
+				PublicKeyBase58Check: PkToString(utxoView.GetPublicKeyForPKID(sellerPKID), utxoView.Params),
+				Metadata:             "SellerPublicKeyBase58Check",
+			})
+		}
+	}
+	if txn.TxnMeta.GetTxnType() == TxnTypeKeyRotation {
+		realTxMeta := txn.TxnMeta.(*KeyRotationMetadata)
+
+		var operationString string
+		switch realTxMeta.OperationType {
+		case KeyRotationOperationInitiate:
+			operationString = "initiate"
+		case KeyRotationOperationCancel:
+			operationString = "cancel"
+		case KeyRotationOperationComplete:
+			operationString = "complete"
+		case KeyRotationOperationSetMinTimelock:
+			operationString = "set_min_timelock"
+		}
+		txnMeta.KeyRotationTxindexMetadata = &KeyRotationTxindexMetadata{
+			OperationType:  operationString,
+			TimelockBlocks: realTxMeta.TimelockBlocks,
+		}
+
+		// Add the new key to the AffectedPublicKeys. Setting the minimum timelock
+		// doesn't have one.
+		if len(realTxMeta.NewPublicKey) != 0 {
+			txnMeta.KeyRotationTxindexMetadata.NewPublicKeyBase58Check =
+				PkToString(realTxMeta.NewPublicKey, utxoView.Params)
+			txnMeta.AffectedPublicKeys = append(txnMeta.AffectedPublicKeys, &AffectedPublicKey{
+				PublicKeyBase58Check: PkToString(realTxMeta.NewPublicKey, utxoView.Params),
+				Metadata:             "NewPublicKeyBase58Check",
+			})
+		}
+	}
+	if txn.TxnMeta.GetTxnType() == TxnTypeRecoveryGuardians {
+		realTxMeta := txn.TxnMeta.(*RecoveryGuardiansMetadata)
+
+		guardianPublicKeysBase58Check := []string{}
+		for _, guardianPublicKey := range realTxMeta.GuardianPublicKeys {
+			guardianPublicKeysBase58Check = append(guardianPublicKeysBase58Check,
+				PkToString(guardianPublicKey, utxoView.Params))
+		}
+		txnMeta.RecoveryGuardiansTxindexMetadata = &RecoveryGuardiansTxindexMetadata{
+			GuardianPublicKeysBase58Check: guardianPublicKeysBase58Check,
+			Threshold:                     realTxMeta.Threshold,
+			DelayBlocks:                   realTxMeta.DelayBlocks,
+		}
+
+		// Let each guardian know they've been picked.
+		for _, guardianPublicKeyBase58Check := range guardianPublicKeysBase58Check {
+			txnMeta.AffectedPublicKeys = append(txnMeta.AffectedPublicKeys, &AffectedPublicKey{
+				PublicKeyBase58Check: guardianPublicKeyBase58Check,
+				Metadata:             "GuardianPublicKeyBase58Check",
+			})
+		}
+	}
+	if txn.TxnMeta.GetTxnType() == TxnTypeAccountRecovery {
+		realTxMeta := txn.TxnMeta.(*AccountRecoveryMetadata)
+
+		var operationString string
+		switch realTxMeta.OperationType {
+		case AccountRecoveryOperationInitiate:
+			operationString = "initiate"
+		case AccountRecoveryOperationCancel:
+			operationString = "cancel"
+		case AccountRecoveryOperationComplete:
+			operationString = "complete"
+		}
+		txnMeta.AccountRecoveryTxindexMetadata = &AccountRecoveryTxindexMetadata{
+			OwnerPublicKeyBase58Check: PkToString(realTxMeta.OwnerPublicKey, utxoView.Params),
+			NewPublicKeyBase58Check:   PkToString(realTxMeta.NewPublicKey, utxoView.Params),
+			OperationType:             operationString,
+		}
+
+		// Add the owner and the new key to the AffectedPublicKeys.
+		txnMeta.AffectedPublicKeys = append(txnMeta.AffectedPublicKeys, &AffectedPublicKey{
+			PublicKeyBase58Check: PkToString(realTxMeta.OwnerPublicKey, utxoView.Params),
+			Metadata:             "OwnerPublicKeyBase58Check",
+		})
+		txnMeta.AffectedPublicKeys = append(txnMeta.AffectedPublicKeys, &AffectedPublicKey{
+			PublicKeyBase58Check: PkToString(realTxMeta.NewPublicKey, utxoView.Params),
+			Metadata:             "NewPublicKeyBase58Check",
+		})
+	}
 	if txn.TxnMeta.GetTxnType() == TxnTypeBasicTransfer {
 		diamondLevelBytes, hasDiamondLevel := txn.ExtraData[DiamondLevelKey]
 		diamondPostHash, hasDiamondPostHash := txn.ExtraData[DiamondPostHashKey]
@@ -1727,6 +2050,27 @@ func ComputeTransactionMetadata(txn *MsgDeSoTxn, utxoView *UtxoView, blockHash *
 			Metadata:             "ReceiverPublicKey",
 		})
 	}
+	if txn.TxnMeta.GetTxnType() == TxnTypePollVote {
+		realTxMeta := txn.TxnMeta.(*PollVoteMetadata)
+
+		txnMeta.PollVoteTxindexMetadata = &PollVoteTxindexMetadata{
+			PostHashHex: hex.EncodeToString(realTxMeta.PostHash[:]),
+			OptionIndex: realTxMeta.OptionIndex,
+		}
+
+		// PosterPublicKeyBase58Check in AffectedPublicKeys
+		postEntry := utxoView.GetPostEntryForPostHash(realTxMeta.PostHash)
+		if postEntry == nil {
+			return nil, fmt.Errorf(
+				"UpdateTxindex: Error creating PollVoteTxindexMetadata; "+
+					"missing post for hash %v", realTxMeta.PostHash)
+		}
+
+		txnMeta.AffectedPublicKeys = append(txnMeta.AffectedPublicKeys, &AffectedPublicKey{
+			PublicKeyBase58Check: PkToString(postEntry.PosterPublicKey, utxoView.Params),
+			Metadata:             "PosterPublicKeyBase58Check",
+		})
+	}
 
 	return txnMeta, nil
 }
@@ -2025,6 +2369,7 @@ func (mp *DeSoMempool) processTransaction(
 	missingParents, mempoolTx, err := mp.tryAcceptTransaction(
 		tx, rateLimit, true, verifySignatures)
 	if err != nil {
+		recordMempoolReject(err)
 		return nil, err
 	}
 
@@ -2053,6 +2398,7 @@ func (mp *DeSoMempool) processTransaction(
 	if !allowUnconnectedTxn {
 		glog.V(2).Infof("DeSoMempool.processTransaction: TxErrorUnconnectedTxnNotAllowed: %v %v",
 			tx.Hash(), tx.TxnMeta.GetTxnType())
+		recordMempoolReject(TxErrorUnconnectedTxnNotAllowed)
 		return nil, TxErrorUnconnectedTxnNotAllowed
 	}
 
@@ -2060,6 +2406,7 @@ func (mp *DeSoMempool) processTransaction(
 	err = mp.tryAddUnconnectedTxn(tx, peerID)
 	if err != nil {
 		glog.V(2).Infof("DeSoMempool.processTransaction: Error adding transaction as unconnected txn: %v", err)
+		recordMempoolReject(err)
 	}
 	return nil, err
 }
@@ -2142,11 +2489,9 @@ func (mp *DeSoMempool) inefficientRemoveTransaction(tx *MsgDeSoTxn) {
 	//
 	// Create a new DeSoMempool. No need to set the min fees since we're just using
 	// this as a temporary data structure for validation.
-	//
-	// Don't make the new pool object deal with the BlockCypher API.
 	newPool := NewDeSoMempool(mp.bc, 0, /* rateLimitFeeRateNanosPerKB */
 		0, /* minFeeRateNanosPerKB */
-		"" /*blockCypherAPIKey*/, false,
+		false,
 		"" /*dataDir*/, "")
 	// At this point the block txns have been added to the new pool. Now we need to
 	// add the txns from the original pool. Start by fetching them in slice form.
@@ -2355,8 +2700,7 @@ func (mp *DeSoMempool) Stop() {
 
 // Create a new pool with no transactions in it.
 func NewDeSoMempool(_bc *Blockchain, _rateLimitFeerateNanosPerKB uint64,
-	_minFeerateNanosPerKB uint64, _blockCypherAPIKey string,
-	_runReadOnlyViewUpdater bool, _dataDir string, _mempoolDumpDir string) *DeSoMempool {
+	_minFeerateNanosPerKB uint64, _runReadOnlyViewUpdater bool, _dataDir string, _mempoolDumpDir string) *DeSoMempool {
 
 	utxoView, _ := NewUtxoView(_bc.db, _bc.params, _bc.postgres)
 	backupUtxoView, _ := NewUtxoView(_bc.db, _bc.params, _bc.postgres)
@@ -2371,7 +2715,6 @@ func NewDeSoMempool(_bc *Blockchain, _rateLimitFeerateNanosPerKB uint64,
 		unconnectedTxnsByPrev:           make(map[UtxoKey]map[BlockHash]*MsgDeSoTxn),
 		outpoints:                       make(map[UtxoKey]*MsgDeSoTxn),
 		pubKeyToTxnMap:                  make(map[PkMapKey]map[BlockHash]*MempoolTx),
-		blockCypherAPIKey:               _blockCypherAPIKey,
 		backupUniversalUtxoView:         backupUtxoView,
 		universalUtxoView:               utxoView,
 		mempoolDir:                      _mempoolDumpDir,
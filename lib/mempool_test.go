@@ -55,7 +55,7 @@ func TestMempoolLongChainOfDependencies(t *testing.T) {
 	// Validate this txn.
 	mp := NewDeSoMempool(
 		chain, 0, /* rateLimitFeeRateNanosPerKB */
-		0 /* minFeeRateNanosPerKB */, "", true,
+		0 /* minFeeRateNanosPerKB */, true,
 		"" /*dataDir*/, "")
 	_, err := mp.processTransaction(txn1, false /*allowUnconnectedTxn*/, false /*rateLimit*/, 0 /*peerID*/, true /*verifySignatures*/)
 	require.NoError(err)
@@ -114,7 +114,7 @@ func TestMempoolRateLimit(t *testing.T) {
 	// accept all of the transactions we're about to create without fail.
 	mpNoMinFees := NewDeSoMempool(
 		chain, 0, /* rateLimitFeeRateNanosPerKB */
-		0 /* minFeeRateNanosPerKB */, "", true,
+		0 /* minFeeRateNanosPerKB */, true,
 		"" /*dataDir*/, "")
 
 	// Create a transaction that sends 1 DeSo to the recipient as its
@@ -130,7 +130,7 @@ func TestMempoolRateLimit(t *testing.T) {
 	// if we set rateLimit to true.
 	mpWithMinFee := NewDeSoMempool(
 		chain, 0, /* rateLimitFeeRateNanosPerKB */
-		100 /* minFeeRateNanosPerKB */, "", true,
+		100 /* minFeeRateNanosPerKB */, true,
 		"" /*dataDir*/, "")
 	_, err = mpWithMinFee.processTransaction(txn1, false /*allowUnconnectedTxn*/, true /*rateLimit*/, 0 /*peerID*/, false /*verifySignatures*/)
 	require.Error(err)
@@ -179,7 +179,7 @@ func TestMempoolRateLimit(t *testing.T) {
 	// feerate set since 24 transactions should be ~2400 bytes.
 	mpWithRateLimit := NewDeSoMempool(
 		chain, 100, /* rateLimitFeeRateNanosPerKB */
-		0 /* minFeeRateNanosPerKB */, "", true,
+		0 /* minFeeRateNanosPerKB */, true,
 		"" /*dataDir*/, "")
 	processingErrors := []error{}
 	for _, txn := range txnsCreated {
@@ -300,7 +300,7 @@ func TestMempoolAugmentedUtxoViewTransactionChain(t *testing.T) {
 	// not testing that here.
 	mp := NewDeSoMempool(
 		chain, 0, /* rateLimitFeeRateNanosPerKB */
-		0 /* minFeeRateNanosPerKB */, "", true,
+		0 /* minFeeRateNanosPerKB */, true,
 		"" /*dataDir*/, "")
 
 	// Process the first transaction.
@@ -0,0 +1,169 @@
+package lib
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics are always collected since updating them is cheap, but they're only
+// exported when the node is started with --metrics-port. They live in their own
+// registry rather than the global one so that importing this package doesn't
+// change what other binaries export.
+var MetricsRegistry = prometheus.NewRegistry()
+
+const metricsNamespace = "deso"
+
+var (
+	metricBlockConnectSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "block_connect_seconds",
+		Help:      "Time spent connecting a main chain block in ProcessBlock.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 16),
+	})
+	metricDbFlushSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "db_flush_seconds",
+		Help:      "Time spent flushing a UtxoView to badger.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 16),
+	})
+	metricBlockTemplateBuildSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "block_template_build_seconds",
+		Help:      "Time spent building a block template in the block producer.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 16),
+	})
+
+	metricMempoolTxns = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "mempool_txns",
+		Help:      "Number of txns in the mempool by txn type.",
+	}, []string{"txn_type"})
+	metricMempoolBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "mempool_bytes",
+		Help:      "Size of the txns in the mempool by txn type.",
+	}, []string{"txn_type"})
+	metricMempoolRejectsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "mempool_rejects_total",
+		Help:      "Txns rejected by the mempool by reason.",
+	}, []string{"reason"})
+
+	metricPeerMessagesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "peer_messages_total",
+		Help:      "Messages exchanged with each peer by direction and message type.",
+	}, []string{"peer", "direction", "msg_type"})
+	metricPeerBytesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "peer_bytes_total",
+		Help:      "Payload bytes exchanged with each peer by direction and message type.",
+	}, []string{"peer", "direction", "msg_type"})
+
+	metricSyncState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "sync_state",
+		Help:      "Set to 1 for the chain's current sync state and 0 for the others.",
+	}, []string{"state"})
+	metricBlockHeight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "block_height",
+		Help:      "Height of the block tip.",
+	})
+	metricHeaderHeight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "header_height",
+		Help:      "Height of the header tip.",
+	})
+)
+
+func init() {
+	MetricsRegistry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		metricBlockConnectSeconds,
+		metricDbFlushSeconds,
+		metricBlockTemplateBuildSeconds,
+		metricMempoolTxns,
+		metricMempoolBytes,
+		metricMempoolRejectsTotal,
+		metricPeerMessagesTotal,
+		metricPeerBytesTotal,
+		metricSyncState,
+		metricBlockHeight,
+		metricHeaderHeight,
+	)
+}
+
+// NewMetricsHandler returns a handler that serves MetricsRegistry.
+func NewMetricsHandler() http.Handler {
+	return promhttp.HandlerFor(MetricsRegistry, promhttp.HandlerOpts{})
+}
+
+func observeSecondsSince(histogram prometheus.Histogram, start time.Time) {
+	histogram.Observe(time.Since(start).Seconds())
+}
+
+// Errors are wrapped with context that varies from txn to txn, so we label rejects
+// with just the name of the underlying error to keep the number of series bounded.
+var mempoolRejectReasonRegex = regexp.MustCompile(`(RuleError|TxError)[A-Za-z0-9]+`)
+
+func recordMempoolReject(err error) {
+	reason := mempoolRejectReasonRegex.FindString(err.Error())
+	if reason == "" {
+		reason = "Other"
+	}
+	metricMempoolRejectsTotal.WithLabelValues(reason).Inc()
+}
+
+func recordPeerMessage(pp *Peer, direction string, msgType MsgType, numBytes int) {
+	metricPeerMessagesTotal.WithLabelValues(pp.Address(), direction, msgType.String()).Inc()
+	metricPeerBytesTotal.WithLabelValues(pp.Address(), direction, msgType.String()).Add(float64(numBytes))
+}
+
+// deletePeerMetrics drops a disconnected peer's series so they don't accumulate.
+func deletePeerMetrics(pp *Peer) {
+	for _, direction := range []string{"sent", "received"} {
+		// Control messages are never sent on the wire so they aren't recorded.
+		for msgType := MsgTypeUnset; msgType <= MsgTypeGetAddr; msgType++ {
+			metricPeerMessagesTotal.DeleteLabelValues(pp.Address(), direction, msgType.String())
+			metricPeerBytesTotal.DeleteLabelValues(pp.Address(), direction, msgType.String())
+		}
+	}
+}
+
+// updateMempoolMetrics recomputes the per-txn-type mempool gauges from the
+// mempool's read-only view.
+func updateMempoolMetrics(mempool *DeSoMempool) {
+	numTxns := make(map[TxnType]int)
+	numBytes := make(map[TxnType]uint64)
+	for _, mempoolTx := range mempool.readOnlyUniversalTransactionList {
+		txnType := mempoolTx.Tx.TxnMeta.GetTxnType()
+		numTxns[txnType]++
+		numBytes[txnType] += mempoolTx.TxSizeBytes
+	}
+	// Set every type so types that leave the mempool go back to zero.
+	for _, txnType := range AllTxnTypes {
+		metricMempoolTxns.WithLabelValues(txnType.String()).Set(float64(numTxns[txnType]))
+		metricMempoolBytes.WithLabelValues(txnType.String()).Set(float64(numBytes[txnType]))
+	}
+}
+
+func updateChainMetrics(chain *Blockchain) {
+	chainState := chain.ChainState()
+	for _, syncState := range []SyncState{
+		SyncStateSyncingHeaders, SyncStateSyncingBlocks, SyncStateNeedBlocksss, SyncStateFullyCurrent} {
+
+		value := 0.0
+		if syncState == chainState {
+			value = 1.0
+		}
+		metricSyncState.WithLabelValues(syncState.String()).Set(value)
+	}
+	metricBlockHeight.Set(float64(chain.BlockTip().Height))
+	metricHeaderHeight.Set(float64(chain.HeaderTip().Height))
+}
@@ -0,0 +1,44 @@
+package lib
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMempoolRejectMetrics(t *testing.T) {
+	require := require.New(t)
+
+	// Wrapped errors are labeled with the underlying error's name.
+	before := testutil.ToFloat64(metricMempoolRejectsTotal.WithLabelValues(string(RuleErrorInsufficientRefund)))
+	recordMempoolReject(errors.Wrapf(RuleErrorInsufficientRefund, "ConnectTransaction: txn %v", &BlockHash{}))
+	require.Equal(before+1, testutil.ToFloat64(
+		metricMempoolRejectsTotal.WithLabelValues(string(RuleErrorInsufficientRefund))))
+
+	before = testutil.ToFloat64(metricMempoolRejectsTotal.WithLabelValues(string(TxErrorDuplicate)))
+	recordMempoolReject(TxErrorDuplicate)
+	require.Equal(before+1, testutil.ToFloat64(metricMempoolRejectsTotal.WithLabelValues(string(TxErrorDuplicate))))
+
+	// Anything else is lumped together.
+	before = testutil.ToFloat64(metricMempoolRejectsTotal.WithLabelValues("Other"))
+	recordMempoolReject(fmt.Errorf("something went wrong"))
+	require.Equal(before+1, testutil.ToFloat64(metricMempoolRejectsTotal.WithLabelValues("Other")))
+}
+
+func TestChainMetrics(t *testing.T) {
+	require := require.New(t)
+
+	chain, params, _ := NewLowDifficultyBlockchain()
+	mempool, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+
+	_, err := miner.MineAndProcessSingleBlock(0 /*threadIndex*/, mempool)
+	require.NoError(err)
+
+	updateChainMetrics(chain)
+	require.Equal(float64(1), testutil.ToFloat64(metricBlockHeight))
+	updateMempoolMetrics(mempool)
+	require.Equal(float64(0), testutil.ToFloat64(metricMempoolTxns.WithLabelValues(TxnTypeBasicTransfer.String())))
+}
@@ -5,6 +5,8 @@
 package lib
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"math/big"
@@ -415,6 +417,10 @@ func BigintToBytes(bigint *big.Int) []byte {
 	return hexBytes
 }
 
+// The offset of the Nonce in a header encoded by EncodeHeaderVersion1. It comes
+// after the Version, PrevBlockHash, TransactionMerkleRoot, TstampSecs, and Height.
+const headerVersion1NonceOffset = 4 + HashSizeBytes + HashSizeBytes + 8 + 8
+
 // FindLowestHash
 // Mine for a given number of iterations and return the lowest hash value
 // found and its associated nonce. Hashing starts at the value of the Nonce
@@ -424,6 +430,11 @@ func BigintToBytes(bigint *big.Int) []byte {
 func FindLowestHash(
 	blockHeaderr *MsgDeSoHeader, iterations uint64) (
 	lowestHash *BlockHash, lowestNonce uint64, ee error) {
+	// Version 1 headers are hashed eight nonces at a time.
+	if blockHeaderr.Version == HeaderVersion1 {
+		return findLowestHashVersion1(blockHeaderr, iterations)
+	}
+
 	//// Compute a hash of the header with the current nonce value.
 	bestNonce := blockHeaderr.Nonce
 	bestHash, err := blockHeaderr.Hash()
@@ -456,6 +467,58 @@ func FindLowestHash(
 	return bestHash, bestNonce, nil
 }
 
+// findLowestHashVersion1 behaves exactly like FindLowestHash but serializes the
+// header once and hashes batches of nonces with DeSoHashV1x8.
+func findLowestHashVersion1(
+	blockHeaderr *MsgDeSoHeader, iterations uint64) (
+	lowestHash *BlockHash, lowestNonce uint64, ee error) {
+
+	headerBytes, err := blockHeaderr.ToBytes(false)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(headerBytes) < headerVersion1NonceOffset+8 {
+		return nil, 0, fmt.Errorf("findLowestHashVersion1: Header has length %d "+
+			"which is too short to contain a nonce", len(headerBytes))
+	}
+
+	// Like FindLowestHash, we hash the starting nonce plus the next iterations
+	// nonces.
+	startNonce := blockHeaderr.Nonce
+	numHashes := iterations + 1
+
+	var inputs [8][]byte
+	for ii := range inputs {
+		inputs[ii] = append([]byte{}, headerBytes...)
+	}
+
+	var bestHash *BlockHash
+	bestNonce := startNonce
+	for offset := uint64(0); offset < numHashes; offset += uint64(len(inputs)) {
+		for ii := range inputs {
+			binary.BigEndian.PutUint64(inputs[ii][headerVersion1NonceOffset:], startNonce+offset+uint64(ii))
+		}
+		hashes := desohash.DeSoHashV1x8(&inputs)
+
+		// Check the hashes in nonce order so ties resolve to the lowest nonce.
+		for ii := range hashes {
+			if offset+uint64(ii) >= numHashes {
+				break
+			}
+			if bestHash == nil || bytes.Compare(hashes[ii][:], bestHash[:]) < 0 {
+				currentHash := BlockHash(hashes[ii])
+				bestHash = &currentHash
+				bestNonce = startNonce + offset + uint64(ii)
+			}
+		}
+	}
+
+	// Leave the Nonce one past the last value we checked.
+	blockHeaderr.Nonce = startNonce + numHashes
+
+	return bestHash, bestNonce, nil
+}
+
 func LessThan(aa *BlockHash, bb *BlockHash) bool {
 	aaBigint := new(big.Int)
 	aaBigint.SetBytes(aa[:])
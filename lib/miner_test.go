@@ -0,0 +1,54 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindLowestHashVersion1(t *testing.T) {
+	require := require.New(t)
+
+	header := &MsgDeSoHeader{
+		Version:               HeaderVersion1,
+		PrevBlockHash:         mustDecodeHexBlockHash("00000000e9ad1e6a4b0ff4e01fd4b1e3c0f1c3f3a0e8c3c1e6a4b0ff4e01fd4b"),
+		TransactionMerkleRoot: mustDecodeHexBlockHash("097158f0d27e6d10565c4dc696c784652c3380e0ff8382d3599a4d18b782e965"),
+		TstampSecs:            uint64(1560735050),
+		Height:                uint64(12345),
+		Nonce:                 uint64(1000),
+		ExtraNonce:            uint64(7),
+	}
+
+	// Iteration counts that do and don't line up with the batch size.
+	for _, iterations := range []uint64{0, 1, 7, 8, 100, 1001} {
+		startNonce := header.Nonce
+
+		// Compute the expected result one nonce at a time.
+		scalarHeader := *header
+		expectedHash, err := scalarHeader.Hash()
+		require.NoError(err)
+		expectedNonce := startNonce
+		for nonce := startNonce + 1; nonce <= startNonce+iterations; nonce++ {
+			scalarHeader.Nonce = nonce
+			currentHash, err := scalarHeader.Hash()
+			require.NoError(err)
+			if LessThan(currentHash, expectedHash) {
+				expectedHash = currentHash
+				expectedNonce = nonce
+			}
+		}
+
+		bestHash, bestNonce, err := FindLowestHash(header, iterations)
+		require.NoError(err)
+		require.Equal(*expectedHash, *bestHash)
+		require.Equal(expectedNonce, bestNonce)
+		require.Equal(startNonce+iterations+1, header.Nonce)
+
+		// The nonce returned produces the hash returned.
+		checkHeader := *header
+		checkHeader.Nonce = bestNonce
+		checkHash, err := checkHeader.Hash()
+		require.NoError(err)
+		require.Equal(*bestHash, *checkHash)
+	}
+}
@@ -0,0 +1,669 @@
+package lib
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/deso-protocol/go-deadlock"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// mining_server.go implements a work server that external miners connect to over
+// TCP, in the spirit of a mining pool. Messages are newline-delimited JSON objects.
+//
+// A miner first sends an "authorize" request naming the worker and the public key
+// its block rewards should be paid to. The server responds and then pushes a "job"
+// notification containing a header to hash on. A new job is pushed every time the
+// BlockProducer produces a new block template. The miner iterates the header's
+// Nonce (and ExtraNonce for v1 headers) and sends a "submit" request for every
+// hash that beats the job's ShareTargetHex. Shares are tracked per worker so that
+// payouts can be split across the MinerPublicKeys, and shares that also beat the
+// BlockTargetHex are assembled into a full block and passed to ProcessBlock.
+
+const (
+	MiningServerMethodAuthorize = "authorize"
+	MiningServerMethodSubmit    = "submit"
+	MiningServerMethodJob       = "job"
+
+	// The number of jobs we remember for each worker. Shares submitted against
+	// jobs older than this are rejected as unknown.
+	MiningServerMaxJobsPerWorker = 10
+
+	// How often we check the BlockProducer for a new block template.
+	miningServerJobPollInterval = 500 * time.Millisecond
+)
+
+// The number of shares we accept for a single job. Every share's nonce is kept
+// to reject duplicates, so this together with MiningServerMaxJobsPerWorker
+// bounds the memory a single worker can make us use. Shares past the cap are
+// rejected until the next job is pushed. This is a var so tests can lower it.
+var MiningServerMaxSharesPerJob = 100000
+
+// MiningServerRequest is sent from a miner to the MiningServer.
+type MiningServerRequest struct {
+	ID     uint64
+	Method string
+
+	// Set on "authorize" requests.
+	WorkerName           string `json:",omitempty"`
+	PublicKeyBase58Check string `json:",omitempty"`
+
+	// Set on "submit" requests.
+	JobID      string `json:",omitempty"`
+	Nonce      uint64 `json:",omitempty"`
+	ExtraNonce uint64 `json:",omitempty"`
+}
+
+// MiningServerJob describes a header for a miner to hash on.
+type MiningServerJob struct {
+	JobID         string
+	HeaderHex     string
+	HeaderVersion uint32
+	Height        uint64
+	// Hashes less than or equal to ShareTargetHex are accepted as shares.
+	ShareTargetHex string
+	// Hashes less than or equal to BlockTargetHex also solve the block.
+	BlockTargetHex string
+	// Set when the job builds on a new tip, meaning all previous jobs are stale.
+	CleanJobs bool
+}
+
+// MiningServerMessage is sent from the MiningServer to a miner. Responses echo the
+// ID and Method of the request they answer. Job notifications have an ID of zero.
+type MiningServerMessage struct {
+	ID           uint64           `json:",omitempty"`
+	Method       string           `json:",omitempty"`
+	Result       bool             `json:",omitempty"`
+	Error        string           `json:",omitempty"`
+	BlockHashHex string           `json:",omitempty"`
+	Job          *MiningServerJob `json:",omitempty"`
+}
+
+// MiningWorkerStats tracks the work done by a single worker since the last
+// call to ResetShares.
+type MiningWorkerStats struct {
+	WorkerName           string
+	PublicKeyBase58Check string
+	AcceptedShares       uint64
+	RejectedShares       uint64
+	BlocksFound          uint64
+}
+
+type miningServerJob struct {
+	jobID      string
+	blockID    string
+	publicKey  []byte
+	rewardData uint64
+	header     *MsgDeSoHeader
+	diffTarget *BlockHash
+
+	// Used to reject duplicate shares. Capped at MiningServerMaxSharesPerJob.
+	submittedNonces map[[2]uint64]bool
+}
+
+type miningServerConn struct {
+	conn net.Conn
+
+	writeMtx sync.Mutex
+	encoder  *json.Encoder
+
+	// Only set once the connection has been authorized.
+	workerName string
+	publicKey  []byte
+
+	jobs     map[string]*miningServerJob
+	jobOrder []string
+}
+
+type DeSoMiningServer struct {
+	listener        net.Listener
+	shareTarget     *BlockHash
+	minerPublicKeys map[PkMapKey]bool
+	blockProducer   *DeSoBlockProducer
+	params          *DeSoParams
+
+	// Protects conns, workerStats, nextJobID, the last template hashes, and the
+	// job state on each conn.
+	mtx         deadlock.RWMutex
+	conns       map[*miningServerConn]bool
+	workerStats map[string]*MiningWorkerStats
+	nextJobID   uint64
+
+	// The template and tip that the most recent job was built on.
+	lastTemplateHash *BlockHash
+	lastPrevHash     *BlockHash
+
+	serverWaitGroup   sync.WaitGroup
+	stopServerChannel chan struct{}
+}
+
+// NewDeSoMiningServer creates a mining server that accepts miners on the listener
+// passed in. Workers must authorize with one of the minerPublicKeys, which is where
+// the block rewards for the blocks they find are paid.
+func NewDeSoMiningServer(
+	listener net.Listener,
+	shareTargetHex string,
+	minerPublicKeys []string,
+	blockProducer *DeSoBlockProducer,
+	params *DeSoParams) (*DeSoMiningServer, error) {
+
+	if blockProducer == nil {
+		return nil, fmt.Errorf("NewDeSoMiningServer: A BlockProducer is required; set " +
+			"max-block-templates-cache to a non-zero value")
+	}
+	if len(minerPublicKeys) == 0 {
+		return nil, fmt.Errorf("NewDeSoMiningServer: At least one miner public key is required")
+	}
+
+	if shareTargetHex == "" {
+		shareTargetHex = params.MinDifficultyTargetHex
+	}
+	shareTargetBytes, err := hex.DecodeString(shareTargetHex)
+	if err != nil {
+		return nil, errors.Wrapf(err, "NewDeSoMiningServer: Problem decoding share target: ")
+	}
+	if len(shareTargetBytes) != HashSizeBytes {
+		return nil, fmt.Errorf("NewDeSoMiningServer: Share target has length %d but "+
+			"must be %d bytes", len(shareTargetBytes), HashSizeBytes)
+	}
+
+	pkMap := make(map[PkMapKey]bool)
+	for _, publicKeyBase58 := range minerPublicKeys {
+		pkBytes, _, err := Base58CheckDecode(publicKeyBase58)
+		if err != nil {
+			return nil, errors.Wrapf(err, "NewDeSoMiningServer: ")
+		}
+		if len(pkBytes) != btcec.PubKeyBytesLenCompressed {
+			return nil, fmt.Errorf("NewDeSoMiningServer: Miner public key %v has "+
+				"invalid length %d", publicKeyBase58, len(pkBytes))
+		}
+		pkMap[MakePkMapKey(pkBytes)] = true
+	}
+
+	return &DeSoMiningServer{
+		listener:          listener,
+		shareTarget:       CopyBytesIntoBlockHash(shareTargetBytes),
+		minerPublicKeys:   pkMap,
+		blockProducer:     blockProducer,
+		params:            params,
+		conns:             make(map[*miningServerConn]bool),
+		workerStats:       make(map[string]*MiningWorkerStats),
+		stopServerChannel: make(chan struct{}),
+	}, nil
+}
+
+func (ms *DeSoMiningServer) Start() {
+	glog.Infof("DeSoMiningServer.Start: Accepting miners on %v with share target %v",
+		ms.listener.Addr(), ms.shareTarget)
+
+	ms.serverWaitGroup.Add(2)
+	go ms._acceptConnections()
+	go ms._pushJobsOnNewTemplates()
+}
+
+func (ms *DeSoMiningServer) Stop() {
+	glog.Info("DeSoMiningServer.Stop: Stopping mining server")
+
+	close(ms.stopServerChannel)
+	ms.listener.Close()
+
+	ms.mtx.RLock()
+	for msConn := range ms.conns {
+		msConn.conn.Close()
+	}
+	ms.mtx.RUnlock()
+
+	ms.serverWaitGroup.Wait()
+}
+
+func (ms *DeSoMiningServer) _acceptConnections() {
+	defer ms.serverWaitGroup.Done()
+
+	for {
+		conn, err := ms.listener.Accept()
+		if err != nil {
+			select {
+			case <-ms.stopServerChannel:
+				return
+			default:
+			}
+			glog.Errorf("DeSoMiningServer._acceptConnections: Problem accepting "+
+				"connection: %v", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		msConn := &miningServerConn{
+			conn:    conn,
+			encoder: json.NewEncoder(conn),
+			jobs:    make(map[string]*miningServerJob),
+		}
+		ms.mtx.Lock()
+		ms.conns[msConn] = true
+		ms.mtx.Unlock()
+
+		ms.serverWaitGroup.Add(1)
+		go ms._handleConnection(msConn)
+	}
+}
+
+func (ms *DeSoMiningServer) _handleConnection(msConn *miningServerConn) {
+	defer ms.serverWaitGroup.Done()
+	defer func() {
+		msConn.conn.Close()
+		ms.mtx.Lock()
+		delete(ms.conns, msConn)
+		ms.mtx.Unlock()
+	}()
+
+	glog.V(1).Infof("DeSoMiningServer._handleConnection: New miner connected from %v",
+		msConn.conn.RemoteAddr())
+
+	scanner := bufio.NewScanner(msConn.conn)
+	for scanner.Scan() {
+		req := &MiningServerRequest{}
+		if err := json.Unmarshal(scanner.Bytes(), req); err != nil {
+			ms._send(msConn, &MiningServerMessage{
+				Error: fmt.Sprintf("Problem parsing request: %v", err),
+			})
+			continue
+		}
+
+		switch req.Method {
+		case MiningServerMethodAuthorize:
+			ms._handleAuthorize(msConn, req)
+		case MiningServerMethodSubmit:
+			ms._handleSubmit(msConn, req)
+		default:
+			ms._send(msConn, &MiningServerMessage{
+				ID:     req.ID,
+				Method: req.Method,
+				Error:  fmt.Sprintf("Unknown method %v", req.Method),
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		glog.V(1).Infof("DeSoMiningServer._handleConnection: Miner %v disconnected: %v",
+			msConn.conn.RemoteAddr(), err)
+	}
+}
+
+func (ms *DeSoMiningServer) _send(msConn *miningServerConn, msg *MiningServerMessage) {
+	msConn.writeMtx.Lock()
+	defer msConn.writeMtx.Unlock()
+
+	if err := msConn.encoder.Encode(msg); err != nil {
+		glog.V(1).Infof("DeSoMiningServer._send: Problem sending message to %v: %v",
+			msConn.conn.RemoteAddr(), err)
+	}
+}
+
+func (ms *DeSoMiningServer) _handleAuthorize(msConn *miningServerConn, req *MiningServerRequest) {
+	resp := &MiningServerMessage{
+		ID:     req.ID,
+		Method: req.Method,
+	}
+
+	pkBytes, _, err := Base58CheckDecode(req.PublicKeyBase58Check)
+	if err != nil {
+		resp.Error = fmt.Sprintf("Problem decoding public key: %v", err)
+		ms._send(msConn, resp)
+		return
+	}
+	if !ms.minerPublicKeys[MakePkMapKey(pkBytes)] {
+		resp.Error = fmt.Sprintf("Public key %v is not one of this server's "+
+			"miner public keys", req.PublicKeyBase58Check)
+		ms._send(msConn, resp)
+		return
+	}
+	if req.WorkerName == "" {
+		resp.Error = "WorkerName is required"
+		ms._send(msConn, resp)
+		return
+	}
+
+	// A worker name stays bound to the first public key it authorized with. Otherwise
+	// anyone could take over a worker's name and move its shares to their own key
+	// when GetPayoutSplit is called.
+	publicKeyBase58Check := PkToString(pkBytes, ms.params)
+	ms.mtx.Lock()
+	if stats, exists := ms.workerStats[req.WorkerName]; exists &&
+		stats.PublicKeyBase58Check != publicKeyBase58Check {
+
+		ms.mtx.Unlock()
+		resp.Error = fmt.Sprintf("Worker %v is already authorized with public key %v",
+			req.WorkerName, stats.PublicKeyBase58Check)
+		ms._send(msConn, resp)
+		return
+	}
+	msConn.workerName = req.WorkerName
+	msConn.publicKey = pkBytes
+	if _, exists := ms.workerStats[req.WorkerName]; !exists {
+		ms.workerStats[req.WorkerName] = &MiningWorkerStats{
+			WorkerName:           req.WorkerName,
+			PublicKeyBase58Check: publicKeyBase58Check,
+		}
+	}
+	ms.mtx.Unlock()
+
+	resp.Result = true
+	ms._send(msConn, resp)
+
+	// Give the worker something to do right away.
+	ms._sendNewJob(msConn, true /*cleanJobs*/)
+}
+
+// _sendNewJob computes a fresh header for the connection's public key and pushes
+// it to the miner.
+func (ms *DeSoMiningServer) _sendNewJob(msConn *miningServerConn, cleanJobs bool) {
+	ms.mtx.RLock()
+	publicKey := msConn.publicKey
+	ms.mtx.RUnlock()
+	if publicKey == nil {
+		return
+	}
+
+	blockID, headers, extraNonces, diffTarget, err := ms.blockProducer.GetHeadersAndExtraDatas(
+		publicKey, 1 /*numHeaders*/, CurrentHeaderVersion)
+	if err != nil {
+		glog.Errorf("DeSoMiningServer._sendNewJob: Problem getting header: %v", err)
+		return
+	}
+	header := &MsgDeSoHeader{}
+	if err := header.FromBytes(headers[0]); err != nil {
+		glog.Errorf("DeSoMiningServer._sendNewJob: Problem parsing header: %v", err)
+		return
+	}
+	templateHashBytes, err := hex.DecodeString(blockID)
+	if err != nil {
+		glog.Errorf("DeSoMiningServer._sendNewJob: Problem decoding blockID: %v", err)
+		return
+	}
+	templateHash := CopyBytesIntoBlockHash(templateHashBytes)
+
+	ms.mtx.Lock()
+	ms.nextJobID++
+	job := &miningServerJob{
+		jobID:           fmt.Sprintf("%x", ms.nextJobID),
+		blockID:         blockID,
+		publicKey:       publicKey,
+		rewardData:      extraNonces[0],
+		header:          header,
+		diffTarget:      diffTarget,
+		submittedNonces: make(map[[2]uint64]bool),
+	}
+	if cleanJobs {
+		msConn.jobs = make(map[string]*miningServerJob)
+		msConn.jobOrder = nil
+	}
+	msConn.jobs[job.jobID] = job
+	ms.lastTemplateHash = templateHash
+	ms.lastPrevHash = header.PrevBlockHash
+	msConn.jobOrder = append(msConn.jobOrder, job.jobID)
+	for len(msConn.jobOrder) > MiningServerMaxJobsPerWorker {
+		delete(msConn.jobs, msConn.jobOrder[0])
+		msConn.jobOrder = msConn.jobOrder[1:]
+	}
+	ms.mtx.Unlock()
+
+	ms._send(msConn, &MiningServerMessage{
+		Method: MiningServerMethodJob,
+		Job: &MiningServerJob{
+			JobID:          job.jobID,
+			HeaderHex:      hex.EncodeToString(headers[0]),
+			HeaderVersion:  header.Version,
+			Height:         header.Height,
+			ShareTargetHex: hex.EncodeToString(ms.shareTarget[:]),
+			BlockTargetHex: hex.EncodeToString(diffTarget[:]),
+			CleanJobs:      cleanJobs,
+		},
+	})
+}
+
+// _pushJobsOnNewTemplates polls the BlockProducer and pushes a new job to every
+// authorized miner whenever the latest block template changes.
+func (ms *DeSoMiningServer) _pushJobsOnNewTemplates() {
+	defer ms.serverWaitGroup.Done()
+
+	for {
+		select {
+		case <-ms.stopServerChannel:
+			return
+		case <-time.After(miningServerJobPollInterval):
+		}
+
+		latestTemplateHash := ms.blockProducer.GetLatestBlockTemplateHash()
+		if latestTemplateHash == nil {
+			continue
+		}
+		latestTemplate := ms.blockProducer.GetRecentBlock(latestTemplateHash)
+		if latestTemplate == nil {
+			continue
+		}
+
+		// Only push if we've handed out jobs on an older template. If we haven't
+		// handed out any jobs yet then there's nobody to push to.
+		ms.mtx.RLock()
+		lastTemplateHash := ms.lastTemplateHash
+		lastPrevHash := ms.lastPrevHash
+		ms.mtx.RUnlock()
+		if lastTemplateHash == nil || *lastTemplateHash == *latestTemplateHash {
+			continue
+		}
+		cleanJobs := *lastPrevHash != *latestTemplate.Header.PrevBlockHash
+
+		ms.mtx.RLock()
+		msConns := []*miningServerConn{}
+		for msConn := range ms.conns {
+			msConns = append(msConns, msConn)
+		}
+		ms.mtx.RUnlock()
+
+		for _, msConn := range msConns {
+			ms._sendNewJob(msConn, cleanJobs)
+		}
+	}
+}
+
+func (ms *DeSoMiningServer) _handleSubmit(msConn *miningServerConn, req *MiningServerRequest) {
+	resp := &MiningServerMessage{
+		ID:     req.ID,
+		Method: req.Method,
+	}
+	blockHash, err := ms._processShare(msConn, req)
+	if err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Result = true
+	}
+	if blockHash != nil {
+		resp.BlockHashHex = hex.EncodeToString(blockHash[:])
+	}
+	ms._send(msConn, resp)
+}
+
+// _processShare validates a share and records it against the connection's worker.
+// If the share also solves the block then the block is assembled and processed, and
+// its hash is returned.
+func (ms *DeSoMiningServer) _processShare(msConn *miningServerConn, req *MiningServerRequest) (
+	_blockHash *BlockHash, _err error) {
+
+	ms.mtx.Lock()
+	if msConn.workerName == "" {
+		ms.mtx.Unlock()
+		return nil, fmt.Errorf("Worker must authorize before submitting shares")
+	}
+	stats := ms.workerStats[msConn.workerName]
+	job, exists := msConn.jobs[req.JobID]
+	if !exists {
+		stats.RejectedShares++
+		ms.mtx.Unlock()
+		return nil, fmt.Errorf("Unknown or expired job %v", req.JobID)
+	}
+	nonceKey := [2]uint64{req.Nonce, req.ExtraNonce}
+	if job.submittedNonces[nonceKey] {
+		stats.RejectedShares++
+		ms.mtx.Unlock()
+		return nil, fmt.Errorf("Duplicate share for job %v", req.JobID)
+	}
+	if len(job.submittedNonces) >= MiningServerMaxSharesPerJob {
+		stats.RejectedShares++
+		ms.mtx.Unlock()
+		return nil, fmt.Errorf("Too many shares for job %v; wait for the next job", req.JobID)
+	}
+	job.submittedNonces[nonceKey] = true
+	ms.mtx.Unlock()
+
+	// Reject shares on jobs that no longer build on the tip.
+	if tip := ms.blockProducer.chain.BlockTip(); tip != nil && *tip.Hash != *job.header.PrevBlockHash {
+		ms._recordShare(msConn.workerName, false)
+		return nil, fmt.Errorf("Stale share for job %v", req.JobID)
+	}
+
+	header := *job.header
+	header.Nonce = req.Nonce
+	if header.Version == HeaderVersion1 {
+		header.ExtraNonce = req.ExtraNonce
+	}
+	hash, err := header.Hash()
+	if err != nil {
+		ms._recordShare(msConn.workerName, false)
+		return nil, errors.Wrapf(err, "Problem hashing header: ")
+	}
+	if LessThan(ms.shareTarget, hash) && LessThan(job.diffTarget, hash) {
+		ms._recordShare(msConn.workerName, false)
+		return nil, fmt.Errorf("Share hash %v does not meet share target %v",
+			hash, ms.shareTarget)
+	}
+	ms._recordShare(msConn.workerName, true)
+
+	if LessThan(job.diffTarget, hash) {
+		return nil, nil
+	}
+
+	// If we get here then the share solves the block.
+	blockToSubmit, err := ms.blockProducer.GetCopyOfRecentBlock(job.blockID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Problem getting block for solved job %v: ", req.JobID)
+	}
+
+	// Swap in the public key and extraNonce so the block is consistent with the
+	// header the miner was hashing on.
+	blockToSubmit.Txns[0].TxOutputs[0].PublicKey = job.publicKey
+	blockToSubmit.Txns[0].TxnMeta.(*BlockRewardMetadataa).ExtraData = UintToBuf(job.rewardData)
+	blockToSubmit.Header = &header
+
+	if err := ms.blockProducer.SignBlock(blockToSubmit); err != nil {
+		return nil, errors.Wrapf(err, "Problem signing block: ")
+	}
+
+	isMainChain, isOrphan, err := ms.blockProducer.chain.ProcessBlock(
+		blockToSubmit, true /*verifySignatures*/)
+	if err != nil {
+		return hash, fmt.Errorf("Problem processing block: isMainChain=(%v), "+
+			"isOrphan=(%v), err=(%v)", isMainChain, isOrphan, err)
+	}
+	glog.Infof("DeSoMiningServer._processShare: Worker %v found block %v at height %d",
+		msConn.workerName, hash, header.Height)
+
+	ms.mtx.Lock()
+	ms.workerStats[msConn.workerName].BlocksFound++
+	ms.mtx.Unlock()
+
+	return hash, nil
+}
+
+func (ms *DeSoMiningServer) _recordShare(workerName string, accepted bool) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+
+	if accepted {
+		ms.workerStats[workerName].AcceptedShares++
+	} else {
+		ms.workerStats[workerName].RejectedShares++
+	}
+}
+
+// GetWorkerStats returns a copy of the stats for every worker that has authorized
+// since the last call to ResetShares.
+func (ms *DeSoMiningServer) GetWorkerStats() map[string]*MiningWorkerStats {
+	ms.mtx.RLock()
+	defer ms.mtx.RUnlock()
+
+	statsCopy := make(map[string]*MiningWorkerStats)
+	for workerName, stats := range ms.workerStats {
+		statCopy := *stats
+		statsCopy[workerName] = &statCopy
+	}
+	return statsCopy
+}
+
+// ResetShares clears the share counts for every worker. It is typically called
+// after a payout has been made so the next round starts from zero.
+func (ms *DeSoMiningServer) ResetShares() {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+
+	for _, stats := range ms.workerStats {
+		stats.AcceptedShares = 0
+		stats.RejectedShares = 0
+		stats.BlocksFound = 0
+	}
+}
+
+// GetPayoutSplit divides totalNanos across the miner public keys in proportion to
+// the accepted shares of the workers authorized with each key. Any nanos lost to
+// rounding go to the key with the most shares.
+func (ms *DeSoMiningServer) GetPayoutSplit(totalNanos uint64) map[string]uint64 {
+	ms.mtx.RLock()
+	sharesByPublicKey := make(map[string]uint64)
+	totalShares := uint64(0)
+	for _, stats := range ms.workerStats {
+		if stats.AcceptedShares == 0 {
+			continue
+		}
+		sharesByPublicKey[stats.PublicKeyBase58Check] += stats.AcceptedShares
+		totalShares += stats.AcceptedShares
+	}
+	ms.mtx.RUnlock()
+
+	payouts := make(map[string]uint64)
+	if totalShares == 0 {
+		return payouts
+	}
+
+	// Sort the keys so the rounding remainder is assigned deterministically.
+	publicKeys := []string{}
+	for publicKey := range sharesByPublicKey {
+		publicKeys = append(publicKeys, publicKey)
+	}
+	sort.Strings(publicKeys)
+
+	remainingNanos := totalNanos
+	largestPublicKey := ""
+	for _, publicKey := range publicKeys {
+		shares := sharesByPublicKey[publicKey]
+		payout := big.NewInt(0).Div(
+			big.NewInt(0).Mul(
+				big.NewInt(0).SetUint64(totalNanos), big.NewInt(0).SetUint64(shares)),
+			big.NewInt(0).SetUint64(totalShares)).Uint64()
+		payouts[publicKey] = payout
+		remainingNanos -= payout
+
+		if largestPublicKey == "" || shares > sharesByPublicKey[largestPublicKey] {
+			largestPublicKey = publicKey
+		}
+	}
+	payouts[largestPublicKey] += remainingNanos
+
+	return payouts
+}
@@ -0,0 +1,301 @@
+package lib
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type _testMiningClient struct {
+	conn    net.Conn
+	encoder *json.Encoder
+	scanner *bufio.Scanner
+	nextID  uint64
+}
+
+func _newTestMiningClient(t *testing.T, addr string) *_testMiningClient {
+	require := require.New(t)
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(err)
+	return &_testMiningClient{
+		conn:    conn,
+		encoder: json.NewEncoder(conn),
+		scanner: bufio.NewScanner(conn),
+	}
+}
+
+func (client *_testMiningClient) _send(t *testing.T, req *MiningServerRequest) {
+	client.nextID++
+	req.ID = client.nextID
+	require.NoError(t, client.encoder.Encode(req))
+}
+
+func (client *_testMiningClient) _read(t *testing.T) *MiningServerMessage {
+	require := require.New(t)
+
+	require.NoError(client.conn.SetReadDeadline(time.Now().Add(10 * time.Second)))
+	require.True(client.scanner.Scan(), "%v", client.scanner.Err())
+	msg := &MiningServerMessage{}
+	require.NoError(json.Unmarshal(client.scanner.Bytes(), msg))
+	return msg
+}
+
+// _findNonce returns a nonce for the job whose hash either solves the block or
+// doesn't, depending on solvesBlock.
+func _findNonce(t *testing.T, job *MiningServerJob, solvesBlock bool) uint64 {
+	return _findNonceFrom(t, job, solvesBlock, 1)
+}
+
+func _findNonceFrom(t *testing.T, job *MiningServerJob, solvesBlock bool, startNonce uint64) uint64 {
+	require := require.New(t)
+
+	headerBytes, err := hex.DecodeString(job.HeaderHex)
+	require.NoError(err)
+	header := &MsgDeSoHeader{}
+	require.NoError(header.FromBytes(headerBytes))
+	blockTarget := mustDecodeHexBlockHash(job.BlockTargetHex)
+
+	for nonce := startNonce; ; nonce++ {
+		header.Nonce = nonce
+		hash, err := header.Hash()
+		require.NoError(err)
+		if LessThan(blockTarget, hash) != solvesBlock {
+			return nonce
+		}
+	}
+}
+
+func TestMiningServer(t *testing.T) {
+	require := require.New(t)
+
+	chain, params, _ := NewLowDifficultyBlockchain()
+	_, miner := NewTestMiner(t, chain, params, true /*isSender*/)
+	blockProducer := miner.BlockProducer
+
+	// Every hash is a share.
+	shareTargetHex := strings.Repeat("ff", HashSizeBytes)
+
+	// A BlockProducer and at least one miner public key are required.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(err)
+	_, err = NewDeSoMiningServer(listener, shareTargetHex, []string{senderPkString}, nil, params)
+	require.Error(err)
+	_, err = NewDeSoMiningServer(listener, shareTargetHex, []string{}, blockProducer, params)
+	require.Error(err)
+
+	miningServer, err := NewDeSoMiningServer(
+		listener, shareTargetHex, []string{senderPkString, recipientPkString}, blockProducer, params)
+	require.NoError(err)
+	miningServer.Start()
+	defer miningServer.Stop()
+
+	client1 := _newTestMiningClient(t, listener.Addr().String())
+
+	// Submitting before authorizing fails.
+	{
+		client1._send(t, &MiningServerRequest{Method: MiningServerMethodSubmit, JobID: "1"})
+		resp := client1._read(t)
+		require.False(resp.Result)
+		require.Contains(resp.Error, "authorize")
+	}
+
+	// Authorizing with a key that isn't one of the miner public keys fails.
+	{
+		client1._send(t, &MiningServerRequest{
+			Method:               MiningServerMethodAuthorize,
+			WorkerName:           "worker1",
+			PublicKeyBase58Check: moneyPkString,
+		})
+		resp := client1._read(t)
+		require.False(resp.Result)
+		require.Contains(resp.Error, "miner public keys")
+	}
+
+	// Authorizing with a miner public key succeeds and pushes a job.
+	var job1 *MiningServerJob
+	{
+		client1._send(t, &MiningServerRequest{
+			Method:               MiningServerMethodAuthorize,
+			WorkerName:           "worker1",
+			PublicKeyBase58Check: senderPkString,
+		})
+		resp := client1._read(t)
+		require.True(resp.Result)
+		require.Equal("", resp.Error)
+
+		notify := client1._read(t)
+		require.Equal(MiningServerMethodJob, notify.Method)
+		require.NotNil(notify.Job)
+		require.Equal(shareTargetHex, notify.Job.ShareTargetHex)
+		require.Equal(uint64(1), notify.Job.Height)
+		job1 = notify.Job
+	}
+
+	// A share that doesn't solve the block is accepted, but resubmitting it or
+	// submitting against an unknown job is rejected.
+	{
+		nonce := _findNonce(t, job1, false /*solvesBlock*/)
+		client1._send(t, &MiningServerRequest{
+			Method: MiningServerMethodSubmit,
+			JobID:  job1.JobID,
+			Nonce:  nonce,
+		})
+		resp := client1._read(t)
+		require.True(resp.Result)
+		require.Equal("", resp.BlockHashHex)
+
+		client1._send(t, &MiningServerRequest{
+			Method: MiningServerMethodSubmit,
+			JobID:  job1.JobID,
+			Nonce:  nonce,
+		})
+		resp = client1._read(t)
+		require.False(resp.Result)
+		require.Contains(resp.Error, "Duplicate")
+
+		client1._send(t, &MiningServerRequest{
+			Method: MiningServerMethodSubmit,
+			JobID:  "deadbeef",
+			Nonce:  nonce,
+		})
+		resp = client1._read(t)
+		require.False(resp.Result)
+		require.Contains(resp.Error, "Unknown")
+	}
+
+	// Once a job has hit the share cap, new shares against it are rejected.
+	{
+		prevMaxShares := MiningServerMaxSharesPerJob
+		MiningServerMaxSharesPerJob = 2
+		nonce := _findNonceFrom(t, job1, false /*solvesBlock*/, _findNonce(t, job1, false)+1)
+		client1._send(t, &MiningServerRequest{
+			Method: MiningServerMethodSubmit,
+			JobID:  job1.JobID,
+			Nonce:  nonce,
+		})
+		resp := client1._read(t)
+		require.True(resp.Result)
+
+		nonce = _findNonceFrom(t, job1, false /*solvesBlock*/, nonce+1)
+		client1._send(t, &MiningServerRequest{
+			Method: MiningServerMethodSubmit,
+			JobID:  job1.JobID,
+			Nonce:  nonce,
+		})
+		resp = client1._read(t)
+		require.False(resp.Result)
+		require.Contains(resp.Error, "Too many shares")
+		MiningServerMaxSharesPerJob = prevMaxShares
+	}
+
+	// A share that solves the block is processed and becomes the new tip.
+	{
+		nonce := _findNonce(t, job1, true /*solvesBlock*/)
+		client1._send(t, &MiningServerRequest{
+			Method: MiningServerMethodSubmit,
+			JobID:  job1.JobID,
+			Nonce:  nonce,
+		})
+		resp := client1._read(t)
+		require.True(resp.Result)
+		require.Equal("", resp.Error)
+		require.Equal(uint32(1), chain.blockTip().Height)
+		require.Equal(resp.BlockHashHex, hex.EncodeToString(chain.blockTip().Hash[:]))
+
+		// The block reward goes to the worker's public key.
+		blockFound, err := GetBlock(chain.blockTip().Hash, chain.db)
+		require.NoError(err)
+		require.Equal(MustBase58CheckDecode(senderPkString), blockFound.Txns[0].TxOutputs[0].PublicKey)
+	}
+
+	// Once the BlockProducer builds on the new tip, a clean job is pushed.
+	var job2 *MiningServerJob
+	{
+		require.NoError(blockProducer.UpdateLatestBlockTemplate())
+		notify := client1._read(t)
+		require.Equal(MiningServerMethodJob, notify.Method)
+		require.True(notify.Job.CleanJobs)
+		require.Equal(uint64(2), notify.Job.Height)
+		job2 = notify.Job
+
+		// Shares on the old job are rejected.
+		client1._send(t, &MiningServerRequest{
+			Method: MiningServerMethodSubmit,
+			JobID:  job1.JobID,
+			Nonce:  _findNonce(t, job1, false /*solvesBlock*/),
+		})
+		resp := client1._read(t)
+		require.False(resp.Result)
+		require.Contains(resp.Error, "Unknown")
+
+		client1._send(t, &MiningServerRequest{
+			Method: MiningServerMethodSubmit,
+			JobID:  job2.JobID,
+			Nonce:  _findNonce(t, job2, false /*solvesBlock*/),
+		})
+		resp = client1._read(t)
+		require.True(resp.Result)
+	}
+
+	// A second worker mining to a different key.
+	{
+		client2 := _newTestMiningClient(t, listener.Addr().String())
+		client2._send(t, &MiningServerRequest{
+			Method:               MiningServerMethodAuthorize,
+			WorkerName:           "worker2",
+			PublicKeyBase58Check: recipientPkString,
+		})
+		resp := client2._read(t)
+		require.True(resp.Result)
+		notify := client2._read(t)
+		require.Equal(uint64(2), notify.Job.Height)
+
+		client2._send(t, &MiningServerRequest{
+			Method: MiningServerMethodSubmit,
+			JobID:  notify.Job.JobID,
+			Nonce:  _findNonce(t, notify.Job, false /*solvesBlock*/),
+		})
+		resp = client2._read(t)
+		require.True(resp.Result)
+	}
+
+	// A worker name can't be taken over with a different key.
+	{
+		client3 := _newTestMiningClient(t, listener.Addr().String())
+		client3._send(t, &MiningServerRequest{
+			Method:               MiningServerMethodAuthorize,
+			WorkerName:           "worker1",
+			PublicKeyBase58Check: recipientPkString,
+		})
+		resp := client3._read(t)
+		require.False(resp.Result)
+		require.Contains(resp.Error, "already authorized")
+	}
+
+	// Shares are tracked per worker.
+	stats := miningServer.GetWorkerStats()
+	require.Equal(2, len(stats))
+	require.Equal(senderPkString, stats["worker1"].PublicKeyBase58Check)
+	require.Equal(uint64(4), stats["worker1"].AcceptedShares)
+	require.Equal(uint64(4), stats["worker1"].RejectedShares)
+	require.Equal(uint64(1), stats["worker1"].BlocksFound)
+	require.Equal(recipientPkString, stats["worker2"].PublicKeyBase58Check)
+	require.Equal(uint64(1), stats["worker2"].AcceptedShares)
+	require.Equal(uint64(0), stats["worker2"].RejectedShares)
+
+	// Payouts are split pro rata across the miner public keys with the rounding
+	// remainder going to the key with the most shares.
+	payouts := miningServer.GetPayoutSplit(101)
+	require.Equal(uint64(76), payouts[senderPkString])
+	require.Equal(uint64(25), payouts[recipientPkString])
+
+	miningServer.ResetShares()
+	require.Equal(0, len(miningServer.GetPayoutSplit(101)))
+}
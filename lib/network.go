@@ -214,8 +214,23 @@ const (
 	TxnTypeMessagingGroup               TxnType = 23
 	TxnTypeDAOCoin                      TxnType = 24
 	TxnTypeDAOCoinTransfer              TxnType = 25
-
-	// NEXT_ID = 26
+	TxnTypePollVote                     TxnType = 26
+	TxnTypeUserBlock                    TxnType = 27
+	TxnTypeMessagingGroupUpdate         TxnType = 28
+	TxnTypeMessageRead                  TxnType = 29
+	TxnTypeCreateNFTCollection          TxnType = 30
+	TxnTypeNFTVault                     TxnType = 31
+	TxnTypeRedeemNFTVoucher             TxnType = 32
+	TxnTypeRegisterBlockProducer        TxnType = 33
+	TxnTypeDeregisterBlockProducer      TxnType = 34
+	TxnTypeUsernameListing              TxnType = 35
+	TxnTypeAcceptUsernameListing        TxnType = 36
+	TxnTypeKeyRotation                  TxnType = 37
+	TxnTypeRecoveryGuardians            TxnType = 38
+	TxnTypeAccountRecovery              TxnType = 39
+	TxnTypeClaimBlockProducerBond       TxnType = 40
+
+	// NEXT_ID = 41
 )
 
 type TxnString string
@@ -246,6 +261,21 @@ const (
 	TxnStringMessagingGroup               TxnString = "MESSAGING_GROUP"
 	TxnStringDAOCoin                      TxnString = "DAO_COIN"
 	TxnStringDAOCoinTransfer              TxnString = "DAO_COIN_TRANSFER"
+	TxnStringPollVote                     TxnString = "POLL_VOTE"
+	TxnStringUserBlock                    TxnString = "USER_BLOCK"
+	TxnStringMessagingGroupUpdate         TxnString = "MESSAGING_GROUP_UPDATE"
+	TxnStringMessageRead                  TxnString = "MESSAGE_READ"
+	TxnStringCreateNFTCollection          TxnString = "CREATE_NFT_COLLECTION"
+	TxnStringNFTVault                     TxnString = "NFT_VAULT"
+	TxnStringRedeemNFTVoucher             TxnString = "REDEEM_NFT_VOUCHER"
+	TxnStringRegisterBlockProducer        TxnString = "REGISTER_BLOCK_PRODUCER"
+	TxnStringDeregisterBlockProducer      TxnString = "DEREGISTER_BLOCK_PRODUCER"
+	TxnStringUsernameListing              TxnString = "USERNAME_LISTING"
+	TxnStringAcceptUsernameListing        TxnString = "ACCEPT_USERNAME_LISTING"
+	TxnStringKeyRotation                  TxnString = "KEY_ROTATION"
+	TxnStringRecoveryGuardians            TxnString = "RECOVERY_GUARDIANS"
+	TxnStringAccountRecovery              TxnString = "ACCOUNT_RECOVERY"
+	TxnStringClaimBlockProducerBond       TxnString = "CLAIM_BLOCK_PRODUCER_BOND"
 	TxnStringUndefined                    TxnString = "TXN_UNDEFINED"
 )
 
@@ -256,7 +286,11 @@ var (
 		TxnTypeCreatorCoin, TxnTypeSwapIdentity, TxnTypeUpdateGlobalParams, TxnTypeCreatorCoinTransfer,
 		TxnTypeCreateNFT, TxnTypeUpdateNFT, TxnTypeAcceptNFTBid, TxnTypeNFTBid, TxnTypeNFTTransfer,
 		TxnTypeAcceptNFTTransfer, TxnTypeBurnNFT, TxnTypeAuthorizeDerivedKey, TxnTypeMessagingGroup,
-		TxnTypeDAOCoin, TxnTypeDAOCoinTransfer,
+		TxnTypeDAOCoin, TxnTypeDAOCoinTransfer, TxnTypePollVote, TxnTypeUserBlock, TxnTypeMessagingGroupUpdate,
+		TxnTypeMessageRead, TxnTypeCreateNFTCollection, TxnTypeNFTVault, TxnTypeRedeemNFTVoucher,
+		TxnTypeRegisterBlockProducer, TxnTypeDeregisterBlockProducer, TxnTypeUsernameListing,
+		TxnTypeAcceptUsernameListing, TxnTypeKeyRotation, TxnTypeRecoveryGuardians, TxnTypeAccountRecovery,
+		TxnTypeClaimBlockProducerBond,
 	}
 	AllTxnString = []TxnString{
 		TxnStringUnset, TxnStringBlockReward, TxnStringBasicTransfer, TxnStringBitcoinExchange, TxnStringPrivateMessage,
@@ -264,7 +298,11 @@ var (
 		TxnStringCreatorCoin, TxnStringSwapIdentity, TxnStringUpdateGlobalParams, TxnStringCreatorCoinTransfer,
 		TxnStringCreateNFT, TxnStringUpdateNFT, TxnStringAcceptNFTBid, TxnStringNFTBid, TxnStringNFTTransfer,
 		TxnStringAcceptNFTTransfer, TxnStringBurnNFT, TxnStringAuthorizeDerivedKey, TxnStringMessagingGroup,
-		TxnStringDAOCoin, TxnStringDAOCoinTransfer,
+		TxnStringDAOCoin, TxnStringDAOCoinTransfer, TxnStringPollVote, TxnStringUserBlock,
+		TxnStringMessagingGroupUpdate, TxnStringMessageRead, TxnStringCreateNFTCollection, TxnStringNFTVault,
+		TxnStringRedeemNFTVoucher, TxnStringRegisterBlockProducer, TxnStringDeregisterBlockProducer,
+		TxnStringUsernameListing, TxnStringAcceptUsernameListing, TxnStringKeyRotation, TxnStringRecoveryGuardians,
+		TxnStringAccountRecovery, TxnStringClaimBlockProducerBond,
 	}
 )
 
@@ -328,6 +366,36 @@ func (txnType TxnType) GetTxnString() TxnString {
 		return TxnStringDAOCoin
 	case TxnTypeDAOCoinTransfer:
 		return TxnStringDAOCoinTransfer
+	case TxnTypePollVote:
+		return TxnStringPollVote
+	case TxnTypeUserBlock:
+		return TxnStringUserBlock
+	case TxnTypeMessagingGroupUpdate:
+		return TxnStringMessagingGroupUpdate
+	case TxnTypeMessageRead:
+		return TxnStringMessageRead
+	case TxnTypeCreateNFTCollection:
+		return TxnStringCreateNFTCollection
+	case TxnTypeNFTVault:
+		return TxnStringNFTVault
+	case TxnTypeRedeemNFTVoucher:
+		return TxnStringRedeemNFTVoucher
+	case TxnTypeRegisterBlockProducer:
+		return TxnStringRegisterBlockProducer
+	case TxnTypeDeregisterBlockProducer:
+		return TxnStringDeregisterBlockProducer
+	case TxnTypeUsernameListing:
+		return TxnStringUsernameListing
+	case TxnTypeAcceptUsernameListing:
+		return TxnStringAcceptUsernameListing
+	case TxnTypeKeyRotation:
+		return TxnStringKeyRotation
+	case TxnTypeRecoveryGuardians:
+		return TxnStringRecoveryGuardians
+	case TxnTypeAccountRecovery:
+		return TxnStringAccountRecovery
+	case TxnTypeClaimBlockProducerBond:
+		return TxnStringClaimBlockProducerBond
 	default:
 		return TxnStringUndefined
 	}
@@ -385,6 +453,36 @@ func GetTxnTypeFromString(txnString TxnString) TxnType {
 		return TxnTypeDAOCoin
 	case TxnStringDAOCoinTransfer:
 		return TxnTypeDAOCoinTransfer
+	case TxnStringPollVote:
+		return TxnTypePollVote
+	case TxnStringUserBlock:
+		return TxnTypeUserBlock
+	case TxnStringMessagingGroupUpdate:
+		return TxnTypeMessagingGroupUpdate
+	case TxnStringMessageRead:
+		return TxnTypeMessageRead
+	case TxnStringCreateNFTCollection:
+		return TxnTypeCreateNFTCollection
+	case TxnStringNFTVault:
+		return TxnTypeNFTVault
+	case TxnStringRedeemNFTVoucher:
+		return TxnTypeRedeemNFTVoucher
+	case TxnStringRegisterBlockProducer:
+		return TxnTypeRegisterBlockProducer
+	case TxnStringDeregisterBlockProducer:
+		return TxnTypeDeregisterBlockProducer
+	case TxnStringUsernameListing:
+		return TxnTypeUsernameListing
+	case TxnStringAcceptUsernameListing:
+		return TxnTypeAcceptUsernameListing
+	case TxnStringKeyRotation:
+		return TxnTypeKeyRotation
+	case TxnStringRecoveryGuardians:
+		return TxnTypeRecoveryGuardians
+	case TxnStringAccountRecovery:
+		return TxnTypeAccountRecovery
+	case TxnStringClaimBlockProducerBond:
+		return TxnTypeClaimBlockProducerBond
 	default:
 		// TxnTypeUnset means we couldn't find a matching txn type
 		return TxnTypeUnset
@@ -450,6 +548,36 @@ func NewTxnMetadata(txType TxnType) (DeSoTxnMetadata, error) {
 		return (&DAOCoinMetadata{}).New(), nil
 	case TxnTypeDAOCoinTransfer:
 		return (&DAOCoinTransferMetadata{}).New(), nil
+	case TxnTypePollVote:
+		return (&PollVoteMetadata{}).New(), nil
+	case TxnTypeUserBlock:
+		return (&UserBlockMetadata{}).New(), nil
+	case TxnTypeMessagingGroupUpdate:
+		return (&MessagingGroupUpdateMetadata{}).New(), nil
+	case TxnTypeMessageRead:
+		return (&MessageReadMetadata{}).New(), nil
+	case TxnTypeCreateNFTCollection:
+		return (&CreateNFTCollectionMetadata{}).New(), nil
+	case TxnTypeNFTVault:
+		return (&NFTVaultMetadata{}).New(), nil
+	case TxnTypeRedeemNFTVoucher:
+		return (&RedeemNFTVoucherMetadata{}).New(), nil
+	case TxnTypeRegisterBlockProducer:
+		return (&RegisterBlockProducerMetadata{}).New(), nil
+	case TxnTypeDeregisterBlockProducer:
+		return (&DeregisterBlockProducerMetadata{}).New(), nil
+	case TxnTypeUsernameListing:
+		return (&UsernameListingMetadata{}).New(), nil
+	case TxnTypeAcceptUsernameListing:
+		return (&AcceptUsernameListingMetadata{}).New(), nil
+	case TxnTypeKeyRotation:
+		return (&KeyRotationMetadata{}).New(), nil
+	case TxnTypeRecoveryGuardians:
+		return (&RecoveryGuardiansMetadata{}).New(), nil
+	case TxnTypeAccountRecovery:
+		return (&AccountRecoveryMetadata{}).New(), nil
+	case TxnTypeClaimBlockProducerBond:
+		return (&ClaimBlockProducerBondMetadata{}).New(), nil
 	default:
 		return nil, fmt.Errorf("NewTxnMetadata: Unrecognized TxnType: %v; make sure you add the new type of transaction to NewTxnMetadata", txType)
 	}
@@ -1279,6 +1407,10 @@ type ServiceFlag uint64
 const (
 	// SFFullNode is a flag used to indicate a peer is a full node.
 	SFFullNode ServiceFlag = 1 << iota
+	// SFPrunedNode is a flag used to indicate a peer only stores the bodies of
+	// recent blocks. Pruned nodes do not set SFFullNode so that we never try to
+	// sync the full chain from them.
+	SFPrunedNode
 )
 
 type MsgDeSoVersion struct {
@@ -5036,3 +5168,1517 @@ func (txnData *MessagingGroupMetadata) FromBytes(data []byte) error {
 func (txnData *MessagingGroupMetadata) New() DeSoTxnMetadata {
 	return &MessagingGroupMetadata{}
 }
+
+// ==================================================================
+// PostPoll
+//
+// A poll is declared by a new post that sets PollKey in its ExtraData
+// to an encoded PostPoll. Votes are cast with PollVote transactions.
+// ==================================================================
+
+type PollWeightType uint8
+
+const (
+	// Every voter's vote counts once.
+	PollWeightTypeOneVotePerPKID PollWeightType = 0
+	// Votes are weighted by the voter's balance of the poster's creator coin.
+	PollWeightTypeCreatorCoin PollWeightType = 1
+	// Votes are weighted by the voter's balance of the poster's DAO coin.
+	PollWeightTypeDAOCoin PollWeightType = 2
+)
+
+func (weightType PollWeightType) String() string {
+	switch weightType {
+	case PollWeightTypeOneVotePerPKID:
+		return "OneVotePerPKID"
+	case PollWeightTypeCreatorCoin:
+		return "CreatorCoin"
+	case PollWeightTypeDAOCoin:
+		return "DAOCoin"
+	default:
+		return "Unknown"
+	}
+}
+
+type PostPoll struct {
+	Question []byte
+	Options  [][]byte
+
+	// Votes are accepted in blocks below this height.
+	ClosingBlockHeight uint32
+
+	WeightType PollWeightType
+}
+
+func (poll *PostPoll) ToBytes() []byte {
+	data := []byte{}
+
+	data = append(data, UintToBuf(uint64(len(poll.Question)))...)
+	data = append(data, poll.Question...)
+
+	data = append(data, UintToBuf(uint64(len(poll.Options)))...)
+	for _, option := range poll.Options {
+		data = append(data, UintToBuf(uint64(len(option)))...)
+		data = append(data, option...)
+	}
+
+	data = append(data, UintToBuf(uint64(poll.ClosingBlockHeight))...)
+	data = append(data, byte(poll.WeightType))
+
+	return data
+}
+
+func (poll *PostPoll) FromBytes(data []byte) error {
+	ret := PostPoll{}
+	rr := bytes.NewReader(data)
+
+	var err error
+	ret.Question, err = ReadVarString(rr)
+	if err != nil {
+		return errors.Wrapf(err, "PostPoll.FromBytes: Problem reading Question")
+	}
+
+	numOptions, err := ReadUvarint(rr)
+	if err != nil {
+		return errors.Wrapf(err, "PostPoll.FromBytes: Problem reading number of options")
+	}
+	// Bound the number of options so a malformed poll can't make us allocate a huge slice.
+	if numOptions > MaxPollOptions {
+		return fmt.Errorf("PostPoll.FromBytes: Number of options %d exceeds max %d",
+			numOptions, MaxPollOptions)
+	}
+	for ii := uint64(0); ii < numOptions; ii++ {
+		option, err := ReadVarString(rr)
+		if err != nil {
+			return errors.Wrapf(err, "PostPoll.FromBytes: Problem reading option %d", ii)
+		}
+		ret.Options = append(ret.Options, option)
+	}
+
+	closingBlockHeight, err := ReadUvarint(rr)
+	if err != nil {
+		return errors.Wrapf(err, "PostPoll.FromBytes: Problem reading ClosingBlockHeight")
+	}
+	if closingBlockHeight > math.MaxUint32 {
+		return fmt.Errorf("PostPoll.FromBytes: ClosingBlockHeight %d overflows uint32",
+			closingBlockHeight)
+	}
+	ret.ClosingBlockHeight = uint32(closingBlockHeight)
+
+	weightType, err := rr.ReadByte()
+	if err != nil {
+		return errors.Wrapf(err, "PostPoll.FromBytes: Problem reading WeightType")
+	}
+	ret.WeightType = PollWeightType(weightType)
+
+	*poll = ret
+	return nil
+}
+
+// ==================================================================
+// PollVoteMetadata
+// ==================================================================
+
+type PollVoteMetadata struct {
+	// The voter is assumed to be the originator of the top-level transaction.
+
+	// The hash of the post that declared the poll.
+	PostHash *BlockHash
+
+	// The index of the option being voted for.
+	OptionIndex uint32
+}
+
+func (txnData *PollVoteMetadata) GetTxnType() TxnType {
+	return TxnTypePollVote
+}
+
+func (txnData *PollVoteMetadata) ToBytes(preSignature bool) ([]byte, error) {
+	// Post hash must be included and must have the expected length.
+	if len(txnData.PostHash) != HashSizeBytes {
+		return nil, fmt.Errorf("PollVoteMetadata.ToBytes: PostHash "+
+			"has length %d != %d", len(txnData.PostHash), HashSizeBytes)
+	}
+
+	data := []byte{}
+
+	// PostHash
+	data = append(data, txnData.PostHash[:]...)
+
+	// OptionIndex
+	data = append(data, UintToBuf(uint64(txnData.OptionIndex))...)
+
+	return data, nil
+}
+
+func (txnData *PollVoteMetadata) FromBytes(data []byte) error {
+	ret := PollVoteMetadata{}
+	rr := bytes.NewReader(data)
+
+	// PostHash
+	ret.PostHash = &BlockHash{}
+	_, err := io.ReadFull(rr, ret.PostHash[:])
+	if err != nil {
+		return fmt.Errorf(
+			"PollVoteMetadata.FromBytes: Error reading PostHash: %v", err)
+	}
+
+	// OptionIndex
+	optionIndex, err := ReadUvarint(rr)
+	if err != nil {
+		return fmt.Errorf(
+			"PollVoteMetadata.FromBytes: Error reading OptionIndex: %v", err)
+	}
+	if optionIndex > math.MaxUint32 {
+		return fmt.Errorf(
+			"PollVoteMetadata.FromBytes: OptionIndex %d overflows uint32", optionIndex)
+	}
+	ret.OptionIndex = uint32(optionIndex)
+
+	*txnData = ret
+	return nil
+}
+
+func (txnData *PollVoteMetadata) New() DeSoTxnMetadata {
+	return &PollVoteMetadata{}
+}
+
+// ==================================================================
+// UserBlockMetadata
+// ==================================================================
+
+type UserBlockType uint8
+
+const (
+	// A block stops the blocked user from messaging the blocker, giving diamonds
+	// on the blocker's posts and transferring creator coins to the blocker.
+	UserBlockTypeBlock UserBlockType = 0
+	// A mute is recorded on-chain so every node sees the same list, but it isn't
+	// enforced by consensus. It's up to clients to hide muted users.
+	UserBlockTypeMute UserBlockType = 1
+)
+
+func (blockType UserBlockType) String() string {
+	switch blockType {
+	case UserBlockTypeBlock:
+		return "Block"
+	case UserBlockTypeMute:
+		return "Mute"
+	default:
+		return "Unknown"
+	}
+}
+
+type UserBlockMetadata struct {
+	// The blocker is assumed to be the originator of the
+	// top-level transaction.
+
+	// The public key to block or mute.
+	BlockedPublicKey []byte
+
+	// Whether this is a block or a mute.
+	BlockType UserBlockType
+
+	// Set to true when a user is requesting to unblock or unmute.
+	IsUnblock bool
+}
+
+func (txnData *UserBlockMetadata) GetTxnType() TxnType {
+	return TxnTypeUserBlock
+}
+
+func (txnData *UserBlockMetadata) ToBytes(preSignature bool) ([]byte, error) {
+	// Public key must be included and must have the expected length.
+	if len(txnData.BlockedPublicKey) != btcec.PubKeyBytesLenCompressed {
+		return nil, fmt.Errorf("UserBlockMetadata.ToBytes: BlockedPublicKey "+
+			"has length %d != %d", len(txnData.BlockedPublicKey),
+			btcec.PubKeyBytesLenCompressed)
+	}
+
+	data := []byte{}
+
+	// BlockedPublicKey
+	data = append(data, txnData.BlockedPublicKey...)
+
+	// BlockType
+	data = append(data, byte(txnData.BlockType))
+
+	// IsUnblock
+	data = append(data, BoolToByte(txnData.IsUnblock))
+
+	return data, nil
+}
+
+func (txnData *UserBlockMetadata) FromBytes(data []byte) error {
+	ret := UserBlockMetadata{}
+	rr := bytes.NewReader(data)
+
+	// BlockedPublicKey
+	ret.BlockedPublicKey = make([]byte, btcec.PubKeyBytesLenCompressed)
+	_, err := io.ReadFull(rr, ret.BlockedPublicKey)
+	if err != nil {
+		return fmt.Errorf(
+			"UserBlockMetadata.FromBytes: Error reading BlockedPublicKey: %v", err)
+	}
+
+	// BlockType
+	blockType, err := rr.ReadByte()
+	if err != nil {
+		return fmt.Errorf(
+			"UserBlockMetadata.FromBytes: Error reading BlockType: %v", err)
+	}
+	ret.BlockType = UserBlockType(blockType)
+
+	// IsUnblock
+	ret.IsUnblock = ReadBoolByte(rr)
+
+	*txnData = ret
+
+	return nil
+}
+
+func (txnData *UserBlockMetadata) New() DeSoTxnMetadata {
+	return &UserBlockMetadata{}
+}
+
+// ==================================================================
+// MessagingGroupUpdateMetadata
+// ==================================================================
+
+type MessagingGroupUpdateMetadata struct {
+	// The group is identified by its owner and key name. The transaction can be
+	// signed by the owner or, if it only adds members, by one of the group's admins.
+	GroupOwnerPublicKey   []byte
+	MessagingGroupKeyName []byte
+
+	// If set, the group is re-keyed to this messaging public key. Every member that
+	// remains in the group must then be included in MessagingGroupMembers with the
+	// new messaging private key encrypted to them. Only the owner can re-key a group.
+	NewMessagingPublicKey []byte
+	// This value is only required when re-keying the "default-key" group, and is the
+	// signature of the following using the private key of the GroupOwnerPublicKey:
+	// - Sha256DoubleHash(NewMessagingPublicKey || MessagingGroupKeyName)
+	// See MessagingGroupMetadata for why the default-key requires it.
+	GroupOwnerSignature []byte
+
+	// Members to add to the group. When re-keying, this also holds the re-encrypted
+	// keys of the existing members.
+	MessagingGroupMembers []*MessagingGroupMember
+	// Main public keys of the members to remove. Only the owner can remove members.
+	RemovedMemberPublicKeys [][]byte
+
+	// Main public keys of the members to make admins, or to stop being admins. Admins
+	// can add new members to the group. Only the owner can change the admins.
+	AddedAdminPublicKeys   [][]byte
+	RemovedAdminPublicKeys [][]byte
+}
+
+func (txnData *MessagingGroupUpdateMetadata) GetTxnType() TxnType {
+	return TxnTypeMessagingGroupUpdate
+}
+
+func _encodeByteArrayList(byteArrays [][]byte) []byte {
+	data := []byte{}
+	data = append(data, UintToBuf(uint64(len(byteArrays)))...)
+	for _, byteArray := range byteArrays {
+		data = append(data, UintToBuf(uint64(len(byteArray)))...)
+		data = append(data, byteArray...)
+	}
+	return data
+}
+
+func _readByteArrayList(rr *bytes.Reader) ([][]byte, error) {
+	numByteArrays, err := ReadUvarint(rr)
+	if err != nil {
+		return nil, err
+	}
+	var byteArrays [][]byte
+	for ; numByteArrays > 0; numByteArrays-- {
+		byteArray, err := ReadVarString(rr)
+		if err != nil {
+			return nil, err
+		}
+		byteArrays = append(byteArrays, byteArray)
+	}
+	return byteArrays, nil
+}
+
+func (txnData *MessagingGroupUpdateMetadata) ToBytes(preSignature bool) ([]byte, error) {
+	data := []byte{}
+
+	data = append(data, UintToBuf(uint64(len(txnData.GroupOwnerPublicKey)))...)
+	data = append(data, txnData.GroupOwnerPublicKey...)
+
+	data = append(data, UintToBuf(uint64(len(txnData.MessagingGroupKeyName)))...)
+	data = append(data, txnData.MessagingGroupKeyName...)
+
+	data = append(data, UintToBuf(uint64(len(txnData.NewMessagingPublicKey)))...)
+	data = append(data, txnData.NewMessagingPublicKey...)
+
+	data = append(data, UintToBuf(uint64(len(txnData.GroupOwnerSignature)))...)
+	data = append(data, txnData.GroupOwnerSignature...)
+
+	data = append(data, UintToBuf(uint64(len(txnData.MessagingGroupMembers)))...)
+	for _, member := range txnData.MessagingGroupMembers {
+		data = append(data, member.Encode()...)
+	}
+
+	data = append(data, _encodeByteArrayList(txnData.RemovedMemberPublicKeys)...)
+	data = append(data, _encodeByteArrayList(txnData.AddedAdminPublicKeys)...)
+	data = append(data, _encodeByteArrayList(txnData.RemovedAdminPublicKeys)...)
+
+	return data, nil
+}
+
+func (txnData *MessagingGroupUpdateMetadata) FromBytes(data []byte) error {
+	ret := MessagingGroupUpdateMetadata{}
+	rr := bytes.NewReader(data)
+
+	var err error
+	ret.GroupOwnerPublicKey, err = ReadVarString(rr)
+	if err != nil {
+		return errors.Wrapf(err, "MessagingGroupUpdateMetadata.FromBytes: "+
+			"Problem reading GroupOwnerPublicKey")
+	}
+
+	ret.MessagingGroupKeyName, err = ReadVarString(rr)
+	if err != nil {
+		return errors.Wrapf(err, "MessagingGroupUpdateMetadata.FromBytes: "+
+			"Problem reading MessagingGroupKeyName")
+	}
+
+	ret.NewMessagingPublicKey, err = ReadVarString(rr)
+	if err != nil {
+		return errors.Wrapf(err, "MessagingGroupUpdateMetadata.FromBytes: "+
+			"Problem reading NewMessagingPublicKey")
+	}
+
+	ret.GroupOwnerSignature, err = ReadVarString(rr)
+	if err != nil {
+		return errors.Wrapf(err, "MessagingGroupUpdateMetadata.FromBytes: "+
+			"Problem reading GroupOwnerSignature")
+	}
+
+	numMembers, err := ReadUvarint(rr)
+	if err != nil {
+		return errors.Wrapf(err, "MessagingGroupUpdateMetadata.FromBytes: "+
+			"Problem reading number of MessagingGroupMembers")
+	}
+	for ; numMembers > 0; numMembers-- {
+		member := MessagingGroupMember{}
+		if err := member.Decode(rr); err != nil {
+			return errors.Wrapf(err, "MessagingGroupUpdateMetadata.FromBytes: "+
+				"Problem reading MessagingGroupMember")
+		}
+		ret.MessagingGroupMembers = append(ret.MessagingGroupMembers, &member)
+	}
+
+	ret.RemovedMemberPublicKeys, err = _readByteArrayList(rr)
+	if err != nil {
+		return errors.Wrapf(err, "MessagingGroupUpdateMetadata.FromBytes: "+
+			"Problem reading RemovedMemberPublicKeys")
+	}
+
+	ret.AddedAdminPublicKeys, err = _readByteArrayList(rr)
+	if err != nil {
+		return errors.Wrapf(err, "MessagingGroupUpdateMetadata.FromBytes: "+
+			"Problem reading AddedAdminPublicKeys")
+	}
+
+	ret.RemovedAdminPublicKeys, err = _readByteArrayList(rr)
+	if err != nil {
+		return errors.Wrapf(err, "MessagingGroupUpdateMetadata.FromBytes: "+
+			"Problem reading RemovedAdminPublicKeys")
+	}
+
+	*txnData = ret
+	return nil
+}
+
+func (txnData *MessagingGroupUpdateMetadata) New() DeSoTxnMetadata {
+	return &MessagingGroupUpdateMetadata{}
+}
+
+// ==================================================================
+// MessageReadMetadata
+// ==================================================================
+
+type MessageReadMetadata struct {
+	// The reader is assumed to be the originator of the top-level transaction.
+
+	// ThreadPublicKey identifies the thread being read. For a direct message
+	// thread it's the counterparty's main public key, and for a group chat it's
+	// the group's messaging public key.
+	ThreadPublicKey []byte
+
+	// Every message in the thread up to and including this timestamp has been read.
+	LastReadTstampNanos uint64
+}
+
+func (txnData *MessageReadMetadata) GetTxnType() TxnType {
+	return TxnTypeMessageRead
+}
+
+func (txnData *MessageReadMetadata) ToBytes(preSignature bool) ([]byte, error) {
+	// Public key must be included and must have the expected length.
+	if len(txnData.ThreadPublicKey) != btcec.PubKeyBytesLenCompressed {
+		return nil, fmt.Errorf("MessageReadMetadata.ToBytes: ThreadPublicKey "+
+			"has length %d != %d", len(txnData.ThreadPublicKey),
+			btcec.PubKeyBytesLenCompressed)
+	}
+
+	data := []byte{}
+
+	// ThreadPublicKey
+	data = append(data, txnData.ThreadPublicKey...)
+
+	// LastReadTstampNanos
+	data = append(data, UintToBuf(txnData.LastReadTstampNanos)...)
+
+	return data, nil
+}
+
+func (txnData *MessageReadMetadata) FromBytes(data []byte) error {
+	ret := MessageReadMetadata{}
+	rr := bytes.NewReader(data)
+
+	// ThreadPublicKey
+	ret.ThreadPublicKey = make([]byte, btcec.PubKeyBytesLenCompressed)
+	_, err := io.ReadFull(rr, ret.ThreadPublicKey)
+	if err != nil {
+		return fmt.Errorf("MessageReadMetadata.FromBytes: Error reading ThreadPublicKey: %v", err)
+	}
+
+	// LastReadTstampNanos
+	ret.LastReadTstampNanos, err = ReadUvarint(rr)
+	if err != nil {
+		return fmt.Errorf("MessageReadMetadata.FromBytes: Error reading LastReadTstampNanos: %v", err)
+	}
+
+	*txnData = ret
+
+	return nil
+}
+
+func (txnData *MessageReadMetadata) New() DeSoTxnMetadata {
+	return &MessageReadMetadata{}
+}
+
+// ==================================================================
+// CreateNFTCollectionMetadata
+// ==================================================================
+
+type CreateNFTCollectionMetadata struct {
+	// The creator is assumed to be the originator of the top-level transaction.
+	// The collection is identified by the hash of this transaction.
+
+	// Name is the human-readable name of the collection.
+	Name []byte
+
+	// MaxSupply caps the total number of NFT copies that can be minted across
+	// every post attached to the collection. Zero means the supply is unlimited.
+	MaxSupply uint64
+
+	// Royalties that override the ones set on each NFT attached to the
+	// collection. Additional royalties are passed in the transaction's
+	// ExtraData, just like they are for CreateNFT.
+	NFTRoyaltyToCreatorBasisPoints uint64
+	NFTRoyaltyToCoinBasisPoints    uint64
+}
+
+func (txnData *CreateNFTCollectionMetadata) GetTxnType() TxnType {
+	return TxnTypeCreateNFTCollection
+}
+
+func (txnData *CreateNFTCollectionMetadata) ToBytes(preSignature bool) ([]byte, error) {
+	data := []byte{}
+
+	// Name
+	data = append(data, UintToBuf(uint64(len(txnData.Name)))...)
+	data = append(data, txnData.Name...)
+
+	// MaxSupply
+	data = append(data, UintToBuf(txnData.MaxSupply)...)
+
+	// NFTRoyaltyToCreatorBasisPoints
+	data = append(data, UintToBuf(txnData.NFTRoyaltyToCreatorBasisPoints)...)
+
+	// NFTRoyaltyToCoinBasisPoints
+	data = append(data, UintToBuf(txnData.NFTRoyaltyToCoinBasisPoints)...)
+
+	return data, nil
+}
+
+func (txnData *CreateNFTCollectionMetadata) FromBytes(data []byte) error {
+	ret := CreateNFTCollectionMetadata{}
+	rr := bytes.NewReader(data)
+
+	// Name
+	nameLen, err := ReadUvarint(rr)
+	if err != nil {
+		return fmt.Errorf("CreateNFTCollectionMetadata.FromBytes: Error reading Name length: %v", err)
+	}
+	if nameLen > MaxMessagePayload {
+		return fmt.Errorf("CreateNFTCollectionMetadata.FromBytes: Name length %d "+
+			"larger than max allowed %d", nameLen, MaxMessagePayload)
+	}
+	ret.Name = make([]byte, nameLen)
+	_, err = io.ReadFull(rr, ret.Name)
+	if err != nil {
+		return fmt.Errorf("CreateNFTCollectionMetadata.FromBytes: Error reading Name: %v", err)
+	}
+
+	// MaxSupply
+	ret.MaxSupply, err = ReadUvarint(rr)
+	if err != nil {
+		return fmt.Errorf("CreateNFTCollectionMetadata.FromBytes: Error reading MaxSupply: %v", err)
+	}
+
+	// NFTRoyaltyToCreatorBasisPoints
+	ret.NFTRoyaltyToCreatorBasisPoints, err = ReadUvarint(rr)
+	if err != nil {
+		return fmt.Errorf("CreateNFTCollectionMetadata.FromBytes: Error reading NFTRoyaltyToCreatorBasisPoints: %v", err)
+	}
+
+	// NFTRoyaltyToCoinBasisPoints
+	ret.NFTRoyaltyToCoinBasisPoints, err = ReadUvarint(rr)
+	if err != nil {
+		return fmt.Errorf("CreateNFTCollectionMetadata.FromBytes: Error reading NFTRoyaltyToCoinBasisPoints: %v", err)
+	}
+
+	*txnData = ret
+
+	return nil
+}
+
+func (txnData *CreateNFTCollectionMetadata) New() DeSoTxnMetadata {
+	return &CreateNFTCollectionMetadata{}
+}
+
+// ==================================================================
+// NFTVaultMetadata
+// ==================================================================
+
+type NFTVaultOperationType uint8
+
+const (
+	NFTVaultOperationTypeLock           NFTVaultOperationType = 0
+	NFTVaultOperationTypeRedeem         NFTVaultOperationType = 1
+	NFTVaultOperationTypeTransferShares NFTVaultOperationType = 2
+)
+
+type NFTVaultMetadata struct {
+	// The NFT that is being vaulted, redeemed or whose shares are being transferred.
+	NFTPostHash  *BlockHash
+	SerialNumber uint64
+
+	// OperationType specifies what the user wants to do with the vault.
+	OperationType NFTVaultOperationType
+
+	// Lock fields. The owner of the NFT receives TotalSharesNanos shares, and a
+	// bid at or above BuyoutPriceNanos buys the NFT out of the vault.
+	TotalSharesNanos uint256.Int
+	BuyoutPriceNanos uint64
+
+	// TransferShares fields
+	SharesToTransferNanos uint256.Int
+	ReceiverPublicKey     []byte
+}
+
+func (txnData *NFTVaultMetadata) GetTxnType() TxnType {
+	return TxnTypeNFTVault
+}
+
+func (txnData *NFTVaultMetadata) ToBytes(preSignature bool) ([]byte, error) {
+	// Validate the metadata before encoding it.
+	//
+	// Post hash must be included and must have the expected length.
+	if len(txnData.NFTPostHash) != HashSizeBytes {
+		return nil, fmt.Errorf("NFTVaultMetadata.ToBytes: NFTPostHash "+
+			"has length %d != %d", len(txnData.NFTPostHash), HashSizeBytes)
+	}
+
+	data := []byte{}
+
+	// NFTPostHash
+	data = append(data, txnData.NFTPostHash[:]...)
+
+	// SerialNumber uint64
+	data = append(data, UintToBuf(txnData.SerialNumber)...)
+
+	// OperationType byte
+	data = append(data, byte(txnData.OperationType))
+
+	// TotalSharesNanos
+	{
+		totalSharesBytes := txnData.TotalSharesNanos.Bytes()
+		data = append(data, UintToBuf(uint64(len(totalSharesBytes)))...)
+		data = append(data, totalSharesBytes...)
+	}
+
+	// BuyoutPriceNanos
+	data = append(data, UintToBuf(txnData.BuyoutPriceNanos)...)
+
+	// SharesToTransferNanos
+	{
+		sharesToTransferBytes := txnData.SharesToTransferNanos.Bytes()
+		data = append(data, UintToBuf(uint64(len(sharesToTransferBytes)))...)
+		data = append(data, sharesToTransferBytes...)
+	}
+
+	// ReceiverPublicKey
+	data = append(data, UintToBuf(uint64(len(txnData.ReceiverPublicKey)))...)
+	data = append(data, txnData.ReceiverPublicKey...)
+
+	return data, nil
+}
+
+func (txnData *NFTVaultMetadata) FromBytes(data []byte) error {
+	ret := NFTVaultMetadata{}
+	rr := bytes.NewReader(data)
+
+	// NFTPostHash
+	ret.NFTPostHash = &BlockHash{}
+	_, err := io.ReadFull(rr, ret.NFTPostHash[:])
+	if err != nil {
+		return fmt.Errorf(
+			"NFTVaultMetadata.FromBytes: Error reading NFTPostHash: %v", err)
+	}
+
+	// SerialNumber uint64
+	ret.SerialNumber, err = ReadUvarint(rr)
+	if err != nil {
+		return fmt.Errorf("NFTVaultMetadata.FromBytes: Error reading SerialNumber: %v", err)
+	}
+
+	// OperationType byte
+	operationType, err := rr.ReadByte()
+	if err != nil {
+		return fmt.Errorf(
+			"NFTVaultMetadata.FromBytes: Error reading OperationType: %v", err)
+	}
+	ret.OperationType = NFTVaultOperationType(operationType)
+
+	maxUint256BytesLen := len(MaxUint256.Bytes())
+	// TotalSharesNanos
+	{
+		intLen, err := ReadUvarint(rr)
+		if err != nil {
+			return errors.Wrapf(err, "NFTVaultMetadata.FromBytes: Problem "+
+				"reading TotalSharesNanos length")
+		}
+		if intLen > uint64(maxUint256BytesLen) {
+			return fmt.Errorf("NFTVaultMetadata.FromBytes: TotalSharesNanos length %d "+
+				"exceeds max %d", intLen, maxUint256BytesLen)
+		}
+		totalSharesBytes := make([]byte, intLen)
+		_, err = io.ReadFull(rr, totalSharesBytes)
+		if err != nil {
+			return fmt.Errorf("NFTVaultMetadata.FromBytes: Error reading TotalSharesNanos: %v", err)
+		}
+		ret.TotalSharesNanos = *uint256.NewInt().SetBytes(totalSharesBytes)
+	}
+
+	// BuyoutPriceNanos
+	ret.BuyoutPriceNanos, err = ReadUvarint(rr)
+	if err != nil {
+		return fmt.Errorf("NFTVaultMetadata.FromBytes: Error reading BuyoutPriceNanos: %v", err)
+	}
+
+	// SharesToTransferNanos
+	{
+		intLen, err := ReadUvarint(rr)
+		if err != nil {
+			return errors.Wrapf(err, "NFTVaultMetadata.FromBytes: Problem "+
+				"reading SharesToTransferNanos length")
+		}
+		if intLen > uint64(maxUint256BytesLen) {
+			return fmt.Errorf("NFTVaultMetadata.FromBytes: SharesToTransferNanos length %d "+
+				"exceeds max %d", intLen, maxUint256BytesLen)
+		}
+		sharesToTransferBytes := make([]byte, intLen)
+		_, err = io.ReadFull(rr, sharesToTransferBytes)
+		if err != nil {
+			return fmt.Errorf("NFTVaultMetadata.FromBytes: Error reading SharesToTransferNanos: %v", err)
+		}
+		ret.SharesToTransferNanos = *uint256.NewInt().SetBytes(sharesToTransferBytes)
+	}
+
+	// ReceiverPublicKey
+	ret.ReceiverPublicKey, err = ReadVarString(rr)
+	if err != nil {
+		return fmt.Errorf(
+			"NFTVaultMetadata.FromBytes: Error reading ReceiverPublicKey: %v", err)
+	}
+
+	*txnData = ret
+
+	return nil
+}
+
+func (txnData *NFTVaultMetadata) New() DeSoTxnMetadata {
+	return &NFTVaultMetadata{}
+}
+
+// ==================================================================
+// RedeemNFTVoucherMetadata
+// ==================================================================
+
+// NFTVoucher lets a creator sell a copy of an NFT without minting it up front.
+// The creator signs the voucher off-chain, and whoever holds it can redeem it
+// to mint the copy directly to themselves by paying PriceNanos. The first
+// redemption of any voucher for a post turns the post into an NFT with the
+// voucher's NumCopies and royalties, so every voucher for a post must agree on them.
+type NFTVoucher struct {
+	NFTPostHash  *BlockHash
+	SerialNumber uint64
+	PriceNanos   uint64
+
+	NumCopies                      uint64
+	NFTRoyaltyToCreatorBasisPoints uint64
+	NFTRoyaltyToCoinBasisPoints    uint64
+}
+
+// ToBytes encodes the voucher. The creator's signature is made over the
+// double-sha256 hash of NFTVoucherSignatureData, which prefixes these bytes.
+func (voucher *NFTVoucher) ToBytes() ([]byte, error) {
+	// Post hash must be included and must have the expected length.
+	if len(voucher.NFTPostHash) != HashSizeBytes {
+		return nil, fmt.Errorf("NFTVoucher.ToBytes: NFTPostHash "+
+			"has length %d != %d", len(voucher.NFTPostHash), HashSizeBytes)
+	}
+
+	data := []byte{}
+
+	// NFTPostHash
+	data = append(data, voucher.NFTPostHash[:]...)
+
+	// SerialNumber uint64
+	data = append(data, UintToBuf(voucher.SerialNumber)...)
+
+	// PriceNanos uint64
+	data = append(data, UintToBuf(voucher.PriceNanos)...)
+
+	// NumCopies uint64
+	data = append(data, UintToBuf(voucher.NumCopies)...)
+
+	// NFTRoyaltyToCreatorBasisPoints uint64
+	data = append(data, UintToBuf(voucher.NFTRoyaltyToCreatorBasisPoints)...)
+
+	// NFTRoyaltyToCoinBasisPoints uint64
+	data = append(data, UintToBuf(voucher.NFTRoyaltyToCoinBasisPoints)...)
+
+	return data, nil
+}
+
+func _readNFTVoucher(rr io.Reader) (*NFTVoucher, error) {
+	ret := &NFTVoucher{}
+
+	// NFTPostHash
+	ret.NFTPostHash = &BlockHash{}
+	_, err := io.ReadFull(rr, ret.NFTPostHash[:])
+	if err != nil {
+		return nil, fmt.Errorf("_readNFTVoucher: Error reading NFTPostHash: %v", err)
+	}
+
+	// SerialNumber uint64
+	ret.SerialNumber, err = ReadUvarint(rr)
+	if err != nil {
+		return nil, fmt.Errorf("_readNFTVoucher: Error reading SerialNumber: %v", err)
+	}
+
+	// PriceNanos uint64
+	ret.PriceNanos, err = ReadUvarint(rr)
+	if err != nil {
+		return nil, fmt.Errorf("_readNFTVoucher: Error reading PriceNanos: %v", err)
+	}
+
+	// NumCopies uint64
+	ret.NumCopies, err = ReadUvarint(rr)
+	if err != nil {
+		return nil, fmt.Errorf("_readNFTVoucher: Error reading NumCopies: %v", err)
+	}
+
+	// NFTRoyaltyToCreatorBasisPoints uint64
+	ret.NFTRoyaltyToCreatorBasisPoints, err = ReadUvarint(rr)
+	if err != nil {
+		return nil, fmt.Errorf("_readNFTVoucher: Error reading NFTRoyaltyToCreatorBasisPoints: %v", err)
+	}
+
+	// NFTRoyaltyToCoinBasisPoints uint64
+	ret.NFTRoyaltyToCoinBasisPoints, err = ReadUvarint(rr)
+	if err != nil {
+		return nil, fmt.Errorf("_readNFTVoucher: Error reading NFTRoyaltyToCoinBasisPoints: %v", err)
+	}
+
+	return ret, nil
+}
+
+type RedeemNFTVoucherMetadata struct {
+	Voucher *NFTVoucher
+
+	// CreatorSignature is the DER signature of the voucher's double-sha256 hash,
+	// made with the post creator's public key.
+	CreatorSignature []byte
+}
+
+func (txnData *RedeemNFTVoucherMetadata) GetTxnType() TxnType {
+	return TxnTypeRedeemNFTVoucher
+}
+
+func (txnData *RedeemNFTVoucherMetadata) ToBytes(preSignature bool) ([]byte, error) {
+	if txnData.Voucher == nil {
+		return nil, fmt.Errorf("RedeemNFTVoucherMetadata.ToBytes: Voucher must be set")
+	}
+
+	// Voucher
+	data, err := txnData.Voucher.ToBytes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "RedeemNFTVoucherMetadata.ToBytes: ")
+	}
+
+	// CreatorSignature
+	data = append(data, UintToBuf(uint64(len(txnData.CreatorSignature)))...)
+	data = append(data, txnData.CreatorSignature...)
+
+	return data, nil
+}
+
+func (txnData *RedeemNFTVoucherMetadata) FromBytes(data []byte) error {
+	ret := RedeemNFTVoucherMetadata{}
+	rr := bytes.NewReader(data)
+
+	// Voucher
+	var err error
+	ret.Voucher, err = _readNFTVoucher(rr)
+	if err != nil {
+		return errors.Wrapf(err, "RedeemNFTVoucherMetadata.FromBytes: ")
+	}
+
+	// CreatorSignature
+	ret.CreatorSignature, err = ReadVarString(rr)
+	if err != nil {
+		return fmt.Errorf(
+			"RedeemNFTVoucherMetadata.FromBytes: Error reading CreatorSignature: %v", err)
+	}
+
+	*txnData = ret
+
+	return nil
+}
+
+func (txnData *RedeemNFTVoucherMetadata) New() DeSoTxnMetadata {
+	return &RedeemNFTVoucherMetadata{}
+}
+
+// ==================================================================
+// RegisterBlockProducerMetadata
+// ==================================================================
+
+type RegisterBlockProducerMetadata struct {
+	// The block producer is assumed to be the originator of the top-level
+	// transaction. The same public key must sign the blocks it produces.
+
+	// BondNanos is the amount of DESO locked up while the producer is registered.
+	// It is refunded to the producer when they deregister.
+	BondNanos uint64
+}
+
+func (txnData *RegisterBlockProducerMetadata) GetTxnType() TxnType {
+	return TxnTypeRegisterBlockProducer
+}
+
+func (txnData *RegisterBlockProducerMetadata) ToBytes(preSignature bool) ([]byte, error) {
+	data := []byte{}
+
+	// BondNanos
+	data = append(data, UintToBuf(txnData.BondNanos)...)
+
+	return data, nil
+}
+
+func (txnData *RegisterBlockProducerMetadata) FromBytes(data []byte) error {
+	ret := RegisterBlockProducerMetadata{}
+	rr := bytes.NewReader(data)
+
+	// BondNanos
+	var err error
+	ret.BondNanos, err = ReadUvarint(rr)
+	if err != nil {
+		return fmt.Errorf("RegisterBlockProducerMetadata.FromBytes: Error reading BondNanos: %v", err)
+	}
+
+	*txnData = ret
+
+	return nil
+}
+
+func (txnData *RegisterBlockProducerMetadata) New() DeSoTxnMetadata {
+	return &RegisterBlockProducerMetadata{}
+}
+
+// ==================================================================
+// DeregisterBlockProducerMetadata
+// ==================================================================
+
+type DeregisterBlockProducerMetadata struct {
+	// The block producer being deregistered is assumed to be the originator
+	// of the top-level transaction, so there is nothing else to encode.
+}
+
+func (txnData *DeregisterBlockProducerMetadata) GetTxnType() TxnType {
+	return TxnTypeDeregisterBlockProducer
+}
+
+func (txnData *DeregisterBlockProducerMetadata) ToBytes(preSignature bool) ([]byte, error) {
+	return []byte{}, nil
+}
+
+func (txnData *DeregisterBlockProducerMetadata) FromBytes(data []byte) error {
+	if len(data) != 0 {
+		return fmt.Errorf("DeregisterBlockProducerMetadata.FromBytes: Expected no data "+
+			"but found %d bytes", len(data))
+	}
+	*txnData = DeregisterBlockProducerMetadata{}
+
+	return nil
+}
+
+func (txnData *DeregisterBlockProducerMetadata) New() DeSoTxnMetadata {
+	return &DeregisterBlockProducerMetadata{}
+}
+
+// ==================================================================
+// ClaimBlockProducerBondMetadata
+// ==================================================================
+
+type ClaimBlockProducerBondMetadata struct {
+	// The block producer claiming its bond is assumed to be the originator
+	// of the top-level transaction, so there is nothing else to encode.
+}
+
+func (txnData *ClaimBlockProducerBondMetadata) GetTxnType() TxnType {
+	return TxnTypeClaimBlockProducerBond
+}
+
+func (txnData *ClaimBlockProducerBondMetadata) ToBytes(preSignature bool) ([]byte, error) {
+	return []byte{}, nil
+}
+
+func (txnData *ClaimBlockProducerBondMetadata) FromBytes(data []byte) error {
+	if len(data) != 0 {
+		return fmt.Errorf("ClaimBlockProducerBondMetadata.FromBytes: Expected no data "+
+			"but found %d bytes", len(data))
+	}
+	*txnData = ClaimBlockProducerBondMetadata{}
+
+	return nil
+}
+
+func (txnData *ClaimBlockProducerBondMetadata) New() DeSoTxnMetadata {
+	return &ClaimBlockProducerBondMetadata{}
+}
+
+// ==================================================================
+// UsernameListingMetadata
+// ==================================================================
+
+type UsernameListingMetadata struct {
+	// The owner of the username is assumed to be the originator of the
+	// top-level transaction.
+
+	// The username being listed. It must be the owner's current username.
+	Username []byte
+
+	// The username the owner's profile takes once the listing is accepted,
+	// since every profile must have a username.
+	ReplacementUsername []byte
+
+	// If set, only this public key can accept the listing. A listing with a
+	// recipient and a zero price is a transfer.
+	RecipientPublicKey []byte
+
+	// The DESO the buyer pays the owner to accept the listing.
+	PriceNanos uint64
+
+	// Set to true to take down an existing listing.
+	IsRemoved bool
+}
+
+func (txnData *UsernameListingMetadata) GetTxnType() TxnType {
+	return TxnTypeUsernameListing
+}
+
+func (txnData *UsernameListingMetadata) ToBytes(preSignature bool) ([]byte, error) {
+	data := []byte{}
+
+	// Username
+	data = append(data, UintToBuf(uint64(len(txnData.Username)))...)
+	data = append(data, txnData.Username...)
+
+	// ReplacementUsername
+	data = append(data, UintToBuf(uint64(len(txnData.ReplacementUsername)))...)
+	data = append(data, txnData.ReplacementUsername...)
+
+	// RecipientPublicKey
+	data = append(data, UintToBuf(uint64(len(txnData.RecipientPublicKey)))...)
+	data = append(data, txnData.RecipientPublicKey...)
+
+	// PriceNanos
+	data = append(data, UintToBuf(txnData.PriceNanos)...)
+
+	// IsRemoved
+	data = append(data, BoolToByte(txnData.IsRemoved))
+
+	return data, nil
+}
+
+func (txnData *UsernameListingMetadata) FromBytes(data []byte) error {
+	ret := UsernameListingMetadata{}
+	rr := bytes.NewReader(data)
+
+	// Username
+	var err error
+	ret.Username, err = ReadVarString(rr)
+	if err != nil {
+		return fmt.Errorf(
+			"UsernameListingMetadata.FromBytes: Error reading Username: %v", err)
+	}
+
+	// ReplacementUsername
+	ret.ReplacementUsername, err = ReadVarString(rr)
+	if err != nil {
+		return fmt.Errorf(
+			"UsernameListingMetadata.FromBytes: Error reading ReplacementUsername: %v", err)
+	}
+
+	// RecipientPublicKey
+	ret.RecipientPublicKey, err = ReadVarString(rr)
+	if err != nil {
+		return fmt.Errorf(
+			"UsernameListingMetadata.FromBytes: Error reading RecipientPublicKey: %v", err)
+	}
+	// Set the recipient public key to nil if it's not set as a convenience.
+	if len(ret.RecipientPublicKey) == 0 {
+		ret.RecipientPublicKey = nil
+	}
+
+	// PriceNanos
+	ret.PriceNanos, err = ReadUvarint(rr)
+	if err != nil {
+		return fmt.Errorf("UsernameListingMetadata.FromBytes: Error reading PriceNanos: %v", err)
+	}
+
+	// IsRemoved
+	ret.IsRemoved = ReadBoolByte(rr)
+
+	*txnData = ret
+
+	return nil
+}
+
+func (txnData *UsernameListingMetadata) New() DeSoTxnMetadata {
+	return &UsernameListingMetadata{}
+}
+
+// ==================================================================
+// AcceptUsernameListingMetadata
+// ==================================================================
+
+type AcceptUsernameListingMetadata struct {
+	// The buyer is assumed to be the originator of the top-level
+	// transaction.
+
+	// The username whose listing is being accepted.
+	Username []byte
+
+	// The price the buyer expects to pay. The txn fails if the listing's
+	// price doesn't match so that an owner can't raise the price on a
+	// buyer whose txn is still pending.
+	PriceNanos uint64
+}
+
+func (txnData *AcceptUsernameListingMetadata) GetTxnType() TxnType {
+	return TxnTypeAcceptUsernameListing
+}
+
+func (txnData *AcceptUsernameListingMetadata) ToBytes(preSignature bool) ([]byte, error) {
+	data := []byte{}
+
+	// Username
+	data = append(data, UintToBuf(uint64(len(txnData.Username)))...)
+	data = append(data, txnData.Username...)
+
+	// PriceNanos
+	data = append(data, UintToBuf(txnData.PriceNanos)...)
+
+	return data, nil
+}
+
+func (txnData *AcceptUsernameListingMetadata) FromBytes(data []byte) error {
+	ret := AcceptUsernameListingMetadata{}
+	rr := bytes.NewReader(data)
+
+	// Username
+	var err error
+	ret.Username, err = ReadVarString(rr)
+	if err != nil {
+		return fmt.Errorf(
+			"AcceptUsernameListingMetadata.FromBytes: Error reading Username: %v", err)
+	}
+
+	// PriceNanos
+	ret.PriceNanos, err = ReadUvarint(rr)
+	if err != nil {
+		return fmt.Errorf("AcceptUsernameListingMetadata.FromBytes: Error reading PriceNanos: %v", err)
+	}
+
+	*txnData = ret
+
+	return nil
+}
+
+func (txnData *AcceptUsernameListingMetadata) New() DeSoTxnMetadata {
+	return &AcceptUsernameListingMetadata{}
+}
+
+// ==================================================================
+// KeyRotationMetadata
+// ==================================================================
+
+type KeyRotationOperationType uint8
+
+const (
+	// KeyRotationOperationInitiate rotates the PKID to the new key, either right
+	// away or, if TimelockBlocks is non-zero, once the timelock has passed.
+	KeyRotationOperationInitiate KeyRotationOperationType = 0
+	// KeyRotationOperationCancel cancels a rotation that is waiting on its timelock.
+	KeyRotationOperationCancel KeyRotationOperationType = 1
+	// KeyRotationOperationComplete carries out a rotation whose timelock has passed.
+	KeyRotationOperationComplete KeyRotationOperationType = 2
+	// KeyRotationOperationSetMinTimelock sets the smallest TimelockBlocks that a
+	// later Initiate can use. A lower minimum only takes effect once the current
+	// one has passed.
+	KeyRotationOperationSetMinTimelock KeyRotationOperationType = 3
+)
+
+type KeyRotationMetadata struct {
+	// The key being rotated away from is assumed to be the originator of the
+	// top-level transaction. It signs every operation.
+
+	OperationType KeyRotationOperationType
+
+	// NewPublicKey is the key that takes over the PKID. For Cancel and Complete
+	// it must match the pending rotation.
+	NewPublicKey []byte
+
+	// TimelockBlocks is the number of blocks the old key has to cancel the
+	// rotation before it can be completed. For SetMinTimelock it's the new
+	// minimum instead. Not used by Cancel or Complete.
+	TimelockBlocks uint64
+
+	// NewKeySignature is the new key's DER signature of the double SHA-256 hash
+	// of the old public key followed by the new public key. It shows that the
+	// new key agrees to take over the PKID. Only used by Initiate.
+	NewKeySignature []byte
+}
+
+func (txnData *KeyRotationMetadata) GetTxnType() TxnType {
+	return TxnTypeKeyRotation
+}
+
+func (txnData *KeyRotationMetadata) ToBytes(preSignature bool) ([]byte, error) {
+	data := []byte{}
+
+	// OperationType
+	data = append(data, byte(txnData.OperationType))
+
+	// NewPublicKey
+	data = append(data, UintToBuf(uint64(len(txnData.NewPublicKey)))...)
+	data = append(data, txnData.NewPublicKey...)
+
+	// TimelockBlocks
+	data = append(data, UintToBuf(txnData.TimelockBlocks)...)
+
+	// NewKeySignature
+	data = append(data, UintToBuf(uint64(len(txnData.NewKeySignature)))...)
+	data = append(data, txnData.NewKeySignature...)
+
+	return data, nil
+}
+
+func (txnData *KeyRotationMetadata) FromBytes(data []byte) error {
+	ret := KeyRotationMetadata{}
+	rr := bytes.NewReader(data)
+
+	// OperationType
+	operationType, err := rr.ReadByte()
+	if err != nil {
+		return fmt.Errorf("KeyRotationMetadata.FromBytes: Error reading OperationType: %v", err)
+	}
+	ret.OperationType = KeyRotationOperationType(operationType)
+
+	// NewPublicKey
+	ret.NewPublicKey, err = ReadVarString(rr)
+	if err != nil {
+		return fmt.Errorf(
+			"KeyRotationMetadata.FromBytes: Error reading NewPublicKey: %v", err)
+	}
+
+	// TimelockBlocks
+	ret.TimelockBlocks, err = ReadUvarint(rr)
+	if err != nil {
+		return fmt.Errorf("KeyRotationMetadata.FromBytes: Error reading TimelockBlocks: %v", err)
+	}
+
+	// NewKeySignature
+	ret.NewKeySignature, err = ReadVarString(rr)
+	if err != nil {
+		return fmt.Errorf(
+			"KeyRotationMetadata.FromBytes: Error reading NewKeySignature: %v", err)
+	}
+
+	*txnData = ret
+
+	return nil
+}
+
+func (txnData *KeyRotationMetadata) New() DeSoTxnMetadata {
+	return &KeyRotationMetadata{}
+}
+
+// ==================================================================
+// RecoveryGuardiansMetadata
+// ==================================================================
+
+// MaxRecoveryGuardians is the largest number of guardians a user can register.
+const MaxRecoveryGuardians = 16
+
+type RecoveryGuardiansMetadata struct {
+	// The owner of the guardian set is assumed to be the originator of the
+	// top-level transaction.
+
+	// GuardianPublicKeys are the keys that can jointly recover the owner's
+	// account. An empty list, with a zero Threshold and DelayBlocks, removes
+	// the owner's guardians. If the owner already has guardians, the change
+	// only takes effect after their DelayBlocks.
+	GuardianPublicKeys [][]byte
+
+	// Threshold is the number of guardians that must sign a recovery.
+	Threshold uint64
+
+	// DelayBlocks is the number of blocks the owner has to cancel a recovery
+	// before it can be completed.
+	DelayBlocks uint64
+}
+
+func (txnData *RecoveryGuardiansMetadata) GetTxnType() TxnType {
+	return TxnTypeRecoveryGuardians
+}
+
+func (txnData *RecoveryGuardiansMetadata) ToBytes(preSignature bool) ([]byte, error) {
+	data := []byte{}
+
+	// GuardianPublicKeys
+	data = append(data, UintToBuf(uint64(len(txnData.GuardianPublicKeys)))...)
+	for _, guardianPublicKey := range txnData.GuardianPublicKeys {
+		data = append(data, UintToBuf(uint64(len(guardianPublicKey)))...)
+		data = append(data, guardianPublicKey...)
+	}
+
+	// Threshold
+	data = append(data, UintToBuf(txnData.Threshold)...)
+
+	// DelayBlocks
+	data = append(data, UintToBuf(txnData.DelayBlocks)...)
+
+	return data, nil
+}
+
+func (txnData *RecoveryGuardiansMetadata) FromBytes(data []byte) error {
+	ret := RecoveryGuardiansMetadata{}
+	rr := bytes.NewReader(data)
+
+	// GuardianPublicKeys
+	numGuardians, err := ReadUvarint(rr)
+	if err != nil {
+		return fmt.Errorf("RecoveryGuardiansMetadata.FromBytes: Error reading number of guardians: %v", err)
+	}
+	if numGuardians > MaxRecoveryGuardians {
+		return fmt.Errorf("RecoveryGuardiansMetadata.FromBytes: Number of guardians %d "+
+			"exceeds the maximum of %d", numGuardians, MaxRecoveryGuardians)
+	}
+	for ii := uint64(0); ii < numGuardians; ii++ {
+		guardianPublicKey, err := ReadVarString(rr)
+		if err != nil {
+			return fmt.Errorf(
+				"RecoveryGuardiansMetadata.FromBytes: Error reading GuardianPublicKey %d: %v", ii, err)
+		}
+		ret.GuardianPublicKeys = append(ret.GuardianPublicKeys, guardianPublicKey)
+	}
+
+	// Threshold
+	ret.Threshold, err = ReadUvarint(rr)
+	if err != nil {
+		return fmt.Errorf("RecoveryGuardiansMetadata.FromBytes: Error reading Threshold: %v", err)
+	}
+
+	// DelayBlocks
+	ret.DelayBlocks, err = ReadUvarint(rr)
+	if err != nil {
+		return fmt.Errorf("RecoveryGuardiansMetadata.FromBytes: Error reading DelayBlocks: %v", err)
+	}
+
+	*txnData = ret
+
+	return nil
+}
+
+func (txnData *RecoveryGuardiansMetadata) New() DeSoTxnMetadata {
+	return &RecoveryGuardiansMetadata{}
+}
+
+// ==================================================================
+// AccountRecoveryMetadata
+// ==================================================================
+
+type AccountRecoveryOperationType uint8
+
+const (
+	// AccountRecoveryOperationInitiate starts a recovery signed by enough of the
+	// owner's guardians. It can be completed once the owner's delay has passed.
+	AccountRecoveryOperationInitiate AccountRecoveryOperationType = 0
+	// AccountRecoveryOperationCancel lets the owner cancel a pending recovery.
+	AccountRecoveryOperationCancel AccountRecoveryOperationType = 1
+	// AccountRecoveryOperationComplete moves the owner's PKID to the new key once
+	// the delay has passed.
+	AccountRecoveryOperationComplete AccountRecoveryOperationType = 2
+)
+
+// GuardianSignature is a guardian's DER signature approving a recovery.
+type GuardianSignature struct {
+	GuardianPublicKey []byte
+	Signature         []byte
+}
+
+type AccountRecoveryMetadata struct {
+	// Anyone can submit an Initiate or Complete and pay its fees. A Cancel
+	// must come from the owner.
+
+	OperationType AccountRecoveryOperationType
+
+	// OwnerPublicKey is the key currently holding the PKID being recovered.
+	OwnerPublicKey []byte
+
+	// NewPublicKey is the key that takes over the PKID. For Cancel and Complete
+	// it must match the pending recovery.
+	NewPublicKey []byte
+
+	// NewKeySignature is the new key's DER signature of the double SHA-256 hash
+	// of the owner's public key, the new public key and the RecoveryNonce of the
+	// owner's guardians. See AccountRecoverySignatureData. Only used by Initiate.
+	NewKeySignature []byte
+
+	// GuardianSignatures are the guardians' signatures of the same data as the
+	// NewKeySignature. Only used by Initiate.
+	GuardianSignatures []*GuardianSignature
+}
+
+func (txnData *AccountRecoveryMetadata) GetTxnType() TxnType {
+	return TxnTypeAccountRecovery
+}
+
+func (txnData *AccountRecoveryMetadata) ToBytes(preSignature bool) ([]byte, error) {
+	data := []byte{}
+
+	// OperationType
+	data = append(data, byte(txnData.OperationType))
+
+	// OwnerPublicKey
+	data = append(data, UintToBuf(uint64(len(txnData.OwnerPublicKey)))...)
+	data = append(data, txnData.OwnerPublicKey...)
+
+	// NewPublicKey
+	data = append(data, UintToBuf(uint64(len(txnData.NewPublicKey)))...)
+	data = append(data, txnData.NewPublicKey...)
+
+	// NewKeySignature
+	data = append(data, UintToBuf(uint64(len(txnData.NewKeySignature)))...)
+	data = append(data, txnData.NewKeySignature...)
+
+	// GuardianSignatures
+	data = append(data, UintToBuf(uint64(len(txnData.GuardianSignatures)))...)
+	for _, guardianSignature := range txnData.GuardianSignatures {
+		data = append(data, UintToBuf(uint64(len(guardianSignature.GuardianPublicKey)))...)
+		data = append(data, guardianSignature.GuardianPublicKey...)
+		data = append(data, UintToBuf(uint64(len(guardianSignature.Signature)))...)
+		data = append(data, guardianSignature.Signature...)
+	}
+
+	return data, nil
+}
+
+func (txnData *AccountRecoveryMetadata) FromBytes(data []byte) error {
+	ret := AccountRecoveryMetadata{}
+	rr := bytes.NewReader(data)
+
+	// OperationType
+	operationType, err := rr.ReadByte()
+	if err != nil {
+		return fmt.Errorf("AccountRecoveryMetadata.FromBytes: Error reading OperationType: %v", err)
+	}
+	ret.OperationType = AccountRecoveryOperationType(operationType)
+
+	// OwnerPublicKey
+	ret.OwnerPublicKey, err = ReadVarString(rr)
+	if err != nil {
+		return fmt.Errorf(
+			"AccountRecoveryMetadata.FromBytes: Error reading OwnerPublicKey: %v", err)
+	}
+
+	// NewPublicKey
+	ret.NewPublicKey, err = ReadVarString(rr)
+	if err != nil {
+		return fmt.Errorf(
+			"AccountRecoveryMetadata.FromBytes: Error reading NewPublicKey: %v", err)
+	}
+
+	// NewKeySignature
+	ret.NewKeySignature, err = ReadVarString(rr)
+	if err != nil {
+		return fmt.Errorf(
+			"AccountRecoveryMetadata.FromBytes: Error reading NewKeySignature: %v", err)
+	}
+
+	// GuardianSignatures
+	numSignatures, err := ReadUvarint(rr)
+	if err != nil {
+		return fmt.Errorf("AccountRecoveryMetadata.FromBytes: Error reading number of signatures: %v", err)
+	}
+	if numSignatures > MaxRecoveryGuardians {
+		return fmt.Errorf("AccountRecoveryMetadata.FromBytes: Number of signatures %d "+
+			"exceeds the maximum of %d", numSignatures, MaxRecoveryGuardians)
+	}
+	for ii := uint64(0); ii < numSignatures; ii++ {
+		guardianSignature := &GuardianSignature{}
+		guardianSignature.GuardianPublicKey, err = ReadVarString(rr)
+		if err != nil {
+			return fmt.Errorf(
+				"AccountRecoveryMetadata.FromBytes: Error reading GuardianPublicKey %d: %v", ii, err)
+		}
+		guardianSignature.Signature, err = ReadVarString(rr)
+		if err != nil {
+			return fmt.Errorf(
+				"AccountRecoveryMetadata.FromBytes: Error reading Signature %d: %v", ii, err)
+		}
+		ret.GuardianSignatures = append(ret.GuardianSignatures, guardianSignature)
+	}
+
+	*txnData = ret
+
+	return nil
+}
+
+func (txnData *AccountRecoveryMetadata) New() DeSoTxnMetadata {
+	return &AccountRecoveryMetadata{}
+}
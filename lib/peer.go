@@ -376,7 +376,23 @@ func (pp *Peer) HandleGetBlocks(msg *MsgDeSoGetBlocks) {
 	// Note that the requester should generally ask for the blocks in the
 	// order they'd like to receive them as we will typically honor this
 	// ordering.
+	prunedHeight := pp.srv.blockchain.PrunedBlockHeight()
 	for _, hashToSend := range msg.HashList {
+		// If we're a pruned node, refuse to serve blocks we've already deleted.
+		// Peers shouldn't be asking us for these since we don't advertise
+		// SFFullNode.
+		if prunedHeight > 0 {
+			pp.srv.blockchain.ChainLock.RLock()
+			node, exists := pp.srv.blockchain.blockIndex[*hashToSend]
+			pp.srv.blockchain.ChainLock.RUnlock()
+			if exists && uint64(node.Height) < prunedHeight {
+				glog.Errorf("Server._handleGetBlocks: Disconnecting peer %v because "+
+					"she asked for block %v at height %d which is below our prune "+
+					"height %d", pp, hashToSend, node.Height, prunedHeight)
+				pp.Disconnect()
+				return
+			}
+		}
 		blockToSend := pp.srv.blockchain.GetBlock(hashToSend)
 		if blockToSend == nil {
 			// Don't ask us for blocks before verifying that we have them with a
@@ -1042,6 +1058,7 @@ func (pp *Peer) WriteDeSoMessage(msg DeSoMessage) error {
 
 	// Only track the payload sent in the statistics we track.
 	atomic.AddUint64(&pp.bytesSent, uint64(len(payload)))
+	recordPeerMessage(pp, "sent", msg.GetMsgType(), len(payload))
 	atomic.StoreInt64(&pp.lastSend, time.Now().Unix())
 
 	// Useful for debugging.
@@ -1064,6 +1081,7 @@ func (pp *Peer) ReadDeSoMessage() (DeSoMessage, error) {
 	// Only track the payload received in the statistics we track.
 	msgLen := uint64(len(payload))
 	atomic.AddUint64(&pp.bytesReceived, msgLen)
+	recordPeerMessage(pp, "received", msg.GetMsgType(), len(payload))
 	atomic.StoreInt64(&pp.lastRecv, time.Now().Unix())
 
 	// Useful for debugging.
@@ -1084,9 +1102,12 @@ func (pp *Peer) NewVersionMessage(params *DeSoParams) *MsgDeSoVersion {
 	// unique value.
 	ver.Nonce = uint64(RandInt64(math.MaxInt64))
 	ver.UserAgent = params.UserAgent
-	// TODO: Right now all peers are full nodes. Later on we'll want to change this,
-	// at which point we'll need to do a little refactoring.
+	// Pruned nodes don't advertise SFFullNode so that peers never pick them
+	// as a sync candidate for blocks they no longer have.
 	ver.Services = SFFullNode
+	if pp.srv != nil && pp.srv.blockchain.PruneDepth() > 0 {
+		ver.Services = SFPrunedNode
+	}
 
 	// When a node asks you for what height you have, you should reply with
 	// the height of the latest actual block you have. This makes it so that
@@ -108,28 +108,40 @@ type PGTransaction struct {
 	S         *BlockHash `pg:",type:bytea"`
 
 	// Relationships
-	Outputs                     []*PGTransactionOutput         `pg:"rel:has-many,join_fk:output_hash"`
-	MetadataBlockReward         *PGMetadataBlockReward         `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataBitcoinExchange     *PGMetadataBitcoinExchange     `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataPrivateMessage      *PGMetadataPrivateMessage      `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataSubmitPost          *PGMetadataSubmitPost          `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataUpdateExchangeRate  *PGMetadataUpdateExchangeRate  `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataUpdateProfile       *PGMetadataUpdateProfile       `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataFollow              *PGMetadataFollow              `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataLike                *PGMetadataLike                `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataCreatorCoin         *PGMetadataCreatorCoin         `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataCreatorCoinTransfer *PGMetadataCreatorCoinTransfer `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataSwapIdentity        *PGMetadataSwapIdentity        `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataCreateNFT           *PGMetadataCreateNFT           `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataUpdateNFT           *PGMetadataUpdateNFT           `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataAcceptNFTBid        *PGMetadataAcceptNFTBid        `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataNFTBid              *PGMetadataNFTBid              `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataNFTTransfer         *PGMetadataNFTTransfer         `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataAcceptNFTTransfer   *PGMetadataAcceptNFTTransfer   `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataBurnNFT             *PGMetadataBurnNFT             `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataDerivedKey          *PGMetadataDerivedKey          `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataDAOCoin             *PGMetadataDAOCoin             `pg:"rel:belongs-to,join_fk:transaction_hash"`
-	MetadataDAOCoinTransfer     *PGMetadataDAOCoinTransfer     `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	Outputs                       []*PGTransactionOutput           `pg:"rel:has-many,join_fk:output_hash"`
+	MetadataBlockReward           *PGMetadataBlockReward           `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataBitcoinExchange       *PGMetadataBitcoinExchange       `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataPrivateMessage        *PGMetadataPrivateMessage        `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataSubmitPost            *PGMetadataSubmitPost            `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataUpdateExchangeRate    *PGMetadataUpdateExchangeRate    `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataUpdateProfile         *PGMetadataUpdateProfile         `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataFollow                *PGMetadataFollow                `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataLike                  *PGMetadataLike                  `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataCreatorCoin           *PGMetadataCreatorCoin           `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataCreatorCoinTransfer   *PGMetadataCreatorCoinTransfer   `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataSwapIdentity          *PGMetadataSwapIdentity          `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataCreateNFT             *PGMetadataCreateNFT             `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataUpdateNFT             *PGMetadataUpdateNFT             `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataAcceptNFTBid          *PGMetadataAcceptNFTBid          `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataNFTBid                *PGMetadataNFTBid                `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataNFTTransfer           *PGMetadataNFTTransfer           `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataAcceptNFTTransfer     *PGMetadataAcceptNFTTransfer     `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataBurnNFT               *PGMetadataBurnNFT               `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataDerivedKey            *PGMetadataDerivedKey            `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataDAOCoin               *PGMetadataDAOCoin               `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataDAOCoinTransfer       *PGMetadataDAOCoinTransfer       `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataPollVote              *PGMetadataPollVote              `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataUserBlock             *PGMetadataUserBlock             `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataMessageRead           *PGMetadataMessageRead           `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataCreateNFTCollection   *PGMetadataCreateNFTCollection   `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataNFTVault              *PGMetadataNFTVault              `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataRedeemNFTVoucher      *PGMetadataRedeemNFTVoucher      `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataRegisterBlockProducer *PGMetadataRegisterBlockProducer `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataUsernameListing       *PGMetadataUsernameListing       `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataAcceptUsernameListing *PGMetadataAcceptUsernameListing `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataKeyRotation           *PGMetadataKeyRotation           `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataRecoveryGuardians     *PGMetadataRecoveryGuardians     `pg:"rel:belongs-to,join_fk:transaction_hash"`
+	MetadataAccountRecovery       *PGMetadataAccountRecovery       `pg:"rel:belongs-to,join_fk:transaction_hash"`
 }
 
 // PGTransactionOutput represents DeSoOutput, DeSoInput, and UtxoEntry
@@ -231,6 +243,121 @@ type PGMetadataFollow struct {
 	IsUnfollow        bool       `pg:",use_zero"`
 }
 
+// PGMetadataUserBlock represents UserBlockMetadata
+type PGMetadataUserBlock struct {
+	tableName struct{} `pg:"pg_metadata_user_blocks"`
+
+	TransactionHash  *BlockHash    `pg:",pk,type:bytea"`
+	BlockedPublicKey []byte        `pg:",type:bytea"`
+	BlockType        UserBlockType `pg:",use_zero"`
+	IsUnblock        bool          `pg:",use_zero"`
+}
+
+// PGMetadataMessageRead represents MessageReadMetadata
+type PGMetadataMessageRead struct {
+	tableName struct{} `pg:"pg_metadata_message_reads"`
+
+	TransactionHash     *BlockHash `pg:",pk,type:bytea"`
+	ThreadPublicKey     []byte     `pg:",type:bytea"`
+	LastReadTstampNanos uint64     `pg:",use_zero"`
+}
+
+// PGMetadataCreateNFTCollection represents CreateNFTCollectionMetadata
+
+type PGMetadataCreateNFTCollection struct {
+	tableName struct{} `pg:"pg_metadata_create_nft_collections"`
+
+	TransactionHash           *BlockHash `pg:",pk,type:bytea"`
+	Name                      string
+	MaxSupply                 uint64 `pg:",use_zero"`
+	CreatorRoyaltyBasisPoints uint64 `pg:",use_zero"`
+	CoinRoyaltyBasisPoints    uint64 `pg:",use_zero"`
+}
+
+// PGMetadataNFTVault represents NFTVaultMetadata
+type PGMetadataNFTVault struct {
+	tableName struct{} `pg:"pg_metadata_nft_vaults"`
+
+	TransactionHash       *BlockHash            `pg:",pk,type:bytea"`
+	NFTPostHash           *BlockHash            `pg:",type:bytea"`
+	SerialNumber          uint64                `pg:",use_zero"`
+	OperationType         NFTVaultOperationType `pg:",use_zero"`
+	TotalSharesNanos      string
+	BuyoutPriceNanos      uint64 `pg:",use_zero"`
+	SharesToTransferNanos string
+	ReceiverPublicKey     []byte `pg:",type:bytea"`
+}
+
+// PGMetadataRedeemNFTVoucher represents RedeemNFTVoucherMetadata
+type PGMetadataRedeemNFTVoucher struct {
+	tableName struct{} `pg:"pg_metadata_redeem_nft_vouchers"`
+
+	TransactionHash  *BlockHash `pg:",pk,type:bytea"`
+	NFTPostHash      *BlockHash `pg:",type:bytea"`
+	SerialNumber     uint64     `pg:",use_zero"`
+	PriceNanos       uint64     `pg:",use_zero"`
+	CreatorSignature []byte     `pg:",type:bytea"`
+}
+
+// PGMetadataRegisterBlockProducer represents RegisterBlockProducerMetadata
+type PGMetadataRegisterBlockProducer struct {
+	tableName struct{} `pg:"pg_metadata_register_block_producers"`
+
+	TransactionHash *BlockHash `pg:",pk,type:bytea"`
+	BondNanos       uint64     `pg:",use_zero"`
+}
+
+// PGMetadataUsernameListing represents UsernameListingMetadata
+type PGMetadataUsernameListing struct {
+	tableName struct{} `pg:"pg_metadata_username_listings"`
+
+	TransactionHash     *BlockHash `pg:",pk,type:bytea"`
+	Username            string     `pg:",use_zero"`
+	ReplacementUsername string     `pg:",use_zero"`
+	RecipientPublicKey  []byte     `pg:",type:bytea"`
+	PriceNanos          uint64     `pg:",use_zero"`
+	IsRemoved           bool       `pg:",use_zero"`
+}
+
+// PGMetadataAcceptUsernameListing represents AcceptUsernameListingMetadata
+type PGMetadataAcceptUsernameListing struct {
+	tableName struct{} `pg:"pg_metadata_accept_username_listings"`
+
+	TransactionHash *BlockHash `pg:",pk,type:bytea"`
+	Username        string     `pg:",use_zero"`
+	PriceNanos      uint64     `pg:",use_zero"`
+}
+
+// PGMetadataKeyRotation represents KeyRotationMetadata
+type PGMetadataKeyRotation struct {
+	tableName struct{} `pg:"pg_metadata_key_rotations"`
+
+	TransactionHash *BlockHash               `pg:",pk,type:bytea"`
+	OperationType   KeyRotationOperationType `pg:",use_zero"`
+	NewPublicKey    []byte                   `pg:",type:bytea"`
+	TimelockBlocks  uint64                   `pg:",use_zero"`
+}
+
+// PGMetadataRecoveryGuardians represents RecoveryGuardiansMetadata
+type PGMetadataRecoveryGuardians struct {
+	tableName struct{} `pg:"pg_metadata_recovery_guardians"`
+
+	TransactionHash    *BlockHash `pg:",pk,type:bytea"`
+	GuardianPublicKeys [][]byte
+	Threshold          uint64 `pg:",use_zero"`
+	DelayBlocks        uint64 `pg:",use_zero"`
+}
+
+// PGMetadataAccountRecovery represents AccountRecoveryMetadata
+type PGMetadataAccountRecovery struct {
+	tableName struct{} `pg:"pg_metadata_account_recoveries"`
+
+	TransactionHash *BlockHash                   `pg:",pk,type:bytea"`
+	OperationType   AccountRecoveryOperationType `pg:",use_zero"`
+	OwnerPublicKey  []byte                       `pg:",type:bytea"`
+	NewPublicKey    []byte                       `pg:",type:bytea"`
+}
+
 // PGMetadataLike represents LikeMetadata
 type PGMetadataLike struct {
 	tableName struct{} `pg:"pg_metadata_likes"`
@@ -286,6 +413,15 @@ type PGMetadataDAOCoinTransfer struct {
 	ReceiverPublicKey      []byte     `pg:",type:bytea"`
 }
 
+// PGMetadataPollVote represents PollVoteMetadata
+type PGMetadataPollVote struct {
+	tableName struct{} `pg:"pg_metadata_poll_votes"`
+
+	TransactionHash *BlockHash `pg:",pk,type:bytea"`
+	PostHash        *BlockHash `pg:",type:bytea"`
+	OptionIndex     uint32     `pg:",use_zero"`
+}
+
 // PGMetadataSwapIdentity represents SwapIdentityMetadataa
 type PGMetadataSwapIdentity struct {
 	tableName struct{} `pg:"pg_metadata_swap_identities"`
@@ -475,6 +611,8 @@ type PGPost struct {
 	CoinRoyaltyBasisPoints                      uint64            `pg:",use_zero"`
 	AdditionalNFTRoyaltiesToCoinsBasisPoints    map[string]uint64 `pg:"additional_nft_royalties_to_coins_basis_points"`
 	AdditionalNFTRoyaltiesToCreatorsBasisPoints map[string]uint64 `pg:"additional_nft_royalties_to_creators_basis_points"`
+	NFTCollectionID                             *BlockHash        `pg:",type:bytea"`
+	LazyMintedNFT                               bool              `pg:",use_zero"`
 	ExtraData                                   map[string][]byte
 }
 
@@ -500,6 +638,8 @@ func (post *PGPost) NewPostEntry() *PostEntry {
 		HasUnlockable:                  post.Unlockable,
 		NFTRoyaltyToCoinBasisPoints:    post.CoinRoyaltyBasisPoints,
 		NFTRoyaltyToCreatorBasisPoints: post.CreatorRoyaltyBasisPoints,
+		NFTCollectionID:                post.NFTCollectionID,
+		IsLazyMintedNFT:                post.LazyMintedNFT,
 		PostExtraData:                  post.ExtraData,
 	}
 
@@ -541,6 +681,100 @@ func (post *PGPost) HasMedia() bool {
 	return (err == nil && len(bodyJSONObj.ImageURLs) > 0 || len(bodyJSONObj.VideoURLs) > 0) || len(post.ExtraData["EmbedVideoURL"]) > 0
 }
 
+// PGPostVersion represents PostVersionEntry
+type PGPostVersion struct {
+	tableName struct{} `pg:"pg_post_versions"`
+
+	PostHash       *BlockHash `pg:",pk,type:bytea"`
+	Version        uint64     `pg:",pk,use_zero"`
+	Body           string
+	ExtraData      map[string][]byte
+	Hidden         bool   `pg:",use_zero"`
+	TimestampNanos uint64 `pg:",use_zero"`
+	BlockHeight    uint32 `pg:",use_zero"`
+}
+
+func (version *PGPostVersion) NewPostVersionEntry() *PostVersionEntry {
+	return &PostVersionEntry{
+		PostHash:       version.PostHash,
+		Version:        version.Version,
+		Body:           []byte(version.Body),
+		PostExtraData:  version.ExtraData,
+		IsHidden:       version.Hidden,
+		TimestampNanos: version.TimestampNanos,
+		BlockHeight:    version.BlockHeight,
+	}
+}
+
+// PGPoll represents PollEntry
+type PGPoll struct {
+	tableName struct{} `pg:"pg_polls"`
+
+	PostHash           *BlockHash `pg:",pk,type:bytea"`
+	PosterPKID         *PKID      `pg:",type:bytea"`
+	Question           string
+	Options            []string
+	ClosingBlockHeight uint32         `pg:",use_zero"`
+	WeightType         PollWeightType `pg:",use_zero"`
+	OptionTallies      []string
+	NumVotes           uint64 `pg:",use_zero"`
+}
+
+func (poll *PGPoll) NewPollEntry() *PollEntry {
+	options := make([][]byte, len(poll.Options))
+	for ii, option := range poll.Options {
+		options[ii] = []byte(option)
+	}
+	optionTallies := make([]uint256.Int, len(poll.Options))
+	for ii := range optionTallies {
+		if ii >= len(poll.OptionTallies) || poll.OptionTallies[ii] == "" {
+			continue
+		}
+		if tally, err := uint256.FromHex(poll.OptionTallies[ii]); err == nil {
+			optionTallies[ii] = *tally
+		}
+	}
+
+	return &PollEntry{
+		PostHash:           poll.PostHash,
+		PosterPKID:         poll.PosterPKID,
+		Question:           []byte(poll.Question),
+		Options:            options,
+		ClosingBlockHeight: poll.ClosingBlockHeight,
+		WeightType:         poll.WeightType,
+		OptionTallies:      optionTallies,
+		NumVotes:           poll.NumVotes,
+	}
+}
+
+// PGPollVote represents PollVoteEntry
+type PGPollVote struct {
+	tableName struct{} `pg:"pg_poll_votes"`
+
+	PostHash    *BlockHash `pg:",pk,type:bytea"`
+	VoterPKID   *PKID      `pg:",pk,type:bytea"`
+	OptionIndex uint32     `pg:",use_zero"`
+	Weight      string
+	BlockHeight uint32 `pg:",use_zero"`
+}
+
+func (vote *PGPollVote) NewPollVoteEntry() *PollVoteEntry {
+	weight := uint256.NewInt()
+	if vote.Weight != "" {
+		if parsedWeight, err := uint256.FromHex(vote.Weight); err == nil {
+			weight = parsedWeight
+		}
+	}
+
+	return &PollVoteEntry{
+		PostHash:    vote.PostHash,
+		VoterPKID:   vote.VoterPKID,
+		OptionIndex: vote.OptionIndex,
+		Weight:      *weight,
+		BlockHeight: vote.BlockHeight,
+	}
+}
+
 type PGLike struct {
 	tableName struct{} `pg:"pg_likes"`
 
@@ -569,6 +803,23 @@ func (follow *PGFollow) NewFollowEntry() *FollowEntry {
 	}
 }
 
+// PGUserBlock represents UserBlockEntry
+type PGUserBlock struct {
+	tableName struct{} `pg:"pg_user_blocks"`
+
+	BlockerPKID *PKID         `pg:",pk,type:bytea"`
+	BlockedPKID *PKID         `pg:",pk,type:bytea"`
+	BlockType   UserBlockType `pg:",use_zero"`
+}
+
+func (userBlock *PGUserBlock) NewUserBlockEntry() *UserBlockEntry {
+	return &UserBlockEntry{
+		BlockerPKID: userBlock.BlockerPKID,
+		BlockedPKID: userBlock.BlockedPKID,
+		BlockType:   userBlock.BlockType,
+	}
+}
+
 type PGDiamond struct {
 	tableName struct{} `pg:"pg_diamonds"`
 
@@ -594,13 +845,31 @@ type PGMessage struct {
 	isDeleted bool
 }
 
+// PGMessageRead represents MessageReadEntry
+type PGMessageRead struct {
+	tableName struct{} `pg:"pg_message_reads"`
+
+	ReaderPublicKey     *PublicKey `pg:",pk,type:bytea"`
+	ThreadPublicKey     *PublicKey `pg:",pk,type:bytea"`
+	LastReadTstampNanos uint64     `pg:",use_zero"`
+}
+
+func (messageRead *PGMessageRead) NewMessageReadEntry() *MessageReadEntry {
+	return &MessageReadEntry{
+		ReaderPublicKey:     messageRead.ReaderPublicKey,
+		ThreadPublicKey:     messageRead.ThreadPublicKey,
+		LastReadTstampNanos: messageRead.LastReadTstampNanos,
+	}
+}
+
 type PGMessagingGroup struct {
 	tableName struct{} `pg:"pg_messaging_group"`
 
-	GroupOwnerPublicKey *PublicKey     `pg:",type:bytea"`
-	MessagingPublicKey *PublicKey    `pg:",type:bytea"`
-	MessagingGroupKeyName *GroupKeyName    `pg:",type:bytea"`
-	MessagingGroupMembers []byte    `pg:",type:bytea"`
+	GroupOwnerPublicKey   *PublicKey    `pg:",pk,type:bytea"`
+	MessagingPublicKey    *PublicKey    `pg:",type:bytea"`
+	MessagingGroupKeyName *GroupKeyName `pg:",pk,type:bytea"`
+	MessagingGroupMembers []byte        `pg:",type:bytea"`
+	MessagingGroupAdmins  []byte        `pg:",type:bytea"`
 }
 
 type PGCreatorCoinBalance struct {
@@ -651,6 +920,34 @@ func (balance *PGDAOCoinBalance) NewBalanceEntry() *BalanceEntry {
 	}
 }
 
+// PGCreatorCoinPriceCandle represents CreatorCoinPriceCandleEntry
+type PGCreatorCoinPriceCandle struct {
+	tableName struct{} `pg:"pg_creator_coin_price_candles"`
+
+	CreatorPKID     *PKID  `pg:",pk,type:bytea"`
+	BlockHeight     uint32 `pg:",pk,use_zero"`
+	OpenPriceNanos  uint64 `pg:",use_zero"`
+	HighPriceNanos  uint64 `pg:",use_zero"`
+	LowPriceNanos   uint64 `pg:",use_zero"`
+	ClosePriceNanos uint64 `pg:",use_zero"`
+	VolumeNanos     uint64 `pg:",use_zero"`
+	NumTrades       uint64 `pg:",use_zero"`
+}
+
+func (candle *PGCreatorCoinPriceCandle) NewCreatorCoinPriceCandleEntry() *CreatorCoinPriceCandleEntry {
+	return &CreatorCoinPriceCandleEntry{
+		CreatorPKID:      candle.CreatorPKID,
+		StartBlockHeight: candle.BlockHeight,
+		EndBlockHeight:   candle.BlockHeight,
+		OpenPriceNanos:   candle.OpenPriceNanos,
+		HighPriceNanos:   candle.HighPriceNanos,
+		LowPriceNanos:    candle.LowPriceNanos,
+		ClosePriceNanos:  candle.ClosePriceNanos,
+		VolumeNanos:      candle.VolumeNanos,
+		NumTrades:        candle.NumTrades,
+	}
+}
+
 // PGBalance represents PublicKeyToDeSoBalanceNanos
 type PGBalance struct {
 	tableName struct{} `pg:"pg_balances"`
@@ -698,30 +995,271 @@ type PGNFT struct {
 	SerialNumber uint64     `pg:",pk"`
 
 	// This is needed to decrypt unlockable text.
-	LastOwnerPKID              *PKID  `pg:",type:bytea"`
-	OwnerPKID                  *PKID  `pg:",type:bytea"`
-	ForSale                    bool   `pg:",use_zero"`
-	MinBidAmountNanos          uint64 `pg:",use_zero"`
-	UnlockableText             string
-	LastAcceptedBidAmountNanos uint64 `pg:",use_zero"`
-	IsPending                  bool   `pg:",use_zero"`
-	IsBuyNow                   bool   `pg:",use_zero"`
-	BuyNowPriceNanos           uint64 `pg:",use_zero"`
+	LastOwnerPKID               *PKID  `pg:",type:bytea"`
+	OwnerPKID                   *PKID  `pg:",type:bytea"`
+	ForSale                     bool   `pg:",use_zero"`
+	MinBidAmountNanos           uint64 `pg:",use_zero"`
+	UnlockableText              string
+	LastAcceptedBidAmountNanos  uint64 `pg:",use_zero"`
+	IsPending                   bool   `pg:",use_zero"`
+	IsBuyNow                    bool   `pg:",use_zero"`
+	BuyNowPriceNanos            uint64 `pg:",use_zero"`
+	AuctionEndBlockHeight       uint64 `pg:",use_zero"`
+	AuctionReservePriceNanos    uint64 `pg:",use_zero"`
+	AuctionMinBidIncrementNanos uint64 `pg:",use_zero"`
+	IsVaulted                   bool   `pg:",use_zero"`
+	VaultTotalSharesNanos       string
+	VaultBuyoutPriceNanos       uint64 `pg:",use_zero"`
 }
 
 func (nft *PGNFT) NewNFTEntry() *NFTEntry {
+	vaultTotalSharesNanos := uint256.NewInt()
+	if nft.VaultTotalSharesNanos != "" {
+		var err error
+		vaultTotalSharesNanos, err = uint256.FromHex(nft.VaultTotalSharesNanos)
+		if err != nil {
+			vaultTotalSharesNanos = uint256.NewInt()
+		}
+	}
+
 	return &NFTEntry{
-		LastOwnerPKID:              nft.LastOwnerPKID,
-		OwnerPKID:                  nft.OwnerPKID,
-		NFTPostHash:                nft.NFTPostHash,
-		SerialNumber:               nft.SerialNumber,
-		IsForSale:                  nft.ForSale,
-		MinBidAmountNanos:          nft.MinBidAmountNanos,
-		UnlockableText:             []byte(nft.UnlockableText),
-		LastAcceptedBidAmountNanos: nft.LastAcceptedBidAmountNanos,
-		IsPending:                  nft.IsPending,
-		IsBuyNow:                   nft.IsBuyNow,
-		BuyNowPriceNanos:           nft.BuyNowPriceNanos,
+		LastOwnerPKID:               nft.LastOwnerPKID,
+		OwnerPKID:                   nft.OwnerPKID,
+		NFTPostHash:                 nft.NFTPostHash,
+		SerialNumber:                nft.SerialNumber,
+		IsForSale:                   nft.ForSale,
+		MinBidAmountNanos:           nft.MinBidAmountNanos,
+		UnlockableText:              []byte(nft.UnlockableText),
+		LastAcceptedBidAmountNanos:  nft.LastAcceptedBidAmountNanos,
+		IsPending:                   nft.IsPending,
+		IsBuyNow:                    nft.IsBuyNow,
+		BuyNowPriceNanos:            nft.BuyNowPriceNanos,
+		AuctionEndBlockHeight:       nft.AuctionEndBlockHeight,
+		AuctionReservePriceNanos:    nft.AuctionReservePriceNanos,
+		AuctionMinBidIncrementNanos: nft.AuctionMinBidIncrementNanos,
+		IsVaulted:                   nft.IsVaulted,
+		VaultTotalSharesNanos:       *vaultTotalSharesNanos,
+		VaultBuyoutPriceNanos:       nft.VaultBuyoutPriceNanos,
+	}
+}
+
+// PGNFTCollection represents NFTCollectionEntry
+
+type PGNFTCollection struct {
+	tableName struct{} `pg:"pg_nft_collections"`
+
+	CollectionID                                *BlockHash `pg:",pk,type:bytea"`
+	CreatorPKID                                 *PKID      `pg:",type:bytea"`
+	Name                                        string
+	MaxSupply                                   uint64            `pg:",use_zero"`
+	NumNFTCopies                                uint64            `pg:",use_zero"`
+	CreatorRoyaltyBasisPoints                   uint64            `pg:",use_zero"`
+	CoinRoyaltyBasisPoints                      uint64            `pg:",use_zero"`
+	AdditionalNFTRoyaltiesToCoinsBasisPoints    map[string]uint64 `pg:"additional_nft_royalties_to_coins_basis_points"`
+	AdditionalNFTRoyaltiesToCreatorsBasisPoints map[string]uint64 `pg:"additional_nft_royalties_to_creators_basis_points"`
+}
+
+func (nftCollection *PGNFTCollection) NewNFTCollectionEntry() *NFTCollectionEntry {
+	nftCollectionEntry := &NFTCollectionEntry{
+		CollectionID:                   nftCollection.CollectionID,
+		CreatorPKID:                    nftCollection.CreatorPKID,
+		Name:                           []byte(nftCollection.Name),
+		MaxSupply:                      nftCollection.MaxSupply,
+		NumNFTCopies:                   nftCollection.NumNFTCopies,
+		NFTRoyaltyToCreatorBasisPoints: nftCollection.CreatorRoyaltyBasisPoints,
+		NFTRoyaltyToCoinBasisPoints:    nftCollection.CoinRoyaltyBasisPoints,
+	}
+
+	if len(nftCollection.AdditionalNFTRoyaltiesToCoinsBasisPoints) > 0 {
+		nftCollectionEntry.AdditionalNFTRoyaltiesToCoinsBasisPoints = make(map[PKID]uint64)
+		for pkidStr, bp := range nftCollection.AdditionalNFTRoyaltiesToCoinsBasisPoints {
+			pkidBytes, err := hex.DecodeString(pkidStr)
+			if err != nil {
+				panic(err)
+			}
+			nftCollectionEntry.AdditionalNFTRoyaltiesToCoinsBasisPoints[*NewPKID(pkidBytes)] = bp
+		}
+	}
+
+	if len(nftCollection.AdditionalNFTRoyaltiesToCreatorsBasisPoints) > 0 {
+		nftCollectionEntry.AdditionalNFTRoyaltiesToCreatorsBasisPoints = make(map[PKID]uint64)
+		for pkidStr, bp := range nftCollection.AdditionalNFTRoyaltiesToCreatorsBasisPoints {
+			pkidBytes, err := hex.DecodeString(pkidStr)
+			if err != nil {
+				panic(err)
+			}
+			nftCollectionEntry.AdditionalNFTRoyaltiesToCreatorsBasisPoints[*NewPKID(pkidBytes)] = bp
+		}
+	}
+
+	return nftCollectionEntry
+}
+
+// PGNFTVoucherRedemption represents NFTVoucherRedemptionEntry
+type PGNFTVoucherRedemption struct {
+	tableName struct{} `pg:"pg_nft_voucher_redemptions"`
+
+	NFTPostHash  *BlockHash `pg:",pk,type:bytea"`
+	SerialNumber uint64     `pg:",pk"`
+	RedeemerPKID *PKID      `pg:",type:bytea"`
+	PriceNanos   uint64     `pg:",use_zero"`
+}
+
+func (redemption *PGNFTVoucherRedemption) NewNFTVoucherRedemptionEntry() *NFTVoucherRedemptionEntry {
+	return &NFTVoucherRedemptionEntry{
+		NFTPostHash:  redemption.NFTPostHash,
+		SerialNumber: redemption.SerialNumber,
+		RedeemerPKID: redemption.RedeemerPKID,
+		PriceNanos:   redemption.PriceNanos,
+	}
+}
+
+// PGBlockProducer represents BlockProducerEntry
+type PGBlockProducer struct {
+	tableName struct{} `pg:"pg_block_producers"`
+
+	PublicKey             []byte `pg:",pk,type:bytea"`
+	BondNanos             uint64 `pg:",use_zero"`
+	IsRegistered          bool   `pg:",use_zero"`
+	RegisteredBlockHeight uint32 `pg:",use_zero"`
+	UnbondingNanos        uint64 `pg:",use_zero"`
+	UnbondingBlockHeight  uint32 `pg:",use_zero"`
+	NumBlocksProduced     uint64 `pg:",use_zero"`
+	NumMissedSlots        uint64 `pg:",use_zero"`
+	LastMissedBlockHeight uint32 `pg:",use_zero"`
+}
+
+func (blockProducer *PGBlockProducer) NewBlockProducerEntry() *BlockProducerEntry {
+	return &BlockProducerEntry{
+		PublicKey:             blockProducer.PublicKey,
+		BondNanos:             blockProducer.BondNanos,
+		IsRegistered:          blockProducer.IsRegistered,
+		RegisteredBlockHeight: blockProducer.RegisteredBlockHeight,
+		UnbondingNanos:        blockProducer.UnbondingNanos,
+		UnbondingBlockHeight:  blockProducer.UnbondingBlockHeight,
+		NumBlocksProduced:     blockProducer.NumBlocksProduced,
+		NumMissedSlots:        blockProducer.NumMissedSlots,
+		LastMissedBlockHeight: blockProducer.LastMissedBlockHeight,
+	}
+}
+
+// PGUsernameListing represents UsernameListingEntry. LowercaseUsername is the
+// primary key so that listings are unique regardless of case.
+type PGUsernameListing struct {
+	tableName struct{} `pg:"pg_username_listings"`
+
+	LowercaseUsername   string `pg:",pk"`
+	Username            string `pg:",use_zero"`
+	ReplacementUsername string `pg:",use_zero"`
+	OwnerPKID           *PKID  `pg:",type:bytea"`
+	RecipientPKID       *PKID  `pg:",type:bytea"`
+	PriceNanos          uint64 `pg:",use_zero"`
+}
+
+func (listing *PGUsernameListing) NewUsernameListingEntry() *UsernameListingEntry {
+	return &UsernameListingEntry{
+		Username:            []byte(listing.Username),
+		ReplacementUsername: []byte(listing.ReplacementUsername),
+		OwnerPKID:           listing.OwnerPKID,
+		RecipientPKID:       listing.RecipientPKID,
+		PriceNanos:          listing.PriceNanos,
+	}
+}
+
+// PGPendingKeyRotation represents KeyRotationEntry
+type PGPendingKeyRotation struct {
+	tableName struct{} `pg:"pg_pending_key_rotations"`
+
+	OldPublicKey      []byte `pg:",pk,type:bytea"`
+	NewPublicKey      []byte `pg:",type:bytea"`
+	UnlockBlockHeight uint64 `pg:",use_zero"`
+}
+
+func (keyRotation *PGPendingKeyRotation) NewKeyRotationEntry() *KeyRotationEntry {
+	return &KeyRotationEntry{
+		OldPublicKey:      keyRotation.OldPublicKey,
+		NewPublicKey:      keyRotation.NewPublicKey,
+		UnlockBlockHeight: keyRotation.UnlockBlockHeight,
+	}
+}
+
+// PGKeyRotationTimelock represents KeyRotationTimelockEntry
+type PGKeyRotationTimelock struct {
+	tableName struct{} `pg:"pg_key_rotation_timelocks"`
+
+	OwnerPKID                *PKID  `pg:",pk,type:bytea"`
+	MinTimelockBlocks        uint64 `pg:",use_zero"`
+	PendingMinTimelockBlocks uint64 `pg:",use_zero"`
+	PendingUnlockBlockHeight uint64 `pg:",use_zero"`
+}
+
+func (timelock *PGKeyRotationTimelock) NewKeyRotationTimelockEntry() *KeyRotationTimelockEntry {
+	return &KeyRotationTimelockEntry{
+		OwnerPKID:                timelock.OwnerPKID,
+		MinTimelockBlocks:        timelock.MinTimelockBlocks,
+		PendingMinTimelockBlocks: timelock.PendingMinTimelockBlocks,
+		PendingUnlockBlockHeight: timelock.PendingUnlockBlockHeight,
+	}
+}
+
+// PGRotatedPublicKey represents RotatedPublicKeyEntry
+type PGRotatedPublicKey struct {
+	tableName struct{} `pg:"pg_rotated_public_keys"`
+
+	OldPublicKey []byte `pg:",pk,type:bytea"`
+	PKID         *PKID  `pg:",type:bytea"`
+}
+
+func (rotatedPublicKey *PGRotatedPublicKey) NewRotatedPublicKeyEntry() *RotatedPublicKeyEntry {
+	return &RotatedPublicKeyEntry{
+		OldPublicKey: rotatedPublicKey.OldPublicKey,
+		PKID:         rotatedPublicKey.PKID,
+	}
+}
+
+// PGRecoveryGuardianSet represents RecoveryGuardianSetEntry
+type PGRecoveryGuardianSet struct {
+	tableName struct{} `pg:"pg_recovery_guardian_sets"`
+
+	OwnerPKID                 *PKID `pg:",pk,type:bytea"`
+	GuardianPublicKeys        [][]byte
+	Threshold                 uint64 `pg:",use_zero"`
+	DelayBlocks               uint64 `pg:",use_zero"`
+	RecoveryNonce             uint64 `pg:",use_zero"`
+	PendingGuardianPublicKeys [][]byte
+	PendingThreshold          uint64 `pg:",use_zero"`
+	PendingDelayBlocks        uint64 `pg:",use_zero"`
+	PendingUnlockBlockHeight  uint64 `pg:",use_zero"`
+}
+
+func (guardianSet *PGRecoveryGuardianSet) NewRecoveryGuardianSetEntry() *RecoveryGuardianSetEntry {
+	return &RecoveryGuardianSetEntry{
+		OwnerPKID:                 guardianSet.OwnerPKID,
+		GuardianPublicKeys:        guardianSet.GuardianPublicKeys,
+		Threshold:                 guardianSet.Threshold,
+		DelayBlocks:               guardianSet.DelayBlocks,
+		RecoveryNonce:             guardianSet.RecoveryNonce,
+		PendingGuardianPublicKeys: guardianSet.PendingGuardianPublicKeys,
+		PendingThreshold:          guardianSet.PendingThreshold,
+		PendingDelayBlocks:        guardianSet.PendingDelayBlocks,
+		PendingUnlockBlockHeight:  guardianSet.PendingUnlockBlockHeight,
+	}
+}
+
+// PGPendingAccountRecovery represents AccountRecoveryEntry
+type PGPendingAccountRecovery struct {
+	tableName struct{} `pg:"pg_pending_account_recoveries"`
+
+	OwnerPKID         *PKID  `pg:",pk,type:bytea"`
+	NewPublicKey      []byte `pg:",type:bytea"`
+	UnlockBlockHeight uint64 `pg:",use_zero"`
+}
+
+func (accountRecovery *PGPendingAccountRecovery) NewAccountRecoveryEntry() *AccountRecoveryEntry {
+	return &AccountRecoveryEntry{
+		OwnerPKID:         accountRecovery.OwnerPKID,
+		NewPublicKey:      accountRecovery.NewPublicKey,
+		UnlockBlockHeight: accountRecovery.UnlockBlockHeight,
 	}
 }
 
@@ -839,6 +1377,18 @@ func (postgres *Postgres) GetBlockIndex() (map[BlockHash]*BlockNode, error) {
 	return blockMap, nil
 }
 
+// GetBlock returns the block with the given hash, or nil if it hasn't been processed.
+func (postgres *Postgres) GetBlock(blockHash *BlockHash) *PGBlock {
+	block := PGBlock{
+		Hash: blockHash,
+	}
+	err := postgres.db.Model(&block).WherePK().First()
+	if err != nil {
+		return nil
+	}
+	return &block
+}
+
 // GetChain returns the current chain by name. Postgres only supports MAIN_CHAIN for now but will eventually
 // support multiple chains. A chain is defined by its Name and TipHash.
 func (postgres *Postgres) GetChain(name string) *PGChain {
@@ -898,6 +1448,18 @@ func (postgres *Postgres) InsertTransactionsTx(tx *pg.Tx, desoTxns []*MsgDeSoTxn
 	var metadataDerivedKey []*PGMetadataDerivedKey
 	var metadataDAOCoin []*PGMetadataDAOCoin
 	var metadataDAOCoinTransfer []*PGMetadataDAOCoinTransfer
+	var metadataPollVotes []*PGMetadataPollVote
+	var metadataUserBlocks []*PGMetadataUserBlock
+	var metadataMessageReads []*PGMetadataMessageRead
+	var metadataCreateNFTCollections []*PGMetadataCreateNFTCollection
+	var metadataNFTVaults []*PGMetadataNFTVault
+	var metadataRedeemNFTVouchers []*PGMetadataRedeemNFTVoucher
+	var metadataRegisterBlockProducers []*PGMetadataRegisterBlockProducer
+	var metadataUsernameListings []*PGMetadataUsernameListing
+	var metadataAcceptUsernameListings []*PGMetadataAcceptUsernameListing
+	var metadataKeyRotations []*PGMetadataKeyRotation
+	var metadataRecoveryGuardians []*PGMetadataRecoveryGuardians
+	var metadataAccountRecoveries []*PGMetadataAccountRecovery
 
 	blockHash := blockNode.Hash
 
@@ -1134,11 +1696,114 @@ func (postgres *Postgres) InsertTransactionsTx(tx *pg.Tx, desoTxns []*MsgDeSoTxn
 				DAOCoinToTransferNanos: txMeta.DAOCoinToTransferNanos.Hex(),
 				ReceiverPublicKey:      txMeta.ReceiverPublicKey,
 			})
+		} else if txn.TxnMeta.GetTxnType() == TxnTypePollVote {
+			txMeta := txn.TxnMeta.(*PollVoteMetadata)
+			metadataPollVotes = append(metadataPollVotes, &PGMetadataPollVote{
+				TransactionHash: txnHash,
+				PostHash:        txMeta.PostHash,
+				OptionIndex:     txMeta.OptionIndex,
+			})
+		} else if txn.TxnMeta.GetTxnType() == TxnTypeUserBlock {
+			txMeta := txn.TxnMeta.(*UserBlockMetadata)
+			metadataUserBlocks = append(metadataUserBlocks, &PGMetadataUserBlock{
+				TransactionHash:  txnHash,
+				BlockedPublicKey: txMeta.BlockedPublicKey,
+				BlockType:        txMeta.BlockType,
+				IsUnblock:        txMeta.IsUnblock,
+			})
+		} else if txn.TxnMeta.GetTxnType() == TxnTypeMessageRead {
+			txMeta := txn.TxnMeta.(*MessageReadMetadata)
+			metadataMessageReads = append(metadataMessageReads, &PGMetadataMessageRead{
+				TransactionHash:     txnHash,
+				ThreadPublicKey:     txMeta.ThreadPublicKey,
+				LastReadTstampNanos: txMeta.LastReadTstampNanos,
+			})
+		} else if txn.TxnMeta.GetTxnType() == TxnTypeCreateNFTCollection {
+			txMeta := txn.TxnMeta.(*CreateNFTCollectionMetadata)
+			metadataCreateNFTCollections = append(metadataCreateNFTCollections, &PGMetadataCreateNFTCollection{
+				TransactionHash:           txnHash,
+				Name:                      string(txMeta.Name),
+				MaxSupply:                 txMeta.MaxSupply,
+				CreatorRoyaltyBasisPoints: txMeta.NFTRoyaltyToCreatorBasisPoints,
+				CoinRoyaltyBasisPoints:    txMeta.NFTRoyaltyToCoinBasisPoints,
+			})
+		} else if txn.TxnMeta.GetTxnType() == TxnTypeNFTVault {
+			txMeta := txn.TxnMeta.(*NFTVaultMetadata)
+			metadataNFTVaults = append(metadataNFTVaults, &PGMetadataNFTVault{
+				TransactionHash:       txnHash,
+				NFTPostHash:           txMeta.NFTPostHash,
+				SerialNumber:          txMeta.SerialNumber,
+				OperationType:         txMeta.OperationType,
+				TotalSharesNanos:      txMeta.TotalSharesNanos.Hex(),
+				BuyoutPriceNanos:      txMeta.BuyoutPriceNanos,
+				SharesToTransferNanos: txMeta.SharesToTransferNanos.Hex(),
+				ReceiverPublicKey:     txMeta.ReceiverPublicKey,
+			})
+		} else if txn.TxnMeta.GetTxnType() == TxnTypeRedeemNFTVoucher {
+			txMeta := txn.TxnMeta.(*RedeemNFTVoucherMetadata)
+			metadataRedeemNFTVouchers = append(metadataRedeemNFTVouchers, &PGMetadataRedeemNFTVoucher{
+				TransactionHash:  txnHash,
+				NFTPostHash:      txMeta.Voucher.NFTPostHash,
+				SerialNumber:     txMeta.Voucher.SerialNumber,
+				PriceNanos:       txMeta.Voucher.PriceNanos,
+				CreatorSignature: txMeta.CreatorSignature,
+			})
+		} else if txn.TxnMeta.GetTxnType() == TxnTypeRegisterBlockProducer {
+			txMeta := txn.TxnMeta.(*RegisterBlockProducerMetadata)
+			metadataRegisterBlockProducers = append(metadataRegisterBlockProducers, &PGMetadataRegisterBlockProducer{
+				TransactionHash: txnHash,
+				BondNanos:       txMeta.BondNanos,
+			})
+		} else if txn.TxnMeta.GetTxnType() == TxnTypeUsernameListing {
+			txMeta := txn.TxnMeta.(*UsernameListingMetadata)
+			metadataUsernameListings = append(metadataUsernameListings, &PGMetadataUsernameListing{
+				TransactionHash:     txnHash,
+				Username:            string(txMeta.Username),
+				ReplacementUsername: string(txMeta.ReplacementUsername),
+				RecipientPublicKey:  txMeta.RecipientPublicKey,
+				PriceNanos:          txMeta.PriceNanos,
+				IsRemoved:           txMeta.IsRemoved,
+			})
+		} else if txn.TxnMeta.GetTxnType() == TxnTypeAcceptUsernameListing {
+			txMeta := txn.TxnMeta.(*AcceptUsernameListingMetadata)
+			metadataAcceptUsernameListings = append(metadataAcceptUsernameListings, &PGMetadataAcceptUsernameListing{
+				TransactionHash: txnHash,
+				Username:        string(txMeta.Username),
+				PriceNanos:      txMeta.PriceNanos,
+			})
+		} else if txn.TxnMeta.GetTxnType() == TxnTypeKeyRotation {
+			txMeta := txn.TxnMeta.(*KeyRotationMetadata)
+			metadataKeyRotations = append(metadataKeyRotations, &PGMetadataKeyRotation{
+				TransactionHash: txnHash,
+				OperationType:   txMeta.OperationType,
+				NewPublicKey:    txMeta.NewPublicKey,
+				TimelockBlocks:  txMeta.TimelockBlocks,
+			})
+		} else if txn.TxnMeta.GetTxnType() == TxnTypeRecoveryGuardians {
+			txMeta := txn.TxnMeta.(*RecoveryGuardiansMetadata)
+			metadataRecoveryGuardians = append(metadataRecoveryGuardians, &PGMetadataRecoveryGuardians{
+				TransactionHash:    txnHash,
+				GuardianPublicKeys: txMeta.GuardianPublicKeys,
+				Threshold:          txMeta.Threshold,
+				DelayBlocks:        txMeta.DelayBlocks,
+			})
+		} else if txn.TxnMeta.GetTxnType() == TxnTypeAccountRecovery {
+			txMeta := txn.TxnMeta.(*AccountRecoveryMetadata)
+			metadataAccountRecoveries = append(metadataAccountRecoveries, &PGMetadataAccountRecovery{
+				TransactionHash: txnHash,
+				OperationType:   txMeta.OperationType,
+				OwnerPublicKey:  txMeta.OwnerPublicKey,
+				NewPublicKey:    txMeta.NewPublicKey,
+			})
 
 		} else if txn.TxnMeta.GetTxnType() == TxnTypeMessagingGroup {
 
 			// FIXME: Skip PGMetadataMessagingGroup for now since it's not used downstream
 
+		} else if txn.TxnMeta.GetTxnType() == TxnTypeMessagingGroupUpdate {
+
+			// Like MessagingGroup, updates are only reflected in pg_messaging_group for now.
+
 		} else {
 			return fmt.Errorf("InsertTransactionTx: Unimplemented txn type %v", txn.TxnMeta.GetTxnType().String())
 		}
@@ -1296,6 +1961,78 @@ func (postgres *Postgres) InsertTransactionsTx(tx *pg.Tx, desoTxns []*MsgDeSoTxn
 		}
 	}
 
+	if len(metadataPollVotes) > 0 {
+		if _, err := tx.Model(&metadataPollVotes).Returning("NULL").Insert(); err != nil {
+			return err
+		}
+	}
+
+	if len(metadataUserBlocks) > 0 {
+		if _, err := tx.Model(&metadataUserBlocks).Returning("NULL").Insert(); err != nil {
+			return err
+		}
+	}
+
+	if len(metadataMessageReads) > 0 {
+		if _, err := tx.Model(&metadataMessageReads).Returning("NULL").Insert(); err != nil {
+			return err
+		}
+	}
+
+	if len(metadataCreateNFTCollections) > 0 {
+		if _, err := tx.Model(&metadataCreateNFTCollections).Returning("NULL").Insert(); err != nil {
+			return err
+		}
+	}
+
+	if len(metadataNFTVaults) > 0 {
+		if _, err := tx.Model(&metadataNFTVaults).Returning("NULL").Insert(); err != nil {
+			return err
+		}
+	}
+
+	if len(metadataRedeemNFTVouchers) > 0 {
+		if _, err := tx.Model(&metadataRedeemNFTVouchers).Returning("NULL").Insert(); err != nil {
+			return err
+		}
+	}
+
+	if len(metadataRegisterBlockProducers) > 0 {
+		if _, err := tx.Model(&metadataRegisterBlockProducers).Returning("NULL").Insert(); err != nil {
+			return err
+		}
+	}
+
+	if len(metadataUsernameListings) > 0 {
+		if _, err := tx.Model(&metadataUsernameListings).Returning("NULL").Insert(); err != nil {
+			return err
+		}
+	}
+
+	if len(metadataAcceptUsernameListings) > 0 {
+		if _, err := tx.Model(&metadataAcceptUsernameListings).Returning("NULL").Insert(); err != nil {
+			return err
+		}
+	}
+
+	if len(metadataKeyRotations) > 0 {
+		if _, err := tx.Model(&metadataKeyRotations).Returning("NULL").Insert(); err != nil {
+			return err
+		}
+	}
+
+	if len(metadataRecoveryGuardians) > 0 {
+		if _, err := tx.Model(&metadataRecoveryGuardians).Returning("NULL").Insert(); err != nil {
+			return err
+		}
+	}
+
+	if len(metadataAccountRecoveries) > 0 {
+		if _, err := tx.Model(&metadataAccountRecoveries).Returning("NULL").Insert(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -1327,60 +2064,113 @@ func (postgres *Postgres) UpsertBlockAndTransactions(blockNode *BlockNode, desoB
 
 func (postgres *Postgres) FlushView(view *UtxoView) error {
 	return postgres.db.RunInTransaction(postgres.db.Context(), func(tx *pg.Tx) error {
-		if err := postgres.flushUtxos(tx, view); err != nil {
+		changeLog, err := newPGChangeLogWriter(tx, view)
+		if err != nil {
 			return err
 		}
-		if err := postgres.flushProfiles(tx, view); err != nil {
+
+		if err := postgres.flushUtxos(tx, view, changeLog); err != nil {
 			return err
 		}
-		if err := postgres.flushPosts(tx, view); err != nil {
+		if err := postgres.flushProfiles(tx, view, changeLog); err != nil {
 			return err
 		}
-		if err := postgres.flushLikes(tx, view); err != nil {
+		if err := postgres.flushPosts(tx, view, changeLog); err != nil {
 			return err
 		}
-		if err := postgres.flushFollows(tx, view); err != nil {
+		if err := postgres.flushPostVersions(tx, view, changeLog); err != nil {
 			return err
 		}
-		if err := postgres.flushDiamonds(tx, view); err != nil {
+		if err := postgres.flushPolls(tx, view, changeLog); err != nil {
 			return err
 		}
-		if err := postgres.flushMessages(tx, view); err != nil {
+		if err := postgres.flushPollVotes(tx, view, changeLog); err != nil {
 			return err
 		}
-		if err := postgres.flushCreatorCoinBalances(tx, view); err != nil {
+		if err := postgres.flushLikes(tx, view, changeLog); err != nil {
 			return err
 		}
-		if err := postgres.flushDAOCoinBalances(tx, view); err != nil {
+		if err := postgres.flushFollows(tx, view, changeLog); err != nil {
 			return err
 		}
-		if err := postgres.flushBalances(tx, view); err != nil {
+		if err := postgres.flushUserBlocks(tx, view, changeLog); err != nil {
 			return err
 		}
-		if err := postgres.flushForbiddenKeys(tx, view); err != nil {
+		if err := postgres.flushDiamonds(tx, view, changeLog); err != nil {
 			return err
 		}
-		if err := postgres.flushNFTs(tx, view); err != nil {
+		if err := postgres.flushMessages(tx, view, changeLog); err != nil {
 			return err
 		}
-		if err := postgres.flushNFTBids(tx, view); err != nil {
+		if err := postgres.flushMessageReads(tx, view, changeLog); err != nil {
 			return err
 		}
-		if err := postgres.flushDerivedKeys(tx, view); err != nil {
+		if err := postgres.flushMessagingGroups(tx, view, changeLog); err != nil {
 			return err
 		}
-
-		return nil
-	})
-}
-
-func (postgres *Postgres) flushUtxos(tx *pg.Tx, view *UtxoView) error {
-	var outputs []*PGTransactionOutput
-	for utxoKeyIter, utxoEntry := range view.UtxoKeyToUtxoEntry {
-		// Making a copy of the iterator is required
-		utxoKey := utxoKeyIter
-		outputs = append(outputs, &PGTransactionOutput{
-			OutputHash:  &utxoKey.TxID,
+		if err := postgres.flushCreatorCoinBalances(tx, view, changeLog); err != nil {
+			return err
+		}
+		if err := postgres.flushDAOCoinBalances(tx, view, changeLog); err != nil {
+			return err
+		}
+		if err := postgres.flushCreatorCoinPriceCandles(tx, view, changeLog); err != nil {
+			return err
+		}
+		if err := postgres.flushBalances(tx, view, changeLog); err != nil {
+			return err
+		}
+		if err := postgres.flushForbiddenKeys(tx, view, changeLog); err != nil {
+			return err
+		}
+		if err := postgres.flushNFTs(tx, view, changeLog); err != nil {
+			return err
+		}
+		if err := postgres.flushNFTCollections(tx, view, changeLog); err != nil {
+			return err
+		}
+		if err := postgres.flushNFTVoucherRedemptions(tx, view, changeLog); err != nil {
+			return err
+		}
+		if err := postgres.flushBlockProducers(tx, view, changeLog); err != nil {
+			return err
+		}
+		if err := postgres.flushUsernameListings(tx, view, changeLog); err != nil {
+			return err
+		}
+		if err := postgres.flushPendingKeyRotations(tx, view, changeLog); err != nil {
+			return err
+		}
+		if err := postgres.flushKeyRotationTimelocks(tx, view, changeLog); err != nil {
+			return err
+		}
+		if err := postgres.flushRotatedPublicKeys(tx, view, changeLog); err != nil {
+			return err
+		}
+		if err := postgres.flushRecoveryGuardianSets(tx, view, changeLog); err != nil {
+			return err
+		}
+		if err := postgres.flushPendingAccountRecoveries(tx, view, changeLog); err != nil {
+			return err
+		}
+		if err := postgres.flushNFTBids(tx, view, changeLog); err != nil {
+			return err
+		}
+		if err := postgres.flushDerivedKeys(tx, view, changeLog); err != nil {
+			return err
+		}
+
+		return changeLog.flush(tx)
+	})
+}
+
+func (postgres *Postgres) flushUtxos(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var outputs []*PGTransactionOutput
+	for utxoKeyIter, utxoEntry := range view.UtxoKeyToUtxoEntry {
+		// Making a copy of the iterator is required
+		utxoKey := utxoKeyIter
+		outputs = append(outputs, &PGTransactionOutput{
+			OutputHash:  &utxoKey.TxID,
 			OutputIndex: utxoKey.Index,
 			OutputType:  utxoEntry.UtxoType,
 			PublicKey:   utxoEntry.PublicKey,
@@ -1389,6 +2179,10 @@ func (postgres *Postgres) flushUtxos(tx *pg.Tx, view *UtxoView) error {
 		})
 	}
 
+	if err := changeLog.recordChanges(tx, &outputs, nil); err != nil {
+		return err
+	}
+
 	_, err := tx.Model(&outputs).WherePK().OnConflict("(output_hash, output_index) DO UPDATE").Insert()
 	if err != nil {
 		return err
@@ -1397,7 +2191,7 @@ func (postgres *Postgres) flushUtxos(tx *pg.Tx, view *UtxoView) error {
 	return nil
 }
 
-func (postgres *Postgres) flushProfiles(tx *pg.Tx, view *UtxoView) error {
+func (postgres *Postgres) flushProfiles(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
 	var insertProfiles []*PGProfile
 	var deleteProfiles []*PGProfile
 	for _, pkidEntry := range view.PublicKeyToPKIDEntry {
@@ -1431,6 +2225,10 @@ func (postgres *Postgres) flushProfiles(tx *pg.Tx, view *UtxoView) error {
 		}
 	}
 
+	if err := changeLog.recordChanges(tx, &insertProfiles, &deleteProfiles); err != nil {
+		return err
+	}
+
 	if len(insertProfiles) > 0 {
 		_, err := tx.Model(&insertProfiles).WherePK().OnConflict("(pkid) DO UPDATE").Returning("NULL").Insert()
 		if err != nil {
@@ -1448,7 +2246,7 @@ func (postgres *Postgres) flushProfiles(tx *pg.Tx, view *UtxoView) error {
 	return nil
 }
 
-func (postgres *Postgres) flushPosts(tx *pg.Tx, view *UtxoView) error {
+func (postgres *Postgres) flushPosts(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
 	var insertPosts []*PGPost
 	var deletePosts []*PGPost
 	for _, postEntry := range view.PostHashToPostEntry {
@@ -1477,6 +2275,8 @@ func (postgres *Postgres) flushPosts(tx *pg.Tx, view *UtxoView) error {
 			Unlockable:                postEntry.HasUnlockable,
 			CreatorRoyaltyBasisPoints: postEntry.NFTRoyaltyToCreatorBasisPoints,
 			CoinRoyaltyBasisPoints:    postEntry.NFTRoyaltyToCoinBasisPoints,
+			NFTCollectionID:           postEntry.NFTCollectionID,
+			LazyMintedNFT:             postEntry.IsLazyMintedNFT,
 			ExtraData:                 postEntry.PostExtraData,
 		}
 
@@ -1507,6 +2307,10 @@ func (postgres *Postgres) flushPosts(tx *pg.Tx, view *UtxoView) error {
 		}
 	}
 
+	if err := changeLog.recordChanges(tx, &insertPosts, &deletePosts); err != nil {
+		return err
+	}
+
 	if len(insertPosts) > 0 {
 		_, err := tx.Model(&insertPosts).WherePK().OnConflict("(post_hash) DO UPDATE").Returning("NULL").Insert()
 		if err != nil {
@@ -1524,7 +2328,141 @@ func (postgres *Postgres) flushPosts(tx *pg.Tx, view *UtxoView) error {
 	return nil
 }
 
-func (postgres *Postgres) flushLikes(tx *pg.Tx, view *UtxoView) error {
+func (postgres *Postgres) flushPostVersions(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertVersions []*PGPostVersion
+	var deleteVersions []*PGPostVersion
+	for _, versionEntry := range view.PostVersionKeyToPostVersionEntry {
+		version := &PGPostVersion{
+			PostHash:       versionEntry.PostHash,
+			Version:        versionEntry.Version,
+			Body:           string(versionEntry.Body),
+			ExtraData:      versionEntry.PostExtraData,
+			Hidden:         versionEntry.IsHidden,
+			TimestampNanos: versionEntry.TimestampNanos,
+			BlockHeight:    versionEntry.BlockHeight,
+		}
+
+		if versionEntry.isDeleted {
+			deleteVersions = append(deleteVersions, version)
+		} else {
+			insertVersions = append(insertVersions, version)
+		}
+	}
+
+	if err := changeLog.recordChanges(tx, &insertVersions, &deleteVersions); err != nil {
+		return err
+	}
+
+	if len(insertVersions) > 0 {
+		_, err := tx.Model(&insertVersions).WherePK().OnConflict("(post_hash, version) DO UPDATE").Returning("NULL").Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(deleteVersions) > 0 {
+		_, err := tx.Model(&deleteVersions).Returning("NULL").Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (postgres *Postgres) flushPolls(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertPolls []*PGPoll
+	var deletePolls []*PGPoll
+	for _, pollEntry := range view.PostHashToPollEntry {
+		options := make([]string, len(pollEntry.Options))
+		for ii, option := range pollEntry.Options {
+			options[ii] = string(option)
+		}
+		optionTallies := make([]string, len(pollEntry.OptionTallies))
+		for ii := range pollEntry.OptionTallies {
+			optionTallies[ii] = pollEntry.OptionTallies[ii].Hex()
+		}
+
+		poll := &PGPoll{
+			PostHash:           pollEntry.PostHash,
+			PosterPKID:         pollEntry.PosterPKID,
+			Question:           string(pollEntry.Question),
+			Options:            options,
+			ClosingBlockHeight: pollEntry.ClosingBlockHeight,
+			WeightType:         pollEntry.WeightType,
+			OptionTallies:      optionTallies,
+			NumVotes:           pollEntry.NumVotes,
+		}
+
+		if pollEntry.isDeleted {
+			deletePolls = append(deletePolls, poll)
+		} else {
+			insertPolls = append(insertPolls, poll)
+		}
+	}
+
+	if err := changeLog.recordChanges(tx, &insertPolls, &deletePolls); err != nil {
+		return err
+	}
+
+	if len(insertPolls) > 0 {
+		_, err := tx.Model(&insertPolls).WherePK().OnConflict("(post_hash) DO UPDATE").Returning("NULL").Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(deletePolls) > 0 {
+		_, err := tx.Model(&deletePolls).Returning("NULL").Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (postgres *Postgres) flushPollVotes(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertVotes []*PGPollVote
+	var deleteVotes []*PGPollVote
+	for _, voteEntry := range view.PollVoteKeyToPollVoteEntry {
+		vote := &PGPollVote{
+			PostHash:    voteEntry.PostHash,
+			VoterPKID:   voteEntry.VoterPKID,
+			OptionIndex: voteEntry.OptionIndex,
+			Weight:      voteEntry.Weight.Hex(),
+			BlockHeight: voteEntry.BlockHeight,
+		}
+
+		if voteEntry.isDeleted {
+			deleteVotes = append(deleteVotes, vote)
+		} else {
+			insertVotes = append(insertVotes, vote)
+		}
+	}
+
+	if err := changeLog.recordChanges(tx, &insertVotes, &deleteVotes); err != nil {
+		return err
+	}
+
+	if len(insertVotes) > 0 {
+		_, err := tx.Model(&insertVotes).WherePK().OnConflict("(post_hash, voter_pkid) DO UPDATE").Returning("NULL").Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(deleteVotes) > 0 {
+		_, err := tx.Model(&deleteVotes).Returning("NULL").Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (postgres *Postgres) flushLikes(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
 	var insertLikes []*PGLike
 	var deleteLikes []*PGLike
 	for _, likeEntry := range view.LikeKeyToLikeEntry {
@@ -1544,6 +2482,10 @@ func (postgres *Postgres) flushLikes(tx *pg.Tx, view *UtxoView) error {
 		}
 	}
 
+	if err := changeLog.recordChanges(tx, &insertLikes, &deleteLikes); err != nil {
+		return err
+	}
+
 	if len(insertLikes) > 0 {
 		_, err := tx.Model(&insertLikes).WherePK().OnConflict("DO NOTHING").Returning("NULL").Insert()
 		if err != nil {
@@ -1561,7 +2503,7 @@ func (postgres *Postgres) flushLikes(tx *pg.Tx, view *UtxoView) error {
 	return nil
 }
 
-func (postgres *Postgres) flushFollows(tx *pg.Tx, view *UtxoView) error {
+func (postgres *Postgres) flushFollows(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
 	var insertFollows []*PGFollow
 	var deleteFollows []*PGFollow
 	for _, followEntry := range view.FollowKeyToFollowEntry {
@@ -1581,6 +2523,10 @@ func (postgres *Postgres) flushFollows(tx *pg.Tx, view *UtxoView) error {
 		}
 	}
 
+	if err := changeLog.recordChanges(tx, &insertFollows, &deleteFollows); err != nil {
+		return err
+	}
+
 	if len(insertFollows) > 0 {
 		_, err := tx.Model(&insertFollows).WherePK().OnConflict("DO NOTHING").Returning("NULL").Insert()
 		if err != nil {
@@ -1598,7 +2544,45 @@ func (postgres *Postgres) flushFollows(tx *pg.Tx, view *UtxoView) error {
 	return nil
 }
 
-func (postgres *Postgres) flushDiamonds(tx *pg.Tx, view *UtxoView) error {
+func (postgres *Postgres) flushUserBlocks(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertUserBlocks []*PGUserBlock
+	var deleteUserBlocks []*PGUserBlock
+	for _, userBlockEntry := range view.UserBlockKeyToUserBlockEntry {
+		userBlock := &PGUserBlock{
+			BlockerPKID: userBlockEntry.BlockerPKID,
+			BlockedPKID: userBlockEntry.BlockedPKID,
+			BlockType:   userBlockEntry.BlockType,
+		}
+
+		if userBlockEntry.isDeleted {
+			deleteUserBlocks = append(deleteUserBlocks, userBlock)
+		} else {
+			insertUserBlocks = append(insertUserBlocks, userBlock)
+		}
+	}
+
+	if err := changeLog.recordChanges(tx, &insertUserBlocks, &deleteUserBlocks); err != nil {
+		return err
+	}
+
+	if len(insertUserBlocks) > 0 {
+		_, err := tx.Model(&insertUserBlocks).WherePK().OnConflict("(blocker_pkid, blocked_pkid) DO UPDATE").Returning("NULL").Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(deleteUserBlocks) > 0 {
+		_, err := tx.Model(&deleteUserBlocks).Returning("NULL").Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (postgres *Postgres) flushDiamonds(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
 	var insertDiamonds []*PGDiamond
 	var deleteDiamonds []*PGDiamond
 	for _, diamondEntry := range view.DiamondKeyToDiamondEntry {
@@ -1616,6 +2600,10 @@ func (postgres *Postgres) flushDiamonds(tx *pg.Tx, view *UtxoView) error {
 		}
 	}
 
+	if err := changeLog.recordChanges(tx, &insertDiamonds, &deleteDiamonds); err != nil {
+		return err
+	}
+
 	if len(insertDiamonds) > 0 {
 		_, err := tx.Model(&insertDiamonds).WherePK().OnConflict("(sender_pkid, receiver_pkid, diamond_post_hash) DO UPDATE").Returning("NULL").Insert()
 		if err != nil {
@@ -1633,7 +2621,7 @@ func (postgres *Postgres) flushDiamonds(tx *pg.Tx, view *UtxoView) error {
 	return nil
 }
 
-func (postgres *Postgres) flushMessages(tx *pg.Tx, view *UtxoView) error {
+func (postgres *Postgres) flushMessages(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
 	var insertMessages []*PGMessage
 	var deleteMessages []*PGMessage
 	for _, message := range view.MessageMap {
@@ -1644,16 +2632,482 @@ func (postgres *Postgres) flushMessages(tx *pg.Tx, view *UtxoView) error {
 		}
 	}
 
-	if len(insertMessages) > 0 {
-		// TODO: There should never be a conflict here. Should we raise an error?
-		_, err := tx.Model(&insertMessages).WherePK().OnConflict("(message_hash) DO NOTHING").Returning("NULL").Insert()
+	if err := changeLog.recordChanges(tx, &insertMessages, &deleteMessages); err != nil {
+		return err
+	}
+
+	if len(insertMessages) > 0 {
+		// TODO: There should never be a conflict here. Should we raise an error?
+		_, err := tx.Model(&insertMessages).WherePK().OnConflict("(message_hash) DO NOTHING").Returning("NULL").Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(deleteMessages) > 0 {
+		_, err := tx.Model(&deleteMessages).Returning("NULL").Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (postgres *Postgres) flushMessageReads(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertMessageReads []*PGMessageRead
+	var deleteMessageReads []*PGMessageRead
+	for _, messageReadEntry := range view.MessageReadKeyToMessageReadEntry {
+		messageRead := &PGMessageRead{
+			ReaderPublicKey:     messageReadEntry.ReaderPublicKey,
+			ThreadPublicKey:     messageReadEntry.ThreadPublicKey,
+			LastReadTstampNanos: messageReadEntry.LastReadTstampNanos,
+		}
+
+		if messageReadEntry.isDeleted {
+			deleteMessageReads = append(deleteMessageReads, messageRead)
+		} else {
+			insertMessageReads = append(insertMessageReads, messageRead)
+		}
+	}
+
+	if err := changeLog.recordChanges(tx, &insertMessageReads, &deleteMessageReads); err != nil {
+		return err
+	}
+
+	if len(insertMessageReads) > 0 {
+		_, err := tx.Model(&insertMessageReads).WherePK().OnConflict("(reader_public_key, thread_public_key) DO UPDATE").Returning("NULL").Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(deleteMessageReads) > 0 {
+		_, err := tx.Model(&deleteMessageReads).Returning("NULL").Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (postgres *Postgres) flushMessagingGroups(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertMessages []*PGMessagingGroup
+	var deleteMessages []*PGMessagingGroup
+	for groupKey, groupEntry := range view.MessagingGroupKeyToMessagingGroupEntry {
+		messagingGroupMembersBytes := bytes.NewBuffer([]byte{})
+		gob.NewEncoder(messagingGroupMembersBytes).Encode(groupEntry.MessagingGroupMembers)
+		messagingGroupAdminsBytes := bytes.NewBuffer([]byte{})
+		gob.NewEncoder(messagingGroupAdminsBytes).Encode(groupEntry.MessagingGroupAdmins)
+		// Rows are keyed by the view's key, like the badger flush, so that a group
+		// moved to a new owner by a key rotation is deleted under its old owner.
+		ownerPublicKey := groupKey.OwnerPublicKey
+		pgGroupEntry := &PGMessagingGroup{
+			GroupOwnerPublicKey: &ownerPublicKey,
+			MessagingPublicKey: groupEntry.MessagingPublicKey,
+			MessagingGroupKeyName: groupEntry.MessagingGroupKeyName,
+			MessagingGroupMembers: messagingGroupMembersBytes.Bytes(),
+			MessagingGroupAdmins: messagingGroupAdminsBytes.Bytes(),
+		}
+		if groupEntry.isDeleted {
+			deleteMessages = append(deleteMessages, pgGroupEntry)
+		} else {
+			insertMessages = append(insertMessages, pgGroupEntry)
+		}
+	}
+
+	if err := changeLog.recordChanges(tx, &insertMessages, &deleteMessages); err != nil {
+		return err
+	}
+
+	if len(insertMessages) > 0 {
+		// TODO: There should never be a conflict here. Should we raise an error?
+		_, err := tx.Model(&insertMessages).WherePK().OnConflict(
+			"(group_owner_public_key, messaging_group_key_name) DO UPDATE").Returning("NULL").Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(deleteMessages) > 0 {
+		_, err := tx.Model(&deleteMessages).Returning("NULL").Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (postgres *Postgres) flushCreatorCoinBalances(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertBalances []*PGCreatorCoinBalance
+	var deleteBalances []*PGCreatorCoinBalance
+	for _, balanceEntry := range view.HODLerPKIDCreatorPKIDToBalanceEntry {
+		if balanceEntry == nil {
+			continue
+		}
+
+		balance := &PGCreatorCoinBalance{
+			HolderPKID:  balanceEntry.HODLerPKID,
+			CreatorPKID: balanceEntry.CreatorPKID,
+			// FIXME: This will break if the value exceeds uint256
+			BalanceNanos: balanceEntry.BalanceNanos.Uint64(),
+			HasPurchased: balanceEntry.HasPurchased,
+		}
+
+		if balanceEntry.isDeleted {
+			deleteBalances = append(deleteBalances, balance)
+		} else {
+			insertBalances = append(insertBalances, balance)
+		}
+	}
+
+	if err := changeLog.recordChanges(tx, &insertBalances, &deleteBalances); err != nil {
+		return err
+	}
+
+	if len(insertBalances) > 0 {
+		_, err := tx.Model(&insertBalances).WherePK().OnConflict("(holder_pkid, creator_pkid) DO UPDATE").Returning("NULL").Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(deleteBalances) > 0 {
+		_, err := tx.Model(&deleteBalances).Returning("NULL").Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (postgres *Postgres) flushDAOCoinBalances(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertBalances []*PGDAOCoinBalance
+	var deleteBalances []*PGDAOCoinBalance
+	for _, balanceEntry := range view.HODLerPKIDCreatorPKIDToDAOCoinBalanceEntry {
+		if balanceEntry == nil {
+			continue
+		}
+
+		balance := &PGDAOCoinBalance{
+			HolderPKID:  balanceEntry.HODLerPKID,
+			CreatorPKID: balanceEntry.CreatorPKID,
+			BalanceNanos: balanceEntry.BalanceNanos.Hex(),
+			HasPurchased: balanceEntry.HasPurchased,
+		}
+
+		if balanceEntry.isDeleted {
+			deleteBalances = append(deleteBalances, balance)
+		} else {
+			insertBalances = append(insertBalances, balance)
+		}
+	}
+
+	if err := changeLog.recordChanges(tx, &insertBalances, &deleteBalances); err != nil {
+		return err
+	}
+
+	if len(insertBalances) > 0 {
+		_, err := tx.Model(&insertBalances).WherePK().OnConflict("(holder_pkid, creator_pkid) DO UPDATE").Returning("NULL").Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(deleteBalances) > 0 {
+		_, err := tx.Model(&deleteBalances).Returning("NULL").Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (postgres *Postgres) flushCreatorCoinPriceCandles(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertCandles []*PGCreatorCoinPriceCandle
+	var deleteCandles []*PGCreatorCoinPriceCandle
+	for _, candleEntry := range view.CreatorCoinPriceCandleKeyToCandleEntry {
+		candle := &PGCreatorCoinPriceCandle{
+			CreatorPKID:     candleEntry.CreatorPKID,
+			BlockHeight:     candleEntry.StartBlockHeight,
+			OpenPriceNanos:  candleEntry.OpenPriceNanos,
+			HighPriceNanos:  candleEntry.HighPriceNanos,
+			LowPriceNanos:   candleEntry.LowPriceNanos,
+			ClosePriceNanos: candleEntry.ClosePriceNanos,
+			VolumeNanos:     candleEntry.VolumeNanos,
+			NumTrades:       candleEntry.NumTrades,
+		}
+
+		if candleEntry.isDeleted {
+			deleteCandles = append(deleteCandles, candle)
+		} else {
+			insertCandles = append(insertCandles, candle)
+		}
+	}
+
+	if err := changeLog.recordChanges(tx, &insertCandles, &deleteCandles); err != nil {
+		return err
+	}
+
+	if len(insertCandles) > 0 {
+		_, err := tx.Model(&insertCandles).WherePK().OnConflict("(creator_pkid, block_height) DO UPDATE").Returning("NULL").Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(deleteCandles) > 0 {
+		_, err := tx.Model(&deleteCandles).Returning("NULL").Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (postgres *Postgres) flushBalances(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var balances []*PGBalance
+	for pubKeyIter, balanceNanos := range view.PublicKeyToDeSoBalanceNanos {
+		// Make a copy of the iterator since it might change from under us.
+		pubKey := pubKeyIter[:]
+
+		balance := &PGBalance{
+			PublicKey:    NewPublicKey(pubKey),
+			BalanceNanos: balanceNanos,
+		}
+
+		balances = append(balances, balance)
+	}
+
+	if err := changeLog.recordChanges(tx, &balances, nil); err != nil {
+		return err
+	}
+
+	if len(balances) > 0 {
+		_, err := tx.Model(&balances).WherePK().OnConflict("(public_key) DO UPDATE").Returning("NULL").Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (postgres *Postgres) flushForbiddenKeys(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertKeys []*PGForbiddenKey
+	var deleteKeys []*PGForbiddenKey
+	for _, keyEntry := range view.ForbiddenPubKeyToForbiddenPubKeyEntry {
+		balance := &PGForbiddenKey{
+			PublicKey: NewPublicKey(keyEntry.PubKey),
+		}
+
+		if keyEntry.isDeleted {
+			deleteKeys = append(deleteKeys, balance)
+		} else {
+			insertKeys = append(insertKeys, balance)
+		}
+	}
+
+	if err := changeLog.recordChanges(tx, &insertKeys, &deleteKeys); err != nil {
+		return err
+	}
+
+	if len(insertKeys) > 0 {
+		_, err := tx.Model(&insertKeys).WherePK().OnConflict("(public_key) DO UPDATE").Returning("NULL").Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(deleteKeys) > 0 {
+		_, err := tx.Model(&deleteKeys).Returning("NULL").Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (postgres *Postgres) flushNFTCollections(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertNFTCollections []*PGNFTCollection
+	var deleteNFTCollections []*PGNFTCollection
+	for _, nftCollectionEntry := range view.NFTCollectionIDToNFTCollectionEntry {
+		nftCollection := &PGNFTCollection{
+			CollectionID:              nftCollectionEntry.CollectionID,
+			CreatorPKID:               nftCollectionEntry.CreatorPKID,
+			Name:                      string(nftCollectionEntry.Name),
+			MaxSupply:                 nftCollectionEntry.MaxSupply,
+			NumNFTCopies:              nftCollectionEntry.NumNFTCopies,
+			CreatorRoyaltyBasisPoints: nftCollectionEntry.NFTRoyaltyToCreatorBasisPoints,
+			CoinRoyaltyBasisPoints:    nftCollectionEntry.NFTRoyaltyToCoinBasisPoints,
+		}
+
+		if len(nftCollectionEntry.AdditionalNFTRoyaltiesToCoinsBasisPoints) > 0 {
+			nftCollection.AdditionalNFTRoyaltiesToCoinsBasisPoints = make(map[string]uint64)
+			for pkid, bps := range nftCollectionEntry.AdditionalNFTRoyaltiesToCoinsBasisPoints {
+				pkidHexString := hex.EncodeToString(pkid[:])
+				nftCollection.AdditionalNFTRoyaltiesToCoinsBasisPoints[pkidHexString] = bps
+			}
+		}
+
+		if len(nftCollectionEntry.AdditionalNFTRoyaltiesToCreatorsBasisPoints) > 0 {
+			nftCollection.AdditionalNFTRoyaltiesToCreatorsBasisPoints = make(map[string]uint64)
+			for pkid, bps := range nftCollectionEntry.AdditionalNFTRoyaltiesToCreatorsBasisPoints {
+				pkidHexString := hex.EncodeToString(pkid[:])
+				nftCollection.AdditionalNFTRoyaltiesToCreatorsBasisPoints[pkidHexString] = bps
+			}
+		}
+
+		if nftCollectionEntry.isDeleted {
+			deleteNFTCollections = append(deleteNFTCollections, nftCollection)
+		} else {
+			insertNFTCollections = append(insertNFTCollections, nftCollection)
+		}
+	}
+
+	if err := changeLog.recordChanges(tx, &insertNFTCollections, &deleteNFTCollections); err != nil {
+		return err
+	}
+
+	if len(insertNFTCollections) > 0 {
+		_, err := tx.Model(&insertNFTCollections).WherePK().OnConflict("(collection_id) DO UPDATE").Returning("NULL").Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(deleteNFTCollections) > 0 {
+		_, err := tx.Model(&deleteNFTCollections).Returning("NULL").Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (postgres *Postgres) flushNFTVoucherRedemptions(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertRedemptions []*PGNFTVoucherRedemption
+	var deleteRedemptions []*PGNFTVoucherRedemption
+	for _, redemptionEntry := range view.NFTKeyToNFTVoucherRedemptionEntry {
+		redemption := &PGNFTVoucherRedemption{
+			NFTPostHash:  redemptionEntry.NFTPostHash,
+			SerialNumber: redemptionEntry.SerialNumber,
+			RedeemerPKID: redemptionEntry.RedeemerPKID,
+			PriceNanos:   redemptionEntry.PriceNanos,
+		}
+
+		if redemptionEntry.isDeleted {
+			deleteRedemptions = append(deleteRedemptions, redemption)
+		} else {
+			insertRedemptions = append(insertRedemptions, redemption)
+		}
+	}
+
+	if err := changeLog.recordChanges(tx, &insertRedemptions, &deleteRedemptions); err != nil {
+		return err
+	}
+
+	if len(insertRedemptions) > 0 {
+		_, err := tx.Model(&insertRedemptions).WherePK().OnConflict("(nft_post_hash, serial_number) DO UPDATE").Returning("NULL").Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(deleteRedemptions) > 0 {
+		_, err := tx.Model(&deleteRedemptions).Returning("NULL").Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (postgres *Postgres) flushBlockProducers(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertBlockProducers []*PGBlockProducer
+	var deleteBlockProducers []*PGBlockProducer
+	for _, blockProducerEntry := range view.PublicKeyToBlockProducerEntry {
+		blockProducer := &PGBlockProducer{
+			PublicKey:             blockProducerEntry.PublicKey,
+			BondNanos:             blockProducerEntry.BondNanos,
+			IsRegistered:          blockProducerEntry.IsRegistered,
+			RegisteredBlockHeight: blockProducerEntry.RegisteredBlockHeight,
+			UnbondingNanos:        blockProducerEntry.UnbondingNanos,
+			UnbondingBlockHeight:  blockProducerEntry.UnbondingBlockHeight,
+			NumBlocksProduced:     blockProducerEntry.NumBlocksProduced,
+			NumMissedSlots:        blockProducerEntry.NumMissedSlots,
+			LastMissedBlockHeight: blockProducerEntry.LastMissedBlockHeight,
+		}
+
+		if blockProducerEntry.isDeleted {
+			deleteBlockProducers = append(deleteBlockProducers, blockProducer)
+		} else {
+			insertBlockProducers = append(insertBlockProducers, blockProducer)
+		}
+	}
+
+	if err := changeLog.recordChanges(tx, &insertBlockProducers, &deleteBlockProducers); err != nil {
+		return err
+	}
+
+	if len(insertBlockProducers) > 0 {
+		_, err := tx.Model(&insertBlockProducers).WherePK().OnConflict("(public_key) DO UPDATE").Returning("NULL").Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(deleteBlockProducers) > 0 {
+		_, err := tx.Model(&deleteBlockProducers).Returning("NULL").Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (postgres *Postgres) flushUsernameListings(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertListings []*PGUsernameListing
+	var deleteListings []*PGUsernameListing
+	for _, listingEntry := range view.UsernameToUsernameListingEntry {
+		listing := &PGUsernameListing{
+			LowercaseUsername:   strings.ToLower(string(listingEntry.Username)),
+			Username:            string(listingEntry.Username),
+			ReplacementUsername: string(listingEntry.ReplacementUsername),
+			OwnerPKID:           listingEntry.OwnerPKID,
+			RecipientPKID:       listingEntry.RecipientPKID,
+			PriceNanos:          listingEntry.PriceNanos,
+		}
+
+		if listingEntry.isDeleted {
+			deleteListings = append(deleteListings, listing)
+		} else {
+			insertListings = append(insertListings, listing)
+		}
+	}
+
+	if err := changeLog.recordChanges(tx, &insertListings, &deleteListings); err != nil {
+		return err
+	}
+
+	if len(insertListings) > 0 {
+		_, err := tx.Model(&insertListings).WherePK().OnConflict("(lowercase_username) DO UPDATE").Returning("NULL").Insert()
 		if err != nil {
 			return err
 		}
 	}
 
-	if len(deleteMessages) > 0 {
-		_, err := tx.Model(&deleteMessages).Returning("NULL").Delete()
+	if len(deleteListings) > 0 {
+		_, err := tx.Model(&deleteListings).Returning("NULL").Delete()
 		if err != nil {
 			return err
 		}
@@ -1662,36 +3116,36 @@ func (postgres *Postgres) flushMessages(tx *pg.Tx, view *UtxoView) error {
 	return nil
 }
 
-func (postgres *Postgres) flushMessagingGroups(tx *pg.Tx, view *UtxoView) error {
-	var insertMessages []*PGMessagingGroup
-	var deleteMessages []*PGMessagingGroup
-	for _, groupEntry := range view.MessagingGroupKeyToMessagingGroupEntry {
-		messagingGroupMembersBytes := bytes.NewBuffer([]byte{})
-		gob.NewEncoder(messagingGroupMembersBytes).Encode(groupEntry.MessagingGroupMembers)
-		pgGroupEntry := &PGMessagingGroup{
-			GroupOwnerPublicKey: groupEntry.GroupOwnerPublicKey,
-			MessagingPublicKey: groupEntry.MessagingPublicKey,
-			MessagingGroupKeyName: groupEntry.MessagingGroupKeyName,
-			MessagingGroupMembers: messagingGroupMembersBytes.Bytes(),
+func (postgres *Postgres) flushPendingKeyRotations(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertKeyRotations []*PGPendingKeyRotation
+	var deleteKeyRotations []*PGPendingKeyRotation
+	for _, keyRotationEntry := range view.PublicKeyToKeyRotationEntry {
+		keyRotation := &PGPendingKeyRotation{
+			OldPublicKey:      keyRotationEntry.OldPublicKey,
+			NewPublicKey:      keyRotationEntry.NewPublicKey,
+			UnlockBlockHeight: keyRotationEntry.UnlockBlockHeight,
 		}
-		if groupEntry.isDeleted {
-			deleteMessages = append(deleteMessages, pgGroupEntry)
+
+		if keyRotationEntry.isDeleted {
+			deleteKeyRotations = append(deleteKeyRotations, keyRotation)
 		} else {
-			insertMessages = append(insertMessages, pgGroupEntry)
+			insertKeyRotations = append(insertKeyRotations, keyRotation)
 		}
 	}
 
-	if len(insertMessages) > 0 {
-		// TODO: There should never be a conflict here. Should we raise an error?
-		_, err := tx.Model(&insertMessages).WherePK().OnConflict(
-			"(group_owner_public_key, messaging_group_key_name) DO UPDATE").Returning("NULL").Insert()
+	if err := changeLog.recordChanges(tx, &insertKeyRotations, &deleteKeyRotations); err != nil {
+		return err
+	}
+
+	if len(insertKeyRotations) > 0 {
+		_, err := tx.Model(&insertKeyRotations).WherePK().OnConflict("(old_public_key) DO UPDATE").Returning("NULL").Insert()
 		if err != nil {
 			return err
 		}
 	}
 
-	if len(deleteMessages) > 0 {
-		_, err := tx.Model(&deleteMessages).Returning("NULL").Delete()
+	if len(deleteKeyRotations) > 0 {
+		_, err := tx.Model(&deleteKeyRotations).Returning("NULL").Delete()
 		if err != nil {
 			return err
 		}
@@ -1700,38 +3154,37 @@ func (postgres *Postgres) flushMessagingGroups(tx *pg.Tx, view *UtxoView) error
 	return nil
 }
 
-func (postgres *Postgres) flushCreatorCoinBalances(tx *pg.Tx, view *UtxoView) error {
-	var insertBalances []*PGCreatorCoinBalance
-	var deleteBalances []*PGCreatorCoinBalance
-	for _, balanceEntry := range view.HODLerPKIDCreatorPKIDToBalanceEntry {
-		if balanceEntry == nil {
-			continue
-		}
-
-		balance := &PGCreatorCoinBalance{
-			HolderPKID:  balanceEntry.HODLerPKID,
-			CreatorPKID: balanceEntry.CreatorPKID,
-			// FIXME: This will break if the value exceeds uint256
-			BalanceNanos: balanceEntry.BalanceNanos.Uint64(),
-			HasPurchased: balanceEntry.HasPurchased,
+func (postgres *Postgres) flushKeyRotationTimelocks(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertTimelocks []*PGKeyRotationTimelock
+	var deleteTimelocks []*PGKeyRotationTimelock
+	for _, timelockEntry := range view.PKIDToKeyRotationTimelockEntry {
+		timelock := &PGKeyRotationTimelock{
+			OwnerPKID:                timelockEntry.OwnerPKID,
+			MinTimelockBlocks:        timelockEntry.MinTimelockBlocks,
+			PendingMinTimelockBlocks: timelockEntry.PendingMinTimelockBlocks,
+			PendingUnlockBlockHeight: timelockEntry.PendingUnlockBlockHeight,
 		}
 
-		if balanceEntry.isDeleted {
-			deleteBalances = append(deleteBalances, balance)
+		if timelockEntry.isDeleted {
+			deleteTimelocks = append(deleteTimelocks, timelock)
 		} else {
-			insertBalances = append(insertBalances, balance)
+			insertTimelocks = append(insertTimelocks, timelock)
 		}
 	}
 
-	if len(insertBalances) > 0 {
-		_, err := tx.Model(&insertBalances).WherePK().OnConflict("(holder_pkid, creator_pkid) DO UPDATE").Returning("NULL").Insert()
+	if err := changeLog.recordChanges(tx, &insertTimelocks, &deleteTimelocks); err != nil {
+		return err
+	}
+
+	if len(insertTimelocks) > 0 {
+		_, err := tx.Model(&insertTimelocks).WherePK().OnConflict("(owner_pkid) DO UPDATE").Returning("NULL").Insert()
 		if err != nil {
 			return err
 		}
 	}
 
-	if len(deleteBalances) > 0 {
-		_, err := tx.Model(&deleteBalances).Returning("NULL").Delete()
+	if len(deleteTimelocks) > 0 {
+		_, err := tx.Model(&deleteTimelocks).Returning("NULL").Delete()
 		if err != nil {
 			return err
 		}
@@ -1740,37 +3193,35 @@ func (postgres *Postgres) flushCreatorCoinBalances(tx *pg.Tx, view *UtxoView) er
 	return nil
 }
 
-func (postgres *Postgres) flushDAOCoinBalances(tx *pg.Tx, view *UtxoView) error {
-	var insertBalances []*PGDAOCoinBalance
-	var deleteBalances []*PGDAOCoinBalance
-	for _, balanceEntry := range view.HODLerPKIDCreatorPKIDToDAOCoinBalanceEntry {
-		if balanceEntry == nil {
-			continue
-		}
-
-		balance := &PGDAOCoinBalance{
-			HolderPKID:  balanceEntry.HODLerPKID,
-			CreatorPKID: balanceEntry.CreatorPKID,
-			BalanceNanos: balanceEntry.BalanceNanos.Hex(),
-			HasPurchased: balanceEntry.HasPurchased,
+func (postgres *Postgres) flushRotatedPublicKeys(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertRotatedPublicKeys []*PGRotatedPublicKey
+	var deleteRotatedPublicKeys []*PGRotatedPublicKey
+	for _, rotatedPublicKeyEntry := range view.PublicKeyToRotatedPublicKeyEntry {
+		rotatedPublicKey := &PGRotatedPublicKey{
+			OldPublicKey: rotatedPublicKeyEntry.OldPublicKey,
+			PKID:         rotatedPublicKeyEntry.PKID,
 		}
 
-		if balanceEntry.isDeleted {
-			deleteBalances = append(deleteBalances, balance)
+		if rotatedPublicKeyEntry.isDeleted {
+			deleteRotatedPublicKeys = append(deleteRotatedPublicKeys, rotatedPublicKey)
 		} else {
-			insertBalances = append(insertBalances, balance)
+			insertRotatedPublicKeys = append(insertRotatedPublicKeys, rotatedPublicKey)
 		}
 	}
 
-	if len(insertBalances) > 0 {
-		_, err := tx.Model(&insertBalances).WherePK().OnConflict("(holder_pkid, creator_pkid) DO UPDATE").Returning("NULL").Insert()
+	if err := changeLog.recordChanges(tx, &insertRotatedPublicKeys, &deleteRotatedPublicKeys); err != nil {
+		return err
+	}
+
+	if len(insertRotatedPublicKeys) > 0 {
+		_, err := tx.Model(&insertRotatedPublicKeys).WherePK().OnConflict("(old_public_key) DO UPDATE").Returning("NULL").Insert()
 		if err != nil {
 			return err
 		}
 	}
 
-	if len(deleteBalances) > 0 {
-		_, err := tx.Model(&deleteBalances).Returning("NULL").Delete()
+	if len(deleteRotatedPublicKeys) > 0 {
+		_, err := tx.Model(&deleteRotatedPublicKeys).Returning("NULL").Delete()
 		if err != nil {
 			return err
 		}
@@ -1779,22 +3230,42 @@ func (postgres *Postgres) flushDAOCoinBalances(tx *pg.Tx, view *UtxoView) error
 	return nil
 }
 
-func (postgres *Postgres) flushBalances(tx *pg.Tx, view *UtxoView) error {
-	var balances []*PGBalance
-	for pubKeyIter, balanceNanos := range view.PublicKeyToDeSoBalanceNanos {
-		// Make a copy of the iterator since it might change from under us.
-		pubKey := pubKeyIter[:]
+func (postgres *Postgres) flushRecoveryGuardianSets(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertGuardianSets []*PGRecoveryGuardianSet
+	var deleteGuardianSets []*PGRecoveryGuardianSet
+	for _, guardianSetEntry := range view.PKIDToRecoveryGuardianSetEntry {
+		guardianSet := &PGRecoveryGuardianSet{
+			OwnerPKID:                 guardianSetEntry.OwnerPKID,
+			GuardianPublicKeys:        guardianSetEntry.GuardianPublicKeys,
+			Threshold:                 guardianSetEntry.Threshold,
+			DelayBlocks:               guardianSetEntry.DelayBlocks,
+			RecoveryNonce:             guardianSetEntry.RecoveryNonce,
+			PendingGuardianPublicKeys: guardianSetEntry.PendingGuardianPublicKeys,
+			PendingThreshold:          guardianSetEntry.PendingThreshold,
+			PendingDelayBlocks:        guardianSetEntry.PendingDelayBlocks,
+			PendingUnlockBlockHeight:  guardianSetEntry.PendingUnlockBlockHeight,
+		}
 
-		balance := &PGBalance{
-			PublicKey:    NewPublicKey(pubKey),
-			BalanceNanos: balanceNanos,
+		if guardianSetEntry.isDeleted {
+			deleteGuardianSets = append(deleteGuardianSets, guardianSet)
+		} else {
+			insertGuardianSets = append(insertGuardianSets, guardianSet)
 		}
+	}
 
-		balances = append(balances, balance)
+	if err := changeLog.recordChanges(tx, &insertGuardianSets, &deleteGuardianSets); err != nil {
+		return err
 	}
 
-	if len(balances) > 0 {
-		_, err := tx.Model(&balances).WherePK().OnConflict("(public_key) DO UPDATE").Returning("NULL").Insert()
+	if len(insertGuardianSets) > 0 {
+		_, err := tx.Model(&insertGuardianSets).WherePK().OnConflict("(owner_pkid) DO UPDATE").Returning("NULL").Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(deleteGuardianSets) > 0 {
+		_, err := tx.Model(&deleteGuardianSets).Returning("NULL").Delete()
 		if err != nil {
 			return err
 		}
@@ -1803,30 +3274,36 @@ func (postgres *Postgres) flushBalances(tx *pg.Tx, view *UtxoView) error {
 	return nil
 }
 
-func (postgres *Postgres) flushForbiddenKeys(tx *pg.Tx, view *UtxoView) error {
-	var insertKeys []*PGForbiddenKey
-	var deleteKeys []*PGForbiddenKey
-	for _, keyEntry := range view.ForbiddenPubKeyToForbiddenPubKeyEntry {
-		balance := &PGForbiddenKey{
-			PublicKey: NewPublicKey(keyEntry.PubKey),
+func (postgres *Postgres) flushPendingAccountRecoveries(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
+	var insertAccountRecoveries []*PGPendingAccountRecovery
+	var deleteAccountRecoveries []*PGPendingAccountRecovery
+	for _, accountRecoveryEntry := range view.PKIDToAccountRecoveryEntry {
+		accountRecovery := &PGPendingAccountRecovery{
+			OwnerPKID:         accountRecoveryEntry.OwnerPKID,
+			NewPublicKey:      accountRecoveryEntry.NewPublicKey,
+			UnlockBlockHeight: accountRecoveryEntry.UnlockBlockHeight,
 		}
 
-		if keyEntry.isDeleted {
-			deleteKeys = append(deleteKeys, balance)
+		if accountRecoveryEntry.isDeleted {
+			deleteAccountRecoveries = append(deleteAccountRecoveries, accountRecovery)
 		} else {
-			insertKeys = append(insertKeys, balance)
+			insertAccountRecoveries = append(insertAccountRecoveries, accountRecovery)
 		}
 	}
 
-	if len(insertKeys) > 0 {
-		_, err := tx.Model(&insertKeys).WherePK().OnConflict("(public_key) DO UPDATE").Returning("NULL").Insert()
+	if err := changeLog.recordChanges(tx, &insertAccountRecoveries, &deleteAccountRecoveries); err != nil {
+		return err
+	}
+
+	if len(insertAccountRecoveries) > 0 {
+		_, err := tx.Model(&insertAccountRecoveries).WherePK().OnConflict("(owner_pkid) DO UPDATE").Returning("NULL").Insert()
 		if err != nil {
 			return err
 		}
 	}
 
-	if len(deleteKeys) > 0 {
-		_, err := tx.Model(&deleteKeys).Returning("NULL").Delete()
+	if len(deleteAccountRecoveries) > 0 {
+		_, err := tx.Model(&deleteAccountRecoveries).Returning("NULL").Delete()
 		if err != nil {
 			return err
 		}
@@ -1835,22 +3312,28 @@ func (postgres *Postgres) flushForbiddenKeys(tx *pg.Tx, view *UtxoView) error {
 	return nil
 }
 
-func (postgres *Postgres) flushNFTs(tx *pg.Tx, view *UtxoView) error {
+func (postgres *Postgres) flushNFTs(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
 	var insertNFTs []*PGNFT
 	var deleteNFTs []*PGNFT
 	for _, nftEntry := range view.NFTKeyToNFTEntry {
 		nft := &PGNFT{
-			NFTPostHash:                nftEntry.NFTPostHash,
-			SerialNumber:               nftEntry.SerialNumber,
-			LastOwnerPKID:              nftEntry.LastOwnerPKID,
-			OwnerPKID:                  nftEntry.OwnerPKID,
-			ForSale:                    nftEntry.IsForSale,
-			MinBidAmountNanos:          nftEntry.MinBidAmountNanos,
-			UnlockableText:             string(nftEntry.UnlockableText),
-			LastAcceptedBidAmountNanos: nftEntry.LastAcceptedBidAmountNanos,
-			IsPending:                  nftEntry.IsPending,
-			IsBuyNow:                   nftEntry.IsBuyNow,
-			BuyNowPriceNanos:           nftEntry.BuyNowPriceNanos,
+			NFTPostHash:                 nftEntry.NFTPostHash,
+			SerialNumber:                nftEntry.SerialNumber,
+			LastOwnerPKID:               nftEntry.LastOwnerPKID,
+			OwnerPKID:                   nftEntry.OwnerPKID,
+			ForSale:                     nftEntry.IsForSale,
+			MinBidAmountNanos:           nftEntry.MinBidAmountNanos,
+			UnlockableText:              string(nftEntry.UnlockableText),
+			LastAcceptedBidAmountNanos:  nftEntry.LastAcceptedBidAmountNanos,
+			IsPending:                   nftEntry.IsPending,
+			IsBuyNow:                    nftEntry.IsBuyNow,
+			BuyNowPriceNanos:            nftEntry.BuyNowPriceNanos,
+			AuctionEndBlockHeight:       nftEntry.AuctionEndBlockHeight,
+			AuctionReservePriceNanos:    nftEntry.AuctionReservePriceNanos,
+			AuctionMinBidIncrementNanos: nftEntry.AuctionMinBidIncrementNanos,
+			IsVaulted:                   nftEntry.IsVaulted,
+			VaultTotalSharesNanos:       nftEntry.VaultTotalSharesNanos.Hex(),
+			VaultBuyoutPriceNanos:       nftEntry.VaultBuyoutPriceNanos,
 		}
 
 		if nftEntry.isDeleted {
@@ -1860,6 +3343,10 @@ func (postgres *Postgres) flushNFTs(tx *pg.Tx, view *UtxoView) error {
 		}
 	}
 
+	if err := changeLog.recordChanges(tx, &insertNFTs, &deleteNFTs); err != nil {
+		return err
+	}
+
 	if len(insertNFTs) > 0 {
 		_, err := tx.Model(&insertNFTs).WherePK().OnConflict("(nft_post_hash, serial_number) DO UPDATE").Returning("NULL").Insert()
 		if err != nil {
@@ -1877,7 +3364,7 @@ func (postgres *Postgres) flushNFTs(tx *pg.Tx, view *UtxoView) error {
 	return nil
 }
 
-func (postgres *Postgres) flushNFTBids(tx *pg.Tx, view *UtxoView) error {
+func (postgres *Postgres) flushNFTBids(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
 	var insertBids []*PGNFTBid
 	var deleteBids []*PGNFTBid
 	for _, bidEntry := range view.NFTBidKeyToNFTBidEntry {
@@ -1897,6 +3384,10 @@ func (postgres *Postgres) flushNFTBids(tx *pg.Tx, view *UtxoView) error {
 		}
 	}
 
+	if err := changeLog.recordChanges(tx, &insertBids, &deleteBids); err != nil {
+		return err
+	}
+
 	if len(insertBids) > 0 {
 		_, err := tx.Model(&insertBids).WherePK().OnConflict("(nft_post_hash, bidder_pkid, serial_number) DO UPDATE").Returning("NULL").Insert()
 		if err != nil {
@@ -1914,7 +3405,7 @@ func (postgres *Postgres) flushNFTBids(tx *pg.Tx, view *UtxoView) error {
 	return nil
 }
 
-func (postgres *Postgres) flushDerivedKeys(tx *pg.Tx, view *UtxoView) error {
+func (postgres *Postgres) flushDerivedKeys(tx *pg.Tx, view *UtxoView, changeLog *pgChangeLogWriter) error {
 	var insertKeys []*PGDerivedKey
 	var deleteKeys []*PGDerivedKey
 	for _, keyEntry := range view.DerivedKeyToDerivedEntry {
@@ -1932,6 +3423,10 @@ func (postgres *Postgres) flushDerivedKeys(tx *pg.Tx, view *UtxoView) error {
 		}
 	}
 
+	if err := changeLog.recordChanges(tx, &insertKeys, &deleteKeys); err != nil {
+		return err
+	}
+
 	if len(insertKeys) > 0 {
 		_, err := tx.Model(&insertKeys).WherePK().OnConflict("(owner_public_key, derived_public_key) DO UPDATE").Returning("NULL").Insert()
 		if err != nil {
@@ -2091,6 +3586,48 @@ func (postgres *Postgres) GetPosts(posts []*PGPost) []*PGPost {
 	return posts
 }
 
+// GetPostVersions returns every version of a post in version order.
+func (postgres *Postgres) GetPostVersions(postHash *BlockHash) []*PGPostVersion {
+	var versions []*PGPostVersion
+	err := postgres.db.Model(&versions).Where("post_hash = ?", postHash).Order("version ASC").Select()
+	if err != nil {
+		return nil
+	}
+	return versions
+}
+
+func (postgres *Postgres) GetPoll(postHash *BlockHash) *PGPoll {
+	poll := PGPoll{
+		PostHash: postHash,
+	}
+	err := postgres.db.Model(&poll).WherePK().First()
+	if err != nil {
+		return nil
+	}
+	return &poll
+}
+
+func (postgres *Postgres) GetPollVote(postHash *BlockHash, voterPKID *PKID) *PGPollVote {
+	vote := PGPollVote{
+		PostHash:  postHash,
+		VoterPKID: voterPKID,
+	}
+	err := postgres.db.Model(&vote).WherePK().First()
+	if err != nil {
+		return nil
+	}
+	return &vote
+}
+
+func (postgres *Postgres) GetPollVotesForPost(postHash *BlockHash) []*PGPollVote {
+	var votes []*PGPollVote
+	err := postgres.db.Model(&votes).Where("post_hash = ?", postHash).Select()
+	if err != nil {
+		return nil
+	}
+	return votes
+}
+
 func (postgres *Postgres) GetPostsForPublicKey(publicKey []byte, startTime uint64, limit uint64) []*PGPost {
 	var posts []*PGPost
 	err := postgres.db.Model(&posts).
@@ -2126,6 +3663,18 @@ func (postgres *Postgres) GetMessage(messageHash *BlockHash) *PGMessage {
 	return &message
 }
 
+func (postgres *Postgres) GetMessageRead(readerPublicKey []byte, threadPublicKey []byte) *PGMessageRead {
+	messageRead := PGMessageRead{
+		ReaderPublicKey: NewPublicKey(readerPublicKey),
+		ThreadPublicKey: NewPublicKey(threadPublicKey),
+	}
+	err := postgres.db.Model(&messageRead).WherePK().First()
+	if err != nil {
+		return nil
+	}
+	return &messageRead
+}
+
 //
 // LIKES
 //
@@ -2201,6 +3750,38 @@ func (postgres *Postgres) GetFollowers(pkid *PKID) []*PGFollow {
 	return follows
 }
 
+func (postgres *Postgres) GetUserBlock(blockerPkid *PKID, blockedPkid *PKID) *PGUserBlock {
+	userBlock := PGUserBlock{
+		BlockerPKID: blockerPkid,
+		BlockedPKID: blockedPkid,
+	}
+	err := postgres.db.Model(&userBlock).WherePK().First()
+	if err != nil {
+		return nil
+	}
+	return &userBlock
+}
+
+// GetUserBlocked returns the blocks and mutes a PKID has made.
+func (postgres *Postgres) GetUserBlocked(pkid *PKID) []*PGUserBlock {
+	var userBlocks []*PGUserBlock
+	err := postgres.db.Model(&userBlocks).Where("blocker_pkid = ?", pkid).Select()
+	if err != nil {
+		return nil
+	}
+	return userBlocks
+}
+
+// GetUserBlockers returns the blocks and mutes made against a PKID.
+func (postgres *Postgres) GetUserBlockers(pkid *PKID) []*PGUserBlock {
+	var userBlocks []*PGUserBlock
+	err := postgres.db.Model(&userBlocks).Where("blocked_pkid = ?", pkid).Select()
+	if err != nil {
+		return nil
+	}
+	return userBlocks
+}
+
 func (postgres *Postgres) GetDiamond(senderPkid *PKID, receiverPkid *PKID, postHash *BlockHash) *PGDiamond {
 	diamond := PGDiamond{
 		SenderPKID:      senderPkid,
@@ -2332,6 +3913,187 @@ func (postgres *Postgres) GetNFTsForPKID(pkid *PKID) []*PGNFT {
 	return nfts
 }
 
+// GetNFTsWithAuctionsEndingByHeight returns the NFTs whose auctions end at or before blockHeight.
+func (postgres *Postgres) GetNFTsWithAuctionsEndingByHeight(blockHeight uint64) []*PGNFT {
+	var nfts []*PGNFT
+	err := postgres.db.Model(&nfts).
+		Where("auction_end_block_height > 0").
+		Where("auction_end_block_height <= ?", blockHeight).
+		Select()
+	if err != nil {
+		return nil
+	}
+	return nfts
+}
+
+func (postgres *Postgres) GetNFTVoucherRedemption(nftPostHash *BlockHash, serialNumber uint64) *PGNFTVoucherRedemption {
+	redemption := PGNFTVoucherRedemption{
+		NFTPostHash:  nftPostHash,
+		SerialNumber: serialNumber,
+	}
+	err := postgres.db.Model(&redemption).WherePK().First()
+	if err != nil {
+		return nil
+	}
+	return &redemption
+}
+
+func (postgres *Postgres) GetBlockProducer(publicKey []byte) *PGBlockProducer {
+	blockProducer := PGBlockProducer{
+		PublicKey: publicKey,
+	}
+	err := postgres.db.Model(&blockProducer).WherePK().First()
+	if err != nil {
+		return nil
+	}
+	return &blockProducer
+}
+
+func (postgres *Postgres) GetForbiddenKey(publicKey []byte) *PGForbiddenKey {
+	forbiddenKey := PGForbiddenKey{
+		PublicKey: NewPublicKey(publicKey),
+	}
+	err := postgres.db.Model(&forbiddenKey).WherePK().First()
+	if err != nil {
+		return nil
+	}
+	return &forbiddenKey
+}
+
+func (postgres *Postgres) GetCreatorCoinPriceCandle(creatorPKID *PKID, blockHeight uint32) *PGCreatorCoinPriceCandle {
+	candle := PGCreatorCoinPriceCandle{
+		CreatorPKID: creatorPKID,
+		BlockHeight: blockHeight,
+	}
+	err := postgres.db.Model(&candle).WherePK().First()
+	if err != nil {
+		return nil
+	}
+	return &candle
+}
+
+// GetCreatorCoinPriceCandles returns the per-block candles for a creator coin with a
+// height in [startHeight, endHeight], in height order.
+func (postgres *Postgres) GetCreatorCoinPriceCandles(creatorPKID *PKID, startHeight uint32,
+	endHeight uint32) []*PGCreatorCoinPriceCandle {
+
+	var candles []*PGCreatorCoinPriceCandle
+	err := postgres.db.Model(&candles).
+		Where("creator_pkid = ?", creatorPKID).
+		Where("block_height >= ?", startHeight).
+		Where("block_height <= ?", endHeight).
+		Order("block_height ASC").
+		Select()
+	if err != nil {
+		return nil
+	}
+	return candles
+}
+
+func (postgres *Postgres) GetAllBlockProducers() []*PGBlockProducer {
+	var blockProducers []*PGBlockProducer
+	err := postgres.db.Model(&blockProducers).Select()
+	if err != nil {
+		return nil
+	}
+	return blockProducers
+}
+
+func (postgres *Postgres) GetUsernameListing(nonLowercaseUsername []byte) *PGUsernameListing {
+	listing := PGUsernameListing{
+		LowercaseUsername: strings.ToLower(string(nonLowercaseUsername)),
+	}
+	err := postgres.db.Model(&listing).WherePK().First()
+	if err != nil {
+		return nil
+	}
+	return &listing
+}
+
+func (postgres *Postgres) GetAllUsernameListings() []*PGUsernameListing {
+	var listings []*PGUsernameListing
+	err := postgres.db.Model(&listings).Select()
+	if err != nil {
+		return nil
+	}
+	return listings
+}
+
+func (postgres *Postgres) GetPendingKeyRotation(oldPublicKey []byte) *PGPendingKeyRotation {
+	keyRotation := PGPendingKeyRotation{
+		OldPublicKey: oldPublicKey,
+	}
+	err := postgres.db.Model(&keyRotation).WherePK().First()
+	if err != nil {
+		return nil
+	}
+	return &keyRotation
+}
+
+func (postgres *Postgres) GetKeyRotationTimelock(ownerPKID *PKID) *PGKeyRotationTimelock {
+	timelock := PGKeyRotationTimelock{
+		OwnerPKID: ownerPKID,
+	}
+	err := postgres.db.Model(&timelock).WherePK().First()
+	if err != nil {
+		return nil
+	}
+	return &timelock
+}
+
+func (postgres *Postgres) GetRotatedPublicKey(oldPublicKey []byte) *PGRotatedPublicKey {
+	rotatedPublicKey := PGRotatedPublicKey{
+		OldPublicKey: oldPublicKey,
+	}
+	err := postgres.db.Model(&rotatedPublicKey).WherePK().First()
+	if err != nil {
+		return nil
+	}
+	return &rotatedPublicKey
+}
+
+func (postgres *Postgres) GetRecoveryGuardianSet(ownerPKID *PKID) *PGRecoveryGuardianSet {
+	guardianSet := PGRecoveryGuardianSet{
+		OwnerPKID: ownerPKID,
+	}
+	err := postgres.db.Model(&guardianSet).WherePK().First()
+	if err != nil {
+		return nil
+	}
+	return &guardianSet
+}
+
+func (postgres *Postgres) GetPendingAccountRecovery(ownerPKID *PKID) *PGPendingAccountRecovery {
+	accountRecovery := PGPendingAccountRecovery{
+		OwnerPKID: ownerPKID,
+	}
+	err := postgres.db.Model(&accountRecovery).WherePK().First()
+	if err != nil {
+		return nil
+	}
+	return &accountRecovery
+}
+
+func (postgres *Postgres) GetNFTCollection(collectionID *BlockHash) *PGNFTCollection {
+	nftCollection := PGNFTCollection{
+		CollectionID: collectionID,
+	}
+	err := postgres.db.Model(&nftCollection).WherePK().First()
+	if err != nil {
+		return nil
+	}
+	return &nftCollection
+}
+
+func (postgres *Postgres) GetPostsForNFTCollection(collectionID *BlockHash) []*PGPost {
+	var posts []*PGPost
+	err := postgres.db.Model(&posts).Where("nft_collection_id = ?", collectionID).Select()
+	if err != nil {
+		return nil
+	}
+	return posts
+}
+
 func (postgres *Postgres) GetNFTBidsForPKID(pkid *PKID) []*PGNFTBid {
 	var nftBids []*PGNFTBid
 	err := postgres.db.Model(&nftBids).Where("bidder_pkid = ?", pkid).Select()
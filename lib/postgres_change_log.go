@@ -0,0 +1,425 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+//
+// Change Log
+//
+// FlushView records every row it writes in pg_change_logs, in the same transaction
+// as the write itself. A replica can then be kept in sync by replaying the log in ID
+// order. Because FlushView is only ever called while holding the ChainLock, IDs are
+// committed in the same order they are assigned.
+//
+// Reorgs are flushed like any other view, so the blocks that were reverted show up
+// as ordinary changes whose NewValue is the state before those blocks.
+//
+
+// PGChangeLog is a single row-level change. Key, OldValue, and NewValue are JSON
+// encodings of the entity's model. An empty OldValue means the row was created and
+// an empty NewValue means the row was deleted.
+type PGChangeLog struct {
+	tableName struct{} `pg:"pg_change_logs"`
+
+	ID          uint64     `pg:",pk"`
+	BlockHash   *BlockHash `pg:",type:bytea"`
+	BlockHeight uint64     `pg:",use_zero"`
+	Entity      string
+	Key         string `pg:",type:jsonb"`
+	OldValue    string `pg:",type:jsonb"`
+	NewValue    string `pg:",type:jsonb"`
+}
+
+// PGChangeLogCursor is stored in a replica and holds the ID of the last change that
+// was applied to it.
+type PGChangeLogCursor struct {
+	tableName struct{} `pg:"pg_change_log_cursors"`
+
+	Name         string `pg:",pk"`
+	LastChangeID uint64 `pg:",use_zero"`
+}
+
+// pgChangeLogModels are the models FlushView writes. Entities are named after the
+// model's table.
+var pgChangeLogModels = []interface{}{
+	&PGTransactionOutput{},
+	&PGProfile{},
+	&PGPost{},
+	&PGPostVersion{},
+	&PGPoll{},
+	&PGPollVote{},
+	&PGLike{},
+	&PGFollow{},
+	&PGUserBlock{},
+	&PGDiamond{},
+	&PGMessage{},
+	&PGMessageRead{},
+	&PGMessagingGroup{},
+	&PGCreatorCoinBalance{},
+	&PGDAOCoinBalance{},
+	&PGCreatorCoinPriceCandle{},
+	&PGBalance{},
+	&PGForbiddenKey{},
+	&PGNFT{},
+	&PGNFTCollection{},
+	&PGNFTVoucherRedemption{},
+	&PGBlockProducer{},
+	&PGUsernameListing{},
+	&PGPendingKeyRotation{},
+	&PGKeyRotationTimelock{},
+	&PGRotatedPublicKey{},
+	&PGRecoveryGuardianSet{},
+	&PGPendingAccountRecovery{},
+	&PGNFTBid{},
+	&PGDerivedKey{},
+}
+
+var pgChangeLogEntityTypes = func() map[string]reflect.Type {
+	entityTypes := make(map[string]reflect.Type)
+	for _, model := range pgChangeLogModels {
+		modelType := reflect.TypeOf(model).Elem()
+		entityTypes[pgModelTableName(modelType)] = modelType
+	}
+	return entityTypes
+}()
+
+// pgModelTableName returns the table name from a model's tableName tag.
+func pgModelTableName(modelType reflect.Type) string {
+	field, exists := modelType.FieldByName("tableName")
+	if !exists {
+		return ""
+	}
+	return strings.Split(field.Tag.Get("pg"), ",")[0]
+}
+
+func isPGPrimaryKeyField(field reflect.StructField) bool {
+	options := strings.Split(field.Tag.Get("pg"), ",")
+	for _, option := range options[1:] {
+		if option == "pk" {
+			return true
+		}
+	}
+	return false
+}
+
+// pgModelKey encodes the primary key fields of a model as a JSON object keyed by
+// field name. Since json sorts map keys, equal keys always encode the same way.
+func pgModelKey(modelVal reflect.Value) (string, error) {
+	key := make(map[string]interface{})
+	for ii := 0; ii < modelVal.NumField(); ii++ {
+		field := modelVal.Type().Field(ii)
+		if isPGPrimaryKeyField(field) {
+			key[field.Name] = modelVal.Field(ii).Interface()
+		}
+	}
+	if len(key) == 0 {
+		return "", fmt.Errorf("pgModelKey: Model %v has no primary key", modelVal.Type())
+	}
+	keyBytes, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	return string(keyBytes), nil
+}
+
+// pgChangeLogWriter accumulates the changes made by a single FlushView.
+type pgChangeLogWriter struct {
+	blockHash   *BlockHash
+	blockHeight uint64
+	entries     []*PGChangeLog
+}
+
+func newPGChangeLogWriter(tx *pg.Tx, view *UtxoView) (*pgChangeLogWriter, error) {
+	writer := &pgChangeLogWriter{
+		blockHash: view.TipHash,
+	}
+
+	// The block is always upserted before its view is flushed.
+	if view.TipHash != nil {
+		block := &PGBlock{
+			Hash: view.TipHash,
+		}
+		if err := tx.Model(block).WherePK().Select(); err != nil {
+			return nil, errors.Wrapf(err, "newPGChangeLogWriter: Problem fetching block %v", view.TipHash)
+		}
+		writer.blockHeight = block.Height
+	}
+
+	return writer, nil
+}
+
+// recordChanges appends an entry for every row in upserts and deletes, which must
+// be pointers to slices of model pointers or nil. It must be called before the rows
+// are written so the old values can be read.
+func (writer *pgChangeLogWriter) recordChanges(tx *pg.Tx, upserts interface{}, deletes interface{}) error {
+	if err := writer.recordRows(tx, upserts, false); err != nil {
+		return err
+	}
+	return writer.recordRows(tx, deletes, true)
+}
+
+func (writer *pgChangeLogWriter) recordRows(tx *pg.Tx, rows interface{}, isDelete bool) error {
+	if rows == nil {
+		return nil
+	}
+	rowsVal := reflect.ValueOf(rows).Elem()
+	if rowsVal.Len() == 0 {
+		return nil
+	}
+	modelType := rowsVal.Type().Elem().Elem()
+	entity := pgModelTableName(modelType)
+
+	// Select the current rows into a copy so the rows being flushed aren't overwritten.
+	existingRows := reflect.New(rowsVal.Type())
+	existingRows.Elem().Set(reflect.MakeSlice(rowsVal.Type(), 0, rowsVal.Len()))
+	for ii := 0; ii < rowsVal.Len(); ii++ {
+		rowCopy := reflect.New(modelType)
+		rowCopy.Elem().Set(rowsVal.Index(ii).Elem())
+		existingRows.Elem().Set(reflect.Append(existingRows.Elem(), rowCopy))
+	}
+	if err := tx.Model(existingRows.Interface()).WherePK().Select(); err != nil && err != pg.ErrNoRows {
+		return errors.Wrapf(err, "recordRows: Problem fetching existing %s rows", entity)
+	}
+
+	oldValues := make(map[string]string)
+	for ii := 0; ii < existingRows.Elem().Len(); ii++ {
+		existingRow := existingRows.Elem().Index(ii)
+		key, err := pgModelKey(existingRow.Elem())
+		if err != nil {
+			return err
+		}
+		valueBytes, err := json.Marshal(existingRow.Interface())
+		if err != nil {
+			return errors.Wrapf(err, "recordRows: Problem encoding %s row", entity)
+		}
+		oldValues[key] = string(valueBytes)
+	}
+
+	var entries []*PGChangeLog
+	for ii := 0; ii < rowsVal.Len(); ii++ {
+		row := rowsVal.Index(ii)
+		key, err := pgModelKey(row.Elem())
+		if err != nil {
+			return err
+		}
+
+		newValue := ""
+		if !isDelete {
+			valueBytes, err := json.Marshal(row.Interface())
+			if err != nil {
+				return errors.Wrapf(err, "recordRows: Problem encoding %s row", entity)
+			}
+			newValue = string(valueBytes)
+		}
+
+		// Deleting a row that doesn't exist or rewriting a row as-is isn't a change.
+		if oldValues[key] == newValue {
+			continue
+		}
+
+		entries = append(entries, &PGChangeLog{
+			BlockHash:   writer.blockHash,
+			BlockHeight: writer.blockHeight,
+			Entity:      entity,
+			Key:         key,
+			OldValue:    oldValues[key],
+			NewValue:    newValue,
+		})
+	}
+
+	// Views are backed by maps so sort the entries to keep the log deterministic.
+	sort.Slice(entries, func(ii, jj int) bool {
+		return entries[ii].Key < entries[jj].Key
+	})
+	writer.entries = append(writer.entries, entries...)
+
+	return nil
+}
+
+func (writer *pgChangeLogWriter) flush(tx *pg.Tx) error {
+	if len(writer.entries) == 0 {
+		return nil
+	}
+	_, err := tx.Model(&writer.entries).Returning("NULL").Insert()
+	return err
+}
+
+// GetChangeLogs returns at most limit changes with an ID greater than afterID in
+// ID order.
+func (postgres *Postgres) GetChangeLogs(afterID uint64, limit int) ([]*PGChangeLog, error) {
+	var changes []*PGChangeLog
+	err := postgres.db.Model(&changes).Where("id > ?", afterID).Order("id ASC").Limit(limit).Select()
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// GetChangeLogCursor returns the ID of the last change applied by the named
+// consumer, or zero if it hasn't applied any.
+func (postgres *Postgres) GetChangeLogCursor(name string) (uint64, error) {
+	cursor := &PGChangeLogCursor{
+		Name: name,
+	}
+	err := postgres.db.Model(cursor).WherePK().Select()
+	if err == pg.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return cursor.LastChangeID, nil
+}
+
+// ApplyChangeLogs writes changes to the database and advances the named cursor in a
+// single transaction. Each change is applied by deleting the row and then inserting
+// its new value, so applying the same change more than once is harmless.
+func (postgres *Postgres) ApplyChangeLogs(name string, changes []*PGChangeLog) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return postgres.db.RunInTransaction(postgres.db.Context(), func(tx *pg.Tx) error {
+		for _, change := range changes {
+			if err := applyChangeLogTx(tx, change); err != nil {
+				return errors.Wrapf(err, "ApplyChangeLogs: Problem applying change %d", change.ID)
+			}
+		}
+
+		cursor := &PGChangeLogCursor{
+			Name:         name,
+			LastChangeID: changes[len(changes)-1].ID,
+		}
+		_, err := tx.Model(cursor).WherePK().OnConflict("(name) DO UPDATE").Insert()
+		return err
+	})
+}
+
+func applyChangeLogTx(tx *pg.Tx, change *PGChangeLog) error {
+	modelType, exists := pgChangeLogEntityTypes[change.Entity]
+	if !exists {
+		return fmt.Errorf("applyChangeLogTx: Unknown entity %s", change.Entity)
+	}
+
+	keyModel := reflect.New(modelType).Interface()
+	if err := json.Unmarshal([]byte(change.Key), keyModel); err != nil {
+		return errors.Wrapf(err, "applyChangeLogTx: Problem decoding key")
+	}
+	if _, err := tx.Model(keyModel).WherePK().Delete(); err != nil {
+		return err
+	}
+
+	if change.NewValue == "" {
+		return nil
+	}
+
+	newModel := reflect.New(modelType).Interface()
+	if err := json.Unmarshal([]byte(change.NewValue), newModel); err != nil {
+		return errors.Wrapf(err, "applyChangeLogTx: Problem decoding new value")
+	}
+	_, err := tx.Model(newModel).Returning("NULL").Insert()
+	return err
+}
+
+//
+// Replica
+//
+
+// The maximum number of changes applied to a replica in a single transaction.
+const MaxChangeLogsPerReplicaTxn = 1000
+
+// PostgresReplica keeps a replica database in sync with a source database by
+// replaying the source's change log. Delivery is at-least-once: the replica's
+// cursor is advanced in the same transaction that applies the changes, and since
+// applying a change is idempotent, replaying changes the replica already has is
+// safe.
+type PostgresReplica struct {
+	source  *Postgres
+	replica *Postgres
+	name    string
+
+	// Update wait group
+	updateWaitGroup sync.WaitGroup
+
+	// Shutdown channel
+	stopUpdateChannel chan struct{}
+}
+
+func NewPostgresReplica(source *Postgres, replica *Postgres, name string) *PostgresReplica {
+	return &PostgresReplica{
+		source:            source,
+		replica:           replica,
+		name:              name,
+		stopUpdateChannel: make(chan struct{}),
+	}
+}
+
+func (replica *PostgresReplica) Start() {
+	glog.Infof("PostgresReplica: Starting replay thread for %s", replica.name)
+
+	go func() {
+		replica.updateWaitGroup.Add(1)
+
+		for {
+			select {
+			case <-replica.stopUpdateChannel:
+				replica.updateWaitGroup.Done()
+				return
+			default:
+				numApplied, err := replica.ReplayOnce()
+				if err != nil {
+					glog.Error(fmt.Errorf("PostgresReplica: Problem replaying change log: %v", err))
+				}
+				// Keep going without sleeping while we're behind.
+				if numApplied == MaxChangeLogsPerReplicaTxn {
+					continue
+				}
+			}
+
+			time.Sleep(1 * time.Second)
+		}
+	}()
+}
+
+func (replica *PostgresReplica) Stop() {
+	glog.Info("PostgresReplica: Stopping replay thread")
+
+	replica.stopUpdateChannel <- struct{}{}
+	replica.updateWaitGroup.Wait()
+}
+
+// ReplayOnce applies at most MaxChangeLogsPerReplicaTxn changes that the replica
+// hasn't seen yet and returns the number applied.
+func (replica *PostgresReplica) ReplayOnce() (int, error) {
+	lastChangeID, err := replica.replica.GetChangeLogCursor(replica.name)
+	if err != nil {
+		return 0, errors.Wrapf(err, "ReplayOnce: Problem fetching cursor")
+	}
+
+	changes, err := replica.source.GetChangeLogs(lastChangeID, MaxChangeLogsPerReplicaTxn)
+	if err != nil {
+		return 0, errors.Wrapf(err, "ReplayOnce: Problem fetching changes after %d", lastChangeID)
+	}
+	if len(changes) == 0 {
+		return 0, nil
+	}
+
+	if err := replica.replica.ApplyChangeLogs(replica.name, changes); err != nil {
+		return 0, errors.Wrapf(err, "ReplayOnce: ")
+	}
+	glog.V(1).Infof("PostgresReplica: Applied changes %d through %d at block height %d",
+		changes[0].ID, changes[len(changes)-1].ID, changes[len(changes)-1].BlockHeight)
+
+	return len(changes), nil
+}
@@ -0,0 +1,45 @@
+package lib
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPGChangeLogKeys(t *testing.T) {
+	require := require.New(t)
+
+	// Every entity FlushView writes must be registered under its table name and
+	// have a primary key the replica can delete by.
+	require.Equal(len(pgChangeLogModels), len(pgChangeLogEntityTypes))
+	for entity, modelType := range pgChangeLogEntityTypes {
+		require.NotEmpty(entity)
+		_, err := pgModelKey(reflect.New(modelType).Elem())
+		require.NoError(err, entity)
+	}
+
+	// Keys only contain primary key fields and decode back into the model.
+	nft := &PGNFT{
+		NFTPostHash:       &BlockHash{1},
+		SerialNumber:      3,
+		OwnerPKID:         &PKID{2},
+		MinBidAmountNanos: 100,
+	}
+	key, err := pgModelKey(reflect.ValueOf(nft).Elem())
+	require.NoError(err)
+
+	decoded := &PGNFT{}
+	require.NoError(json.Unmarshal([]byte(key), decoded))
+	require.Equal(nft.NFTPostHash, decoded.NFTPostHash)
+	require.Equal(nft.SerialNumber, decoded.SerialNumber)
+	require.Nil(decoded.OwnerPKID)
+	require.Equal(uint64(0), decoded.MinBidAmountNanos)
+
+	// The same key always encodes the same way.
+	sameKey, err := pgModelKey(reflect.ValueOf(&PGNFT{NFTPostHash: &BlockHash{1}, SerialNumber: 3}).Elem())
+	require.NoError(err)
+	require.Equal(key, sameKey)
+	require.Equal("pg_nfts", pgModelTableName(reflect.TypeOf(PGNFT{})))
+}
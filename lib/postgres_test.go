@@ -0,0 +1,121 @@
+package lib
+
+import (
+	"os"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests in this file need a Postgres database to write to. They're skipped
+// unless DESO_TEST_POSTGRES_URI points at one. Tables are created as temporary
+// tables so nothing is left behind.
+func _newTestPostgres(t *testing.T, models ...interface{}) *Postgres {
+	require := require.New(t)
+
+	postgresURI := os.Getenv("DESO_TEST_POSTGRES_URI")
+	if postgresURI == "" {
+		t.Skip("DESO_TEST_POSTGRES_URI is not set")
+	}
+	options, err := pg.ParseURL(postgresURI)
+	require.NoError(err)
+	// Temporary tables only exist on the connection that created them.
+	options.PoolSize = 1
+	db := pg.Connect(options)
+	t.Cleanup(func() { db.Close() })
+
+	for _, model := range models {
+		require.NoError(db.Model(model).CreateTable(&orm.CreateTableOptions{Temp: true}))
+	}
+	return NewPostgres(db)
+}
+
+func TestPostgresMessagingGroupMemberRemoval(t *testing.T) {
+	require := require.New(t)
+
+	postgres := _newTestPostgres(t, (*PGMessagingGroup)(nil))
+	_, params, db := NewLowDifficultyBlockchain()
+
+	// The view reads groups from Postgres but the rest of the chain tables
+	// don't exist, so don't let NewUtxoView look up the tip there.
+	newPostgresView := func() *UtxoView {
+		view, err := NewUtxoView(db, params, nil)
+		require.NoError(err)
+		view.Postgres = postgres
+		return view
+	}
+	flushGroups := func(view *UtxoView) {
+		require.NoError(postgres.db.RunInTransaction(postgres.db.Context(), func(tx *pg.Tx) error {
+			return postgres.flushMessagingGroups(tx, view, &pgChangeLogWriter{})
+		}))
+	}
+	newPublicKey := func() *PublicKey {
+		privKey, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(err)
+		return NewPublicKey(privKey.PubKey().SerializeCompressed())
+	}
+	newMember := func() *MessagingGroupMember {
+		return &MessagingGroupMember{
+			GroupMemberPublicKey: newPublicKey(),
+			GroupMemberKeyName:   BaseGroupKeyName(),
+			EncryptedKey:         RandomBytes(btcec.PrivKeyBytesLen),
+		}
+	}
+
+	ownerPublicKey := newPublicKey()
+	groupKey := NewMessagingGroupKey(ownerPublicKey, []byte("group"))
+	member1 := newMember()
+	member2 := newMember()
+	admin := newPublicKey()
+
+	// Create the group with two members and an admin.
+	{
+		view := newPostgresView()
+		view._setMessagingGroupKeyToMessagingGroupEntryMapping(ownerPublicKey, &MessagingGroupEntry{
+			GroupOwnerPublicKey:   ownerPublicKey,
+			MessagingPublicKey:    newPublicKey(),
+			MessagingGroupKeyName: NewGroupKeyName([]byte("group")),
+			MessagingGroupMembers: []*MessagingGroupMember{member1, member2},
+			MessagingGroupAdmins:  []*PublicKey{admin},
+		})
+		flushGroups(view)
+	}
+
+	// Remove the second member in a fresh view and flush again.
+	{
+		view := newPostgresView()
+		groupEntry := view.GetMessagingGroupKeyToMessagingGroupEntryMapping(groupKey)
+		require.NotNil(groupEntry)
+		require.Equal(2, len(groupEntry.MessagingGroupMembers))
+		require.Equal([]*PublicKey{admin}, groupEntry.MessagingGroupAdmins)
+
+		updatedEntry := *groupEntry
+		updatedEntry.MessagingGroupMembers = []*MessagingGroupMember{member1}
+		view._setMessagingGroupKeyToMessagingGroupEntryMapping(ownerPublicKey, &updatedEntry)
+		flushGroups(view)
+	}
+
+	// Reloading the group should only return the remaining member.
+	{
+		view := newPostgresView()
+		groupEntry := view.GetMessagingGroupKeyToMessagingGroupEntryMapping(groupKey)
+		require.NotNil(groupEntry)
+		require.Equal(1, len(groupEntry.MessagingGroupMembers))
+		require.Equal(*member1.GroupMemberPublicKey, *groupEntry.MessagingGroupMembers[0].GroupMemberPublicKey)
+		require.Equal([]*PublicKey{admin}, groupEntry.MessagingGroupAdmins)
+	}
+
+	// Deleting the group removes its row.
+	{
+		view := newPostgresView()
+		groupEntry := view.GetMessagingGroupKeyToMessagingGroupEntryMapping(groupKey)
+		require.NotNil(groupEntry)
+		view._deleteMessagingGroupKeyToMessagingGroupEntryMapping(ownerPublicKey, groupEntry)
+		flushGroups(view)
+
+		require.Nil(newPostgresView().GetMessagingGroupKeyToMessagingGroupEntryMapping(groupKey))
+	}
+}
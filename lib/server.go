@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -47,12 +48,15 @@ type ServerReply struct {
 // accordingly. Probably the best place to start looking is the messageHandler
 // function.
 type Server struct {
-	cmgr          *ConnectionManager
-	blockchain    *Blockchain
-	mempool       *DeSoMempool
-	miner         *DeSoMiner
-	blockProducer *DeSoBlockProducer
-	eventManager  *EventManager
+	cmgr               *ConnectionManager
+	blockchain         *Blockchain
+	mempool            *DeSoMempool
+	miner              *DeSoMiner
+	blockProducer      *DeSoBlockProducer
+	blockPruner        *BlockPruner
+	miningServer       *DeSoMiningServer
+	bitcoinHeaderChain *BitcoinHeaderChain
+	eventManager       *EventManager
 
 	// All messages received from peers get sent from the ConnectionManager to the
 	// Server through this channel.
@@ -284,7 +288,6 @@ func NewServer(
 	_stallTimeoutSeconds uint64,
 	_maxBlockTemplatesToCache uint64,
 	_minBlockUpdateIntervalSeconds uint64,
-	_blockCypherAPIKey string,
 	_runReadOnlyUtxoViewUpdater bool,
 	_dataDir string,
 	_mempoolDumpDir string,
@@ -295,6 +298,11 @@ func NewServer(
 	_blockProducerSeed string,
 	_trustedBlockProducerPublicKeys []string,
 	_trustedBlockProducerStartHeight uint64,
+	_pruneBlocksDepth uint64,
+	_miningServerHost string,
+	_miningServerPort uint16,
+	_miningServerShareTargetHex string,
+	_bitcoinHeaderSourceURL string,
 	eventManager *EventManager,
 ) (*Server, error) {
 
@@ -337,7 +345,7 @@ func NewServer(
 	_chain, err := NewBlockchain(
 		_trustedBlockProducerPublicKeys,
 		_trustedBlockProducerStartHeight,
-		_params, timesource, _db, postgres, eventManager)
+		_params, timesource, _db, postgres, _pruneBlocksDepth, eventManager)
 	if err != nil {
 		return nil, errors.Wrapf(err, "NewServer: Problem initializing blockchain")
 	}
@@ -353,7 +361,7 @@ func NewServer(
 	// Create a mempool to store transactions until they're ready to be mined into
 	// blocks.
 	_mempool := NewDeSoMempool(_chain, _rateLimitFeerateNanosPerKB,
-		_minFeeRateNanosPerKB, _blockCypherAPIKey, _runReadOnlyUtxoViewUpdater, _dataDir,
+		_minFeeRateNanosPerKB, _runReadOnlyUtxoViewUpdater, _dataDir,
 		_mempoolDumpDir)
 
 	// Useful for debugging. Every second, it outputs the contents of the mempool
@@ -405,12 +413,51 @@ func NewServer(
 		return nil, errors.Wrapf(err, "NewServer: ")
 	}
 
+	// Only set up the BlockPruner if we've been asked to prune.
+	var _blockPruner *BlockPruner
+	if _pruneBlocksDepth > 0 {
+		_blockPruner, err = NewBlockPruner(_chain, _pruneBlocksDepth)
+		if err != nil {
+			return nil, errors.Wrapf(err, "NewServer: ")
+		}
+	}
+
+	// Only set up the mining server if we've been given a port to listen on.
+	var _miningServer *DeSoMiningServer
+	if _miningServerPort > 0 {
+		miningListener, err := net.Listen("tcp", net.JoinHostPort(
+			_miningServerHost, strconv.Itoa(int(_miningServerPort))))
+		if err != nil {
+			return nil, errors.Wrapf(err, "NewServer: Problem listening for miners: ")
+		}
+		_miningServer, err = NewDeSoMiningServer(miningListener, _miningServerShareTargetHex,
+			_minerPublicKeys, _blockProducer, _params)
+		if err != nil {
+			miningListener.Close()
+			return nil, errors.Wrapf(err, "NewServer: ")
+		}
+	}
+
+	// Only sync Bitcoin headers if we've been given somewhere to get them from.
+	var _bitcoinHeaderChain *BitcoinHeaderChain
+	if _bitcoinHeaderSourceURL != "" {
+		_bitcoinHeaderChain, err = NewBitcoinHeaderChain(_db, _params,
+			NewEsploraBitcoinHeaderSource(_bitcoinHeaderSourceURL), BitcoinHeaderSyncInterval)
+		if err != nil {
+			return nil, errors.Wrapf(err, "NewServer: ")
+		}
+	}
+
 	// Set all the fields on the Server object.
 	srv.cmgr = _cmgr
 	srv.blockchain = _chain
 	srv.mempool = _mempool
 	srv.miner = _miner
 	srv.blockProducer = _blockProducer
+	srv.blockPruner = _blockPruner
+	srv.miningServer = _miningServer
+	srv.bitcoinHeaderChain = _bitcoinHeaderChain
+	_mempool.bitcoinHeaderChain = _bitcoinHeaderChain
 	srv.incomingMessages = _incomingMessages
 	// Make this hold a multiple of what we hold for individual peers.
 	srv.inventoryBeingProcessed = lru.NewCache(maxKnownInventory)
@@ -943,6 +990,7 @@ func (srv *Server) _handleDonePeer(pp *Peer) {
 	glog.V(1).Infof("Server._handleDonePeer: Processing DonePeer: %v", pp)
 
 	srv._cleanupDonePeerPeerState(pp)
+	deletePeerMetrics(pp)
 
 	// Attempt to find a new peer to sync from if the quitting peer is the
 	// sync peer and if our blockchain isn't current.
@@ -1391,6 +1439,24 @@ func (srv *Server) StartStatsdReporter() {
 	}()
 }
 
+// StartMetricsReporter periodically updates the metrics that are sampled rather
+// than recorded as they happen.
+func (srv *Server) StartMetricsReporter() {
+	go func() {
+	out:
+		for {
+			select {
+			case <-time.After(5 * time.Second):
+				updateMempoolMetrics(srv.mempool)
+				updateChainMetrics(srv.blockchain)
+
+			case <-srv.mempool.quit:
+				break out
+			}
+		}
+	}()
+}
+
 func (srv *Server) _handleAddrMessage(pp *Peer, msg *MsgDeSoAddr) {
 	srv.addrsToBroadcastLock.Lock()
 	defer srv.addrsToBroadcastLock.Unlock()
@@ -1679,6 +1745,21 @@ func (srv *Server) Stop() {
 		srv.blockProducer.Stop()
 	}
 
+	// Stop the block pruner
+	if srv.blockPruner != nil {
+		srv.blockPruner.Stop()
+	}
+
+	// Stop the mining server
+	if srv.miningServer != nil {
+		srv.miningServer.Stop()
+	}
+
+	// Stop syncing Bitcoin headers
+	if srv.bitcoinHeaderChain != nil {
+		srv.bitcoinHeaderChain.Stop()
+	}
+
 	// This will signal any goroutines to quit. Note that enqueing this after stopping
 	// the ConnectionManager seems like it should cause the Server to process any remaining
 	// messages before calling waitGroup.Done(), which seems like a good thing.
@@ -1719,7 +1800,21 @@ func (srv *Server) Start() {
 		go srv.cmgr.Start()
 	}
 
-	if srv.miner != nil && len(srv.miner.PublicKeys) > 0 {
+	// When a mining server is running, external miners do the hashing so we don't
+	// start the in-process miner.
+	if srv.miner != nil && len(srv.miner.PublicKeys) > 0 && srv.miningServer == nil {
 		go srv.miner.Start()
 	}
+
+	if srv.blockPruner != nil {
+		srv.blockPruner.Start()
+	}
+
+	if srv.miningServer != nil {
+		srv.miningServer.Start()
+	}
+
+	if srv.bitcoinHeaderChain != nil {
+		srv.bitcoinHeaderChain.Start()
+	}
 }
@@ -131,7 +131,7 @@ func NewTXIndex(coreChain *Blockchain, params *DeSoParams, dataDirectory string)
 	// Note that we *DONT* pass server here because it is already tied to the to the main blockchain.
 	txIndexChain, err := NewBlockchain(
 		[]string{}, 0,
-		params, chainlib.NewMedianTime(), txIndexDb, nil, nil)
+		params, chainlib.NewMedianTime(), txIndexDb, nil, 0, nil)
 	if err != nil {
 		return nil, fmt.Errorf("NewTXIndex: Error initializing TxIndex: %v", err)
 	}
@@ -0,0 +1,56 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`
+			CREATE TABLE pg_change_logs (
+				id           BIGSERIAL PRIMARY KEY,
+				block_hash   BYTEA,
+				block_height BIGINT NOT NULL,
+				entity       TEXT NOT NULL,
+				key          JSONB NOT NULL,
+				old_value    JSONB,
+				new_value    JSONB
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE INDEX pg_change_logs_block_height_idx ON pg_change_logs(block_height);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE TABLE pg_change_log_cursors (
+				name           TEXT PRIMARY KEY,
+				last_change_id BIGINT NOT NULL
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			DROP TABLE pg_change_logs;
+			DROP TABLE pg_change_log_cursors;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220301000000_create_change_log", up, down, opts)
+}
@@ -0,0 +1,36 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`
+			CREATE TABLE pg_post_versions (
+				post_hash       BYTEA NOT NULL,
+				version         BIGINT NOT NULL,
+				body            TEXT,
+				extra_data      JSONB,
+				hidden          BOOLEAN NOT NULL,
+				timestamp_nanos BIGINT NOT NULL,
+				block_height    BIGINT NOT NULL,
+
+				PRIMARY KEY (post_hash, version)
+			);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			DROP TABLE pg_post_versions;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220315000000_create_post_versions", up, down, opts)
+}
@@ -0,0 +1,63 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`
+			CREATE TABLE pg_polls (
+				post_hash            BYTEA PRIMARY KEY,
+				poster_pkid          BYTEA NOT NULL,
+				question             TEXT NOT NULL,
+				options              JSONB NOT NULL,
+				closing_block_height BIGINT NOT NULL,
+				weight_type          SMALLINT NOT NULL,
+				option_tallies       JSONB NOT NULL,
+				num_votes            BIGINT NOT NULL
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE TABLE pg_poll_votes (
+				post_hash    BYTEA NOT NULL,
+				voter_pkid   BYTEA NOT NULL,
+				option_index BIGINT NOT NULL,
+				weight       TEXT NOT NULL,
+				block_height BIGINT NOT NULL,
+
+				PRIMARY KEY (post_hash, voter_pkid)
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE TABLE pg_metadata_poll_votes (
+				transaction_hash BYTEA PRIMARY KEY,
+				post_hash        BYTEA NOT NULL,
+				option_index     BIGINT NOT NULL
+			);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			DROP TABLE pg_polls;
+			DROP TABLE pg_poll_votes;
+			DROP TABLE pg_metadata_poll_votes;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220322000000_create_polls", up, down, opts)
+}
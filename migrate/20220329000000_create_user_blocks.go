@@ -0,0 +1,47 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`
+			CREATE TABLE pg_user_blocks (
+				blocker_pkid BYTEA NOT NULL,
+				blocked_pkid BYTEA NOT NULL,
+				block_type   SMALLINT NOT NULL,
+
+				PRIMARY KEY (blocker_pkid, blocked_pkid)
+			);
+
+			CREATE INDEX pg_user_blocks_blocked_pkid ON pg_user_blocks(blocked_pkid);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE TABLE pg_metadata_user_blocks (
+				transaction_hash   BYTEA PRIMARY KEY,
+				blocked_public_key BYTEA NOT NULL,
+				block_type         SMALLINT NOT NULL,
+				is_unblock         BOOLEAN NOT NULL
+			);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			DROP TABLE pg_user_blocks;
+			DROP TABLE pg_metadata_user_blocks;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220329000000_create_user_blocks", up, down, opts)
+}
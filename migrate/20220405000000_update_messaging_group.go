@@ -0,0 +1,36 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		// Groups are upserted by owner and key name when members, admins or the
+		// messaging public key change, which requires a unique constraint.
+		_, err := db.Exec(`
+			ALTER TABLE pg_messaging_group
+				ADD COLUMN messaging_group_admins BYTEA,
+				ADD PRIMARY KEY (group_owner_public_key, messaging_group_key_name);
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			ALTER TABLE pg_messaging_group
+				DROP CONSTRAINT pg_messaging_group_pkey,
+				DROP COLUMN messaging_group_admins;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220405000000_update_messaging_group", up, down, opts)
+}
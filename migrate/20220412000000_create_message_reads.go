@@ -0,0 +1,44 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`
+			CREATE TABLE pg_message_reads (
+				reader_public_key      BYTEA NOT NULL,
+				thread_public_key      BYTEA NOT NULL,
+				last_read_tstamp_nanos BIGINT NOT NULL,
+
+				PRIMARY KEY (reader_public_key, thread_public_key)
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE TABLE pg_metadata_message_reads (
+				transaction_hash       BYTEA PRIMARY KEY,
+				thread_public_key      BYTEA NOT NULL,
+				last_read_tstamp_nanos BIGINT NOT NULL
+			);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			DROP TABLE pg_message_reads;
+			DROP TABLE pg_metadata_message_reads;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220412000000_create_message_reads", up, down, opts)
+}
@@ -0,0 +1,41 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`
+			ALTER TABLE pg_nfts
+				ADD COLUMN auction_end_block_height        BIGINT NOT NULL DEFAULT 0,
+				ADD COLUMN auction_reserve_price_nanos     BIGINT NOT NULL DEFAULT 0,
+				ADD COLUMN auction_min_bid_increment_nanos BIGINT NOT NULL DEFAULT 0;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE INDEX pg_nfts_auction_end_block_height_idx ON pg_nfts (auction_end_block_height)
+				WHERE auction_end_block_height > 0;
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			DROP INDEX pg_nfts_auction_end_block_height_idx;
+			ALTER TABLE pg_nfts
+				DROP COLUMN auction_end_block_height,
+				DROP COLUMN auction_reserve_price_nanos,
+				DROP COLUMN auction_min_bid_increment_nanos;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220419000000_update_nfts_auction", up, down, opts)
+}
@@ -0,0 +1,61 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`
+			CREATE TABLE pg_nft_collections (
+				collection_id                                     BYTEA PRIMARY KEY,
+				creator_pkid                                      BYTEA NOT NULL,
+				name                                              TEXT NOT NULL,
+				max_supply                                        BIGINT NOT NULL,
+				num_nft_copies                                    BIGINT NOT NULL,
+				creator_royalty_basis_points                      BIGINT NOT NULL,
+				coin_royalty_basis_points                         BIGINT NOT NULL,
+				additional_nft_royalties_to_coins_basis_points    JSONB,
+				additional_nft_royalties_to_creators_basis_points JSONB
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE TABLE pg_metadata_create_nft_collections (
+				transaction_hash             BYTEA PRIMARY KEY,
+				name                         TEXT NOT NULL,
+				max_supply                   BIGINT NOT NULL,
+				creator_royalty_basis_points BIGINT NOT NULL,
+				coin_royalty_basis_points    BIGINT NOT NULL
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			ALTER TABLE pg_posts ADD COLUMN nft_collection_id BYTEA;
+			CREATE INDEX pg_posts_nft_collection_id_idx ON pg_posts (nft_collection_id)
+				WHERE nft_collection_id IS NOT NULL;
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			DROP INDEX pg_posts_nft_collection_id_idx;
+			ALTER TABLE pg_posts DROP COLUMN nft_collection_id;
+			DROP TABLE pg_nft_collections;
+			DROP TABLE pg_metadata_create_nft_collections;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220426000000_create_nft_collections", up, down, opts)
+}
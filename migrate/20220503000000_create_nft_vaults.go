@@ -0,0 +1,49 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`
+			ALTER TABLE pg_nfts
+				ADD COLUMN is_vaulted               BOOL NOT NULL DEFAULT FALSE,
+				ADD COLUMN vault_total_shares_nanos TEXT,
+				ADD COLUMN vault_buyout_price_nanos BIGINT NOT NULL DEFAULT 0;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE TABLE pg_metadata_nft_vaults (
+				transaction_hash         BYTEA PRIMARY KEY,
+				nft_post_hash            BYTEA NOT NULL,
+				serial_number            BIGINT NOT NULL,
+				operation_type           SMALLINT NOT NULL,
+				total_shares_nanos       TEXT,
+				buyout_price_nanos       BIGINT NOT NULL,
+				shares_to_transfer_nanos TEXT,
+				receiver_public_key      BYTEA
+			);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			DROP TABLE pg_metadata_nft_vaults;
+			ALTER TABLE pg_nfts
+				DROP COLUMN is_vaulted,
+				DROP COLUMN vault_total_shares_nanos,
+				DROP COLUMN vault_buyout_price_nanos;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220503000000_create_nft_vaults", up, down, opts)
+}
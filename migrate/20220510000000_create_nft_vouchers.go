@@ -0,0 +1,55 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`
+			ALTER TABLE pg_posts ADD COLUMN lazy_minted_nft BOOL NOT NULL DEFAULT FALSE;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE TABLE pg_nft_voucher_redemptions (
+				nft_post_hash BYTEA NOT NULL,
+				serial_number BIGINT NOT NULL,
+				redeemer_pkid BYTEA NOT NULL,
+				price_nanos   BIGINT NOT NULL,
+
+				PRIMARY KEY (nft_post_hash, serial_number)
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE TABLE pg_metadata_redeem_nft_vouchers (
+				transaction_hash  BYTEA PRIMARY KEY,
+				nft_post_hash     BYTEA NOT NULL,
+				serial_number     BIGINT NOT NULL,
+				price_nanos       BIGINT NOT NULL,
+				creator_signature BYTEA NOT NULL
+			);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			DROP TABLE pg_metadata_redeem_nft_vouchers;
+			DROP TABLE pg_nft_voucher_redemptions;
+			ALTER TABLE pg_posts DROP COLUMN lazy_minted_nft;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220510000000_create_nft_vouchers", up, down, opts)
+}
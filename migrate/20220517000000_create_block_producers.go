@@ -0,0 +1,45 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`
+			CREATE TABLE pg_block_producers (
+				public_key               BYTEA PRIMARY KEY,
+				bond_nanos               BIGINT NOT NULL,
+				is_registered            BOOL NOT NULL,
+				registered_block_height  BIGINT NOT NULL,
+				num_blocks_produced      BIGINT NOT NULL,
+				num_missed_slots         BIGINT NOT NULL,
+				last_missed_block_height BIGINT NOT NULL
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE TABLE pg_metadata_register_block_producers (
+				transaction_hash BYTEA PRIMARY KEY,
+				bond_nanos       BIGINT NOT NULL
+			);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			DROP TABLE pg_metadata_register_block_producers;
+			DROP TABLE pg_block_producers;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220517000000_create_block_producers", up, down, opts)
+}
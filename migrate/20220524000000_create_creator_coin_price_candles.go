@@ -0,0 +1,37 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`
+			CREATE TABLE pg_creator_coin_price_candles (
+				creator_pkid      BYTEA NOT NULL,
+				block_height      BIGINT NOT NULL,
+				open_price_nanos  BIGINT NOT NULL,
+				high_price_nanos  BIGINT NOT NULL,
+				low_price_nanos   BIGINT NOT NULL,
+				close_price_nanos BIGINT NOT NULL,
+				volume_nanos      BIGINT NOT NULL,
+				num_trades        BIGINT NOT NULL,
+
+				PRIMARY KEY (creator_pkid, block_height)
+			);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			DROP TABLE pg_creator_coin_price_candles;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220524000000_create_creator_coin_price_candles", up, down, opts)
+}
@@ -0,0 +1,60 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`
+			CREATE TABLE pg_username_listings (
+				lowercase_username   TEXT PRIMARY KEY,
+				username             TEXT NOT NULL,
+				replacement_username TEXT NOT NULL,
+				owner_pkid           BYTEA NOT NULL,
+				recipient_pkid       BYTEA,
+				price_nanos          BIGINT NOT NULL
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE TABLE pg_metadata_username_listings (
+				transaction_hash     BYTEA PRIMARY KEY,
+				username             TEXT NOT NULL,
+				replacement_username TEXT NOT NULL,
+				recipient_public_key BYTEA,
+				price_nanos          BIGINT NOT NULL,
+				is_removed           BOOL NOT NULL
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE TABLE pg_metadata_accept_username_listings (
+				transaction_hash BYTEA PRIMARY KEY,
+				username         TEXT NOT NULL,
+				price_nanos      BIGINT NOT NULL
+			);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			DROP TABLE pg_metadata_accept_username_listings;
+			DROP TABLE pg_metadata_username_listings;
+			DROP TABLE pg_username_listings;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220531000000_create_username_listings", up, down, opts)
+}
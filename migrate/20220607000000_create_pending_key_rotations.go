@@ -0,0 +1,43 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`
+			CREATE TABLE pg_pending_key_rotations (
+				old_public_key      BYTEA PRIMARY KEY,
+				new_public_key      BYTEA NOT NULL,
+				unlock_block_height BIGINT NOT NULL
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE TABLE pg_metadata_key_rotations (
+				transaction_hash BYTEA PRIMARY KEY,
+				operation_type   SMALLINT NOT NULL,
+				new_public_key   BYTEA NOT NULL,
+				timelock_blocks  BIGINT NOT NULL
+			);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			DROP TABLE pg_metadata_key_rotations;
+			DROP TABLE pg_pending_key_rotations;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220607000000_create_pending_key_rotations", up, down, opts)
+}
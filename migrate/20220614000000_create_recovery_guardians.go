@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`
+			CREATE TABLE pg_recovery_guardian_sets (
+				owner_pkid           BYTEA PRIMARY KEY,
+				guardian_public_keys JSONB,
+				threshold            BIGINT NOT NULL,
+				delay_blocks         BIGINT NOT NULL,
+				recovery_nonce       BIGINT NOT NULL
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE TABLE pg_pending_account_recoveries (
+				owner_pkid          BYTEA PRIMARY KEY,
+				new_public_key      BYTEA NOT NULL,
+				unlock_block_height BIGINT NOT NULL
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE TABLE pg_metadata_recovery_guardians (
+				transaction_hash     BYTEA PRIMARY KEY,
+				guardian_public_keys JSONB,
+				threshold            BIGINT NOT NULL,
+				delay_blocks         BIGINT NOT NULL
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			CREATE TABLE pg_metadata_account_recoveries (
+				transaction_hash BYTEA PRIMARY KEY,
+				operation_type   SMALLINT NOT NULL,
+				owner_public_key BYTEA NOT NULL,
+				new_public_key   BYTEA NOT NULL
+			);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			DROP TABLE pg_metadata_account_recoveries;
+			DROP TABLE pg_metadata_recovery_guardians;
+			DROP TABLE pg_pending_account_recoveries;
+			DROP TABLE pg_recovery_guardian_sets;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220614000000_create_recovery_guardians", up, down, opts)
+}
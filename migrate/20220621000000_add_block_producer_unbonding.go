@@ -0,0 +1,31 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		// Deregistered producers' bonds are held until the unbonding period ends.
+		_, err := db.Exec(`
+			ALTER TABLE pg_block_producers
+				ADD COLUMN unbonding_nanos        BIGINT NOT NULL DEFAULT 0,
+				ADD COLUMN unbonding_block_height BIGINT NOT NULL DEFAULT 0;
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			ALTER TABLE pg_block_producers
+				DROP COLUMN unbonding_nanos,
+				DROP COLUMN unbonding_block_height;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220621000000_add_block_producer_unbonding", up, down, opts)
+}
@@ -0,0 +1,31 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`
+			CREATE TABLE pg_key_rotation_timelocks (
+				owner_pkid                  BYTEA PRIMARY KEY,
+				min_timelock_blocks         BIGINT NOT NULL,
+				pending_min_timelock_blocks BIGINT NOT NULL,
+				pending_unlock_block_height BIGINT NOT NULL
+			);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			DROP TABLE pg_key_rotation_timelocks;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220628000000_create_key_rotation_timelocks", up, down, opts)
+}
@@ -0,0 +1,35 @@
+package migrate
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	migrations "github.com/robinjoseph08/go-pg-migrations/v3"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		// Changes to a registered guardian set wait out the set's delay.
+		_, err := db.Exec(`
+			ALTER TABLE pg_recovery_guardian_sets
+				ADD COLUMN pending_guardian_public_keys JSONB,
+				ADD COLUMN pending_threshold            BIGINT NOT NULL DEFAULT 0,
+				ADD COLUMN pending_delay_blocks         BIGINT NOT NULL DEFAULT 0,
+				ADD COLUMN pending_unlock_block_height  BIGINT NOT NULL DEFAULT 0;
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`
+			ALTER TABLE pg_recovery_guardian_sets
+				DROP COLUMN pending_guardian_public_keys,
+				DROP COLUMN pending_threshold,
+				DROP COLUMN pending_delay_blocks,
+				DROP COLUMN pending_unlock_block_height;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("20220630000000_add_pending_recovery_guardians", up, down, opts)
+}